@@ -0,0 +1,449 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// analyzeRequest is the POST /api/v1/analyze body:
+//
+//	{
+//	  "repo": "acme/api",
+//	  "weeks": 26,
+//	  "filters": {"branch": "main", "exclude": ["release-bot"], "min_prs": 3}
+//	}
+//
+// Only these fields reach the run; the portal can't set output paths or
+// anything else on the command line.
+type analyzeRequest struct {
+	Repo    string         `json:"repo"`
+	Weeks   int            `json:"weeks"`
+	Filters analyzeFilters `json:"filters"`
+}
+
+// analyzeFilters are the data filters of an analysis, each mapping to the
+// flag of the same name.
+type analyzeFilters struct {
+	Branch           string   `json:"branch,omitempty"`
+	Exclude          []string `json:"exclude,omitempty"`
+	MinPRs           int      `json:"min_prs,omitempty"`
+	ExcludeBottomPct int      `json:"exclude_bottom_contributor_pct,omitempty"`
+	ExcludeBottomBy  string   `json:"exclude_bottom_by,omitempty"`
+	Granularity      string   `json:"granularity,omitempty"`
+}
+
+// maxAnalyzeWeeks bounds the weeks an API caller can ask for.
+const maxAnalyzeWeeks = 156
+
+// validate checks the request and fills in defaults.
+func (req *analyzeRequest) validate() error {
+	owner, repo := parseRepo(req.Repo)
+	if _, err := snapshotPath(".", owner, repo); err != nil {
+		return fmt.Errorf("repo must be owner/repo, got %q", req.Repo)
+	}
+	req.Repo = owner + "/" + repo
+	if req.Weeks == 0 {
+		req.Weeks = 12
+	}
+	if req.Weeks < 1 || req.Weeks > maxAnalyzeWeeks {
+		return fmt.Errorf("weeks must be 1-%d", maxAnalyzeWeeks)
+	}
+	f := req.Filters
+	if strings.HasPrefix(f.Branch, "-") {
+		return fmt.Errorf("invalid branch %q", f.Branch)
+	}
+	for _, u := range f.Exclude {
+		if u == "" || strings.ContainsAny(u, ", ") {
+			return fmt.Errorf("invalid exclude login %q", u)
+		}
+	}
+	if f.MinPRs < 0 {
+		return fmt.Errorf("min_prs must not be negative")
+	}
+	if f.ExcludeBottomPct < 0 || f.ExcludeBottomPct > 99 {
+		return fmt.Errorf("exclude_bottom_contributor_pct must be 0-99")
+	}
+	if f.ExcludeBottomBy != "" && !slices.Contains([]string{"prs", "commits", "active-weeks"}, f.ExcludeBottomBy) {
+		return fmt.Errorf("exclude_bottom_by must be prs, commits, or active-weeks")
+	}
+	if f.Granularity != "" && f.Granularity != "weekly" && f.Granularity != "monthly" {
+		return fmt.Errorf("granularity must be weekly or monthly")
+	}
+	slices.Sort(req.Filters.Exclude) // so identical requests are spotted
+	req.Filters.Exclude = slices.Compact(req.Filters.Exclude)
+	return nil
+}
+
+// args returns the run's command line, without output flags.
+func (req analyzeRequest) args() []string {
+	args := []string{"--repo", req.Repo, "--weeks", strconv.Itoa(req.Weeks)}
+	f := req.Filters
+	if f.Branch != "" {
+		args = append(args, "--branch", f.Branch)
+	}
+	if len(f.Exclude) > 0 {
+		args = append(args, "--exclude", strings.Join(f.Exclude, ","))
+	}
+	if f.MinPRs > 0 {
+		args = append(args, "--min-prs", strconv.Itoa(f.MinPRs))
+	}
+	if f.ExcludeBottomPct > 0 {
+		args = append(args, "--exclude-bottom-contributor-pct", strconv.Itoa(f.ExcludeBottomPct))
+	}
+	if f.ExcludeBottomBy != "" {
+		args = append(args, "--exclude-bottom-by", f.ExcludeBottomBy)
+	}
+	if f.Granularity != "" {
+		args = append(args, "--granularity", f.Granularity)
+	}
+	return args
+}
+
+// Job states, as reported in analyzeJob.Status.
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// analyzeJob is one analysis started through the API. Its JSON form is the
+// job status response.
+type analyzeJob struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"`
+	Request       analyzeRequest    `json:"request"`
+	QueuePosition int               `json:"queue_position,omitempty"` // 1 = next to start
+	Waiting       string            `json:"waiting,omitempty"`        // why a queued job hasn't started
+	CreatedAt     time.Time         `json:"created_at"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Links         map[string]string `json:"links"`
+
+	key      string // the run's command line, to spot identical requests
+	cost     int    // estimated GraphQL points (see jobCost)
+	attempts int
+}
+
+// Files of a job directory.
+const (
+	jobReportFile = "report.html"
+	jobCSVFile    = "weekly.csv"
+	jobLogFile    = "run.log"
+)
+
+// analyzeRunner runs API analyses as child processes of this binary, like
+// --batch. Jobs wait in a FIFO queue and start when one of maxJobs slots is
+// free and the token's rate limit has room for them (see dispatch). Each job
+// writes its report, CSV, and log into <dir>/<id>/ and its snapshot into the
+// store, so the repository's /api/v1/repos endpoints reflect the latest job
+// too. Job states are kept in memory and don't survive a restart; the files
+// do. A finished job stays available for retention after it finished, then
+// expire drops it along with its directory.
+type analyzeRunner struct {
+	self      string // this executable
+	dir       string // --jobs-dir
+	storeDir  string
+	storage   string // --storage spec, passed on to the runs
+	maxJobs   int
+	retention time.Duration // --job-retention; 0 keeps finished jobs
+	token     string        // for the rate limit budget; "" skips it
+	reserve   int           // --rate-reserve
+	wake      chan struct{}
+
+	mu       sync.Mutex
+	jobs     map[string]*analyzeJob
+	queue    []*analyzeJob
+	running  int
+	inFlight int // estimated GraphQL points of the running jobs
+}
+
+func newAnalyzeRunner(dir, storeDir, storage string, maxJobs int, retention time.Duration, token string, reserve int) (*analyzeRunner, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate own executable: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	ar := &analyzeRunner{
+		self:      self,
+		dir:       dir,
+		storeDir:  storeDir,
+		storage:   storage,
+		maxJobs:   maxJobs,
+		retention: retention,
+		token:     token,
+		reserve:   reserve,
+		wake:      make(chan struct{}, 1),
+		jobs:      make(map[string]*analyzeJob),
+	}
+	go ar.dispatch()
+	return ar, nil
+}
+
+// submit queues an analysis and returns its job. A request identical to a
+// queued or running job's returns that job instead, with existing set. The
+// lookup and the insert share one critical section, so two identical
+// requests arriving together can't both be queued.
+func (ar *analyzeRunner) submit(req analyzeRequest) (job analyzeJob, existing bool, err error) {
+	ar.expire()
+	key := strings.Join(req.args(), "\x00")
+	id, err := newJobID()
+	if err != nil {
+		return analyzeJob{}, false, err
+	}
+	base := "/api/v1/jobs/" + id
+	j := &analyzeJob{
+		ID:        id,
+		Status:    jobQueued,
+		Request:   req,
+		CreatedAt: time.Now().UTC(),
+		Links: map[string]string{
+			"self":   base,
+			"report": base + "/report",
+			"csv":    base + "/csv",
+			"log":    base + "/log",
+		},
+		key:  key,
+		cost: jobCost(req),
+	}
+	ar.mu.Lock()
+	for _, other := range ar.jobs {
+		if other.key == key && (other.Status == jobQueued || other.Status == jobRunning) {
+			job = ar.view(other)
+			ar.mu.Unlock()
+			return job, true, nil
+		}
+	}
+	ar.jobs[id] = j
+	ar.queue = append(ar.queue, j)
+	job = ar.view(j)
+	ar.mu.Unlock()
+	ar.poke()
+	return job, false, nil
+}
+
+// run runs a job started by dispatch. A run that ran out of rate limit goes
+// back to the front of the queue once, to start again when the budget allows.
+func (ar *analyzeRunner) run(job *analyzeJob) {
+	fmt.Fprintf(os.Stderr, "Job %s: analyzing %s (%d weeks)\n", job.ID, job.Request.Repo, job.Request.Weeks)
+	err := ar.exec(job)
+
+	var exit *exec.ExitError
+	ar.mu.Lock()
+	ar.running--
+	ar.inFlight -= job.cost
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		job.Status, job.FinishedAt = jobSucceeded, &now
+	case errors.As(err, &exit) && exit.ExitCode() == exitRateLimit && job.attempts < 2:
+		job.Status, job.StartedAt = jobQueued, nil
+		ar.queue = append([]*analyzeJob{job}, ar.queue...)
+	case errors.As(err, &exit):
+		job.Status, job.FinishedAt = jobFailed, &now
+		job.Error = fmt.Sprintf("analysis exited with code %d (see log)", exit.ExitCode())
+	default:
+		job.Status, job.FinishedAt = jobFailed, &now
+		job.Error = err.Error()
+	}
+	status := job.Status
+	if job.Error != "" {
+		status += ": " + job.Error
+	}
+	ar.mu.Unlock()
+	ar.poke()
+
+	if status == jobQueued {
+		status = "rate limited, queued again"
+	}
+	fmt.Fprintf(os.Stderr, "Job %s: %s\n", job.ID, status)
+}
+
+// exec runs the analysis, writing the job's files.
+func (ar *analyzeRunner) exec(job *analyzeJob) error {
+	dir := filepath.Join(ar.dir, job.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	logFile, err := os.Create(filepath.Join(dir, jobLogFile))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	args := append(job.Request.args(),
+		"--output", filepath.Join(dir, jobCSVFile),
+		"--html", filepath.Join(dir, jobReportFile),
+		"--store", ar.storeDir)
+	if ar.storage != "" {
+		args = append(args, "--storage", ar.storage)
+	}
+	cmd := exec.Command(ar.self, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	return cmd.Run()
+}
+
+// view returns a copy of job with its queue position filled in. The caller
+// holds ar.mu.
+func (ar *analyzeRunner) view(job *analyzeJob) analyzeJob {
+	v := *job
+	if i := slices.Index(ar.queue, job); i >= 0 {
+		v.QueuePosition = i + 1
+	}
+	return v
+}
+
+// expire forgets the jobs that finished more than ar.retention ago and
+// deletes their files.
+func (ar *analyzeRunner) expire() {
+	if ar.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-ar.retention)
+	var expired []string
+	ar.mu.Lock()
+	for id, j := range ar.jobs {
+		if j.FinishedAt != nil && j.FinishedAt.Before(cutoff) {
+			delete(ar.jobs, id)
+			expired = append(expired, id)
+		}
+	}
+	ar.mu.Unlock()
+	for _, id := range expired {
+		if err := os.RemoveAll(filepath.Join(ar.dir, id)); err != nil {
+			fmt.Fprintf(os.Stderr, "Job %s: cannot remove files: %v\n", id, err)
+		}
+	}
+}
+
+// get returns a copy of the job with the given ID.
+func (ar *analyzeRunner) get(id string) (analyzeJob, bool) {
+	ar.expire()
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	job, ok := ar.jobs[id]
+	if !ok {
+		return analyzeJob{}, false
+	}
+	return ar.view(job), true
+}
+
+// list returns every job, newest first.
+func (ar *analyzeRunner) list() []analyzeJob {
+	ar.expire()
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	jobs := make([]analyzeJob, 0, len(ar.jobs))
+	for _, j := range ar.jobs {
+		jobs = append(jobs, ar.view(j))
+	}
+	slices.SortFunc(jobs, func(a, b analyzeJob) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return jobs
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// maxAnalyzeBody bounds the POST /api/v1/analyze body.
+const maxAnalyzeBody = 64 << 10
+
+// registerAnalyzeRoutes adds the analysis endpoints to the API server:
+//
+//	POST /api/v1/analyze            queue an analysis; 202 with the job, or 200 with
+//	                                the queued or running job of an identical request
+//	GET  /api/v1/jobs               every job since the server started (finished ones
+//	                                for --job-retention), newest first
+//	GET  /api/v1/jobs/{id}          job status
+//	GET  /api/v1/jobs/{id}/report   HTML report of a succeeded job
+//	GET  /api/v1/jobs/{id}/csv      weekly CSV of a succeeded job
+//	GET  /api/v1/jobs/{id}/log      the run's log so far
+func registerAnalyzeRoutes(mux *http.ServeMux, ar *analyzeRunner) {
+	mux.HandleFunc("POST /api/v1/analyze", func(w http.ResponseWriter, r *http.Request) {
+		var req analyzeRequest
+		dec := json.NewDecoder(io.LimitReader(r.Body, maxAnalyzeBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if err := req.validate(); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		job, existing, err := ar.submit(req)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+		w.Header().Set("Content-Type", "application/json")
+		if existing {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(job)
+	})
+	mux.HandleFunc("GET /api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"jobs": ar.list()})
+	})
+	mux.HandleFunc("GET /api/v1/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, ok := ar.get(r.PathValue("id"))
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+			return
+		}
+		writeJSON(w, job)
+	})
+	jobFile := func(name, contentType string, needsSuccess bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			job, ok := ar.get(r.PathValue("id"))
+			if !ok {
+				writeAPIError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+				return
+			}
+			if needsSuccess && job.Status != jobSucceeded {
+				writeAPIError(w, http.StatusConflict, fmt.Errorf("job %s is %s", job.ID, job.Status))
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(ar.dir, job.ID, name))
+			if errors.Is(err, os.ErrNotExist) {
+				writeAPIError(w, http.StatusNotFound, fmt.Errorf("job %s has no %s yet", job.ID, name))
+				return
+			}
+			if err != nil {
+				writeAPIError(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Write(data)
+		}
+	}
+	mux.HandleFunc("GET /api/v1/jobs/{id}/report", jobFile(jobReportFile, "text/html; charset=utf-8", true))
+	mux.HandleFunc("GET /api/v1/jobs/{id}/csv", jobFile(jobCSVFile, "text/csv; charset=utf-8", true))
+	mux.HandleFunc("GET /api/v1/jobs/{id}/log", jobFile(jobLogFile, "text/plain; charset=utf-8", false))
+}
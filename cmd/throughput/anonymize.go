@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// anonymizeMode is the --anonymize value. "all" (also the bare flag)
+// replaces every login with a pseudonym before any output is written;
+// "contributors" only hashes the logins shown in the contributor, reviewer,
+// and Ona heat lists (HTML, logs, and --store) with hashLogin, leaving the
+// rest of the report, including --pr-output, unchanged.
+type anonymizeMode string
+
+func (m *anonymizeMode) String() string   { return string(*m) }
+func (m *anonymizeMode) IsBoolFlag() bool { return true }
+
+func (m *anonymizeMode) Set(v string) error {
+	switch v {
+	case "true", "all":
+		*m = "all"
+	case "contributors":
+		*m = "contributors"
+	case "false", "off":
+		*m = ""
+	default:
+		return fmt.Errorf("must be all or contributors, got %q", v)
+	}
+	return nil
+}
+
+func (m anonymizeMode) pseudonyms() bool         { return m == "all" }
+func (m anonymizeMode) hashesContributors() bool { return m == "contributors" }
+
+// pseudonymizer replaces logins with stable pseudonyms (Engineer-01,
+// Engineer-02, ...) for --anonymize. Pseudonyms are only stable across runs
+// when the same --anonymize-map file is reused; without one, numbering
+// follows the sorted logins of the current run.
+type pseudonymizer struct {
+	byLogin map[string]string
+	next    int
+}
+
+// loadPseudonyms reads an existing login,pseudonym mapping file. A missing
+// file (or empty path) starts an empty mapping.
+func loadPseudonyms(path string) (*pseudonymizer, error) {
+	p := &pseudonymizer{byLogin: make(map[string]string), next: 1}
+	if path == "" {
+		return p, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, rec := range records {
+		if i == 0 && len(rec) > 0 && rec[0] == "login" {
+			continue // header
+		}
+		if len(rec) != 2 {
+			return nil, fmt.Errorf("%s line %d: expected login,pseudonym", path, i+1)
+		}
+		p.byLogin[rec[0]] = rec[1]
+		if n, err := strconv.Atoi(strings.TrimPrefix(rec[1], "Engineer-")); err == nil && n >= p.next {
+			p.next = n + 1
+		}
+	}
+	return p, nil
+}
+
+// anonymizePRs replaces every PR's author, merger, and reviewer logins with
+// their pseudonyms, assigning new pseudonyms to unseen logins in sorted order.
+func (p *pseudonymizer) anonymizePRs(prs []enrichedPR) {
+	var unseen []string
+	see := func(login string) {
+		if _, ok := p.byLogin[login]; !ok {
+			p.byLogin[login] = ""
+			unseen = append(unseen, login)
+		}
+	}
+	for _, pr := range prs {
+		see(pr.authorLogin)
+		if pr.mergerLogin != "" {
+			see(pr.mergerLogin)
+		}
+		for _, r := range pr.reviewResponses {
+			see(r.reviewer)
+		}
+	}
+	sort.Strings(unseen)
+	for _, login := range unseen {
+		p.byLogin[login] = fmt.Sprintf("Engineer-%02d", p.next)
+		p.next++
+	}
+	for i := range prs {
+		prs[i].authorLogin = p.byLogin[prs[i].authorLogin]
+		if prs[i].mergerLogin != "" {
+			prs[i].mergerLogin = p.byLogin[prs[i].mergerLogin]
+		}
+		for j := range prs[i].reviewResponses {
+			prs[i].reviewResponses[j].reviewer = p.byLogin[prs[i].reviewResponses[j].reviewer]
+		}
+	}
+}
+
+// save writes the mapping as login,pseudonym CSV, readable only by the
+// owner since it de-anonymizes every report produced with it.
+func (p *pseudonymizer) save(path string) error {
+	logins := make([]string, 0, len(p.byLogin))
+	for login := range p.byLogin {
+		logins = append(logins, login)
+	}
+	sort.Slice(logins, func(i, j int) bool {
+		a, b := p.byLogin[logins[i]], p.byLogin[logins[j]]
+		if len(a) != len(b) {
+			return len(a) < len(b) // Engineer-9 before Engineer-10
+		}
+		return a < b
+	})
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"login", "pseudonym"})
+	for _, login := range logins {
+		w.Write([]string{login, p.byLogin[login]})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// REST responses are revalidated with ETags: the first request for a URL
+// stores the response's ETag and body, later requests send If-None-Match and
+// reuse the stored body on 304 Not Modified, which GitHub doesn't count
+// against the rate limit. Entries live in memory for the run and, with
+// --cache-dir, in
+//
+//	<cache>/_rest/<sha256 of URL>.json
+//
+// so scheduled runs revalidate the Actions pages of past weeks instead of
+// downloading them again. The entries expire with the raw PR cache
+// (--cache-retention, `throughput cache purge`).
+
+// restEntry is a stored REST response. Body is the decoded response
+// re-encoded with only the fields the tool reads, so no actor logins are
+// written to disk.
+type restEntry struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+type restCache struct {
+	mu      sync.Mutex
+	dir     string // "" keeps entries in memory only
+	entries map[string]restEntry
+}
+
+// restETags is the run's REST response cache; main sets dir from --cache-dir.
+var restETags = &restCache{entries: make(map[string]restEntry)}
+
+func (c *restCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, "_rest", hex.EncodeToString(sum[:])+".json")
+}
+
+// lookup returns the stored response for url, from memory or disk.
+func (c *restCache) lookup(url string) (restEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[url]; ok {
+		return e, true
+	}
+	if c.dir == "" {
+		return restEntry{}, false
+	}
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return restEntry{}, false
+	}
+	var e restEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != url || e.ETag == "" {
+		return restEntry{}, false
+	}
+	c.entries[url] = e
+	return e, true
+}
+
+// store keeps body under url. Responses without an ETag can't be
+// revalidated and are not stored. Write errors are logged and otherwise
+// ignored: the cache only saves requests.
+func (c *restCache) store(url, etag string, body any) {
+	if etag == "" {
+		return
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	e := restEntry{URL: url, ETag: etag, Body: data}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = e
+	if c.dir == "" {
+		return
+	}
+	path := c.path(url)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Failed to write REST cache: %v\n", err)
+		return
+	}
+	data, err = json.Marshal(e)
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Failed to write REST cache: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Failed to write REST cache: %v\n", err)
+	}
+}
+
+// apiUsage counts the run's GitHub API requests and the ones saved.
+var apiUsage struct {
+	graphql, graphqlDeduped atomic.Int64
+	rest, restNotModified   atomic.Int64
+}
+
+// logAPIUsage prints how many GitHub requests the run made and how many the
+// de-duplication and ETag revalidation saved.
+func logAPIUsage() {
+	gql, rest := apiUsage.graphql.Load(), apiUsage.rest.Load()
+	if gql == 0 && rest == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "GitHub API: %d GraphQL request(s) (%d duplicate(s) reused), %d REST request(s) (%d not modified)\n",
+		gql, apiUsage.graphqlDeduped.Load(), rest, apiUsage.restNotModified.Load())
+}
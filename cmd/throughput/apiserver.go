@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runAPIServer implements `throughput server`: a read-only REST API over the
+// --store directory so dashboards can query results instead of scraping CSV
+// artifacts. Runs populate the store independently (e.g. a scheduled
+// `throughput --repo ... --store DIR`); the server picks up new snapshots on
+// the next request. With --max-jobs, clients can also start analyses (see
+// registerAnalyzeRoutes). With --storage, /weeks serves the weeks
+// accumulated there by runs with the same --storage instead of the latest
+// snapshot's. It blocks forever.
+//
+//	GET /api/v1/repos                                list of owner/repo in the store
+//	GET /api/v1/repos/{owner}/{repo}                 full snapshot
+//	GET /api/v1/repos/{owner}/{repo}/weeks           weekly metrics; ?from=&to= (YYYY-MM-DD) with --storage
+//	GET /api/v1/repos/{owner}/{repo}/stats           before/after comparison rows
+//	GET /api/v1/repos/{owner}/{repo}/contributors    per-contributor before/after Ona rates
+//	GET /api/v1/repos/{owner}/{repo}/history         comparison rows of every stored run, by run date
+func runAPIServer(args []string) {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	storeDir := flags.String("store", "", "result store directory written by runs with --store (required)")
+	port := flags.Int("port", 8081, "port for the API server")
+	maxJobs := flags.Int("max-jobs", 0, "accept POST /api/v1/analyze and run up to N analyses at once (0 = read-only API)")
+	jobsDir := flags.String("jobs-dir", filepath.Join(os.TempDir(), "throughput-jobs"), "with --max-jobs, directory for each analysis' report, CSV, and log")
+	storageSpec := flags.String("storage", "", "serve weekly metrics from this --storage of the runs (fs:DIR, sqlite:FILE, or postgres), with from/to filters")
+	jobRetention := flags.String("job-retention", "24h", "with --max-jobs, how long a finished analysis' status and files stay available (e.g. 7d, 36h; 0d = until restart)")
+	rateReserve := flags.Int("rate-reserve", 500, "with --max-jobs, GraphQL rate limit points of the token to leave unused; queued analyses wait rather than spend them")
+	flags.Parse(args)
+	if *storeDir == "" {
+		fatal("server requires --store <dir>")
+	}
+	if *maxJobs < 0 || *rateReserve < 0 {
+		fatal("--max-jobs and --rate-reserve must not be negative")
+	}
+	retention, err := parseAge(*jobRetention)
+	if err != nil {
+		fatal("Invalid --job-retention: %v", err)
+	}
+
+	var storage Storage
+	if *storageSpec != "" {
+		if storage, err = openStorage(*storageSpec); err != nil {
+			fatal("Invalid --storage: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	if *maxJobs > 0 {
+		// The analyses resolve the same token, so its rate limit is the
+		// budget they share.
+		token := resolveToken()
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "WARNING: No GitHub token found; analyses will fail until one is configured\n")
+		}
+		runner, err := newAnalyzeRunner(*jobsDir, *storeDir, *storageSpec, *maxJobs, retention, token, *rateReserve)
+		if err != nil {
+			fatal("Failed to set up --jobs-dir: %v", err)
+		}
+		registerAnalyzeRoutes(mux, runner)
+	}
+	mux.HandleFunc("GET /api/v1/repos", func(w http.ResponseWriter, r *http.Request) {
+		repos, err := listSnapshots(*storeDir)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, map[string]any{"repos": repos})
+	})
+	snapshotHandler := func(pick func(runSnapshot) any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			snap, err := loadSnapshot(*storeDir, r.PathValue("owner"), r.PathValue("repo"))
+			if errors.Is(err, fs.ErrNotExist) {
+				writeAPIError(w, http.StatusNotFound, fmt.Errorf("no results stored for %s/%s", r.PathValue("owner"), r.PathValue("repo")))
+				return
+			}
+			if err != nil {
+				writeAPIError(w, http.StatusBadRequest, err)
+				return
+			}
+			writeJSON(w, pick(snap))
+		}
+	}
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}", snapshotHandler(func(s runSnapshot) any { return s }))
+	if storage != nil {
+		mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/weeks", func(w http.ResponseWriter, r *http.Request) {
+			var from, to time.Time
+			for _, p := range []struct {
+				name string
+				t    *time.Time
+			}{{"from", &from}, {"to", &to}} {
+				if v := r.URL.Query().Get(p.name); v != "" {
+					t, err := time.Parse("2006-01-02", v)
+					if err != nil {
+						writeAPIError(w, http.StatusBadRequest, fmt.Errorf("%s must be YYYY-MM-DD", p.name))
+						return
+					}
+					*p.t = t
+				}
+			}
+			weeks, err := storage.LoadRange(r.PathValue("owner")+"/"+r.PathValue("repo"), from, to)
+			if err != nil {
+				writeAPIError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if len(weeks) == 0 {
+				writeAPIError(w, http.StatusNotFound, fmt.Errorf("no weeks stored for %s/%s in that range", r.PathValue("owner"), r.PathValue("repo")))
+				return
+			}
+			writeJSON(w, map[string]any{"weeks": weeks})
+		})
+	} else {
+		mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/weeks", snapshotHandler(func(s runSnapshot) any {
+			return map[string]any{"generated_at": s.GeneratedAt, "weeks": s.Weeks}
+		}))
+	}
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/stats", snapshotHandler(func(s runSnapshot) any {
+		return map[string]any{"generated_at": s.GeneratedAt, "granularity": s.Granularity, "stats": s.Stats}
+	}))
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/contributors", snapshotHandler(func(s runSnapshot) any {
+		return map[string]any{"generated_at": s.GeneratedAt, "contributors": s.Contributors}
+	}))
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/history", func(w http.ResponseWriter, r *http.Request) {
+		history, err := loadStatsHistory(*storeDir, r.PathValue("owner"), r.PathValue("repo"))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		if len(history) == 0 {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no results stored for %s/%s", r.PathValue("owner"), r.PathValue("repo")))
+			return
+		}
+		writeJSON(w, map[string]any{"runs": history})
+	})
+
+	addr := fmt.Sprintf(":%d", *port)
+	fmt.Fprintf(os.Stderr, "API server on http://localhost%s/api/v1/repos (store: %s)\n", addr, *storeDir)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fatal("Server error: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,50 @@
+package main
+
+// attribution selects who is credited with a merged PR in the per-engineer
+// metrics (--attribution): its "author" (the default), its "merger", or
+// "split" between both. Teams with a release captain merging everyone's PRs
+// use author; teams where the merger owns landing the change use merger.
+var attribution = "author"
+
+// attributionModes lists the valid --attribution values.
+var attributionModes = []string{"author", "merger", "split"}
+
+// creditedLogins returns the engineers credited with pr. A PR whose merger
+// is unknown (e.g. cached before mergers were fetched), a bot such as a
+// merge queue, or excluded is credited to its author in every mode. With
+// "split", a PR merged by someone else is credited to both, so both count
+// as active engineers that week.
+func creditedLogins(pr enrichedPR) []string {
+	switch {
+	case pr.mergerLogin == "" || attribution == "author":
+	case attribution == "merger":
+		return []string{pr.mergerLogin}
+	case pr.mergerLogin != pr.authorLogin:
+		return []string{pr.authorLogin, pr.mergerLogin}
+	}
+	return []string{pr.authorLogin}
+}
+
+// prsByEngineer groups PRs by credited engineer; with "split" a PR is in
+// the groups of both its author and its merger.
+func prsByEngineer(prs []enrichedPR) map[string][]enrichedPR {
+	by := make(map[string][]enrichedPR)
+	for _, pr := range prs {
+		for _, login := range creditedLogins(pr) {
+			by[login] = append(by[login], pr)
+		}
+	}
+	return by
+}
+
+// attributionNote describes a non-default --attribution for the filter
+// notes, or returns "".
+func attributionNote() string {
+	switch attribution {
+	case "merger":
+		return "PRs are credited to the engineer who merged them (--attribution merger); bot and unknown mergers fall back to the author"
+	case "split":
+		return "PRs merged by someone else are credited to both author and merger (--attribution split)"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package main
+
+import "fmt"
+
+// audience selects the HTML report variant (--audience). Every variant is
+// rendered from the same pipeline output; the audience only decides which
+// sections reach the page.
+type audience string
+
+const (
+	// audienceManager is the full report.
+	audienceManager audience = "manager"
+	// audienceIC is for individual contributors: the per-PR drill-down is
+	// always on, and sections that rank individual engineers (top
+	// contributors, top reviewers, the Ona heat list) are left out.
+	audienceIC audience = "ic"
+	// audienceExec is the summary: key findings, filters, and the stat
+	// banners, without the chart or any detail section.
+	audienceExec audience = "exec"
+)
+
+var audienceNames = []string{string(audienceIC), string(audienceManager), string(audienceExec)}
+
+func parseAudience(s string) (audience, error) {
+	switch a := audience(s); a {
+	case audienceIC, audienceManager, audienceExec:
+		return a, nil
+	}
+	return "", fmt.Errorf("--audience must be 'ic', 'manager', or 'exec'")
+}
+
+// wantsDrilldown reports whether the audience's report embeds the per-PR
+// lists even without --pr-drilldown.
+func (a audience) wantsDrilldown() bool {
+	return a == audienceIC
+}
+
+// apply drops the sections the audience doesn't see. Dropped sections are
+// removed from the extras rather than hidden, so their data isn't embedded
+// in the page either.
+func (a audience) apply(extras reportExtras) reportExtras {
+	switch a {
+	case audienceIC:
+		extras.topContributors = nil
+		extras.topReviewers = nil
+		extras.onaPowerUsers, extras.onaUntouched = nil, nil
+	case audienceExec:
+		return reportExtras{
+			categories:    extras.categories,
+			glossary:      extras.glossary,
+			schemaVersion: extras.schemaVersion,
+			summaryOnly:   true,
+		}
+	}
+	return extras
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// automationPR is a merged bot-authored PR (Dependabot, Renovate, ...)
+// counted by --automation instead of only being excluded.
+type automationPR struct {
+	login       string
+	mergedEpoch int64
+	mergeHours  float64 // created to merged
+}
+
+// isAutomation reports whether a PR was opened by a bot: a GitHub Bot
+// actor, or a login with the "[bot]" suffix used by GitHub Apps elsewhere.
+func isAutomation(pr PR) bool {
+	return pr.Author.Typename == "Bot" || strings.HasSuffix(strings.ToLower(pr.Author.Login), "[bot]")
+}
+
+// automationPRs returns the merged, non-draft bot PRs. Bots are kept even
+// when --exclude (or the default exclusions) lists them.
+func automationPRs(prs []PR) []automationPR {
+	var out []automationPR
+	for _, pr := range prs {
+		if !isAutomation(pr) || pr.MergedAt.IsZero() || pr.IsDraft {
+			continue
+		}
+		out = append(out, automationPR{
+			login:       strings.TrimSuffix(strings.ToLower(pr.Author.Login), "[bot]"),
+			mergedEpoch: pr.MergedAt.Unix(),
+			mergeHours:  math.Round(pr.MergedAt.Sub(pr.CreatedAt).Hours()*100) / 100,
+		})
+	}
+	return out
+}
+
+// applyAutomation sets each week's automation PR count and median merge
+// time. stats must be aligned with weeks.
+func applyAutomation(prs []automationPR, weeks []weekRange, stats []weekStats) {
+	hours := make([][]float64, len(weeks))
+	for _, pr := range prs {
+		if i := weekIndex(weeks, pr.mergedEpoch); i >= 0 {
+			hours[i] = append(hours[i], pr.mergeHours)
+		}
+	}
+	for i := range stats {
+		stats[i].automationTracked = true
+		stats[i].automationPRs = len(hours[i])
+		stats[i].medianAutomationMerge = median(hours[i])
+	}
+}
+
+// logAutomation prints the bot PR count per bot and their median merge time.
+func logAutomation(prs []automationPR) {
+	byBot := make(map[string][]float64)
+	for _, pr := range prs {
+		byBot[pr.login] = append(byBot[pr.login], pr.mergeHours)
+	}
+	bots := make([]string, 0, len(byBot))
+	for b := range byBot {
+		bots = append(bots, b)
+	}
+	sort.Slice(bots, func(i, j int) bool {
+		if len(byBot[bots[i]]) != len(byBot[bots[j]]) {
+			return len(byBot[bots[i]]) > len(byBot[bots[j]])
+		}
+		return bots[i] < bots[j]
+	})
+	fmt.Fprintf(os.Stderr, "Automation PRs: %d merged by %d bot(s)\n", len(prs), len(bots))
+	for _, b := range bots {
+		fmt.Fprintf(os.Stderr, "  %-24s %5d PRs, median %.1fh to merge\n", b, len(byBot[b]), median(byBot[b]))
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchConfig is the --batch file format:
+//
+//	{
+//	  "args": ["--weeks", "26", "--granularity", "monthly"],
+//	  "targets": [
+//	    {"name": "Payments", "repo": "acme/payments", "args": ["--branch", "master"]},
+//	    {"name": "Platform", "org": "acme-platform"}
+//	  ]
+//	}
+//
+// "args" apply to every run (after any flags given on the command line);
+// per-target "args" are appended last so they win. An "org" target expands to
+// every non-archived repository in the GitHub organization.
+type batchConfig struct {
+	Args    []string      `json:"args"`
+	Targets []batchTarget `json:"targets"`
+}
+
+type batchTarget struct {
+	Name string   `json:"name"`
+	Repo string   `json:"repo"`
+	Org  string   `json:"org"`
+	Args []string `json:"args"`
+}
+
+// batchJob is one repository run after org expansion.
+type batchJob struct {
+	group string // target name the repo came from
+	repo  string
+	args  []string
+	slug  string // file name stem for the report, CSV, and log
+}
+
+// batchResult holds a job's outcome and headline numbers for the index.
+type batchResult struct {
+	Group          string
+	Repo           string
+	HTMLFile       string
+	LogFile        string
+	Failed         bool
+	Error          string
+	PRsMerged      int
+	PRsPerEngineer string // median over weeks with PRs
+	PctOnaInvolved string // mean over the last 4 weeks with PRs
+	Duration       string
+}
+
+// batchFlags are owned by the batch runner and never forwarded to child runs.
+var batchFlags = map[string]bool{
+	"batch": true, "batch-out": true, "batch-parallel": true,
+	"repo": true, "html": true, "output": true, "serve": true, "port": true,
+	"columns": true, // the index reads the child CSVs' default columns
+}
+
+var slugRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// runBatch runs the tool once per repository listed in the batch config,
+// writing <slug>.html, <slug>.csv, and <slug>.log into outDir, then generates
+// outDir/index.html linking every report with headline numbers. Each run is a
+// child process of this binary so a failing repository can't take down the
+// rest of the batch.
+func runBatch(path, outDir string, parallel int, forwarded []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatal("Failed to read --batch config: %v", err)
+	}
+	var bc batchConfig
+	if err := json.Unmarshal(data, &bc); err != nil {
+		fatal("Invalid --batch config %s: %v", path, err)
+	}
+	if len(bc.Targets) == 0 {
+		fatal("--batch config %s has no targets", path)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fatalCode(exitWrite, "Failed to create --batch-out directory: %v", err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		fatal("Cannot locate own executable for batch runs: %v", err)
+	}
+
+	var jobs []batchJob
+	for _, t := range bc.Targets {
+		var repos []string
+		switch {
+		case t.Repo != "" && t.Org != "":
+			fatal("Batch target %q: set either repo or org, not both", t.Name)
+		case t.Repo != "":
+			repos = []string{t.Repo}
+		case t.Org != "":
+			token := resolveToken()
+			if token == "" {
+				fatal("Batch target %q: expanding an org requires a GitHub token", t.Name)
+			}
+			repos, err = listOrgRepos(token, t.Org)
+			if err != nil {
+				fatal("Batch target %q: listing repos for org %s: %v", t.Name, t.Org, err)
+			}
+			fmt.Fprintf(os.Stderr, "Org %s: %d repositories\n", t.Org, len(repos))
+		default:
+			fatal("Batch target %q: missing repo or org", t.Name)
+		}
+		group := t.Name
+		if group == "" {
+			group = t.Repo + t.Org
+		}
+		for _, repo := range repos {
+			args := append(append(append([]string{}, forwarded...), bc.Args...), t.Args...)
+			jobs = append(jobs, batchJob{
+				group: group,
+				repo:  repo,
+				args:  args,
+				slug:  slugRe.ReplaceAllString(strings.ReplaceAll(repo, "/", "__"), "-"),
+			})
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Batch: %d repositories, %d at a time, writing to %s\n", len(jobs), parallel, outDir)
+
+	results := make([]batchResult, len(jobs))
+	parallelFor(len(jobs), parallel, func(i int) {
+		results[i] = runBatchJob(self, outDir, jobs[i])
+		status := "ok"
+		if results[i].Failed {
+			status = "FAILED: " + results[i].Error
+		}
+		fmt.Fprintf(os.Stderr, "  [%d/%d] %s (%s) %s\n", i+1, len(jobs), jobs[i].repo, results[i].Duration, status)
+	})
+
+	indexPath := filepath.Join(outDir, "index.html")
+	if err := writeBatchIndex(indexPath, results); err != nil {
+		fatalCode(exitWrite, "Failed to write batch index: %v", err)
+	}
+	var failed int
+	for _, r := range results {
+		if r.Failed {
+			failed++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Batch index written to %s (%d ok, %d failed)\n", indexPath, len(results)-failed, failed)
+	switch {
+	case failed == len(results):
+		os.Exit(exitError)
+	case failed > 0:
+		os.Exit(exitPartialData)
+	}
+}
+
+func runBatchJob(self, outDir string, job batchJob) batchResult {
+	res := batchResult{
+		Group:    job.group,
+		Repo:     job.repo,
+		HTMLFile: job.slug + ".html",
+		LogFile:  job.slug + ".log",
+	}
+	csvPath := filepath.Join(outDir, job.slug+".csv")
+	args := append(append([]string{}, job.args...),
+		"--repo", job.repo,
+		"--output", csvPath,
+		"--html", filepath.Join(outDir, res.HTMLFile))
+
+	logFile, err := os.Create(filepath.Join(outDir, res.LogFile))
+	if err != nil {
+		res.Failed, res.Error = true, err.Error()
+		return res
+	}
+	defer logFile.Close()
+
+	start := time.Now()
+	cmd := exec.Command(self, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	err = cmd.Run()
+	res.Duration = time.Since(start).Round(time.Second).String()
+	if err != nil {
+		res.Failed, res.Error = true, err.Error()
+		return res
+	}
+
+	if err := summarizeBatchCSV(csvPath, &res); err != nil {
+		res.Failed, res.Error = true, "reading CSV: "+err.Error()
+	}
+	return res
+}
+
+// summarizeBatchCSV fills the headline numbers from a run's weekly CSV.
+func summarizeBatchCSV(path string, res *batchResult) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(rows) < 2 {
+		return fmt.Errorf("no data rows")
+	}
+	col := make(map[string]int)
+	for i, h := range rows[0] {
+		col[h] = i
+	}
+
+	var ppe, ona []float64
+	for _, row := range rows[1:] {
+		prs, _ := strconv.Atoi(row[col["prs_merged"]])
+		if prs == 0 {
+			continue
+		}
+		res.PRsMerged += prs
+		if v, err := strconv.ParseFloat(row[col["prs_per_engineer"]], 64); err == nil {
+			ppe = append(ppe, v)
+		}
+		if v, err := strconv.ParseFloat(row[col["pct_ona_involved"]], 64); err == nil {
+			ona = append(ona, v)
+		}
+	}
+	res.PRsPerEngineer, res.PctOnaInvolved = "—", "—"
+	if len(ppe) > 0 {
+		res.PRsPerEngineer = fmt.Sprintf("%.2f", median(ppe))
+	}
+	if len(ona) > 0 {
+		recent := ona[max(0, len(ona)-4):]
+		var sum float64
+		for _, v := range recent {
+			sum += v
+		}
+		res.PctOnaInvolved = fmt.Sprintf("%.1f%%", sum/float64(len(recent)))
+	}
+	return nil
+}
+
+// listOrgRepos returns owner/name for every non-archived repository in a GitHub org.
+func listOrgRepos(token, org string) ([]string, error) {
+	var repos []string
+	cursor := ""
+	for {
+		after := ""
+		if cursor != "" {
+			after = fmt.Sprintf(`, after: "%s"`, cursor)
+		}
+		query := fmt.Sprintf(`{
+  organization(login: "%s") {
+    repositories(first: 100, isArchived: false, orderBy: {field: NAME, direction: ASC}%s) {
+      nodes { nameWithOwner }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`, org, after)
+
+		resp, err := graphqlQuery(token, query)
+		if err != nil {
+			return repos, err
+		}
+		var result struct {
+			Organization *struct {
+				Repositories struct {
+					Nodes []struct {
+						NameWithOwner string `json:"nameWithOwner"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return repos, fmt.Errorf("unmarshal response: %w", err)
+		}
+		if result.Organization == nil {
+			return nil, fmt.Errorf("organization %q not found", org)
+		}
+		for _, n := range result.Organization.Repositories.Nodes {
+			repos = append(repos, n.NameWithOwner)
+		}
+		if !result.Organization.Repositories.PageInfo.HasNextPage {
+			break
+		}
+		cursor = result.Organization.Repositories.PageInfo.EndCursor
+	}
+	return repos, nil
+}
+
+// forwardedFlags returns the flags explicitly set on the command line, minus
+// batch-owned ones, so child runs inherit them. Repeatable flags are expanded.
+func forwardedFlags(series, derived []string) []string {
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if batchFlags[f.Name] {
+			return
+		}
+		if f.Name == "series" {
+			for _, s := range series {
+				args = append(args, "--series="+s)
+			}
+			return
+		}
+		if f.Name == "derived" {
+			for _, d := range derived {
+				args = append(args, "--derived="+d)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+func writeBatchIndex(path string, results []batchResult) error {
+	tmpl, err := template.New("index").Parse(batchIndexTemplate)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tmpl.Execute(f, struct {
+		Generated string
+		Results   []batchResult
+	}{
+		Generated: time.Now().Format("Jan 2, 2006 15:04"),
+		Results:   results,
+	})
+}
+
+const batchIndexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Engineering throughput reports</title>
+<style>
+  * { margin: 0; padding: 0; box-sizing: border-box; }
+  body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #f8f9fa; color: #1a1a2e; padding: 24px; }
+  h1 { font-size: 1.25rem; font-weight: 600; margin-bottom: 4px; }
+  .generated { font-size: 0.8rem; color: #6b7280; margin-bottom: 16px; }
+  .container { max-width: 1200px; margin: 0 auto; }
+  .data-table { width: 100%; border-collapse: collapse; background: #fff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1); font-size: 0.85rem; }
+  .data-table th { text-align: left; font-size: 0.7rem; font-weight: 600; text-transform: uppercase; letter-spacing: 0.05em; color: #6b7280; padding: 10px 14px; border-bottom: 1px solid #e5e7eb; }
+  .data-table td { padding: 8px 14px; border-bottom: 1px solid #f3f4f6; }
+  .data-table td.num, .data-table th.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .data-table a { color: #2563eb; text-decoration: none; }
+  .failed { color: #dc2626; }
+</style>
+</head>
+<body>
+<div class="container">
+  <h1>Engineering throughput reports</h1>
+  <div class="generated">Generated {{.Generated}}</div>
+  <table class="data-table">
+    <tr><th>Area</th><th>Repository</th><th class="num">PRs merged</th><th class="num">Median PRs / engineer</th><th class="num">% Ona (last 4 weeks)</th><th class="num">Run time</th><th>Log</th></tr>
+    {{range .Results}}
+    <tr>
+      <td>{{.Group}}</td>
+      {{if .Failed}}
+      <td>{{.Repo}}</td><td class="failed" colspan="4">Failed: {{.Error}}</td>
+      {{else}}
+      <td><a href="{{.HTMLFile}}">{{.Repo}}</a></td><td class="num">{{.PRsMerged}}</td><td class="num">{{.PRsPerEngineer}}</td><td class="num">{{.PctOnaInvolved}}</td><td class="num">{{.Duration}}</td>
+      {{end}}
+      <td><a href="{{.LogFile}}">log</a></td>
+    </tr>
+    {{end}}
+  </table>
+</div>
+</body>
+</html>
+`
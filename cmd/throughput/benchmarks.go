@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// benchmarkMetric is one industry benchmark with the upper (or, for
+// higherIsBetter, lower) bounds of its elite, high, and medium bands.
+// Anything beyond medium is low.
+type benchmarkMetric struct {
+	label          string
+	unit           string // "h", "%", or "/day"
+	higherIsBetter bool
+	elite          float64
+	high           float64
+	medium         float64
+	proxy          string // how the value is approximated from PR data, shown in the report
+	value          func(rows map[string]consolidatedRow, periodDays float64) (float64, bool)
+}
+
+// benchmarkSet is a named benchmark dataset selectable with --benchmark.
+type benchmarkSet struct {
+	title   string
+	source  string
+	metrics []benchmarkMetric
+}
+
+// benchmarkSets are the datasets compiled into the binary. Values are
+// compared against the last comparison window (the "after" side of the
+// summary banner).
+var benchmarkSets = map[string]benchmarkSet{
+	"dora-2023": {
+		title:  "DORA 2023",
+		source: "Accelerate State of DevOps Report 2023, software delivery performance clusters",
+		metrics: []benchmarkMetric{
+			{
+				label:          "Deployment frequency",
+				unit:           "/day",
+				higherIsBetter: true,
+				elite:          1,       // on demand, multiple per day
+				high:           1.0 / 7, // daily to weekly
+				medium:         1.0 / 30,
+				proxy:          "PRs merged per day",
+				value: func(rows map[string]consolidatedRow, periodDays float64) (float64, bool) {
+					r, ok := rows["prs_merged"]
+					return r.lastAvg / periodDays, ok
+				},
+			},
+			{
+				label:  "Lead time for changes",
+				unit:   "h",
+				elite:  24,      // less than one day
+				high:   24 * 7,  // one day to one week
+				medium: 24 * 30, // one week to one month
+				proxy:  "median coding time + median review time",
+				value: func(rows map[string]consolidatedRow, _ float64) (float64, bool) {
+					coding, okC := rows["median_coding_time_hours"]
+					review, okR := rows["median_review_time_hours"]
+					return coding.lastAvg + review.lastAvg, okC && okR
+				},
+			},
+			{
+				label:  "Change failure rate",
+				unit:   "%",
+				elite:  5,
+				high:   10,
+				medium: 15,
+				proxy:  "share of merged PRs that are reverts",
+				// Pooled over the window: pct_reverts is a median of weekly
+				// rates for months and sprints, which is 0 when most weeks
+				// have no reverts.
+				value: func(rows map[string]consolidatedRow, _ float64) (float64, bool) {
+					reverts, okR := rows["revert_count"]
+					prs, okP := rows["prs_merged"]
+					if !okR || !okP || prs.lastAvg == 0 {
+						return 0, false
+					}
+					return reverts.lastAvg / prs.lastAvg * 100, true
+				},
+			},
+			{
+				label:  "Failed deployment recovery time",
+				unit:   "h",
+				elite:  1,      // less than one hour
+				high:   24,     // less than one day
+				medium: 24 * 7, // one day to one week
+				proxy:  "median incident MTTR (needs --incidents-csv or --pagerduty)",
+				value: func(rows map[string]consolidatedRow, _ float64) (float64, bool) {
+					r, ok := rows["median_mttr_hours"]
+					return r.lastAvg, ok
+				},
+			},
+		},
+	},
+}
+
+// benchmarkWindowMetrics are weekly values the benchmarks need that have
+// no stats row. Their rows are built over the same periods and windows as
+// prs_merged, so the ratio of two rows' window means is the ratio of the
+// window totals.
+var benchmarkWindowMetrics = []metricDef{
+	{
+		name:    "revert_count",
+		extract: func(ws weekStats) float64 { return float64(ws.revertCount) },
+		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+	},
+}
+
+// benchmarkNames returns the --benchmark choices, sorted.
+func benchmarkNames() []string {
+	var names []string
+	for name := range benchmarkSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// benchmarkResult places one of our metrics within a benchmark's bands.
+type benchmarkResult struct {
+	metric benchmarkMetric
+	value  float64
+	band   string // "Elite", "High", "Medium", or "Low"
+}
+
+// benchmarkBands names the bands bounded by elite, high, and medium.
+var benchmarkBands = []string{"Elite", "High", "Medium"}
+
+func (m benchmarkMetric) bounds() []float64 { return []float64{m.elite, m.high, m.medium} }
+
+func (m benchmarkMetric) classify(v float64) string {
+	for i, bound := range m.bounds() {
+		if (m.higherIsBetter && v >= bound) || (!m.higherIsBetter && v <= bound) {
+			return benchmarkBands[i]
+		}
+	}
+	return "Low"
+}
+
+// evaluateBenchmarks classifies each benchmark metric we have data for.
+// periodDays is the length of a chart period, used for per-day rates.
+func evaluateBenchmarks(set benchmarkSet, rows []consolidatedRow, periodDays float64) []benchmarkResult {
+	byMetric := make(map[string]consolidatedRow, len(rows))
+	for _, r := range rows {
+		byMetric[r.metric] = r
+	}
+	var results []benchmarkResult
+	for _, m := range set.metrics {
+		v, ok := m.value(byMetric, periodDays)
+		if !ok {
+			continue
+		}
+		results = append(results, benchmarkResult{metric: m, value: v, band: m.classify(v)})
+	}
+	return results
+}
+
+// logBenchmarks prints each benchmark band to stderr.
+func logBenchmarks(set benchmarkSet, results []benchmarkResult) {
+	fmt.Fprintf(os.Stderr, "Benchmarks (%s):\n", set.title)
+	for _, r := range results {
+		fmt.Fprintf(os.Stderr, "  %-32s %8.2f%s  %s\n", r.metric.label, r.value, r.metric.unit, r.band)
+	}
+}
+
+// bandThresholds describes a metric's bands, e.g. "Elite ≤ 24h · High ≤ 168h · Medium ≤ 720h".
+func (m benchmarkMetric) bandThresholds(loc reportLocale) string {
+	op := "≤"
+	if m.higherIsBetter {
+		op = "≥"
+	}
+	var parts []string
+	for i, bound := range m.bounds() {
+		parts = append(parts, fmt.Sprintf("%s %s %s", loc.T(benchmarkBands[i]), op, loc.number(bound, benchmarkPrec(bound))+loc.T(m.unit)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// benchmarkPrec picks enough decimals to show small per-day rates.
+func benchmarkPrec(v float64) int {
+	switch {
+	case v == math.Trunc(v):
+		return 0
+	case v < 0.1:
+		return 3
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChangeFailureRatePoolsReverts(t *testing.T) {
+	// One week a month has 12 reverts among its 25 PRs and the others have
+	// none, so every month's median weekly revert rate is 0.
+	weeks := computeWeekRanges(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), 26)
+	stats := make([]weekStats, len(weeks))
+	for i, wr := range weeks {
+		stats[i] = weekStats{prsMerged: 25, uniqueAuthors: 5, medianCodingTime: -1, medianReviewTime: -1}
+		if wr.start.Day() <= 7 {
+			stats[i].revertCount = 12
+			stats[i].pctReverts = 48
+		}
+	}
+	months, monthly := aggregateMonthly(weeks, stats)
+
+	rows := generateStatsTo(io.Discard, monthly, 10, 0, "month")
+	rows = slices.Concat(rows, windowRows(monthly, benchmarkWindowMetrics, 10, 0, "month"))
+	var got *benchmarkResult
+	for _, r := range evaluateBenchmarks(benchmarkSets["dora-2023"], rows, 365.25/12) {
+		if r.metric.label == "Change failure rate" {
+			got = &r
+		}
+	}
+	if got == nil {
+		t.Fatal("no change failure rate")
+	}
+
+	// The last comparison window is the last 3 months.
+	var reverts, prs int
+	for _, ms := range monthly[len(months)-3:] {
+		if ms.pctReverts != 0 {
+			t.Fatalf("monthly pct_reverts = %.1f, want the 0 median this test is about", ms.pctReverts)
+		}
+		reverts += ms.revertCount
+		prs += ms.prsMerged
+	}
+	want := float64(reverts) / float64(prs) * 100
+	if reverts == 0 || math.Abs(got.value-want) > 1e-9 {
+		t.Errorf("change failure rate = %.2f%%, want %.2f%% (%d reverts of %d PRs)", got.value, want, reverts, prs)
+	}
+	if got.band == "Elite" {
+		t.Errorf("band = %s for %.2f%% reverts", got.band, got.value)
+	}
+}
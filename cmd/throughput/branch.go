@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// fallbackBranch is analyzed when --branch isn't given and the default
+// branch can't be detected.
+const fallbackBranch = "main"
+
+// detectDefaultBranch returns the repository's default branch: GitHub's
+// defaultBranchRef, the Gerrit project's HEAD, or for --local-git the
+// branch origin/HEAD points to, else the clone's checked-out branch.
+func detectDefaultBranch(cfg config) (string, error) {
+	switch cfg.provider {
+	case "gerrit":
+		data, err := gerritGet(cfg, "/projects/"+url.PathEscape(cfg.repo)+"/HEAD")
+		if err != nil {
+			return "", err
+		}
+		var head string
+		if err := json.Unmarshal(data, &head); err != nil {
+			return "", fmt.Errorf("unmarshal project HEAD: %w", err)
+		}
+		if head == "" {
+			return "", fmt.Errorf("project %s has no HEAD", cfg.repo)
+		}
+		return strings.TrimPrefix(head, "refs/heads/"), nil
+	case "git":
+		// A clone's origin/HEAD names the default branch; mirrors and bare
+		// clones only have HEAD
+		if out, err := exec.Command("git", "-C", cfg.gitDir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output(); err == nil {
+			return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/"), nil
+		}
+		out, err := exec.Command("git", "-C", cfg.gitDir, "symbolic-ref", "--short", "HEAD").Output()
+		if err != nil {
+			return "", fmt.Errorf("git symbolic-ref: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	default:
+		resp, err := graphqlQuery(cfg.token, fmt.Sprintf(`{ repository(owner: %q, name: %q) { defaultBranchRef { name } } }`, cfg.owner, cfg.repo))
+		if err != nil {
+			return "", err
+		}
+		var result struct {
+			Repository *struct {
+				DefaultBranchRef *struct {
+					Name string `json:"name"`
+				} `json:"defaultBranchRef"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return "", fmt.Errorf("unmarshal response: %w", err)
+		}
+		if result.Repository == nil || result.Repository.DefaultBranchRef == nil {
+			return "", fmt.Errorf("repository %s/%s has no default branch (empty, or not visible to the token)", cfg.owner, cfg.repo)
+		}
+		return result.Repository.DefaultBranchRef.Name, nil
+	}
+}
@@ -1,18 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type buildWeekStats struct {
 	runs         int
 	successCount int
+	queueMinutes []float64 // --ci-queue: sampled first attempts' queue times
+	sampleRunIDs []int64   // --runners: the sampled runs
 }
 
 type workflowRun struct {
@@ -21,6 +25,15 @@ type workflowRun struct {
 	Status     string    `json:"status"`
 	Conclusion string    `json:"conclusion"`
 	CreatedAt  time.Time `json:"created_at"`
+	// RunStartedAt is when the latest attempt started running, so it only
+	// gives the queue time for a first attempt (--ci-queue)
+	RunStartedAt time.Time `json:"run_started_at"`
+	RunAttempt   int       `json:"run_attempt"`
+	// PullRequests lists the PRs a pull_request run belongs to. GitHub
+	// leaves it empty for runs from forks.
+	PullRequests []struct {
+		Number int `json:"number"`
+	} `json:"pull_requests"`
 }
 
 type workflowRunsResponse struct {
@@ -87,11 +100,90 @@ func fetchBuildRuns(cfg config, weeks []weekRange) []buildWeekStats {
 	return stats
 }
 
+// prBuildResult counts the completed pull_request workflow runs of one PR.
+type prBuildResult struct {
+	runs     int
+	failures int // runs that concluded with "failure"
+}
+
+// maxPRRunPages caps the pull_request runs paged per week. The Actions API
+// returns at most 1,000 runs for a filtered query anyway.
+const maxPRRunPages = 10
+
+// fetchPRBuildResults pages every completed pull_request workflow run
+// created in the weeks and groups them by PR number. Runs a PR triggered
+// before the first week aren't seen. Returns nil if Actions data is
+// unavailable.
+func fetchPRBuildResults(cfg config, weeks []weekRange) map[int]prBuildResult {
+	if len(weeks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching pull request workflow runs...\n")
+
+	results := make(map[int]prBuildResult)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed, truncated atomic.Int64
+	sem := make(chan struct{}, maxConcurrency)
+
+	for _, wr := range weeks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(wr weekRange) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rangeStart := wr.start.Format("2006-01-02")
+			rangeEnd := wr.end.AddDate(0, 0, 1).Format("2006-01-02")
+			for page := 1; page <= maxPRRunPages; page++ {
+				runs, total, err := restGetPage(cfg.token, cfg.owner, cfg.repo, rangeStart, rangeEnd, "pull_request", page)
+				if err != nil {
+					failed.Add(1)
+					return
+				}
+				mu.Lock()
+				for _, r := range runs {
+					for _, pr := range r.PullRequests {
+						res := results[pr.Number]
+						res.runs++
+						if r.Conclusion == "failure" {
+							res.failures++
+						}
+						results[pr.Number] = res
+					}
+				}
+				mu.Unlock()
+				if len(runs) < 100 || page*100 >= total {
+					return
+				}
+			}
+			truncated.Add(1)
+		}(wr)
+	}
+	wg.Wait()
+
+	if int(failed.Load()) == len(weeks) {
+		fmt.Fprintf(os.Stderr, "  Skipping per-PR CI results: Actions API unavailable\n")
+		return nil
+	}
+	if n := failed.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  WARNING: pull request runs missing for %d week(s)\n", n)
+	}
+	if n := truncated.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  WARNING: %d week(s) had more than %d pull request runs; only the first %d were read\n", n, maxPRRunPages*100, maxPRRunPages*100)
+	}
+	fmt.Fprintf(os.Stderr, "  Workflow runs found for %d PRs\n", len(results))
+	return results
+}
+
 // fetchWeekBuildStats gets run count and success rate for one week.
 // Queries push and pull_request events separately, using total_count for
 // the run count and a sample of up to 100 runs for the success rate.
 func fetchWeekBuildStats(token, owner, repo, rangeStart, rangeEnd string) buildWeekStats {
 	var totalRuns, totalSuccess, sampleSize int
+	var queue []float64
+	var ids []int64
 
 	for _, event := range []string{"push", "pull_request"} {
 		runs, count, err := restGetPage(token, owner, repo, rangeStart, rangeEnd, event, 1)
@@ -100,16 +192,20 @@ func fetchWeekBuildStats(token, owner, repo, rangeStart, rangeEnd string) buildW
 		}
 		totalRuns += count
 
-		// Compute success rate from the sample
+		// Compute success rate and queue times from the sample
 		for _, r := range runs {
 			sampleSize++
 			if r.Conclusion == "success" {
 				totalSuccess++
 			}
+			if q, ok := runQueueMinutes(r); ok {
+				queue = append(queue, q)
+			}
+			ids = append(ids, r.ID)
 		}
 	}
 
-	ws := buildWeekStats{runs: totalRuns}
+	ws := buildWeekStats{runs: totalRuns, queueMinutes: queue, sampleRunIDs: ids}
 	if sampleSize > 0 {
 		// Extrapolate success count from sample rate
 		rate := float64(totalSuccess) / float64(sampleSize)
@@ -125,6 +221,11 @@ func restGetPage(token, owner, repo, rangeStart, rangeEnd, event string, page in
 		owner, repo, event, rangeStart, rangeEnd, page,
 	)
 
+	cached, hasCached := restETags.lookup(url)
+	// Entries stored before run start times were read have no queue times
+	if hasCached && !bytes.Contains(cached.Body, []byte(`"run_started_at"`)) {
+		hasCached = false
+	}
 	var lastErr error
 	for attempt := 1; attempt <= 3; attempt++ {
 		req, err := http.NewRequest("GET", url, nil)
@@ -134,7 +235,11 @@ func restGetPage(token, owner, repo, rangeStart, rangeEnd, event string, page in
 		req.Header.Set("Authorization", "bearer "+token)
 		req.Header.Set("Accept", "application/vnd.github+json")
 		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if hasCached {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
 
+		apiUsage.rest.Add(1)
 		resp, err := httpClient.Do(req)
 		if err != nil {
 			lastErr = err
@@ -150,11 +255,18 @@ func restGetPage(token, owner, repo, rangeStart, rangeEnd, event string, page in
 			continue
 		}
 
+		// 304: the stored response is still current
+		notModified := hasCached && resp.StatusCode == http.StatusNotModified
+		if notModified {
+			apiUsage.restNotModified.Add(1)
+			data = cached.Body
+		}
+
 		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
 			return nil, 0, fmt.Errorf("Actions API returned %d (no access or not enabled)", resp.StatusCode)
 		}
 
-		if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode != http.StatusOK && !notModified {
 			lastErr = fmt.Errorf("REST API returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
 			time.Sleep(time.Duration(attempt*5) * time.Second)
 			continue
@@ -167,6 +279,9 @@ func restGetPage(token, owner, repo, rangeStart, rangeEnd, event string, page in
 			continue
 		}
 
+		if !notModified {
+			restETags.store(url, resp.Header.Get("ETag"), result)
+		}
 		return result.WorkflowRuns, result.TotalCount, nil
 	}
 	return nil, 0, fmt.Errorf("REST query failed after 3 attempts: %v", lastErr)
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The raw PR cache (--cache-dir) stores the fetched PRs of each completed
+// week so later runs only fetch weeks they haven't seen:
+//
+//	<cache>/<owner>/<repo>/<branch>/<week_start>.json
+//
+// Path components are URL-escaped (Gerrit projects and branches may contain
+// slashes). A file's modification time is when its week was fetched; entries
+// older than --cache-retention are deleted at the start of each run.
+// `throughput cache ls` and `info` show what is cached, and `purge` deletes
+// entries on demand, by age or selectively by repository and week.
+
+// cachedWeek is the on-disk form of one cached week.
+type cachedWeek struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Redacted  bool      `json:"redacted"`
+	// RedactVersion is the redactVersion the entry was redacted with.
+	RedactVersion int  `json:"redact_version,omitempty"`
+	Reviews       bool `json:"enriched_reviews"` // PRs carry --enrich-reviews review history
+	PRs           []PR `json:"prs"`
+}
+
+// redactVersion is bumped whenever redactPRIdentities starts hashing another
+// field, so redacted entries written before are refetched instead of mixing
+// raw and hashed logins (1: review-state authors).
+const redactVersion = 1
+
+type prCache struct {
+	dir     string
+	redact  bool // --cache-redact-authors
+	reviews bool // --enrich-reviews
+}
+
+func (c prCache) weekPath(cfg config, wr weekRange) string {
+	return filepath.Join(c.dir,
+		url.PathEscape(cfg.owner), url.PathEscape(cfg.repo), url.PathEscape(cfg.branch),
+		wr.start.Format("2006-01-02")+".json")
+}
+
+// load returns the cached PRs for every week that has a usable cache entry,
+// and the weeks that still need to be fetched. Entries written with a
+// different --cache-redact-authors setting are refetched, so identities stay
+// consistent across weeks, as are redacted entries from an older
+// redactVersion and entries without review history when --enrich-reviews is
+// set. Entries with review history serve runs without it.
+func (c prCache) load(cfg config, weeks []weekRange) ([]PR, []weekRange) {
+	var prs []PR
+	var missing []weekRange
+	for _, wr := range weeks {
+		data, err := os.ReadFile(c.weekPath(cfg, wr))
+		if err != nil {
+			missing = append(missing, wr)
+			continue
+		}
+		var cw cachedWeek
+		if err := json.Unmarshal(data, &cw); err != nil || cw.Redacted != c.redact || c.redact && cw.RedactVersion < redactVersion || c.reviews && !cw.Reviews {
+			missing = append(missing, wr)
+			continue
+		}
+		prs = append(prs, cw.PRs...)
+	}
+	return prs, missing
+}
+
+// save writes one cache entry per week in weeks, bucketing prs by merge
+// date. Weeks without PRs are saved too so they aren't refetched.
+func (c prCache) save(cfg config, weeks []weekRange, prs []PR) error {
+	byWeek := make([][]PR, len(weeks))
+	for _, pr := range prs {
+		if i := weekIndex(weeks, pr.MergedAt.Unix()); i >= 0 {
+			byWeek[i] = append(byWeek[i], pr)
+		}
+	}
+	now := time.Now().UTC()
+	for i, wr := range weeks {
+		path := c.weekPath(cfg, wr)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		cw := cachedWeek{FetchedAt: now, Redacted: c.redact, Reviews: c.reviews, PRs: byWeek[i]}
+		if c.redact {
+			cw.RedactVersion = redactVersion
+		}
+		data, err := json.Marshal(cw)
+		if err != nil {
+			return err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeCache deletes cache entries fetched before cutoff (all entries if
+// cutoff is zero) and removes directories left empty. It returns the number
+// of entries deleted.
+func purgeCache(dir string, cutoff time.Time) (int, error) {
+	var removed int
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		if !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".json.tmp") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if cutoff.IsZero() || info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return removed, err
+	}
+	removeEmptyDirs(dirs)
+	return removed, nil
+}
+
+// removeEmptyDirs removes the directories of a walk below its root, deepest
+// first; Remove fails harmlessly on directories that aren't empty.
+func removeEmptyDirs(dirs []string) {
+	for i := len(dirs) - 1; i > 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// cacheEntry is one cached week found by scanCache.
+type cacheEntry struct {
+	owner, repo, branch string
+	week                time.Time // week start
+	path                string
+	size                int64
+	fetched             time.Time // file modification time
+}
+
+func (e cacheEntry) key() string {
+	return e.owner + "/" + e.repo + "@" + e.branch
+}
+
+// scanCache lists the PR cache entries under dir, sorted by repository,
+// branch, and week. The REST ETag entries under _rest are not included.
+func scanCache(dir string) ([]cacheEntry, error) {
+	var entries []cacheEntry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "_rest" {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(dir, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() || len(parts) != 4 || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		week, err := time.Parse("2006-01-02", strings.TrimSuffix(parts[3], ".json"))
+		if err != nil {
+			return nil
+		}
+		e := cacheEntry{week: week, path: path}
+		for i, p := range []*string{&e.owner, &e.repo, &e.branch} {
+			if *p, err = url.PathUnescape(parts[i]); err != nil {
+				*p = parts[i]
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		e.size, e.fetched = info.Size(), info.ModTime()
+		entries = append(entries, e)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if a, b := entries[i].key(), entries[j].key(); a != b {
+			return a < b
+		}
+		return entries[i].week.Before(entries[j].week)
+	})
+	return entries, err
+}
+
+// formatSize formats a byte count as B, KB, or MB.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// formatAge formats how long ago t was, in days or hours.
+func formatAge(now, t time.Time) string {
+	d := now.Sub(t)
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%dh ago", int(d.Hours()))
+}
+
+// parseAge parses a retention age: a number of days ("90d") or a Go
+// duration ("36h").
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d or 36h)", s)
+	}
+	return d, nil
+}
+
+// identityTrailerRe matches commit trailers that name a person.
+var identityTrailerRe = regexp.MustCompile(`(?im)^(co-authored-by|signed-off-by|reviewed-by|acked-by|tested-by|reported-by):.*(\n|$)`)
+
+// redactPRIdentities replaces author, merger, and reviewer logins with hashLogin IDs and strips
+// identity trailers from commit messages, keeping only the Ona co-author
+// trailer that Ona detection needs. The "ona-" login prefix is preserved for
+// the same reason. Review-state authors are hashed like the author, so
+// self-reviews are still recognized. Applied to freshly fetched PRs with
+// --cache-redact-authors, before they are cached or used.
+func redactPRIdentities(prs []PR) {
+	for i := range prs {
+		pr := &prs[i]
+		pr.Author.Login = redactAuthorLogin(pr.Author.Login)
+		if login := strings.ToLower(pr.MergedBy.Login); login != "" {
+			pr.MergedBy.Login = hashLogin(login)
+		}
+		if rs := pr.ReviewStates; rs != nil {
+			for j := range rs.Nodes {
+				rs.Nodes[j].Author.Login = redactAuthorLogin(rs.Nodes[j].Author.Login)
+			}
+		}
+		if pr.ReviewDetails != nil {
+			pr.ReviewDetails.redactReviewerIdentities()
+		}
+		for j := range pr.Commits.Nodes {
+			msg := &pr.Commits.Nodes[j].Commit.Message
+			*msg = identityTrailerRe.ReplaceAllStringFunc(*msg, func(line string) string {
+				if onaCoauthorRe.MatchString(line) {
+					return line
+				}
+				return ""
+			})
+		}
+	}
+}
+
+// redactAuthorLogin hashes a PR or review author's login, keeping the "ona-"
+// prefix. Empty logins (deleted accounts) stay empty.
+func redactAuthorLogin(login string) string {
+	login = strings.ToLower(login)
+	if login == "" {
+		return ""
+	}
+	id := hashLogin(login)
+	if strings.HasPrefix(login, "ona-") {
+		id = "ona-" + id
+	}
+	return id
+}
+
+// cacheSettleDays is how long after a week ends its cache entry is
+// considered settled: PRs merged late into that week (a delayed merge queue,
+// a force-merge) show up in fetches made within this window.
+const cacheSettleDays = 7
+
+const cacheUsage = `usage:
+  throughput cache ls --cache-dir <dir>
+  throughput cache info --cache-dir <dir> [--repo owner/repo] [--branch <name>]
+  throughput cache purge --cache-dir <dir> [--repo owner/repo] [--branch <name>]
+      (--all | --older-than <age> | --last-weeks <n> | --since <YYYY-MM-DD>)`
+
+// runCacheCommand implements `throughput cache ls|info|purge`.
+func runCacheCommand(args []string) {
+	if len(args) == 0 {
+		fatal("%s", cacheUsage)
+	}
+	cmd := args[0]
+	if cmd != "ls" && cmd != "info" && cmd != "purge" {
+		fatal("unknown cache command %q\n%s", cmd, cacheUsage)
+	}
+	flags := flag.NewFlagSet("cache "+cmd, flag.ExitOnError)
+	cacheDir := flags.String("cache-dir", "", "raw PR cache directory (required)")
+	repo := flags.String("repo", "", "only this owner/repo")
+	branch := flags.String("branch", "", "only this branch")
+	olderThan := flags.String("older-than", "", "purge entries fetched longer ago than this, e.g. 90d or 36h")
+	all := flags.Bool("all", false, "purge every cache entry")
+	lastWeeks := flags.Int("last-weeks", 0, "purge the latest N cached weeks of each repository and branch")
+	since := flags.String("since", "", "purge weeks starting on or after this date (YYYY-MM-DD)")
+	flags.Parse(args[1:])
+
+	if *cacheDir == "" {
+		fatal("cache %s requires --cache-dir <dir>", cmd)
+	}
+	if cmd != "purge" && (*olderThan != "" || *all || *lastWeeks != 0 || *since != "") {
+		fatal("--all, --older-than, --last-weeks, and --since only apply to cache purge")
+	}
+
+	switch cmd {
+	case "ls":
+		if *repo != "" || *branch != "" {
+			fatal("cache ls lists every repository; use cache info --repo for one")
+		}
+		cacheList(*cacheDir)
+	case "info":
+		cacheInfo(*cacheDir, *repo, *branch)
+	case "purge":
+		cachePurge(*cacheDir, *repo, *branch, *olderThan, *all, *lastWeeks, *since)
+	}
+}
+
+// selectCacheEntries returns the entries of repo (owner/repo) and branch,
+// either of which may be empty for all.
+func selectCacheEntries(entries []cacheEntry, repo, branch string) []cacheEntry {
+	var out []cacheEntry
+	for _, e := range entries {
+		if (repo == "" || strings.EqualFold(e.owner+"/"+e.repo, repo)) && (branch == "" || e.branch == branch) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// cacheList prints one line per cached repository and branch: the weeks
+// covered, entry count, size, and when the oldest and newest were fetched.
+func cacheList(dir string) {
+	entries, err := scanCache(dir)
+	if err != nil {
+		fatal("Failed to read cache: %v", err)
+	}
+	now := time.Now()
+	fmt.Printf("%-40s %-23s %7s %10s  %-14s %s\n", "REPOSITORY@BRANCH", "WEEKS", "ENTRIES", "SIZE", "OLDEST FETCH", "NEWEST FETCH")
+	for i := 0; i < len(entries); {
+		j, size := i, int64(0)
+		oldest, newest := entries[i].fetched, entries[i].fetched
+		for ; j < len(entries) && entries[j].key() == entries[i].key(); j++ {
+			size += entries[j].size
+			if entries[j].fetched.Before(oldest) {
+				oldest = entries[j].fetched
+			}
+			if entries[j].fetched.After(newest) {
+				newest = entries[j].fetched
+			}
+		}
+		weeks := entries[i].week.Format("2006-01-02") + ".." + entries[j-1].week.Format("2006-01-02")
+		fmt.Printf("%-40s %-23s %7d %10s  %-14s %s\n", entries[i].key(), weeks, j-i, formatSize(size), formatAge(now, oldest), formatAge(now, newest))
+		i = j
+	}
+
+	var restCount int
+	var restSize int64
+	filepath.WalkDir(filepath.Join(dir, "_rest"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				restCount++
+				restSize += info.Size()
+			}
+		}
+		return nil
+	})
+	if restCount > 0 {
+		fmt.Printf("%-40s %-23s %7d %10s\n", "_rest (REST ETags)", "", restCount, formatSize(restSize))
+	}
+	if len(entries) == 0 && restCount == 0 {
+		fmt.Fprintf(os.Stderr, "No cache entries in %s\n", dir)
+	}
+}
+
+// cacheInfo prints one line per cached week: its PRs, size, when it was
+// fetched, and whether it was fetched before the week had settled.
+func cacheInfo(dir, repo, branch string) {
+	entries, err := scanCache(dir)
+	if err != nil {
+		fatal("Failed to read cache: %v", err)
+	}
+	entries = selectCacheEntries(entries, repo, branch)
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "No cache entries in %s match\n", dir)
+		return
+	}
+	now := time.Now()
+	var unsettled int
+	for i, e := range entries {
+		if i == 0 || e.key() != entries[i-1].key() {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(e.key())
+			fmt.Printf("  %-10s %5s %10s  %-10s %-8s %-7s %s\n", "WEEK", "PRS", "SIZE", "FETCHED", "REDACTED", "REVIEWS", "SETTLED")
+		}
+		prs, redacted, reviews := "?", "?", "?"
+		if data, err := os.ReadFile(e.path); err == nil {
+			var cw cachedWeek
+			if json.Unmarshal(data, &cw) == nil {
+				prs, redacted, reviews = strconv.Itoa(len(cw.PRs)), strconv.FormatBool(cw.Redacted), strconv.FormatBool(cw.Reviews)
+			}
+		}
+		settled := "yes"
+		if weekEnd := e.week.AddDate(0, 0, 7); e.fetched.Before(weekEnd.AddDate(0, 0, cacheSettleDays)) {
+			settled = fmt.Sprintf("no (%dd after week end)", int(e.fetched.Sub(weekEnd).Hours()/24))
+			unsettled++
+		}
+		fmt.Printf("  %-10s %5s %10s  %-10s %-8s %-7s %s\n", e.week.Format("2006-01-02"), prs, formatSize(e.size), formatAge(now, e.fetched), redacted, reviews, settled)
+	}
+	if unsettled > 0 {
+		fmt.Fprintf(os.Stderr, "%d week(s) were fetched less than %d days after they ended and may miss late merges; refresh them with cache purge --last-weeks or --since\n",
+			unsettled, cacheSettleDays)
+	}
+}
+
+// cachePurge deletes the selected cache entries. Without --repo, --branch,
+// --last-weeks, or --since it purges by fetch age like --cache-retention,
+// including REST ETag entries; otherwise only matching PR cache weeks.
+func cachePurge(dir, repo, branch, olderThan string, all bool, lastWeeks int, since string) {
+	var cutoff time.Time
+	if olderThan != "" {
+		age, err := parseAge(olderThan)
+		if err != nil {
+			fatal("Invalid --older-than: %v", err)
+		}
+		cutoff = time.Now().Add(-age)
+	}
+	var sinceDate time.Time
+	if since != "" {
+		var err error
+		if sinceDate, err = time.Parse("2006-01-02", since); err != nil {
+			fatal("Invalid --since: want YYYY-MM-DD, got %q", since)
+		}
+	}
+	switch {
+	case lastWeeks < 0:
+		fatal("--last-weeks must be positive")
+	case all && (olderThan != "" || lastWeeks > 0 || since != ""):
+		fatal("--all can't be combined with --older-than, --last-weeks, or --since")
+	case !all && olderThan == "" && lastWeeks == 0 && since == "":
+		fatal("cache purge requires --all, --older-than <age>, --last-weeks <n>, or --since <date>")
+	}
+
+	if repo == "" && branch == "" && lastWeeks == 0 && since == "" {
+		removed, err := purgeCache(dir, cutoff)
+		if err != nil {
+			fatal("Failed to purge cache: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Purged %d cache entr(ies) from %s\n", removed, dir)
+		return
+	}
+
+	entries, err := scanCache(dir)
+	if err != nil {
+		fatal("Failed to read cache: %v", err)
+	}
+	entries = selectCacheEntries(entries, repo, branch)
+	var removed int
+	for i, e := range entries {
+		// Entries are sorted by week within each repository and branch, so
+		// the latest N are the last N before the key changes.
+		latest := true
+		if lastWeeks > 0 {
+			j := i + lastWeeks
+			latest = j >= len(entries) || entries[j].key() != e.key()
+		}
+		if !latest || !sinceDate.IsZero() && e.week.Before(sinceDate) || !cutoff.IsZero() && !e.fetched.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil {
+			fatal("Failed to purge cache: %v", err)
+		}
+		removed++
+	}
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	removeEmptyDirs(dirs)
+	fmt.Fprintf(os.Stderr, "Purged %d cache entr(ies) from %s\n", removed, dir)
+}
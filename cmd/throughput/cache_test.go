@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactedCacheKeepsSelfReviews(t *testing.T) {
+	weeks := computeWeekRanges(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), 1)
+	merged := time.Unix(weeks[0].start.Unix()+3600, 0).UTC().Format(time.RFC3339)
+	var prs []PR
+	data := `[
+		{"number": 1, "mergedAt": "` + merged + `", "author": {"login": "Alice"},
+		 "reviewStates": {"nodes": [{"state": "APPROVED", "author": {"login": "alice"}}]}},
+		{"number": 2, "mergedAt": "` + merged + `", "author": {"login": "ona-bob"},
+		 "reviewStates": {"nodes": [{"state": "COMMENTED", "author": {"login": "ona-bob"}}]}},
+		{"number": 3, "mergedAt": "` + merged + `", "author": {"login": "alice"},
+		 "reviewStates": {"nodes": [{"state": "APPROVED", "author": {"login": "carol"}}]}}
+	]`
+	if err := json.Unmarshal([]byte(data), &prs); err != nil {
+		t.Fatal(err)
+	}
+
+	redactPRIdentities(prs)
+	cache := prCache{dir: t.TempDir(), redact: true}
+	cfg := config{owner: "acme", repo: "api", branch: "main"}
+	if err := cache.save(cfg, weeks, prs); err != nil {
+		t.Fatal(err)
+	}
+	cached, missing := cache.load(cfg, weeks)
+	if len(missing) > 0 || len(cached) != len(prs) {
+		t.Fatalf("load = %d PRs, %d missing weeks; want %d PRs, none missing", len(cached), len(missing), len(prs))
+	}
+	for _, pr := range cached {
+		for _, r := range pr.ReviewStates.Nodes {
+			if login := r.Author.Login; !strings.HasPrefix(login, "user-") && !strings.HasPrefix(login, "ona-user-") {
+				t.Errorf("PR %d: reviewer login %q cached unhashed", pr.Number, login)
+			}
+		}
+	}
+
+	want := map[int]struct{ reviewed, approved bool }{
+		1: {false, false}, // self-approval
+		2: {false, false}, // self-review keeps the ona- prefix on both sides
+		3: {true, true},
+	}
+	for _, pr := range filterPRs(cached, nil, &filterAudit{}) {
+		w := want[pr.number]
+		if pr.reviewedByOther != w.reviewed || pr.approvedByOther != w.approved {
+			t.Errorf("PR %d: reviewedByOther, approvedByOther = %v, %v, want %v, %v",
+				pr.number, pr.reviewedByOther, pr.approvedByOther, w.reviewed, w.approved)
+		}
+	}
+}
+
+func TestRedactedCacheRefetchesOlderRedactVersion(t *testing.T) {
+	weeks := computeWeekRanges(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), 2)
+	cache := prCache{dir: t.TempDir(), redact: true}
+	cfg := config{owner: "acme", repo: "api", branch: "main"}
+	if err := cache.save(cfg, weeks, nil); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := json.Marshal(cachedWeek{FetchedAt: time.Now(), Redacted: true})
+	if err := os.WriteFile(cache.weekPath(cfg, weeks[0]), old, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, missing := cache.load(cfg, weeks); len(missing) != 1 || !missing[0].start.Equal(weeks[0].start) {
+		t.Errorf("missing = %v, want only the week without redact_version", missing)
+	}
+}
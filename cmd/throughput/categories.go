@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// bannerCategory is one stat banner of the HTML report from --categories:
+// its title, colors, and the stats metrics it shows, in order.
+type bannerCategory struct {
+	Name    string   `json:"name"`
+	Accent  string   `json:"accent"` // #rgb or #rrggbb; defaults to a built-in palette
+	Metrics []string `json:"metrics"`
+}
+
+// categoryPalette is used for banners without an accent, in order. The first
+// four are the built-in Speed, Quality, Ona Uptake, and Release colors.
+var categoryPalette = []string{"#2563eb", "#16a34a", "#9333ea", "#0d9488", "#ea580c", "#db2777", "#ca8a04", "#475569"}
+
+var hexColorRe = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// loadCategories reads --categories: a JSON array of banners replacing the
+// built-in Speed, Quality, Ona Uptake, and Release grouping. Metric names are
+// those of the stats CSV; a metric may appear in one banner only. Call it
+// after --series and --derived are registered so their names resolve.
+func loadCategories(path string) ([]bannerCategory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cats []bannerCategory
+	if err := json.Unmarshal(data, &cats); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(cats) == 0 {
+		return nil, fmt.Errorf("%s lists no categories", path)
+	}
+
+	seen := make(map[string]string)
+	for i := range cats {
+		c := &cats[i]
+		if c.Name == "" {
+			return nil, fmt.Errorf("category %d has no name", i+1)
+		}
+		if len(c.Metrics) == 0 {
+			return nil, fmt.Errorf("category %q lists no metrics", c.Name)
+		}
+		if c.Accent == "" {
+			c.Accent = categoryPalette[i%len(categoryPalette)]
+		} else if !hexColorRe.MatchString(c.Accent) {
+			return nil, fmt.Errorf("category %q: accent %q is not a #rgb or #rrggbb color", c.Name, c.Accent)
+		}
+		for _, name := range c.Metrics {
+			md, ok := metricByName(name)
+			if !ok || md.category == "" {
+				return nil, fmt.Errorf("category %q: %q is not a stats metric", c.Name, name)
+			}
+			if other, dup := seen[name]; dup {
+				return nil, fmt.Errorf("metric %q is in both %q and %q", name, other, c.Name)
+			}
+			seen[name] = c.Name
+		}
+	}
+	return cats, nil
+}
+
+// categoryTint returns the banner background for an accent color: the
+// accent mixed 6% into white, like the built-in banners' tints.
+func categoryTint(accent string) string {
+	hex := accent[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, _ := strconv.ParseUint(hex, 16, 32)
+	mix := func(c uint64) int { return 255 - int(math.Round(float64(255-c)*0.06)) }
+	return fmt.Sprintf("#%02x%02x%02x", mix(rgb>>16), mix(rgb>>8&0xff), mix(rgb&0xff))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// flowPR is the lifecycle of one PR for the cumulative flow diagram. Unlike
+// the merged-PR fetch, it includes PRs that are still open or were closed
+// without merging.
+type flowPR struct {
+	Number    int        `json:"number"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt"`
+	MergedAt  *time.Time `json:"mergedAt"`
+	IsDraft   bool       `json:"isDraft"`
+	Author    struct {
+		Login    string `json:"login"`
+		Typename string `json:"__typename"`
+	} `json:"author"`
+	TimelineItems struct {
+		Nodes []struct {
+			CreatedAt *time.Time `json:"createdAt"`
+		} `json:"nodes"`
+	} `json:"timelineItems"`
+}
+
+// flowPoint is the number of PRs in each state at the end of a period.
+// Merged counts PRs merged since the start of the first period, so the
+// merged band starts at zero and accumulates.
+type flowPoint struct {
+	open     int // opened as a draft and not marked ready yet
+	inReview int // ready for review, not merged or closed yet
+	merged   int
+}
+
+// searchResultLimit is the most results GitHub search returns for a query.
+const searchResultLimit = 1000
+
+// fetchFlowPRs fetches every PR against the branch that was open at some
+// point in the weeks: PRs created in each week, plus PRs created earlier and
+// still open at the start of the first week. Bots and excluded authors are
+// dropped.
+func fetchFlowPRs(cfg config, weeks []weekRange) []flowPR {
+	if len(weeks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching opened PRs for the cumulative flow diagram...\n")
+
+	firstDay := weeks[0].start.Format("2006-01-02")
+	prefix := fmt.Sprintf("repo:%s/%s is:pr base:%s", cfg.owner, cfg.repo, cfg.branch)
+	queries := []string{
+		fmt.Sprintf("%s created:<%s is:open", prefix, firstDay),
+		fmt.Sprintf("%s created:<%s closed:>=%s", prefix, firstDay, firstDay),
+	}
+	for _, wr := range weeks {
+		queries = append(queries, fmt.Sprintf("%s created:%s..%s", prefix,
+			wr.start.Format("2006-01-02"), wr.end.Format("2006-01-02")))
+	}
+
+	byNumber := make(map[int]flowPR)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed, truncated atomic.Int64
+	sem := make(chan struct{}, maxConcurrency)
+
+	for _, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(q string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prs, total, err := searchFlowPRs(cfg, q)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  WARNING: %q: %v\n", q, err)
+				failed.Add(1)
+			}
+			if total > searchResultLimit {
+				truncated.Add(1)
+			}
+			mu.Lock()
+			for _, pr := range prs {
+				byNumber[pr.Number] = pr
+			}
+			mu.Unlock()
+		}(q)
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  WARNING: %d of %d searches failed; the diagram undercounts\n", n, len(queries))
+	}
+	if n := truncated.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  WARNING: %d search(es) matched more than %d PRs; only the first %d were read\n", n, searchResultLimit, searchResultLimit)
+	}
+
+	var prs []flowPR
+	for _, pr := range byNumber {
+		if pr.Author.Typename == "Bot" || cfg.excludeSet[strings.ToLower(pr.Author.Login)] {
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	fmt.Fprintf(os.Stderr, "  %d PRs open during the window\n", len(prs))
+	return prs
+}
+
+// searchFlowPRs pages one PR search, returning the PRs read and the total
+// number of matches reported by the API.
+func searchFlowPRs(cfg config, searchQuery string) ([]flowPR, int, error) {
+	var prs []flowPR
+	var total int
+	cursor := ""
+	for {
+		afterClause := ""
+		if cursor != "" {
+			afterClause = fmt.Sprintf(`, after: %q`, cursor)
+		}
+
+		query := fmt.Sprintf(`{
+			search(query: %q, type: ISSUE, first: 100%s) {
+				issueCount
+				pageInfo { hasNextPage endCursor }
+				nodes {
+					... on PullRequest {
+						number
+						createdAt
+						closedAt
+						mergedAt
+						isDraft
+						author {
+							login
+							... on Bot { __typename }
+							... on User { __typename }
+						}
+						timelineItems(itemTypes: READY_FOR_REVIEW_EVENT, first: 1) {
+							nodes {
+								... on ReadyForReviewEvent {
+									createdAt
+								}
+							}
+						}
+					}
+				}
+			}
+		}`, searchQuery, afterClause)
+
+		resp, err := graphqlQuery(cfg.token, query)
+		if err != nil {
+			return prs, total, err
+		}
+		if len(resp.Errors) > 0 {
+			return prs, total, fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
+		}
+
+		var sr struct {
+			Search struct {
+				IssueCount int `json:"issueCount"`
+				PageInfo   struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []json.RawMessage `json:"nodes"`
+			} `json:"search"`
+		}
+		if err := json.Unmarshal(resp.Data, &sr); err != nil {
+			return prs, total, err
+		}
+		total = sr.Search.IssueCount
+		for _, raw := range sr.Search.Nodes {
+			var pr flowPR
+			if err := json.Unmarshal(raw, &pr); err != nil || pr.Number == 0 {
+				continue
+			}
+			prs = append(prs, pr)
+		}
+
+		if !sr.Search.PageInfo.HasNextPage {
+			return prs, total, nil
+		}
+		cursor = sr.Search.PageInfo.EndCursor
+	}
+}
+
+// readyAt returns when a PR became ready for review: its first
+// ReadyForReviewEvent, its creation for PRs that were never drafts, or nil
+// for drafts that are still drafts.
+func (pr flowPR) readyAt() *time.Time {
+	if len(pr.TimelineItems.Nodes) > 0 && pr.TimelineItems.Nodes[0].CreatedAt != nil {
+		return pr.TimelineItems.Nodes[0].CreatedAt
+	}
+	if pr.IsDraft {
+		return nil
+	}
+	return &pr.CreatedAt
+}
+
+// cumulativeFlow counts the PRs in each state at the end of every period.
+// PRs closed without merging leave the diagram when they are closed.
+func cumulativeFlow(prs []flowPR, periods []weekRange) []flowPoint {
+	if len(periods) == 0 {
+		return nil
+	}
+	windowStart := periods[0].start
+	points := make([]flowPoint, len(periods))
+	for i, p := range periods {
+		t := p.end.Add(24 * time.Hour) // end is the period's last day
+		pt := &points[i]
+		for _, pr := range prs {
+			switch {
+			case !pr.CreatedAt.Before(t):
+				// not opened yet
+			case pr.MergedAt != nil && pr.MergedAt.Before(t):
+				if !pr.MergedAt.Before(windowStart) {
+					pt.merged++
+				}
+			case pr.ClosedAt != nil && pr.ClosedAt.Before(t):
+				// closed without merging
+			default:
+				if ready := pr.readyAt(); ready != nil && ready.Before(t) {
+					pt.inReview++
+				} else {
+					pt.open++
+				}
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxChangelogPages caps the pages of 100 commits compared per release.
+const maxChangelogPages = 10
+
+// changelogPRRe finds the PR a commit subject comes from: GitHub's merge
+// commit subject ("Merge pull request #123 from ...") or the number appended
+// to squash-merged titles ("Fix parser (#123)").
+var changelogPRRe = regexp.MustCompile(`^Merge pull request #(\d+)|\(#(\d+)\)\s*$`)
+
+// changelogCommit is one commit between two releases.
+type changelogCommit struct {
+	sha     string
+	subject string
+}
+
+// releaseChangelog is the size of one release: the PRs among the commits
+// since the previous release.
+type releaseChangelog struct {
+	release   release
+	prs       int
+	commits   int
+	truncated bool // more commits than maxChangelogPages compared
+}
+
+// fetchChangelogs compares each release in the window with the one before
+// it and counts the PRs it includes. A commit counts as a PR when it is the
+// merge commit of a fetched PR or its subject names one, so PRs merged
+// before the window count too. Releases whose comparison fails are left out
+// with a warning.
+func fetchChangelogs(cfg config, releases []release, weeks []weekRange, prs []PR) []releaseChangelog {
+	mergeCommits := make(map[string]int)
+	for _, pr := range prs {
+		if pr.MergeCommit != nil && pr.Number != 0 {
+			mergeCommits[pr.MergeCommit.Oid] = pr.Number
+		}
+	}
+
+	var out []releaseChangelog
+	for i := 1; i < len(releases); i++ {
+		r := releases[i]
+		if weekIndex(weeks, r.date.Unix()) < 0 {
+			continue
+		}
+		var commits []changelogCommit
+		var truncated bool
+		var err error
+		if cfg.provider == "git" {
+			commits, err = localGitChangelog(cfg.gitDir, releases[i-1].name, r.name)
+		} else {
+			commits, truncated, err = compareReleases(cfg, releases[i-1].name, r.name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  WARNING: comparing %s...%s failed: %v\n", releases[i-1].name, r.name, err)
+			continue
+		}
+		out = append(out, releaseChangelog{
+			release:   r,
+			prs:       countChangelogPRs(commits, mergeCommits, cfg.provider == "git"),
+			commits:   len(commits),
+			truncated: truncated,
+		})
+	}
+	return out
+}
+
+// countChangelogPRs counts the distinct PRs among a release's commits. With
+// --local-git every non-merge commit stands for a PR, as in the other
+// metrics.
+func countChangelogPRs(commits []changelogCommit, mergeCommits map[string]int, localGit bool) int {
+	seen := make(map[int]bool)
+	var n int
+	for _, c := range commits {
+		if num, ok := mergeCommits[c.sha]; ok {
+			if !seen[num] {
+				seen[num] = true
+				n++
+			}
+			continue
+		}
+		if m := changelogPRRe.FindStringSubmatch(c.subject); m != nil {
+			num, _ := strconv.Atoi(m[1] + m[2])
+			if !seen[num] {
+				seen[num] = true
+				n++
+			}
+			continue
+		}
+		if localGit && !strings.HasPrefix(c.subject, "Merge ") {
+			n++
+		}
+	}
+	return n
+}
+
+// compareReleases lists the commits reachable from head but not base with
+// the REST compare endpoint, a page of 100 at a time.
+func compareReleases(cfg config, base, head string) ([]changelogCommit, bool, error) {
+	var out []changelogCommit
+	for page := 1; page <= maxChangelogPages; page++ {
+		endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s?per_page=100&page=%d",
+			cfg.owner, cfg.repo, url.PathEscape(base), url.PathEscape(head), page)
+		var resp struct {
+			TotalCommits int `json:"total_commits"`
+			Commits      []struct {
+				SHA    string `json:"sha"`
+				Commit struct {
+					Message string `json:"message"`
+				} `json:"commit"`
+			} `json:"commits"`
+		}
+		if err := githubREST(cfg.token, "GET", endpoint, nil, &resp); err != nil {
+			return nil, false, err
+		}
+		for _, c := range resp.Commits {
+			subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+			out = append(out, changelogCommit{sha: c.SHA, subject: subject})
+		}
+		if len(resp.Commits) < 100 || len(out) >= resp.TotalCommits {
+			return out, false, nil
+		}
+	}
+	return out, true, nil
+}
+
+// localGitChangelog lists the commits in base..head of a local clone.
+func localGitChangelog(dir, base, head string) ([]changelogCommit, error) {
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%H%x1f%s", base+".."+head).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var commits []changelogCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if sha, subject, ok := strings.Cut(line, "\x1f"); ok {
+			commits = append(commits, changelogCommit{sha: sha, subject: subject})
+		}
+	}
+	return commits, nil
+}
+
+// applyChangelogs sets each week's median PRs included per release.
+func applyChangelogs(changelogs []releaseChangelog, weeks []weekRange, stats []weekStats) {
+	sizes := make([][]float64, len(weeks))
+	for _, c := range changelogs {
+		if w := weekIndex(weeks, c.release.date.Unix()); w >= 0 {
+			sizes[w] = append(sizes[w], float64(c.prs))
+		}
+	}
+	for i := range stats {
+		stats[i].changelogTracked = true
+		stats[i].releaseBatch = -1
+		if len(sizes[i]) > 0 {
+			stats[i].releaseBatch = medianFloat(sizes[i])
+		}
+	}
+}
+
+// logChangelogs prints the median and largest release batch, and which
+// releases had more commits than were compared.
+func logChangelogs(changelogs []releaseChangelog) {
+	if len(changelogs) == 0 {
+		fmt.Fprintf(os.Stderr, "Release changelogs: no release in the window has a predecessor to compare with\n")
+		return
+	}
+	sizes := make([]float64, len(changelogs))
+	largest := changelogs[0]
+	for i, c := range changelogs {
+		sizes[i] = float64(c.prs)
+		if c.prs > largest.prs {
+			largest = c
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Release changelogs: %d release(s) compared, median %.1f PRs, largest %s with %d PRs (%d commits)\n",
+		len(changelogs), median(sizes), largest.release.name, largest.prs, largest.commits)
+	var truncated []string
+	for _, c := range changelogs {
+		if c.truncated {
+			truncated = append(truncated, c.release.name)
+		}
+	}
+	if len(truncated) > 0 {
+		fmt.Fprintf(os.Stderr, "  Only the first %d commits compared for: %s\n", maxChangelogPages*100, strings.Join(truncated, ", "))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// jobBillableMinutes returns the Actions minutes GitHub bills for a job: its
+// run time rounded up to the whole minute, times the runner OS multiplier
+// (Windows 2, macOS 10). Jobs on self-hosted runners and jobs that never
+// started are free.
+func jobBillableMinutes(j workflowJob) float64 {
+	if j.StartedAt.IsZero() || !j.CompletedAt.After(j.StartedAt) {
+		return 0
+	}
+	multiplier := 1.0
+	for _, l := range j.Labels {
+		l = strings.ToLower(l)
+		switch {
+		case l == "self-hosted":
+			return 0
+		case strings.Contains(l, "macos"):
+			multiplier = 10
+		case strings.Contains(l, "windows"):
+			multiplier = 2
+		}
+	}
+	return math.Ceil(j.CompletedAt.Sub(j.StartedAt).Minutes()) * multiplier
+}
+
+// applyCICost sets each week's billable Actions minutes from the sampled
+// runs' jobs, scaled up to all of the week's runs, and with a price per
+// minute its cost. Weeks without sampled jobs are left at -1.
+func applyCICost(sampled []weekJobs, stats []weekStats, price float64) {
+	for i := range stats {
+		stats[i].ciCostTracked, stats[i].ciPriced = true, price > 0
+		stats[i].ciMinutes, stats[i].ciCost = -1, -1
+		if i >= len(sampled) || len(sampled[i].jobs) == 0 {
+			continue
+		}
+		var minutes float64
+		for _, j := range sampled[i].jobs {
+			minutes += jobBillableMinutes(j)
+		}
+		stats[i].ciMinutes = math.Round(minutes * sampled[i].scale)
+		if price > 0 {
+			stats[i].ciCost = stats[i].ciMinutes * price
+		}
+	}
+}
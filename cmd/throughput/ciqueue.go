@@ -0,0 +1,26 @@
+package main
+
+import ()
+
+// runQueueMinutes returns how long a workflow run waited between being
+// created and starting, in minutes. Re-run attempts are skipped: their start
+// time belongs to the latest attempt, not to the wait after creation.
+func runQueueMinutes(r workflowRun) (float64, bool) {
+	if r.RunAttempt > 1 || r.RunStartedAt.IsZero() || r.CreatedAt.IsZero() {
+		return 0, false
+	}
+	return max(0, r.RunStartedAt.Sub(r.CreatedAt).Minutes()), true
+}
+
+// applyCIQueue sets each week's median CI queue time from the workflow runs
+// sampled by fetchBuildRuns. Weeks without a sampled first attempt are left
+// at -1.
+func applyCIQueue(builds []buildWeekStats, stats []weekStats) {
+	for i := range stats {
+		stats[i].ciQueueTracked = true
+		stats[i].medianCIQueue = -1
+		if i < len(builds) && len(builds[i].queueMinutes) > 0 {
+			stats[i].medianCIQueue = medianFloat(builds[i].queueMinutes)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// subcommands are dispatched from main() before flag parsing.
+var subcommands = []string{"server", "cache", "completion"}
+
+// flagValueHints tells shell completion what a flag's value looks like:
+// "<file>", "<dir>", or a space-separated list of choices. Flags not listed
+// complete nothing (free-form values).
+var flagValueHints = map[string]string{
+	"output":                    "<file>",
+	"html":                      "<file>",
+	"chart-js":                  "<file>",
+	"pr-output":                 "<file>",
+	"notebook-bundle":           "<dir>",
+	"runners-output":            "<file>",
+	"correlation-matrix-output": "<file>",
+	"template":                  "<file>",
+	"categories":                "<file>",
+	"incidents-csv":             "<file>",
+	"batch":                     "<file>",
+	"anonymize-map":             "<file>",
+	"store":                     "<dir>",
+	"cache-dir":                 "<dir>",
+	"batch-out":                 "<dir>",
+	"local-git":                 "<dir>",
+	"provider":                  "github gerrit",
+	"fetch":                     strings.Join(fetchModes, " "),
+	"token-source":              strings.Join(tokenSourceNames(), " "),
+	"releases":                  "releases tags",
+	"granularity":               "weekly monthly sprint",
+	"outlier-policy":            "none winsorize drop",
+	"contributors-sort":         strings.Join(contributorSortKeys, " "),
+	"attribution":               strings.Join(attributionModes, " "),
+	"exclude-bottom-by":         "prs commits active-weeks",
+	"locale":                    "en de",
+	"audience":                  strings.Join(audienceNames, " "),
+	"benchmark":                 strings.Join(benchmarkNames(), " "),
+}
+
+// flagDependencies lists flags that only take effect together with another
+// flag. Setting one without the other is almost always a mistake, so it is
+// rejected with a hint rather than silently ignored.
+var flagDependencies = []struct{ flag, requires string }{
+	{"port", "serve"},
+	{"max-clients", "serve"},
+	{"contributors-sort", "top-contributors"},
+	{"top-reviewers", "enrich-reviews"},
+	{"lifecycle", "enrich-reviews"},
+	{"hygiene-min-description", "hygiene"},
+	{"template-sections", "template-compliance"},
+	{"security-labels", "security"},
+	{"release-pattern", "releases"},
+	{"release-changelog", "releases"},
+	{"runners-output", "runners"},
+	{"correlation-matrix-output", "correlation-matrix"},
+	{"ci-minute-price", "ci-cost"},
+	{"timezone", "heatmap"},
+	{"holiday-policy", "holidays"},
+	{"sensitivity-bottom-pct", "sensitivity"},
+	{"sensitivity-min-prs", "sensitivity"},
+	{"outlier-bounds", "outlier-policy"},
+	{"compare-fiscal-quarters", "fiscal-year-start"},
+	{"sprint-field", "sprint-project"},
+	{"jira-key-regex", "jira-url"},
+	{"jira-in-progress-status", "jira-url"},
+	{"confluence-space", "confluence-url"},
+	{"confluence-page", "confluence-url"},
+	{"linear-key-regex", "linear"},
+	{"pagerduty-service-ids", "pagerduty"},
+	{"batch-out", "batch"},
+	{"batch-parallel", "batch"},
+	{"anonymize-map", "anonymize"},
+	{"cache-retention", "cache-dir"},
+	{"cache-redact-authors", "cache-dir"},
+}
+
+// checkFlagDependencies rejects flags set without the flag they depend on.
+func checkFlagDependencies() {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, d := range flagDependencies {
+		if set[d.flag] && !set[d.requires] {
+			fatal("--%s has no effect without --%s", d.flag, d.requires)
+		}
+	}
+}
+
+// parseRepoArg accepts the repository as a positional argument
+// (`throughput owner/repo --weeks 26`) as an alternative to --repo. The flag
+// package stops at the first non-flag argument, so flags after it are
+// parsed in a second pass.
+func parseRepoArg() {
+	if flag.NArg() == 0 {
+		return
+	}
+	if flag.Lookup("repo").Value.String() != "" {
+		fatal("repository given both as --repo and as argument %q", flag.Arg(0))
+	}
+	repo := flag.Arg(0)
+	flag.CommandLine.Parse(flag.Args()[1:])
+	if flag.NArg() > 0 {
+		fatal("unexpected argument(s): %s (only one repository can be given; see --batch for several)", strings.Join(flag.Args(), " "))
+	}
+	flag.Set("repo", repo)
+}
+
+// checkWritable verifies that path can be created before any data is
+// fetched, so a typo in an output path doesn't surface after a long run.
+func checkWritable(flagName, path string) {
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		fatalCode(exitWrite, "--%s %s: directory %s does not exist", flagName, path, dir)
+	}
+	if !info.IsDir() {
+		fatalCode(exitWrite, "--%s %s: %s is not a directory", flagName, path, dir)
+	}
+	f, err := os.CreateTemp(dir, ".throughput-write-check-*")
+	if err != nil {
+		fatalCode(exitWrite, "--%s %s: directory %s is not writable", flagName, path, dir)
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// runCompletion implements `throughput completion bash|zsh|fish`, printing a
+// completion script generated from the registered flags.
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fatal("usage: throughput completion bash|zsh|fish")
+	}
+	var flags []*flag.Flag
+	flag.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion(flags))
+	case "zsh":
+		fmt.Print(zshCompletion(flags))
+	case "fish":
+		fmt.Print(fishCompletion(flags))
+	default:
+		fatal("unsupported shell %q (use bash, zsh, or fish)", args[0])
+	}
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func bashCompletion(flags []*flag.Flag) string {
+	var names, files, dirs []string
+	var sb strings.Builder
+	sb.WriteString("# bash completion for throughput\n_throughput() {\n")
+	sb.WriteString("  local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	sb.WriteString("  case \"$prev\" in\n")
+	for _, f := range flags {
+		names = append(names, "--"+f.Name)
+		switch hint := flagValueHints[f.Name]; hint {
+		case "":
+		case "<file>":
+			files = append(files, "--"+f.Name)
+		case "<dir>":
+			dirs = append(dirs, "--"+f.Name)
+		default:
+			fmt.Fprintf(&sb, "    --%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.Name, hint)
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(&sb, "    %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(files, "|"))
+	}
+	if len(dirs) > 0 {
+		fmt.Fprintf(&sb, "    %s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;\n", strings.Join(dirs, "|"))
+	}
+	sb.WriteString("  esac\n")
+	fmt.Fprintf(&sb, "  if [[ $COMP_CWORD -eq 1 && \"$cur\" != -* ]]; then\n    COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n  fi\n", strings.Join(subcommands, " "))
+	fmt.Fprintf(&sb, "  COMPREPLY=($(compgen -W %q -- \"$cur\"))\n}\n", strings.Join(names, " "))
+	sb.WriteString("complete -F _throughput throughput\n")
+	return sb.String()
+}
+
+func zshCompletion(flags []*flag.Flag) string {
+	escape := strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+	var sb strings.Builder
+	sb.WriteString("#compdef throughput\n# zsh completion for throughput\n_arguments \\\n")
+	fmt.Fprintf(&sb, "  '1::command:(%s)' \\\n", strings.Join(subcommands, " "))
+	for _, f := range flags {
+		spec := fmt.Sprintf("--%s[%s]", f.Name, escape.Replace(f.Usage))
+		if !isBoolFlag(f) {
+			switch hint := flagValueHints[f.Name]; hint {
+			case "":
+				spec += ":" + f.Name + ":"
+			case "<file>":
+				spec += ":" + f.Name + ":_files"
+			case "<dir>":
+				spec += ":" + f.Name + ":_files -/"
+			default:
+				spec += ":" + f.Name + ":(" + hint + ")"
+			}
+		}
+		fmt.Fprintf(&sb, "  '%s' \\\n", spec)
+	}
+	sb.WriteString("  '*::'\n")
+	return sb.String()
+}
+
+func fishCompletion(flags []*flag.Flag) string {
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var sb strings.Builder
+	sb.WriteString("# fish completion for throughput\n")
+	fmt.Fprintf(&sb, "complete -c throughput -n '__fish_use_subcommand' -f -a '%s'\n", strings.Join(subcommands, " "))
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c throughput -l %s -d '%s'", f.Name, escape.Replace(f.Usage))
+		if !isBoolFlag(f) {
+			switch hint := flagValueHints[f.Name]; hint {
+			case "<file>":
+				line += " -r -F"
+			case "<dir>":
+				line += " -r -a '(__fish_complete_directories)'"
+			case "":
+				line += " -r -f"
+			default:
+				line += " -r -f -a '" + hint + "'"
+			}
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
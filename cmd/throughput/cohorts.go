@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// cohortBlock is the number of weeks averaged into one point of a cohort
+// curve.
+const cohortBlock = 4
+
+// cohort is the contributors whose first merged PR in the window falls in
+// one calendar or, with --fiscal-year-start, fiscal quarter (--cohorts).
+type cohort struct {
+	quarter  time.Time // first day of the quarter
+	name     string    // e.g. "2025 Q3" or "FY2026 Q1"
+	members  int
+	censored bool // the window's first quarter: also holds everyone who joined before the window
+	// perWeek is the members' mean PRs per week in each cohortBlock-week
+	// block since their first PR; -1 where fewer than minMembers members
+	// have a complete block.
+	perWeek []float64
+}
+
+func (c cohort) label() string {
+	return c.name
+}
+
+// cohortName names a quarter by calendar year, or by fiscal year when fc
+// doesn't start in January.
+func cohortName(fc fiscalCalendar, quarter time.Time) string {
+	if fc.start() != time.January {
+		return quarterLabel(fc.quarterKey(quarter))
+	}
+	return fmt.Sprintf("%d Q%d", quarter.Year(), (int(quarter.Month())-1)/3+1)
+}
+
+// buildCohorts groups authors by the quarter of their first merged PR and
+// computes each cohort's throughput curve over the weeks since joining. A
+// member counts towards a block only once all its weeks are in the window,
+// so the curves end where the window does. Cohorts and blocks with fewer
+// than minMembers members are left out (--min-group-size). Quarters follow
+// fc; its zero value is calendar quarters.
+func buildCohorts(prs []enrichedPR, weeks []weekRange, minMembers int, fc fiscalCalendar) []cohort {
+	if len(weeks) == 0 {
+		return nil
+	}
+	minMembers = max(minMembers, 1)
+	prsByAuthor := make(map[string][]int) // week index per merged PR
+	firstWeek := make(map[string]int)
+	firstMerge := make(map[string]int64)
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		prsByAuthor[pr.authorLogin] = append(prsByAuthor[pr.authorLogin], i)
+		if f, ok := firstMerge[pr.authorLogin]; !ok || pr.mergedEpoch < f {
+			firstMerge[pr.authorLogin] = pr.mergedEpoch
+			firstWeek[pr.authorLogin] = i
+		}
+	}
+
+	byQuarter := make(map[time.Time][]string)
+	for author, epoch := range firstMerge {
+		q := fc.quarterStart(time.Unix(epoch, 0).UTC())
+		byQuarter[q] = append(byQuarter[q], author)
+	}
+	windowQuarter := fc.quarterStart(weeks[0].start)
+
+	var cohorts []cohort
+	for q, authors := range byQuarter {
+		if len(authors) < minMembers {
+			continue
+		}
+		c := cohort{quarter: q, name: cohortName(fc, q), members: len(authors), censored: !q.After(windowQuarter)}
+		for b := 0; ; b++ {
+			var sum float64
+			var n int
+			for _, a := range authors {
+				from := firstWeek[a] + b*cohortBlock
+				to := from + cohortBlock - 1
+				if to >= len(weeks) {
+					continue
+				}
+				count := 0
+				for _, w := range prsByAuthor[a] {
+					if w >= from && w <= to {
+						count++
+					}
+				}
+				sum += float64(count) / cohortBlock
+				n++
+			}
+			if n == 0 {
+				break
+			}
+			v := -1.0
+			if n >= minMembers {
+				v = sum / float64(n)
+			}
+			c.perWeek = append(c.perWeek, v)
+		}
+		cohorts = append(cohorts, c)
+	}
+	sort.Slice(cohorts, func(i, j int) bool { return cohorts[i].quarter.Before(cohorts[j].quarter) })
+	return cohorts
+}
+
+// logCohorts prints each cohort's size and its throughput in the first and
+// third block after joining.
+func logCohorts(cohorts []cohort) {
+	fmt.Fprintf(os.Stderr, "Cohorts by join quarter (mean PRs/week per contributor):\n")
+	block := func(c cohort, b int) string {
+		if b >= len(c.perWeek) || c.perWeek[b] < 0 {
+			return "—"
+		}
+		return fmt.Sprintf("%.2f", c.perWeek[b])
+	}
+	for _, c := range cohorts {
+		note := ""
+		if c.censored {
+			note = " (includes contributors who joined before the window)"
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %3d contributor(s), weeks 1-4: %s, weeks 9-12: %s%s\n",
+			c.label(), c.members, block(c, 0), block(c, 2), note)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// schemaVersionColumn is the column holding the schema version (see
+// schemaVersion). Under --columns it is written only where listed.
+const schemaVersionColumn = "schema_version"
+
+// parseColumns parses --columns: comma-separated column names, or @path to
+// read them from a file, one or more per line, with # comments.
+func parseColumns(s string) ([]string, error) {
+	if path, ok := strings.CutPrefix(s, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			lines = append(lines, line)
+		}
+		s = strings.Join(lines, ",")
+	}
+	var columns []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if slices.Contains(columns, name) {
+			return nil, fmt.Errorf("column %q listed twice", name)
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns listed")
+	}
+	return columns, nil
+}
+
+// selectColumns rewrites the weekly CSV to exactly the given columns, in
+// order. Columns this run didn't produce (a misspelling, or an optional
+// column whose flag isn't set) are written empty, so the layout doesn't
+// depend on the flags, and returned so the caller can warn about them. A
+// listed schema_version column holds version.
+func selectColumns(data string, columns []string, version int) (string, []string, error) {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return "", nil, fmt.Errorf("parse CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return data, nil, nil
+	}
+	index := make(map[string]int)
+	for i, h := range rows[0] {
+		index[h] = i
+	}
+	var missing []string
+	for _, c := range columns {
+		if _, ok := index[c]; !ok && c != schemaVersionColumn {
+			missing = append(missing, c)
+		}
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Write(columns)
+	for _, row := range rows[1:] {
+		out := make([]string, len(columns))
+		for j, c := range columns {
+			if c == schemaVersionColumn {
+				out[j] = fmt.Sprint(version)
+			} else if i, ok := index[c]; ok && i < len(row) {
+				out[j] = row[i]
+			}
+		}
+		w.Write(out)
+	}
+	w.Flush()
+	return sb.String(), missing, w.Error()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateWindow is an inclusive date range of --compare-windows.
+type dateWindow struct {
+	start, end time.Time
+}
+
+// String formats the window as it is written on the command line.
+func (w dateWindow) String() string {
+	return w.start.Format("2006-01-02") + ".." + w.end.Format("2006-01-02")
+}
+
+// compareWindows, when set, makes the before/after comparison use the
+// periods starting in these two date ranges instead of the first and last N%
+// of periods (--compare-windows).
+var compareWindows []dateWindow
+
+// parseCompareWindows parses two comma-separated START..END date ranges. The
+// ranges must not overlap; the first is the "before" side.
+func parseCompareWindows(s string) ([]dateWindow, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("want two ranges START..END,START..END, got %d", len(parts))
+	}
+	var windows []dateWindow
+	for _, p := range parts {
+		from, to, ok := strings.Cut(strings.TrimSpace(p), "..")
+		if !ok {
+			return nil, fmt.Errorf("range %q is not START..END", p)
+		}
+		start, err := time.Parse("2006-01-02", strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("range %q: invalid start date", p)
+		}
+		end, err := time.Parse("2006-01-02", strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("range %q: invalid end date", p)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("range %q ends before it starts", p)
+		}
+		windows = append(windows, dateWindow{start: start, end: end})
+	}
+	if !windows[0].end.Before(windows[1].start) && !windows[1].end.Before(windows[0].start) {
+		return nil, fmt.Errorf("ranges %s and %s overlap", windows[0], windows[1])
+	}
+	return windows, nil
+}
+
+// applyCompareWindows tags each period with the --compare-windows range its
+// start date falls in, as periods belong to the fiscal quarter they start in.
+func applyCompareWindows(periods []weekRange, stats []weekStats) {
+	for i, p := range periods {
+		stats[i].compareWindow = 0
+		for w, win := range compareWindows {
+			if !p.start.Before(win.start) && !p.start.After(win.end) {
+				stats[i].compareWindow = w + 1
+			}
+		}
+	}
+}
+
+// dateWindowValues returns a metric's values in the two --compare-windows
+// ranges and the number of values.
+func dateWindowValues(periods []weekStats, md metricDef) (first, last []float64, n int, ok bool) {
+	for _, ws := range periods {
+		if !md.valid(ws) {
+			continue
+		}
+		switch ws.compareWindow {
+		case 1:
+			first = append(first, md.extract(ws))
+		case 2:
+			last = append(last, md.extract(ws))
+		default:
+			continue
+		}
+		n++
+	}
+	return first, last, n, len(first) > 0 && len(last) > 0
+}
+
+// compareWindowsWeeks returns the number of weeks to analyze so the range
+// starts no later than the earliest --compare-windows date; weeks if it
+// already does.
+func compareWindowsWeeks(now time.Time, weeks int) int {
+	earliest := compareWindows[0].start
+	if compareWindows[1].start.Before(earliest) {
+		earliest = compareWindows[1].start
+	}
+	for computeWeekRanges(now, weeks)[0].start.After(earliest) {
+		weeks++
+	}
+	return weeks
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// confluenceTarget is the page --confluence-url, --confluence-space, and
+// --confluence-page update with the run summary.
+type confluenceTarget struct {
+	baseURL string // e.g. https://acme.atlassian.net/wiki
+	space   string
+	pageID  string
+}
+
+// publishConfluence replaces the page's body with the run summary, converted
+// from Markdown to Confluence storage format, as a new page version. The page
+// must already exist in the given space; its title is kept. Returns the
+// page's URL.
+func publishConfluence(t confluenceTarget, markdown string) (string, error) {
+	endpoint := strings.TrimSuffix(t.baseURL, "/") + "/rest/api/content/" + t.pageID
+
+	var page struct {
+		Title   string `json:"title"`
+		Version struct {
+			Number int `json:"number"`
+		} `json:"version"`
+		Space struct {
+			Key string `json:"key"`
+		} `json:"space"`
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	if err := confluenceREST("GET", endpoint+"?expand=version,space", nil, &page); err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(page.Space.Key, t.space) {
+		return "", fmt.Errorf("page %s is in space %s, not %s", t.pageID, page.Space.Key, t.space)
+	}
+
+	update := map[string]any{
+		"id":    t.pageID,
+		"type":  "page",
+		"title": page.Title,
+		"space": map[string]string{"key": page.Space.Key},
+		"body": map[string]any{
+			"storage": map[string]string{"value": markdownToStorage(markdown), "representation": "storage"},
+		},
+		"version": map[string]any{"number": page.Version.Number + 1, "message": "Updated by throughput"},
+	}
+	if err := confluenceREST("PUT", endpoint, update, nil); err != nil {
+		return "", err
+	}
+	return page.Links.Base + page.Links.WebUI, nil
+}
+
+// confluenceREST sends a JSON request to the Confluence REST API with retry
+// on transport and server errors, decoding the response into out. It
+// authenticates with CONFLUENCE_EMAIL and CONFLUENCE_API_TOKEN (Cloud), or
+// the token alone as a personal access token (Data Center).
+func confluenceREST(method, url string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if email, tok := os.Getenv("CONFLUENCE_EMAIL"), os.Getenv("CONFLUENCE_API_TOKEN"); tok != "" {
+			if email != "" {
+				req.SetBasicAuth(email, tok)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+tok)
+			}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("Confluence returned %d (check CONFLUENCE_EMAIL/CONFLUENCE_API_TOKEN)", resp.StatusCode)
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("Confluence returned %d", resp.StatusCode)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	}
+	return fmt.Errorf("Confluence request failed after 3 attempts: %v", lastErr)
+}
+
+// markdownToStorage converts the summary Markdown (see parseMarkdown) to
+// Confluence storage format, with <details> blocks as expand macros.
+func markdownToStorage(md string) string {
+	var sb strings.Builder
+	writeStorage(&sb, parseMarkdown(md))
+	return sb.String()
+}
+
+func writeStorage(sb *strings.Builder, blocks []mdBlock) {
+	for _, b := range blocks {
+		switch b.kind {
+		case "heading":
+			fmt.Fprintf(sb, "<h%d>%s</h%d>", b.level, storageInline(b.text), b.level)
+		case "paragraph":
+			sb.WriteString("<p>" + storageInline(b.text) + "</p>")
+		case "bullets":
+			sb.WriteString("<ul>")
+			for _, item := range b.items {
+				sb.WriteString("<li>" + storageInline(item) + "</li>")
+			}
+			sb.WriteString("</ul>")
+		case "table":
+			sb.WriteString("<table><tbody>")
+			for i, row := range b.rows {
+				cell := "td"
+				if i == 0 {
+					cell = "th"
+				}
+				sb.WriteString("<tr>")
+				for _, c := range row {
+					fmt.Fprintf(sb, "<%s>%s</%s>", cell, storageInline(c), cell)
+				}
+				sb.WriteString("</tr>")
+			}
+			sb.WriteString("</tbody></table>")
+		case "details":
+			sb.WriteString(`<ac:structured-macro ac:name="expand"><ac:parameter ac:name="title">` + html.EscapeString(b.text) + `</ac:parameter><ac:rich-text-body>`)
+			writeStorage(sb, b.children)
+			sb.WriteString("</ac:rich-text-body></ac:structured-macro>")
+		}
+	}
+}
+
+// storageInline escapes text and converts bold and code spans.
+func storageInline(s string) string {
+	var sb strings.Builder
+	for _, sp := range parseInline(s) {
+		t := html.EscapeString(sp.text)
+		switch {
+		case sp.code:
+			t = "<code>" + t + "</code>"
+		case sp.bold:
+			t = "<strong>" + t + "</strong>"
+		}
+		sb.WriteString(t)
+	}
+	return sb.String()
+}
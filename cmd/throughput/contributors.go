@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"math"
 	"sort"
 )
@@ -13,47 +15,40 @@ type contributorStat struct {
 	afterRate  float64 // PRs per active week after first Ona PR
 	pctChange  float64
 	hasOnaPRs  bool
+	onaPct     float64 // share of the contributor's PRs that were Ona-involved
+	excluded   bool    // cut by --exclude-bottom-contributor-pct: not in the metrics
 }
 
+// contributorOptions configures which contributors computeTopContributors
+// returns and how they are ranked.
+type contributorOptions struct {
+	n         int             // number of contributors to return
+	sortBy    string          // "total" (PR count), "change" (before/after % change), or "ona" (Ona PR share)
+	minPRs    int             // skip contributors with fewer PRs
+	anonymize bool            // replace logins with hashLogin
+	excluded  map[string]bool // authors left out of the metrics by the bottomCut; listed and marked
+}
+
+// contributorSortKeys lists the valid --contributors-sort values.
+var contributorSortKeys = []string{"total", "change", "ona"}
+
 type contribWeekBound struct {
 	startEpoch int64
 	endEpoch   int64
 }
 
-// computeTopContributors ranks contributors by total PR count and computes
-// before/after Ona PR throughput rates for the top N.
+// computeTopContributors computes before/after Ona PR throughput rates for
+// every contributor with at least opts.minPRs PRs, ranks them by opts.sortBy,
+// and returns the top opts.n.
 // The before/after split is per-contributor: "after" starts at the merge date
 // of their first Ona-involved PR. PR/week = total PRs / active weeks in period.
-func computeTopContributors(prs []enrichedPR, weekRanges []weekRange, n int) []contributorStat {
-	if len(prs) == 0 || n <= 0 {
+func computeTopContributors(prs []enrichedPR, weekRanges []weekRange, opts contributorOptions) []contributorStat {
+	if len(prs) == 0 || opts.n <= 0 {
 		return nil
 	}
 
-	// Group PRs by author
-	byAuthor := make(map[string][]enrichedPR)
-	for _, pr := range prs {
-		byAuthor[pr.authorLogin] = append(byAuthor[pr.authorLogin], pr)
-	}
-
-	// Rank authors by total PR count descending
-	type authorCount struct {
-		login string
-		count int
-	}
-	ranked := make([]authorCount, 0, len(byAuthor))
-	for login, authorPRs := range byAuthor {
-		ranked = append(ranked, authorCount{login, len(authorPRs)})
-	}
-	sort.Slice(ranked, func(i, j int) bool {
-		if ranked[i].count != ranked[j].count {
-			return ranked[i].count > ranked[j].count
-		}
-		return ranked[i].login < ranked[j].login // stable tie-break
-	})
-
-	if n > len(ranked) {
-		n = len(ranked)
-	}
+	// Group PRs by credited engineer (--attribution)
+	byAuthor := prsByEngineer(prs)
 
 	// Precompute week boundaries for active-week counting
 	wb := make([]contribWeekBound, len(weekRanges))
@@ -64,16 +59,19 @@ func computeTopContributors(prs []enrichedPR, weekRanges []weekRange, n int) []c
 		}
 	}
 
-	results := make([]contributorStat, n)
-	for idx := 0; idx < n; idx++ {
-		login := ranked[idx].login
-		authorPRs := byAuthor[login]
+	var results []contributorStat
+	for login, authorPRs := range byAuthor {
+		if len(authorPRs) < opts.minPRs {
+			continue
+		}
 
 		// Find first Ona-involved PR (by merge epoch)
 		var firstOnaEpoch int64
+		var onaCount int
 		hasOna := false
 		for _, pr := range authorPRs {
 			if pr.onaInvolved {
+				onaCount++
 				if !hasOna || pr.mergedEpoch < firstOnaEpoch {
 					firstOnaEpoch = pr.mergedEpoch
 					hasOna = true
@@ -113,19 +111,67 @@ func computeTopContributors(prs []enrichedPR, weekRanges []weekRange, n int) []c
 			pctChange = math.Round(pctChange*10) / 10
 		}
 
-		results[idx] = contributorStat{
+		results = append(results, contributorStat{
 			login:      login,
 			totalPRs:   len(authorPRs),
 			beforeRate: beforeRate,
 			afterRate:  afterRate,
 			pctChange:  pctChange,
 			hasOnaPRs:  hasOna,
-		}
+			onaPct:     math.Round(float64(onaCount)/float64(len(authorPRs))*1000) / 10,
+			excluded:   opts.excluded[login],
+		})
 	}
 
+	sortContributors(results, opts.sortBy)
+	if len(results) > opts.n {
+		results = results[:opts.n]
+	}
+	if opts.anonymize {
+		for i := range results {
+			results[i].login = hashLogin(results[i].login)
+		}
+	}
 	return results
 }
 
+// sortContributors orders contributors by the given key, descending. For
+// "change", contributors without a before/after comparison (no Ona PRs, or no
+// PRs before their first one) sort last. Ties fall back to total PRs, then login.
+func sortContributors(cs []contributorStat, sortBy string) {
+	key := func(c contributorStat) (float64, bool) {
+		switch sortBy {
+		case "change":
+			return c.pctChange, c.hasOnaPRs && c.beforeRate > 0
+		case "ona":
+			return c.onaPct, true
+		}
+		return float64(c.totalPRs), true
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		ki, oki := key(cs[i])
+		kj, okj := key(cs[j])
+		if oki != okj {
+			return oki
+		}
+		if ki != kj {
+			return ki > kj
+		}
+		if cs[i].totalPRs != cs[j].totalPRs {
+			return cs[i].totalPRs > cs[j].totalPRs
+		}
+		return cs[i].login < cs[j].login // stable tie-break
+	})
+}
+
+// hashLogin replaces a login with a stable ID for reports shared outside the
+// team. The same login always maps to the same ID, so contributors can be
+// followed across runs. Anyone who can guess a login can recompute its ID.
+func hashLogin(login string) string {
+	sum := sha256.Sum256([]byte(login))
+	return "user-" + hex.EncodeToString(sum[:4])
+}
+
 // countActiveWeeks returns how many week ranges contain at least one PR.
 func countActiveWeeks(prs []enrichedPR, wb []contribWeekBound) int {
 	if len(prs) == 0 {
@@ -142,3 +188,126 @@ func countActiveWeeks(prs []enrichedPR, wb []contribWeekBound) int {
 	}
 	return active
 }
+
+// authorCount is an author's activity by a contributorMeasure.
+type authorCount struct {
+	login string
+	count int
+}
+
+// contributorMeasure is an activity measure the bottom-contributor cut ranks
+// authors by (--exclude-bottom-by).
+type contributorMeasure struct {
+	name  string
+	desc  string                                        // for the filter note, e.g. "total PR count"
+	unit  string                                        // for logs, e.g. "PRs"
+	value func(prs []enrichedPR, weeks []weekRange) int // over one author's PRs
+}
+
+// contributorMeasures lists the valid --exclude-bottom-by values.
+var contributorMeasures = []contributorMeasure{
+	{
+		name: "prs", desc: "total PR count", unit: "PRs",
+		value: func(prs []enrichedPR, _ []weekRange) int { return len(prs) },
+	},
+	{
+		name: "commits", desc: "total commits", unit: "commits",
+		value: func(prs []enrichedPR, _ []weekRange) int {
+			var n int
+			for _, pr := range prs {
+				n += max(pr.commitCount, 1)
+			}
+			return n
+		},
+	},
+	{
+		name: "active-weeks", desc: "weeks with a merged PR", unit: "active weeks",
+		value: func(prs []enrichedPR, weeks []weekRange) int {
+			active := make(map[int]bool)
+			for _, pr := range prs {
+				if i := weekIndex(weeks, pr.mergedEpoch); i >= 0 {
+					active[i] = true
+				}
+			}
+			return len(active)
+		},
+	},
+}
+
+// contributorMeasureByName looks up a --exclude-bottom-by value.
+func contributorMeasureByName(name string) (contributorMeasure, bool) {
+	for _, m := range contributorMeasures {
+		if m.name == name {
+			return m, true
+		}
+	}
+	return contributorMeasure{}, false
+}
+
+// bottomCut drops the least active contributors from the metrics
+// (--exclude-bottom-contributor-pct, --exclude-bottom-by). main and the
+// --sensitivity reruns share it; contributor stats list the cut authors as
+// excluded instead of dropping them.
+type bottomCut struct {
+	pct     int
+	measure contributorMeasure
+}
+
+// enabled reports whether the cut removes anyone at all.
+func (c bottomCut) enabled() bool {
+	return c.pct > 0 && c.pct < 100
+}
+
+// authors returns the bottom pct% of authors of prs by the measure, least
+// active first. Authors tied with the last one in the cut are included too,
+// so the result can exceed pct%.
+func (c bottomCut) authors(prs []enrichedPR, weeks []weekRange) []authorCount {
+	if !c.enabled() {
+		return nil
+	}
+	byAuthor := prsByEngineer(prs)
+	authors := make([]authorCount, 0, len(byAuthor))
+	for login, authorPRs := range byAuthor {
+		authors = append(authors, authorCount{login, c.measure.value(authorPRs, weeks)})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].count != authors[j].count {
+			return authors[i].count < authors[j].count
+		}
+		return authors[i].login < authors[j].login
+	})
+
+	cutoff := len(authors) * c.pct / 100
+	if cutoff == 0 {
+		return nil
+	}
+	threshold := authors[cutoff-1].count
+	for cutoff < len(authors) && authors[cutoff].count <= threshold {
+		cutoff++
+	}
+	return authors[:cutoff]
+}
+
+// authorSet returns the logins of authors as a set.
+func authorSet(authors []authorCount) map[string]bool {
+	set := make(map[string]bool, len(authors))
+	for _, a := range authors {
+		set[a.login] = true
+	}
+	return set
+}
+
+// withoutAuthors returns the PRs credited to anyone not in excludeSet; with
+// --attribution split, a PR is dropped only if both its engineers are.
+func withoutAuthors(prs []enrichedPR, excludeSet map[string]bool) []enrichedPR {
+	var kept []enrichedPR
+	for _, pr := range prs {
+		for _, login := range creditedLogins(pr) {
+			if !excludeSet[login] {
+				kept = append(kept, pr)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// correlationRow holds the Pearson correlation between two weekly metrics.
+type correlationRow struct {
+	metricA string
+	metricB string
+	n       int
+	r       float64
+	pValue  float64
+}
+
+// computeCorrelations correlates each metric in targets against each metric in
+// against, using only periods where both metrics are valid. Pairs with fewer
+// than 4 overlapping periods are skipped.
+func computeCorrelations(stats []weekStats, targets, against []metricDef) []correlationRow {
+	var rows []correlationRow
+	for _, a := range against {
+		for _, t := range targets {
+			if a.name == t.name {
+				continue
+			}
+			var xs, ys []float64
+			for _, ws := range stats {
+				if a.valid(ws) && t.valid(ws) {
+					xs = append(xs, a.extract(ws))
+					ys = append(ys, t.extract(ws))
+				}
+			}
+			if len(xs) < 4 {
+				continue
+			}
+			r, ok := pearson(xs, ys)
+			if !ok {
+				continue
+			}
+			rows = append(rows, correlationRow{
+				metricA: a.name,
+				metricB: t.name,
+				n:       len(xs),
+				r:       r,
+				pValue:  pearsonPValue(r, len(xs)),
+			})
+		}
+	}
+	return rows
+}
+
+// metricsByName looks up metric definitions by name, skipping unknown names.
+func metricsByName(defs []metricDef, names ...string) []metricDef {
+	var out []metricDef
+	for _, name := range names {
+		for _, md := range defs {
+			if md.name == name {
+				out = append(out, md)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// logCorrelations prints correlation rows to stderr.
+func logCorrelations(rows []correlationRow) {
+	for _, c := range rows {
+		fmt.Fprintf(os.Stderr, "  %s vs %s: r=%+.2f p=%.3f (n=%d)\n", c.metricA, c.metricB, c.r, c.pValue, c.n)
+	}
+}
+
+// pearson returns the Pearson correlation coefficient. ok is false when either
+// series has zero variance.
+func pearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// pearsonPValue returns the two-tailed p-value for a correlation of r over n
+// samples, using the t-distribution with n-2 degrees of freedom.
+func pearsonPValue(r float64, n int) float64 {
+	df := float64(n - 2)
+	if df <= 0 {
+		return 1
+	}
+	if math.Abs(r) >= 1 {
+		return 0
+	}
+	t := r * math.Sqrt(df/(1-r*r))
+	return studentTTwoTailed(t, df)
+}
+
+// studentTTwoTailed returns P(|T| >= |t|) for a t-distribution with df degrees
+// of freedom, via the regularized incomplete beta function.
+func studentTTwoTailed(t, df float64) float64 {
+	x := df / (df + t*t)
+	return regIncBeta(df/2, 0.5, x)
+}
+
+// regIncBeta computes the regularized incomplete beta function I_x(a, b)
+// using the continued-fraction expansion (Numerical Recipes, betacf).
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lbeta, _ := math.Lgamma(a + b)
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	front := math.Exp(lbeta - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(a, b, x) / a
+	}
+	return 1 - front*betaCF(b, a, 1-x)/b
+}
+
+func betaCF(a, b, x float64) float64 {
+	const maxIter = 200
+	const eps = 3e-14
+	const fpmin = 1e-300
+
+	qab, qap, qam := a+b, a+1, a-1
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < fpmin {
+		d = fpmin
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < fpmin {
+			d = fpmin
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < fpmin {
+			c = fpmin
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
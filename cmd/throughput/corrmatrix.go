@@ -0,0 +1,183 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// corrMatrixHeader is the --correlation-matrix-output CSV header.
+var corrMatrixHeader = []string{"metric_a", "metric_b", "periods", "r", "p_value"}
+
+// corrMatrix holds the Pearson correlation of every pair of weekly metrics
+// with data (--correlation-matrix).
+type corrMatrix struct {
+	metrics []string            // metric names, in allMetrics, cycle time, then CSV order
+	cells   [][]*correlationRow // cells[i][j] for i != j; nil when too few periods overlap
+}
+
+// metricValue returns md's value for one period, and false when the period
+// has no data for it. Metrics without a valid func mark no data as negative.
+func metricValue(md metricDef, ws weekStats) (float64, bool) {
+	if md.valid != nil && !md.valid(ws) {
+		return 0, false
+	}
+	v := md.extract(ws)
+	if md.valid == nil && v < 0 {
+		return 0, false
+	}
+	return v, !math.IsNaN(v)
+}
+
+// computeCorrMatrix correlates every pair of metrics: the stats metrics
+// (including user-defined ones), the cycle time metrics, and the CSV-only
+// columns. Metrics with fewer than 4 periods of data, or the same value in
+// every period, are left out. Like computeCorrelations, a pair uses only the
+// periods where both have data and needs at least 4 of them.
+func computeCorrMatrix(stats []weekStats) corrMatrix {
+	var defs []metricDef
+	var values [][]float64 // values[i][period], NaN without data
+	for _, md := range slices.Concat(allMetrics, cycleTimeMetrics, csvOnlyMetrics) {
+		vs := make([]float64, len(stats))
+		n, lo, hi := 0, math.Inf(1), math.Inf(-1)
+		for k, ws := range stats {
+			vs[k] = math.NaN()
+			if v, ok := metricValue(md, ws); ok {
+				vs[k] = v
+				n++
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+		if n < 4 || lo == hi {
+			continue
+		}
+		defs = append(defs, md)
+		values = append(values, vs)
+	}
+
+	m := corrMatrix{cells: make([][]*correlationRow, len(defs))}
+	for i, md := range defs {
+		m.metrics = append(m.metrics, md.name)
+		m.cells[i] = make([]*correlationRow, len(defs))
+	}
+	for i := range defs {
+		for j := i + 1; j < len(defs); j++ {
+			var xs, ys []float64
+			for k := range stats {
+				if !math.IsNaN(values[i][k]) && !math.IsNaN(values[j][k]) {
+					xs = append(xs, values[i][k])
+					ys = append(ys, values[j][k])
+				}
+			}
+			if len(xs) < 4 {
+				continue
+			}
+			r, ok := pearson(xs, ys)
+			if !ok {
+				continue
+			}
+			c := correlationRow{metricA: defs[i].name, metricB: defs[j].name, n: len(xs), r: r, pValue: pearsonPValue(r, len(xs))}
+			m.cells[i][j], m.cells[j][i] = &c, &c
+		}
+	}
+	return m
+}
+
+// pairs returns each correlated pair once, in matrix order.
+func (m corrMatrix) pairs() []correlationRow {
+	var rows []correlationRow
+	for i := range m.metrics {
+		for j := i + 1; j < len(m.metrics); j++ {
+			if c := m.cells[i][j]; c != nil {
+				rows = append(rows, *c)
+			}
+		}
+	}
+	return rows
+}
+
+// maxLoggedCorrPairs is how many of the strongest significant pairs
+// logCorrMatrix prints.
+const maxLoggedCorrPairs = 10
+
+// logCorrMatrix prints the matrix size and its strongest significant pairs.
+// Pairs of metrics computed from each other (PRs merged and PRs per engineer)
+// tend to top the list; the rest is where a relationship worth a look shows.
+func logCorrMatrix(m corrMatrix) {
+	rows := m.pairs()
+	fmt.Fprintf(os.Stderr, "Correlation matrix: %d metric(s), %d pair(s)\n", len(m.metrics), len(rows))
+	rows = slices.DeleteFunc(rows, func(c correlationRow) bool {
+		return significanceLevel > 0 && c.pValue >= significanceLevel
+	})
+	slices.SortStableFunc(rows, func(a, b correlationRow) int {
+		return cmp.Compare(math.Abs(b.r), math.Abs(a.r))
+	})
+	logCorrelations(rows[:min(len(rows), maxLoggedCorrPairs)])
+}
+
+// writeCorrMatrixCSV writes one row per correlated pair of metrics.
+func writeCorrMatrixCSV(path string, m corrMatrix, version int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := corrMatrixHeader
+	var trailer []string
+	if embedsSchemaVersion(version) {
+		header = append(append([]string{}, header...), schemaVersionColumn)
+		trailer = []string{strconv.Itoa(version)}
+	}
+	w.Write(header)
+	for _, c := range m.pairs() {
+		row := []string{c.metricA, c.metricB, strconv.Itoa(c.n),
+			strconv.FormatFloat(c.r, 'f', 3, 64), strconv.FormatFloat(c.pValue, 'f', 4, 64)}
+		w.Write(append(row, trailer...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// corrMatrixTable renders the matrix for the HTML heatmap.
+func corrMatrixTable(m corrMatrix, labelOf func(string) string, loc reportLocale) *htmlCorrMatrix {
+	t := &htmlCorrMatrix{}
+	if significanceLevel > 0 {
+		t.Note = fmt.Sprintf(loc.T("Pearson r of each pair of metrics over the periods where both have data: blue moves together, red in opposite directions. Bold is significant at p < %s; hover a cell for its p-value. Empty cells have fewer than 4 shared periods. Metrics computed from each other correlate by construction."),
+			loc.number(significanceLevel, 2))
+	} else {
+		t.Note = loc.T("Pearson r of each pair of metrics over the periods where both have data: blue moves together, red in opposite directions. Hover a cell for its p-value. Empty cells have fewer than 4 shared periods. Metrics computed from each other correlate by construction.")
+	}
+	for i, name := range m.metrics {
+		row := htmlCorrMatrixRow{Num: i + 1, Label: labelOf(name)}
+		for j, c := range m.cells[i] {
+			switch {
+			case i == j:
+				row.Cells = append(row.Cells, htmlCorrCell{Self: true})
+			case c == nil:
+				row.Cells = append(row.Cells, htmlCorrCell{})
+			default:
+				shade := math.Round(math.Abs(c.r)*100) / 100
+				row.Cells = append(row.Cells, htmlCorrCell{
+					R: loc.localizeNumeric(fmt.Sprintf("%+.2f", c.r)),
+					Title: fmt.Sprintf("%s vs %s: p = %s, n = %d", labelOf(name), labelOf(m.metrics[j]),
+						loc.number(c.pValue, 3), c.n),
+					Shade:       shade,
+					Negative:    c.r < 0,
+					Dark:        shade > 0.55,
+					Significant: significanceLevel > 0 && c.pValue < significanceLevel,
+				})
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
@@ -2,28 +2,215 @@ package main
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
 
-const csvHeader = "week_start,week_end,prs_merged,unique_authors,prs_per_engineer,total_additions,total_deletions,total_files_changed,median_coding_time_hours,p90_coding_time_hours,median_review_time_hours,p90_review_time_hours,median_review_turnaround_hours,p90_review_turnaround_hours,avg_pr_size_lines,pct_ona_involved,revert_count,pct_reverts"
+// weeklyCSVLayout is the order of the weekly CSV's metric columns, after
+// week_start and week_end. Each name is a metricDef with a csv verb. The
+// layout is versioned (see schemaVersion), so it is pinned here rather than
+// following the registry's order.
+var weeklyCSVLayout = []string{
+	"prs_merged", "unique_authors", "prs_per_engineer",
+	"total_additions", "total_deletions", "total_files_changed",
+	"median_coding_time_hours", "p90_coding_time_hours",
+	"median_review_time_hours", "p90_review_time_hours",
+	"median_review_turnaround_hours", "p90_review_turnaround_hours",
+	"avg_pr_size_lines", "pct_ona_involved", "revert_count", "pct_reverts",
+}
+
+var csvHeader = "week_start,week_end," + strings.Join(weeklyCSVLayout, ",")
+
+// optionalCSVLayout is the order of the optional metric columns, after the
+// base ones. A column is written when its metricDef.column reports that the
+// run tracks it, in this pinned order (the order the flags were added);
+// user-defined metrics follow in registration order.
+var optionalCSVLayout = slices.Concat([]string{
+	"build_runs", "build_success_pct", "median_ci_queue_minutes",
+	"billable_ci_minutes", "ci_cost", "reopened_count",
+	"size_points_per_engineer", "pct_ona_lines",
+}, metricNames(sizeBucketMetrics()), []string{
+	"active_engineers_4w", "churned_engineers",
+	"automation_prs", "median_automation_merge_hours",
+	"security_prs", "median_security_lead_hours",
+	"releases", "median_days_between_releases", "prs_per_release", "median_release_batch_prs",
+	"pct_described", "pct_linked_issue", "pct_with_tests", "pct_template_compliant",
+	"pct_approved", "pct_unreviewed",
+	"median_review_response_hours", "review_requests", "unanswered_review_requests",
+	"incident_count", "median_mttr_hours",
+})
+
+// everyRun is the column func of optional columns every run writes.
+func everyRun(weekStats) bool { return true }
+
+func metricNames(defs []metricDef) []string {
+	names := make([]string, len(defs))
+	for i, md := range defs {
+		names[i] = md.name
+	}
+	return names
+}
+
+// weeklyCSVColumns resolves the weekly CSV's metric columns against the
+// metric registry: weeklyCSVLayout, then the optional columns stats carries.
+func weeklyCSVColumns(stats []weekStats) []metricDef {
+	resolve := func(name string) metricDef {
+		md, ok := metricByName(name)
+		if !ok || (md.csv == "" && md.format == nil) {
+			panic(fmt.Sprintf("weekly CSV column %q has no metric with a csv verb", name))
+		}
+		return md
+	}
+	cols := make([]metricDef, 0, len(weeklyCSVLayout)+len(optionalCSVLayout)+len(userMetricNames))
+	for _, name := range weeklyCSVLayout {
+		cols = append(cols, resolve(name))
+	}
+	for _, name := range slices.Concat(optionalCSVLayout, userMetricNames) {
+		if md := resolve(name); slices.ContainsFunc(stats, md.column) {
+			cols = append(cols, md)
+		}
+	}
+	return cols
+}
+
+// formatMetricCell writes one metric value as a weekly CSV cell. Engineer
+// metrics are empty in weeks --min-group-size suppressed.
+func formatMetricCell(md metricDef, ws weekStats) string {
+	if md.engineer && ws.suppressed {
+		return ""
+	}
+	v := md.extract(ws)
+	if md.format != nil {
+		return md.format(v)
+	}
+	if v < 0 {
+		return ""
+	}
+	return fmt.Sprintf(md.csv, v)
+}
+
+// writeWeeklyCSV formats the weekly CSV: week_start, week_end, and one
+// column per weeklyCSVColumns entry.
+func writeWeeklyCSV(weeks []weekRange, stats []weekStats) string {
+	columns := weeklyCSVColumns(stats)
+	var sb strings.Builder
+	sb.WriteString("week_start,week_end")
+	for _, md := range columns {
+		sb.WriteString("," + md.name)
+	}
+	sb.WriteByte('\n')
+	for i, wr := range weeks {
+		sb.WriteString(wr.start.Format("2006-01-02"))
+		sb.WriteByte(',')
+		sb.WriteString(wr.end.Format("2006-01-02"))
+		for _, md := range columns {
+			sb.WriteByte(',')
+			sb.WriteString(formatMetricCell(md, stats[i]))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
 
 // weekStats holds the computed per-week values needed by the stats analysis.
 type weekStats struct {
-	prsMerged            int
-	uniqueAuthors        int
-	prsPerEngineer       float64
-	medianCodingTime     float64 // first commit to ready-for-review; -1 if no data
-	medianReviewTime     float64 // ready-for-review to merged; -1 if no data
-	pctOnaInvolved       float64
-	pctReverts           float64
-	buildRuns            int
-	buildSuccessPct      float64
+	prsMerged             int
+	uniqueAuthors         int
+	prsPerEngineer        float64
+	commitsPerEngineer    float64 // commits on merged PRs / unique authors
+	medianCodingTime      float64 // first commit to ready-for-review; -1 if no data
+	medianReviewTime      float64 // ready-for-review to merged; -1 if no data
+	pctOnaInvolved        float64
+	onaWeighted           bool    // true when --ona-weighted is set
+	onaLines              int     // lines changed (additions + deletions) in Ona-involved PRs
+	pctOnaLines           float64 // onaLines as a share of all lines changed; -1 if no lines changed
+	pctReverts            float64
+	buildRuns             int
+	buildSuccessPct       float64
+	sizeWeighted          bool    // true when --size-weighted is set
+	sizePointsPerEngineer float64 // sum of sizePoints / unique authors
+	retentionTracked      bool    // true when --retention is set
+	activeEngineers4w     int     // distinct authors in this and the previous 3 weeks; -1 without enough history
+	churnedEngineers      int     // authors active in the prior 4-week window but not this one; -1 without enough history
+	responseTracked       bool    // true with --enrich-reviews
+	medianReviewResponse  float64 // median review request to first review in hours; -1 if no data
+	reviewRequests        int     // review requests to individual reviewers, by PR merge week
+	unansweredRequests    int     // requests never answered by a review
+	hygieneTracked        bool    // true when --hygiene is set
+	pctDescribed          float64 // PRs with a description of at least --hygiene-min-description characters
+	pctLinkedIssue        float64 // PRs linked to an issue
+	pctWithTests          float64 // PRs changing at least one test file
+	suppressed            bool    // engineer-derived values removed by --min-group-size
+	incomplete            bool    // the week's fetch failed or was cut off; counts may be low
+	reopenTracked         bool    // true when close/reopen events were fetched (GitHub)
+	reopenedCount         int     // merged PRs that were closed and reopened at least once
+	automationTracked     bool    // true when --automation is set
+	automationPRs         int     // merged bot-authored PRs
+	medianAutomationMerge float64 // median bot PR created to merged in hours; -1 if no data
+	incidentsTracked      bool    // true when an incident source was configured
+	incidentCount         int
+	medianMTTR            float64              // median incident time to resolve in hours; -1 if no data
+	external              map[string]float64   // user-defined metric values by name (--series, RegisterMetric); NaN or absent if no data
+	customValues          map[string][]float64 // raw per-PR RegisterMetric values, kept so months can re-aggregate exactly
+	fiscalQuarter         int                  // quarterKey of the period's fiscal quarter (--fiscal-year-start); 0 if untagged
+	fiscalPartial         bool                 // the range covers the fiscal quarter only partly
+	compareWindow         int                  // 1 or 2: the --compare-windows range the period starts in; 0 for neither
+
+	// --review-coverage; shares of PRs whose reviews were fetched, -1 if none
+	reviewCoverageTracked bool
+	pctApproved           float64 // approved by someone other than the author
+	pctUnreviewed         float64 // no review from someone other than the author
+
+	// --ci-queue
+	ciQueueTracked bool
+	medianCIQueue  float64 // median workflow run created to started in minutes; -1 if no data
+
+	// --security, from every fetched PR like --automation
+	securityTracked    bool
+	securityPRs        int     // merged security fixes
+	medianSecurityLead float64 // median security fix created to merged in hours; -1 if none
+
+	// --releases
+	releasesTracked  bool
+	releases         int     // releases published in the week
+	medianReleaseGap float64 // median days since the previous release; -1 if no releases
+	prsPerRelease    float64 // median PRs merged since the previous release; -1 if unknown
+	changelogTracked bool    // --release-changelog
+	releaseBatch     float64 // median PRs included per release, compared between tags; -1 if none
+
+	// --template-compliance
+	templateTracked      bool
+	pctTemplateCompliant float64 // merged PRs filling every required description section; -1 if no PRs
+
+	// --ci-cost, scaled from the sampled runs' jobs to all runs; -1 if no data
+	ciCostTracked bool
+	ciPriced      bool    // --ci-minute-price set, so ciCost has a column
+	ciMinutes     float64 // billable Actions minutes
+	ciCost        float64 // ciMinutes × --ci-minute-price; -1 without a price
+
+	// --size-buckets, indexed like sizeBuckets
+	sizeBucketsTracked bool
+	prsBySize          []int     // merged PRs per bucket
+	reviewTimeBySize   []float64 // median review time per bucket; -1 if no data
+
+	// Weekly CSV only (csvOnlyMetrics); months and sprints sum the counts and
+	// leave the spreads at -1
+	additions        int
+	deletions        int
+	filesChanged     int
+	p90CodingTime    float64 // -1 if no data
+	p90ReviewTime    float64 // -1 if no data
+	medianTurnaround float64 // PR created to first review; -1 if no data
+	p90Turnaround    float64 // -1 if no data
+	avgPRSize        float64 // additions + deletions per PR
+	revertCount      int
 }
 
-// aggregateCSV buckets PRs into weeks and produces CSV output.
-// It also returns per-week stats for use by the statistical analysis.
-func aggregateCSV(prs []enrichedPR, weeks []weekRange) (string, []weekStats) {
+// aggregateWeeks buckets PRs into weeks and computes the per-week stats that
+// the weekly CSV, the statistical analysis, and the HTML report read.
+func aggregateWeeks(prs []enrichedPR, weeks []weekRange) []weekStats {
 	// Precompute week epoch boundaries
 	type weekBounds struct {
 		startEpoch int64
@@ -40,131 +227,129 @@ func aggregateCSV(prs []enrichedPR, weeks []weekRange) (string, []weekStats) {
 
 	// Bucket PRs into weeks
 	type weekBucket struct {
-		count            int
-		additions        int
-		deletions        int
-		files            int
-		onaCount         int
-		revertCount      int
-		codingTimes      []float64 // first commit to ready-for-review
-		reviewTimes      []float64 // ready-for-review to merged
-		turnaroundTimes  []float64 // PR created to first review
-		authors          map[string]bool
+		count           int
+		additions       int
+		deletions       int
+		files           int
+		onaCount        int
+		onaLines        int
+		sizePoints      float64
+		commits         int
+		revertCount     int
+		revertBase      int // PRs in the pct_reverts denominator (inRevertBase)
+		reopenedCount   int
+		codingTimes     []float64 // first commit to ready-for-review
+		reviewTimes     []float64 // ready-for-review to merged
+		turnaroundTimes []float64 // PR created to first review
+		authors         map[string]bool
+		customValues    map[string][]float64
 	}
 	buckets := make([]weekBucket, len(weeks))
 	for i := range buckets {
 		buckets[i].authors = make(map[string]bool)
 	}
 
+	// Weeks are in order, so each PR's week is found by binary search
+	// rather than a scan; org-wide runs reuse this for every repo and
+	// --sensitivity setting.
 	for _, pr := range prs {
-		for i := range weeks {
-			if pr.mergedEpoch >= bounds[i].startEpoch && pr.mergedEpoch <= bounds[i].endEpoch {
-				buckets[i].count++
-				buckets[i].additions += pr.additions
-				buckets[i].deletions += pr.deletions
-				buckets[i].files += pr.changedFiles
-				buckets[i].authors[pr.authorLogin] = true
-				if pr.onaInvolved {
-					buckets[i].onaCount++
-				}
-				if pr.isRevert {
-					buckets[i].revertCount++
-				}
-				if pr.codingTimeHours >= 0 {
-					buckets[i].codingTimes = append(buckets[i].codingTimes, pr.codingTimeHours)
-				}
-				if pr.reviewTimeHours >= 0 {
-					buckets[i].reviewTimes = append(buckets[i].reviewTimes, pr.reviewTimeHours)
-				}
-				if pr.reviewTurnaround >= 0 {
-					buckets[i].turnaroundTimes = append(buckets[i].turnaroundTimes, pr.reviewTurnaround)
-				}
-				break
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i].endEpoch >= pr.mergedEpoch })
+		if i == len(bounds) || pr.mergedEpoch < bounds[i].startEpoch {
+			continue
+		}
+		b := &buckets[i]
+		b.count++
+		b.additions += pr.additions
+		b.deletions += pr.deletions
+		b.files += pr.changedFiles
+		b.sizePoints += sizePoints(pr)
+		b.commits += max(pr.commitCount, 1)
+		for _, login := range creditedLogins(pr) {
+			b.authors[login] = true
+		}
+		if pr.onaInvolved {
+			b.onaCount++
+			b.onaLines += pr.additions + pr.deletions
+		}
+		if pr.isRevert {
+			b.revertCount++
+		}
+		if inRevertBase(pr) {
+			b.revertBase++
+		}
+		if pr.reopenCount > 0 {
+			b.reopenedCount++
+		}
+		if pr.codingTimeHours >= 0 {
+			b.codingTimes = append(b.codingTimes, pr.codingTimeHours)
+		}
+		if pr.reviewTimeHours >= 0 {
+			b.reviewTimes = append(b.reviewTimes, pr.reviewTimeHours)
+		}
+		if pr.reviewTurnaround >= 0 {
+			b.turnaroundTimes = append(b.turnaroundTimes, pr.reviewTurnaround)
+		}
+		for name, v := range pr.custom {
+			if b.customValues == nil {
+				b.customValues = make(map[string][]float64)
 			}
+			b.customValues[name] = append(b.customValues[name], v)
 		}
 	}
 
-	// Build CSV and collect stats
-	var sb strings.Builder
-	sb.WriteString(csvHeader)
-	sb.WriteByte('\n')
-
+	// The medians and percentiles sort each week's values; with long
+	// windows and many PRs that dominates, so weeks are summarized in
+	// parallel.
 	allStats := make([]weekStats, len(weeks))
-
-	for i, wr := range weeks {
+	parallelFor(len(weeks), statsWorkers, func(i int) {
 		b := buckets[i]
-		ws := wr.start.Format("2006-01-02")
-		we := wr.end.Format("2006-01-02")
 
 		uniqueAuthors := len(b.authors)
-		var prsPerEng float64
+		var prsPerEng, commitsPerEng, sizePerEng float64
 		if uniqueAuthors > 0 {
 			prsPerEng = float64(b.count) / float64(uniqueAuthors)
+			commitsPerEng = float64(b.commits) / float64(uniqueAuthors)
+			sizePerEng = b.sizePoints / float64(uniqueAuthors)
 		}
 
-		medCoding := formatPercentile(median(b.codingTimes))
-		p90Coding := formatPercentile(p90(b.codingTimes))
-		medReviewTime := formatPercentile(median(b.reviewTimes))
-		p90ReviewTime := formatPercentile(p90(b.reviewTimes))
-		medTurnaround := formatPercentile(median(b.turnaroundTimes))
-		p90Turnaround := formatPercentile(p90(b.turnaroundTimes))
-
-		var avgSize string
-		var pctOna float64
-		var pctReverts float64
+		var avgSize, pctOna, pctReverts float64
 		if b.count > 0 {
-			avgSize = fmt.Sprintf("%.2f", float64(b.additions+b.deletions)/float64(b.count))
+			avgSize = float64(b.additions+b.deletions) / float64(b.count)
 			pctOna = float64(b.onaCount) / float64(b.count) * 100
-			pctReverts = float64(b.revertCount) / float64(b.count) * 100
-		} else {
-			avgSize = "0.00"
 		}
-
-		fmt.Fprintf(&sb, "%s,%s,%d,%d,%.2f,%d,%d,%d,%s,%s,%s,%s,%s,%s,%s,%.1f,%d,%.1f\n",
-			ws, we, b.count, uniqueAuthors, prsPerEng,
-			b.additions, b.deletions, b.files,
-			medCoding, p90Coding, medReviewTime, p90ReviewTime,
-			medTurnaround, p90Turnaround, avgSize, pctOna,
-			b.revertCount, pctReverts)
-
-		allStats[i] = weekStats{
-			prsMerged:         b.count,
-			uniqueAuthors:     uniqueAuthors,
-			prsPerEngineer:    prsPerEng,
-			medianCodingTime:  median(b.codingTimes),
-			medianReviewTime:  median(b.reviewTimes),
-			pctOnaInvolved:    pctOna,
-			pctReverts:        pctReverts,
+		if b.revertBase > 0 {
+			pctReverts = float64(b.revertCount) / float64(b.revertBase) * 100
 		}
-	}
-
-	return sb.String(), allStats
-}
 
-// appendBuildColumns appends build_runs and build_success_pct columns to existing CSV.
-func appendBuildColumns(csv string, stats []weekStats) string {
-	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
-	if len(lines) == 0 {
-		return csv
-	}
-
-	var sb strings.Builder
-	// Header
-	sb.WriteString(lines[0])
-	sb.WriteString(",build_runs,build_success_pct\n")
-
-	// Data rows
-	for i, line := range lines[1:] {
-		sb.WriteString(line)
-		if i < len(stats) {
-			fmt.Fprintf(&sb, ",%d,%.1f", stats[i].buildRuns, stats[i].buildSuccessPct)
-		} else {
-			sb.WriteString(",0,0.0")
+		allStats[i] = weekStats{
+			prsMerged:             b.count,
+			uniqueAuthors:         uniqueAuthors,
+			prsPerEngineer:        prsPerEng,
+			commitsPerEngineer:    commitsPerEng,
+			sizePointsPerEngineer: sizePerEng,
+			medianCodingTime:      median(b.codingTimes),
+			medianReviewTime:      median(b.reviewTimes),
+			pctOnaInvolved:        pctOna,
+			onaLines:              b.onaLines,
+			pctOnaLines:           pctOnaLines(b.onaLines, b.additions+b.deletions),
+			pctReverts:            pctReverts,
+			reopenedCount:         b.reopenedCount,
+			customValues:          b.customValues,
+			additions:             b.additions,
+			deletions:             b.deletions,
+			filesChanged:          b.files,
+			p90CodingTime:         p90(b.codingTimes),
+			p90ReviewTime:         p90(b.reviewTimes),
+			medianTurnaround:      median(b.turnaroundTimes),
+			p90Turnaround:         p90(b.turnaroundTimes),
+			avgPRSize:             avgSize,
+			revertCount:           b.revertCount,
+			ciMinutes:             -1, // set by applyCICost with --ci-cost
+			ciCost:                -1,
 		}
-		sb.WriteByte('\n')
-	}
-
-	return sb.String()
+		aggregateCustomMetrics(&allStats[i])
+	})
+	return allStats
 }
 
 // formatPercentile formats a percentile value, returning empty string for no data.
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// parseDeltaMetrics parses the comma-separated --deltas metric list. Call it
+// after --series are registered so their names are known.
+func parseDeltaMetrics(s string) ([]metricDef, error) {
+	defs := slices.Concat(allMetrics, cycleTimeMetrics)
+	var out []metricDef
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.ContainsFunc(out, func(md metricDef) bool { return md.name == name }) {
+			continue
+		}
+		md := metricsByName(defs, name)
+		if len(md) == 0 {
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+		out = append(out, md[0])
+	}
+	return out, nil
+}
+
+// periodDeltas returns, per period, the metric's change from the previous
+// period and the change of its 4-period mean from the 4 periods before.
+// Periods without data for the metric (or excluded by include) are skipped
+// in the means; a delta is NaN when a period it needs has no data, or when
+// either 4-period window has fewer than 2 periods with data.
+func periodDeltas(stats []weekStats, md metricDef, include func(ws weekStats) bool) (prev, rolling []float64) {
+	value := func(i int) (float64, bool) {
+		if i < 0 || !md.valid(stats[i]) || include != nil && !include(stats[i]) {
+			return 0, false
+		}
+		return md.extract(stats[i]), true
+	}
+	windowMean := func(end int) (float64, bool) {
+		var vals []float64
+		for i := end - 3; i <= end; i++ {
+			if v, ok := value(i); ok {
+				vals = append(vals, v)
+			}
+		}
+		return mean(vals), len(vals) >= 2
+	}
+	prev = make([]float64, len(stats))
+	rolling = make([]float64, len(stats))
+	for i := range stats {
+		prev[i], rolling[i] = math.NaN(), math.NaN()
+		cur, ok := value(i)
+		if before, okBefore := value(i - 1); ok && okBefore {
+			prev[i] = cur - before
+		}
+		if i < 7 {
+			continue
+		}
+		last, okLast := windowMean(i)
+		first, okFirst := windowMean(i - 4)
+		if okLast && okFirst {
+			rolling[i] = last - first
+		}
+	}
+	return prev, rolling
+}
+
+// appendDeltaColumns adds <metric>_wow_delta and <metric>_4w_delta for each
+// --deltas metric. include drops weeks that won't be output (--min-prs).
+func appendDeltaColumns(csv string, stats []weekStats, metrics []metricDef, include func(ws weekStats) bool) string {
+	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
+	if len(lines) == 0 || len(metrics) == 0 {
+		return csv
+	}
+
+	prev := make([][]float64, len(metrics))
+	rolling := make([][]float64, len(metrics))
+	for m, md := range metrics {
+		prev[m], rolling[m] = periodDeltas(stats, md, include)
+	}
+	cell := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lines[0])
+	for _, md := range metrics {
+		fmt.Fprintf(&sb, ",%s_wow_delta,%s_4w_delta", md.name, md.name)
+	}
+	sb.WriteByte('\n')
+	for i, line := range lines[1:] {
+		sb.WriteString(line)
+		for m := range metrics {
+			if i < len(stats) {
+				fmt.Fprintf(&sb, ",%s,%s", cell(prev[m][i]), cell(rolling[m][i]))
+			} else {
+				sb.WriteString(",,")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
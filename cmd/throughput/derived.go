@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// derivedMetricDef is a metric computed from other metrics of the same
+// period by an arithmetic expression, from --derived.
+type derivedMetricDef struct {
+	name string
+	expr string // as written, for the glossary
+	root exprNode
+	refs []string // metric names the expression reads
+}
+
+// derivedMetricDefs holds the derived metrics for this run, in flag order.
+// A definition may read the ones before it.
+var derivedMetricDefs []derivedMetricDef
+
+// exprNode is a node of a parsed --derived expression. eval returns NaN when
+// an operand has no data or a division is by zero.
+type exprNode interface {
+	eval(ws weekStats) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(weekStats) float64 { return float64(n) }
+
+// metricNode reads a metric by its CSV column name.
+type metricNode struct {
+	md metricDef
+}
+
+func (n metricNode) eval(ws weekStats) float64 {
+	if v, ok := metricValue(n.md, ws); ok {
+		return v
+	}
+	return math.NaN()
+}
+
+type negNode struct {
+	x exprNode
+}
+
+func (n negNode) eval(ws weekStats) float64 { return -n.x.eval(ws) }
+
+type binaryNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binaryNode) eval(ws weekStats) float64 {
+	l, r := n.l.eval(ws), n.r.eval(ws)
+	switch n.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	}
+	if r == 0 {
+		return math.NaN()
+	}
+	return l / r
+}
+
+// derivedSpecLines expands --derived values into one definition each: a
+// value is "name = expression", or @path to read one per line, with #
+// comments.
+func derivedSpecLines(specs []string) ([]string, error) {
+	var lines []string
+	for _, spec := range specs {
+		path, ok := strings.CutPrefix(spec, "@")
+		if !ok {
+			lines = append(lines, spec)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// parseDerivedSpec parses one "name = expression". Identifiers in the
+// expression are metric names: the weekly CSV columns, the stats metrics,
+// and the user-defined metrics registered so far, including earlier
+// derived ones.
+func parseDerivedSpec(spec string) (derivedMetricDef, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		return derivedMetricDef{}, fmt.Errorf("expected name = expression, got %q", spec)
+	}
+	if !isIdent(name) {
+		return derivedMetricDef{}, fmt.Errorf("derived metric name %q must be letters, digits, and underscores", name)
+	}
+	if _, exists := metricByName(name); exists {
+		return derivedMetricDef{}, fmt.Errorf("derived metric %q: a metric with that name already exists", name)
+	}
+
+	p := exprParser{src: expr}
+	root, err := p.parse()
+	if err != nil {
+		return derivedMetricDef{}, fmt.Errorf("derived metric %q: %w", name, err)
+	}
+	return derivedMetricDef{name: name, expr: expr, root: root, refs: p.refs}, nil
+}
+
+// isIdent reports whether s is a valid metric name: ASCII letters, digits,
+// and underscores, not starting with a digit.
+func isIdent(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i]) {
+			return false
+		}
+	}
+	return s != "" && isIdentStart(s[0])
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// exprParser is a recursive-descent parser for + - * / over numbers, metric
+// names, and parentheses, with the usual precedence.
+type exprParser struct {
+	src  string
+	pos  int
+	refs []string // metric names read, in order
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	n, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos+1)
+	}
+	return n, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// next returns the next non-space byte without consuming it, or 0 at the end.
+func (p *exprParser) next() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	l, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '+' || op == '-'; op = p.next() {
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '*' || op == '/'; op = p.next() {
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if p.next() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	c := p.next()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("expression ends early")
+	case c == '(':
+		p.pos++
+		n, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return n, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return numberNode(v), nil
+	case isIdentStart(c):
+		start := p.pos
+		for p.pos < len(p.src) && isIdentByte(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		md, ok := metricByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+		p.refs = append(p.refs, name)
+		return metricNode{md: md}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+}
+
+// registerDerivedMetric records a --derived definition and registers it as a
+// user-defined metric, after its inputs so it can be computed from them. It
+// is engineer-derived if any input is.
+func registerDerivedMetric(def derivedMetricDef) {
+	derivedMetricDefs = append(derivedMetricDefs, def)
+	registerUserMetric(def.name, slices.ContainsFunc(def.refs, func(ref string) bool {
+		md, _ := metricByName(ref)
+		return md.engineer
+	}))
+}
+
+// computeDerivedMetrics evaluates the derived metrics for one period from
+// its other values, in definition order. A period where an input has no data
+// gets NaN, like a --series week without points.
+func computeDerivedMetrics(ws *weekStats) {
+	for _, def := range derivedMetricDefs {
+		if ws.external == nil {
+			ws.external = make(map[string]float64)
+		}
+		v := def.root.eval(*ws)
+		if math.IsInf(v, 0) {
+			v = math.NaN()
+		}
+		ws.external[def.name] = v
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// drilldownPR is one PR in the HTML chart drill-down (--pr-drilldown). It is
+// marshalled into the report as JSON, so missing cycle times stay -1 and the
+// script renders them as "—".
+type drilldownPR struct {
+	Number      int     `json:"number"`
+	Title       string  `json:"title"`
+	Author      string  `json:"author"`
+	URL         string  `json:"url"`
+	MergedAt    string  `json:"mergedAt"`
+	Size        int     `json:"size"` // additions + deletions
+	CodingHours float64 `json:"codingHours"`
+	ReviewHours float64 `json:"reviewHours"`
+	Ona         bool    `json:"ona"`
+	Revert      bool    `json:"revert"`
+}
+
+// prURL links a PR (or Gerrit change) on its code review host. Commits from
+// --local-git have no host to link to.
+func prURL(cfg config, number int) string {
+	if cfg.provider == "git" {
+		return ""
+	}
+	if cfg.provider == "gerrit" {
+		return fmt.Sprintf("%s/c/%s/+/%d", strings.TrimSuffix(cfg.gerritURL, "/"), cfg.repo, number)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/pull/%d", cfg.owner, cfg.repo, number)
+}
+
+// buildDrilldown buckets PRs into the chart periods, newest merge last, so
+// a clicked chart point can list the PRs behind it. With redact
+// (--anonymize), number, title, and link are left empty since each
+// identifies the author.
+func buildDrilldown(cfg config, prs []enrichedPR, periods []weekRange, redact bool) [][]drilldownPR {
+	lists := make([][]drilldownPR, len(periods))
+	for i := range lists {
+		lists[i] = []drilldownPR{}
+	}
+	byMerge := slices.Clone(prs)
+	slices.SortStableFunc(byMerge, func(a, b enrichedPR) int { return int(a.mergedEpoch - b.mergedEpoch) })
+	for _, pr := range byMerge {
+		i := weekIndex(periods, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		d := drilldownPR{
+			Number:      pr.number,
+			Title:       pr.title,
+			Author:      pr.authorLogin,
+			URL:         prURL(cfg, pr.number),
+			MergedAt:    time.Unix(pr.mergedEpoch, 0).UTC().Format("2006-01-02 15:04"),
+			Size:        pr.additions + pr.deletions,
+			CodingHours: pr.codingTimeHours,
+			ReviewHours: pr.reviewTimeHours,
+			Ona:         pr.onaInvolved,
+			Revert:      pr.isRevert,
+		}
+		if redact {
+			d.Number, d.Title, d.URL = 0, "", ""
+		}
+		lists[i] = append(lists[i], d)
+	}
+	return lists
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// prReviewDetails is the review history fetched by the --enrich-reviews pass.
+// The search query only asks for the first review and ready-for-review
+// event, which keeps it cheap; this pass pages the full connections for the
+// PRs that survive filtering. A nil PR.ReviewDetails means the pass didn't
+// run for that PR (flag off, cached without it, or the fetch failed).
+type prReviewDetails struct {
+	Reviews   []prReview       `json:"reviews"`
+	Threads   []prReviewThread `json:"threads"`
+	Timeline  []prReviewEvent  `json:"timeline"`
+	Truncated bool             `json:"truncated"` // a connection had more than maxEnrichItems entries
+}
+
+type prReview struct {
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	State       string     `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
+	SubmittedAt *time.Time `json:"submittedAt"`
+}
+
+type prReviewThread struct {
+	IsResolved bool `json:"isResolved"`
+	Comments   struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+}
+
+// prReviewEvent is a review-related timeline event. RequestedReviewer is
+// empty for team review requests and for ready/draft events.
+type prReviewEvent struct {
+	Typename          string     `json:"__typename"`
+	CreatedAt         *time.Time `json:"createdAt"`
+	RequestedReviewer struct {
+		Login string `json:"login"`
+	} `json:"requestedReviewer"`
+}
+
+// maxEnrichItems caps each paged connection per PR so a bot-reviewed PR with
+// thousands of comments can't dominate the pass.
+const maxEnrichItems = 1000
+
+// reviewEventTypes are the timelineItems fetched by the enrichment pass.
+const reviewEventTypes = "[REVIEW_REQUESTED_EVENT, REVIEW_REQUEST_REMOVED_EVENT, READY_FOR_REVIEW_EVENT, CONVERT_TO_DRAFT_EVENT]"
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// enrichReviews runs the --enrich-reviews pass over the PRs that filterPRs
+// would keep and that don't carry review details yet. Each PR costs at least
+// one extra query. Returns how many PRs were enriched and how many failed.
+func enrichReviews(cfg config, prs []PR, excludeSet map[string]bool) (enriched, failed int) {
+	var indexes []int
+	for i, pr := range prs {
+		if pr.ReviewDetails == nil && !skipPR(pr, excludeSet) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return 0, 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching review history for %d PRs...\n", len(indexes))
+
+	var (
+		wg            sync.WaitGroup
+		sem           = make(chan struct{}, maxConcurrency)
+		enrichedCount atomic.Int64
+		failedCount   atomic.Int64
+	)
+	for _, idx := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pr := &prs[idx]
+			details, err := fetchReviewDetails(cfg, pr.Number)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  WARNING: Failed to fetch review history for PR #%d: %v\n", pr.Number, err)
+				failedCount.Add(1)
+				return
+			}
+			pr.ReviewDetails = details
+			if n := enrichedCount.Add(1); n%100 == 0 {
+				fmt.Fprintf(os.Stderr, "  Review history: %d/%d PRs\n", n, len(indexes))
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	return int(enrichedCount.Load()), int(failedCount.Load())
+}
+
+// fetchReviewDetails pages reviews, review threads, and review timeline
+// events for one PR. All three connections share a query; once one runs out
+// of pages it is dropped from the following queries.
+func fetchReviewDetails(cfg config, number int) (*prReviewDetails, error) {
+	details := &prReviewDetails{Reviews: []prReview{}, Threads: []prReviewThread{}, Timeline: []prReviewEvent{}}
+	var reviewsCursor, threadsCursor, timelineCursor string
+	moreReviews, moreThreads, moreTimeline := true, true, true
+
+	for moreReviews || moreThreads || moreTimeline {
+		var fields strings.Builder
+		if moreReviews {
+			fmt.Fprintf(&fields, `
+				reviews(first: 100%s) {
+					pageInfo { hasNextPage endCursor }
+					nodes { author { login } state submittedAt }
+				}`, afterArg(reviewsCursor))
+		}
+		if moreThreads {
+			fmt.Fprintf(&fields, `
+				reviewThreads(first: 100%s) {
+					pageInfo { hasNextPage endCursor }
+					nodes { isResolved comments { totalCount } }
+				}`, afterArg(threadsCursor))
+		}
+		if moreTimeline {
+			fmt.Fprintf(&fields, `
+				timelineItems(itemTypes: %s, first: 100%s) {
+					pageInfo { hasNextPage endCursor }
+					nodes {
+						__typename
+						... on ReviewRequestedEvent { createdAt requestedReviewer { ... on User { login } } }
+						... on ReviewRequestRemovedEvent { createdAt requestedReviewer { ... on User { login } } }
+						... on ReadyForReviewEvent { createdAt }
+						... on ConvertToDraftEvent { createdAt }
+					}
+				}`, reviewEventTypes, afterArg(timelineCursor))
+		}
+		query := fmt.Sprintf(`{
+			repository(owner: %q, name: %q) {
+				pullRequest(number: %d) {%s
+				}
+			}
+		}`, cfg.owner, cfg.repo, number, fields.String())
+
+		resp, err := graphqlQuery(cfg.token, query)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
+		}
+		var result struct {
+			Repository struct {
+				PullRequest struct {
+					Reviews *struct {
+						PageInfo pageInfo   `json:"pageInfo"`
+						Nodes    []prReview `json:"nodes"`
+					} `json:"reviews"`
+					ReviewThreads *struct {
+						PageInfo pageInfo         `json:"pageInfo"`
+						Nodes    []prReviewThread `json:"nodes"`
+					} `json:"reviewThreads"`
+					TimelineItems *struct {
+						PageInfo pageInfo        `json:"pageInfo"`
+						Nodes    []prReviewEvent `json:"nodes"`
+					} `json:"timelineItems"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return nil, fmt.Errorf("parse review history: %w", err)
+		}
+		pr := result.Repository.PullRequest
+
+		if moreReviews {
+			if pr.Reviews == nil {
+				return nil, fmt.Errorf("no reviews in response")
+			}
+			details.Reviews = append(details.Reviews, pr.Reviews.Nodes...)
+			reviewsCursor = pr.Reviews.PageInfo.EndCursor
+			moreReviews = pr.Reviews.PageInfo.HasNextPage && len(pr.Reviews.Nodes) > 0
+		}
+		if moreThreads {
+			if pr.ReviewThreads == nil {
+				return nil, fmt.Errorf("no reviewThreads in response")
+			}
+			details.Threads = append(details.Threads, pr.ReviewThreads.Nodes...)
+			threadsCursor = pr.ReviewThreads.PageInfo.EndCursor
+			moreThreads = pr.ReviewThreads.PageInfo.HasNextPage && len(pr.ReviewThreads.Nodes) > 0
+		}
+		if moreTimeline {
+			if pr.TimelineItems == nil {
+				return nil, fmt.Errorf("no timelineItems in response")
+			}
+			details.Timeline = append(details.Timeline, pr.TimelineItems.Nodes...)
+			timelineCursor = pr.TimelineItems.PageInfo.EndCursor
+			moreTimeline = pr.TimelineItems.PageInfo.HasNextPage && len(pr.TimelineItems.Nodes) > 0
+		}
+
+		if len(details.Reviews) >= maxEnrichItems && moreReviews ||
+			len(details.Threads) >= maxEnrichItems && moreThreads ||
+			len(details.Timeline) >= maxEnrichItems && moreTimeline {
+			details.Truncated = true
+		}
+		moreReviews = moreReviews && len(details.Reviews) < maxEnrichItems
+		moreThreads = moreThreads && len(details.Threads) < maxEnrichItems
+		moreTimeline = moreTimeline && len(details.Timeline) < maxEnrichItems
+	}
+	return details, nil
+}
+
+func afterArg(cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	return fmt.Sprintf(`, after: %q`, cursor)
+}
+
+// redactReviewerIdentities hashes reviewer and requested-reviewer logins,
+// the review-history part of redactPRIdentities.
+func (d *prReviewDetails) redactReviewerIdentities() {
+	hash := func(login *string) {
+		if l := strings.ToLower(*login); l != "" {
+			*login = hashLogin(l)
+		}
+	}
+	for i := range d.Reviews {
+		hash(&d.Reviews[i].Author.Login)
+	}
+	for i := range d.Timeline {
+		hash(&d.Timeline[i].RequestedReviewer.Login)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Exit codes, so CI can tell failure classes apart. 2 is left to the flag
+// package, which exits with it on unknown flags.
+const (
+	exitError       = 1 // invalid flags or configuration, and failures not classified below
+	exitAuth        = 3 // no token, or the token can't read the repository
+	exitRateLimit   = 4 // API rate limit exhausted after retries
+	exitPartialData = 5 // --strict: some data couldn't be fetched completely
+	exitNoData      = 6 // no merged PRs in the window
+	exitWrite       = 7 // an output file couldn't be written
+)
+
+// Error classes carried by wrapped errors; exitCodeFor maps them to exit codes.
+var (
+	errAuth        = errors.New("authentication failed")
+	errRateLimited = errors.New("rate limit exhausted")
+	errNoData      = errors.New("no data")
+)
+
+// authFailed and rateLimited record that an API request of the run gave up
+// for that reason, including ones whose error a caller only logged, so a run
+// that ends with no usable data can still exit with the cause.
+var authFailed, rateLimited atomic.Bool
+
+// strict is --strict: partial-data warnings end the run with exitPartialData.
+var strict bool
+
+// classifiedError attaches an error class to err without changing its message.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e classifiedError) Error() string        { return e.err.Error() }
+func (e classifiedError) Unwrap() error        { return e.err }
+func (e classifiedError) Is(target error) bool { return target == e.class }
+
+// classified marks err as belonging to class (errAuth, errRateLimited,
+// errNoData).
+func classified(class, err error) error {
+	return classifiedError{class: class, err: err}
+}
+
+// recordFailure notes the class of a request's final error for the run.
+func recordFailure(err error) error {
+	switch {
+	case errors.Is(err, errAuth):
+		authFailed.Store(true)
+	case errors.Is(err, errRateLimited):
+		rateLimited.Store(true)
+	}
+	return err
+}
+
+// httpStatusError classifies a failed GitHub response: 401 as errAuth, and
+// 403 or 429 with the rate limit used up as errRateLimited. It returns nil
+// for other statuses, which callers handle as before.
+func httpStatusError(resp *http.Response, body []byte) error {
+	msg := fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return classified(errAuth, msg)
+	case (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""):
+		return classified(errRateLimited, msg)
+	}
+	return nil
+}
+
+// exitCodeFor maps an error's class to its exit code, or fallback.
+func exitCodeFor(err error, fallback int) int {
+	switch {
+	case errors.Is(err, errAuth):
+		return exitAuth
+	case errors.Is(err, errRateLimited):
+		return exitRateLimit
+	case errors.Is(err, errNoData):
+		return exitNoData
+	}
+	return fallback
+}
+
+// fatalCode prints an error and exits with code.
+func fatalCode(code int, format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "ERROR: "+format+"\n", args...)
+	os.Exit(code)
+}
+
+// warnPartial logs a warning that the data is incomplete. With --strict it
+// ends the run instead, with exitRateLimit if a request ran out of rate
+// limit and exitPartialData otherwise.
+func warnPartial(format string, args ...any) {
+	if !strict {
+		fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+		return
+	}
+	code := exitPartialData
+	if rateLimited.Load() {
+		code = exitRateLimit
+	}
+	fatalCode(code, format+" (--strict)", args...)
+}
+
+// checkFetchFailures ends the run when no week could be fetched, with the
+// cause's exit code, and otherwise reports the weeks that failed as partial
+// data. Truncated weeks are among failed but were already reported.
+func checkFetchFailures(weeks, failed []weekRange, truncated []truncatedWeek) {
+	var dates []string
+	for _, wr := range failed {
+		if !slices.ContainsFunc(truncated, func(t truncatedWeek) bool { return t.week == wr }) {
+			dates = append(dates, wr.start.Format("2006-01-02"))
+		}
+	}
+	if len(dates) == 0 {
+		return
+	}
+	if len(dates) == len(weeks) {
+		code := exitError
+		switch {
+		case authFailed.Load():
+			code = exitAuth
+		case rateLimited.Load():
+			code = exitRateLimit
+		}
+		fatalCode(code, "None of the %d week(s) could be fetched", len(weeks))
+	}
+	warnPartial("%d of %d week(s) failed to fetch and may be incomplete: %s", len(dates), len(weeks), strings.Join(dates, ", "))
+}
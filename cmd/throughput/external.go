@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seriesPoint is one dated observation of an external time series.
+type seriesPoint struct {
+	date  time.Time
+	value float64
+}
+
+// seriesSource loads dated observations for a user-defined metric. New sources
+// (e.g. a native Sentry client) can implement this and be returned from
+// newSeriesSource without touching the aggregation, stats, or HTML code.
+type seriesSource interface {
+	load() ([]seriesPoint, error)
+}
+
+// externalSeriesDef describes a user-defined weekly metric from --series.
+type externalSeriesDef struct {
+	name   string
+	agg    string // "sum" or "mean": how points combine into a week (and weeks into a month)
+	source seriesSource
+}
+
+// externalSeriesDefs holds the series registered for this run, in flag order.
+var externalSeriesDefs []externalSeriesDef
+
+// seriesFlag collects repeated --series values.
+type seriesFlag []string
+
+func (f *seriesFlag) String() string     { return strings.Join(*f, ", ") }
+func (f *seriesFlag) Set(v string) error { *f = append(*f, v); return nil }
+
+// parseSeriesSpec parses "name[:sum|mean]=source" where source is a CSV path
+// or an http(s) URL returning JSON.
+func parseSeriesSpec(spec string) (externalSeriesDef, error) {
+	lhs, src, ok := strings.Cut(spec, "=")
+	if !ok || lhs == "" || src == "" {
+		return externalSeriesDef{}, fmt.Errorf("expected name[:sum|mean]=source, got %q", spec)
+	}
+	name, agg, _ := strings.Cut(lhs, ":")
+	if agg == "" {
+		agg = "sum"
+	}
+	if agg != "sum" && agg != "mean" {
+		return externalSeriesDef{}, fmt.Errorf("series %q: aggregation must be sum or mean", name)
+	}
+	return externalSeriesDef{name: name, agg: agg, source: newSeriesSource(src)}, nil
+}
+
+func newSeriesSource(src string) seriesSource {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return jsonURLSource{url: src}
+	}
+	return csvFileSource{path: src}
+}
+
+// registerExternalSeries records a --series for loading and registers it as a
+// user-defined metric.
+func registerExternalSeries(def externalSeriesDef) {
+	externalSeriesDefs = append(externalSeriesDefs, def)
+	registerUserMetric(def.name, false)
+}
+
+// csvFileSource reads "date,value" rows (header optional). Dates are YYYY-MM-DD
+// or RFC 3339.
+type csvFileSource struct {
+	path string
+}
+
+func (s csvFileSource) load() ([]seriesPoint, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	var points []seriesPoint
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", s.path, line, err)
+		}
+		if len(rec) < 2 {
+			continue
+		}
+		date, err := parseIncidentTime(rec[0])
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("%s line %d: %w", s.path, line, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", s.path, line, err)
+		}
+		points = append(points, seriesPoint{date: date, value: v})
+	}
+	return points, nil
+}
+
+// jsonURLSource fetches a JSON array of {"date": ..., "value": ...} objects.
+// If THROUGHPUT_SERIES_TOKEN is set it is sent as a bearer token.
+type jsonURLSource struct {
+	url string
+}
+
+func (s jsonURLSource) load() ([]seriesPoint, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if tok := os.Getenv("THROUGHPUT_SERIES_TOKEN"); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d: %s", s.url, resp.StatusCode, string(data[:min(200, len(data))]))
+	}
+
+	var raw []struct {
+		Date  string  `json:"date"`
+		Value float64 `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	points := make([]seriesPoint, 0, len(raw))
+	for _, r := range raw {
+		date, err := parseIncidentTime(r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("bad date %q: %w", r.Date, err)
+		}
+		points = append(points, seriesPoint{date: date, value: r.Value})
+	}
+	return points, nil
+}
+
+// bucketSeries combines points into weekly values using the series
+// aggregation. Weeks without points are NaN (treated as missing).
+func bucketSeries(points []seriesPoint, agg string, weeks []weekRange) []float64 {
+	sums := make([]float64, len(weeks))
+	counts := make([]int, len(weeks))
+	for _, p := range points {
+		epoch := p.date.Unix()
+		for i, wr := range weeks {
+			if epoch >= wr.start.Unix() && epoch <= wr.end.Unix()+86399 {
+				sums[i] += p.value
+				counts[i]++
+				break
+			}
+		}
+	}
+	out := make([]float64, len(weeks))
+	for i := range weeks {
+		switch {
+		case counts[i] == 0:
+			out[i] = math.NaN()
+		case agg == "mean":
+			out[i] = sums[i] / float64(counts[i])
+		default:
+			out[i] = sums[i]
+		}
+	}
+	return out
+}
+
+// formatUserMetric writes a user-defined metric's weekly CSV cell: the value
+// in its shortest form, negative values included, and empty without data.
+func formatUserMetric(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +16,8 @@ import (
 type PR struct {
 	Number       int       `json:"number"`
 	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	HeadRefName  string    `json:"headRefName"`
 	CreatedAt    time.Time `json:"createdAt"`
 	MergedAt     time.Time `json:"mergedAt"`
 	IsDraft      bool      `json:"isDraft"`
@@ -23,6 +28,12 @@ type PR struct {
 		Login    string `json:"login"`
 		Typename string `json:"__typename"`
 	} `json:"author"`
+	// MergedBy is who merged the PR, for --attribution. Empty for PRs cached
+	// before it was fetched.
+	MergedBy struct {
+		Login    string `json:"login"`
+		Typename string `json:"__typename"`
+	} `json:"mergedBy"`
 	Commits struct {
 		TotalCount int `json:"totalCount"`
 		Nodes      []struct {
@@ -32,21 +43,85 @@ type PR struct {
 			} `json:"commit"`
 		} `json:"nodes"`
 	} `json:"commits"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Files struct {
+		Nodes []struct {
+			Path string `json:"path"`
+		} `json:"nodes"`
+	} `json:"files"`
 	Reviews struct {
 		Nodes []struct {
 			SubmittedAt *time.Time `json:"submittedAt"`
 		} `json:"nodes"`
 	} `json:"reviews"`
+	// ReviewStates lists the first reviews' states and authors, for review
+	// coverage. Nil for PRs cached before it was fetched and with --local-git.
+	ReviewStates *struct {
+		Nodes []prReviewState `json:"nodes"`
+	} `json:"reviewStates"`
 	TimelineItems struct {
 		Nodes []struct {
 			CreatedAt *time.Time `json:"createdAt"`
 		} `json:"nodes"`
 	} `json:"timelineItems"`
+	// CloseEvents are the ClosedEvents and ReopenedEvents in order, for
+	// leaving the time a PR spent closed out of its cycle times.
+	CloseEvents struct {
+		Nodes []struct {
+			Typename  string     `json:"__typename"`
+			CreatedAt *time.Time `json:"createdAt"`
+		} `json:"nodes"`
+	} `json:"closeEvents"`
+	// ForcePushes holds the first HeadRefForcePushedEvent. Its beforeCommit is
+	// the branch head before the first rewrite, whose authoredDate survives
+	// rebases that reset the dates of the current commits.
+	ForcePushes struct {
+		Nodes []struct {
+			BeforeCommit *struct {
+				AuthoredDate time.Time `json:"authoredDate"`
+			} `json:"beforeCommit"`
+		} `json:"nodes"`
+	} `json:"forcePushes"`
+	// HeadRefOid and MergeCommit locate the PR's commits in a --mirror clone.
+	HeadRefOid  string `json:"headRefOid"`
+	MergeCommit *struct {
+		Oid string `json:"oid"`
+	} `json:"mergeCommit"`
+	// ClosingIssuesReferences counts issues the PR closes when merged
+	// ("Fixes #123" or a manual link in the sidebar).
+	ClosingIssuesReferences struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"closingIssuesReferences"`
+	// ReviewDetails is filled by the --enrich-reviews pass (see enrich.go),
+	// not by the search query.
+	ReviewDetails *prReviewDetails `json:"reviewDetails,omitempty"`
+}
+
+// prReviewState is one review of PR.ReviewStates.
+type prReviewState struct {
+	State  string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// commitNode is the element type of PR.Commits.Nodes, for providers that
+// build commit lists themselves.
+type commitNode = struct {
+	Commit struct {
+		AuthoredDate time.Time `json:"authoredDate"`
+		Message      string    `json:"message"`
+	} `json:"commit"`
 }
 
 type searchResponse struct {
 	Search struct {
-		PageInfo struct {
+		IssueCount int `json:"issueCount"`
+		PageInfo   struct {
 			HasNextPage bool   `json:"hasNextPage"`
 			EndCursor   string `json:"endCursor"`
 		} `json:"pageInfo"`
@@ -56,13 +131,27 @@ type searchResponse struct {
 
 const maxConcurrency = 10
 
-// fetchAllPRs fetches merged PRs for all weeks concurrently.
-func fetchAllPRs(cfg config, weeks []weekRange) []PR {
+// truncatedWeek is a week with more merged PRs than could be read, even
+// after splitting its search and listing the repository.
+type truncatedWeek struct {
+	week    weekRange
+	matched int // PRs the search matched
+	fetched int
+}
+
+// fetchAllPRs fetches merged PRs for all weeks concurrently. Weeks still
+// truncated after fetchWeekPRs split their search are read again with
+// fetchPullsByRepository. It also returns the weeks whose results may be
+// incomplete because a query failed or the week stayed truncated, and the
+// truncated weeks alone.
+func fetchAllPRs(cfg config, weeks []weekRange) ([]PR, []weekRange, []truncatedWeek) {
 	var (
-		mu       sync.Mutex
-		allPRs   []PR
-		wg       sync.WaitGroup
-		sem      = make(chan struct{}, maxConcurrency)
+		mu           sync.Mutex
+		byWeek       = make([][]PR, len(weeks))
+		failed       []weekRange
+		truncated    []truncatedWeek
+		wg           sync.WaitGroup
+		sem          = make(chan struct{}, maxConcurrency)
 		totalFetched atomic.Int64
 	)
 
@@ -73,12 +162,17 @@ func fetchAllPRs(cfg config, weeks []weekRange) []PR {
 			defer wg.Done()
 			defer func() { <-sem }() // release semaphore
 
-			prs := fetchWeekPRs(cfg, wr)
+			prs, matched, err := fetchWeekPRs(cfg, wr)
 			weekCount := len(prs)
 			total := totalFetched.Add(int64(weekCount))
 
 			mu.Lock()
-			allPRs = append(allPRs, prs...)
+			byWeek[idx] = prs
+			if err != nil {
+				failed = append(failed, wr)
+			} else if matched > weekCount {
+				truncated = append(truncated, truncatedWeek{week: wr, matched: matched, fetched: weekCount})
+			}
 			mu.Unlock()
 
 			fmt.Fprintf(os.Stderr, "  Week %s: %d PRs (total: %d)\n",
@@ -88,20 +182,125 @@ func fetchAllPRs(cfg config, weeks []weekRange) []PR {
 
 	wg.Wait()
 
+	if len(truncated) > 0 {
+		truncated = listTruncatedWeeks(cfg, weeks, byWeek, truncated)
+		for _, t := range truncated {
+			failed = append(failed, t.week)
+		}
+	}
+
+	var allPRs []PR
+	for _, prs := range byWeek {
+		allPRs = append(allPRs, prs...)
+	}
 	fmt.Fprintf(os.Stderr, "Total PRs fetched: %d\n", len(allPRs))
-	return allPRs
+	sort.Slice(truncated, func(i, j int) bool { return truncated[i].week.start.Before(truncated[j].week.start) })
+	return allPRs, failed, truncated
 }
 
-func fetchWeekPRs(cfg config, wr weekRange) []PR {
-	rangeStart := wr.start.Format("2006-01-02")
-	rangeEnd := wr.end.Format("2006-01-02")
+// listTruncatedWeeks reads the weeks a split search couldn't read in full
+// with fetchPullsByRepository, replacing their PRs in byWeek. It returns
+// the weeks still truncated, which is all of them when the listing failed.
+func listTruncatedWeeks(cfg config, weeks []weekRange, byWeek [][]PR, truncated []truncatedWeek) []truncatedWeek {
+	var ranges []weekRange
+	for _, t := range truncated {
+		ranges = append(ranges, t.week)
+	}
+	fmt.Fprintf(os.Stderr, "%d week(s) have more merged PRs per hour than the search API returns; listing the repository's PRs for them instead\n", len(ranges))
+	prs, failed := fetchPullsByRepository(cfg, ranges)
+	if len(failed) > 0 {
+		return truncated
+	}
+	for _, t := range truncated {
+		i := slices.IndexFunc(weeks, func(wr weekRange) bool { return wr == t.week })
+		byWeek[i] = nil
+	}
+	for _, pr := range prs {
+		if i := weekIndex(weeks, pr.MergedAt.Unix()); i >= 0 {
+			byWeek[i] = append(byWeek[i], pr)
+		}
+	}
+	return nil
+}
 
+// truncationNote describes the weeks that couldn't be read in full, for
+// stderr and the filter notes.
+func truncationNote(weeks []truncatedWeek) string {
+	var parts []string
+	missing := 0
+	for _, t := range weeks {
+		parts = append(parts, fmt.Sprintf("%s (%d of %d)", t.week.start.Format("2006-01-02"), t.fetched, t.matched))
+		missing += t.matched - t.fetched
+	}
+	return fmt.Sprintf("Search API limit: %d week(s) matched more than %d merged PRs in one hour and listing the repository failed; they are undercounted by %d PR(s): %s. Rerun with --fetch pulls to read them all",
+		len(weeks), searchResultLimit, missing, strings.Join(parts, ", "))
+}
+
+// fetchWeekPRs returns the PRs merged in one week and how many the search
+// matched. A week with more PRs than the search API's 1000-result limit
+// returns is searched again day by day, and a day still over the limit hour
+// by hour; the matched count is then the windows' total, which is more than
+// were returned only when an hour was cut off. On error, the PRs fetched
+// before the failure are returned along with it.
+func fetchWeekPRs(cfg config, wr weekRange) ([]PR, int, error) {
+	label := wr.start.Format("2006-01-02")
+	prs, matched, err := searchMergedPRs(cfg, label+".."+wr.end.Format("2006-01-02"), label)
+	if err != nil || matched <= len(prs) {
+		return prs, matched, err
+	}
+	fmt.Fprintf(os.Stderr, "  Week %s matched %d PRs, more than one search returns; searching it day by day\n", label, matched)
+	return fetchSplitPRs(cfg, wr.start, wr.end.AddDate(0, 0, 1), 24*time.Hour)
+}
+
+// fetchSplitPRs searches [from, to) in windows of step (a day or an hour),
+// splitting a day that is cut off into hours. PRs are deduplicated by
+// number, since a PR can move between pages while the windows are read.
+func fetchSplitPRs(cfg config, from, to time.Time, step time.Duration) ([]PR, int, error) {
+	var prs []PR
+	seen := make(map[int]bool)
+	matched := 0
+	for t := from; t.Before(to); t = t.Add(step) {
+		var window []PR
+		var n int
+		var err error
+		if step == 24*time.Hour {
+			day := t.Format("2006-01-02")
+			window, n, err = searchMergedPRs(cfg, day, day)
+			if err == nil && n > len(window) {
+				fmt.Fprintf(os.Stderr, "  Day %s matched %d PRs; searching it hour by hour\n", day, n)
+				window, n, err = fetchSplitPRs(cfg, t, t.Add(step), time.Hour)
+			}
+		} else {
+			const layout = "2006-01-02T15:04:05+00:00"
+			hour := t.UTC().Format(layout) + ".." + t.Add(step-time.Second).UTC().Format(layout)
+			window, n, err = searchMergedPRs(cfg, hour, t.UTC().Format("2006-01-02 15:04"))
+		}
+		for _, pr := range window {
+			if !seen[pr.Number] {
+				seen[pr.Number] = true
+				prs = append(prs, pr)
+			}
+		}
+		matched += n
+		if err != nil {
+			return prs, matched, err
+		}
+	}
+	return prs, matched, nil
+}
+
+// searchMergedPRs pages through the PRs merged in mergedRange, a date,
+// datetime, or range in the search syntax's merged: qualifier, and returns
+// them with how many the search matched. label names the range in errors.
+func searchMergedPRs(cfg config, mergedRange, label string) ([]PR, int, error) {
 	searchQuery := fmt.Sprintf(
-		`repo:%s/%s is:pr is:merged base:%s merged:%s..%s`,
-		cfg.owner, cfg.repo, cfg.branch, rangeStart, rangeEnd,
+		`repo:%s/%s is:pr is:merged base:%s merged:%s`,
+		cfg.owner, cfg.repo, cfg.branch, mergedRange,
 	)
 
 	var prs []PR
+	var partialErr error
+	matched := 0
 	hasNext := true
 	cursor := ""
 
@@ -113,64 +312,32 @@ func fetchWeekPRs(cfg config, wr weekRange) []PR {
 
 		query := fmt.Sprintf(`{
 			search(query: %q, type: ISSUE, first: 100%s) {
+				issueCount
 				pageInfo { hasNextPage endCursor }
 				nodes {
-					... on PullRequest {
-						number
-						title
-						createdAt
-						mergedAt
-						isDraft
-						additions
-						deletions
-						changedFiles
-						author {
-							login
-							... on Bot { __typename }
-							... on User { __typename }
-						}
-						commits(first: 50) {
-							totalCount
-							nodes {
-								commit {
-									authoredDate
-									message
-								}
-							}
-						}
-						reviews(first: 1) {
-							nodes {
-								submittedAt
-							}
-						}
-						timelineItems(itemTypes: READY_FOR_REVIEW_EVENT, first: 1) {
-							nodes {
-								... on ReadyForReviewEvent {
-									createdAt
-								}
-							}
-						}
-					}
+					... on PullRequest {%s}
 				}
 			}
-		}`, searchQuery, afterClause)
+		}`, searchQuery, afterClause, prFields(cfg))
 
 		resp, err := graphqlQuery(cfg.token, query)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: GraphQL query failed for week %s: %v\n", rangeStart, err)
-			return prs
+			fmt.Fprintf(os.Stderr, "ERROR: GraphQL query failed for %s: %v\n", label, err)
+			return prs, matched, err
 		}
 
 		// Log non-fatal errors
 		if len(resp.Errors) > 0 {
-			fmt.Fprintf(os.Stderr, "  GraphQL error (week %s): %s\n", rangeStart, resp.Errors[0].Message)
+			fmt.Fprintf(os.Stderr, "  GraphQL error (%s): %s\n", label, resp.Errors[0].Message)
+			partialErr = fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
 		}
 
 		var sr searchResponse
 		if err := json.Unmarshal(resp.Data, &sr); err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: Failed to parse search response for week %s: %v\n", rangeStart, err)
-			return prs
+			fmt.Fprintf(os.Stderr, "ERROR: Failed to parse search response for %s: %v\n", label, err)
+			return prs, matched, err
 		}
+		matched = sr.Search.IssueCount
 
 		for _, raw := range sr.Search.Nodes {
 			var pr PR
@@ -188,7 +355,97 @@ func fetchWeekPRs(cfg config, wr weekRange) []PR {
 		cursor = sr.Search.PageInfo.EndCursor
 	}
 
-	return prs
+	return prs, matched, partialErr
+}
+
+// prFields is the PullRequest selection shared by the search and
+// --fetch pulls queries.
+func prFields(cfg config) string {
+	// With --mirror, commits are read from the local clone, so only the
+	// count is fetched
+	commitsField := `commits(first: 50) {
+							totalCount
+							nodes {
+								commit {
+									authoredDate
+									message
+								}
+							}
+						}`
+	if cfg.mirrorDir != "" {
+		commitsField = `commits { totalCount }`
+	}
+
+	return fmt.Sprintf(`
+						number
+						title
+						body
+						headRefName
+						createdAt
+						mergedAt
+						isDraft
+						additions
+						deletions
+						changedFiles
+						headRefOid
+						mergeCommit { oid }
+						closingIssuesReferences { totalCount }
+						author {
+							login
+							... on Bot { __typename }
+							... on User { __typename }
+						}
+						mergedBy {
+							login
+							... on Bot { __typename }
+							... on User { __typename }
+						}
+						%s
+						labels(first: 20) {
+							nodes {
+								name
+							}
+						}
+						files(first: 100) {
+							nodes {
+								path
+							}
+						}
+						reviews(first: 1) {
+							nodes {
+								submittedAt
+							}
+						}
+						reviewStates: reviews(first: 30) {
+							nodes {
+								state
+								author { login }
+							}
+						}
+						timelineItems(itemTypes: READY_FOR_REVIEW_EVENT, first: 1) {
+							nodes {
+								... on ReadyForReviewEvent {
+									createdAt
+								}
+							}
+						}
+						closeEvents: timelineItems(itemTypes: [CLOSED_EVENT, REOPENED_EVENT], first: 20) {
+							nodes {
+								__typename
+								... on ClosedEvent { createdAt }
+								... on ReopenedEvent { createdAt }
+							}
+						}
+						forcePushes: timelineItems(itemTypes: HEAD_REF_FORCE_PUSHED_EVENT, first: 1) {
+							nodes {
+								... on HeadRefForcePushedEvent {
+									beforeCommit {
+										authoredDate
+									}
+								}
+							}
+						}
+`, commitsField)
 }
 
 // backfillFirstCommits fetches the first commit for PRs with >50 commits.
@@ -197,7 +454,7 @@ func fetchWeekPRs(cfg config, wr weekRange) []PR {
 func backfillFirstCommits(cfg config, prs []PR) {
 	// Find PRs that need backfill
 	type backfillItem struct {
-		index int
+		index  int
 		number int
 	}
 	var items []backfillItem
@@ -273,3 +530,110 @@ func backfillFirstCommits(cfg config, prs []PR) {
 
 	wg.Wait()
 }
+
+// paginateCommits replaces the first-page commit list with the full commit
+// history for PRs with more commits than listed (more than 50, or any with
+// --mirror when the clone lacks the PR), fetching up to maxCommits per PR.
+// Ona co-author trailers on later commits are otherwise missed. Returns the
+// number of PRs paginated and how many still exceed maxCommits.
+func paginateCommits(cfg config, prs []PR, maxCommits int) (paginated, truncated int) {
+	var indexes []int
+	for i, pr := range prs {
+		if pr.Commits.TotalCount > len(pr.Commits.Nodes) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return 0, 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Paginating commits for %d PRs (max %d per PR)...\n", len(indexes), maxCommits)
+
+	var (
+		wg             sync.WaitGroup
+		sem            = make(chan struct{}, maxConcurrency)
+		paginatedCount atomic.Int64
+		truncatedCount atomic.Int64
+	)
+	for _, idx := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pr := &prs[idx]
+			var all []struct {
+				Commit struct {
+					AuthoredDate time.Time `json:"authoredDate"`
+					Message      string    `json:"message"`
+				} `json:"commit"`
+			}
+			cursor := ""
+			for len(all) < maxCommits {
+				afterClause := ""
+				if cursor != "" {
+					afterClause = fmt.Sprintf(`, after: %q`, cursor)
+				}
+				query := fmt.Sprintf(`{
+					repository(owner: %q, name: %q) {
+						pullRequest(number: %d) {
+							commits(first: %d%s) {
+								pageInfo { hasNextPage endCursor }
+								nodes {
+									commit {
+										authoredDate
+										message
+									}
+								}
+							}
+						}
+					}
+				}`, cfg.owner, cfg.repo, pr.Number, min(100, maxCommits-len(all)), afterClause)
+
+				resp, err := graphqlQuery(cfg.token, query)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "  WARNING: Failed to paginate commits for PR #%d: %v\n", pr.Number, err)
+					return
+				}
+				var result struct {
+					Repository struct {
+						PullRequest struct {
+							Commits struct {
+								PageInfo struct {
+									HasNextPage bool   `json:"hasNextPage"`
+									EndCursor   string `json:"endCursor"`
+								} `json:"pageInfo"`
+								Nodes []struct {
+									Commit struct {
+										AuthoredDate time.Time `json:"authoredDate"`
+										Message      string    `json:"message"`
+									} `json:"commit"`
+								} `json:"nodes"`
+							} `json:"commits"`
+						} `json:"pullRequest"`
+					} `json:"repository"`
+				}
+				if err := json.Unmarshal(resp.Data, &result); err != nil {
+					fmt.Fprintf(os.Stderr, "  WARNING: Failed to parse commits for PR #%d: %v\n", pr.Number, err)
+					return
+				}
+				commits := result.Repository.PullRequest.Commits
+				all = append(all, commits.Nodes...)
+				if !commits.PageInfo.HasNextPage || len(commits.Nodes) == 0 {
+					break
+				}
+				cursor = commits.PageInfo.EndCursor
+			}
+
+			pr.Commits.Nodes = all
+			paginatedCount.Add(1)
+			if pr.Commits.TotalCount > len(all) {
+				truncatedCount.Add(1)
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	return int(paginatedCount.Load()), int(truncatedCount.Load())
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGitHub answers search and repository.pullRequests queries from a fixed
+// list of merged PRs, capping each search at searchResultLimit like GitHub.
+type fakeGitHub struct {
+	merged   []time.Time // merge time of PR i+1
+	searches []string    // merged: ranges searched
+	listed   bool        // repository.pullRequests was read
+}
+
+var (
+	fakeMergedRe = regexp.MustCompile(`merged:([^\s"\\]+)`)
+	fakeAfterRe  = regexp.MustCompile(`after: \\?"(\d+)\\?"`)
+)
+
+func (f *fakeGitHub) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body graphqlRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	offset := 0
+	if m := fakeAfterRe.FindStringSubmatch(body.Query); m != nil {
+		offset, _ = strconv.Atoi(m[1])
+	}
+
+	var data any
+	if m := fakeMergedRe.FindStringSubmatch(body.Query); m != nil {
+		f.searches = append(f.searches, m[1])
+		from, to := fakeMergedRange(m[1])
+		var nums []int
+		for i, t := range f.merged {
+			if !t.Before(from) && t.Before(to) {
+				nums = append(nums, i+1)
+			}
+		}
+		readable := min(len(nums), searchResultLimit)
+		end := min(offset+100, readable)
+		data = map[string]any{"search": map[string]any{
+			"issueCount": len(nums),
+			"pageInfo":   map[string]any{"hasNextPage": end < readable, "endCursor": strconv.Itoa(end)},
+			"nodes":      f.nodes(nums[offset:end]),
+		}}
+	} else {
+		f.listed = true
+		nums := make([]int, len(f.merged))
+		for i := range nums {
+			nums[i] = i + 1
+		}
+		sort.Slice(nums, func(i, j int) bool { return f.merged[nums[i]-1].After(f.merged[nums[j]-1]) })
+		end := min(offset+100, len(nums))
+		data = map[string]any{"repository": map[string]any{"pullRequests": map[string]any{
+			"pageInfo": map[string]any{"hasNextPage": end < len(nums), "endCursor": strconv.Itoa(end)},
+			"nodes":    f.nodes(nums[offset:end]),
+		}}}
+	}
+	out, _ := json.Marshal(map[string]any{"data": data})
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(out))), Header: http.Header{}}, nil
+}
+
+func (f *fakeGitHub) nodes(nums []int) []map[string]any {
+	var nodes []map[string]any
+	for _, n := range nums {
+		at := f.merged[n-1].Format(time.RFC3339)
+		nodes = append(nodes, map[string]any{"number": n, "mergedAt": at, "updatedAt": at})
+	}
+	return nodes
+}
+
+// fakeMergedRange parses a merged: qualifier (a date, a date range, or a
+// datetime range, all inclusive) into [from, to).
+func fakeMergedRange(q string) (time.Time, time.Time) {
+	lo, hi, ok := strings.Cut(q, "..")
+	if !ok {
+		hi = lo
+	}
+	if strings.Contains(lo, "T") {
+		from, _ := time.Parse(time.RFC3339, lo)
+		to, _ := time.Parse(time.RFC3339, hi)
+		return from, to.Add(time.Second)
+	}
+	from, _ := time.Parse("2006-01-02", lo)
+	to, _ := time.Parse("2006-01-02", hi)
+	return from, to.AddDate(0, 0, 1)
+}
+
+// withFakeGitHub routes GraphQL requests to f for the rest of the test.
+func withFakeGitHub(t *testing.T, f *fakeGitHub) {
+	saved := httpClient.Transport
+	httpClient.Transport = f
+	t.Cleanup(func() { httpClient.Transport = saved })
+}
+
+// spread returns n merge times evenly spaced over [from, from+d).
+func spread(from time.Time, d time.Duration, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = from.Add(d * time.Duration(i) / time.Duration(n))
+	}
+	return times
+}
+
+func TestFetchAllPRsSplitsTruncatedWeeks(t *testing.T) {
+	weeks := computeWeekRanges(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), 2)
+	busy := weeks[1].start
+	tests := []struct {
+		name       string
+		merged     []time.Time
+		wantHourly bool
+		wantListed bool
+	}{
+		{
+			name:   "days under the limit",
+			merged: append(spread(weeks[0].start, 7*24*time.Hour, 300), spread(busy, 7*24*time.Hour, 2100)...),
+		},
+		{
+			name:       "one day over the limit",
+			merged:     append(spread(busy, 24*time.Hour, 1500), spread(busy.AddDate(0, 0, 1), 6*24*time.Hour, 600)...),
+			wantHourly: true,
+		},
+		{
+			name:       "one hour over the limit",
+			merged:     append(spread(busy, time.Hour, 1100), spread(busy.AddDate(0, 0, 1), 24*time.Hour, 50)...),
+			wantHourly: true,
+			wantListed: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeGitHub{merged: tt.merged}
+			withFakeGitHub(t, f)
+			// A repository per case, so graphqlQuery doesn't share responses.
+			cfg := config{owner: "acme", repo: fmt.Sprintf("split%d", i), branch: "main"}
+			prs, failed, truncated := fetchAllPRs(cfg, weeks)
+
+			if len(failed) > 0 || len(truncated) > 0 {
+				t.Errorf("failed = %v, truncated = %v, want none", failed, truncated)
+			}
+			seen := make(map[int]bool)
+			for _, pr := range prs {
+				if seen[pr.Number] {
+					t.Errorf("PR %d returned twice", pr.Number)
+				}
+				seen[pr.Number] = true
+			}
+			if len(seen) != len(tt.merged) {
+				t.Errorf("fetched %d distinct PRs, want %d", len(seen), len(tt.merged))
+			}
+			hourly := false
+			for _, q := range f.searches {
+				hourly = hourly || strings.Contains(q, "T")
+			}
+			if hourly != tt.wantHourly || f.listed != tt.wantListed {
+				t.Errorf("hourly searches = %v, listed repository = %v, want %v, %v", hourly, f.listed, tt.wantHourly, tt.wantListed)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// filterAudit records what each step of the filter pipeline removed. It
+// feeds the stderr audit, the FilterNotes lines, and the report's Data
+// Quality table.
+type filterAudit struct {
+	steps  []filterStep
+	redact bool // --anonymize: count removed PRs without listing their numbers
+}
+
+// filterStep is one filter's removals. Each PR or period is charged to the
+// first step that removes it.
+type filterStep struct {
+	name      string   // e.g. "Bot authors"
+	unit      string   // "PR", "week", "month", or "sprint"
+	count     int      // PRs or periods removed
+	removed   []string // "#123" or a period's start date; empty when redacted
+	note      string   // FilterNotes line; empty to leave the step out
+	statsOnly bool     // kept in the chart, left out of the before/after comparison
+}
+
+// prFilter is a PR-level step of the pipeline. note builds the FilterNotes
+// line from the number of PRs removed; nil leaves the step out of them.
+type prFilter struct {
+	name string
+	drop func(pr PR) bool
+	note func(removed int) string
+}
+
+// basePRFilters are the filters every fetched PR goes through, in order:
+// bots, excluded users (case-insensitive), PRs without mergedAt, and drafts
+// (matching GetDX behavior). With redact, the excluded users' note gives
+// their number instead of their logins.
+func basePRFilters(excludeSet map[string]bool, redact bool) []prFilter {
+	return []prFilter{
+		{
+			name: "Bot authors",
+			drop: func(pr PR) bool { return pr.Author.Typename == "Bot" },
+			note: func(n int) string { return fmt.Sprintf("Excluded %d bot-authored PR(s)", n) },
+		},
+		{
+			name: "Excluded users",
+			drop: func(pr PR) bool { return excludeSet[strings.ToLower(pr.Author.Login)] },
+			note: func(n int) string {
+				if len(excludeSet) == 0 {
+					return ""
+				}
+				if redact {
+					return fmt.Sprintf("Excluded %d user(s) (%d PRs)", len(excludeSet), n)
+				}
+				var users []string
+				for u := range excludeSet {
+					users = append(users, u)
+				}
+				sort.Strings(users)
+				return fmt.Sprintf("Excluded users: %s (%d PRs)", strings.Join(users, ", "), n)
+			},
+		},
+		{
+			name: "Not merged",
+			drop: func(pr PR) bool { return pr.MergedAt.IsZero() },
+		},
+		{
+			name: "Drafts",
+			drop: func(pr PR) bool { return pr.IsDraft },
+			note: func(n int) string { return fmt.Sprintf("Excluded %d draft PR(s)", n) },
+		},
+	}
+}
+
+// applyPRFilters returns the PRs no filter drops.
+func (a *filterAudit) applyPRFilters(prs []PR, filters []prFilter) []PR {
+	steps := make([]filterStep, len(filters))
+	for i, f := range filters {
+		steps[i] = filterStep{name: f.name, unit: "PR"}
+	}
+	var kept []PR
+	for _, pr := range prs {
+		dropped := false
+		for i, f := range filters {
+			if f.drop(pr) {
+				steps[i].count++
+				if !a.redact {
+					steps[i].removed = append(steps[i].removed, fmt.Sprintf("#%d", pr.Number))
+				}
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			kept = append(kept, pr)
+		}
+	}
+	for i, f := range filters {
+		if f.note != nil {
+			steps[i].note = f.note(steps[i].count)
+		}
+	}
+	a.steps = append(a.steps, steps...)
+	return kept
+}
+
+// dropAuthors removes the PRs of the authors in excludeSet as one step.
+func (a *filterAudit) dropAuthors(prs []enrichedPR, name, note string, excludeSet map[string]bool) []enrichedPR {
+	step := filterStep{name: name, unit: "PR", note: note}
+	for _, pr := range prs {
+		if excludeSet[pr.authorLogin] {
+			step.count++
+			if !a.redact {
+				step.removed = append(step.removed, fmt.Sprintf("#%d", pr.number))
+			}
+		}
+	}
+	a.steps = append(a.steps, step)
+	return withoutAuthors(prs, excludeSet)
+}
+
+// dropPeriods records the periods keep rejects as one step and returns the
+// indices of the others. note builds the FilterNotes line from the number
+// removed and is only called if there are any.
+func (a *filterAudit) dropPeriods(name, unit string, ranges []weekRange, keep func(i int) bool, note func(removed int) string) []int {
+	step := filterStep{name: name, unit: unit}
+	var kept []int
+	for i, wr := range ranges {
+		if keep(i) {
+			kept = append(kept, i)
+			continue
+		}
+		step.count++
+		step.removed = append(step.removed, wr.start.Format("2006-01-02"))
+	}
+	if step.count > 0 {
+		step.note = note(step.count)
+	}
+	a.steps = append(a.steps, step)
+	return kept
+}
+
+// skipStatsPeriods is dropPeriods for periods that stay in the chart but
+// are left out of the before/after comparison.
+func (a *filterAudit) skipStatsPeriods(name, unit string, ranges []weekRange, keep func(i int) bool, note func(removed int) string) []int {
+	kept := a.dropPeriods(name, unit, ranges, keep, note)
+	a.steps[len(a.steps)-1].statsOnly = true
+	return kept
+}
+
+// recordStatsPeriods records the chart periods generateStats leaves out of
+// the before/after comparison: those without PRs or below 10% of the
+// average (see activePeriods).
+func (a *filterAudit) recordStatsPeriods(ranges []weekRange, stats []weekStats, unit string) {
+	active, threshold, _ := activePeriods(stats)
+	if len(active) == 0 {
+		return
+	}
+	isActive := make(map[int]bool)
+	for _, i := range active {
+		isActive[i] = true
+	}
+	step := filterStep{name: "Below 10% of average PRs", unit: unit, statsOnly: true}
+	for i, wr := range ranges {
+		if !isActive[i] {
+			step.count++
+			step.removed = append(step.removed, wr.start.Format("2006-01-02"))
+		}
+	}
+	if step.count > 0 {
+		step.note = fmt.Sprintf("Left %d %s(s) with fewer than %.0f merged PRs (10%% of the average) out of the before/after comparison", step.count, unit, threshold)
+	}
+	a.steps = append(a.steps, step)
+}
+
+// notes returns the FilterNotes lines of the recorded steps, in order.
+func (a *filterAudit) notes() []string {
+	var notes []string
+	for _, s := range a.steps {
+		if s.note != "" {
+			notes = append(notes, s.note)
+		}
+	}
+	return notes
+}
+
+// logFilterAudit prints how many PRs or periods each step removed, with up
+// to 10 of them.
+func logFilterAudit(a *filterAudit) {
+	fmt.Fprintf(os.Stderr, "Filter audit:\n")
+	for _, s := range a.steps {
+		line := fmt.Sprintf("  %-32s %5d %s(s)", s.name, s.count, s.unit)
+		if s.statsOnly {
+			line += " (stats only)"
+		}
+		if len(s.removed) > 0 {
+			line += ": " + truncatedList(s.removed, 10)
+		}
+		fmt.Fprintf(os.Stderr, "%s\n", line)
+	}
+}
+
+// truncatedList joins up to limit items, noting how many were left out.
+func truncatedList(items []string, limit int) string {
+	if len(items) <= limit {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s, … (%d more)", strings.Join(items[:limit], ", "), len(items)-limit)
+}
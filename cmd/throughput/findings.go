@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxFindingMovers is how many of the biggest changes the key findings name.
+const maxFindingMovers = 2
+
+// minFindingWindow is the comparison window size, in periods, below which
+// the key findings warn that single periods dominate.
+const minFindingWindow = 3
+
+// buildFindings writes the key findings shown at the top of the HTML report
+// and in the --post-issue summary: the biggest significant movers, how many
+// metrics improved or regressed, and caveats about the data. The sentences
+// are assembled from fixed templates, translated by loc.
+func buildFindings(rows []consolidatedRow, stats []weekStats, periodLabel string, loc reportLocale) []string {
+	if len(rows) == 0 {
+		return []string{loc.T("Too few periods for a before/after comparison, so there are no findings.")}
+	}
+
+	type mover struct {
+		row consolidatedRow
+		md  metricDef
+		rel float64 // relative change; +Inf from 0
+	}
+	var movers []mover
+	var significant, improved, regressed int
+	for _, r := range rows {
+		md, ok := metricByName(r.metric)
+		if !ok || md.label == "" {
+			continue
+		}
+		if r.absChange != 0 {
+			rel := math.Inf(1)
+			if r.firstAvg != 0 {
+				rel = math.Abs(r.absChange / r.firstAvg)
+			}
+			movers = append(movers, mover{r, md, rel})
+		}
+		if !r.significant() {
+			continue
+		}
+		significant++
+		switch findingJudgement(r, md) {
+		case 1:
+			improved++
+		case -1:
+			regressed++
+		}
+	}
+	sort.SliceStable(movers, func(i, j int) bool {
+		si, sj := movers[i].row.significant(), movers[j].row.significant()
+		if si != sj {
+			return si
+		}
+		return movers[i].rel > movers[j].rel
+	})
+
+	var findings []string
+	switch {
+	case significanceLevel <= 0:
+		findings = append(findings, loc.T("Significance testing is off (--significance-level 0), so the changes below may be noise."))
+	case significant == 0 && len(movers) > 0:
+		findings = append(findings, fmt.Sprintf(loc.T("No metric changed significantly (p < %s); the largest move, %s, is within noise."),
+			loc.number(significanceLevel, 2), findingChange(movers[0].row, movers[0].md, loc)))
+		movers = nil
+	case significant > 0:
+		findings = append(findings, fmt.Sprintf(loc.T("%d of %d metrics changed significantly: %d improved, %d regressed."),
+			significant, len(rows), improved, regressed))
+	}
+	for i, m := range movers {
+		if i == maxFindingMovers || (significanceLevel > 0 && !m.row.significant()) {
+			break
+		}
+		s := findingChange(m.row, m.md, loc)
+		switch findingJudgement(m.row, m.md) {
+		case 1:
+			s += loc.T(", an improvement")
+		case -1:
+			s += loc.T(", a regression")
+		}
+		switch p := m.row.pValue; {
+		case p >= 0.001:
+			s += " (p = " + loc.number(p, 3) + ")"
+		case p >= 0:
+			s += " (p < " + loc.number(0.001, 3) + ")"
+		}
+		findings = append(findings, s+".")
+	}
+
+	// Caveats
+	unit := loc.T(periodLabel + "(s)")
+	if w := min(rows[0].firstWindowSize, rows[0].lastWindowSize); w < minFindingWindow {
+		findings = append(findings, fmt.Sprintf(loc.T("One side of the comparison has only %d %s, so a single unusual period weighs heavily."), w, unit))
+	}
+	// --min-group-size suppresses weeks; rolled-up periods don't carry it
+	var suppressed int
+	for _, ws := range stats {
+		if ws.suppressed {
+			suppressed++
+		}
+	}
+	if suppressed > 0 {
+		findings = append(findings, fmt.Sprintf(loc.T("%d %s had fewer authors than --min-group-size and are left out of the comparison."), suppressed, loc.T("week(s)")))
+	}
+	return findings
+}
+
+// findingJudgement returns 1 if the row's change is an improvement, -1 if a
+// regression, and 0 for activity metrics, which have no good direction.
+func findingJudgement(r consolidatedRow, md metricDef) int {
+	if md.category == "activity" {
+		return 0
+	}
+	if (r.absChange < 0) == md.lowerIsBetter {
+		return 1
+	}
+	return -1
+}
+
+// findingChange describes a row's change, e.g. "PRs merged rose 12.3%
+// (41.0 → 46.0)".
+func findingChange(r consolidatedRow, md metricDef, loc reportLocale) string {
+	first, last := loc.number(r.firstAvg, 1), loc.number(r.lastAvg, 1)
+	if md.unit != "" {
+		first += loc.T(md.unit)
+		last += loc.T(md.unit)
+	}
+	if r.firstAvg == 0 {
+		return fmt.Sprintf(loc.T("%s rose from %s to %s"), loc.T(md.label), first, last)
+	}
+	format := loc.T("%s rose %s (%s → %s)")
+	if r.absChange < 0 {
+		format = loc.T("%s fell %s (%s → %s)")
+	}
+	return fmt.Sprintf(format, loc.T(md.label), loc.localizeNumeric(strings.TrimLeft(r.pctChange, "+-")), first, last)
+}
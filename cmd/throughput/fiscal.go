@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fiscalCalendar maps dates to fiscal years and quarters (--fiscal-year-start).
+// A fiscal year is named after the calendar year it ends in, so with a
+// February start, February 2025 to January 2026 is FY2026. The zero value is
+// the calendar year. Periods belong to the fiscal quarter their start date
+// falls in, as weeks belong to the month they start in.
+type fiscalCalendar struct {
+	startMonth time.Month // 0 = January
+}
+
+// compareFiscalQuarters makes the before/after comparison use the first and
+// last complete fiscal quarters instead of the first and last N% of periods
+// (--compare-fiscal-quarters).
+var compareFiscalQuarters bool
+
+// parseFiscalStart parses a month number (1-12) or an English month name or
+// abbreviation ("feb", "February").
+func parseFiscalStart(s string) (time.Month, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 12 {
+			return 0, fmt.Errorf("month %d out of range 1-12", n)
+		}
+		return time.Month(n), nil
+	}
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if len(s) >= 3 && strings.HasPrefix(name, s) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown month %q (use 1-12 or a month name)", s)
+}
+
+func (fc fiscalCalendar) start() time.Month {
+	if fc.startMonth == 0 {
+		return time.January
+	}
+	return fc.startMonth
+}
+
+// period returns t's fiscal year, quarter (1-4), and month of the fiscal
+// year (1-12).
+func (fc fiscalCalendar) period(t time.Time) (year, quarter, month int) {
+	start := fc.start()
+	year = t.Year()
+	if start > time.January && t.Month() >= start {
+		year++
+	}
+	month = (int(t.Month())-int(start)+12)%12 + 1
+	return year, (month-1)/3 + 1, month
+}
+
+// quarterKey numbers fiscal quarters consecutively, so keys compare in time
+// order and differ by one between adjacent quarters.
+func (fc fiscalCalendar) quarterKey(t time.Time) int {
+	year, quarter, _ := fc.period(t)
+	return year*4 + quarter - 1
+}
+
+// quarterStart is the first day of t's fiscal quarter.
+func (fc fiscalCalendar) quarterStart(t time.Time) time.Time {
+	_, _, month := fc.period(t)
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, -((month - 1) % 3), 0)
+}
+
+// quarterLabel formats a quarterKey, e.g. "FY2026 Q1".
+func quarterLabel(key int) string {
+	return fmt.Sprintf("FY%d Q%d", key/4, key%4+1)
+}
+
+// applyFiscalQuarters tags each period with its fiscal quarter. Periods of
+// a quarter the range only partly covers (the first and last quarters,
+// usually) are marked partial and left out of --compare-fiscal-quarters.
+func applyFiscalQuarters(fc fiscalCalendar, periods []weekRange, stats []weekStats) {
+	if len(periods) == 0 {
+		return
+	}
+	rangeStart, rangeEnd := periods[0].start, periods[len(periods)-1].end
+	for i, p := range periods {
+		qStart := fc.quarterStart(p.start)
+		qEnd := qStart.AddDate(0, 3, -1)
+		stats[i].fiscalQuarter = fc.quarterKey(p.start)
+		// Weeks start on Mondays, so a covered quarter may begin up to 6
+		// days into the first week and end up to 6 days before the last
+		stats[i].fiscalPartial = rangeStart.After(qStart.AddDate(0, 0, 6)) || rangeEnd.Before(qEnd.AddDate(0, 0, -6))
+	}
+}
+
+// fiscalWindow returns a metric's values in the first and last complete
+// fiscal quarters with data for it, their keys, and the number of values.
+func fiscalWindow(periods []weekStats, md metricDef) (first, last []float64, firstKey, lastKey, n int, ok bool) {
+	firstKey, lastKey = -1, -1
+	for _, ws := range periods {
+		if ws.fiscalPartial || !md.valid(ws) {
+			continue
+		}
+		if firstKey < 0 || ws.fiscalQuarter < firstKey {
+			firstKey = ws.fiscalQuarter
+		}
+		lastKey = max(lastKey, ws.fiscalQuarter)
+	}
+	if firstKey < 0 || firstKey == lastKey {
+		return nil, nil, firstKey, lastKey, 0, false
+	}
+	for _, ws := range periods {
+		if ws.fiscalPartial || !md.valid(ws) {
+			continue
+		}
+		switch ws.fiscalQuarter {
+		case firstKey:
+			first = append(first, md.extract(ws))
+		case lastKey:
+			last = append(last, md.extract(ws))
+		}
+		n++
+	}
+	return first, last, firstKey, lastKey, n, true
+}
+
+// appendFiscalColumns adds fiscal_year, fiscal_quarter, and fiscal_month to
+// the weekly CSV, by week start.
+func appendFiscalColumns(csv string, weeks []weekRange, fc fiscalCalendar) string {
+	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
+	if len(lines) == 0 {
+		return csv
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lines[0])
+	sb.WriteString(",fiscal_year,fiscal_quarter,fiscal_month\n")
+	for i, line := range lines[1:] {
+		sb.WriteString(line)
+		if i < len(weeks) {
+			year, quarter, month := fc.period(weeks[i].start)
+			fmt.Fprintf(&sb, ",%d,%d,%d", year, quarter, month)
+		} else {
+			sb.WriteString(",,,")
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
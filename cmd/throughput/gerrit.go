@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// gerritTimeLayout is the timestamp format used by the Gerrit REST API (always UTC).
+const gerritTimeLayout = "2006-01-02 15:04:05.000000000"
+
+// gerritMagicPrefix is prepended to every Gerrit JSON response to prevent XSSI.
+const gerritMagicPrefix = ")]}'"
+
+type gerritTime struct {
+	time.Time
+}
+
+func (t *gerritTime) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	parsed, err := time.ParseInLocation(gerritTimeLayout, s, time.UTC)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type gerritAccount struct {
+	AccountID int      `json:"_account_id"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	Tags      []string `json:"tags"`
+}
+
+type gerritCommit struct {
+	Author struct {
+		Date gerritTime `json:"date"`
+	} `json:"author"`
+	Message string `json:"message"`
+}
+
+type gerritRevision struct {
+	Number  int                        `json:"_number"`
+	Created gerritTime                 `json:"created"`
+	Commit  gerritCommit               `json:"commit"`
+	Files   map[string]json.RawMessage `json:"files"`
+}
+
+type gerritChange struct {
+	Number          int                       `json:"_number"`
+	Subject         string                    `json:"subject"`
+	Created         gerritTime                `json:"created"`
+	Submitted       gerritTime                `json:"submitted"`
+	Insertions      int                       `json:"insertions"`
+	Deletions       int                       `json:"deletions"`
+	WorkInProgress  bool                      `json:"work_in_progress"`
+	CurrentRevision string                    `json:"current_revision"`
+	Owner           gerritAccount             `json:"owner"`
+	Submitter       gerritAccount             `json:"submitter"`
+	Revisions       map[string]gerritRevision `json:"revisions"`
+	Labels          map[string]struct {
+		All []struct {
+			gerritAccount
+			Value int        `json:"value"`
+			Date  gerritTime `json:"date"`
+		} `json:"all"`
+	} `json:"labels"`
+	Messages []struct {
+		Date    gerritTime `json:"date"`
+		Message string     `json:"message"`
+	} `json:"messages"`
+	MoreChanges bool `json:"_more_changes"`
+}
+
+// fetchAllGerritChanges fetches merged Gerrit changes for all weeks concurrently
+// and maps them onto the PR model so the rest of the pipeline is provider-agnostic.
+// It also returns the weeks whose results may be incomplete because a query failed.
+func fetchAllGerritChanges(cfg config, weeks []weekRange) ([]PR, []weekRange) {
+	var (
+		mu           sync.Mutex
+		allPRs       []PR
+		failed       []weekRange
+		wg           sync.WaitGroup
+		sem          = make(chan struct{}, maxConcurrency)
+		totalFetched atomic.Int64
+	)
+
+	for _, wr := range weeks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(wr weekRange) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prs, err := fetchWeekGerritChanges(cfg, wr)
+			total := totalFetched.Add(int64(len(prs)))
+
+			mu.Lock()
+			allPRs = append(allPRs, prs...)
+			if err != nil {
+				failed = append(failed, wr)
+			}
+			mu.Unlock()
+
+			fmt.Fprintf(os.Stderr, "  Week %s: %d changes (total: %d)\n",
+				wr.start.Format("2006-01-02"), len(prs), total)
+		}(wr)
+	}
+
+	wg.Wait()
+
+	fmt.Fprintf(os.Stderr, "Total changes fetched: %d\n", len(allPRs))
+	return allPRs, failed
+}
+
+func fetchWeekGerritChanges(cfg config, wr weekRange) ([]PR, error) {
+	rangeStart := wr.start.Format("2006-01-02")
+	rangeEnd := wr.end.AddDate(0, 0, 1).Format("2006-01-02")
+
+	q := fmt.Sprintf(`project:"%s" branch:"%s" status:merged mergedafter:"%s" mergedbefore:"%s"`,
+		cfg.repo, cfg.branch, rangeStart, rangeEnd)
+
+	var prs []PR
+	start := 0
+	for {
+		params := url.Values{}
+		params.Set("q", q)
+		params.Set("n", "100")
+		params.Set("S", fmt.Sprintf("%d", start))
+		for _, o := range []string{"ALL_REVISIONS", "ALL_COMMITS", "CURRENT_FILES", "DETAILED_LABELS", "DETAILED_ACCOUNTS", "MESSAGES"} {
+			params.Add("o", o)
+		}
+
+		data, err := gerritGet(cfg, "/changes/?"+params.Encode())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gerrit query failed for week %s: %v\n", rangeStart, err)
+			return prs, err
+		}
+
+		var changes []gerritChange
+		if err := json.Unmarshal(data, &changes); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Failed to parse Gerrit response for week %s: %v\n", rangeStart, err)
+			return prs, err
+		}
+
+		for _, c := range changes {
+			prs = append(prs, gerritChangeToPR(c))
+		}
+
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			break
+		}
+		start += len(changes)
+	}
+
+	return prs, nil
+}
+
+// gerritChangeToPR maps a Gerrit change onto the PR model:
+//   - submitted time → mergedAt
+//   - first patchset's commit → first commit (coding time start)
+//   - earliest positive Code-Review vote from a non-owner → first review
+//   - Code-Review votes → review states (+2 approves)
+//   - "Set Ready For Review" message → ReadyForReviewEvent
+//   - SERVICE_USER owners → Bot
+func gerritChangeToPR(c gerritChange) PR {
+	var pr PR
+	pr.Number = c.Number
+	pr.Title = c.Subject
+	pr.CreatedAt = c.Created.Time
+	pr.MergedAt = c.Submitted.Time
+	pr.IsDraft = c.WorkInProgress
+	pr.Additions = c.Insertions
+	pr.Deletions = c.Deletions
+
+	pr.Author.Login = c.Owner.Username
+	if pr.Author.Login == "" {
+		pr.Author.Login, _, _ = strings.Cut(c.Owner.Email, "@")
+	}
+	pr.Author.Typename = "User"
+	for _, tag := range c.Owner.Tags {
+		if tag == "SERVICE_USER" {
+			pr.Author.Typename = "Bot"
+		}
+	}
+	pr.MergedBy.Login = c.Submitter.Username
+	if pr.MergedBy.Login == "" {
+		pr.MergedBy.Login, _, _ = strings.Cut(c.Submitter.Email, "@")
+	}
+	pr.MergedBy.Typename = "User"
+	for _, tag := range c.Submitter.Tags {
+		if tag == "SERVICE_USER" {
+			pr.MergedBy.Typename = "Bot"
+		}
+	}
+
+	// Order patchsets so the first patchset's commit becomes the first commit.
+	revs := make([]gerritRevision, 0, len(c.Revisions))
+	for _, r := range c.Revisions {
+		revs = append(revs, r)
+	}
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Number < revs[j].Number })
+	for _, r := range revs {
+		var node struct {
+			Commit struct {
+				AuthoredDate time.Time `json:"authoredDate"`
+				Message      string    `json:"message"`
+			} `json:"commit"`
+		}
+		node.Commit.AuthoredDate = r.Commit.Author.Date.Time
+		node.Commit.Message = r.Commit.Message
+		pr.Commits.Nodes = append(pr.Commits.Nodes, node)
+	}
+	pr.Commits.TotalCount = 1 // a Gerrit change is a single commit
+	if cur, ok := c.Revisions[c.CurrentRevision]; ok {
+		pr.ChangedFiles = len(cur.Files)
+		for path := range cur.Files {
+			pr.Files.Nodes = append(pr.Files.Nodes, struct {
+				Path string `json:"path"`
+			}{Path: path})
+		}
+	}
+
+	// Every Code-Review vote is a review; +2 approves
+	pr.ReviewStates = &struct {
+		Nodes []prReviewState `json:"nodes"`
+	}{}
+	for _, v := range c.Labels["Code-Review"].All {
+		if v.Value == 0 {
+			continue
+		}
+		rs := prReviewState{State: "COMMENTED"}
+		switch {
+		case v.Value >= 2:
+			rs.State = "APPROVED"
+		case v.Value < 0:
+			rs.State = "CHANGES_REQUESTED"
+		}
+		rs.Author.Login = v.Username
+		if rs.Author.Login == "" {
+			rs.Author.Login, _, _ = strings.Cut(v.Email, "@")
+		}
+		pr.ReviewStates.Nodes = append(pr.ReviewStates.Nodes, rs)
+	}
+
+	var firstReview time.Time
+	for _, v := range c.Labels["Code-Review"].All {
+		if v.Value <= 0 || v.AccountID == c.Owner.AccountID || v.Date.IsZero() {
+			continue
+		}
+		if firstReview.IsZero() || v.Date.Before(firstReview) {
+			firstReview = v.Date.Time
+		}
+	}
+	if !firstReview.IsZero() {
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, struct {
+			SubmittedAt *time.Time `json:"submittedAt"`
+		}{SubmittedAt: &firstReview})
+	}
+
+	for _, m := range c.Messages {
+		if strings.Contains(m.Message, "Set Ready For Review") {
+			ready := m.Date.Time
+			pr.TimelineItems.Nodes = append(pr.TimelineItems.Nodes, struct {
+				CreatedAt *time.Time `json:"createdAt"`
+			}{CreatedAt: &ready})
+			break
+		}
+	}
+
+	return pr
+}
+
+// gerritGet performs an authenticated (when credentials are configured) GET
+// against the Gerrit REST API with retry, and strips the XSSI prefix.
+func gerritGet(cfg config, path string) ([]byte, error) {
+	user := os.Getenv("GERRIT_USERNAME")
+	pass := os.Getenv("GERRIT_HTTP_PASSWORD")
+	endpoint := strings.TrimSuffix(cfg.gerritURL, "/")
+	if user != "" && pass != "" {
+		endpoint += "/a"
+	}
+	endpoint += path
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if user != "" && pass != "" {
+			req.SetBasicAuth(user, pass)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("Gerrit returned %d (check GERRIT_USERNAME/GERRIT_HTTP_PASSWORD)", resp.StatusCode)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("Gerrit returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		return bytes.TrimPrefix(data, []byte(gerritMagicPrefix)), nil
+	}
+	return nil, fmt.Errorf("Gerrit query failed after 3 attempts: %v", lastErr)
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// metricDoc is a metric's card in the HTML "Metric Definitions" section. Docs
+// hang off metricDef so the glossary lists exactly the metrics the stats
+// compare; metrics without a doc (e.g. churned_engineers, covered by the
+// active engineers card) get no card of their own.
+type metricDoc struct {
+	title      string // card heading, translated with the report locale
+	definition template.HTML
+	benefits   template.HTML
+	drawbacks  template.HTML
+	// caveats returns notes that depend on this run's settings, e.g. the
+	// outlier policy applied to cycle times. May be nil.
+	caveats func(gc glossaryContext) []string
+}
+
+// glossaryContext is the run configuration that metric caveats describe.
+type glossaryContext struct {
+	granularity string
+	outliers    outlierPolicy
+	maxCommits  int
+	minPRs      int
+	provider    string
+}
+
+// buildGlossary returns the definition cards for every documented metric
+// with data in at least one chart period.
+func buildGlossary(stats []weekStats, gc glossaryContext, loc reportLocale) []htmlMetricDoc {
+	var cards []htmlMetricDoc
+	for _, md := range slices.Concat(allMetrics, cycleTimeMetrics) {
+		if md.doc == nil || !slices.ContainsFunc(stats, md.valid) {
+			continue
+		}
+		card := htmlMetricDoc{
+			Title:      loc.T(md.doc.title),
+			Definition: md.doc.definition,
+			Benefits:   md.doc.benefits,
+			Drawbacks:  md.doc.drawbacks,
+		}
+		if md.doc.caveats != nil {
+			card.Caveats = md.doc.caveats(gc)
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// rollupMedianCaveat notes how a per-period rate is rolled up into months
+// or sprints.
+func rollupMedianCaveat(gc glossaryContext) []string {
+	switch gc.granularity {
+	case "monthly":
+		return []string{"Monthly values are the median of the month's weekly values."}
+	case "sprint":
+		return []string{"Sprint values are the median of the sprint's weekly values."}
+	}
+	return nil
+}
+
+// outlierCaveat describes --outlier-policy for a cycle-time metric.
+func outlierCaveat(gc glossaryContext) []string {
+	switch gc.outliers.mode {
+	case "winsorize":
+		return []string{fmt.Sprintf("Per-PR values are clamped to the %gth–%gth percentile of the window (--outlier-policy winsorize) before the weekly median.", gc.outliers.lowerPct, gc.outliers.upperPct)}
+	case "drop":
+		return []string{fmt.Sprintf("Per-PR values outside the %gth–%gth percentile of the window are left out (--outlier-policy drop) before the weekly median.", gc.outliers.lowerPct, gc.outliers.upperPct)}
+	}
+	return nil
+}
+
+var prsMergedDoc = metricDoc{
+	title:      "PRs Merged",
+	definition: "Total number of merged pull requests per period, excluding bots and excluded authors. Raw volume metric.",
+	benefits:   "Simple, unambiguous count. Useful for spotting holidays, freezes, or unusual activity spikes.",
+	drawbacks:  "Not normalized by team size. Conflates small fixes with large features. Higher isn't necessarily better — could indicate PR splitting or churn.",
+	caveats: func(gc glossaryContext) []string {
+		if gc.minPRs <= 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("Periods with fewer than %d merged PRs are left out of every metric (--min-prs).", gc.minPRs)}
+	},
+}
+
+var uniqueAuthorsDoc = metricDoc{
+	title:      "Unique authors",
+	definition: "Distinct authors with at least one merged PR in the period. The denominator of PRs per engineer.",
+	benefits:   "Shows team size as seen through merged work, so throughput changes can be told apart from headcount changes.",
+	drawbacks:  "Counts anyone who merged a single PR, including occasional contributors from other teams.",
+	caveats: func(gc glossaryContext) []string {
+		switch gc.granularity {
+		case "monthly":
+			return []string{"Monthly values are the median of weekly unique authors; PR authors aren't re-counted per month."}
+		case "sprint":
+			return []string{"Sprint values are the median of weekly unique authors; PR authors aren't re-counted per sprint."}
+		}
+		return nil
+	},
+}
+
+var prsPerEngineerDoc = metricDoc{
+	title:      "PRs per Engineer",
+	definition: "Merged PRs divided by unique authors in the period. Measures individual throughput normalized by team size.",
+	benefits:   "Controls for team growth — a team doubling in size won't appear twice as productive. Useful for comparing periods with different headcounts.",
+	drawbacks:  "Doesn't account for PR size or complexity. A week of small refactors scores the same as a week of large features. Infrequent contributors (1 PR) inflate the denominator.",
+	caveats:    rollupMedianCaveat,
+}
+
+var commitsPerEngineerDoc = metricDoc{
+	title:      "Commits per Engineer",
+	definition: "Commits on the merged PRs divided by unique authors in the period. A PR whose commit count isn't known counts as one commit.",
+	benefits:   "Shows how work is split up: with steady PRs per engineer, rising commits per engineer means more iterations per PR.",
+	drawbacks:  "Commit habits vary (squashing locally, fixup commits), so it compares periods of one team better than teams. Rewritten history after a force push isn't counted.",
+	caveats:    rollupMedianCaveat,
+}
+
+var sizePointsDoc = metricDoc{
+	title:      "Size Points per Engineer",
+	definition: "Sum of per-PR size points divided by unique authors, where a PR scores log<sub>2</sub>(1 + lines added + lines deleted). A 1-line fix scores 1 point; a 1,000-line change about 10.",
+	benefits:   "Makes periods of many tiny PRs and periods of a few large PRs comparable. The log scale keeps one huge generated or vendored change from dominating the week.",
+	drawbacks:  "Lines changed is still a rough proxy for effort. Deletions and renames score like new code, and the points are not comparable across repos with different conventions.",
+	caveats:    rollupMedianCaveat,
+}
+
+var retentionDoc = metricDoc{
+	title:      "Active / Churned Engineers",
+	definition: "Active engineers are the distinct authors who merged at least one PR in the week or the 3 weeks before it. Churned engineers were active in the 4 weeks before that window but merged nothing in it.",
+	benefits:   "Separates a shrinking or rotating team from a slowing one. A throughput drop that coincides with rising churn points to attrition or reassignment rather than a process problem.",
+	drawbacks:  "Only counts PR authors, so people on leave, reviewing, or working outside this repo look churned. The first 3 weeks have no active count and the first 7 no churn, because the window needs history.",
+	caveats: func(gc glossaryContext) []string {
+		switch gc.granularity {
+		case "monthly":
+			return []string{"Monthly values are taken from the last week of each month."}
+		case "sprint":
+			return []string{"Sprint values are taken from the last week of each sprint."}
+		}
+		return nil
+	},
+}
+
+var revertsDoc = metricDoc{
+	title:      "% Reverts",
+	definition: "Percentage of PRs that revert an earlier change. A proxy for code quality and deployment stability.",
+	benefits:   "Captures production issues that required rolling back changes. Trending upward may signal quality regression or insufficient testing.",
+	drawbacks:  "Only catches problems fixed by a revert, not by a forward fix, and doesn't distinguish severity.",
+	caveats: func(gc glossaryContext) []string {
+		var labels []string
+		for l := range revertLabels {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		signals := "a GitHub-generated revert description, a “This reverts commit” message, or a revert/rollback title"
+		if len(labels) > 0 {
+			signals = fmt.Sprintf("a %s label, %s", strings.Join(labels, "/"), signals)
+		}
+		notes := []string{"A PR counts as a revert if it has " + signals + " (--revert-labels)."}
+		if note := revertBaseNote(); note != "" {
+			notes = append(notes, note+".")
+		}
+		return append(notes, rollupMedianCaveat(gc)...)
+	},
+}
+
+var onaInvolvedDoc = metricDoc{
+	title:      "% Ona Involved",
+	definition: "Percentage of PRs where Ona was a co-author (via <code>Co-authored-by</code> trailer) or the primary author (login prefix <code>ona-</code>).",
+	benefits:   "Tracks adoption of Ona-assisted development over time. Correlating with other metrics shows whether Ona usage coincides with throughput or quality changes.",
+	drawbacks:  "Measures presence, not impact. A PR with a trivial Ona contribution counts the same as one where Ona wrote most of the code. Relies on the co-author trailer being present.",
+	caveats: func(gc glossaryContext) []string {
+		notes := []string{fmt.Sprintf("Co-author trailers are looked for in the first %d commits of each PR (--max-commits).", max(gc.maxCommits, 50))}
+		return append(notes, rollupMedianCaveat(gc)...)
+	},
+}
+
+var onaLinesDoc = metricDoc{
+	title:      "% Lines in Ona PRs",
+	definition: "Lines changed (added + deleted) in Ona-involved PRs as a percentage of all lines changed in merged PRs.",
+	benefits:   "Weights uptake by volume of work, so a period where Ona is used on a few large changes isn't read as low adoption. Compared with % Ona Involved, it shows whether Ona is used on the small or the large PRs.",
+	drawbacks:  "Lines changed is a rough proxy for effort: one large generated or vendored change can dominate a period. Inherits the co-author detection limits of % Ona Involved.",
+	caveats: func(gc glossaryContext) []string {
+		switch gc.granularity {
+		case "monthly":
+			return []string{"Monthly values are computed from the month's total lines, not from weekly percentages."}
+		case "sprint":
+			return []string{"Sprint values are computed from the sprint's total lines, not from weekly percentages."}
+		}
+		return nil
+	},
+}
+
+var buildRunsDoc = metricDoc{
+	title:      "Builds",
+	definition: "Completed GitHub Actions workflow runs triggered by <code>push</code> or <code>pull_request</code> events in the period.",
+	benefits:   "Shows CI load, which tends to rise with PR volume and with the number of pushes per PR.",
+	drawbacks:  "Counts every workflow separately, so adding a workflow raises the count without any change in activity.",
+}
+
+var buildSuccessDoc = metricDoc{
+	title:      "Build success",
+	definition: "Percentage of those workflow runs that concluded with <code>success</code>.",
+	benefits:   "A falling rate points to flaky tests or broken main-branch builds that slow everyone down.",
+	drawbacks:  "Cancelled and skipped runs count as not successful. Failures on work-in-progress PR pushes are expected and lower the rate.",
+	caveats:    rollupMedianCaveat,
+}
+
+var ciQueueDoc = metricDoc{
+	title:      "CI Queue Time",
+	definition: "Median time from a GitHub Actions workflow run being created to it starting, in minutes, over the same sample of up to 100 push and 100 pull request runs per week as build success. Re-run attempts are skipped. Needs <code>--ci-queue</code>.",
+	benefits:   "Runner capacity problems show up here first, before they inflate review time: every PR waits for its checks before it can merge.",
+	drawbacks:  "A run's start is when its first job was picked up, so jobs queued behind it in the same run aren't seen. Runs waiting on a deployment approval or concurrency group count as queued.",
+	caveats:    rollupMedianCaveat,
+}
+
+var releasesDoc = metricDoc{
+	title:      "Releases",
+	definition: "Published GitHub releases in the period, drafts and prereleases left out, or tags with <code>--releases tags</code> and <code>--local-git</code>. A release is dated when it was published, a tag by its tagger date (or commit date for lightweight tags). <code>--release-pattern</code> narrows them by name. Needs <code>--releases</code>.",
+	benefits:   "For teams that ship versioned releases rather than deploying continuously, this is the delivery rate: merged PRs only reach users when a release goes out.",
+	drawbacks:  "Counts every matching release the same, so a hotfix counts as much as a major version. Backfilled tags and releases published long after their tag skew the dates.",
+}
+
+var releaseGapDoc = metricDoc{
+	title:      "Days Between Releases",
+	definition: "Median time from the previous release to each release published in the period, in calendar days. The first release in the window is measured from the last one before it.",
+	benefits:   "A steady or shrinking gap means changes reach users sooner after they merge; a growing one shows a release train slowing down.",
+	drawbacks:  "Periods without a release have no value, so a long freeze shows up as one large gap in the week it ends rather than as a gap in every week it spans.",
+	caveats:    rollupMedianCaveat,
+}
+
+var prsPerReleaseDoc = metricDoc{
+	title:      "PRs per Release",
+	definition: "Median count of PRs merged between the previous release and each release published in the period, counting the PRs included in the other metrics.",
+	benefits:   "Shows batch size: large releases bundle more change into each rollout, which makes them riskier and harder to roll back.",
+	drawbacks:  "Counts merges to the analyzed branch by date, not the commits actually contained in the release, so release branches and cherry-picks aren't followed. The window's first release has no value since earlier PRs aren't fetched.",
+	caveats:    rollupMedianCaveat,
+}
+
+var releaseBatchDoc = metricDoc{
+	title:      "Release Batch Size",
+	definition: "Median count of PRs included in each release published in the period, from the commits between its tag and the previous release's tag. A commit counts as a PR when it is a fetched PR's merge commit or its subject names one (<code>Merge pull request #123</code>, <code>Title (#123)</code>); with <code>--local-git</code> every non-merge commit counts. Needs <code>--release-changelog</code>.",
+	benefits:   "Large batches are a deployment-risk indicator: more change per rollout makes failures more likely and harder to trace back to a single PR.",
+	drawbacks:  "Commits pushed directly without a PR, or PRs merged with a rewritten title, aren't counted. Only the first 1,000 commits of a comparison are read.",
+	caveats:    rollupMedianCaveat,
+}
+
+var ciMinutesDoc = metricDoc{
+	title:      "Billable CI Minutes",
+	definition: "GitHub Actions minutes billed for the period's workflow runs: each job's run time rounded up to the minute, times 2 on Windows and 10 on macOS runners. Read from the jobs of the same sample as build success and scaled up to all push and pull request runs. Jobs on self-hosted runners are free. Needs <code>--ci-cost</code>.",
+	benefits:   "Puts CI spend next to throughput: more PRs should cost more minutes, but minutes growing faster than PRs point to slower or redundant workflows.",
+	drawbacks:  "An estimate: larger runners bill at higher rates than their OS multiplier, scheduled and manually dispatched runs aren't sampled, and the included free minutes of a plan aren't subtracted.",
+}
+
+var ciCostDoc = metricDoc{
+	title:      "CI Cost",
+	definition: "Billable CI minutes times the price per Linux minute given with <code>--ci-minute-price</code>, in that price's currency.",
+	benefits:   "States CI spend in money, next to productivity on the same chart.",
+	drawbacks:  "Inherits every approximation of billable CI minutes, and a single list price ignores discounts and included minutes.",
+}
+
+var incidentsDoc = metricDoc{
+	title:      "Incidents",
+	definition: "Incidents created in the period, from <code>--incidents-csv</code> or PagerDuty.",
+	benefits:   "Ties delivery speed to production stability: faster throughput with flat incident counts is a healthier signal than faster throughput alone.",
+	drawbacks:  "Depends on how consistently incidents are declared. Incidents unrelated to this repository are counted too unless the source is filtered.",
+}
+
+var mttrDoc = metricDoc{
+	title:      "Median MTTR",
+	definition: "Median time from incident creation to resolution, for incidents created in the period.",
+	benefits:   "Measures how quickly production problems are fixed, the recovery side of delivery performance.",
+	drawbacks:  "Unresolved incidents are left out, so a period with a long-running open incident can look better than it is.",
+	caveats:    rollupMedianCaveat,
+}
+
+var codingTimeDoc = metricDoc{
+	title:      "Coding Time",
+	definition: "Time from first commit (<code>authoredDate</code>) to when the PR was marked ready for review (<code>ReadyForReviewEvent</code>). If the PR was force-pushed, the head before the first force push counts when it was authored earlier, so rebases don't shorten coding time. Measures pre-review development duration.",
+	benefits:   "Isolates the development phase from the review phase. Helps identify whether slowdowns are in coding or review. Not inflated by review wait times.",
+	drawbacks:  "Only computed for PRs that were created as drafts and later marked ready. Non-draft PRs are excluded. Median can be low if most PRs are opened shortly after the first commit.",
+	caveats: func(gc glossaryContext) []string {
+		return append(outlierCaveat(gc), rollupMedianCaveat(gc)...)
+	},
+}
+
+var reviewTimeDoc = metricDoc{
+	title:      "Review Time",
+	definition: "Time from when the PR was marked ready for review (<code>ReadyForReviewEvent</code>) to merged. Measures how long PRs spend in code review.",
+	benefits:   "Directly measures review bottlenecks. High review time may indicate reviewer availability issues, large PRs, or complex changes requiring multiple review rounds.",
+	drawbacks:  "Only computed for PRs that were created as drafts. Includes time the author spends addressing feedback, not just reviewer wait time. Doesn't distinguish between active review and idle waiting.",
+	caveats: func(gc glossaryContext) []string {
+		return append(outlierCaveat(gc), rollupMedianCaveat(gc)...)
+	},
+}
+
+// userMetricDoc documents a --series, --derived, or RegisterMetric metric.
+func userMetricDoc(name string) *metricDoc {
+	for _, def := range derivedMetricDefs {
+		if def.name == name {
+			return &metricDoc{
+				title:      name,
+				definition: template.HTML("Derived metric <code>" + template.HTMLEscapeString(def.expr) + "</code>, computed for each period from that period's values."),
+				benefits:   "Tracks a ratio or difference the team cares about with the same before/after comparison and correlations as a built-in metric.",
+				drawbacks:  "A ratio of period values is not the average of per-PR ratios; periods where an input has no data or a divisor is zero are left out.",
+				caveats: func(gc glossaryContext) []string {
+					if gc.granularity == "weekly" {
+						return nil
+					}
+					return []string{"Computed from each period's rolled-up inputs, not combined from weekly values."}
+				},
+			}
+		}
+	}
+	return &metricDoc{
+		title:      name,
+		definition: "User-defined metric, supplied from outside the PR data.",
+		benefits:   "Puts team-specific signals next to throughput and Ona uptake, with the same before/after comparison and correlations.",
+		drawbacks:  "Its meaning and quality depend entirely on the source; periods without a value are left out.",
+		caveats: func(gc glossaryContext) []string {
+			for _, def := range externalSeriesDefs {
+				if def.name == name {
+					return []string{fmt.Sprintf("Loaded with --series; values within a period are combined by %s.", def.agg)}
+				}
+			}
+			return []string{"Computed per PR by a compiled-in RegisterMetric extractor."}
+		},
+	}
+}
+
+var reviewResponseDoc = metricDoc{
+	title:      "Review Response Time",
+	definition: "Median time from a review request to an individual reviewer (<code>ReviewRequestedEvent</code>) to that reviewer's first submitted review, for PRs merged in the period. Needs <code>--enrich-reviews</code>.",
+	benefits:   "Measures reviewer wait time directly, without the author's own time addressing feedback that review time includes. The per-reviewer leaderboard shows where review load and delays concentrate.",
+	drawbacks:  "Team review requests and reviews given without a request aren't counted. Requests withdrawn or re-sent before a review are dropped, and unanswered requests are counted but left out of the median.",
+	caveats:    rollupMedianCaveat,
+}
+
+var describedDoc = metricDoc{
+	title:      "% Described",
+	definition: "Percentage of PRs whose description has at least <code>--hygiene-min-description</code> characters, not counting whitespace or unfilled template comments.",
+	benefits:   "A described PR is easier to review and to understand later. A falling share can mean generated PRs are being opened without context.",
+	drawbacks:  "Length isn't quality: a pasted template or a generated summary passes, and a one-line fix may need no description at all.",
+	caveats: func(gc glossaryContext) []string {
+		if gc.provider == "git" {
+			return append([]string{"With --local-git the description is the full commit message."}, rollupMedianCaveat(gc)...)
+		}
+		return rollupMedianCaveat(gc)
+	},
+}
+
+var linkedIssueDoc = metricDoc{
+	title:      "% Linked to Issue",
+	definition: "Percentage of PRs that close a GitHub issue (a closing keyword like <code>Fixes #123</code> or a manual link), or whose branch or title carries a Jira or Linear key when <code>--jira-url</code> or <code>--linear</code> is set.",
+	benefits:   "Shows how much work is traceable to planned issues, as opposed to unplanned or ad-hoc changes.",
+	drawbacks:  "Plain mentions of an issue without a closing keyword don't count, and teams that track work outside GitHub issues score low without Jira or Linear configured.",
+	caveats:    rollupMedianCaveat,
+}
+
+var securityPRsDoc = metricDoc{
+	title:      "Security Fixes",
+	definition: "Merged PRs with one of the <code>--security-labels</code> (default <code>security</code>), plus Dependabot security updates, recognized by the <code>[Security]</code> title prefix or the advisory details in their description. Bots and excluded authors count. Needs <code>--security</code>.",
+	benefits:   "Shows how much security work ships, separate from general throughput, so a drop in feature output during a patching push is explained.",
+	drawbacks:  "Only as complete as the labeling: security fixes without a label, and Dependabot security updates with a custom title and description, are missed.",
+}
+
+var securityLeadDoc = metricDoc{
+	title:      "Security Fix Lead Time",
+	definition: "Median time from a security fix PR being opened to being merged, in wall-clock hours (weekends count, unlike cycle times).",
+	benefits:   "Measures the remediation SLA from the same dataset as the other metrics: how long a known fix waits before it ships.",
+	drawbacks:  "Starts when the PR is opened, not when the vulnerability was disclosed or the alert raised, so time spent before anyone opened a PR isn't seen.",
+	caveats:    rollupMedianCaveat,
+}
+
+var templateDoc = metricDoc{
+	title:      "Template compliance",
+	definition: "Percentage of merged PRs whose description has every required section with content of its own. The sections are the headings of the repository's PR template, or <code>--template-sections</code>. A section left empty, or only with the template's placeholder text or comments, counts as missing. Needs <code>--template-compliance</code>.",
+	benefits:   "Shows whether the team actually uses the template: the context, test notes, or rollout plan reviewers and auditors rely on.",
+	drawbacks:  "Checks structure, not quality: a one-word section counts as filled. Headings are matched by text, so a renamed heading counts as missing.",
+	caveats:    rollupMedianCaveat,
+}
+
+var approvedDoc = metricDoc{
+	title:      "% Approved",
+	definition: "Percentage of merged PRs with at least one approving review from someone other than the author. With Gerrit, a Code-Review +2 vote counts as an approval.",
+	benefits:   "Shows how much of what ships passed a second pair of eyes, which matters for audits and for teams relying on review to catch AI-written mistakes.",
+	drawbacks:  "An approval says nothing about how thorough the review was. Only the first 30 reviews of a PR are checked, and approvals dismissed later still count.",
+	caveats: func(gc glossaryContext) []string {
+		return append([]string{"PRs whose reviews weren't fetched (--local-git, or cache entries from older versions) are left out; a period with none has no value."}, rollupMedianCaveat(gc)...)
+	},
+}
+
+var unreviewedDoc = metricDoc{
+	title:      "% Unreviewed",
+	definition: "Percentage of merged PRs with no review of any kind (approval, change request, or comment) from someone other than the author.",
+	benefits:   "Surfaces self-merged changes that bypassed review, whether through admin merges, missing branch protection, or bots.",
+	drawbacks:  "Reviews left as plain comments on the conversation, outside a review, don't count, so teams reviewing that way look unreviewed.",
+	caveats: func(gc glossaryContext) []string {
+		return append([]string{"PRs whose reviews weren't fetched (--local-git, or cache entries from older versions) are left out; a period with none has no value."}, rollupMedianCaveat(gc)...)
+	},
+}
+
+var withTestsDoc = metricDoc{
+	title:      "% With Tests",
+	definition: "Percentage of PRs that change at least one test file, judged by path: <code>_test</code>, <code>test_</code>, <code>.test</code>, <code>.spec</code>, or <code>Test</code>/<code>Tests</code> file names, or a <code>test</code>, <code>tests</code>, <code>__tests__</code>, <code>spec</code>, or <code>testdata</code> directory.",
+	benefits:   "A rough signal of whether changes come with tests, which often shifts as AI assistance writes more of the code.",
+	drawbacks:  "Docs, config, and refactor-only PRs don't need tests but lower the share. Conventions outside the heuristics aren't recognized, and only the first 100 changed files of a PR are checked.",
+	caveats:    rollupMedianCaveat,
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -21,8 +23,8 @@ type graphqlRequest struct {
 }
 
 type graphqlResponse struct {
-	Data   json.RawMessage  `json:"data"`
-	Errors []graphqlError   `json:"errors"`
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
 }
 
 type graphqlError struct {
@@ -30,8 +32,49 @@ type graphqlError struct {
 	Type    string `json:"type"`
 }
 
-// graphqlQuery executes a GraphQL query with retry and rate-limit handling.
+// graphqlCall is a query sent once per run; callers asking for the same
+// query wait for it and share its response.
+type graphqlCall struct {
+	done chan struct{}
+	resp *graphqlResponse
+	err  error
+}
+
+var graphqlCalls = struct {
+	sync.Mutex
+	m map[string]*graphqlCall
+}{m: make(map[string]*graphqlCall)}
+
+// graphqlQuery executes a GraphQL query, sending identical queries (same
+// token and text) only once per run. Responses are shared and must not be
+// modified. Failed queries are forgotten so a later call tries again.
 func graphqlQuery(token, query string) (*graphqlResponse, error) {
+	key := token + "\x00" + query
+	graphqlCalls.Lock()
+	if c, ok := graphqlCalls.m[key]; ok {
+		graphqlCalls.Unlock()
+		<-c.done
+		if c.err == nil {
+			apiUsage.graphqlDeduped.Add(1)
+		}
+		return c.resp, c.err
+	}
+	c := &graphqlCall{done: make(chan struct{})}
+	graphqlCalls.m[key] = c
+	graphqlCalls.Unlock()
+
+	c.resp, c.err = graphqlPost(token, query)
+	if c.err != nil {
+		graphqlCalls.Lock()
+		delete(graphqlCalls.m, key)
+		graphqlCalls.Unlock()
+	}
+	close(c.done)
+	return c.resp, c.err
+}
+
+// graphqlPost executes a GraphQL query with retry and rate-limit handling.
+func graphqlPost(token, query string) (*graphqlResponse, error) {
 	reqBody := graphqlRequest{Query: query}
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
@@ -47,6 +90,7 @@ func graphqlQuery(token, query string) (*graphqlResponse, error) {
 		req.Header.Set("Authorization", "bearer "+token)
 		req.Header.Set("Content-Type", "application/json")
 
+		apiUsage.graphql.Add(1)
 		resp, err := httpClient.Do(req)
 		if err != nil {
 			lastErr = err
@@ -62,6 +106,16 @@ func graphqlQuery(token, query string) (*graphqlResponse, error) {
 			continue
 		}
 
+		if err := httpStatusError(resp, data); err != nil {
+			if errors.Is(err, errAuth) {
+				return nil, recordFailure(err)
+			}
+			fmt.Fprintf(os.Stderr, "  Rate limited, waiting 60s (attempt %d)...\n", attempt)
+			time.Sleep(60 * time.Second)
+			lastErr = err
+			continue
+		}
+
 		// Retry on server errors (502, 503, etc.)
 		if resp.StatusCode >= 500 {
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
@@ -81,7 +135,7 @@ func graphqlQuery(token, query string) (*graphqlResponse, error) {
 		if len(gqlResp.Errors) > 0 && gqlResp.Errors[0].Type == "RATE_LIMITED" {
 			fmt.Fprintf(os.Stderr, "  Rate limited, waiting 60s (attempt %d)...\n", attempt)
 			time.Sleep(60 * time.Second)
-			lastErr = fmt.Errorf("rate limited")
+			lastErr = classified(errRateLimited, fmt.Errorf("rate limited: %s", gqlResp.Errors[0].Message))
 			continue
 		}
 
@@ -99,5 +153,5 @@ func graphqlQuery(token, query string) (*graphqlResponse, error) {
 
 		return &gqlResp, nil
 	}
-	return nil, fmt.Errorf("graphql query failed after 3 attempts: %v", lastErr)
+	return nil, recordFailure(fmt.Errorf("graphql query failed after 3 attempts: %w", lastErr))
 }
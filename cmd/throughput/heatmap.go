@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// heatmapPair is the --heatmap report section: merge and commit heatmaps
+// in the --timezone location.
+type heatmapPair struct {
+	zone    string
+	merges  activityHeatmap
+	commits activityHeatmap
+}
+
+// weekdayAbbrevs are the heatmap row labels, Monday first.
+var weekdayAbbrevs = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// activityHeatmap counts events by local weekday (Monday first) and hour.
+type activityHeatmap struct {
+	counts [7][24]int
+	total  int
+}
+
+// add records an event at the given Unix time in loc.
+func (h *activityHeatmap) add(epoch int64, loc *time.Location) {
+	t := time.Unix(epoch, 0).In(loc)
+	day := (int(t.Weekday()) + 6) % 7 // Monday=0
+	h.counts[day][t.Hour()]++
+	h.total++
+}
+
+// afterHoursPct is the share of events outside Monday–Friday 9:00–18:00.
+func (h *activityHeatmap) afterHoursPct() float64 {
+	if h.total == 0 {
+		return 0
+	}
+	var inside int
+	for day := 0; day < 5; day++ {
+		for hour := 9; hour < 18; hour++ {
+			inside += h.counts[day][hour]
+		}
+	}
+	return float64(h.total-inside) / float64(h.total) * 100
+}
+
+// buildHeatmaps counts the merges and commits (--heatmap) of PRs merged in
+// the chart periods, in tz (--timezone). Commits are those fetched with
+// each PR, so PRs with more than --max-commits commits are only partly
+// counted.
+func buildHeatmaps(prs []enrichedPR, periods []weekRange, tz *time.Location) *heatmapPair {
+	hm := &heatmapPair{zone: tz.String()}
+	for _, pr := range prs {
+		if weekIndex(periods, pr.mergedEpoch) < 0 {
+			continue
+		}
+		hm.merges.add(pr.mergedEpoch, tz)
+		for _, c := range pr.commitEpochs {
+			hm.commits.add(c, tz)
+		}
+	}
+	return hm
+}
+
+// heatmapTable formats a heatmap for the HTML report, shading each cell
+// relative to the busiest one.
+func heatmapTable(label string, h activityHeatmap, zone string, loc reportLocale) htmlHeatmap {
+	var peak int
+	for _, row := range h.counts {
+		for _, c := range row {
+			peak = max(peak, c)
+		}
+	}
+	t := htmlHeatmap{
+		Title: fmt.Sprintf("%s (%s)", label, loc.number(float64(h.total), 0)),
+		Note:  fmt.Sprintf(loc.T("Times in %s. %s outside Mon–Fri 9:00–18:00."), zone, loc.number(h.afterHoursPct(), 1)+"%"),
+	}
+	for day, row := range h.counts {
+		r := htmlHeatmapRow{Day: loc.T(weekdayAbbrevs[day])}
+		for _, c := range row {
+			shade := 0.0
+			if peak > 0 {
+				shade = math.Round(float64(c)/float64(peak)*100) / 100
+			}
+			r.Cells = append(r.Cells, htmlHeatCell{Count: c, Shade: shade, Dark: shade > 0.55})
+		}
+		t.Rows = append(t.Rows, r)
+	}
+	return t
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// histogramMetric is a per-PR value whose distribution is compared between
+// the stat cards' windows (--histograms). Bins double in width from 1 up to
+// 2^maxExp, since cycle times and sizes are heavily right-skewed.
+type histogramMetric struct {
+	label  string
+	unit   string // "h" or "" for lines
+	maxExp int
+	value  func(pr enrichedPR) (float64, bool)
+}
+
+// histogramMetrics are the distributions shown in the report.
+var histogramMetrics = []histogramMetric{
+	{
+		label: "Review Time", unit: "h", maxExp: 9, // 512h ≈ 3 weeks
+		value: func(pr enrichedPR) (float64, bool) { return pr.reviewTimeHours, pr.reviewTimeHours >= 0 },
+	},
+	{
+		label: "Coding Time", unit: "h", maxExp: 9,
+		value: func(pr enrichedPR) (float64, bool) { return pr.codingTimeHours, pr.codingTimeHours >= 0 },
+	},
+	{
+		label: "PR Size (lines)", maxExp: 13, // 8,192 lines
+		value: func(pr enrichedPR) (float64, bool) { return float64(pr.additions + pr.deletions), true },
+	},
+}
+
+// histogramSet holds the --histograms distributions and the chart periods
+// making up the two windows they compare.
+type histogramSet struct {
+	first, last  []int // chart period indices
+	onaThreshold float64
+	fiscal       [2]string // the windows' fiscal quarter labels with --compare-fiscal-quarters
+	histograms   []histogram
+}
+
+// histogram is one metric's binned distribution in both windows, as the
+// percentage of each window's PRs per bin.
+type histogram struct {
+	metric histogramMetric
+	first  []float64
+	last   []float64
+	firstN int
+	lastN  int
+	pValue float64 // Mann-Whitney U on the raw values; -1 if untestable
+}
+
+// comparisonWindows returns the chart periods in the stat cards' first and
+// last windows: the first and last windowPct% of periods, or with
+// onaThreshold > 0, the periods below and above that Ona share, or with
+// --compare-fiscal-quarters, the first and last complete fiscal quarters, or
+// with --compare-windows, the periods starting in its two ranges.
+// Like generateStats, only activePeriods are used.
+func comparisonWindows(stats []weekStats, windowPct int, onaThreshold float64) (first, last []int) {
+	active, _, _ := activePeriods(stats)
+	if len(compareWindows) > 0 {
+		for _, i := range active {
+			switch stats[i].compareWindow {
+			case 1:
+				first = append(first, i)
+			case 2:
+				last = append(last, i)
+			}
+		}
+		return first, last
+	}
+	if compareFiscalQuarters {
+		firstKey, lastKey := -1, -1
+		for _, i := range active {
+			if stats[i].fiscalPartial {
+				continue
+			}
+			if firstKey < 0 || stats[i].fiscalQuarter < firstKey {
+				firstKey = stats[i].fiscalQuarter
+			}
+			lastKey = max(lastKey, stats[i].fiscalQuarter)
+		}
+		if firstKey == lastKey {
+			return nil, nil
+		}
+		for _, i := range active {
+			if stats[i].fiscalPartial {
+				continue
+			}
+			switch stats[i].fiscalQuarter {
+			case firstKey:
+				first = append(first, i)
+			case lastKey:
+				last = append(last, i)
+			}
+		}
+		return first, last
+	}
+	if onaThreshold > 0 {
+		for _, i := range active {
+			if stats[i].pctOnaInvolved < onaThreshold {
+				first = append(first, i)
+			} else {
+				last = append(last, i)
+			}
+		}
+		return first, last
+	}
+	if len(active) < 2 {
+		return nil, nil
+	}
+	size := trendWindowSize(len(active), windowPct)
+	return active[:size], active[len(active)-size:]
+}
+
+// buildHistograms bins each metric's per-PR values for the PRs merged in
+// the first and last comparison windows. Metrics without values in both
+// windows are skipped. Returns nil if a window is empty or, with
+// minAuthors > 1, has PRs from fewer distinct authors (--min-group-size).
+func buildHistograms(prs []enrichedPR, periods []weekRange, stats []weekStats, windowPct int, onaThreshold float64, minAuthors int) *histogramSet {
+	firstIdx, lastIdx := comparisonWindows(stats, windowPct, onaThreshold)
+	if len(firstIdx) == 0 || len(lastIdx) == 0 {
+		return nil
+	}
+	inWindow := func(idx []int) []enrichedPR {
+		set := make(map[int]bool)
+		for _, i := range idx {
+			set[i] = true
+		}
+		var out []enrichedPR
+		for _, pr := range prs {
+			if set[weekIndex(periods, pr.mergedEpoch)] {
+				out = append(out, pr)
+			}
+		}
+		return out
+	}
+	firstPRs, lastPRs := inWindow(firstIdx), inWindow(lastIdx)
+	if minAuthors > 1 && (distinctAuthors(firstPRs) < minAuthors || distinctAuthors(lastPRs) < minAuthors) {
+		return nil
+	}
+
+	set := &histogramSet{first: firstIdx, last: lastIdx, onaThreshold: onaThreshold}
+	if compareFiscalQuarters {
+		set.fiscal = [2]string{quarterLabel(stats[firstIdx[0]].fiscalQuarter), quarterLabel(stats[lastIdx[0]].fiscalQuarter)}
+	}
+	for _, m := range histogramMetrics {
+		a, b := histogramValues(m, firstPRs), histogramValues(m, lastPRs)
+		if len(a) == 0 || len(b) == 0 {
+			continue
+		}
+		set.histograms = append(set.histograms, histogram{
+			metric: m,
+			first:  binShares(a, m.maxExp),
+			last:   binShares(b, m.maxExp),
+			firstN: len(a),
+			lastN:  len(b),
+			pValue: mannWhitneyPValue(a, b),
+		})
+	}
+	return set
+}
+
+func histogramValues(m histogramMetric, prs []enrichedPR) []float64 {
+	var vals []float64
+	for _, pr := range prs {
+		if v, ok := m.value(pr); ok {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+// binShares returns the percentage of values in each of maxExp+2 bins:
+// [0,1), [1,2), [2,4), ..., [2^(maxExp-1), 2^maxExp), and ≥ 2^maxExp.
+func binShares(values []float64, maxExp int) []float64 {
+	shares := make([]float64, maxExp+2)
+	for _, v := range values {
+		bin := 0
+		if v >= 1 {
+			bin = min(int(math.Floor(math.Log2(v)))+1, maxExp+1)
+		}
+		shares[bin]++
+	}
+	for i := range shares {
+		shares[i] = shares[i] / float64(len(values)) * 100
+	}
+	return shares
+}
+
+// binLabels names binShares' bins, e.g. "<1h", "1–2h", ..., "≥512h".
+func binLabels(m histogramMetric, loc reportLocale) []string {
+	labels := []string{"<1" + m.unit}
+	for e := 0; e < m.maxExp; e++ {
+		labels = append(labels, fmt.Sprintf("%s–%s%s", loc.number(math.Exp2(float64(e)), 0), loc.number(math.Exp2(float64(e+1)), 0), m.unit))
+	}
+	return append(labels, "≥"+loc.number(math.Exp2(float64(m.maxExp)), 0)+m.unit)
+}
+
+// windowLabels names the two histogram windows for the chart legends.
+func (set *histogramSet) windowLabels(periods []weekRange, periodLabel string, loc reportLocale) (string, string) {
+	if set.onaThreshold > 0 {
+		pct := loc.number(set.onaThreshold, 0) + "%"
+		return fmt.Sprintf(loc.T("Below %s Ona"), pct), fmt.Sprintf(loc.T("Above %s Ona"), pct)
+	}
+	describe := func(idx []int) string {
+		if len(idx) == 0 {
+			return ""
+		}
+		return loc.date(periods[idx[0]].start) + " – " + loc.date(periods[idx[len(idx)-1]].end)
+	}
+	if len(compareWindows) > 0 {
+		span := func(w dateWindow) string { return loc.date(w.start) + " – " + loc.date(w.end) }
+		return span(compareWindows[0]), span(compareWindows[1])
+	}
+	if set.fiscal[0] != "" {
+		return set.fiscal[0] + " (" + describe(set.first) + ")", set.fiscal[1] + " (" + describe(set.last) + ")"
+	}
+	unit := loc.T(periodLabel + "(s)")
+	return fmt.Sprintf(loc.T("First %d %s (%s)"), len(set.first), unit, describe(set.first)),
+		fmt.Sprintf(loc.T("Last %d %s (%s)"), len(set.last), unit, describe(set.last))
+}
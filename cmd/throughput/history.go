@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The stats history records each run's before/after comparison rows next to
+// the snapshot, one JSON object per line, keyed by run date:
+//
+//	<store>/<owner>/<repo>.history.jsonl
+//
+// Unlike the snapshot, which always holds the latest run, the history keeps
+// every run so the headline estimates can be watched as data accumulates. A
+// second run on the same date replaces that date's entry.
+
+// statsHistoryEntry is one run's comparison rows.
+type statsHistoryEntry struct {
+	RunDate     string         `json:"run_date"`
+	Granularity string         `json:"granularity"`
+	Weeks       int            `json:"weeks"`
+	Stats       []snapshotStat `json:"stats"`
+}
+
+// headlineMetrics are the metrics tracked run-over-run in the HTML report
+// and stderr log.
+var headlineMetrics = []string{
+	"prs_per_engineer",
+	"median_coding_time_hours",
+	"median_review_time_hours",
+	"pct_reverts",
+	"pct_ona_involved",
+}
+
+func historyPath(dir, owner, repo string) (string, error) {
+	path, err := snapshotPath(dir, owner, strings.ReplaceAll(repo, "/", "__"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".json") + ".history.jsonl", nil
+}
+
+// loadStatsHistory reads the history for owner/repo, oldest run first. A
+// repository without history returns nil.
+func loadStatsHistory(dir, owner, repo string) ([]statsHistoryEntry, error) {
+	path, err := historyPath(dir, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []statsHistoryEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e statsHistoryEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// appendStatsHistory adds the snapshot's comparison rows to the history,
+// replacing an entry from the same run date, and returns the full history.
+func appendStatsHistory(dir string, snap runSnapshot, weeks int) ([]statsHistoryEntry, error) {
+	entries, err := loadStatsHistory(dir, snap.Owner, snap.Repo)
+	if err != nil {
+		return nil, err
+	}
+	entry := statsHistoryEntry{
+		RunDate:     snap.GeneratedAt.Format("2006-01-02"),
+		Granularity: snap.Granularity,
+		Weeks:       weeks,
+		Stats:       snap.Stats,
+	}
+	entries = withoutRunDate(entries, entry.RunDate)
+	entries = append(entries, entry)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].RunDate < entries[j].RunDate })
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	path, err := historyPath(dir, snap.Owner, snap.Repo)
+	if err != nil {
+		return nil, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return entries, os.Rename(tmp, path)
+}
+
+func withoutRunDate(entries []statsHistoryEntry, runDate string) []statsHistoryEntry {
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.RunDate != runDate {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// statOf returns the comparison row for metric in a history entry.
+func (e statsHistoryEntry) statOf(metric string) (snapshotStat, bool) {
+	for _, s := range e.Stats {
+		if s.Metric == metric {
+			return s, true
+		}
+	}
+	return snapshotStat{}, false
+}
+
+// logHistoryDrift prints how each headline estimate moved since the previous run.
+func logHistoryDrift(entries []statsHistoryEntry) {
+	if len(entries) < 2 {
+		return
+	}
+	prev, last := entries[len(entries)-2], entries[len(entries)-1]
+	fmt.Fprintf(os.Stderr, "Estimate history: %d runs; change since %s:\n", len(entries), prev.RunDate)
+	for _, m := range headlineMetrics {
+		p, okP := prev.statOf(m)
+		l, okL := last.statOf(m)
+		if okP && okL {
+			fmt.Fprintf(os.Stderr, "  %-28s %8s -> %8s\n", m, p.PctChange, l.PctChange)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// holidayRule is one public holiday, as its date in a given year.
+type holidayRule struct {
+	name string
+	date func(year int) time.Time
+}
+
+// holidayCalendars are the national public holidays per ISO 3166 country
+// code (--holidays). Regional holidays and substitute days for holidays
+// falling on a weekend are not included.
+var holidayCalendars = map[string][]holidayRule{
+	"US": {
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Martin Luther King Jr. Day", nthWeekday(time.January, time.Monday, 3)},
+		{"Presidents' Day", nthWeekday(time.February, time.Monday, 3)},
+		{"Memorial Day", nthWeekday(time.May, time.Monday, -1)},
+		{"Juneteenth", fixedDate(time.June, 19)},
+		{"Independence Day", fixedDate(time.July, 4)},
+		{"Labor Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Thanksgiving", nthWeekday(time.November, time.Thursday, 4)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+	},
+	"GB": {
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Good Friday", easterOffset(-2)},
+		{"Easter Monday", easterOffset(1)},
+		{"Early May Bank Holiday", nthWeekday(time.May, time.Monday, 1)},
+		{"Spring Bank Holiday", nthWeekday(time.May, time.Monday, -1)},
+		{"Summer Bank Holiday", nthWeekday(time.August, time.Monday, -1)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+		{"Boxing Day", fixedDate(time.December, 26)},
+	},
+	"DE": {
+		{"Neujahr", fixedDate(time.January, 1)},
+		{"Karfreitag", easterOffset(-2)},
+		{"Ostermontag", easterOffset(1)},
+		{"Tag der Arbeit", fixedDate(time.May, 1)},
+		{"Christi Himmelfahrt", easterOffset(39)},
+		{"Pfingstmontag", easterOffset(50)},
+		{"Tag der Deutschen Einheit", fixedDate(time.October, 3)},
+		{"1. Weihnachtstag", fixedDate(time.December, 25)},
+		{"2. Weihnachtstag", fixedDate(time.December, 26)},
+	},
+	"FR": {
+		{"Jour de l'an", fixedDate(time.January, 1)},
+		{"Lundi de Pâques", easterOffset(1)},
+		{"Fête du Travail", fixedDate(time.May, 1)},
+		{"Victoire 1945", fixedDate(time.May, 8)},
+		{"Ascension", easterOffset(39)},
+		{"Lundi de Pentecôte", easterOffset(50)},
+		{"Fête nationale", fixedDate(time.July, 14)},
+		{"Assomption", fixedDate(time.August, 15)},
+		{"Toussaint", fixedDate(time.November, 1)},
+		{"Armistice", fixedDate(time.November, 11)},
+		{"Noël", fixedDate(time.December, 25)},
+	},
+	"NL": {
+		{"Nieuwjaarsdag", fixedDate(time.January, 1)},
+		{"Tweede Paasdag", easterOffset(1)},
+		{"Koningsdag", kingsDay},
+		{"Hemelvaartsdag", easterOffset(39)},
+		{"Tweede Pinksterdag", easterOffset(50)},
+		{"Eerste Kerstdag", fixedDate(time.December, 25)},
+		{"Tweede Kerstdag", fixedDate(time.December, 26)},
+	},
+	"CA": {
+		{"New Year's Day", fixedDate(time.January, 1)},
+		{"Good Friday", easterOffset(-2)},
+		{"Victoria Day", victoriaDay},
+		{"Canada Day", fixedDate(time.July, 1)},
+		{"Labour Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Thanksgiving", nthWeekday(time.October, time.Monday, 2)},
+		{"Christmas Day", fixedDate(time.December, 25)},
+		{"Boxing Day", fixedDate(time.December, 26)},
+	},
+}
+
+func fixedDate(month time.Month, day int) func(int) time.Time {
+	return func(year int) time.Time { return time.Date(year, month, day, 0, 0, 0, 0, time.UTC) }
+}
+
+// nthWeekday is the nth weekday of month, or the last one for n = -1.
+func nthWeekday(month time.Month, wd time.Weekday, n int) func(int) time.Time {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			return last.AddDate(0, 0, -((int(last.Weekday()) - int(wd) + 7) % 7))
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, 0, (int(wd)-int(first.Weekday())+7)%7+7*(n-1))
+	}
+}
+
+func easterOffset(days int) func(int) time.Time {
+	return func(year int) time.Time { return easterSunday(year).AddDate(0, 0, days) }
+}
+
+// easterSunday is the Gregorian Easter date (anonymous Gregorian algorithm).
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// kingsDay is April 27, or April 26 when the 27th is a Sunday.
+func kingsDay(year int) time.Time {
+	d := time.Date(year, time.April, 27, 0, 0, 0, 0, time.UTC)
+	if d.Weekday() == time.Sunday {
+		return d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// victoriaDay is the last Monday before May 25.
+func victoriaDay(year int) time.Time {
+	d := time.Date(year, time.May, 24, 0, 0, 0, 0, time.UTC)
+	return d.AddDate(0, 0, -((int(d.Weekday()) - int(time.Monday) + 7) % 7))
+}
+
+// parseHolidayCountries parses --holidays into known country codes.
+func parseHolidayCountries(s string) ([]string, error) {
+	var codes []string
+	for _, f := range strings.Split(s, ",") {
+		code := strings.ToUpper(strings.TrimSpace(f))
+		if code == "" {
+			continue
+		}
+		if _, ok := holidayCalendars[code]; !ok {
+			var known []string
+			for c := range holidayCalendars {
+				known = append(known, c)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown country %q (known: %s)", code, strings.Join(known, ", "))
+		}
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// periodHolidays lists, for each period, the holidays of the countries that
+// fall on a weekday within it, as "Name (CC)". Periods without holidays get
+// an empty list.
+func periodHolidays(periods []weekRange, countries []string) [][]string {
+	out := make([][]string, len(periods))
+	for i, p := range periods {
+		for year := p.start.Year(); year <= p.end.Year(); year++ {
+			for _, cc := range countries {
+				for _, h := range holidayCalendars[cc] {
+					d := h.date(year)
+					if d.Before(p.start) || d.After(p.end) || d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+						continue
+					}
+					out[i] = append(out[i], fmt.Sprintf("%s (%s)", h.name, cc))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// logHolidays prints the periods containing holidays.
+func logHolidays(periods []weekRange, holidays [][]string) {
+	var n int
+	for _, names := range holidays {
+		if len(names) > 0 {
+			n++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Holidays fall in %d of %d period(s)\n", n, len(periods))
+	for i, names := range holidays {
+		if len(names) > 0 {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", periods[i].start.Format("2006-01-02"), strings.Join(names, ", "))
+		}
+	}
+}
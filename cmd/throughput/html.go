@@ -1,37 +1,147 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"html/template"
+	"math"
+	"strings"
+	"time"
 )
 
 type htmlData struct {
-	Title            string
-	WindowDesc       string
-	FilterNotes      []string
-	Weeks            []htmlWeek
-	Stats            []htmlStat
-	Categories       []htmlCategory
-	ActivityLine     []htmlActivity
-	Contributors     []htmlContributor
+	Lang            string // BCP 47 locale tag from --locale, e.g. "de-DE"
+	Title           string
+	ChartJS         template.JS // --chart-js source to inline; empty loads Chart.js from the CDN
+	WindowDesc      string
+	FilterNotes     []string
+	Findings        []string // key findings, generated from the comparison rows
+	Summary         bool     // --audience exec: no chart or detail sections
+	Weeks           []htmlWeek
+	Stats           []htmlStat
+	Categories      []htmlCategory
+	ActivityLine    []htmlActivity
+	Contributors    []htmlContributor
+	Reviewers       []htmlReviewer
+	OnaPowerUsers   []htmlOnaUser // --ona-heat-list
+	OnaUntouched    []htmlOnaUser
+	IssueGroupLabel string
+	IssueGroups     []htmlIssueGroup
+	Correlations    []htmlCorrelation
+	CorrMatrix      *htmlCorrMatrix  // --correlation-matrix
+	Sensitivity     *htmlSensitivity // --sensitivity
+	OnaComparison   []htmlOutcomeRow
+	MatchedOutcomes []htmlOutcomeRow // --ona-matching
+	MatchingSummary string
+	MatchingBalance []htmlBalance
+	MixedModels     []htmlMixedModel // --ona-mixed-model
+	HistoryMetrics  []string         // column labels for StatsHistory
+	StatsHistory    []htmlHistoryRun
+	Targets         []htmlTarget
+	BenchmarkTitle  string // e.g. "DORA 2023"
+	BenchmarkSource string
+	Benchmarks      []htmlBenchmark
+	Glossary        []htmlMetricDoc      // Metric Definitions cards, from the metric registry
+	FilterAudit     []htmlFilterStep     // Data Quality table: what each filter removed
+	PRLists         [][]drilldownPR      // --pr-drilldown: PRs per chart period; empty without it
+	Flow            []htmlFlowPoint      // --cfd: PR states per chart period; empty without it
+	Cohorts         []htmlCohort         // --cohorts; empty without it
+	YoY             []htmlYoYSeries      // --yoy: this year's and last year's lines per metric; empty without it
+	Lifecycle       []htmlLifecycleState // --lifecycle time in state; empty without it
+	LifecycleFlow   htmlLifecycleFlow    // --lifecycle Sankey nodes and links
+	LifecycleNote   string
+	Scatter         []scatterPR     // --scatter: one point per PR; empty without it
+	Histograms      []htmlHistogram // --histograms; empty without it
+	Heatmaps        []htmlHeatmap   // --heatmap: merges and commits
+	Holidays        []string        // --holidays: per chart period, the holidays in it ("" for none)
+	HolidayNote     string
+	Unstable        int // --unstable-weeks: trailing chart periods that may still change
+	UnstableNote    string
+	TrendNote       string // explains the legend's trend badges; empty without any
+	HeatmapHours    []int
+	TargetLines     []htmlTargetLine
+	Deltas          []htmlDelta // --deltas: change from the previous period
+	HasIncidents    bool
+	HasSizeWeighted bool
+	HasOnaWeighted  bool
+	HasRetention    bool
+	HasResponse     bool // --enrich-reviews review response times
+	HasHygiene      bool
+	HasReopens      bool
+	HasAutomation   bool
+	ExternalSeries  []htmlSeries
+	SizeBuckets     []htmlSizeSeries // --size-buckets: review time per size bucket; empty without it
+	Data            reportData       // embedded JSON the chart script reads
+
+	// --review-coverage approved and unreviewed shares
+	HasReviewCoverage bool
+	HasCIQueue        bool
+	HasCICost         bool
+	HasTemplate       bool
+	HasSecurity       bool
+	HasReleases       bool
+	HasChangelog      bool // --release-changelog
+	HasCIPrice        bool // --ci-minute-price: draw CI cost rather than minutes
+
+	// --branch-protection changes marked on the chart
+	ProtectionChanges []htmlProtectionChange
+	ProtectionNote    string
+
+	// --runners self-hosted runner utilization, always weekly
+	RunnerWeeks []string
+	Runners     []htmlRunnerSeries
+}
+
+// htmlRunnerSeries is one --runners label set's weekly utilization and
+// median job queue time, nil for weeks without its jobs.
+type htmlRunnerSeries struct {
+	Label       string     `json:"label"`
+	Utilization []*float64 `json:"utilization"`
+	Queue       []*float64 `json:"queue"`
 }
 
 type htmlWeek struct {
-	WeekStart        string
-	PRsMerged        int
-	PRsPerEngineer   float64
-	MedianCodingTime float64
-	MedianReviewTime float64
-	PctOnaInvolved   float64
-	PctReverts       float64
-	BuildRuns        int
+	WeekStart             string // ISO date, always YYYY-MM-DD
+	WeekLabel             string // WeekStart formatted for the report locale, or the sprint name
+	PRsMerged             int
+	PRsPerEngineer        float64
+	SizePointsPerEngineer float64
+	ActiveEngineers4w     int // -1 without enough history
+	ChurnedEngineers      int // -1 without enough history
+	MedianCodingTime      float64
+	MedianReviewTime      float64
+	MedianReviewResponse  float64 // -1 if no data
+	PctOnaInvolved        float64
+	PctOnaLines           float64 // --ona-weighted; -1 if no lines changed
+	PctReverts            float64
+	PctDescribed          float64
+	PctLinkedIssue        float64
+	PctWithTests          float64
+	PctApproved           float64 // -1 if no PR's reviews are known
+	PctUnreviewed         float64 // -1 if no PR's reviews are known
+	BuildRuns             int
+	Incidents             int
+	MedianMTTR            float64
+	Reopened              int     // merged PRs closed and reopened at least once
+	AutomationPRs         int     // --automation: merged bot PRs
+	AutomationMergeTime   float64 // -1 if no bot PRs
+	MedianCIQueue         float64 // --ci-queue, minutes; -1 if no data
+	CIMinutes             float64 // --ci-cost billable minutes; -1 if no data
+	PctTemplate           float64 // --template-compliance; -1 if no PRs
+	SecurityPRs           int     // --security: merged security fixes
+	SecurityLead          float64 // -1 if no security fixes
+	Releases              int     // --releases: releases published
+	ReleaseGap            float64 // median days since the previous release; -1 if none
+	PRsPerRelease         float64 // -1 if unknown
+	ReleaseBatch          float64 // --release-changelog: median PRs per release; -1 if none
+	CICost                float64 // -1 without --ci-minute-price
+	FiscalQuarter         string  // --fiscal-year-start: e.g. "FY2026 Q1"; "" otherwise
+	Incomplete            bool    // a week's fetch failed or was cut off
 }
 
 type htmlCategory struct {
 	Name           string // e.g. "Speed"
 	AccentColor    string // e.g. "#2563eb"
-	TintColor      string // e.g. "rgba(37,99,235,0.06)"
+	TintColor      string // e.g. "#f0f4ff"
 	Stats          []htmlStat
 	CycleTimeStats []htmlStat // second row: coding time | review time
 }
@@ -40,10 +150,13 @@ type htmlStat struct {
 	Label       string
 	FirstAvg    string
 	LastAvg     string
-	IsPositive  bool   // true = change is in the "good" direction (accounts for inversion)
+	IsPositive  bool // true = change is in the "good" direction (accounts for inversion)
 	PctChange   string
 	Unit        string
-	InvertColor bool // true = lower is better (e.g. reverts)
+	InvertColor bool   // true = lower is better (e.g. reverts)
+	Neutral     bool   // change not significant: rendered gray with a badge
+	Untested    bool   // Neutral because a window is too small (or flat) to test: "insufficient data" badge
+	PValue      string // badge tooltip
 }
 
 type htmlActivity struct {
@@ -62,10 +175,497 @@ type htmlContributor struct {
 	PctChange  string
 	IsUp       bool
 	HasOnaPRs  bool
+	OnaPct     string
+	Excluded   bool // cut by --exclude-bottom-contributor-pct
+}
+
+// htmlOnaUser is one row of the --ona-heat-list power users or untouched
+// contributors.
+type htmlOnaUser struct {
+	Login    string
+	PRs      int
+	OnaPRs   int
+	OnaPct   string
+	Latest   string // latest Ona-involved PR for power users, latest PR otherwise
+	Excluded bool   // cut by --exclude-bottom-contributor-pct
+}
+
+// htmlReviewer is one row of the reviewer responsiveness leaderboard.
+type htmlReviewer struct {
+	Login      string
+	Requests   int
+	Answered   int
+	MedianTime string
+	P90Time    string
+}
+
+type htmlIssueGroup struct {
+	Group            string
+	PRs              int
+	PctOfPRs         string
+	MedianCodingTime string
+	MedianReviewTime string
+	MedianLeadTime   string
+}
+
+// htmlHistogram is one --histograms chart: the share of PRs per bin in the
+// first (comparison) and last (current) window.
+type htmlHistogram struct {
+	Title      string
+	Labels     []string
+	First      []float64
+	Last       []float64
+	FirstLabel string
+	LastLabel  string
+	Note       string // e.g. "n = 40 vs 52 · p = 0.012"
+}
+
+// htmlHeatmap is one --heatmap table: event counts by weekday and hour.
+type htmlHeatmap struct {
+	Title string // e.g. "Merges (412)"
+	Note  string // time zone and after-hours share
+	Rows  []htmlHeatmapRow
+}
+
+type htmlHeatmapRow struct {
+	Day   string
+	Cells []htmlHeatCell
+}
+
+// htmlHeatCell is one weekday/hour cell, shaded by its share of the busiest
+// cell's count.
+type htmlHeatCell struct {
+	Count int
+	Shade float64 // background opacity, 0-1
+	Dark  bool    // dark enough to need light text
+}
+
+// htmlFlowPoint is one period of the cumulative flow diagram.
+type htmlFlowPoint struct {
+	Open     int
+	InReview int
+	Merged   int
+}
+
+// htmlCohort is one --cohorts curve: mean PRs per week per contributor in
+// each 4-week block since joining, nil where too few members have data.
+type htmlCohort struct {
+	Label    string     `json:"label"`
+	Members  int        `json:"members"`
+	Censored bool       `json:"censored"` // also holds contributors who joined before the window
+	Values   []*float64 `json:"values"`
+}
+
+// htmlYoYSeries is one --yoy chart line: a metric per chart period, this
+// year or a year earlier, nil without data. Both lines of a metric share
+// Axis.
+type htmlYoYSeries struct {
+	Label    string     `json:"label"`
+	Axis     string     `json:"axis"` // the metric's label, titling its y axis
+	Previous bool       `json:"previous"`
+	Hidden   bool       `json:"hidden"`
+	Values   []*float64 `json:"values"`
+}
+
+// htmlLifecycleState is one row of the --lifecycle time-in-state table.
+type htmlLifecycleState struct {
+	State      string
+	PRs        int
+	MedianTime string
+	P90Time    string
+	Share      string
+	Bottleneck bool // the state holding the most open time
+}
+
+// htmlLifecycleFlow is the --lifecycle Sankey: states in column order and
+// the transition counts between them.
+type htmlLifecycleFlow struct {
+	States []htmlLifecycleNode `json:"states"`
+	Links  []htmlLifecycleLink `json:"links"`
+}
+
+type htmlLifecycleNode struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type htmlLifecycleLink struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// htmlSeries is a user-defined metric (--series or RegisterMetric) rendered as
+// an extra chart dataset.
+// Missing weeks are nil so Chart.js draws gaps.
+type htmlSeries struct {
+	Name   string
+	Values []*float64
+}
+
+// htmlSizeSeries is one --size-buckets chart line: a bucket's median review
+// time per period, null without data.
+type htmlSizeSeries struct {
+	Label  string     `json:"label"`
+	Values []*float64 `json:"values"`
+}
+
+type htmlCorrelation struct {
+	MetricA     string
+	MetricB     string
+	N           int
+	R           string
+	PValue      string
+	Significant bool
+}
+
+// htmlCorrMatrix is the --correlation-matrix heatmap. Columns are numbered
+// after the rows to keep the table narrow.
+type htmlCorrMatrix struct {
+	Rows []htmlCorrMatrixRow
+	Note string
+}
+
+type htmlCorrMatrixRow struct {
+	Num   int // 1-based, the column header
+	Label string
+	Cells []htmlCorrCell
+}
+
+// htmlCorrCell is one pair of the correlation heatmap, shaded by |r|: blue
+// for a positive correlation, red for a negative one.
+type htmlCorrCell struct {
+	Self        bool // the diagonal
+	R           string
+	Title       string // tooltip: both metrics, p-value, and periods
+	Shade       float64
+	Negative    bool
+	Dark        bool
+	Significant bool
+}
+
+// htmlSensitivity is the --sensitivity table: headline changes recomputed
+// per filter setting.
+type htmlSensitivity struct {
+	Metrics   []string // column labels
+	Rows      []htmlSensitivityRow
+	Agreement []string // per metric, e.g. "14/16"
+	Note      string
+}
+
+type htmlSensitivityRow struct {
+	BottomPct int
+	MinPRs    int
+	PRs       int
+	Periods   int
+	Baseline  bool // the run's own setting
+	Cells     []htmlSensitivityCell
+}
+
+// htmlSensitivityCell is one metric's change at a setting; Flip marks a
+// conclusion different from the baseline's.
+type htmlSensitivityCell struct {
+	Change      string // "—" without a comparison
+	Significant bool
+	Flip        bool
+}
+
+// htmlOutcomeRow is one row of the Ona-involved vs other PRs table.
+type htmlOutcomeRow struct {
+	Metric      string
+	Ona         string
+	OnaN        int
+	Other       string
+	OtherN      int
+	Difference  string
+	PValue      string // "—" if not testable
+	Significant bool
+}
+
+// htmlMixedModel is one outcome row of the --ona-mixed-model table.
+type htmlMixedModel struct {
+	Outcome      string
+	Effect       string // Ona coefficient as a percentage difference, e.g. "-18.2%"
+	CI           string // its 95% interval
+	Coef         string // on the log scale, with SE
+	PValue       string
+	Significant  bool
+	PRs          int
+	OnaPRs       int
+	Contributors int
+	ICC          string // share of variance between contributors
+}
+
+// htmlBalance is a covariate's standardized mean difference before and
+// after --ona-matching.
+type htmlBalance struct {
+	Covariate string
+	Before    string
+	After     string
+}
+
+// htmlHistoryRun is one stored run in the estimate history table: the
+// percent change of each headline metric as that run computed it.
+type htmlHistoryRun struct {
+	RunDate string
+	Periods int
+	Changes []string
+}
+
+// htmlTarget is one row of the goals table.
+type htmlTarget struct {
+	Metric     string
+	Target     string // e.g. "< 24h"
+	Current    string // last-window average, "—" without data
+	Status     string // "pass", "fail", or "" without data
+	PeriodsMet string // e.g. "9/12"
+}
+
+// htmlDelta is a --deltas metric's change from the previous chart period,
+// drawn as hidden-by-default bars. Periods without a delta are nil.
+type htmlDelta struct {
+	Label  string     `json:"label"`
+	Unit   string     `json:"unit"` // "%", "hrs", or ""
+	Values []*float64 `json:"values"`
 }
 
-func generateHTML(title string, weeks []weekRange, weeklyStats []weekStats, summaryRows []consolidatedRow, periodLabel string, filterNotes []string, topContributors []contributorStat) (string, error) {
-	data := htmlData{Title: title, FilterNotes: filterNotes}
+// htmlTargetLine is a goal drawn as a horizontal line on the chart.
+type htmlTargetLine struct {
+	Label  string
+	Axis   string // yAxisID of the metric's dataset
+	Value  float64
+	Hidden bool // hidden by default, like the metric's dataset
+}
+
+// htmlBenchmark places one metric within an industry benchmark's bands.
+type htmlBenchmark struct {
+	Metric     string
+	Value      string
+	Band       string // localized band name
+	BandClass  string // "elite", "high", "medium", or "low"
+	Thresholds string // e.g. "Elite ≤ 24h · High ≤ 168h · Medium ≤ 720h"
+	Proxy      string // how the value is derived from PR data
+}
+
+// htmlFilterStep is one row of the Data Quality table.
+type htmlFilterStep struct {
+	Filter    string
+	Removed   string // e.g. "12 PR(s)"
+	StatsOnly bool   // left out of the before/after comparison only
+	Items     string // removed PR numbers or period start dates, truncated
+}
+
+// htmlMetricDoc is one Metric Definitions card. Caveats describe how this
+// run's settings (outlier policy, --min-prs, ...) shape the metric.
+type htmlMetricDoc struct {
+	Title      string
+	Definition template.HTML
+	Benefits   template.HTML
+	Drawbacks  template.HTML
+	Caveats    []string
+}
+
+// reportExtras holds optional report sections computed outside the weekly pipeline.
+type reportExtras struct {
+	topContributors  []contributorStat
+	topReviewers     []reviewerStat
+	onaPowerUsers    []onaUserStat // --ona-heat-list
+	onaUntouched     []onaUserStat
+	issueGroupLabel  string // e.g. "Jira Issue Type" or "Linear Project"
+	issueGroups      []issueGroupStat
+	correlations     []correlationRow
+	corrMatrix       *corrMatrix         // --correlation-matrix
+	onaComparisons   []outcomeComparison // --ona-comparison
+	onaMatches       *matchingResult     // --ona-matching
+	mixedModels      []mixedModelFit     // --ona-mixed-model
+	statsHistory     []statsHistoryEntry // from --store; shown with two or more runs
+	targets          []targetResult      // --targets
+	benchmark        benchmarkSet        // --benchmark
+	benchmarks       []benchmarkResult
+	prLists          [][]drilldownPR    // --pr-drilldown, one list per chart period
+	schemaVersion    int                // --schema-version, embedded in the report data
+	flow             []flowPoint        // --cfd, one point per chart period
+	scatter          []scatterPR        // --scatter
+	histograms       *histogramSet      // --histograms
+	sensitivity      *sensitivityResult // --sensitivity
+	heatmaps         *heatmapPair       // --heatmap
+	audit            *filterAudit
+	holidays         [][]string // --holidays, per chart period
+	holidayCountries []string
+	protection       []htmlProtectionChange
+	runnerWeeks      []weekRange
+	runners          []runnerLabel    // --runners, per week of runnerWeeks
+	deltaMetrics     []metricDef      // --deltas
+	cohorts          []cohort         // --cohorts
+	lifecycle        *lifecycleModel  // --lifecycle
+	yoy              []*weekStats     // --yoy, the prior-year period per chart period
+	unstable         int              // --unstable-weeks, trailing chart periods
+	categories       []bannerCategory // --categories; nil for the built-in banners
+	glossary         glossaryContext
+	summaryOnly      bool // --audience exec: findings and banners only
+}
+
+func generateHTML(title string, weeks []weekRange, weeklyStats []weekStats, summaryRows []consolidatedRow, periodLabel string, filterNotes []string, extras reportExtras) (string, error) {
+	loc := activeLocale
+	// Slices read by the chart script must render as [] rather than null.
+	data := htmlData{Lang: loc.code, Title: title, ChartJS: chartJS, Summary: extras.summaryOnly, FilterNotes: filterNotes, ExternalSeries: []htmlSeries{}, TargetLines: []htmlTargetLine{}, Deltas: []htmlDelta{}, PRLists: extras.prLists}
+	if data.PRLists == nil {
+		data.PRLists = [][]drilldownPR{}
+	}
+	data.Holidays = make([]string, len(weeks))
+	for i, names := range extras.holidays {
+		data.Holidays[i] = strings.Join(names, ", ")
+	}
+	data.Unstable = extras.unstable
+	if extras.unstable > 0 {
+		data.UnstableNote = fmt.Sprintf(loc.T("The last %d %s may still change as late-merged PRs arrive; they are drawn dashed."), extras.unstable, loc.T(periodLabel+"(s)"))
+	}
+	trends := computeTrends(weeklyStats)
+	if len(trends) > 0 {
+		if significanceLevel > 0 {
+			data.TrendNote = fmt.Sprintf(loc.T("▲/▼ after a series name: it trends up/down over the chart (Mann-Kendall test, p < %s); – : no significant trend. Series with fewer than %d %s of data have no badge."),
+				loc.number(significanceLevel, 2), minTrendPeriods, loc.T(periodLabel+"(s)"))
+		} else {
+			data.TrendNote = fmt.Sprintf(loc.T("▲/▼ after a series name: the direction it trends over the chart, with the Mann-Kendall p-value. Series with fewer than %d %s of data have no badge."),
+				minTrendPeriods, loc.T(periodLabel+"(s)"))
+		}
+	}
+	data.ProtectionChanges = extras.protection
+	if data.ProtectionChanges == nil {
+		data.ProtectionChanges = []htmlProtectionChange{}
+	} else {
+		data.ProtectionNote = loc.T("Violet lines mark branch protection changes; hover a period for details.")
+	}
+	if len(extras.holidayCountries) > 0 {
+		data.HolidayNote = fmt.Sprintf(loc.T("Shaded periods contain public holidays (%s); hover a period to see which."), strings.Join(extras.holidayCountries, ", "))
+	}
+	data.Scatter = extras.scatter
+	if data.Scatter == nil {
+		data.Scatter = []scatterPR{}
+	}
+	data.Histograms = []htmlHistogram{}
+	if set := extras.histograms; set != nil {
+		firstLabel, lastLabel := set.windowLabels(weeks, periodLabel, loc)
+		for _, h := range set.histograms {
+			note := fmt.Sprintf("n = %d vs %d · ", h.firstN, h.lastN)
+			if h.pValue >= 0 {
+				note += "Mann-Whitney p = " + loc.number(h.pValue, 3)
+			} else {
+				note += loc.T("too few PRs to test")
+			}
+			data.Histograms = append(data.Histograms, htmlHistogram{
+				Title:      loc.T(h.metric.label),
+				Labels:     binLabels(h.metric, loc),
+				First:      h.first,
+				Last:       h.last,
+				FirstLabel: firstLabel,
+				LastLabel:  lastLabel,
+				Note:       note,
+			})
+		}
+	}
+	if hm := extras.heatmaps; hm != nil {
+		for h := 0; h < 24; h++ {
+			data.HeatmapHours = append(data.HeatmapHours, h)
+		}
+		for _, m := range []struct {
+			label string
+			h     activityHeatmap
+		}{{"Merges", hm.merges}, {"Commits", hm.commits}} {
+			if m.h.total == 0 {
+				continue
+			}
+			data.Heatmaps = append(data.Heatmaps, heatmapTable(loc.T(m.label), m.h, hm.zone, loc))
+		}
+	}
+	data.YoY = []htmlYoYSeries{}
+	if extras.yoy != nil {
+		for i, m := range yoyMetrics {
+			md, _ := metricByName(m.name)
+			label := loc.T(m.label)
+			value := func(ws *weekStats) *float64 {
+				if ws == nil || !md.valid(*ws) {
+					return nil
+				}
+				v := md.extract(*ws)
+				return &v
+			}
+			cur := htmlYoYSeries{Label: label, Axis: label, Hidden: i > 0}
+			prev := htmlYoYSeries{Label: fmt.Sprintf(loc.T("%s, a year earlier"), label), Axis: label, Previous: true, Hidden: i > 0}
+			for p := range weeks {
+				cur.Values = append(cur.Values, value(&weeklyStats[p]))
+				prev.Values = append(prev.Values, value(extras.yoy[p]))
+			}
+			data.YoY = append(data.YoY, cur, prev)
+		}
+	}
+	data.RunnerWeeks, data.Runners = []string{}, []htmlRunnerSeries{}
+	if len(extras.runners) > 0 {
+		for _, wr := range extras.runnerWeeks {
+			data.RunnerWeeks = append(data.RunnerWeeks, wr.start.Format(loc.shortLayout))
+		}
+		round := func(v float64) *float64 {
+			v = math.Round(v*10) / 10
+			return &v
+		}
+		for _, rl := range extras.runners {
+			s := htmlRunnerSeries{Label: rl.label}
+			for _, w := range rl.weeks {
+				if w.jobs == 0 {
+					s.Utilization, s.Queue = append(s.Utilization, nil), append(s.Queue, nil)
+					continue
+				}
+				var util *float64
+				if w.utilization >= 0 {
+					util = round(w.utilization)
+				}
+				s.Utilization, s.Queue = append(s.Utilization, util), append(s.Queue, round(median(w.queue)))
+			}
+			data.Runners = append(data.Runners, s)
+		}
+	}
+	data.Cohorts = []htmlCohort{}
+	for _, c := range extras.cohorts {
+		hc := htmlCohort{Label: c.label(), Members: c.members, Censored: c.censored}
+		if c.censored {
+			hc.Label = fmt.Sprintf(loc.T("%s and earlier"), hc.Label)
+		}
+		for _, v := range c.perWeek {
+			if v < 0 {
+				hc.Values = append(hc.Values, nil)
+				continue
+			}
+			v = math.Round(v*100) / 100
+			hc.Values = append(hc.Values, &v)
+		}
+		data.Cohorts = append(data.Cohorts, hc)
+	}
+	data.LifecycleFlow = htmlLifecycleFlow{States: []htmlLifecycleNode{}, Links: []htmlLifecycleLink{}}
+	if m := extras.lifecycle; m != nil {
+		bottleneck := m.bottleneck()
+		for _, d := range m.dwell {
+			data.Lifecycle = append(data.Lifecycle, htmlLifecycleState{
+				State:      loc.T(d.state),
+				PRs:        d.prs,
+				MedianTime: loc.number(d.medianHours, 1) + "h",
+				P90Time:    loc.number(d.p90Hours, 1) + "h",
+				Share:      loc.number(d.share, 0) + "%",
+				Bottleneck: d.state == bottleneck.state,
+			})
+		}
+		for _, s := range lifecycleStates {
+			data.LifecycleFlow.States = append(data.LifecycleFlow.States, htmlLifecycleNode{ID: s, Label: loc.T(s)})
+		}
+		for _, t := range m.transitions {
+			data.LifecycleFlow.Links = append(data.LifecycleFlow.Links, htmlLifecycleLink{From: t.from, To: t.to, Count: t.count})
+		}
+		data.LifecycleNote = fmt.Sprintf(loc.T("%d PRs with review history. Band widths are transitions between states; moves back to an earlier state arc below. Most open time is spent in %s."),
+			m.prs, loc.T(bottleneck.state))
+	}
+	data.Flow = []htmlFlowPoint{}
+	for _, p := range extras.flow {
+		data.Flow = append(data.Flow, htmlFlowPoint{Open: p.open, InReview: p.inReview, Merged: p.merged})
+	}
 	for i, wr := range weeks {
 		s := weeklyStats[i]
 		ct := s.medianCodingTime
@@ -76,43 +676,142 @@ func generateHTML(title string, weeks []weekRange, weeklyStats []weekStats, summ
 		if rt < 0 {
 			rt = 0
 		}
+		mttr := s.medianMTTR
+		if mttr < 0 {
+			mttr = 0
+		}
+		if s.incidentsTracked {
+			data.HasIncidents = true
+		}
+		if s.sizeWeighted {
+			data.HasSizeWeighted = true
+		}
+		if s.onaWeighted {
+			data.HasOnaWeighted = true
+		}
+		if s.retentionTracked {
+			data.HasRetention = true
+		}
+		if s.responseTracked {
+			data.HasResponse = true
+		}
+		if s.hygieneTracked {
+			data.HasHygiene = true
+		}
+		if s.reviewCoverageTracked {
+			data.HasReviewCoverage = true
+		}
+		if s.ciQueueTracked {
+			data.HasCIQueue = true
+		}
+		if s.ciCostTracked {
+			data.HasCICost = true
+		}
+		if s.templateTracked {
+			data.HasTemplate = true
+		}
+		if s.securityTracked {
+			data.HasSecurity = true
+		}
+		if s.releasesTracked {
+			data.HasReleases = true
+		}
+		if s.changelogTracked {
+			data.HasChangelog = true
+		}
+		if s.ciCostTracked && s.ciCost >= 0 {
+			data.HasCIPrice = true
+		}
+		if s.reopenTracked {
+			data.HasReopens = true
+		}
+		if s.automationTracked {
+			data.HasAutomation = true
+		}
+		fiscal := ""
+		if s.fiscalQuarter != 0 {
+			fiscal = quarterLabel(s.fiscalQuarter)
+		}
+		label := wr.start.Format(loc.shortLayout)
+		if wr.name != "" {
+			label = wr.name
+		}
 		data.Weeks = append(data.Weeks, htmlWeek{
-			WeekStart:        wr.start.Format("2006-01-02"),
-			PRsMerged:        s.prsMerged,
-			PRsPerEngineer:   s.prsPerEngineer,
-			MedianCodingTime: ct,
-			MedianReviewTime: rt,
-			PctOnaInvolved:   s.pctOnaInvolved,
-			PctReverts:       s.pctReverts,
-			BuildRuns:        s.buildRuns,
+			WeekStart:             wr.start.Format("2006-01-02"),
+			WeekLabel:             label,
+			PRsMerged:             s.prsMerged,
+			PRsPerEngineer:        s.prsPerEngineer,
+			SizePointsPerEngineer: s.sizePointsPerEngineer,
+			ActiveEngineers4w:     s.activeEngineers4w,
+			ChurnedEngineers:      s.churnedEngineers,
+			MedianCodingTime:      ct,
+			MedianReviewTime:      rt,
+			MedianReviewResponse:  s.medianReviewResponse,
+			PctOnaInvolved:        s.pctOnaInvolved,
+			PctOnaLines:           s.pctOnaLines,
+			PctReverts:            s.pctReverts,
+			PctDescribed:          s.pctDescribed,
+			PctLinkedIssue:        s.pctLinkedIssue,
+			PctWithTests:          s.pctWithTests,
+			PctApproved:           s.pctApproved,
+			PctUnreviewed:         s.pctUnreviewed,
+			BuildRuns:             s.buildRuns,
+			Incidents:             s.incidentCount,
+			MedianMTTR:            mttr,
+			Reopened:              s.reopenedCount,
+			AutomationPRs:         s.automationPRs,
+			AutomationMergeTime:   s.medianAutomationMerge,
+			MedianCIQueue:         s.medianCIQueue,
+			CIMinutes:             s.ciMinutes,
+			PctTemplate:           s.pctTemplateCompliant,
+			SecurityPRs:           s.securityPRs,
+			SecurityLead:          s.medianSecurityLead,
+			Releases:              s.releases,
+			ReleaseGap:            s.medianReleaseGap,
+			PRsPerRelease:         s.prsPerRelease,
+			ReleaseBatch:          s.releaseBatch,
+			CICost:                s.ciCost,
+			FiscalQuarter:         fiscal,
+			Incomplete:            s.incomplete,
 		})
 	}
 
-	// Metric display config
-	type metricConfig struct {
-		label       string
-		unit        string
-		category    string // "Speed", "Quality", "Ona Uptake", or "activity"
-		invertColor bool   // true = lower is better
+	for _, name := range userMetricNames {
+		series := htmlSeries{Name: name}
+		for _, s := range weeklyStats {
+			v, ok := s.external[name]
+			if !ok || math.IsNaN(v) {
+				series.Values = append(series.Values, nil)
+				continue
+			}
+			series.Values = append(series.Values, &v)
+		}
+		data.ExternalSeries = append(data.ExternalSeries, series)
 	}
-	metricCfg := map[string]metricConfig{
-		"prs_per_engineer": {label: "Median PRs / Engineer", unit: "", category: "Speed", invertColor: false},
-		"pct_reverts":      {label: "Reverts", unit: "%", category: "Quality", invertColor: true},
-		"pct_ona_involved": {label: "Ona Involved", unit: "%", category: "Ona Uptake", invertColor: false},
-		"prs_merged":        {label: "PRs merged", unit: "", category: "activity"},
-		"unique_authors":    {label: "Unique authors", unit: "", category: "activity"},
-		"build_runs":              {label: "Builds", unit: "", category: "activity"},
-		"build_success_pct":       {label: "Build success", unit: "%", category: "activity"},
-		"median_coding_time_hours": {label: "Median Time Spent Coding", unit: "hrs", category: "Cycle Time", invertColor: true},
-		"median_review_time_hours": {label: "Median Time Spent Reviewing", unit: "hrs", category: "Cycle Time", invertColor: true},
+
+	data.SizeBuckets = []htmlSizeSeries{}
+	if len(weeklyStats) > 0 && weeklyStats[0].sizeBucketsTracked {
+		for b, bucket := range sizeBuckets {
+			series := htmlSizeSeries{Label: fmt.Sprintf(loc.T("Time Spent Reviewing, %s PRs (hrs)"), bucket.name)}
+			for _, s := range weeklyStats {
+				if v := s.reviewTimeBySize[b]; v >= 0 {
+					series.Values = append(series.Values, &v)
+				} else {
+					series.Values = append(series.Values, nil)
+				}
+			}
+			data.SizeBuckets = append(data.SizeBuckets, series)
+		}
 	}
 
+	data.Findings = buildFindings(summaryRows, weeklyStats, periodLabel, loc)
+
 	// Compute window description from the first summary row
 	if len(summaryRows) > 0 && len(weeks) > 0 {
 		r := summaryRows[0]
 		n := len(weeks)
-		if r.firstWindowSize != r.lastWindowSize {
-			data.WindowDesc = "Comparing " + r.window
+		if r.firstWindowSize != r.lastWindowSize || compareFiscalQuarters || len(compareWindows) > 0 {
+			data.WindowDesc = loc.T("Comparing ") + r.window
 		} else {
 			ws := r.windowSize
 			if ws < 1 {
@@ -122,9 +821,10 @@ func generateHTML(title string, weeks []weekRange, weeklyStats []weekStats, summ
 			firstEnd := weeks[ws-1].end
 			lastStart := weeks[n-ws].start
 			lastEnd := weeks[n-1].end
-			data.WindowDesc = fmt.Sprintf("Comparing first %d %s(s) (%s – %s) vs last %d %s(s) (%s – %s)",
-				ws, periodLabel, firstStart.Format("Jan 2, 2006"), firstEnd.Format("Jan 2, 2006"),
-				ws, periodLabel, lastStart.Format("Jan 2, 2006"), lastEnd.Format("Jan 2, 2006"))
+			unit := loc.T(periodLabel + "(s)")
+			data.WindowDesc = fmt.Sprintf(loc.T("Comparing first %d %s (%s – %s) vs last %d %s (%s – %s)"),
+				ws, unit, loc.date(firstStart), loc.date(firstEnd),
+				ws, unit, loc.date(lastStart), loc.date(lastEnd))
 		}
 	}
 
@@ -138,106 +838,324 @@ func generateHTML(title string, weeks []weekRange, weeklyStats []weekStats, summ
 		{name: "Speed", accent: "#2563eb", tint: "#f0f4ff"},
 		{name: "Quality", accent: "#16a34a", tint: "#f0fdf4"},
 		{name: "Ona Uptake", accent: "#9333ea", tint: "#faf5ff"},
+		{name: "Release", accent: "#0d9488", tint: "#f0fdfa"},
 	}
 	catStats := make(map[string][]htmlStat)
+	// With --categories, banners are keyed by metric instead, and metrics
+	// in no banner go to the activity line.
+	inBanner := make(map[string]bool)
+	for _, c := range extras.categories {
+		for _, name := range c.Metrics {
+			inBanner[name] = true
+		}
+	}
+	metricStats := make(map[string][]htmlStat)
 
 	for _, r := range summaryRows {
-		cfg, ok := metricCfg[r.metric]
-		if !ok {
+		cfg, ok := metricByName(r.metric)
+		if !ok || cfg.category == "" {
 			continue // skip unknown metrics
 		}
 
-		firstAvg := fmt.Sprintf("%.1f", r.firstAvg)
-		lastAvg := fmt.Sprintf("%.1f", r.lastAvg)
+		firstAvg := loc.number(r.firstAvg, 1)
+		lastAvg := loc.number(r.lastAvg, 1)
 		if cfg.unit != "" {
-			firstAvg += cfg.unit
-			lastAvg += cfg.unit
+			firstAvg += loc.T(cfg.unit)
+			lastAvg += loc.T(cfg.unit)
 		}
 		// For inverted metrics (review speed, reverts), a decrease is good.
 		isGood := r.absChange >= 0
-		if cfg.invertColor {
+		if cfg.lowerIsBetter {
 			isGood = r.absChange <= 0
 		}
 
 		stat := htmlStat{
-			Label:       cfg.label,
+			Label:       loc.T(cfg.label),
 			FirstAvg:    firstAvg,
 			LastAvg:     lastAvg,
 			IsPositive:  isGood,
-			PctChange:   r.pctChange,
-			Unit:        cfg.unit,
-			InvertColor: cfg.invertColor,
+			PctChange:   loc.localizeNumeric(r.pctChange),
+			Unit:        loc.T(cfg.unit),
+			InvertColor: cfg.lowerIsBetter,
+			Neutral:     !r.significant(),
+		}
+		if r.pValue >= 0 {
+			stat.PValue = "p = " + loc.number(r.pValue, 3)
+		} else {
+			stat.PValue = loc.T("too few periods to test")
+			stat.Untested = stat.Neutral
 		}
 
-		if cfg.category == "activity" {
+		activity := cfg.category == "activity"
+		if extras.categories != nil {
+			activity = !inBanner[r.metric]
+		}
+		if activity {
 			data.ActivityLine = append(data.ActivityLine, htmlActivity{
-				Label:     cfg.label,
+				Label:     loc.T(cfg.label),
 				FirstAvg:  firstAvg,
 				LastAvg:   lastAvg,
-				PctChange: r.pctChange,
+				PctChange: loc.localizeNumeric(r.pctChange),
 				IsUp:      r.absChange >= 0,
 			})
+		} else if extras.categories != nil {
+			metricStats[r.metric] = append(metricStats[r.metric], stat)
 		} else {
 			catStats[cfg.category] = append(catStats[cfg.category], stat)
 		}
 		data.Stats = append(data.Stats, stat)
 	}
 
-	for _, c := range catOrder {
-		stats, hasStats := catStats[c.name]
-		ctStats := catStats["Cycle Time"] // attach to Speed category
-		if !hasStats && (c.name != "Speed" || len(ctStats) == 0) {
+	for _, c := range extras.categories {
+		var stats []htmlStat
+		for _, name := range c.Metrics {
+			stats = append(stats, metricStats[name]...)
+		}
+		if len(stats) == 0 {
 			continue
 		}
-		cat := htmlCategory{
-			Name:        c.name,
-			AccentColor: c.accent,
-			TintColor:   c.tint,
+		data.Categories = append(data.Categories, htmlCategory{
+			Name:        loc.T(c.Name),
+			AccentColor: c.Accent,
+			TintColor:   categoryTint(c.Accent),
 			Stats:       stats,
+		})
+	}
+	if extras.categories == nil {
+		for _, c := range catOrder {
+			stats, hasStats := catStats[c.name]
+			ctStats := catStats["Cycle Time"] // attach to Speed category
+			if !hasStats && (c.name != "Speed" || len(ctStats) == 0) {
+				continue
+			}
+			cat := htmlCategory{
+				Name:        loc.T(c.name),
+				AccentColor: c.accent,
+				TintColor:   c.tint,
+				Stats:       stats,
+			}
+			if c.name == "Speed" {
+				cat.CycleTimeStats = ctStats
+			}
+			data.Categories = append(data.Categories, cat)
 		}
-		if c.name == "Speed" {
-			cat.CycleTimeStats = ctStats
-		}
-		data.Categories = append(data.Categories, cat)
 	}
 
-	for _, c := range topContributors {
-		pctStr := fmt.Sprintf("%+.1f%%", c.pctChange)
+	for _, c := range extras.topContributors {
+		pctStr := loc.localizeNumeric(fmt.Sprintf("%+.1f%%", c.pctChange))
 		if !c.hasOnaPRs {
-			pctStr = "No Ona PRs"
+			pctStr = loc.T("No Ona PRs")
 		} else if c.beforeRate == 0 {
 			pctStr = "N/A"
 		}
 		data.Contributors = append(data.Contributors, htmlContributor{
 			Login:      c.login,
 			TotalPRs:   c.totalPRs,
-			BeforeRate: fmt.Sprintf("%.2f", c.beforeRate),
-			AfterRate:  fmt.Sprintf("%.2f", c.afterRate),
+			BeforeRate: loc.number(c.beforeRate, 2),
+			AfterRate:  loc.number(c.afterRate, 2),
 			PctChange:  pctStr,
 			IsUp:       c.afterRate >= c.beforeRate,
 			HasOnaPRs:  c.hasOnaPRs,
+			OnaPct:     loc.number(c.onaPct, 1),
+			Excluded:   c.excluded,
+		})
+	}
+
+	labelOf := func(metric string) string {
+		if md, ok := metricByName(metric); ok && md.label != "" {
+			return loc.T(md.label)
+		}
+		return metric
+	}
+	deltaLabel := "Δ %s week over week"
+	if periodLabel == "month" {
+		deltaLabel = "Δ %s month over month"
+	} else if periodLabel == "sprint" {
+		deltaLabel = "Δ %s sprint over sprint"
+	}
+	for _, md := range extras.deltaMetrics {
+		prev, _ := periodDeltas(weeklyStats, md, nil)
+		d := htmlDelta{Label: fmt.Sprintf(loc.T(deltaLabel), labelOf(md.name)), Unit: md.unit}
+		for _, v := range prev {
+			if math.IsNaN(v) {
+				d.Values = append(d.Values, nil)
+				continue
+			}
+			v = math.Round(v*100) / 100
+			d.Values = append(d.Values, &v)
+		}
+		data.Deltas = append(data.Deltas, d)
+	}
+	for _, c := range extras.correlations {
+		data.Correlations = append(data.Correlations, htmlCorrelation{
+			MetricA:     labelOf(c.metricA),
+			MetricB:     labelOf(c.metricB),
+			N:           c.n,
+			R:           loc.localizeNumeric(fmt.Sprintf("%+.2f", c.r)),
+			PValue:      loc.number(c.pValue, 3),
+			Significant: c.pValue < 0.05,
+		})
+	}
+
+	if m := extras.corrMatrix; m != nil && len(m.metrics) >= 2 {
+		data.CorrMatrix = corrMatrixTable(*m, labelOf, loc)
+	}
+
+	if res := extras.sensitivity; res != nil {
+		data.Sensitivity = sensitivityTable(res, labelOf, loc)
+	}
+
+	data.OnaComparison = outcomeRows(extras.onaComparisons, loc)
+	if m := extras.onaMatches; m != nil && m.matched > 0 {
+		data.MatchedOutcomes = outcomeRows(m.comparisons, loc)
+		data.MatchingSummary = fmt.Sprintf(loc.T("%d of %d Ona-involved PRs matched to a similar other PR (%s same author, %s same top-level directory)."),
+			m.matched, m.onaPRs, loc.number(m.sameAuthorPct, 0)+"%", loc.number(m.sameAreaPct, 0)+"%")
+		for _, b := range m.balance {
+			data.MatchingBalance = append(data.MatchingBalance, htmlBalance{
+				Covariate: loc.T(b.name),
+				Before:    loc.localizeNumeric(fmt.Sprintf("%+.2f", b.before)),
+				After:     loc.localizeNumeric(fmt.Sprintf("%+.2f", b.after)),
+			})
+		}
+	}
+
+	data.MixedModels = mixedModelRows(extras.mixedModels, loc)
+
+	if len(extras.statsHistory) >= 2 {
+		for _, m := range headlineMetrics {
+			data.HistoryMetrics = append(data.HistoryMetrics, labelOf(m))
+		}
+		for _, e := range extras.statsHistory {
+			run := htmlHistoryRun{RunDate: e.RunDate, Periods: e.Weeks}
+			for _, m := range headlineMetrics {
+				change := "—"
+				if st, ok := e.statOf(m); ok {
+					change = loc.localizeNumeric(st.PctChange)
+				}
+				run.Changes = append(run.Changes, change)
+			}
+			data.StatsHistory = append(data.StatsHistory, run)
+		}
+	}
+
+	for _, r := range extras.targets {
+		t := r.target
+		unit := ""
+		if md, ok := metricByName(t.metric); ok {
+			unit = loc.T(md.unit)
+		}
+		row := htmlTarget{
+			Metric:     labelOf(t.metric),
+			Target:     t.op + " " + loc.number(t.value, 1) + unit,
+			Current:    "—",
+			PeriodsMet: fmt.Sprintf("%d/%d", r.periodsMet, r.periods),
+		}
+		if r.hasCurrent {
+			row.Current = loc.number(r.current, 1) + unit
+			row.Status = "fail"
+			if r.met {
+				row.Status = "pass"
+			}
+		}
+		data.Targets = append(data.Targets, row)
+		if axis := targetAxis(t.metric); axis != "" {
+			data.TargetLines = append(data.TargetLines, htmlTargetLine{
+				Label:  fmt.Sprintf(loc.T("Target: %s %s"), labelOf(t.metric), row.Target),
+				Axis:   axis,
+				Value:  t.value,
+				Hidden: axis != "yPPE" && axis != "yPct",
+			})
+		}
+	}
+
+	if len(extras.benchmarks) > 0 {
+		data.BenchmarkTitle = extras.benchmark.title
+		data.BenchmarkSource = extras.benchmark.source
+	}
+	for _, b := range extras.benchmarks {
+		prec := 1
+		if b.metric.unit == "/day" {
+			prec = 2
+		}
+		data.Benchmarks = append(data.Benchmarks, htmlBenchmark{
+			Metric:     loc.T(b.metric.label),
+			Value:      loc.number(b.value, prec) + loc.T(b.metric.unit),
+			Band:       loc.T(b.band),
+			BandClass:  strings.ToLower(b.band),
+			Thresholds: b.metric.bandThresholds(loc),
+			Proxy:      loc.T(b.metric.proxy),
 		})
 	}
+	data.Glossary = buildGlossary(weeklyStats, extras.glossary, loc)
+	if extras.audit != nil {
+		for _, s := range extras.audit.steps {
+			data.FilterAudit = append(data.FilterAudit, htmlFilterStep{
+				Filter:    loc.T(s.name),
+				Removed:   loc.number(float64(s.count), 0) + " " + loc.T(s.unit+"(s)"),
+				StatsOnly: s.statsOnly,
+				Items:     truncatedList(s.removed, 50),
+			})
+		}
+	}
 
-	tmpl, err := template.New("chart").Parse(htmlTemplate)
-	if err != nil {
-		return "", fmt.Errorf("parse template: %w", err)
+	hrs := func(v float64) string {
+		if v < 0 {
+			return "—"
+		}
+		return loc.number(v, 1) + "h"
+	}
+	for _, r := range extras.topReviewers {
+		data.Reviewers = append(data.Reviewers, htmlReviewer{
+			Login:      r.login,
+			Requests:   r.requests,
+			Answered:   r.answered,
+			MedianTime: hrs(r.medianHours),
+			P90Time:    hrs(r.p90Hours),
+		})
+	}
+	onaUser := func(s onaUserStat, latest int64) htmlOnaUser {
+		return htmlOnaUser{
+			Login:    s.login,
+			PRs:      s.prs,
+			OnaPRs:   s.onaPRs,
+			OnaPct:   loc.number(s.onaPct, 1) + "%",
+			Latest:   loc.date(time.Unix(latest, 0).UTC()),
+			Excluded: s.excluded,
+		}
+	}
+	for _, s := range extras.onaPowerUsers {
+		data.OnaPowerUsers = append(data.OnaPowerUsers, onaUser(s, s.lastOna))
+	}
+	for _, s := range extras.onaUntouched {
+		data.OnaUntouched = append(data.OnaUntouched, onaUser(s, s.lastPR))
+	}
+	data.IssueGroupLabel = loc.T(extras.issueGroupLabel)
+	for _, it := range extras.issueGroups {
+		data.IssueGroups = append(data.IssueGroups, htmlIssueGroup{
+			Group:            it.group,
+			PRs:              it.prs,
+			PctOfPRs:         loc.number(it.pctOfPRs, 1) + "%",
+			MedianCodingTime: hrs(it.medianCodingTime),
+			MedianReviewTime: hrs(it.medianReviewTime),
+			MedianLeadTime:   hrs(it.medianLeadTime),
+		})
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("execute template: %w", err)
+	data.Data = buildReportData(data, periodLabel, summaryRows)
+	data.Data.Trends = trends
+	if embedsSchemaVersion(extras.schemaVersion) {
+		data.Data.SchemaVersion = extras.schemaVersion
 	}
-	return buf.String(), nil
+	return renderReportTemplate(data)
 }
 
 const htmlTemplate = `<!DOCTYPE html>
-<html lang="en">
+<html lang="{{.Lang}}">
 <head>
 <meta charset="UTF-8">
 <meta name="viewport" content="width=device-width, initial-scale=1.0">
 <title>{{.Title}}</title>
-<script src="https://cdn.jsdelivr.net/npm/chart.js"></script>
+{{if not .Summary}}{{if .ChartJS}}<script>{{.ChartJS}}</script>{{else}}<script src="https://cdn.jsdelivr.net/npm/chart.js"></script>{{end}}{{end}}
 <style>
   * { margin: 0; padding: 0; box-sizing: border-box; }
   body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #f8f9fa; color: #1a1a2e; padding: 24px; }
@@ -247,6 +1165,10 @@ const htmlTemplate = `<!DOCTYPE html>
   .filter-notes ul { margin: 4px 0 0 0; padding-left: 20px; }
   .filter-notes li { margin: 2px 0; }
   .filter-notes .filter-title { font-weight: 600; color: #374151; }
+  .findings { background: #fff; border-left: 4px solid #2563eb; border-radius: 8px; padding: 12px 16px; margin-bottom: 16px; font-size: 0.9rem; color: #1f2937; box-shadow: 0 1px 3px rgba(0,0,0,0.06); }
+  .findings ul { margin: 4px 0 0 0; padding-left: 20px; }
+  .findings li { margin: 3px 0; }
+  .findings .findings-title { font-weight: 600; }
   .window-desc { font-size: 0.85rem; color: #6b7280; text-align: center; margin-bottom: 16px; }
 
   .banner-strip { display: flex; align-items: center; gap: 20px; border-radius: 8px; padding: 16px 20px; margin-bottom: 10px; border-left: 5px solid; box-shadow: 0 1px 3px rgba(0,0,0,0.06); }
@@ -260,6 +1182,8 @@ const htmlTemplate = `<!DOCTYPE html>
   .banner-pct { font-size: 1.5rem; font-weight: 700; }
   .banner-pct.positive { color: #16a34a; }
   .banner-pct.negative { color: #dc2626; }
+  .banner-pct.neutral { color: #6b7280; }
+  .sig-badge { font-size: 0.65rem; font-weight: 600; text-transform: uppercase; letter-spacing: 0.04em; color: #6b7280; background: #e5e7eb; border-radius: 4px; padding: 2px 6px; cursor: help; }
   .banner-detail { font-size: 0.85rem; color: #6b7280; margin-left: 8px; }
   .banner-arrow { color: #9ca3af; margin: 0 4px; }
 
@@ -271,10 +1195,23 @@ const htmlTemplate = `<!DOCTYPE html>
   .activity-line .activity-pct.down { color: #dc2626; }
 
   .chart-container { background: #fff; border-radius: 8px; padding: 24px; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
+  .drilldown-hint { font-size: 0.75rem; color: #9ca3af; text-align: center; margin-top: 8px; }
+  .pr-tag { font-size: 0.65rem; font-weight: 600; text-transform: uppercase; border-radius: 4px; padding: 1px 5px; margin-left: 6px; }
+  .pr-tag.ona { color: #7e22ce; background: #f3e8ff; }
+  .pr-tag.revert { color: #991b1b; background: #fee2e2; }
   canvas { width: 100% !important; }
 
   .contributors-section { margin-top: 24px; }
   .contributors-section h2 { font-size: 1rem; font-weight: 600; margin-bottom: 12px; color: #374151; }
+  .heatmap { border-collapse: collapse; font-size: 0.7rem; margin-bottom: 4px; }
+  .heatmap th { font-weight: 500; color: #6b7280; padding: 2px 4px; }
+  .heatmap td { width: 28px; height: 20px; text-align: center; border: 1px solid #fff; color: #1f2937; }
+  .heatmap td.dark { color: #fff; }
+  .corr-matrix td { width: 40px; }
+  .corr-matrix td.self { background: #f3f4f6; }
+  .corr-matrix th.corr-label { text-align: left; white-space: nowrap; }
+  .heatmap-title { font-size: 0.85rem; font-weight: 600; color: #374151; margin: 12px 0 6px; }
+  .histogram-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(340px, 1fr)); gap: 16px; }
   .contributors-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(220px, 1fr)); gap: 12px; }
   .contrib-card { background: #fff; border-radius: 8px; padding: 14px 18px; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
   .contrib-login { font-size: 0.95rem; font-weight: 600; color: #1a1a2e; }
@@ -285,10 +1222,32 @@ const htmlTemplate = `<!DOCTYPE html>
   .contrib-pct.up { color: #16a34a; }
   .contrib-pct.down { color: #dc2626; }
   .contrib-pct.neutral { color: #9ca3af; }
+  .contrib-card.excluded, .data-table tr.excluded { opacity: 0.6; }
+  .contrib-excluded { font-size: 0.7rem; color: #6b7280; margin-top: 4px; }
+
+  .issue-types-section { margin-top: 24px; }
+  .issue-types-section h2 { font-size: 1rem; font-weight: 600; margin-bottom: 12px; color: #374151; }
+  .data-table { width: 100%; border-collapse: collapse; background: #fff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1); font-size: 0.85rem; }
+  .data-table th { text-align: left; font-size: 0.7rem; font-weight: 600; text-transform: uppercase; letter-spacing: 0.05em; color: #6b7280; padding: 10px 14px; border-bottom: 1px solid #e5e7eb; }
+  .data-table td { padding: 8px 14px; border-bottom: 1px solid #f3f4f6; color: #1a1a2e; }
+  .data-table td.num, .data-table th.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .sensitivity tr.baseline td { background: #f0f4ff; }
+  .sensitivity td.flip { background: #fef3c7; }
+  .sensitivity tr.agreement td { font-weight: 600; border-top: 1px solid #e5e7eb; }
+  .goal-status { font-size: 0.7rem; font-weight: 700; text-transform: uppercase; letter-spacing: 0.05em; border-radius: 4px; padding: 2px 8px; }
+  .goal-status.pass { color: #166534; background: #dcfce7; }
+  .goal-status.fail { color: #991b1b; background: #fee2e2; }
+  .bench-band { font-size: 0.7rem; font-weight: 700; text-transform: uppercase; letter-spacing: 0.05em; border-radius: 4px; padding: 2px 8px; }
+  .bench-band.elite { color: #166534; background: #dcfce7; }
+  .bench-band.high { color: #1e40af; background: #dbeafe; }
+  .bench-band.medium { color: #92400e; background: #fef3c7; }
+  .bench-band.low { color: #991b1b; background: #fee2e2; }
+  .bench-source { font-size: 0.75rem; color: #6b7280; margin-top: 8px; }
 
   .metric-defs { margin-top: 24px; }
   .metric-defs summary { font-size: 0.95rem; font-weight: 600; color: #374151; cursor: pointer; padding: 12px 0; }
   .metric-defs summary:hover { color: #1a1a2e; }
+  .audit-items { font-size: 0.8rem; color: #6b7280; word-break: break-word; }
   .metric-defs-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(340px, 1fr)); gap: 12px; margin-top: 12px; }
   .metric-def-card { background: #fff; border-radius: 8px; padding: 16px 20px; box-shadow: 0 1px 3px rgba(0,0,0,0.08); border-left: 4px solid #d1d5db; }
   .metric-def-card h3 { font-size: 0.9rem; font-weight: 600; color: #1a1a2e; margin-bottom: 6px; }
@@ -296,14 +1255,23 @@ const htmlTemplate = `<!DOCTYPE html>
   .metric-def-card .def-label { font-size: 0.7rem; font-weight: 700; text-transform: uppercase; letter-spacing: 0.05em; color: #9ca3af; margin-bottom: 2px; }
   .metric-def-card .def-good { color: #16a34a; }
   .metric-def-card .def-warn { color: #b45309; }
+  .metric-def-card .def-caveats { font-size: 0.78rem; color: #6b7280; line-height: 1.5; margin: 0 0 6px 16px; }
 </style>
 </head>
 <body>
 <div class="container">
   <h1>{{.Title}}</h1>
+  {{if .Findings}}
+  <div class="findings">
+    <span class="findings-title">{{t "Key findings"}}</span>
+    <ul>
+    {{range .Findings}}<li>{{.}}</li>
+    {{end}}</ul>
+  </div>
+  {{end}}
   {{if .FilterNotes}}
   <div class="filter-notes">
-    <span class="filter-title">Data filters applied:</span>
+    <span class="filter-title">{{t "Data filters applied:"}}</span>
     <ul>
     {{range .FilterNotes}}<li>{{.}}</li>
     {{end}}</ul>
@@ -318,16 +1286,18 @@ const htmlTemplate = `<!DOCTYPE html>
       <div class="banner-row">
         {{range $i, $s := .Stats}}{{if $i}}<span class="banner-sep">|</span>{{end}}
         <span class="banner-metric">{{$s.Label}}</span>
-        <span class="banner-pct {{if $s.IsPositive}}positive{{else}}negative{{end}}">{{$s.PctChange}}</span>
+        <span class="banner-pct {{if $s.Neutral}}neutral{{else if $s.IsPositive}}positive{{else}}negative{{end}}">{{$s.PctChange}}</span>{{if $s.Neutral}}
+        <span class="sig-badge" title="{{$s.PValue}}">{{if $s.Untested}}{{t "insufficient data"}}{{else}}{{t "not significant"}}{{end}}</span>{{end}}
         <span class="banner-detail">{{$s.FirstAvg}} <span class="banner-arrow">&rarr;</span> {{$s.LastAvg}}</span>
         {{end}}
       </div>
       {{if .CycleTimeStats}}
       <div class="banner-row">
-        <span class="banner-sublabel">Cycle Time:</span>
+        <span class="banner-sublabel">{{t "Cycle Time:"}}</span>
         {{range $i, $s := .CycleTimeStats}}{{if $i}}<span class="banner-sep">|</span>{{end}}
         <span class="banner-metric-sub">{{$s.Label}}</span>
-        <span class="banner-pct {{if $s.IsPositive}}positive{{else}}negative{{end}}">{{$s.PctChange}}</span>
+        <span class="banner-pct {{if $s.Neutral}}neutral{{else if $s.IsPositive}}positive{{else}}negative{{end}}">{{$s.PctChange}}</span>{{if $s.Neutral}}
+        <span class="sig-badge" title="{{$s.PValue}}">{{if $s.Untested}}{{t "insufficient data"}}{{else}}{{t "not significant"}}{{end}}</span>{{end}}
         <span class="banner-detail">{{$s.FirstAvg}} <span class="banner-arrow">&rarr;</span> {{$s.LastAvg}}</span>
         {{end}}
       </div>
@@ -338,110 +1308,444 @@ const htmlTemplate = `<!DOCTYPE html>
   {{end}}
   {{if .ActivityLine}}
   <div class="activity-line">
-    <span class="activity-label">Activity</span>
+    <span class="activity-label">{{t "Activity"}}</span>
     {{range $i, $a := .ActivityLine}}{{if $i}}<span class="activity-sep">&middot;</span>{{end}}{{$a.Label}}: {{$a.FirstAvg}} <span class="banner-arrow">&rarr;</span> {{$a.LastAvg}} <span class="activity-pct {{if $a.IsUp}}up{{else}}down{{end}}">({{$a.PctChange}})</span>{{end}}
   </div>
   {{end}}
+  {{if not .Summary}}
   <div class="chart-container">
     <canvas id="chart"></canvas>
+    {{if .HolidayNote}}<p class="drilldown-hint">{{.HolidayNote}}</p>{{end}}
+    {{if .UnstableNote}}<p class="drilldown-hint">{{.UnstableNote}}</p>{{end}}
+    {{if .TrendNote}}<p class="drilldown-hint">{{.TrendNote}}</p>{{end}}
+    {{if .ProtectionNote}}<p class="drilldown-hint">{{.ProtectionNote}}</p>{{end}}
+    {{if .PRLists}}<p class="drilldown-hint">{{t "Click a point on the chart to list the PRs merged in that period."}}</p>{{end}}
+  </div>
+  {{if .PRLists}}
+  <div id="pr-drilldown" class="issue-types-section" hidden>
+    <h2 id="pr-drilldown-title"></h2>
+    <table class="data-table">
+      <thead><tr><th>{{t "PR"}}</th><th>{{t "Title"}}</th><th>{{t "Author"}}</th><th class="num">{{t "Lines changed"}}</th><th class="num">{{t "Coding Time"}}</th><th class="num">{{t "Review Time"}}</th><th>{{t "Merged"}}</th></tr></thead>
+      <tbody id="pr-drilldown-rows"></tbody>
+    </table>
+  </div>
+  {{end}}
+  {{if .Histograms}}
+  <div class="issue-types-section">
+    <h2>{{t "Distributions"}}</h2>
+    <div class="histogram-grid">
+      {{range $i, $h := .Histograms}}
+      <div class="chart-container">
+        <canvas id="hist-{{$i}}"></canvas>
+        <p class="drilldown-hint">{{$h.Note}}</p>
+      </div>
+      {{end}}
+    </div>
+  </div>
+  {{end}}
+  {{if .Heatmaps}}
+  <div class="issue-types-section">
+    <h2>{{t "Activity by Weekday and Hour"}}</h2>
+    {{range .Heatmaps}}
+    <div class="heatmap-title">{{.Title}}</div>
+    <table class="heatmap">
+      <thead><tr><th></th>{{range $.HeatmapHours}}<th>{{.}}</th>{{end}}</tr></thead>
+      <tbody>
+        {{range .Rows}}<tr><th>{{.Day}}</th>{{range .Cells}}<td{{if .Dark}} class="dark"{{end}} style="background: rgba(37,99,235,{{.Shade}})">{{if .Count}}{{.Count}}{{end}}</td>{{end}}</tr>
+        {{end}}
+      </tbody>
+    </table>
+    <p class="drilldown-hint">{{.Note}}</p>
+    {{end}}
+  </div>
+  {{end}}
+  {{if .Scatter}}
+  <div class="issue-types-section">
+    <h2>{{t "Cycle Time per PR"}}</h2>
+    <div class="chart-container">
+      <canvas id="scatter"></canvas>
+    </div>
+  </div>
+  {{end}}
+  {{if .YoY}}
+  <div class="issue-types-section">
+    <h2>{{t "Year over Year"}}</h2>
+    <div class="chart-container">
+      <canvas id="yoy"></canvas>
+    </div>
+    <p class="drilldown-hint">{{t "Each period next to the same period a year earlier (52 weeks, or the same calendar month), dashed. A change that also shows up last year is likely seasonal. Click a legend entry to switch metrics."}}</p>
+  </div>
+  {{end}}
+  {{if .Cohorts}}
+  <div class="issue-types-section">
+    <h2>{{t "Throughput by Join Quarter"}}</h2>
+    <div class="chart-container">
+      <canvas id="cohorts"></canvas>
+    </div>
+    <p class="drilldown-hint">{{t "Contributors are grouped by the quarter of their first merged PR in the window. Each point is the cohort's mean PRs per week per contributor in a 4-week block since joining; a curve ends where its members' blocks run past the window. The first quarter also holds everyone who was already active before the window, so it is hidden by default."}}</p>
+  </div>
+  {{end}}
+  {{if .Runners}}
+  <div class="issue-types-section">
+    <h2>{{t "Self-Hosted Runner Utilization"}}</h2>
+    <div class="chart-container">
+      <canvas id="runners"></canvas>
+    </div>
+    <p class="drilldown-hint">{{t "Weekly, from the jobs of up to 200 sampled push and pull request runs per week. Utilization is the jobs' run time, scaled up to all of the week's runs, over the runners seen times 168 hours, capped at 100%. Dashed lines are the median time jobs waited for a runner. Scheduled and manually dispatched runs aren't sampled."}}</p>
+  </div>
+  {{end}}
+  {{if .Flow}}
+  <div class="issue-types-section">
+    <h2>{{t "Cumulative Flow"}}</h2>
+    <div class="chart-container">
+      <canvas id="cfd"></canvas>
+    </div>
   </div>
+  {{end}}
+  {{if .Lifecycle}}
+  <div class="issue-types-section">
+    <h2>{{t "PR Lifecycle"}}</h2>
+    <div id="lifecycle-sankey"></div>
+    <p class="drilldown-hint">{{.LifecycleNote}}</p>
+    <table class="data-table">
+      <tr><th>{{t "State"}}</th><th class="num">{{t "PRs"}}</th><th class="num">{{t "Median time in state"}}</th><th class="num">{{t "P90 time in state"}}</th><th class="num">{{t "Share of open time"}}</th></tr>
+      {{range .Lifecycle}}
+      <tr><td>{{if .Bottleneck}}<strong>{{.State}}</strong>{{else}}{{.State}}{{end}}</td><td class="num">{{.PRs}}</td><td class="num">{{.MedianTime}}</td><td class="num">{{.P90Time}}</td><td class="num">{{if .Bottleneck}}<strong>{{.Share}}</strong>{{else}}{{.Share}}{{end}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
   {{if .Contributors}}
   <div class="contributors-section">
-    <h2>Top Contributors — Before &amp; After Ona</h2>
+    <h2>{{t "Top Contributors — Before & After Ona"}}</h2>
     <div class="contributors-grid">
       {{range .Contributors}}
-      <div class="contrib-card">
+      <div class="contrib-card{{if .Excluded}} excluded{{end}}">
         <div class="contrib-login">@{{.Login}}</div>
-        <div class="contrib-total">{{.TotalPRs}} PRs total</div>
+        <div class="contrib-total">{{.TotalPRs}} {{t "PRs total"}} · {{.OnaPct}}% {{t "Ona"}}</div>
         <div class="contrib-rates">
           <span>{{.BeforeRate}}</span>
           <span class="stat-arrow">&rarr;</span>
           <span>{{.AfterRate}}</span>
-          <span class="unit">PRs/week</span>
+          <span class="unit">{{t "PRs/week"}}</span>
         </div>
         <div class="contrib-pct {{if not .HasOnaPRs}}neutral{{else if .IsUp}}up{{else}}down{{end}}">{{.PctChange}}</div>
+        {{if .Excluded}}<div class="contrib-excluded">{{t "Excluded from the metrics (bottom contributors)"}}</div>{{end}}
       </div>
       {{end}}
     </div>
   </div>
   {{end}}
-  <details class="metric-defs">
-    <summary>Metric Definitions</summary>
-    <div class="metric-defs-grid">
-      <div class="metric-def-card">
-        <h3>PRs per Engineer</h3>
-        <p>Merged PRs divided by unique authors in the period. Measures individual throughput normalized by team size.</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Controls for team growth — a team doubling in size won't appear twice as productive. Useful for comparing periods with different headcounts.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Doesn't account for PR size or complexity. A week of small refactors scores the same as a week of large features. Infrequent contributors (1 PR) inflate the denominator.</p>
-      </div>
-      <div class="metric-def-card">
-        <h3>% Ona Involved</h3>
-        <p>Percentage of PRs where Ona was a co-author (via <code>Co-authored-by</code> trailer) or the primary author (login prefix <code>ona-</code>).</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Tracks adoption of Ona-assisted development over time. Correlating with other metrics shows whether Ona usage coincides with throughput or quality changes.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Measures presence, not impact. A PR with a trivial Ona contribution counts the same as one where Ona wrote most of the code. Relies on the co-author trailer being present.</p>
-      </div>
-      <div class="metric-def-card">
-        <h3>% Reverts</h3>
-        <p>Percentage of PRs whose title matches revert/rollback patterns. A proxy for code quality and deployment stability.</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Captures production issues that required rolling back changes. Trending upward may signal quality regression or insufficient testing.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Title-based detection only — misses reverts with non-standard titles and may false-positive on PRs that mention "revert" without being one. Doesn't distinguish severity.</p>
-      </div>
-      <div class="metric-def-card">
-        <h3>Coding Time</h3>
-        <p>Time from first commit (<code>authoredDate</code>) to when the PR was marked ready for review (<code>ReadyForReviewEvent</code>). Measures pre-review development duration.</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Isolates the development phase from the review phase. Helps identify whether slowdowns are in coding or review. Not inflated by review wait times.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Only computed for PRs that were created as drafts and later marked ready. Non-draft PRs are excluded. Rebased or amended commits may shift the first commit timestamp. Median can be low if most PRs are opened shortly after the first commit.</p>
-      </div>
-      <div class="metric-def-card">
-        <h3>Review Time</h3>
-        <p>Time from when the PR was marked ready for review (<code>ReadyForReviewEvent</code>) to merged. Measures how long PRs spend in code review.</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Directly measures review bottlenecks. High review time may indicate reviewer availability issues, large PRs, or complex changes requiring multiple review rounds.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Only computed for PRs that were created as drafts. Includes time the author spends addressing feedback, not just reviewer wait time. Doesn't distinguish between active review and idle waiting.</p>
+  {{if .Reviewers}}
+  <div class="issue-types-section">
+    <h2>{{t "Reviewer Responsiveness"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Reviewer"}}</th><th class="num">{{t "Review requests"}}</th><th class="num">{{t "Answered"}}</th><th class="num">{{t "Median response"}}</th><th class="num">{{t "P90 response"}}</th></tr>
+      {{range .Reviewers}}
+      <tr><td>@{{.Login}}</td><td class="num">{{.Requests}}</td><td class="num">{{.Answered}}</td><td class="num">{{.MedianTime}}</td><td class="num">{{.P90Time}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if or .OnaPowerUsers .OnaUntouched}}
+  <div class="issue-types-section">
+    <h2>{{t "Ona Adoption by Contributor"}}</h2>
+    <div class="histogram-grid">
+      <div>
+        <div class="heatmap-title">{{t "Power users"}}</div>
+        <table class="data-table">
+          <tr><th>{{t "Contributor"}}</th><th class="num">{{t "Ona PRs"}}</th><th class="num">{{t "Share"}}</th><th class="num">{{t "Latest Ona PR"}}</th></tr>
+          {{range .OnaPowerUsers}}
+          <tr{{if .Excluded}} class="excluded"{{end}}><td>@{{.Login}}</td><td class="num">{{.OnaPRs}} / {{.PRs}}</td><td class="num">{{.OnaPct}}</td><td class="num">{{.Latest}}</td></tr>
+          {{else}}
+          <tr><td colspan="4">{{t "No contributor has an Ona-involved PR yet."}}</td></tr>
+          {{end}}
+        </table>
       </div>
-      <div class="metric-def-card">
-        <h3>PRs Merged</h3>
-        <p>Total number of merged (non-draft, non-bot) pull requests per period. Raw volume metric.</p>
-        <div class="def-label def-good">Benefits</div>
-        <p>Simple, unambiguous count. Useful for spotting holidays, freezes, or unusual activity spikes.</p>
-        <div class="def-label def-warn">Drawbacks</div>
-        <p>Not normalized by team size. Conflates small fixes with large features. Higher isn't necessarily better — could indicate PR splitting or churn.</p>
+      <div>
+        <div class="heatmap-title">{{t "Not using Ona yet"}}</div>
+        <table class="data-table">
+          <tr><th>{{t "Contributor"}}</th><th class="num">{{t "PRs"}}</th><th class="num">{{t "Latest PR"}}</th></tr>
+          {{range .OnaUntouched}}
+          <tr{{if .Excluded}} class="excluded"{{end}}><td>@{{.Login}}</td><td class="num">{{.PRs}}</td><td class="num">{{.Latest}}</td></tr>
+          {{else}}
+          <tr><td colspan="3">{{t "Every contributor has an Ona-involved PR."}}</td></tr>
+          {{end}}
+        </table>
       </div>
     </div>
-  </details>
-</div>
-<script>
-const weeks = [{{range $i, $w := .Weeks}}{{if $i}},{{end}}{
-  week: "{{$w.WeekStart}}",
-  prsMerged: {{$w.PRsMerged}},
-  prsPerEngineer: {{$w.PRsPerEngineer}},
-  codingTime: {{$w.MedianCodingTime}},
-  reviewTime: {{$w.MedianReviewTime}},
-  pctOna: {{$w.PctOnaInvolved}},
-  pctReverts: {{$w.PctReverts}},
-  buildRuns: {{$w.BuildRuns}}
-}{{end}}];
-
-const labels = weeks.map(w => w.week);
-
-// Linear regression for PRs per Engineer trendline
-const ppeData = weeks.map(w => w.prsPerEngineer);
-const n = ppeData.length;
-let sumX = 0, sumY = 0, sumXY = 0, sumXX = 0;
-for (let i = 0; i < n; i++) {
-  sumX += i; sumY += ppeData[i]; sumXY += i * ppeData[i]; sumXX += i * i;
-}
-const slope = (n * sumXY - sumX * sumY) / (n * sumXX - sumX * sumX);
-const intercept = (sumY - slope * sumX) / n;
+    <p class="drilldown-hint">{{t "Power users have the most Ona-involved PRs in the window; the second list holds the most active contributors without any. Greyed-out rows are excluded from the metrics as bottom contributors."}}</p>
+  </div>
+  {{end}}
+  {{if .IssueGroups}}
+  <div class="issue-types-section">
+    <h2>{{t "Throughput & Cycle Time by"}} {{.IssueGroupLabel}}</h2>
+    <table class="data-table">
+      <tr><th>{{.IssueGroupLabel}}</th><th class="num">{{t "PRs"}}</th><th class="num">{{t "Share"}}</th><th class="num">{{t "Median coding time"}}</th><th class="num">{{t "Median review time"}}</th><th class="num">{{t "Median lead time (started → merged)"}}</th></tr>
+      {{range .IssueGroups}}
+      <tr><td>{{.Group}}</td><td class="num">{{.PRs}}</td><td class="num">{{.PctOfPRs}}</td><td class="num">{{.MedianCodingTime}}</td><td class="num">{{.MedianReviewTime}}</td><td class="num">{{.MedianLeadTime}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .OnaComparison}}
+  <div class="issue-types-section">
+    <h2>{{t "Ona-Involved vs Other PRs"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Metric"}}</th><th class="num">{{t "Ona-involved"}}</th><th class="num">n</th><th class="num">{{t "Other"}}</th><th class="num">n</th><th class="num">{{t "Difference"}}</th><th class="num">{{t "p-value"}}</th></tr>
+      {{range .OnaComparison}}
+      <tr><td>{{.Metric}}</td><td class="num">{{.Ona}}</td><td class="num">{{.OnaN}}</td><td class="num">{{.Other}}</td><td class="num">{{.OtherN}}</td><td class="num">{{.Difference}}</td><td class="num">{{if .Significant}}<strong>{{.PValue}}</strong>{{else}}{{.PValue}}{{end}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .MatchedOutcomes}}
+  <div class="issue-types-section">
+    <h2>{{t "Ona-Involved vs Matched PRs"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Metric"}}</th><th class="num">{{t "Ona-involved"}}</th><th class="num">n</th><th class="num">{{t "Matched"}}</th><th class="num">n</th><th class="num">{{t "Difference"}}</th><th class="num">{{t "p-value"}}</th></tr>
+      {{range .MatchedOutcomes}}
+      <tr><td>{{.Metric}}</td><td class="num">{{.Ona}}</td><td class="num">{{.OnaN}}</td><td class="num">{{.Other}}</td><td class="num">{{.OtherN}}</td><td class="num">{{.Difference}}</td><td class="num">{{if .Significant}}<strong>{{.PValue}}</strong>{{else}}{{.PValue}}{{end}}</td></tr>
+      {{end}}
+    </table>
+    <p class="bench-source">{{.MatchingSummary}} {{t "Covariate balance (standardized mean difference, |SMD| < 0.1 is balanced):"}}
+    {{range $i, $b := .MatchingBalance}}{{if $i}} · {{end}}{{$b.Covariate}} {{$b.Before}} → {{$b.After}}{{end}}</p>
+  </div>
+  {{end}}
+  {{if .MixedModels}}
+  <div class="issue-types-section">
+    <h2>{{t "Ona-Involved PRs: Mixed-Effects Model"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Outcome"}}</th><th class="num">{{t "Ona effect"}}</th><th class="num">{{t "95% CI"}}</th><th class="num">{{t "Coefficient (log)"}}</th><th class="num">{{t "p-value"}}</th><th class="num">PRs</th><th class="num">{{t "Ona-involved"}}</th><th class="num">{{t "Contributors"}}</th><th class="num">{{t "Between contributors"}}</th></tr>
+      {{range .MixedModels}}
+      <tr><td>{{.Outcome}}</td><td class="num">{{.Effect}}</td><td class="num">{{.CI}}</td><td class="num">{{.Coef}}</td><td class="num">{{if .Significant}}<strong>{{.PValue}}</strong>{{else}}{{.PValue}}{{end}}</td><td class="num">{{.PRs}}</td><td class="num">{{.OnaPRs}}</td><td class="num">{{.Contributors}}</td><td class="num">{{.ICC}}</td></tr>
+      {{end}}
+    </table>
+    <p class="bench-source">{{t "Per-PR linear mixed model of log(1 + outcome) on Ona involvement and merge time, plus log lines changed for the time outcomes, with a random intercept per contributor (REML). The effect is how an Ona-involved PR differs from a PR of the same size and time, mostly compared within each contributor's own PRs; intervals are 95% Wald intervals and p < 0.05 is bold. Between contributors is the share of unexplained variance due to who wrote the PR. An association, not a causal effect."}}</p>
+  </div>
+  {{end}}
+  {{if .Correlations}}
+  <div class="issue-types-section">
+    <h2>{{t "Correlations"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Metric"}}</th><th>{{t "Against"}}</th><th class="num">{{t "Periods"}}</th><th class="num">{{t "Pearson r"}}</th><th class="num">{{t "p-value"}}</th></tr>
+      {{range .Correlations}}
+      <tr><td>{{.MetricB}}</td><td>{{.MetricA}}</td><td class="num">{{.N}}</td><td class="num">{{.R}}</td><td class="num">{{if .Significant}}<strong>{{.PValue}}</strong>{{else}}{{.PValue}}{{end}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{with .CorrMatrix}}
+  <div class="issue-types-section">
+    <h2>{{t "Correlation Matrix"}}</h2>
+    <table class="heatmap corr-matrix">
+      <thead><tr><th></th>{{range .Rows}}<th title="{{.Label}}">{{.Num}}</th>{{end}}</tr></thead>
+      <tbody>
+        {{range .Rows}}<tr><th class="corr-label">{{.Num}} {{.Label}}</th>{{range .Cells}}{{if .Self}}<td class="self"></td>{{else}}<td{{if .Dark}} class="dark"{{end}} title="{{.Title}}" style="background: rgba({{if .Negative}}220,38,38{{else}}37,99,235{{end}},{{.Shade}})">{{if .Significant}}<strong>{{.R}}</strong>{{else}}{{.R}}{{end}}</td>{{end}}{{end}}</tr>
+        {{end}}
+      </tbody>
+    </table>
+    <p class="drilldown-hint">{{.Note}}</p>
+  </div>
+  {{end}}
+  {{with .Sensitivity}}
+  <div class="issue-types-section">
+    <h2>{{t "Filter Sensitivity"}}</h2>
+    <table class="data-table sensitivity">
+      <tr><th class="num">{{t "Bottom contributors excluded"}}</th><th class="num">{{t "Min PRs"}}</th><th class="num">PRs</th><th class="num">{{t "Periods"}}</th>{{range .Metrics}}<th class="num">{{.}}</th>{{end}}</tr>
+      {{range .Rows}}
+      <tr{{if .Baseline}} class="baseline"{{end}}><td class="num">{{.BottomPct}}%</td><td class="num">{{.MinPRs}}</td><td class="num">{{.PRs}}</td><td class="num">{{.Periods}}</td>{{range .Cells}}<td class="num{{if .Flip}} flip{{end}}">{{if .Significant}}<strong>{{.Change}}</strong>{{else}}{{.Change}}{{end}}</td>{{end}}</tr>
+      {{end}}
+      <tr class="agreement"><td colspan="4">{{t "Same conclusion as this run"}}</td>{{range .Agreement}}<td class="num">{{.}}</td>{{end}}</tr>
+    </table>
+    <p class="bench-source">{{.Note}}</p>
+  </div>
+  {{end}}
+  {{if .Targets}}
+  <div class="issue-types-section">
+    <h2>{{t "Goals"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Metric"}}</th><th class="num">{{t "Target"}}</th><th class="num">{{t "Current"}}</th><th class="num">{{t "Periods met"}}</th><th>{{t "Status"}}</th></tr>
+      {{range .Targets}}
+      <tr><td>{{.Metric}}</td><td class="num">{{.Target}}</td><td class="num">{{.Current}}</td><td class="num">{{.PeriodsMet}}</td><td>{{if eq .Status "pass"}}<span class="goal-status pass">{{t "Pass"}}</span>{{else if eq .Status "fail"}}<span class="goal-status fail">{{t "Fail"}}</span>{{else}}—{{end}}</td></tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .Benchmarks}}
+  <div class="issue-types-section">
+    <h2>{{t "Industry benchmarks"}} ({{.BenchmarkTitle}})</h2>
+    <table class="data-table">
+      <tr><th>{{t "Metric"}}</th><th class="num">{{t "Ours"}}</th><th>{{t "Band"}}</th><th>{{t "Bands"}}</th><th>{{t "Measured as"}}</th></tr>
+      {{range .Benchmarks}}
+      <tr><td>{{.Metric}}</td><td class="num">{{.Value}}</td><td><span class="bench-band {{.BandClass}}">{{.Band}}</span></td><td>{{.Thresholds}}</td><td>{{.Proxy}}</td></tr>
+      {{end}}
+    </table>
+    <p class="bench-source">{{t "Source:"}} {{.BenchmarkSource}}. {{t "Values are the last comparison window. PR data only approximates deployment metrics, so read bands as a rough placement."}}</p>
+  </div>
+  {{end}}
+  {{if .StatsHistory}}
+  <div class="issue-types-section">
+    <h2>{{t "Estimate history"}}</h2>
+    <table class="data-table">
+      <tr><th>{{t "Run"}}</th><th class="num">{{t "Periods"}}</th>{{range .HistoryMetrics}}<th class="num">{{.}}</th>{{end}}</tr>
+      {{range .StatsHistory}}
+      <tr><td>{{.RunDate}}</td><td class="num">{{.Periods}}</td>{{range .Changes}}<td class="num">{{.}}</td>{{end}}</tr>
+      {{end}}
+    </table>
+  </div>
+  {{end}}
+  {{if .FilterAudit}}
+  <details class="metric-defs">
+    <summary>{{t "Data Quality"}}</summary>
+    <table class="data-table">
+      <tr><th>{{t "Filter"}}</th><th class="num">{{t "Removed"}}</th><th>{{t "Scope"}}</th><th>{{t "Removed items"}}</th></tr>
+      {{range .FilterAudit}}
+      <tr><td>{{.Filter}}</td><td class="num">{{.Removed}}</td><td>{{if .StatsOnly}}{{t "Before/after comparison"}}{{else}}{{t "Whole report"}}{{end}}</td><td class="audit-items">{{.Items}}</td></tr>
+      {{end}}
+    </table>
+  </details>
+  {{end}}
+  <details class="metric-defs">
+    <summary>{{t "Metric Definitions"}}</summary>
+    <div class="metric-defs-grid">
+      {{range .Glossary}}
+      <div class="metric-def-card">
+        <h3>{{.Title}}</h3>
+        <p>{{.Definition}}</p>
+        <div class="def-label def-good">{{t "Benefits"}}</div>
+        <p>{{.Benefits}}</p>
+        <div class="def-label def-warn">{{t "Drawbacks"}}</div>
+        <p>{{.Drawbacks}}</p>
+        {{if .Caveats}}
+        <div class="def-label">{{t "In this report"}}</div>
+        <ul class="def-caveats">
+        {{range .Caveats}}<li>{{.}}</li>
+        {{end}}</ul>
+        {{end}}
+      </div>
+      {{end}}
+    </div>
+  </details>
+  {{end}}
+</div>
+{{if not .Summary}}
+<script type="application/json" id="report-data">{{.Data}}</script>
+<script>
+// Everything the charts draw comes from the embedded report data
+const report = JSON.parse(document.getElementById("report-data").textContent);
+const weeks = report.periods;
+const hasIncidents = report.hasIncidents;
+const hasSizeWeighted = report.hasSizeWeighted;
+const hasOnaWeighted = report.hasOnaWeighted;
+const hasRetention = report.hasRetention;
+const hasResponse = report.hasResponse;
+const hasHygiene = report.hasHygiene;
+const hasReviewCoverage = report.hasReviewCoverage;
+const hasCIQueue = report.hasCIQueue;
+const hasCICost = report.hasCICost;
+const hasTemplate = report.hasTemplate;
+const hasSecurity = report.hasSecurity;
+const hasReleases = report.hasReleases;
+const hasChangelog = report.hasChangelog;
+const hasCIPrice = report.hasCIPrice;
+const hasReopens = report.hasReopens;
+const hasAutomation = report.hasAutomation;
+const externalSeries = report.externalSeries;
+const trends = report.trends;
+const sizeBuckets = report.sizeBuckets;
+const targetLines = report.targetLines;
+const deltas = report.deltas;
+const prLists = report.prLists;
+const flow = report.flow;
+const cohorts = report.cohorts;
+const runners = report.runners;
+const yoy = report.yoy;
+const lifecycle = report.lifecycle;
+const scatterPRs = report.scatter;
+const histograms = report.histograms;
+const holidays = report.holidays;
+const unstableFrom = weeks.length - report.unstablePeriods;
+const protectionChanges = report.protectionChanges;
+const locale = "{{.Lang}}";
+const externalColors = ["#0d9488", "#7c3aed", "#db2777", "#65a30d", "#0369a1"];
+const sizeColors = ["#fdba74", "#fb923c", "#f97316", "#c2410c", "#7c2d12"];
+
+const labels = weeks.map(w => w.label);
+
+// hatchPattern returns a diagonal-stripe fill in color.
+function hatchPattern(ctx, color) {
+  const tile = document.createElement("canvas");
+  tile.width = tile.height = 6;
+  const t = tile.getContext("2d");
+  t.strokeStyle = color;
+  t.lineWidth = 1.5;
+  t.beginPath();
+  t.moveTo(0, 6);
+  t.lineTo(6, 0);
+  t.moveTo(-1, 1);
+  t.lineTo(1, -1);
+  t.moveTo(5, 7);
+  t.lineTo(7, 5);
+  t.stroke();
+  return ctx.createPattern(tile, "repeat");
+}
+
+// Drill-down: list the PRs behind a clicked chart period (--pr-drilldown)
+function showPRs(i) {
+  const prs = prLists[i] || [];
+  const fixed1 = v => v.toLocaleString(locale, { minimumFractionDigits: 1, maximumFractionDigits: 1 });
+  const hrs = h => h < 0 ? "—" : fixed1(h) + "h";
+  const rows = document.getElementById("pr-drilldown-rows");
+  rows.replaceChildren();
+  for (const pr of prs) {
+    const tr = document.createElement("tr");
+    const cell = (text, cls) => {
+      const td = document.createElement("td");
+      td.textContent = text;
+      if (cls) td.className = cls;
+      tr.appendChild(td);
+      return td;
+    };
+    const num = cell(pr.url ? "" : "—");
+    if (pr.url) {
+      const a = document.createElement("a");
+      a.href = pr.url;
+      a.target = "_blank";
+      a.rel = "noopener";
+      a.textContent = "#" + pr.number;
+      num.appendChild(a);
+    }
+    const title = cell(pr.title || "—");
+    for (const [flag, cls, text] of [[pr.ona, "ona", "Ona"], [pr.revert, "revert", "{{t "Revert"}}"]]) {
+      if (!flag) continue;
+      const tag = document.createElement("span");
+      tag.className = "pr-tag " + cls;
+      tag.textContent = text;
+      title.appendChild(tag);
+    }
+    cell(pr.author);
+    cell(pr.size.toLocaleString(locale), "num");
+    cell(hrs(pr.codingHours), "num");
+    cell(hrs(pr.reviewHours), "num");
+    cell(pr.mergedAt);
+    rows.appendChild(tr);
+  }
+  document.getElementById("pr-drilldown-title").textContent = "{{t "PRs merged"}} — " + labels[i] + " (" + prs.length + ")";
+  const panel = document.getElementById("pr-drilldown");
+  panel.hidden = false;
+  panel.scrollIntoView({ behavior: "smooth", block: "nearest" });
+}
+
+// Linear regression for PRs per Engineer trendline
+const ppeData = weeks.map(w => w.prsPerEngineer);
+const n = ppeData.length;
+let sumX = 0, sumY = 0, sumXY = 0, sumXX = 0;
+for (let i = 0; i < n; i++) {
+  sumX += i; sumY += ppeData[i]; sumXY += i * ppeData[i]; sumXX += i * i;
+}
+const slope = (n * sumXY - sumX * sumY) / (n * sumXX - sumX * sumX);
+const intercept = (sumY - slope * sumX) / n;
 const trendData = ppeData.map((_, i) => Math.round((slope * i + intercept) * 100) / 100);
 
 new Chart(document.getElementById("chart"), {
@@ -450,8 +1754,9 @@ new Chart(document.getElementById("chart"), {
     labels: labels,
     datasets: [
       {
-        label: "PRs per Engineer",
+        label: "{{t "PRs per Engineer"}}",
         data: weeks.map(w => w.prsPerEngineer),
+        metric: "prs_per_engineer",
         borderColor: "#2563eb",
         backgroundColor: "rgba(37,99,235,0.1)",
         yAxisID: "yPPE",
@@ -460,7 +1765,7 @@ new Chart(document.getElementById("chart"), {
         pointHoverRadius: 6
       },
       {
-        label: "PRs/Eng Trend",
+        label: "{{t "PRs/Eng Trend"}}",
         data: trendData,
         borderColor: "rgba(37,99,235,0.5)",
         backgroundColor: "transparent",
@@ -472,8 +1777,9 @@ new Chart(document.getElementById("chart"), {
         tension: 0
       },
       {
-        label: "% Ona Involved",
+        label: "{{t "% Ona Involved"}}",
         data: weeks.map(w => w.pctOna),
+        metric: "pct_ona_involved",
         borderColor: "#9333ea",
         backgroundColor: "rgba(147,51,234,0.1)",
         yAxisID: "yPct",
@@ -483,8 +1789,9 @@ new Chart(document.getElementById("chart"), {
         pointHoverRadius: 6
       },
       {
-        label: "% Reverts",
+        label: "{{t "% Reverts"}}",
         data: weeks.map(w => w.pctReverts),
+        metric: "pct_reverts",
         borderColor: "#16a34a",
         backgroundColor: "rgba(22,163,74,0.1)",
         yAxisID: "yPct",
@@ -494,8 +1801,9 @@ new Chart(document.getElementById("chart"), {
         pointHoverRadius: 6
       },
       {
-        label: "Time Spent Coding (hrs)",
+        label: "{{t "Time Spent Coding (hrs)"}}",
         data: weeks.map(w => w.codingTime),
+        metric: "median_coding_time_hours",
         borderColor: "#0891b2",
         backgroundColor: "rgba(8,145,178,0.1)",
         yAxisID: "yHrs",
@@ -506,8 +1814,9 @@ new Chart(document.getElementById("chart"), {
         hidden: true
       },
       {
-        label: "Time Spent Reviewing (hrs)",
+        label: "{{t "Time Spent Reviewing (hrs)"}}",
         data: weeks.map(w => w.reviewTime),
+        metric: "median_review_time_hours",
         borderColor: "#ea580c",
         backgroundColor: "rgba(234,88,12,0.1)",
         yAxisID: "yHrs",
@@ -517,8 +1826,9 @@ new Chart(document.getElementById("chart"), {
         hidden: true
       },
       {
-        label: "PRs Merged",
+        label: "{{t "PRs Merged"}}",
         data: weeks.map(w => w.prsMerged),
+        metric: "prs_merged",
         borderColor: "#6b7280",
         backgroundColor: "rgba(107,114,128,0.1)",
         yAxisID: "yCount",
@@ -528,8 +1838,9 @@ new Chart(document.getElementById("chart"), {
         hidden: true
       },
       {
-        label: "Builds",
+        label: "{{t "Builds"}}",
         data: weeks.map(w => w.buildRuns),
+        metric: "build_runs",
         borderColor: "#f59e0b",
         backgroundColor: "rgba(245,158,11,0.1)",
         yAxisID: "yBuilds",
@@ -538,42 +1849,465 @@ new Chart(document.getElementById("chart"), {
         pointHoverRadius: 6,
         hidden: true
       }
-    ]
+    ].concat(hasReopens ? [
+      {
+        label: "{{t "Reopened PRs"}}",
+        data: weeks.map(w => w.reopened),
+        borderColor: "#9333ea",
+        backgroundColor: "rgba(147,51,234,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [4, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasSizeWeighted ? [
+      {
+        label: "{{t "Size Points per Engineer"}}",
+        data: weeks.map(w => w.sizePoints),
+        metric: "size_points_per_engineer",
+        borderColor: "#1e3a8a",
+        backgroundColor: "rgba(30,58,138,0.1)",
+        yAxisID: "ySize",
+        tension: 0.3,
+        borderDash: [2, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasOnaWeighted ? [
+      {
+        label: "{{t "% Lines in Ona PRs"}}",
+        data: weeks.map(w => w.pctOnaLines),
+        metric: "pct_ona_lines",
+        borderColor: "#c026d3",
+        backgroundColor: "rgba(192,38,211,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasAutomation ? [
+      {
+        label: "{{t "Automation PRs"}}",
+        data: weeks.map(w => w.automationPRs),
+        borderColor: "#0891b2",
+        backgroundColor: "rgba(8,145,178,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [6, 3],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "Automation Merge Time (hrs)"}}",
+        data: weeks.map(w => w.automationMerge),
+        borderColor: "#155e75",
+        backgroundColor: "rgba(21,94,117,0.1)",
+        yAxisID: "yHrs",
+        tension: 0.3,
+        borderDash: [6, 3],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasSecurity ? [
+      {
+        label: "{{t "Security Fixes"}}",
+        data: weeks.map(w => w.securityPRs),
+        metric: "security_prs",
+        borderColor: "#be123c",
+        backgroundColor: "rgba(190,18,60,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [3, 3],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "Security Fix Lead Time (hrs)"}}",
+        data: weeks.map(w => w.securityLead),
+        metric: "median_security_lead_hours",
+        borderColor: "#881337",
+        backgroundColor: "rgba(136,19,55,0.1)",
+        yAxisID: "yHrs",
+        tension: 0.3,
+        borderDash: [3, 3],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasReleases ? [
+      {
+        label: "{{t "Releases"}}",
+        data: weeks.map(w => w.releases),
+        metric: "releases",
+        borderColor: "#0d9488",
+        backgroundColor: "rgba(13,148,136,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [6, 3],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "Days Between Releases"}}",
+        data: weeks.map(w => w.releaseGap),
+        metric: "median_days_between_releases",
+        borderColor: "#115e59",
+        backgroundColor: "rgba(17,94,89,0.1)",
+        yAxisID: "yDays",
+        tension: 0.3,
+        borderDash: [6, 3],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "PRs per Release"}}",
+        data: weeks.map(w => w.prsPerRelease),
+        metric: "prs_per_release",
+        borderColor: "#5eead4",
+        backgroundColor: "rgba(94,234,212,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [6, 3],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasChangelog ? [
+      {
+        label: "{{t "Release Batch Size (PRs)"}}",
+        data: weeks.map(w => w.releaseBatch),
+        metric: "median_release_batch_prs",
+        borderColor: "#0f766e",
+        backgroundColor: "rgba(15,118,110,0.1)",
+        yAxisID: "yCount",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasCIQueue ? [
+      {
+        label: "{{t "CI Queue Time (min)"}}",
+        data: weeks.map(w => w.ciQueue),
+        metric: "median_ci_queue_minutes",
+        borderColor: "#57534e",
+        backgroundColor: "rgba(87,83,78,0.1)",
+        yAxisID: "yMin",
+        tension: 0.3,
+        borderDash: [4, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasCICost ? [
+      {
+        label: hasCIPrice ? "{{t "CI Cost"}}" : "{{t "Billable CI Minutes"}}",
+        data: weeks.map(w => hasCIPrice ? w.ciCost : w.ciMinutes),
+        metric: hasCIPrice ? "ci_cost" : "billable_ci_minutes",
+        borderColor: "#b45309",
+        backgroundColor: "rgba(180,83,9,0.1)",
+        yAxisID: "yCost",
+        tension: 0.3,
+        borderDash: [8, 4],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6
+      }
+    ] : []).concat(hasRetention ? [
+      {
+        label: "{{t "Active Engineers (4w)"}}",
+        data: weeks.map(w => w.activeEngineers),
+        metric: "active_engineers_4w",
+        borderColor: "#4f46e5",
+        backgroundColor: "rgba(79,70,229,0.1)",
+        yAxisID: "yEngineers",
+        tension: 0.3,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "Churned Engineers"}}",
+        data: weeks.map(w => w.churnedEngineers),
+        metric: "churned_engineers",
+        borderColor: "#a21caf",
+        backgroundColor: "rgba(162,28,175,0.1)",
+        yAxisID: "yEngineers",
+        tension: 0.3,
+        borderDash: [6, 3],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasResponse ? [
+      {
+        label: "{{t "Review Response (hrs)"}}",
+        data: weeks.map(w => w.reviewResponse),
+        metric: "median_review_response_hours",
+        borderColor: "#c2410c",
+        backgroundColor: "rgba(194,65,12,0.1)",
+        yAxisID: "yHrs",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasHygiene ? [
+      {
+        label: "{{t "% Described"}}",
+        data: weeks.map(w => w.pctDescribed),
+        metric: "pct_described",
+        borderColor: "#0f766e",
+        backgroundColor: "rgba(15,118,110,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "% Linked to Issue"}}",
+        data: weeks.map(w => w.pctLinked),
+        metric: "pct_linked_issue",
+        borderColor: "#4d7c0f",
+        backgroundColor: "rgba(77,124,15,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "% With Tests"}}",
+        data: weeks.map(w => w.pctTests),
+        metric: "pct_with_tests",
+        borderColor: "#b45309",
+        backgroundColor: "rgba(180,83,9,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasReviewCoverage ? [
+      {
+        label: "{{t "% Approved"}}",
+        data: weeks.map(w => w.pctApproved),
+        metric: "pct_approved",
+        borderColor: "#15803d",
+        backgroundColor: "rgba(21,128,61,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "% Unreviewed"}}",
+        data: weeks.map(w => w.pctUnreviewed),
+        metric: "pct_unreviewed",
+        borderColor: "#b91c1c",
+        backgroundColor: "rgba(185,28,28,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasTemplate ? [
+      {
+        label: "{{t "% Template Compliant"}}",
+        data: weeks.map(w => w.pctTemplate),
+        metric: "pct_template_compliant",
+        borderColor: "#7e22ce",
+        backgroundColor: "rgba(126,34,206,0.1)",
+        yAxisID: "yPct",
+        tension: 0.3,
+        borderDash: [2, 2],
+        spanGaps: true,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(hasIncidents ? [
+      {
+        label: "{{t "Incidents"}}",
+        data: weeks.map(w => w.incidents),
+        metric: "incident_count",
+        borderColor: "#dc2626",
+        backgroundColor: "rgba(220,38,38,0.1)",
+        yAxisID: "yIncidents",
+        tension: 0.3,
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      },
+      {
+        label: "{{t "Median MTTR (hrs)"}}",
+        data: weeks.map(w => w.mttr),
+        metric: "median_mttr_hours",
+        borderColor: "#be123c",
+        backgroundColor: "rgba(190,18,60,0.1)",
+        yAxisID: "yHrs",
+        tension: 0.3,
+        borderDash: [2, 2],
+        pointRadius: 4,
+        pointHoverRadius: 6,
+        hidden: true
+      }
+    ] : []).concat(sizeBuckets.map((s, i) => ({
+      label: s.label,
+      data: s.values,
+      borderColor: sizeColors[i % sizeColors.length],
+      backgroundColor: "transparent",
+      yAxisID: "yHrs",
+      tension: 0.3,
+      borderDash: [4, 4],
+      spanGaps: true,
+      pointRadius: 3,
+      pointHoverRadius: 5,
+      hidden: true
+    }))).concat(externalSeries.map((s, i) => ({
+      label: s.Name,
+      data: s.Values,
+      metric: s.Name,
+      borderColor: externalColors[i % externalColors.length],
+      backgroundColor: "transparent",
+      yAxisID: "yExt" + i,
+      tension: 0.3,
+      spanGaps: true,
+      pointRadius: 4,
+      pointHoverRadius: 6,
+      hidden: true
+    }))).concat(deltas.map((d, i) => ({
+      type: "bar",
+      label: d.label,
+      data: d.values,
+      unit: d.unit,
+      backgroundColor: ctx => ctx.raw < 0 ? "rgba(234,88,12,0.45)" : "rgba(71,85,105,0.45)",
+      borderWidth: 0,
+      yAxisID: "yDelta" + i,
+      hidden: true
+    }))).concat(targetLines.map(t => ({
+      label: t.Label,
+      data: weeks.map(() => t.Value),
+      borderColor: "#374151",
+      backgroundColor: "transparent",
+      yAxisID: t.Axis,
+      borderDash: [2, 4],
+      borderWidth: 1.5,
+      pointRadius: 0,
+      pointHoverRadius: 0,
+      tension: 0,
+      isTarget: true,
+      hidden: t.Hidden
+    })))
   },
   options: {
+    locale: locale,
     responsive: true,
+    datasets: {
+      line: {
+        // Dash the segments into --unstable-weeks periods
+        segment: {
+          borderDash: ctx => ctx.p1DataIndex >= unstableFrom && !ctx.chart.data.datasets[ctx.datasetIndex].isTarget ? [3, 3] : undefined
+        }
+      }
+    },
+    onClick: (evt, elements) => {
+      if (prLists.length && elements.length) showPRs(elements[0].index);
+    },
+    onHover: (evt, elements) => {
+      evt.native.target.style.cursor = prLists.length && elements.length ? "pointer" : "default";
+    },
     interaction: {
       mode: "index",
       intersect: false
     },
     plugins: {
       tooltip: {
+        filter: item => !item.dataset.isTarget,
         callbacks: {
+          title: items => items.length ? labels[items[0].dataIndex] + (weeks[items[0].dataIndex].fiscal ? " · " + weeks[items[0].dataIndex].fiscal : "") : "",
           label: function(ctx) {
             let v = ctx.parsed.y;
             let lbl = ctx.dataset.label;
             let axis = ctx.dataset.yAxisID;
-            if (axis === "yPct") return lbl + ": " + v.toFixed(1) + "%";
-            if (axis === "yHrs") return lbl + ": " + v.toFixed(1) + "h";
-            if (axis === "yCount" || axis === "yBuilds") return lbl + ": " + v.toLocaleString();
-            return lbl + ": " + v.toFixed(2);
+            const fixed = d => v.toLocaleString(locale, { minimumFractionDigits: d, maximumFractionDigits: d });
+            if (axis === "yPct") return lbl + ": " + fixed(1) + "%";
+            if (axis === "yHrs") return lbl + ": " + fixed(1) + "h";
+            if (axis === "yMin") return lbl + ": " + fixed(1) + " min";
+            if (axis === "yDays") return lbl + ": " + fixed(1) + " {{t "days"}}";
+            if (axis === "yCost") return lbl + ": " + fixed(hasCIPrice ? 2 : 0);
+            if (axis === "yCount" || axis === "yBuilds" || axis === "yIncidents") return lbl + ": " + v.toLocaleString(locale);
+            if (axis.startsWith("yDelta")) return lbl + ": " + (v > 0 ? "+" : "") + fixed(ctx.dataset.unit ? 1 : 2) + (ctx.dataset.unit === "hrs" ? "h" : ctx.dataset.unit === "%" ? " pp" : "");
+            return lbl + ": " + fixed(2);
+          },
+          footer: items => {
+            if (!items.length) return "";
+            const i = items[0].dataIndex, lines = [];
+            if (holidays[i]) lines.push("{{t "Holidays"}}: " + holidays[i]);
+            if (i >= unstableFrom) lines.push("{{t "May still change (late merges)"}}");
+            if (weeks[i].incomplete) lines.push("{{t "Incomplete: some data failed to fetch"}}");
+            protectionChanges.filter(c => c.period === i).forEach(c =>
+              lines.push("{{t "Branch protection changed"}} (" + c.from + " – " + c.to + "): " + c.summary));
+            return lines;
           }
         }
       },
       legend: {
         position: "bottom",
-        labels: { usePointStyle: true, padding: 16 }
+        labels: {
+          usePointStyle: true,
+          padding: 16,
+          // Trend badge after each series with a Mann-Kendall test in report.trends
+          generateLabels: chart => Chart.defaults.plugins.legend.labels.generateLabels(chart).map(item => {
+            const t = trends[chart.data.datasets[item.datasetIndex].metric];
+            if (t) {
+              const p = t.pValue < 0.001 ? "< " + (0.001).toLocaleString(locale) : "= " + t.pValue.toLocaleString(locale, { minimumFractionDigits: 3, maximumFractionDigits: 3 });
+              item.text += "  " + { up: "▲", down: "▼", flat: "–" }[t.direction] + " p " + p;
+            }
+            return item;
+          })
+        }
       }
     },
     scales: {
       x: {
-        title: { display: true, text: "Week Starting" },
+        title: { display: true, text: report.period === "sprint" ? "{{t "Sprint"}}" : "{{t "Week Starting"}}" },
         ticks: { maxRotation: 45 }
       },
       yPPE: {
         type: "linear",
         position: "left",
-        title: { display: true, text: "PRs / Engineer" },
+        title: { display: true, text: "{{t "PRs / Engineer"}}" },
         beginAtZero: true,
         grid: { color: "rgba(0,0,0,0.06)" }
       },
@@ -591,7 +2325,25 @@ new Chart(document.getElementById("chart"), {
         position: "right",
         weight: 2,
         display: false,
-        title: { display: true, text: "Hours" },
+        title: { display: true, text: "{{t "Hours"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      yMin: {
+        type: "linear",
+        position: "right",
+        weight: 2,
+        display: false,
+        title: { display: true, text: "{{t "Minutes"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      yDays: {
+        type: "linear",
+        position: "right",
+        weight: 2,
+        display: false,
+        title: { display: true, text: "{{t "Days"}}" },
         beginAtZero: true,
         grid: { drawOnChartArea: false }
       },
@@ -600,7 +2352,16 @@ new Chart(document.getElementById("chart"), {
         position: "right",
         weight: 3,
         display: false,
-        title: { display: true, text: "PRs Merged" },
+        title: { display: true, text: "{{t "PRs Merged"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      yCost: {
+        type: "linear",
+        position: "right",
+        weight: 4,
+        display: false,
+        title: { display: true, text: hasCIPrice ? "{{t "CI Cost"}}" : "{{t "Billable CI Minutes"}}" },
         beginAtZero: true,
         grid: { drawOnChartArea: false }
       },
@@ -609,16 +2370,154 @@ new Chart(document.getElementById("chart"), {
         position: "right",
         weight: 4,
         display: false,
-        title: { display: true, text: "Builds" },
+        title: { display: true, text: "{{t "Builds"}}" },
         beginAtZero: true,
         grid: { drawOnChartArea: false }
-      }
+      },
+      yIncidents: {
+        type: "linear",
+        position: "right",
+        weight: 5,
+        display: false,
+        title: { display: true, text: "{{t "Incidents"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      ySize: {
+        type: "linear",
+        position: "left",
+        display: false,
+        title: { display: true, text: "{{t "Size Points / Engineer"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      yEngineers: {
+        type: "linear",
+        position: "left",
+        display: false,
+        title: { display: true, text: "{{t "Engineers"}}" },
+        beginAtZero: true,
+        grid: { drawOnChartArea: false }
+      },
+      ...Object.fromEntries(externalSeries.map((s, i) => ["yExt" + i, {
+        type: "linear",
+        position: "right",
+        weight: 6 + i,
+        display: false,
+        title: { display: true, text: s.Name },
+        grid: { drawOnChartArea: false }
+      }])),
+      ...Object.fromEntries(deltas.map((d, i) => ["yDelta" + i, {
+        type: "linear",
+        position: "left",
+        display: false,
+        title: { display: true, text: d.label },
+        grid: { drawOnChartArea: false }
+      }]))
     }
   },
   plugins: [{
+    // Hatch periods whose fetch failed or was cut off, and their points, so
+    // undercounted periods don't read as real lows
+    id: "incompletePeriods",
+    beforeDatasetsDraw(chart) {
+      const x = chart.scales.x, area = chart.chartArea, ctx = chart.ctx;
+      const half = labels.length > 1 ? (x.getPixelForValue(1) - x.getPixelForValue(0)) / 2 : area.width / 2;
+      ctx.save();
+      ctx.fillStyle = hatchPattern(ctx, "rgba(220,38,38,0.18)");
+      weeks.forEach((w, i) => {
+        if (!w.incomplete) return;
+        const left = Math.max(x.getPixelForValue(i) - half, area.left);
+        const right = Math.min(x.getPixelForValue(i) + half, area.right);
+        ctx.fillRect(left, area.top, right - left, area.bottom - area.top);
+      });
+      ctx.restore();
+    },
+    afterDatasetsDraw(chart) {
+      const ctx = chart.ctx;
+      ctx.save();
+      chart.data.datasets.forEach((ds, di) => {
+        const meta = chart.getDatasetMeta(di);
+        if (meta.hidden || ds.isTarget || meta.type !== "line") return;
+        meta.data.forEach((pt, i) => {
+          if (!weeks[i] || !weeks[i].incomplete || pt.skip) return;
+          ctx.beginPath();
+          ctx.arc(pt.x, pt.y, 5, 0, 2 * Math.PI);
+          ctx.fillStyle = "#fff";
+          ctx.fill();
+          ctx.fillStyle = hatchPattern(ctx, ds.borderColor);
+          ctx.fill();
+          ctx.strokeStyle = ds.borderColor;
+          ctx.lineWidth = 1.5;
+          ctx.stroke();
+        });
+      });
+      ctx.restore();
+    }
+  }, {
+    // Shade periods with --holidays public holidays behind the lines
+    id: "holidayBands",
+    beforeDatasetsDraw(chart) {
+      const x = chart.scales.x, area = chart.chartArea, ctx = chart.ctx;
+      const half = labels.length > 1 ? (x.getPixelForValue(1) - x.getPixelForValue(0)) / 2 : area.width / 2;
+      ctx.save();
+      ctx.fillStyle = "rgba(245,158,11,0.12)";
+      holidays.forEach((h, i) => {
+        if (!h) return;
+        const left = Math.max(x.getPixelForValue(i) - half, area.left);
+        const right = Math.min(x.getPixelForValue(i) + half, area.right);
+        ctx.fillRect(left, area.top, right - left, area.bottom - area.top);
+      });
+      ctx.restore();
+    }
+  }, {
+    // Mark fiscal quarter boundaries (--fiscal-year-start)
+    id: "fiscalQuarters",
+    beforeDatasetsDraw(chart) {
+      const x = chart.scales.x, area = chart.chartArea, ctx = chart.ctx;
+      const half = labels.length > 1 ? (x.getPixelForValue(1) - x.getPixelForValue(0)) / 2 : 0;
+      ctx.save();
+      ctx.strokeStyle = "rgba(107,114,128,0.5)";
+      ctx.fillStyle = "#6b7280";
+      ctx.font = "11px sans-serif";
+      ctx.setLineDash([4, 4]);
+      weeks.forEach((w, i) => {
+        if (!w.fiscal || (i > 0 && weeks[i - 1].fiscal === w.fiscal)) return;
+        const left = i > 0 ? x.getPixelForValue(i) - half : area.left;
+        if (i > 0) {
+          ctx.beginPath();
+          ctx.moveTo(left, area.top);
+          ctx.lineTo(left, area.bottom);
+          ctx.stroke();
+        }
+        ctx.fillText(w.fiscal, left + 4, area.top + 12);
+      });
+      ctx.restore();
+    }
+  }, {
+    // Mark --branch-protection changes in the period they were detected
+    id: "protectionChanges",
+    beforeDatasetsDraw(chart) {
+      const x = chart.scales.x, area = chart.chartArea, ctx = chart.ctx;
+      ctx.save();
+      ctx.strokeStyle = "rgba(124,58,237,0.7)";
+      ctx.fillStyle = "#7c3aed";
+      ctx.font = "11px sans-serif";
+      ctx.lineWidth = 2;
+      protectionChanges.forEach(c => {
+        const px = x.getPixelForValue(c.period);
+        ctx.beginPath();
+        ctx.moveTo(px, area.top);
+        ctx.lineTo(px, area.bottom);
+        ctx.stroke();
+        ctx.fillText("{{t "Protection"}}", px + 4, area.bottom - 6);
+      });
+      ctx.restore();
+    }
+  }, {
     id: "axisToggle",
     beforeLayout(chart) {
-      const axisIds = ["yPPE", "yPct", "yHrs", "yCount", "yBuilds"];
+      const axisIds = Object.keys(chart.options.scales).filter(id => id !== "x");
       for (const axisId of axisIds) {
         const scale = chart.options.scales[axisId];
         if (!scale) continue;
@@ -634,7 +2533,278 @@ new Chart(document.getElementById("chart"), {
     }
   }]
 });
+
+// Distribution histograms (--histograms): share of PRs per bin, comparison
+// window vs current window
+histograms.forEach((h, i) => {
+  new Chart(document.getElementById("hist-" + i), {
+    type: "bar",
+    data: {
+      labels: h.Labels,
+      datasets: [
+        { label: h.FirstLabel, data: h.First, backgroundColor: "rgba(156,163,175,0.6)", borderColor: "#9ca3af", borderWidth: 1 },
+        { label: h.LastLabel, data: h.Last, backgroundColor: "rgba(37,99,235,0.5)", borderColor: "#2563eb", borderWidth: 1 }
+      ]
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      plugins: {
+        title: { display: true, text: h.Title },
+        tooltip: {
+          callbacks: {
+            label: ctx => ctx.dataset.label + ": " + ctx.parsed.y.toLocaleString(locale, { minimumFractionDigits: 1, maximumFractionDigits: 1 }) + "%"
+          }
+        },
+        legend: { position: "bottom", labels: { usePointStyle: true, padding: 12 } }
+      },
+      scales: {
+        x: { ticks: { maxRotation: 45 } },
+        y: { beginAtZero: true, title: { display: true, text: "{{t "% of PRs"}}" } }
+      }
+    }
+  });
+});
+
+// Cycle time scatter (--scatter): merge date vs coding + review time,
+// dot area grows with lines changed
+if (scatterPRs.length) {
+  const point = pr => ({ x: pr.mergedAt, y: Math.max(pr.cycleHours, 0.1), r: 2 + Math.log2(1 + pr.size) / 2, pr: pr });
+  const dateLabel = ms => new Date(ms).toLocaleDateString(locale);
+  new Chart(document.getElementById("scatter"), {
+    type: "bubble",
+    data: {
+      datasets: [
+        { label: "{{t "Ona Involved"}}", data: scatterPRs.filter(p => p.ona).map(point), borderColor: "#9333ea", backgroundColor: "rgba(147,51,234,0.35)" },
+        { label: "{{t "Other"}}", data: scatterPRs.filter(p => !p.ona).map(point), borderColor: "#2563eb", backgroundColor: "rgba(37,99,235,0.25)" }
+      ]
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      plugins: {
+        tooltip: {
+          callbacks: {
+            label: function(ctx) {
+              const pr = ctx.raw.pr;
+              const hrs = pr.cycleHours.toLocaleString(locale, { minimumFractionDigits: 1, maximumFractionDigits: 1 });
+              return (pr.number ? "#" + pr.number + " · " : "") + dateLabel(pr.mergedAt) + " · " + hrs + "h · " + pr.size.toLocaleString(locale) + " {{t "lines"}}";
+            }
+          }
+        },
+        legend: { position: "bottom", labels: { usePointStyle: true, padding: 16 } }
+      },
+      scales: {
+        x: { type: "linear", title: { display: true, text: "{{t "Merged"}}" }, ticks: { callback: dateLabel, maxRotation: 45 } },
+        y: { type: "logarithmic", title: { display: true, text: "{{t "Cycle time (hrs, log scale)"}}" } }
+      }
+    }
+  });
+}
+
+// Year-over-year overlay (--yoy): each metric this year and, dashed, a year
+// earlier, on its own axis
+if (yoy.length) {
+  const yoyAxes = [...new Set(yoy.map(s => s.axis))];
+  const yoyColors = ["#2563eb", "#6b7280", "#9333ea", "#0891b2", "#ea580c", "#16a34a"];
+  new Chart(document.getElementById("yoy"), {
+    type: "line",
+    data: {
+      labels: labels,
+      datasets: yoy.map(s => ({
+        label: s.label,
+        data: s.values,
+        borderColor: yoyColors[yoyAxes.indexOf(s.axis) % yoyColors.length],
+        backgroundColor: "transparent",
+        borderDash: s.previous ? [6, 3] : [],
+        yAxisID: "yYoY" + yoyAxes.indexOf(s.axis),
+        tension: 0.3,
+        spanGaps: true,
+        pointRadius: s.previous ? 2 : 4,
+        pointHoverRadius: 6,
+        hidden: s.hidden
+      }))
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      interaction: { mode: "index", intersect: false },
+      plugins: {
+        legend: { position: "bottom", labels: { usePointStyle: true, padding: 16 } }
+      },
+      scales: Object.fromEntries(yoyAxes.map((a, i) => ["yYoY" + i, {
+        type: "linear",
+        position: "left",
+        display: "auto",
+        beginAtZero: true,
+        title: { display: true, text: a }
+      }]))
+    }
+  });
+}
+
+// Join-quarter cohort curves (--cohorts): mean PRs per week per contributor
+// by 4-week block since joining
+if (cohorts.length) {
+  const blocks = Math.max(...cohorts.map(c => c.values.length));
+  const cohortColors = ["#2563eb", "#9333ea", "#16a34a", "#d97706", "#db2777", "#0d9488", "#65a30d", "#0369a1"];
+  new Chart(document.getElementById("cohorts"), {
+    type: "line",
+    data: {
+      labels: Array.from({ length: blocks }, (_, b) => "{{t "Weeks"}} " + (4 * b + 1) + "–" + (4 * b + 4)),
+      datasets: cohorts.map((c, i) => ({
+        label: c.label + " (" + c.members.toLocaleString(locale) + ")",
+        data: c.values,
+        borderColor: cohortColors[i % cohortColors.length],
+        backgroundColor: "transparent",
+        tension: 0.3,
+        pointRadius: 3,
+        pointHoverRadius: 5,
+        hidden: c.censored
+      }))
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      interaction: { mode: "index", intersect: false },
+      plugins: {
+        tooltip: {
+          callbacks: {
+            label: ctx => ctx.dataset.label + ": " + ctx.parsed.y.toLocaleString(locale, { minimumFractionDigits: 2, maximumFractionDigits: 2 })
+          }
+        },
+        legend: { position: "bottom", labels: { usePointStyle: true, padding: 16 } }
+      },
+      scales: {
+        x: { title: { display: true, text: "{{t "Since first merged PR"}}" } },
+        y: { beginAtZero: true, title: { display: true, text: "{{t "PRs / week per contributor"}}" } }
+      }
+    }
+  });
+}
+
+// Self-hosted runner utilization (--runners): busy share per label set,
+// median job queue time dashed on a second axis
+if (runners.series.length) {
+  const runnerColors = ["#2563eb", "#9333ea", "#16a34a", "#d97706", "#db2777", "#0d9488", "#65a30d", "#0369a1"];
+  new Chart(document.getElementById("runners"), {
+    type: "line",
+    data: {
+      labels: runners.weeks,
+      datasets: runners.series.flatMap((s, i) => [
+        { label: s.label + " – {{t "Utilization (%)"}}", data: s.utilization, yAxisID: "y", borderColor: runnerColors[i % runnerColors.length] },
+        { label: s.label + " – {{t "Median Queue (min)"}}", data: s.queue, yAxisID: "yQueue", borderColor: runnerColors[i % runnerColors.length], borderDash: [6, 4] }
+      ]).map(ds => ({ ...ds, backgroundColor: "transparent", tension: 0.3, pointRadius: 3, pointHoverRadius: 5, spanGaps: false }))
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      interaction: { mode: "index", intersect: false },
+      plugins: {
+        tooltip: {
+          callbacks: {
+            label: ctx => ctx.dataset.label + ": " + ctx.parsed.y.toLocaleString(locale, { maximumFractionDigits: 1 })
+          }
+        },
+        legend: { position: "bottom", labels: { usePointStyle: true, padding: 16 } }
+      },
+      scales: {
+        y: { beginAtZero: true, max: 100, position: "left", title: { display: true, text: "{{t "Utilization (%)"}}" } },
+        yQueue: { beginAtZero: true, position: "right", grid: { drawOnChartArea: false }, title: { display: true, text: "{{t "Median Queue (min)"}}" } }
+      }
+    }
+  });
+}
+
+// Cumulative flow diagram (--cfd): stacked PR counts per state
+if (flow.length) {
+  new Chart(document.getElementById("cfd"), {
+    type: "line",
+    data: {
+      labels: labels,
+      datasets: [
+        { label: "{{t "Merged"}}", data: flow.map(p => p.Merged), borderColor: "#16a34a", backgroundColor: "rgba(22,163,74,0.5)", fill: "origin" },
+        { label: "{{t "In Review"}}", data: flow.map(p => p.InReview), borderColor: "#d97706", backgroundColor: "rgba(217,119,6,0.5)", fill: "-1" },
+        { label: "{{t "Open"}}", data: flow.map(p => p.Open), borderColor: "#2563eb", backgroundColor: "rgba(37,99,235,0.5)", fill: "-1" }
+      ].map(ds => ({ ...ds, tension: 0.2, pointRadius: 2, pointHoverRadius: 4 }))
+    },
+    options: {
+      locale: locale,
+      responsive: true,
+      interaction: { mode: "index", intersect: false },
+      plugins: {
+        legend: { position: "bottom", reverse: true, labels: { usePointStyle: true, padding: 16 } }
+      },
+      scales: {
+        x: { title: { display: true, text: "{{t "Week Starting"}}" }, ticks: { maxRotation: 45 } },
+        y: { stacked: true, beginAtZero: true, title: { display: true, text: "PRs" } }
+      }
+    }
+  });
+}
+
+// PR lifecycle Sankey (--lifecycle): states in fixed columns, band widths
+// proportional to transitions; moves back to an earlier state arc below
+if (lifecycle.links.length) {
+  const svgNS = "http://www.w3.org/2000/svg";
+  const states = lifecycle.states;
+  const column = Object.fromEntries(states.map((s, i) => [s.id, i]));
+  const label = Object.fromEntries(states.map(s => [s.id, s.label]));
+  const inflow = {}, outflow = {};
+  lifecycle.links.forEach(l => {
+    outflow[l.from] = (outflow[l.from] || 0) + l.count;
+    inflow[l.to] = (inflow[l.to] || 0) + l.count;
+  });
+  const width = 900, nodeW = 14, top = 24, bandH = 240;
+  const total = id => Math.max(inflow[id] || 0, outflow[id] || 0);
+  const scale = bandH / Math.max(...states.map(s => total(s.id)));
+  const nodes = {};
+  states.forEach((s, i) => {
+    const h = Math.max(total(s.id) * scale, 2);
+    nodes[s.id] = { x: 10 + i * (width - 20 - nodeW) / (states.length - 1), y: top + (bandH - h) / 2, h: h, out: 0, in: 0 };
+  });
+  const svg = document.createElementNS(svgNS, "svg");
+  const add = (name, attrs, parent) => {
+    const e = document.createElementNS(svgNS, name);
+    for (const k in attrs) e.setAttribute(k, attrs[k]);
+    (parent || svg).appendChild(e);
+    return e;
+  };
+  let below = top + bandH + 16;
+  lifecycle.links.forEach(l => {
+    const a = nodes[l.from], b = nodes[l.to], w = Math.max(l.count * scale, 1);
+    let d, color;
+    if (column[l.to] > column[l.from]) {
+      const y0 = a.y + a.out + w / 2, y1 = b.y + b.in + w / 2;
+      a.out += w;
+      b.in += w;
+      const mx = (a.x + nodeW + b.x) / 2;
+      d = "M" + (a.x + nodeW) + "," + y0 + " C" + mx + "," + y0 + " " + mx + "," + y1 + " " + b.x + "," + y1;
+      color = "rgba(37,99,235,0.35)";
+    } else {
+      const ax = a.x + nodeW / 2, bx = b.x + nodeW / 2;
+      const by = below + w / 2;
+      d = "M" + ax + "," + (a.y + a.h) + " C" + ax + "," + by + " " + bx + "," + by + " " + bx + "," + (b.y + b.h);
+      below += w / 2 + 8;
+      color = "rgba(217,119,6,0.45)";
+    }
+    const path = add("path", { d: d, fill: "none", stroke: color, "stroke-width": w });
+    add("title", {}, path).textContent = label[l.from] + " → " + label[l.to] + ": " + l.count.toLocaleString(locale);
+  });
+  states.forEach((s, i) => {
+    const n = nodes[s.id];
+    add("rect", { x: n.x, y: n.y, width: nodeW, height: n.h, fill: "#334155" });
+    const anchor = i === 0 ? "start" : i === states.length - 1 ? "end" : "middle";
+    const tx = i === 0 ? n.x : i === states.length - 1 ? n.x + nodeW : n.x + nodeW / 2;
+    add("text", { x: tx, y: n.y - 6, "text-anchor": anchor, "font-size": 12, fill: "#334155" }).textContent =
+      s.label + " (" + total(s.id).toLocaleString(locale) + ")";
+  });
+  svg.setAttribute("viewBox", "0 0 " + width + " " + (below + 8));
+  svg.setAttribute("width", "100%");
+  document.getElementById("lifecycle-sankey").appendChild(svg);
+}
 </script>
+{{end}}
 </body>
 </html>
 `
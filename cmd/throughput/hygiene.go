@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// htmlCommentRe matches the <!-- ... --> placeholders PR templates leave in
+// descriptions nobody filled in.
+var htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// descriptionLength counts the characters of a PR description, ignoring
+// whitespace and template comments.
+func descriptionLength(body string) int {
+	return utf8.RuneCountInString(strings.TrimSpace(htmlCommentRe.ReplaceAllString(body, "")))
+}
+
+// testDirs are directory names whose files count as tests.
+var testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "specs": true, "testdata": true}
+
+// isTestPath reports whether a changed file looks like a test by common
+// naming conventions: Go/Python/Ruby suffixes, JS/TS .test/.spec files,
+// JVM/.NET Test classes, and test directories.
+func isTestPath(p string) bool {
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if testDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	base := path.Base(p)
+	name := strings.TrimSuffix(base, path.Ext(base))
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "_test"), strings.HasSuffix(lower, "_spec"),
+		strings.HasPrefix(lower, "test_"),
+		strings.HasSuffix(lower, ".test"), strings.HasSuffix(lower, ".spec"),
+		strings.HasSuffix(name, "Test"), strings.HasSuffix(name, "Tests"):
+		return true
+	}
+	return false
+}
+
+// applyHygiene sets the weekly share of PRs with a description of at least
+// minDescription characters, with a linked issue (a GitHub closing
+// reference, or a Jira/Linear key when those are configured), and touching
+// at least one test file.
+func applyHygiene(prs []enrichedPR, weeks []weekRange, stats []weekStats, minDescription int) {
+	type counts struct{ total, described, linked, tested int }
+	byWeek := make([]counts, len(weeks))
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		c := &byWeek[i]
+		c.total++
+		if pr.descriptionLength >= minDescription {
+			c.described++
+		}
+		if pr.closesIssues || pr.issueKey != "" {
+			c.linked++
+		}
+		if pr.touchesTests {
+			c.tested++
+		}
+	}
+	pct := func(n, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(n) / float64(total) * 100
+	}
+	for i, c := range byWeek {
+		stats[i].hygieneTracked = true
+		stats[i].pctDescribed = pct(c.described, c.total)
+		stats[i].pctLinkedIssue = pct(c.linked, c.total)
+		stats[i].pctWithTests = pct(c.tested, c.total)
+	}
+}
+
+// hygieneShare is a hygiene share as a metric value: a week without PRs has
+// no data (-1) rather than a share of 0.
+func hygieneShare(ws weekStats, pct float64) float64 {
+	if ws.prsMerged == 0 {
+		return -1
+	}
+	return pct
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const pagerDutyEndpoint = "https://api.pagerduty.com/incidents"
+
+// incident is a single production incident from PagerDuty or a CSV export.
+type incident struct {
+	createdAt  time.Time
+	resolvedAt time.Time // zero if unresolved
+}
+
+// incidentWeekStats holds incident volume and MTTR for one week.
+type incidentWeekStats struct {
+	count     int
+	mttrHours float64 // median created → resolved; -1 if no resolved incidents
+}
+
+// loadIncidentsCSV reads incidents from a CSV with a header row containing
+// created_at and (optionally) resolved_at columns in RFC 3339 or YYYY-MM-DD
+// format. Other columns are ignored, so PagerDuty/Opsgenie exports work as-is
+// after renaming the timestamp columns.
+func loadIncidentsCSV(path string) ([]incident, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	createdCol, resolvedCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "created_at":
+			createdCol = i
+		case "resolved_at":
+			resolvedCol = i
+		}
+	}
+	if createdCol < 0 {
+		return nil, fmt.Errorf("missing created_at column")
+	}
+
+	var incidents []incident
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		var inc incident
+		if inc.createdAt, err = parseIncidentTime(rec[createdCol]); err != nil {
+			return nil, fmt.Errorf("line %d: created_at: %w", line, err)
+		}
+		if resolvedCol >= 0 && resolvedCol < len(rec) && strings.TrimSpace(rec[resolvedCol]) != "" {
+			if inc.resolvedAt, err = parseIncidentTime(rec[resolvedCol]); err != nil {
+				return nil, fmt.Errorf("line %d: resolved_at: %w", line, err)
+			}
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, nil
+}
+
+func parseIncidentTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// fetchPagerDutyIncidents pages through PagerDuty incidents created in the
+// analysis window, optionally restricted to a set of service IDs.
+// Requires PAGERDUTY_TOKEN.
+func fetchPagerDutyIncidents(serviceIDs []string, since, until time.Time) ([]incident, error) {
+	token := os.Getenv("PAGERDUTY_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("PAGERDUTY_TOKEN not set")
+	}
+
+	var incidents []incident
+	for offset := 0; ; {
+		params := url.Values{}
+		params.Set("since", since.Format(time.RFC3339))
+		params.Set("until", until.Format(time.RFC3339))
+		params.Set("limit", "100")
+		params.Set("offset", fmt.Sprintf("%d", offset))
+		params.Set("time_zone", "UTC")
+		for _, id := range serviceIDs {
+			params.Add("service_ids[]", id)
+		}
+
+		var page struct {
+			Incidents []struct {
+				CreatedAt          time.Time `json:"created_at"`
+				Status             string    `json:"status"`
+				LastStatusChangeAt time.Time `json:"last_status_change_at"`
+			} `json:"incidents"`
+			More bool `json:"more"`
+		}
+		if err := pagerDutyGet(token, pagerDutyEndpoint+"?"+params.Encode(), &page); err != nil {
+			return incidents, err
+		}
+		for _, pi := range page.Incidents {
+			inc := incident{createdAt: pi.CreatedAt}
+			if pi.Status == "resolved" {
+				inc.resolvedAt = pi.LastStatusChangeAt
+			}
+			incidents = append(incidents, inc)
+		}
+		if !page.More || len(page.Incidents) == 0 {
+			break
+		}
+		offset += len(page.Incidents)
+	}
+	return incidents, nil
+}
+
+func pagerDutyGet(token, endpoint string, out any) error {
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Token token="+token)
+		req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("PagerDuty returned %d (check PAGERDUTY_TOKEN)", resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("PagerDuty returned %d", resp.StatusCode)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("PagerDuty returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		return json.Unmarshal(data, out)
+	}
+	return fmt.Errorf("PagerDuty query failed after 3 attempts: %v", lastErr)
+}
+
+// bucketIncidents assigns incidents to weeks by creation time and computes
+// weekly count and median time to resolve.
+func bucketIncidents(incidents []incident, weeks []weekRange) []incidentWeekStats {
+	stats := make([]incidentWeekStats, len(weeks))
+	ttr := make([][]float64, len(weeks))
+	for _, inc := range incidents {
+		epoch := inc.createdAt.Unix()
+		for i, wr := range weeks {
+			endEpoch := wr.end.Unix() + 86399
+			if epoch >= wr.start.Unix() && epoch <= endEpoch {
+				stats[i].count++
+				if !inc.resolvedAt.IsZero() && !inc.resolvedAt.Before(inc.createdAt) {
+					ttr[i] = append(ttr[i], inc.resolvedAt.Sub(inc.createdAt).Hours())
+				}
+				break
+			}
+		}
+	}
+	for i := range stats {
+		stats[i].mttrHours = median(ttr[i])
+	}
+	return stats
+}
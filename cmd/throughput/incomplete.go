@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// completeColumn is the weekly CSV column flagging weeks whose fetch
+// completed. Written from schema version 3.
+const completeColumn = "complete"
+
+// markIncomplete flags the weeks whose fetch failed or was cut off, so
+// their low counts aren't mistaken for quiet weeks. It returns how many
+// were flagged.
+func markIncomplete(stats []weekStats, weeks, failed []weekRange) int {
+	var n int
+	for i, wr := range weeks {
+		if i < len(stats) && slices.Contains(failed, wr) {
+			stats[i].incomplete = true
+			n++
+		}
+	}
+	return n
+}
+
+// incompleteNote describes the incomplete weeks for the filter notes.
+func incompleteNote(n int) string {
+	return fmt.Sprintf("%d week(s) could not be fetched completely and may be undercounted; they are marked complete=false in the CSV and hatched in the chart", n)
+}
+
+// embedsCompleteness reports whether outputs written as the given schema
+// version carry the complete column; versions before 3 predate it.
+func embedsCompleteness(version int) bool {
+	return version >= 3
+}
+
+// appendCompleteColumn adds the complete column: false for weeks flagged by
+// markIncomplete, true otherwise.
+func appendCompleteColumn(csv string, stats []weekStats) string {
+	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return csv
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lines[0])
+	sb.WriteString("," + completeColumn + "\n")
+	for i, line := range lines[1:] {
+		fmt.Fprintf(&sb, "%s,%t\n", line, i >= len(stats) || !stats[i].incomplete)
+	}
+	return sb.String()
+}
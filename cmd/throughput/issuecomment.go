@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var issueRefRe = regexp.MustCompile(`^([^/\s]+)/([^#\s]+)#([0-9]+)$`)
+
+// issueRef identifies a GitHub issue for --post-issue.
+type issueRef struct {
+	owner  string
+	repo   string
+	number int
+}
+
+func parseIssueRef(s string) (issueRef, error) {
+	m := issueRefRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return issueRef{}, fmt.Errorf("expected owner/repo#number, got %q", s)
+	}
+	n, _ := strconv.Atoi(m[3])
+	return issueRef{owner: m[1], repo: m[2], number: n}, nil
+}
+
+// summaryMarker tags the comment for one analyzed repo and week so a re-run
+// in the same week updates its comment instead of adding a duplicate, while
+// each new week adds a comment and builds up the audit trail.
+func summaryMarker(cfg config, lastWeek weekRange) string {
+	return fmt.Sprintf("<!-- throughput-summary %s/%s %s -->", cfg.owner, cfg.repo, lastWeek.start.Format("2006-01-02"))
+}
+
+// formatIssueSummary renders the run summary as GitHub-flavored Markdown.
+func formatIssueSummary(cfg config, marker string, weeks []weekRange, stats []weekStats, rows []consolidatedRow, periodLabel string, filterNotes []string) string {
+	var sb strings.Builder
+	sb.WriteString(marker + "\n")
+	last := len(weeks) - 1
+	fmt.Fprintf(&sb, "### Throughput: %s/%s — week of %s\n\n", cfg.owner, cfg.repo, weeks[last].start.Format("Jan 2, 2006"))
+
+	ws := stats[last]
+	fmt.Fprintf(&sb, "**This week:** %d PRs merged by %d authors (%.2f PRs/engineer), %.1f%% Ona involved, %.1f%% reverts\n\n",
+		ws.prsMerged, ws.uniqueAuthors, ws.prsPerEngineer, ws.pctOnaInvolved, ws.pctReverts)
+
+	sb.WriteString("**Key findings:**\n\n")
+	for _, f := range buildFindings(rows, stats, periodLabel, locales["en"]) {
+		fmt.Fprintf(&sb, "- %s\n", f)
+	}
+	sb.WriteByte('\n')
+
+	if len(rows) > 0 {
+		fmt.Fprintf(&sb, "**Trend** (%s, %s to %s):\n\n", rows[0].window,
+			weeks[0].start.Format("Jan 2, 2006"), weeks[last].end.Format("Jan 2, 2006"))
+		sb.WriteString("| Metric | Before | After | Change |\n|---|---:|---:|---:|\n")
+		for _, r := range rows {
+			fmt.Fprintf(&sb, "| `%s` | %.2f | %.2f | %s |\n", r.metric, r.firstAvg, r.lastAvg, r.pctChange)
+		}
+		sb.WriteByte('\n')
+	}
+
+	if len(filterNotes) > 0 {
+		sb.WriteString("<details><summary>Data filters</summary>\n\n")
+		for _, n := range filterNotes {
+			fmt.Fprintf(&sb, "- %s\n", n)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	return sb.String()
+}
+
+// postIssueSummary creates the summary comment, or updates the existing one
+// carrying the same marker.
+func postIssueSummary(token string, ref issueRef, marker, body string) (string, error) {
+	base := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", ref.owner, ref.repo)
+
+	type comment struct {
+		ID      int64  `json:"id"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	}
+	for page := 1; ; page++ {
+		var comments []comment
+		url := fmt.Sprintf("%s/%d/comments?per_page=100&page=%d", base, ref.number, page)
+		if err := githubREST(token, "GET", url, nil, &comments); err != nil {
+			return "", err
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.Body, marker) {
+				var updated comment
+				err := githubREST(token, "PATCH", fmt.Sprintf("%s/comments/%d", base, c.ID), map[string]string{"body": body}, &updated)
+				return updated.HTMLURL, err
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+
+	var created comment
+	err := githubREST(token, "POST", fmt.Sprintf("%s/%d/comments", base, ref.number), map[string]string{"body": body}, &created)
+	return created.HTMLURL, err
+}
+
+// githubREST sends a JSON request to the GitHub REST API with retry on
+// transport and server errors, decoding the response into out.
+func githubREST(token, method, url string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if err := httpStatusError(resp, data); err != nil {
+			return recordFailure(fmt.Errorf("%s %s: %w", method, url, err))
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("REST API returned %d", resp.StatusCode)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	}
+	return fmt.Errorf("REST request failed after 3 attempts: %v", lastErr)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// extractIssueKeys finds the first issue key in each PR's branch name (falling
+// back to its title), records it on the PR, and returns the sorted unique keys.
+func extractIssueKeys(re *regexp.Regexp, prs []enrichedPR) []string {
+	keySet := make(map[string]bool)
+	for i := range prs {
+		key := re.FindString(prs[i].branch)
+		if key == "" {
+			key = re.FindString(prs[i].title)
+		}
+		if key != "" {
+			prs[i].issueKey = strings.ToUpper(key)
+			keySet[prs[i].issueKey] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// issueLeadTime returns hours from work start to merge, or -1 if unavailable.
+func issueLeadTime(started time.Time, mergedEpoch int64) float64 {
+	if started.IsZero() || mergedEpoch < started.Unix() {
+		return -1
+	}
+	lead := float64(mergedEpoch-started.Unix()) / 3600.0
+	return math.Round(lead*100) / 100
+}
+
+// issueGroupStat summarizes throughput and cycle time for one issue segment
+// (a Jira issue type or a Linear project).
+type issueGroupStat struct {
+	group            string
+	prs              int
+	pctOfPRs         float64
+	medianCodingTime float64 // -1 if no data
+	medianReviewTime float64 // -1 if no data
+	medianLeadTime   float64 // issue started to merged; -1 if no data
+}
+
+// computeIssueBreakdown segments PRs by the given issue attribute. PRs without
+// a value are grouped as "Unlinked". Groups with PRs from fewer than
+// minAuthors distinct authors (--min-group-size) are merged into
+// "Other (small groups)", which is dropped if it is still too small.
+// Sorted by PR count descending.
+func computeIssueBreakdown(prs []enrichedPR, groupOf func(pr enrichedPR) string, minAuthors int) []issueGroupStat {
+	if len(prs) == 0 {
+		return nil
+	}
+	type bucket struct {
+		count                     int
+		coding, review, leadTimes []float64
+		authors                   map[string]bool
+	}
+	buckets := make(map[string]*bucket)
+	for _, pr := range prs {
+		g := groupOf(pr)
+		if g == "" {
+			g = "Unlinked"
+		}
+		b, ok := buckets[g]
+		if !ok {
+			b = &bucket{authors: make(map[string]bool)}
+			buckets[g] = b
+		}
+		b.count++
+		b.authors[pr.authorLogin] = true
+		if pr.codingTimeHours >= 0 {
+			b.coding = append(b.coding, pr.codingTimeHours)
+		}
+		if pr.reviewTimeHours >= 0 {
+			b.review = append(b.review, pr.reviewTimeHours)
+		}
+		if pr.issueLeadTimeHours >= 0 {
+			b.leadTimes = append(b.leadTimes, pr.issueLeadTimeHours)
+		}
+	}
+
+	if minAuthors > 1 {
+		other := &bucket{authors: make(map[string]bool)}
+		for g, b := range buckets {
+			if len(b.authors) >= minAuthors {
+				continue
+			}
+			other.count += b.count
+			other.coding = append(other.coding, b.coding...)
+			other.review = append(other.review, b.review...)
+			other.leadTimes = append(other.leadTimes, b.leadTimes...)
+			for a := range b.authors {
+				other.authors[a] = true
+			}
+			delete(buckets, g)
+		}
+		if len(other.authors) >= minAuthors {
+			buckets["Other (small groups)"] = other
+		}
+	}
+
+	var result []issueGroupStat
+	for g, b := range buckets {
+		result = append(result, issueGroupStat{
+			group:            g,
+			prs:              b.count,
+			pctOfPRs:         float64(b.count) / float64(len(prs)) * 100,
+			medianCodingTime: median(b.coding),
+			medianReviewTime: median(b.review),
+			medianLeadTime:   median(b.leadTimes),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].prs != result[j].prs {
+			return result[i].prs > result[j].prs
+		}
+		return result[i].group < result[j].group
+	})
+	return result
+}
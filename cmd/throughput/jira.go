@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultJiraKeyPattern = `\b[A-Z][A-Z0-9]+-[0-9]+\b`
+
+// jiraConfig holds settings for joining PRs to Jira issues.
+type jiraConfig struct {
+	baseURL          string
+	keyRe            *regexp.Regexp
+	inProgressStatus string
+}
+
+type jiraSearchResponse struct {
+	Total  int `json:"total"`
+	Issues []struct {
+		Key    string `json:"key"`
+		Fields struct {
+			IssueType struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+		} `json:"fields"`
+		Changelog struct {
+			Histories []struct {
+				Created string `json:"created"`
+				Items   []struct {
+					Field    string `json:"field"`
+					ToString string `json:"toString"`
+				} `json:"items"`
+			} `json:"histories"`
+		} `json:"changelog"`
+	} `json:"issues"`
+}
+
+// jiraIssue is the subset of Jira issue data joined onto PRs.
+type jiraIssue struct {
+	issueType  string
+	inProgress time.Time // first transition into the in-progress status; zero if never
+}
+
+// jiraTimeLayout is the timestamp format used in Jira changelogs.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// applyJiraIssues extracts issue keys from PR titles and branch names, fetches
+// the referenced issues from Jira, and records issue type and lead time
+// (first "In Progress" transition to merge) on each PR.
+func applyJiraIssues(jc jiraConfig, prs []enrichedPR) {
+	keys := extractIssueKeys(jc.keyRe, prs)
+	if len(keys) == 0 {
+		fmt.Fprintf(os.Stderr, "  No Jira issue keys found in PR titles or branches\n")
+		return
+	}
+
+	issues := make(map[string]jiraIssue)
+	const batchSize = 50
+	for i := 0; i < len(keys); i += batchSize {
+		batch := keys[i:min(i+batchSize, len(keys))]
+		if err := fetchJiraIssues(jc, batch, issues); err != nil {
+			fmt.Fprintf(os.Stderr, "  WARNING: Jira lookup failed: %v\n", err)
+		}
+	}
+
+	var linked int
+	for i := range prs {
+		is, ok := issues[prs[i].issueKey]
+		if !ok {
+			continue
+		}
+		linked++
+		prs[i].issueType = is.issueType
+		prs[i].issueLeadTimeHours = issueLeadTime(is.inProgress, prs[i].mergedEpoch)
+	}
+	fmt.Fprintf(os.Stderr, "  Linked %d of %d PRs to %d Jira issues\n", linked, len(prs), len(issues))
+}
+
+// fetchJiraIssues looks up a batch of issue keys with their changelog.
+// Unknown keys (e.g. false-positive regex matches) are silently ignored by Jira
+// when validateQuery is disabled.
+func fetchJiraIssues(jc jiraConfig, keys []string, out map[string]jiraIssue) error {
+	params := url.Values{}
+	params.Set("jql", "key in ("+strings.Join(keys, ",")+")")
+	params.Set("fields", "issuetype")
+	params.Set("expand", "changelog")
+	params.Set("maxResults", fmt.Sprintf("%d", len(keys)))
+	params.Set("validateQuery", "false")
+	endpoint := strings.TrimSuffix(jc.baseURL, "/") + "/rest/api/2/search?" + params.Encode()
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if email, tok := os.Getenv("JIRA_EMAIL"), os.Getenv("JIRA_API_TOKEN"); tok != "" {
+			if email != "" {
+				req.SetBasicAuth(email, tok)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+tok)
+			}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("Jira returned %d (check JIRA_EMAIL/JIRA_API_TOKEN)", resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("Jira returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		var sr jiraSearchResponse
+		if err := json.Unmarshal(data, &sr); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+		for _, is := range sr.Issues {
+			ji := jiraIssue{issueType: is.Fields.IssueType.Name}
+			for _, h := range is.Changelog.Histories {
+				for _, item := range h.Items {
+					if item.Field != "status" || !strings.EqualFold(item.ToString, jc.inProgressStatus) {
+						continue
+					}
+					t, err := time.Parse(jiraTimeLayout, h.Created)
+					if err != nil {
+						continue
+					}
+					if ji.inProgress.IsZero() || t.Before(ji.inProgress) {
+						ji.inProgress = t
+					}
+				}
+			}
+			out[strings.ToUpper(is.Key)] = ji
+		}
+		return nil
+	}
+	return fmt.Errorf("Jira query failed after 3 attempts: %v", lastErr)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// jobPointsPerWeek is the GraphQL rate limit points an API analysis is
+// assumed to spend per week: the week's search pages, commit backfill, and
+// the odd retry. Busy repositories spend more and quiet ones less; the queue
+// only needs the order of magnitude, since it rereads the actual budget
+// before starting each job.
+const jobPointsPerWeek = 10
+
+// jobCost estimates the GraphQL points an analysis spends.
+func jobCost(req analyzeRequest) int {
+	return req.Weeks * jobPointsPerWeek
+}
+
+// poke wakes dispatch to look at the queue.
+func (ar *analyzeRunner) poke() {
+	select {
+	case ar.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch starts queued jobs in order for as long as the server runs. The
+// jobs share the server's token, so the head of the queue starts only when
+// a slot is free and the token's remaining GraphQL points, less the
+// estimated cost of the jobs already running and --rate-reserve, cover its
+// estimate. Otherwise it waits for a running job to finish or for the rate
+// limit to reset. Jobs behind the head wait too, so a large analysis isn't
+// starved by smaller ones.
+func (ar *analyzeRunner) dispatch() {
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ar.wake:
+		case <-retry:
+		}
+		retry = nil
+		for {
+			job, inFlight := ar.next()
+			if job == nil {
+				break
+			}
+			if wait := ar.budgetWait(job, inFlight); wait > 0 {
+				retry = time.After(wait)
+				break
+			}
+			ar.start(job)
+		}
+	}
+}
+
+// next returns the head of the queue and the points of the running jobs, or
+// nil when the queue is empty or every slot is taken.
+func (ar *analyzeRunner) next() (*analyzeJob, int) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if len(ar.queue) == 0 || ar.running >= ar.maxJobs {
+		return nil, 0
+	}
+	return ar.queue[0], ar.inFlight
+}
+
+func (ar *analyzeRunner) start(job *analyzeJob) {
+	ar.mu.Lock()
+	now := time.Now().UTC()
+	ar.queue = ar.queue[1:]
+	ar.running++
+	ar.inFlight += job.cost
+	job.attempts++
+	job.Status, job.StartedAt, job.Waiting = jobRunning, &now, ""
+	ar.mu.Unlock()
+	go ar.run(job)
+}
+
+// budgetWait returns how long job should wait for rate limit budget, or 0 to
+// start it now. It fails open: without a token or when the rate limit can't
+// be read, jobs start when a slot is free.
+func (ar *analyzeRunner) budgetWait(job *analyzeJob, inFlight int) time.Duration {
+	if ar.token == "" {
+		return 0
+	}
+	rl, err := fetchGraphQLRateLimit(ar.token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Reading the rate limit failed, starting job %s without a budget check: %v\n", job.ID, err)
+		return 0
+	}
+	// A job costing more than the whole budget above the reserve starts
+	// after a reset once nothing else is running.
+	need := inFlight + min(job.cost, max(rl.Limit-ar.reserve, 0))
+	if rl.Remaining-need >= ar.reserve {
+		return 0
+	}
+
+	wait := max(time.Until(rl.Reset)+5*time.Second, 5*time.Second)
+	msg := fmt.Sprintf("rate limit budget: %d GraphQL point(s) left, %d needed plus %d in reserve; resets at %s",
+		rl.Remaining, need, ar.reserve, rl.Reset.UTC().Format("15:04 UTC"))
+	ar.mu.Lock()
+	changed := job.Waiting != msg
+	job.Waiting = msg
+	ar.mu.Unlock()
+	if changed {
+		fmt.Fprintf(os.Stderr, "Job %s: waiting for %s\n", job.ID, msg)
+	}
+	return wait
+}
+
+// graphQLRateLimit is the token's GraphQL budget from GET /rate_limit.
+type graphQLRateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// fetchGraphQLRateLimit reads the token's GraphQL rate limit. The endpoint
+// itself doesn't count against any limit.
+func fetchGraphQLRateLimit(token string) (graphQLRateLimit, error) {
+	req, err := http.NewRequest("GET", "https://api.github.com/rate_limit", nil)
+	if err != nil {
+		return graphQLRateLimit{}, err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return graphQLRateLimit{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return graphQLRateLimit{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return graphQLRateLimit{}, fmt.Errorf("REST API returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+	}
+	var result struct {
+		Resources struct {
+			GraphQL struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"graphql"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return graphQLRateLimit{}, fmt.Errorf("unmarshal response: %w", err)
+	}
+	g := result.Resources.GraphQL
+	return graphQLRateLimit{Limit: g.Limit, Remaining: g.Remaining, Reset: time.Unix(g.Reset, 0)}, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxJobRuns caps the sampled workflow runs per week whose jobs --runners
+// and --ci-cost fetch, one request each: the push and pull_request samples
+// of fetchWeekBuildStats together.
+const maxJobRuns = 200
+
+type workflowJob struct {
+	Status      string    `json:"status"`
+	Labels      []string  `json:"labels"`
+	RunnerName  string    `json:"runner_name"`
+	CreatedAt   time.Time `json:"created_at"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type workflowJobsResponse struct {
+	Jobs []workflowJob `json:"jobs"`
+}
+
+// weekJobs is the jobs of one week's sampled workflow runs.
+type weekJobs struct {
+	jobs  []workflowJob
+	scale float64 // the week's runs / sampled runs read, to scale totals up to all runs
+}
+
+// fetchWeekJobs fetches the jobs of up to maxJobRuns of each week's sampled
+// workflow runs (see fetchWeekBuildStats).
+func fetchWeekJobs(cfg config, weeks []weekRange, builds []buildWeekStats) []weekJobs {
+	fmt.Fprintf(os.Stderr, "Fetching workflow jobs of the sampled runs...\n")
+
+	out := make([]weekJobs, len(weeks))
+	var failed atomic.Int64
+	sem := make(chan struct{}, maxConcurrency)
+
+	for i := range weeks {
+		if i >= len(builds) {
+			break
+		}
+		ids := builds[i].sampleRunIDs
+		if len(ids) > maxJobRuns {
+			ids = ids[:maxJobRuns]
+		}
+		var sampled atomic.Int64
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, id := range ids {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(id int64) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				jobs, err := restGetJobs(cfg.token, cfg.owner, cfg.repo, id)
+				if err != nil {
+					failed.Add(1)
+					return
+				}
+				sampled.Add(1)
+				mu.Lock()
+				out[i].jobs = append(out[i].jobs, jobs...)
+				mu.Unlock()
+			}(id)
+		}
+		wg.Wait()
+
+		out[i].scale = 1
+		if n := sampled.Load(); n > 0 && builds[i].runs > int(n) {
+			out[i].scale = float64(builds[i].runs) / float64(n)
+		}
+	}
+
+	if n := failed.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  WARNING: jobs missing for %d sampled run(s)\n", n)
+	}
+	return out
+}
+
+// restGetJobs fetches the first 100 jobs of a workflow run's latest attempt.
+// Finished runs' jobs don't change, so they are revalidated with ETags.
+func restGetJobs(token, owner, repo string, runID int64) ([]workflowJob, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs/%d/jobs?per_page=100", owner, repo, runID)
+
+	cached, hasCached := restETags.lookup(url)
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if hasCached {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		apiUsage.rest.Add(1)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		notModified := hasCached && resp.StatusCode == http.StatusNotModified
+		if notModified {
+			apiUsage.restNotModified.Add(1)
+			data = cached.Body
+		}
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("Actions API returned %d (no access or not enabled)", resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK && !notModified {
+			lastErr = fmt.Errorf("REST API returned %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		var result workflowJobsResponse
+		if err := json.Unmarshal(data, &result); err != nil {
+			lastErr = fmt.Errorf("unmarshal response: %w", err)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if !notModified {
+			restETags.store(url, resp.Header.Get("ETag"), result)
+		}
+		return result.Jobs, nil
+	}
+	return nil, fmt.Errorf("REST query failed after 3 attempts: %v", lastErr)
+}
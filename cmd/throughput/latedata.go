@@ -0,0 +1,14 @@
+package main
+
+import "time"
+
+// unstablePeriods returns how many trailing periods end on or after since,
+// the start of the first --unstable-weeks week. A month is unstable if any
+// of its weeks is.
+func unstablePeriods(periods []weekRange, since time.Time) int {
+	n := 0
+	for i := len(periods) - 1; i >= 0 && !periods[i].end.Before(since); i-- {
+		n++
+	}
+	return n
+}
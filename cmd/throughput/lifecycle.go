@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lifecycleStates are the PR states of the --lifecycle model, in the order
+// the Sankey diagram lays them out. Opened is the entry point and Merged the
+// end; a PR spends no time in either.
+var lifecycleStates = []string{"Opened", "Draft", "Ready", "Reviewed", "Approved", "Merged"}
+
+// lifecycleStep is one state a PR entered and when.
+type lifecycleStep struct {
+	state string
+	at    time.Time
+}
+
+// prLifecycle replays a PR's --enrich-reviews history as a state path, from
+// Opened at creation to Merged at merge:
+//
+//   - a PR opened as a draft (its first ready/draft event is a
+//     ReadyForReviewEvent) starts in Draft, any other in Ready;
+//   - ConvertToDraftEvent moves to Draft and ReadyForReviewEvent back to
+//     Ready, awaiting review again;
+//   - outside Draft, an approving review moves to Approved, and a review
+//     requesting changes, or commenting on a PR not yet approved, to Reviewed.
+//
+// Reviews by the author or by excluded users are ignored, as are dismissed
+// ones. Time a PR spent closed before a reopen is counted in the state it was
+// in. Returns nil without review details.
+func prLifecycle(pr PR, excludeSet map[string]bool) []lifecycleStep {
+	d := pr.ReviewDetails
+	if d == nil || pr.MergedAt.IsZero() {
+		return nil
+	}
+
+	type event struct {
+		at    time.Time
+		order int // timeline events before reviews at the same instant
+		kind  string
+	}
+	var events []event
+	initial := "Ready"
+	sawDraftEvent := false
+	for _, e := range d.Timeline {
+		if e.CreatedAt == nil {
+			continue
+		}
+		switch e.Typename {
+		case "ReadyForReviewEvent", "ConvertToDraftEvent":
+			if !sawDraftEvent && e.Typename == "ReadyForReviewEvent" {
+				initial = "Draft"
+			}
+			sawDraftEvent = true
+			events = append(events, event{at: *e.CreatedAt, kind: e.Typename})
+		}
+	}
+	author := strings.ToLower(pr.Author.Login)
+	for _, r := range d.Reviews {
+		login := strings.ToLower(r.Author.Login)
+		if r.SubmittedAt == nil || login == author || excludeSet[login] {
+			continue
+		}
+		events = append(events, event{at: *r.SubmittedAt, order: 1, kind: r.State})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].order < events[j].order
+	})
+
+	steps := []lifecycleStep{{"Opened", pr.CreatedAt}, {initial, pr.CreatedAt}}
+	state := initial
+	for _, e := range events {
+		if e.at.After(pr.MergedAt) {
+			break
+		}
+		next := state
+		switch e.kind {
+		case "ConvertToDraftEvent":
+			next = "Draft"
+		case "ReadyForReviewEvent":
+			if state == "Draft" {
+				next = "Ready"
+			}
+		case "APPROVED":
+			if state != "Draft" {
+				next = "Approved"
+			}
+		case "CHANGES_REQUESTED":
+			if state != "Draft" {
+				next = "Reviewed"
+			}
+		case "COMMENTED":
+			if state == "Ready" {
+				next = "Reviewed"
+			}
+		}
+		if next != state {
+			steps = append(steps, lifecycleStep{next, e.at})
+			state = next
+		}
+	}
+	return append(steps, lifecycleStep{"Merged", pr.MergedAt})
+}
+
+// lifecycleTransition counts the moves between two states across PRs. A PR
+// moving back and forth counts every move.
+type lifecycleTransition struct {
+	from, to string
+	count    int
+}
+
+// stateDwell is the time PRs spent in one state. A PR's time in a state sums
+// all its visits; the distribution is over the PRs that visited it.
+type stateDwell struct {
+	state       string
+	prs         int
+	medianHours float64
+	p90Hours    float64
+	share       float64 // of the total time all PRs spent open, in percent
+}
+
+type lifecycleModel struct {
+	prs         int
+	transitions []lifecycleTransition // in lifecycleStates order of from, then to
+	dwell       []stateDwell          // states PRs spend time in, in lifecycleStates order
+}
+
+// buildLifecycle aggregates the PRs' state paths. PRs without one (no
+// --enrich-reviews data) are left out. With minGroupSize, nil is returned if
+// fewer authors have a path.
+func buildLifecycle(prs []enrichedPR, minGroupSize int) *lifecycleModel {
+	m := &lifecycleModel{}
+	counts := make(map[[2]string]int)
+	hours := make(map[string][]float64)
+	total := make(map[string]float64)
+	var totalHours float64
+	authors := make(map[string]bool)
+	for _, pr := range prs {
+		if len(pr.lifecycle) == 0 {
+			continue
+		}
+		m.prs++
+		authors[pr.authorLogin] = true
+		perState := make(map[string]float64)
+		for i := 1; i < len(pr.lifecycle); i++ {
+			prev, cur := pr.lifecycle[i-1], pr.lifecycle[i]
+			counts[[2]string{prev.state, cur.state}]++
+			if prev.state != "Opened" {
+				perState[prev.state] += cur.at.Sub(prev.at).Hours()
+			}
+		}
+		for state, h := range perState {
+			hours[state] = append(hours[state], h)
+			total[state] += h
+			totalHours += h
+		}
+	}
+	if m.prs == 0 || len(authors) < minGroupSize {
+		return nil
+	}
+
+	for _, from := range lifecycleStates {
+		for _, to := range lifecycleStates {
+			if n := counts[[2]string{from, to}]; n > 0 {
+				m.transitions = append(m.transitions, lifecycleTransition{from: from, to: to, count: n})
+			}
+		}
+		if vals := hours[from]; len(vals) > 0 {
+			d := stateDwell{state: from, prs: len(vals), medianHours: median(vals), p90Hours: p90(vals)}
+			if totalHours > 0 {
+				d.share = total[from] / totalHours * 100
+			}
+			m.dwell = append(m.dwell, d)
+		}
+	}
+	return m
+}
+
+// bottleneck returns the state holding the largest share of open time.
+func (m *lifecycleModel) bottleneck() stateDwell {
+	var top stateDwell
+	for _, d := range m.dwell {
+		if d.share > top.share {
+			top = d
+		}
+	}
+	return top
+}
+
+// logLifecycle prints the time-in-state table and the bottleneck to stderr.
+func logLifecycle(m *lifecycleModel) {
+	fmt.Fprintf(os.Stderr, "PR lifecycle (%d PRs with review history):\n", m.prs)
+	for _, d := range m.dwell {
+		fmt.Fprintf(os.Stderr, "  %-9s %4d PR(s), median %.1fh, p90 %.1fh, %.0f%% of open time\n",
+			d.state, d.prs, d.medianHours, d.p90Hours, d.share)
+	}
+	if b := m.bottleneck(); b.state != "" {
+		fmt.Fprintf(os.Stderr, "  Most open time is spent in %s\n", b.state)
+	}
+}
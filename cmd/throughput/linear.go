@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const linearEndpoint = "https://api.linear.app/graphql"
+
+const defaultLinearKeyPattern = `(?i)\b[A-Z][A-Z0-9]{1,6}-[0-9]+\b`
+
+// linearIssue is the subset of Linear issue data joined onto PRs.
+type linearIssue struct {
+	Identifier string     `json:"identifier"`
+	StartedAt  *time.Time `json:"startedAt"`
+	Project    *struct {
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+// applyLinearIssues resolves Linear issue identifiers referenced in PR branch
+// names or titles and records project and lead time (issue started → merged).
+// Requires LINEAR_API_KEY.
+func applyLinearIssues(keyRe *regexp.Regexp, prs []enrichedPR) {
+	apiKey := os.Getenv("LINEAR_API_KEY")
+	if apiKey == "" {
+		fmt.Fprintf(os.Stderr, "  WARNING: LINEAR_API_KEY not set, skipping Linear join\n")
+		return
+	}
+
+	keys := extractIssueKeys(keyRe, prs)
+	if len(keys) == 0 {
+		fmt.Fprintf(os.Stderr, "  No Linear issue identifiers found in PR titles or branches\n")
+		return
+	}
+
+	issues := make(map[string]linearIssue)
+	const batchSize = 50
+	for i := 0; i < len(keys); i += batchSize {
+		batch := keys[i:min(i+batchSize, len(keys))]
+		if err := fetchLinearIssues(apiKey, batch, issues); err != nil {
+			fmt.Fprintf(os.Stderr, "  WARNING: Linear lookup failed: %v\n", err)
+		}
+	}
+
+	var linked int
+	for i := range prs {
+		is, ok := issues[prs[i].issueKey]
+		if !ok {
+			continue
+		}
+		linked++
+		if is.Project != nil {
+			prs[i].issueProject = is.Project.Name
+		}
+		if is.StartedAt != nil {
+			prs[i].issueLeadTimeHours = issueLeadTime(*is.StartedAt, prs[i].mergedEpoch)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Linked %d of %d PRs to %d Linear issues\n", linked, len(prs), len(issues))
+}
+
+// fetchLinearIssues looks up a batch of identifiers in one query using aliases.
+// Identifiers that don't exist (false-positive regex matches) come back as
+// per-alias errors with null data, which are ignored.
+func fetchLinearIssues(apiKey string, keys []string, out map[string]linearIssue) error {
+	var q strings.Builder
+	q.WriteString("{")
+	for i, k := range keys {
+		fmt.Fprintf(&q, ` i%d: issue(id: %q) { identifier startedAt project { name } }`, i, k)
+	}
+	q.WriteString(" }")
+
+	body, err := json.Marshal(graphqlRequest{Query: q.String()})
+	if err != nil {
+		return fmt.Errorf("marshal query: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("POST", linearEndpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", apiKey)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("Linear returned 401 (check LINEAR_API_KEY)")
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data[:min(200, len(data))]))
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		var gqlResp graphqlResponse
+		if err := json.Unmarshal(data, &gqlResp); err != nil {
+			return fmt.Errorf("unmarshal response: %w", err)
+		}
+		var result map[string]*linearIssue
+		if len(gqlResp.Data) > 0 {
+			if err := json.Unmarshal(gqlResp.Data, &result); err != nil {
+				return fmt.Errorf("unmarshal data: %w", err)
+			}
+		}
+		for _, is := range result {
+			if is != nil {
+				out[strings.ToUpper(is.Identifier)] = *is
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("Linear query failed after 3 attempts: %v", lastErr)
+}
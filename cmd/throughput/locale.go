@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportLocale controls number/date formatting and UI strings in the HTML report.
+// CSV output is never localized so downstream tools can parse it reliably.
+type reportLocale struct {
+	code         string // BCP 47 tag passed to the browser, e.g. "de-DE"
+	decimalSep   string
+	thousandsSep string
+	dateLayout   string            // long date, e.g. window descriptions
+	shortLayout  string            // chart axis labels
+	strings      map[string]string // English UI string → translation; missing keys fall back to English
+}
+
+var locales = map[string]reportLocale{
+	"en": {
+		code:         "en-US",
+		decimalSep:   ".",
+		thousandsSep: ",",
+		dateLayout:   "Jan 2, 2006",
+		shortLayout:  "2006-01-02",
+	},
+	"de": {
+		code:         "de-DE",
+		decimalSep:   ",",
+		thousandsSep: ".",
+		dateLayout:   "02.01.2006",
+		shortLayout:  "02.01.2006",
+		strings:      germanStrings,
+	},
+}
+
+// activeLocale is the locale used for HTML rendering, set from --locale.
+var activeLocale = locales["en"]
+
+// setLocale selects a report locale by short code (e.g. "de") or BCP 47 tag (e.g. "de-DE").
+func setLocale(name string) error {
+	key := strings.ToLower(name)
+	if i := strings.IndexAny(key, "-_"); i > 0 {
+		key = key[:i]
+	}
+	loc, ok := locales[key]
+	if !ok {
+		return fmt.Errorf("unsupported locale %q (supported: en, de)", name)
+	}
+	activeLocale = loc
+	return nil
+}
+
+// T translates an English UI string, falling back to the input.
+func (l reportLocale) T(s string) string {
+	if t, ok := l.strings[s]; ok {
+		return t
+	}
+	return s
+}
+
+// number formats v with prec decimals using the locale's separators.
+func (l reportLocale) number(v float64, prec int) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', prec, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	var grouped strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteString(l.thousandsSep)
+		}
+		grouped.WriteRune(c)
+	}
+	out := grouped.String()
+	if frac != "" {
+		out += l.decimalSep + frac
+	}
+	if v < 0 && strings.Trim(out, "0"+l.decimalSep+l.thousandsSep) != "" {
+		out = "-" + out
+	}
+	return out
+}
+
+// localizeNumeric rewrites the decimal point in a preformatted numeric string
+// such as "+12.5%" or "3.20". Non-numeric strings like "N/A" pass through.
+func (l reportLocale) localizeNumeric(s string) string {
+	if l.decimalSep == "." {
+		return s
+	}
+	return strings.Replace(s, ".", l.decimalSep, 1)
+}
+
+// date formats t with the locale's long date layout.
+func (l reportLocale) date(t time.Time) string {
+	return t.Format(l.dateLayout)
+}
+
+var germanStrings = map[string]string{
+	"Data filters applied:": "Angewendete Datenfilter:",
+	"Cycle Time:":           "Durchlaufzeit:",
+	"Activity":              "Aktivität",
+	"Top Contributors — Before & After Ona": "Top-Beitragende — vor & nach Ona",
+	"PRs total":                           "PRs gesamt",
+	"PRs/week":                            "PRs/Woche",
+	"Throughput & Cycle Time by":          "Durchsatz & Durchlaufzeit nach",
+	"Share":                               "Anteil",
+	"Median coding time":                  "Median Entwicklungszeit",
+	"Median review time":                  "Median Reviewzeit",
+	"Median lead time (started → merged)": "Median Vorlaufzeit (begonnen → gemergt)",
+	"Correlations":                        "Korrelationen",
+	"Correlation Matrix":                  "Korrelationsmatrix",
+	"Metric":                              "Metrik",
+	"Against":                             "Gegen",
+	"Periods":                             "Perioden",
+	"not significant":                     "nicht signifikant",
+	"insufficient data":                   "zu wenige Daten",
+	"too few periods to test":             "zu wenige Perioden für einen Test",
+	"Goals":                               "Ziele",
+	"Target":                              "Ziel",
+	"Current":                             "Aktuell",
+	"Periods met":                         "Perioden erreicht",
+	"Pass":                                "Erreicht",
+	"Fail":                                "Verfehlt",
+	"Target: %s %s":                       "Ziel: %s %s",
+	"Estimate history":                    "Verlauf der Schätzungen",
+	"Run":                                 "Lauf",
+	"p-value":                             "p-Wert",
+	"Metric Definitions":                  "Metrikdefinitionen",
+	"Benefits":                            "Vorteile",
+	"Drawbacks":                           "Nachteile",
+	"PRs per Engineer":                    "PRs pro Entwickler",
+	"Commits per Engineer":                "Commits pro Entwickler",
+	"PRs/Eng Trend":                       "PRs/Entw. Trend",
+	"% Ona Involved":                      "% mit Ona",
+	"% Lines in Ona PRs":                  "% Zeilen in Ona-PRs",
+	"% Reverts":                           "% Reverts",
+	"Coding Time":                         "Entwicklungszeit",
+	"Review Time":                         "Reviewzeit",
+	"Time Spent Coding (hrs)":             "Entwicklungszeit (Std.)",
+	"Time Spent Reviewing (hrs)":          "Reviewzeit (Std.)",
+	"Time Spent Reviewing, %s PRs (hrs)":  "Reviewzeit, %s-PRs (Std.)",
+	"PRs Merged":                          "Gemergte PRs",
+	"Incidents":                           "Vorfälle",
+	"Median MTTR (hrs)":                   "Median MTTR (Std.)",
+	"hrs":                                 "Std.",
+	"Week Starting":                       "Wochenbeginn",
+	"PRs / Engineer":                      "PRs / Entwickler",
+	"Hours":                               "Stunden",
+	"Size Points per Engineer":            "Größenpunkte pro Entwickler",
+	"Size Points / Engineer":              "Größenpunkte / Entwickler",
+	"Median Size Points / Engineer":       "Median Größenpunkte / Entwickler",
+	"Median PRs / Engineer":               "Median PRs / Entwickler",
+	"Ona Involved":                        "Mit Ona",
+	"Ona lines":                           "Ona-Zeilen",
+	"PRs merged":                          "Gemergte PRs",
+	"Unique authors":                      "Autoren",
+	"Commits / engineer":                  "Commits / Entwickler",
+	"Active engineers (4w)":               "Aktive Entwickler (4 Wo.)",
+	"Churned engineers":                   "Abgewanderte Entwickler",
+	"Active Engineers (4w)":               "Aktive Entwickler (4 Wo.)",
+	"Churned Engineers":                   "Abgewanderte Entwickler",
+	"Engineers":                           "Entwickler",
+	"Build success":                       "Build-Erfolg",
+	"Median Time Spent Coding":            "Median Entwicklungszeit",
+	"Median Time Spent Reviewing":         "Median Reviewzeit",
+	"Speed":                               "Geschwindigkeit",
+	"Quality":                             "Qualität",
+	"Ona Uptake":                          "Ona-Nutzung",
+	"No Ona PRs":                          "Keine Ona-PRs",
+	"Excluded from the metrics (bottom contributors)": "Nicht in den Kennzahlen (unterste Beitragende)",
+	"week(s)":   "Woche(n)",
+	"month(s)":  "Monat(e)",
+	"sprint(s)": "Sprint(s)",
+	"Comparing first %d %s (%s – %s) vs last %d %s (%s – %s)": "Vergleich der ersten %d %s (%s – %s) mit den letzten %d %s (%s – %s)",
+	"Pearson r":       "Pearson-r",
+	"Comparing ":      "Vergleich: ",
+	"Jira Issue Type": "Jira-Vorgangstyp",
+	"Linear Project":  "Linear-Projekt",
+
+	"Industry benchmarks":             "Branchenvergleich",
+	"Ours":                            "Unser Wert",
+	"Band":                            "Stufe",
+	"Bands":                           "Stufen",
+	"Measured as":                     "Gemessen als",
+	"Source:":                         "Quelle:",
+	"High":                            "Hoch",
+	"Medium":                          "Mittel",
+	"Low":                             "Niedrig",
+	"/day":                            "/Tag",
+	"Deployment frequency":            "Deployment-Häufigkeit",
+	"Lead time for changes":           "Vorlaufzeit für Änderungen",
+	"Change failure rate":             "Änderungsfehlerrate",
+	"Failed deployment recovery time": "Wiederherstellungszeit nach Fehlern",
+	"PRs merged per day":              "Gemergte PRs pro Tag",
+	"Title":                           "Titel",
+	"Author":                          "Autor",
+	"Lines changed":                   "Geänderte Zeilen",
+	"Merged":                          "Gemergt",
+	"In this report":                  "In diesem Bericht",
+	"Active / Churned Engineers":      "Aktive / abgewanderte Entwickler",
+	"Reviewer Responsiveness":         "Reaktionszeit der Reviewer",
+	"Reviewer":                        "Reviewer",
+	"Review requests":                 "Review-Anfragen",
+	"Answered":                        "Beantwortet",
+	"Median response":                 "Median Reaktionszeit",
+	"P90 response":                    "P90 Reaktionszeit",
+	"Ona Adoption by Contributor":     "Ona-Nutzung nach Entwickler",
+	"Power users":                     "Power-User",
+	"Contributor":                     "Entwickler",
+	"Ona PRs":                         "Ona-PRs",
+	"Latest Ona PR":                   "Letzter Ona-PR",
+	"Not using Ona yet":               "Noch ohne Ona",
+	"Latest PR":                       "Letzter PR",
+	"No contributor has an Ona-involved PR yet.": "Noch kein Entwickler hat einen PR mit Ona.",
+	"Every contributor has an Ona-involved PR.":  "Jeder Entwickler hat einen PR mit Ona.",
+	"Power users have the most Ona-involved PRs in the window; the second list holds the most active contributors without any. Greyed-out rows are excluded from the metrics as bottom contributors.": "Power-User haben die meisten PRs mit Ona im Zeitraum; die zweite Liste enthält die aktivsten Entwickler ohne einen solchen PR. Ausgegraute Zeilen sind als schwächste Beitragende aus den Metriken ausgeschlossen.",
+	"Median Review Response":        "Median Review-Reaktionszeit",
+	"Review Response (hrs)":         "Review-Reaktionszeit (Std.)",
+	"Review Response Time":          "Review-Reaktionszeit",
+	"Described":                     "Mit Beschreibung",
+	"Linked to Issue":               "Mit Issue verknüpft",
+	"With Tests":                    "Mit Tests",
+	"% Described":                   "% mit Beschreibung",
+	"% Linked to Issue":             "% mit Issue verknüpft",
+	"% With Tests":                  "% mit Tests",
+	"Unreviewed":                    "Ohne Review",
+	"% Approved":                    "% freigegeben",
+	"% Unreviewed":                  "% ohne Review",
+	"% Template Compliant":          "% vorlagenkonform",
+	"Template compliance":           "Vorlagenkonformität",
+	"Branch protection changed":     "Branch-Schutz geändert",
+	"Protection":                    "Schutz",
+	"Median CI Queue Time":          "Median CI-Wartezeit",
+	"Median Security Fix Lead Time": "Median Durchlaufzeit Sicherheitskorrekturen",
+	"CI Queue Time (min)":           "CI-Wartezeit (Min.)",
+	"Billable CI Minutes":           "Abgerechnete CI-Minuten",
+	"CI Cost":                       "CI-Kosten",
+	"Minutes":                       "Minuten",
+	"min":                           "Min.",
+	"Ona-Involved vs Other PRs":     "PRs mit Ona vs. andere PRs",
+	"Ona-involved":                  "Mit Ona",
+	"Other":                         "Andere",
+	"Difference":                    "Differenz",
+	"Median size (lines)":           "Median Größe (Zeilen)",
+	"Reviews per PR":                "Reviews pro PR",
+	"Revert rate":                   "Revert-Quote",
+	"CI failure rate":               "CI-Fehlerquote",
+	"Ona-Involved vs Matched PRs":   "PRs mit Ona vs. vergleichbare PRs",
+	"Matched":                       "Vergleichbar",
+	"Outcome":                       "Ergebnis",
+	"Contributors":                  "Beitragende",
+	"Ona effect":                    "Ona-Effekt",
+	"95% CI":                        "95%-KI",
+	"Cycle time":                    "Durchlaufzeit",
+	"Coding time":                   "Entwicklungszeit",
+	"Review time":                   "Reviewzeit",
+	"Size (lines)":                  "Größe (Zeilen)",
+	"log2 lines changed":            "log2 geänderte Zeilen",
+	"log2 files changed":            "log2 geänderte Dateien",
+	"merge week":                    "Merge-Woche",
+	"Cumulative Flow":               "Kumulatives Flussdiagramm",
+	"In Review":                     "Im Review",
+	"Open":                          "Offen",
+	"PR Lifecycle":                  "PR-Lebenszyklus",
+	"State":                         "Status",
+	"Opened":                        "Eröffnet",
+	"Draft":                         "Entwurf",
+	"Ready":                         "Bereit",
+	"Reviewed":                      "Reviewt",
+	"Approved":                      "Freigegeben",
+	"Median time in state":          "Median Verweildauer",
+	"P90 time in state":             "P90 Verweildauer",
+	"Share of open time":            "Anteil der offenen Zeit",
+	"%d PRs with review history. Band widths are transitions between states; moves back to an earlier state arc below. Most open time is spent in %s.": "%d PRs mit Review-Verlauf. Die Bandbreiten sind Übergänge zwischen Status; Rückschritte zu einem früheren Status verlaufen als Bögen darunter. Die meiste offene Zeit entfällt auf %s.",
+	"Cycle Time per PR":            "Durchlaufzeit pro PR",
+	"lines":                        "Zeilen",
+	"Cycle time (hrs, log scale)":  "Durchlaufzeit (Std., log. Skala)",
+	"Distributions":                "Verteilungen",
+	"PR Size (lines)":              "PR-Größe (Zeilen)",
+	"% of PRs":                     "% der PRs",
+	"too few PRs to test":          "zu wenige PRs für einen Test",
+	"Below %s Ona":                 "Unter %s Ona",
+	"Above %s Ona":                 "Über %s Ona",
+	"First %d %s (%s)":             "Erste %d %s (%s)",
+	"Last %d %s (%s)":              "Letzte %d %s (%s)",
+	"Activity by Weekday and Hour": "Aktivität nach Wochentag und Uhrzeit",
+	"Merges":                       "Merges",
+	"Commits":                      "Commits",
+	"Mon":                          "Mo",
+	"Tue":                          "Di",
+	"Wed":                          "Mi",
+	"Thu":                          "Do",
+	"Fri":                          "Fr",
+	"Sat":                          "Sa",
+	"Sun":                          "So",
+	"Filter Sensitivity":           "Filter-Sensitivität",
+	"Bottom contributors excluded": "Ausgeschlossene Beitragende",
+	"Min PRs":                      "Min. PRs",
+	"Same conclusion as this run":  "Gleiche Schlussfolgerung wie dieser Lauf",
+	"Data Quality":                 "Datenqualität",
+	"Holidays":                     "Feiertage",
+	"Reopened PRs":                 "Wiedereröffnete PRs",
+	"Automation PRs":               "Automatisierungs-PRs",
+	"Automation Merge Time (hrs)":  "Merge-Zeit Automatisierung (Std.)",
+	"Security Fixes":               "Sicherheitskorrekturen",
+	"Release":                      "Releases",
+	"Releases":                     "Releases",
+	"Days Between Releases":        "Tage zwischen Releases",
+	"Median Days Between Releases": "Median Tage zwischen Releases",
+	"PRs per Release":              "PRs pro Release",
+	"Median Release Batch Size":    "Median Release-Umfang",
+	"Release Batch Size (PRs)":     "Release-Umfang (PRs)",
+	"Days":                         "Tage",
+	"days":                         "Tage",
+	"Security Fix Lead Time (hrs)": "Durchlaufzeit Sicherheitskorrekturen (Std.)",
+	"Δ %s week over week":          "Δ %s ggü. Vorwoche",
+	"Δ %s month over month":        "Δ %s ggü. Vormonat",
+	"Δ %s sprint over sprint":      "Δ %s ggü. Vorsprint",
+	"Year over Year":               "Vorjahresvergleich",
+	"Key findings":                 "Wichtigste Ergebnisse",
+	"%s rose %s (%s → %s)":         "%s stieg um %s (%s → %s)",
+	"%s fell %s (%s → %s)":         "%s sank um %s (%s → %s)",
+	"%s rose from %s to %s":        "%s stieg von %s auf %s",
+	", an improvement":             ", eine Verbesserung",
+	", a regression":               ", eine Verschlechterung",
+	"%s, a year earlier":           "%s, ein Jahr zuvor",
+	"Throughput by Join Quarter":   "Durchsatz nach Einstiegsquartal",
+	"%s and earlier":               "%s und früher",
+	"Weeks":                        "Wochen",
+	"Since first merged PR":        "Seit dem ersten gemergten PR",
+	"PRs / week per contributor":   "PRs / Woche je Mitwirkendem",
+	"Holiday weeks":                "Feiertagswochen",
+	"Filter":                       "Filter",
+	"Removed":                      "Entfernt",
+	"Scope":                        "Geltungsbereich",
+	"Removed items":                "Entfernte Einträge",
+	"Before/after comparison":      "Vorher/Nachher-Vergleich",
+	"Whole report":                 "Gesamter Bericht",
+	"PR(s)":                        "PR(s)",
+	"Bot authors":                  "Bot-Autoren",
+	"Excluded users":               "Ausgeschlossene Nutzer",
+	"Not merged":                   "Nicht gemergt",
+	"Drafts":                       "Entwürfe",
+	"Bottom contributors":          "Beitragende mit den wenigsten PRs",
+	"Below --min-prs":              "Unter --min-prs",
+	"Below 10% of average PRs":     "Unter 10 % der durchschnittlichen PRs",
+	"Times in %s. %s outside Mon–Fri 9:00–18:00.":                 "Zeiten in %s. %s außerhalb Mo–Fr 9:00–18:00.",
+	"median coding time + median review time":                     "Median Entwicklungszeit + Median Reviewzeit",
+	"share of merged PRs that are reverts":                        "Anteil der gemergten PRs, die Reverts sind",
+	"median incident MTTR (needs --incidents-csv or --pagerduty)": "Median Incident-MTTR (benötigt --incidents-csv oder --pagerduty)",
+	"Values are the last comparison window. PR data only approximates deployment metrics, so read bands as a rough placement.": "Werte aus dem letzten Vergleichszeitraum. PR-Daten nähern Deployment-Metriken nur an; die Stufen sind eine grobe Einordnung.",
+	"Click a point on the chart to list the PRs merged in that period.":                                                        "Klicken Sie auf einen Punkt im Diagramm, um die in diesem Zeitraum gemergten PRs anzuzeigen.",
+	"%d of %d Ona-involved PRs matched to a similar other PR (%s same author, %s same top-level directory).":                   "%d von %d PRs mit Ona wurden einem vergleichbaren anderen PR zugeordnet (%s gleicher Autor, %s gleiches Hauptverzeichnis).",
+	"Covariate balance (standardized mean difference, |SMD| < 0.1 is balanced):":                                               "Kovariatenbalance (standardisierte Mittelwertdifferenz, |SMD| < 0,1 gilt als ausgeglichen):",
+	"Shaded periods contain public holidays (%s); hover a period to see which.":                                                "Hinterlegte Zeiträume enthalten gesetzliche Feiertage (%s); mit der Maus über einen Zeitraum fahren, um sie zu sehen.",
+	"Contributors are grouped by the quarter of their first merged PR in the window. Each point is the cohort's mean PRs per week per contributor in a 4-week block since joining; a curve ends where its members' blocks run past the window. The first quarter also holds everyone who was already active before the window, so it is hidden by default.": "Mitwirkende sind nach dem Quartal ihres ersten gemergten PRs im Zeitraum gruppiert. Jeder Punkt ist der mittlere Wert an PRs pro Woche je Mitwirkendem der Kohorte in einem 4-Wochen-Block seit dem Einstieg; eine Kurve endet, wo die Blöcke ihrer Mitglieder über den Zeitraum hinausreichen. Das erste Quartal enthält auch alle, die schon vor dem Zeitraum aktiv waren, und ist daher standardmäßig ausgeblendet.",
+	"Headline before/after changes recomputed at each filter setting; the run's own setting is shaded. Bold changes are significant at p < %s; highlighted cells reach a different conclusion than the run's setting.":                                                                                                                                      "Zentrale Vorher/Nachher-Änderungen, für jede Filtereinstellung neu berechnet; die Einstellung dieses Laufs ist hinterlegt. Fett gedruckte Änderungen sind signifikant bei p < %s; markierte Zellen kommen zu einer anderen Schlussfolgerung als dieser Lauf.",
+	"Each period next to the same period a year earlier (52 weeks, or the same calendar month), dashed. A change that also shows up last year is likely seasonal. Click a legend entry to switch metrics.":                                                                                                                                                  "Jeder Zeitraum neben demselben Zeitraum ein Jahr zuvor (52 Wochen bzw. derselbe Kalendermonat), gestrichelt. Eine Veränderung, die sich auch im Vorjahr zeigt, ist vermutlich saisonal. Klicken Sie auf einen Legendeneintrag, um die Metrik zu wechseln.",
+	"Too few periods for a before/after comparison, so there are no findings.":                                                                                             "Zu wenige Zeiträume für einen Vorher/Nachher-Vergleich, daher keine Ergebnisse.",
+	"Significance testing is off (--significance-level 0), so the changes below may be noise.":                                                                             "Der Signifikanztest ist ausgeschaltet (--significance-level 0), die folgenden Veränderungen können Rauschen sein.",
+	"No metric changed significantly (p < %s); the largest move, %s, is within noise.":                                                                                     "Keine Metrik hat sich signifikant verändert (p < %s); die größte Bewegung, %s, liegt im Rauschen.",
+	"%d of %d metrics changed significantly: %d improved, %d regressed.":                                                                                                   "%d von %d Metriken haben sich signifikant verändert: %d verbessert, %d verschlechtert.",
+	"One side of the comparison has only %d %s, so a single unusual period weighs heavily.":                                                                                "Eine Seite des Vergleichs umfasst nur %d %s, ein einzelner ungewöhnlicher Zeitraum fällt daher stark ins Gewicht.",
+	"%d %s had fewer authors than --min-group-size and are left out of the comparison.":                                                                                    "%d %s hatten weniger Autoren als --min-group-size und fehlen im Vergleich.",
+	"The last %d %s may still change as late-merged PRs arrive; they are drawn dashed.":                                                                                    "Die letzten %d %s können sich durch spät gemergte PRs noch ändern und sind gestrichelt dargestellt.",
+	"Violet lines mark branch protection changes; hover a period for details.":                                                                                             "Violette Linien markieren Änderungen am Branch-Schutz; für Details über einen Zeitraum fahren.",
+	"▲/▼ after a series name: it trends up/down over the chart (Mann-Kendall test, p < %s); – : no significant trend. Series with fewer than %d %s of data have no badge.": "▲/▼ nach einem Reihennamen: Die Reihe steigt/fällt im Diagramm (Mann-Kendall-Test, p < %s); – : kein signifikanter Trend. Reihen mit weniger als %d %s mit Daten haben kein Symbol.",
+	"▲/▼ after a series name: the direction it trends over the chart, with the Mann-Kendall p-value. Series with fewer than %d %s of data have no badge.":                  "▲/▼ nach einem Reihennamen: die Trendrichtung im Diagramm, mit dem p-Wert des Mann-Kendall-Tests. Reihen mit weniger als %d %s mit Daten haben kein Symbol.",
+	"Pearson r of each pair of metrics over the periods where both have data: blue moves together, red in opposite directions. Bold is significant at p < %s; hover a cell for its p-value. Empty cells have fewer than 4 shared periods. Metrics computed from each other correlate by construction.": "Pearson-r jedes Metrikpaars über die Perioden, in denen beide Daten haben: Blau bewegt sich gleich, Rot gegenläufig. Fett ist signifikant bei p < %s; für den p-Wert mit der Maus auf eine Zelle zeigen. Leere Zellen haben weniger als 4 gemeinsame Perioden. Auseinander berechnete Metriken korrelieren zwangsläufig.",
+	"Pearson r of each pair of metrics over the periods where both have data: blue moves together, red in opposite directions. Hover a cell for its p-value. Empty cells have fewer than 4 shared periods. Metrics computed from each other correlate by construction.":                                "Pearson-r jedes Metrikpaars über die Perioden, in denen beide Daten haben: Blau bewegt sich gleich, Rot gegenläufig. Für den p-Wert mit der Maus auf eine Zelle zeigen. Leere Zellen haben weniger als 4 gemeinsame Perioden. Auseinander berechnete Metriken korrelieren zwangsläufig.",
+	"Ona-Involved PRs: Mixed-Effects Model": "PRs mit Ona: gemischtes Modell",
+	"Coefficient (log)":                     "Koeffizient (log)",
+	"Between contributors":                  "Zwischen Beitragenden",
+	"Per-PR linear mixed model of log(1 + outcome) on Ona involvement and merge time, plus log lines changed for the time outcomes, with a random intercept per contributor (REML). The effect is how an Ona-involved PR differs from a PR of the same size and time, mostly compared within each contributor's own PRs; intervals are 95% Wald intervals and p < 0.05 is bold. Between contributors is the share of unexplained variance due to who wrote the PR. An association, not a causal effect.": "Lineares gemischtes Modell pro PR für log(1 + Ergebnis) mit Ona-Beteiligung und Merge-Zeitpunkt, bei den Zeiten zusätzlich log geänderte Zeilen, und einem zufälligen Achsenabschnitt pro Beitragendem (REML). Der Effekt ist der Unterschied eines PRs mit Ona zu einem PR gleicher Größe und Zeit, überwiegend innerhalb der PRs desselben Beitragenden verglichen; Intervalle sind 95%-Wald-Intervalle, p < 0,05 ist fett. Zwischen Beitragenden ist der Anteil der unerklärten Varianz, der davon abhängt, wer den PR geschrieben hat. Ein Zusammenhang, kein kausaler Effekt.",
+	"May still change (late merges)":        "Kann sich noch ändern (späte Merges)",
+	"Incomplete: some data failed to fetch": "Unvollständig: Daten konnten nicht vollständig abgerufen werden",
+	"Self-Hosted Runner Utilization":        "Auslastung selbst gehosteter Runner",
+	"Utilization (%)":                       "Auslastung (%)",
+	"Median Queue (min)":                    "Median Wartezeit (Min.)",
+	"Weekly, from the jobs of up to 200 sampled push and pull request runs per week. Utilization is the jobs' run time, scaled up to all of the week's runs, over the runners seen times 168 hours, capped at 100%. Dashed lines are the median time jobs waited for a runner. Scheduled and manually dispatched runs aren't sampled.": "Wöchentlich, aus den Jobs von bis zu 200 Stichproben-Läufen (Push und Pull Request) pro Woche. Die Auslastung ist die Laufzeit der Jobs, hochgerechnet auf alle Läufe der Woche, geteilt durch die gesehenen Runner mal 168 Stunden, höchstens 100 %. Gestrichelte Linien zeigen die mittlere Wartezeit der Jobs auf einen Runner. Geplante und manuell gestartete Läufe sind nicht in der Stichprobe.",
+}
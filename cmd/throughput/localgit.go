@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitLogFormat separates commits with RS and fields with US so commit
+// messages can contain anything. --numstat lines follow each commit's fields.
+const gitLogFormat = "%x1e%H%x1f%ae%x1f%aI%x1f%cI%x1f%ce%x1f%B%x1f"
+
+// fetchLocalGitCommits reads the non-merge commits on cfg.branch in the
+// local clone at cfg.gitDir (--local-git) and maps each onto the PR model,
+// so the rest of the pipeline works unchanged with no API token. It returns
+// the weeks that couldn't be read like the API fetchers do; git log covers
+// the whole range in one run, so either all weeks fail or none.
+func fetchLocalGitCommits(cfg config, weeks []weekRange) ([]PR, []weekRange) {
+	if len(weeks) == 0 {
+		return nil, nil
+	}
+	since := weeks[0].start.Format(time.RFC3339)
+	until := weeks[len(weeks)-1].end.AddDate(0, 0, 1).Format(time.RFC3339)
+	out, err := exec.Command("git", "-C", cfg.gitDir, "log", cfg.branch, "--no-merges", "--numstat",
+		"--format="+gitLogFormat, "--since="+since, "--until="+until).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			err = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(ee.Stderr)))
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: git log failed in %s: %v\n", cfg.gitDir, err)
+		return nil, weeks
+	}
+	prs := parseGitLog(string(out))
+	fmt.Fprintf(os.Stderr, "Total commits read: %d\n", len(prs))
+	return prs, nil
+}
+
+// parseGitLog maps git log output in gitLogFormat onto the PR model:
+//   - committer date → mergedAt (when the commit landed on the branch)
+//   - author date → createdAt and the first commit's authoredDate
+//   - subject → title, full message → commit message (revert and Ona trailer detection)
+//   - author email → login: the login from a GitHub noreply address, else the local part
+//   - committer email → mergedBy login, except GitHub's own web-flow committer
+//   - logins ending in "[bot]" → Bot
+//
+// Commits have no review or ready-for-review data, so cycle times and review
+// turnaround stay unavailable.
+func parseGitLog(out string) []PR {
+	var prs []PR
+	for _, rec := range strings.Split(out, "\x1e") {
+		fields := strings.Split(rec, "\x1f")
+		if len(fields) != 7 {
+			continue
+		}
+		authored, err1 := time.Parse(time.RFC3339, fields[2])
+		committed, err2 := time.Parse(time.RFC3339, fields[3])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		message := strings.TrimSpace(fields[5])
+
+		var pr PR
+		pr.Title, _, _ = strings.Cut(message, "\n")
+		pr.Body = message
+		pr.CreatedAt = authored.UTC()
+		pr.MergedAt = committed.UTC()
+		pr.Author.Login = gitLogin(fields[1])
+		pr.Author.Typename = "User"
+		if strings.HasSuffix(pr.Author.Login, "[bot]") {
+			pr.Author.Typename = "Bot"
+		}
+		// Commits merged in GitHub's UI are committed by noreply@github.com
+		if committer := strings.ToLower(fields[4]); committer != "noreply@github.com" {
+			pr.MergedBy.Login = gitLogin(committer)
+			pr.MergedBy.Typename = "User"
+			if strings.HasSuffix(pr.MergedBy.Login, "[bot]") {
+				pr.MergedBy.Typename = "Bot"
+			}
+		}
+		var node commitNode
+		node.Commit.AuthoredDate = pr.CreatedAt
+		node.Commit.Message = message
+		pr.Commits.Nodes = append(pr.Commits.Nodes, node)
+		pr.Commits.TotalCount = 1
+
+		for _, line := range strings.Split(fields[6], "\n") {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			// Binary files show "-" for both counts
+			add, _ := strconv.Atoi(parts[0])
+			del, _ := strconv.Atoi(parts[1])
+			pr.Additions += add
+			pr.Deletions += del
+			pr.ChangedFiles++
+			pr.Files.Nodes = append(pr.Files.Nodes, struct {
+				Path string `json:"path"`
+			}{Path: parts[2]})
+		}
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+// gitLogin derives a login from a commit author email. GitHub noreply
+// addresses ("123+octocat@users.noreply.github.com") carry the real login,
+// which keeps --exclude and the default bot exclusions working.
+func gitLogin(email string) string {
+	local, domain, _ := strings.Cut(strings.ToLower(email), "@")
+	if domain == "users.noreply.github.com" {
+		if _, login, ok := strings.Cut(local, "+"); ok {
+			return login
+		}
+	}
+	return local
+}
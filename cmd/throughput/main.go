@@ -3,9 +3,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
-	"sort"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -20,50 +23,685 @@ type config struct {
 	output     string
 	excludeSet map[string]bool
 	token      string
+	provider   string // "github", "gerrit", or "git" (--local-git)
+	gerritURL  string
+	gitDir     string // --local-git clone
+	mirrorDir  string // --mirror clone
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "server" {
+		runAPIServer(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		runCacheCommand(os.Args[2:])
+		return
+	}
+
 	repoFlag := flag.String("repo", "", "owner/repo (default: detect from git remote)")
-	branch := flag.String("branch", "main", "target branch")
+	branch := flag.String("branch", "", "target branch (default: the repository's default branch)")
 	weeks := flag.Int("weeks", 12, "number of weeks to analyze")
 	output := flag.String("output", "", "output CSV file (default: stdout)")
+	columnsFlag := flag.String("columns", "", "comma-separated weekly CSV columns to write, in order (or @file with one per line); schema_version adds the schema version")
+	schemaVersionFlag := flag.Int("schema-version", schemaVersion, "write CSV and JSON outputs in this schema version, for consumers not yet migrated (see --schema)")
 	exclude := flag.String("exclude", "", "additional usernames to exclude (comma-separated)")
 	htmlOutput := flag.String("html", "", "output HTML file with interactive chart (optional)")
 	serve := flag.Bool("serve", false, "start a local server to view the HTML chart (implies --html)")
 	servePort := flag.Int("port", 8080, "port for the local server (used with --serve)")
+	maxClients := flag.Int("max-clients", 100, "with --serve, most browsers kept connected for live reload at once; more get a page without live reload (0 = no limit)")
 	minPRs := flag.Int("min-prs", 0, "exclude weeks with fewer than N merged PRs (e.g. holiday weeks)")
-	excludeBottomPct := flag.Int("exclude-bottom-contributor-pct", 0, "exclude bottom N% of contributors by total PR count (0-99)")
-	granularity := flag.String("granularity", "weekly", "aggregation granularity for stats and chart: weekly or monthly")
+	holidaysFlag := flag.String("holidays", "", "comma-separated country codes (US, GB, DE, FR, NL, CA) whose public holidays are marked on the chart")
+	holidayPolicy := flag.String("holiday-policy", "annotate", "what to do with --holidays weeks: annotate (mark on the chart) or exclude (also leave them out of the before/after comparison; weekly only)")
+	excludeBottomPct := flag.Int("exclude-bottom-contributor-pct", 0, "exclude bottom N% of contributors by --exclude-bottom-by (0-99)")
+	excludeBottomBy := flag.String("exclude-bottom-by", "prs", "activity measure for --exclude-bottom-contributor-pct and --sensitivity-bottom-pct: prs, commits, or active-weeks")
+	granularity := flag.String("granularity", "weekly", "aggregation granularity for stats and chart: weekly, monthly, or sprint (requires --sprint-project)")
+	sprintProject := flag.String("sprint-project", "", "GitHub Project (v2) whose iterations are the sprints, as owner/number or project URL: adds a sprint CSV column")
+	sprintField := flag.String("sprint-field", "", "name of the --sprint-project iteration field (default: the project's first)")
 	compareWindowPct := flag.Int("compare-window-pct", 5, "compare first/last N% of weeks (1-49, default 5)")
 	compareOnaThreshold := flag.Float64("compare-ona-threshold", 0, "compare weeks below vs above N% Ona usage (e.g. 70)")
+	fiscalYearStart := flag.String("fiscal-year-start", "", "first month of the fiscal year (1-12 or name, e.g. feb): adds fiscal columns and chart quarter labels")
+	compareFiscalQuartersFlag := flag.Bool("compare-fiscal-quarters", false, "compare the first and last complete fiscal quarters (requires --fiscal-year-start)")
+	compareWindowsFlag := flag.String("compare-windows", "", "compare two explicit date ranges, e.g. \"2024-01-01..2024-03-31,2025-01-01..2025-03-31\" (extends --weeks to cover them)")
+	targetsFlag := flag.String("targets", "", "comma-separated metric goals drawn on the chart and checked in a goals table, e.g. \"median_review_time_hours<24,prs_per_engineer>=3\"")
+	deltasFlag := flag.String("deltas", "", "comma-separated metrics to add week-over-week and 4-week delta CSV columns and hidden delta bars in the HTML for, e.g. \"prs_per_engineer,median_review_time_hours\"")
+	benchmark := flag.String("benchmark", "", "place metrics within an industry benchmark's bands in the HTML: dora-2023 (optional)")
+	statsWorkersFlag := flag.Int("stats-workers", 0, "goroutines for aggregating weeks, computing stats rows, and --sensitivity settings (0 = number of CPUs)")
+	sigLevel := flag.Float64("significance-level", 0.05, "p-value below which a before/after change is colored as an improvement or regression in the HTML (0 = color every change)")
 	topN := flag.Int("top-contributors", 0, "show top N contributors with before/after Ona PR rates in HTML (0 = disabled)")
+	contributorsSort := flag.String("contributors-sort", "total", "rank top contributors by: total (PR count), change (before/after % change), or ona (Ona PR share)")
+	attributionFlag := flag.String("attribution", "author", "credit PRs in the per-engineer metrics to their author, their merger (for teams with a release captain), or split between both")
+	contributorsMinPRs := flag.Int("contributors-min-prs", 0, "omit contributors with fewer PRs from the top contributors section and --store")
+	var anonymize anonymizeMode
+	flag.Var(&anonymize, "anonymize", "hide logins: all (the bare flag) uses pseudonyms (Engineer-01, ...) in CSV, HTML, logs, and --store; contributors only hashes the contributor, reviewer, and Ona heat lists")
+	anonymizeMap := flag.String("anonymize-map", "", "with --anonymize=all, read and extend this login,pseudonym CSV so pseudonyms stay stable across runs (keep it private)")
+	minGroupSize := flag.Int("min-group-size", 0, "suppress weekly cells and merge issue groups derived from fewer than N engineers (k-anonymity; 0 = off)")
+	cacheDir := flag.String("cache-dir", "", "cache fetched PRs per week in this directory and only fetch weeks not cached yet")
+	cacheRetention := flag.String("cache-retention", "90d", "with --cache-dir, delete cache entries fetched longer ago than this at startup (e.g. 90d, 36h; 0d = keep forever)")
+	unstableWeeks := flag.Int("unstable-weeks", 0, "treat the latest N weeks as unstable: always refetch them, bypassing --cache-dir, and draw them dashed in the chart since late merges may still change them")
+	cacheRedact := flag.Bool("cache-redact-authors", false, "with --cache-dir, hash author logins and strip co-author trailers before PRs are cached or used")
+	onaComparison := flag.Bool("ona-comparison", false, "compare per-PR outcomes (size, review time, reviews, reverts, CI failures) of Ona-involved vs other PRs in a table with significance tests")
+	onaMixedModel := flag.Bool("ona-mixed-model", false, "fit per-PR mixed-effects regressions (random intercept per contributor) of cycle time and size on Ona involvement, with coefficients and 95% CIs")
+	onaMatching := flag.Bool("ona-matching", false, "pair Ona-involved PRs with similar other PRs by propensity score (size, author, merge time, file area) and compare outcomes on the matched sample")
+	topReviewers := flag.Int("top-reviewers", 0, "with --enrich-reviews, show the N reviewers with the most review requests and their response times in HTML (0 = disabled)")
+	onaHeatList := flag.Int("ona-heat-list", 0, "list the N contributors with the most Ona-involved PRs and the N most active without any in HTML (0 = disabled)")
+	noContributors := flag.Bool("no-contributors", false, "omit per-contributor data from the HTML and --store (overrides --top-contributors)")
+	tokenSourceFlag := flag.String("token-source", "auto", "where to read the GitHub token: auto (env, credential helper, netrc, keychain in order), env, credential-helper, netrc, or keychain")
+	fetchMode := flag.String("fetch", "auto", "how merged PRs are listed: search (per-week search API queries), pulls (repository.pullRequests, no 1000-per-week ceiling or index lag), or auto (pulls only when a week has more than 1000 PRs)")
+	flag.BoolVar(&strict, "strict", false, "fail with exit code 5 instead of warning when data is incomplete: weeks that failed to fetch, weeks cut off by the search API, PRs without review history")
+	noPreflight := flag.Bool("no-preflight", false, "skip the check that --branch exists and has merged PRs in the window before fetching (the token's repository access is still checked)")
+	provider := flag.String("provider", "github", "code review provider: github or gerrit")
+	gerritURL := flag.String("gerrit-url", "", "Gerrit base URL, e.g. https://gerrit.example.com (used with --provider gerrit)")
+	mirror := flag.String("mirror", "", "read PR commits from this local clone (ideally made with git clone --mirror) instead of the API; PRs missing from it fall back to the API")
+	localGit := flag.String("local-git", "", "analyze non-merge commits on --branch in this local clone instead of PRs from an API (no token needed)")
+	jiraURL := flag.String("jira-url", "", "Jira base URL; joins PRs to issues and segments metrics by issue type (optional)")
+	jiraKeyPattern := flag.String("jira-key-regex", defaultJiraKeyPattern, "regex matching Jira issue keys in PR branch names and titles")
+	jiraInProgress := flag.String("jira-in-progress-status", "In Progress", "Jira status marking the start of lead time")
+	linear := flag.Bool("linear", false, "join PRs to Linear issues (needs LINEAR_API_KEY) and segment metrics by project")
+	incidentsCSV := flag.String("incidents-csv", "", "CSV of incidents (created_at, resolved_at columns) to correlate with throughput (optional)")
+	pagerDuty := flag.Bool("pagerduty", false, "fetch incidents from PagerDuty (needs PAGERDUTY_TOKEN)")
+	pagerDutyServices := flag.String("pagerduty-service-ids", "", "restrict PagerDuty incidents to these service IDs (comma-separated)")
+	offline := flag.Bool("offline", false, "forbid all network access except the provider's API (no CDN scripts, no Gitpod CLI); fails if a flag or the report needs anything else")
+	chartJSPath := flag.String("chart-js", "", "local copy of Chart.js (chart.umd.js) to inline into the HTML report instead of loading it from the CDN")
+	templatePath := flag.String("template", "", "custom HTML template file overriding the built-in report layout (optional)")
+	categoriesPath := flag.String("categories", "", "JSON file defining the HTML report's stat banners (name, accent, metrics), replacing Speed/Quality/Ona Uptake/Release")
+	revertLabelsFlag := flag.String("revert-labels", "revert,rollback", "PR labels that mark a revert, in addition to title, body, and commit-message detection (comma-separated)")
+	revertMinLines := flag.Int("revert-min-lines", 0, "leave PRs with fewer lines changed out of the pct_reverts denominator (reverts always count)")
+	revertExcludeTitles := flag.String("revert-exclude-titles", "", "regex; leave PRs whose title matches (e.g. chore PRs) out of the pct_reverts denominator (reverts always count)")
+	lifecycleFlag := flag.Bool("lifecycle", false, "with --enrich-reviews, model each PR's path through draft, ready, reviewed, and approved states and report time in each state and a Sankey diagram of the transitions")
+	cfd := flag.Bool("cfd", false, "add a cumulative flow diagram (open, in review, and merged PRs per period) to the HTML; searches all PRs opened in the window (github only)")
+	heatmap := flag.Bool("heatmap", false, "add weekday × hour heatmaps of merges and commits (with the after-hours share) to the HTML")
+	timezone := flag.String("timezone", "UTC", "IANA time zone for --heatmap hours and weekdays, e.g. Europe/Berlin")
+	histograms := flag.Bool("histograms", false, "add review time, coding time, and PR size histograms comparing the stat cards' first and last windows to the HTML")
+	sensitivity := flag.Bool("sensitivity", false, "recompute the headline changes across a sweep of --exclude-bottom-contributor-pct and --min-prs values and report how stable the conclusions are")
+	sensitivityBottomPct := flag.String("sensitivity-bottom-pct", "0,5,10,20", "comma-separated --exclude-bottom-contributor-pct values for --sensitivity")
+	sensitivityMinPRs := flag.String("sensitivity-min-prs", "0,3,5,10", "comma-separated --min-prs values for --sensitivity")
+	yoyFlag := flag.Bool("yoy", false, "also fetch the same weeks a year earlier and add a year-over-year overlay chart to the HTML (--weeks up to 52)")
+	cohortsFlag := flag.Bool("cohorts", false, "add a chart of each join-quarter cohort's mean PRs per week per contributor over the weeks since their first merged PR to the HTML")
+	scatter := flag.Bool("scatter", false, "add a per-PR scatter plot of merge date vs cycle time (dot size = lines changed, color = Ona involvement) to the HTML")
+	prDrilldown := flag.Bool("pr-drilldown", false, "embed each period's PR list in the HTML; clicking a chart point shows the PRs behind it")
+	audienceFlag := flag.String("audience", "manager", "HTML report variant: manager (everything), ic (per-PR drill-down, no individual rankings), or exec (key findings and stat banners only)")
+	notebookBundleDir := flag.String("notebook-bundle", "", "write the weekly CSV, the per-PR detail CSV, and a starter Jupyter notebook that loads them into this directory (created if needed)")
+	prOutput := flag.String("pr-output", "", "write a per-PR detail CSV (cycle times, first-commit method, flags) to this file (optional)")
+	enrichReviewsFlag := flag.Bool("enrich-reviews", false, "page every review, review thread, and review-request event per PR in a second pass (one or more extra queries per PR; adds review counts to --pr-output)")
+	maxCommits := flag.Int("max-commits", 50, "fetch up to N commits per PR for PRs with more than 50 (default 50 = first page plus the first commit)")
+	retention := flag.Bool("retention", false, "add rolling 4-week active engineer count and churn to CSV, stats, and chart")
+	automation := flag.Bool("automation", false, "report bot-authored PRs (Dependabot, Renovate, ...) as a separate automation series (weekly count and median merge time) in CSV and chart")
+	security := flag.Bool("security", false, "report security fixes (PRs with a --security-labels label, and Dependabot security updates) as a separate series (weekly count and median lead time) in CSV, stats, and chart")
+	securityLabelsFlag := flag.String("security-labels", "security", "with --security, PR labels that mark a security fix (comma-separated)")
+	releasesFlag := flag.String("releases", "", "add release cadence (releases per week, median days between releases, PRs per release) to CSV, stats, and chart, read from: releases (GitHub releases) or tags (optional)")
+	releasePattern := flag.String("release-pattern", "", "with --releases, only count releases or tags whose name matches this regex, e.g. \"^v[0-9]+\\.[0-9]+\\.[0-9]+$\"")
+	releaseChangelog := flag.Bool("release-changelog", false, "with --releases, compare the commits between consecutive releases and add the median PRs included per release (batch size) to CSV, stats, and chart")
+	hygiene := flag.Bool("hygiene", false, "add PR hygiene shares (described, linked to an issue, changing tests) to CSV, stats, and chart")
+	reviewCoverage := flag.Bool("review-coverage", false, "add the shares of merged PRs approved by a non-author and merged without review to CSV, stats, and chart")
+	ciQueue := flag.Bool("ci-queue", false, "add the weekly median GitHub Actions queue time (run created to started) to CSV, stats, and chart (github only)")
+	ciCost := flag.Bool("ci-cost", false, "add the weekly billable GitHub Actions minutes (job time × runner OS multiplier) to CSV, stats, and chart (github only)")
+	ciMinutePrice := flag.Float64("ci-minute-price", 0, "with --ci-cost, price per Linux Actions minute (e.g. 0.008) to add a CI cost series")
+	runnersFlag := flag.Bool("runners", false, "report self-hosted runner utilization and job queue time per runner label and week, from the sampled workflow runs' jobs (github only)")
+	runnersOutput := flag.String("runners-output", "", "with --runners, write the per-label weekly utilization to this CSV file")
+	branchProtection := flag.Bool("branch-protection", false, "read the branch's protection (required reviews and checks), record changes in --store, and mark them on the chart (github only)")
+	templateCompliance := flag.Bool("template-compliance", false, "add the share of merged PRs whose description fills every section of the repository's PR template to CSV, stats, and chart")
+	templateSections := flag.String("template-sections", "", "with --template-compliance, comma-separated headings PR descriptions must fill, instead of the PR template's headings")
+	hygieneMinDescription := flag.Int("hygiene-min-description", 50, "with --hygiene, minimum description length in characters for a PR to count as described")
+	sizeWeighted := flag.Bool("size-weighted", false, "add size-weighted throughput (log2 lines-changed points per engineer) to CSV, stats, and chart")
+	onaWeighted := flag.Bool("ona-weighted", false, "add Ona uptake weighted by lines changed (share of merged lines in Ona-involved PRs) to CSV, stats, chart, and correlations")
+	corrMatrixFlag := flag.Bool("correlation-matrix", false, "correlate every pair of weekly metrics (Pearson r with p-values): strongest pairs to stderr, a heatmap in HTML")
+	corrMatrixOutput := flag.String("correlation-matrix-output", "", "with --correlation-matrix, write every correlated pair to this CSV file")
+	sizeBucketsFlag := flag.Bool("size-buckets", false, "add merged PRs and median review time per PR size bucket (XS-XL by lines changed) to CSV and chart")
+	outlierPolicyFlag := flag.String("outlier-policy", "none", "cycle-time outlier handling before aggregation: none, winsorize (clamp to bounds), or drop")
+	outlierBounds := flag.String("outlier-bounds", "0,99", "lower,upper percentile bounds for --outlier-policy, computed across all PRs in the window")
+	locale := flag.String("locale", "en", "report locale for dates, numbers, and labels in HTML output: en or de (CSV is never localized)")
+	printTemplate := flag.Bool("print-template", false, "print the built-in HTML template to stdout and exit (starting point for --template)")
+	printSchema := flag.Bool("schema", false, "print the CSV columns and JSON fields of every output with migration notes, and exit")
+	var seriesSpecs seriesFlag
+	flag.Var(&seriesSpecs, "series", "user-defined weekly metric as name[:sum|mean]=source, where source is a date,value CSV or a JSON URL (repeatable)")
+	var derivedSpecs seriesFlag
+	flag.Var(&derivedSpecs, "derived", "derived metric as 'name = expression' over other metrics with + - * / and parentheses, or @file with one per line (repeatable)")
+	linearKeyPattern := flag.String("linear-key-regex", defaultLinearKeyPattern, "regex matching Linear issue identifiers in PR branch names and titles")
+	postIssue := flag.String("post-issue", "", "post (or update) a Markdown summary comment on a tracking issue, e.g. owner/repo#123 (optional)")
+	confluenceURL := flag.String("confluence-url", "", "Confluence base URL, e.g. https://acme.atlassian.net/wiki; updates --confluence-page with the run summary each run (optional)")
+	confluenceSpace := flag.String("confluence-space", "", "space key of the --confluence-page")
+	confluencePage := flag.String("confluence-page", "", "ID of the existing Confluence page to update")
+	notionPage := flag.String("notion-page", "", "ID of a Notion page whose content is replaced with the run summary and weekly table each run (optional)")
+	notionDatabase := flag.String("notion-database", "", "ID of a Notion database to upsert one row per week into (optional)")
+	storeDir := flag.String("store", "", "directory to save run results as JSON for the server subcommand (optional)")
+	storageSpec := flag.String("storage", "", "accumulate weekly metrics and per-PR rows across runs in: fs:DIR (JSON Lines), sqlite:FILE, or postgres ($THROUGHPUT_DATABASE_URL or $DATABASE_URL) (optional)")
+	batchPath := flag.String("batch", "", "JSON config listing repos/orgs to run in one go; writes per-repo reports and an index.html (see README)")
+	batchOut := flag.String("batch-out", "reports", "output directory for --batch reports")
+	batchParallel := flag.Int("batch-parallel", 1, "number of --batch repositories to run concurrently")
+
+	// Completion scripts are generated from the flags above
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		runCompletion(os.Args[2:])
+		return
+	}
+
 	flag.Parse()
+	parseRepoArg()
+	checkFlagDependencies()
+
+	if !slices.Contains(tokenSourceNames(), *tokenSourceFlag) {
+		fatal("--token-source must be one of: %s", strings.Join(tokenSourceNames(), ", "))
+	}
+	tokenSource = *tokenSourceFlag
+
+	if *printTemplate {
+		fmt.Print(htmlTemplate)
+		return
+	}
+	if *printSchema {
+		printSchemas(os.Stdout)
+		return
+	}
+
+	if *batchPath != "" {
+		if *batchParallel < 1 {
+			fatal("--batch-parallel must be at least 1")
+		}
+		if *offline {
+			// Each repository's run checks its own report and --series
+			enableOffline(*provider, *gerritURL, nil, false)
+		}
+		runBatch(*batchPath, *batchOut, *batchParallel, forwardedFlags(seriesSpecs, derivedSpecs))
+		return
+	}
+
+	if *provider != "github" && *provider != "gerrit" {
+		fatal("--provider must be 'github' or 'gerrit'")
+	}
+	if *provider == "gerrit" && *gerritURL == "" {
+		fatal("--provider gerrit requires --gerrit-url")
+	}
+	if *provider != "gerrit" && *gerritURL != "" {
+		fatal("--gerrit-url has no effect without --provider gerrit")
+	}
+	if *localGit != "" {
+		if *provider != "github" {
+			fatal("--local-git cannot be combined with --provider %s", *provider)
+		}
+		*provider = "git"
+	}
+	if *mirror != "" {
+		if *provider != "github" {
+			fatal("--mirror is only supported with --provider github (use --local-git for commits alone)")
+		}
+		if err := checkMirror(*mirror); err != nil {
+			fatal("Invalid --mirror: %v", err)
+		}
+	}
+	if *provider != "github" && *enrichReviewsFlag {
+		fatal("--enrich-reviews is only supported with --provider github")
+	}
+	if !slices.Contains(fetchModes, *fetchMode) {
+		fatal("--fetch must be one of: %s", strings.Join(fetchModes, ", "))
+	}
+	if *provider != "github" && *fetchMode != "auto" {
+		fatal("--fetch is only supported with --provider github")
+	}
+	if *provider != "github" && *ciQueue {
+		fatal("--ci-queue is only supported with --provider github")
+	}
+	if *provider != "github" && *ciCost {
+		fatal("--ci-cost is only supported with --provider github")
+	}
+	if *ciMinutePrice < 0 {
+		fatal("--ci-minute-price must not be negative")
+	}
+	if *provider != "github" && *runnersFlag {
+		fatal("--runners is only supported with --provider github")
+	}
+	if *releasesFlag != "" && *releasesFlag != "releases" && *releasesFlag != "tags" {
+		fatal("--releases must be 'releases' or 'tags'")
+	}
+	if *provider == "gerrit" && *releasesFlag != "" {
+		fatal("--releases is not supported with --provider gerrit")
+	}
+	if *provider != "github" && *branchProtection {
+		fatal("--branch-protection is only supported with --provider github")
+	}
 
-	if *granularity != "weekly" && *granularity != "monthly" {
-		fatal("--granularity must be 'weekly' or 'monthly'")
+	for _, spec := range seriesSpecs {
+		def, err := parseSeriesSpec(spec)
+		if err != nil {
+			fatal("Invalid --series: %v", err)
+		}
+		registerExternalSeries(def)
+	}
+	derivedLines, err := derivedSpecLines(derivedSpecs)
+	if err != nil {
+		fatal("Invalid --derived: %v", err)
+	}
+	for _, line := range derivedLines {
+		def, err := parseDerivedSpec(line)
+		if err != nil {
+			fatal("Invalid --derived: %v", err)
+		}
+		registerDerivedMetric(def)
+	}
+
+	benchSet, ok := benchmarkSets[*benchmark]
+	if *benchmark != "" && !ok {
+		fatal("Unknown --benchmark %q (available: %s)", *benchmark, strings.Join(benchmarkNames(), ", "))
+	}
+
+	targets, err := parseTargets(*targetsFlag)
+	if err != nil {
+		fatal("Invalid --targets: %v", err)
+	}
+	deltaMetrics, err := parseDeltaMetrics(*deltasFlag)
+	if err != nil {
+		fatal("Invalid --deltas: %v", err)
+	}
+
+	var jiraCfg *jiraConfig
+	if *jiraURL != "" {
+		re, err := regexp.Compile(*jiraKeyPattern)
+		if err != nil {
+			fatal("Invalid --jira-key-regex: %v", err)
+		}
+		jiraCfg = &jiraConfig{baseURL: *jiraURL, keyRe: re, inProgressStatus: *jiraInProgress}
+	}
+	var releaseRe *regexp.Regexp
+	if *releasePattern != "" {
+		re, err := regexp.Compile(*releasePattern)
+		if err != nil {
+			fatal("Invalid --release-pattern: %v", err)
+		}
+		releaseRe = re
+	}
+	var linearKeyRe *regexp.Regexp
+	if *linear {
+		if jiraCfg != nil {
+			fatal("--jira-url and --linear are mutually exclusive")
+		}
+		re, err := regexp.Compile(*linearKeyPattern)
+		if err != nil {
+			fatal("Invalid --linear-key-regex: %v", err)
+		}
+		linearKeyRe = re
+	}
+
+	if *granularity != "weekly" && *granularity != "monthly" && *granularity != "sprint" {
+		fatal("--granularity must be 'weekly', 'monthly', or 'sprint'")
+	}
+	reportAudience, err := parseAudience(*audienceFlag)
+	if err != nil {
+		fatal("%v", err)
+	}
+	var sprintOwner string
+	var sprintNumber int
+	if *sprintProject != "" {
+		var err error
+		if sprintOwner, sprintNumber, err = parseSprintProject(*sprintProject); err != nil {
+			fatal("Invalid --sprint-project: %v", err)
+		}
+	} else if *granularity == "sprint" {
+		fatal("--granularity sprint requires --sprint-project")
+	}
+
+	var holidayCountries []string
+	if *holidaysFlag != "" {
+		codes, err := parseHolidayCountries(*holidaysFlag)
+		if err != nil {
+			fatal("Invalid --holidays: %v", err)
+		}
+		holidayCountries = codes
+	}
+	if *holidayPolicy != "annotate" && *holidayPolicy != "exclude" {
+		fatal("--holiday-policy must be 'annotate' or 'exclude'")
+	}
+	if *holidayPolicy == "exclude" && *granularity != "weekly" {
+		fatal("--holiday-policy exclude requires --granularity weekly (nearly every month has a holiday)")
 	}
 
 	if *compareWindowPct != 5 && *compareOnaThreshold > 0 {
 		fatal("--compare-window-pct and --compare-ona-threshold are mutually exclusive")
 	}
 
+	var fiscal fiscalCalendar
+	if *fiscalYearStart != "" {
+		m, err := parseFiscalStart(*fiscalYearStart)
+		if err != nil {
+			fatal("Invalid --fiscal-year-start: %v", err)
+		}
+		fiscal.startMonth = m
+	}
+	if *compareFiscalQuartersFlag {
+		if *compareOnaThreshold > 0 || *compareWindowPct != 5 {
+			fatal("--compare-fiscal-quarters can't be combined with --compare-window-pct or --compare-ona-threshold")
+		}
+		compareFiscalQuarters = true
+	}
+	if *compareWindowsFlag != "" {
+		if *compareOnaThreshold > 0 || *compareWindowPct != 5 || compareFiscalQuarters {
+			fatal("--compare-windows can't be combined with --compare-window-pct, --compare-ona-threshold, or --compare-fiscal-quarters")
+		}
+		windows, err := parseCompareWindows(*compareWindowsFlag)
+		if err != nil {
+			fatal("Invalid --compare-windows: %v", err)
+		}
+		compareWindows = windows
+	}
+
+	if *sigLevel < 0 || *sigLevel >= 1 {
+		fatal("--significance-level must be between 0 and 1")
+	}
+	significanceLevel = *sigLevel
+
+	if *statsWorkersFlag < 0 {
+		fatal("--stats-workers must not be negative")
+	}
+	if *statsWorkersFlag > 0 {
+		statsWorkers = *statsWorkersFlag
+	}
+
+	if *hygieneMinDescription < 1 {
+		fatal("--hygiene-min-description must be at least 1")
+	}
+
+	if !slices.Contains(contributorSortKeys, *contributorsSort) {
+		fatal("--contributors-sort must be one of: %s", strings.Join(contributorSortKeys, ", "))
+	}
+	if !slices.Contains(attributionModes, *attributionFlag) {
+		fatal("--attribution must be one of: %s", strings.Join(attributionModes, ", "))
+	}
+	attribution = *attributionFlag
+	bottomBy, ok := contributorMeasureByName(*excludeBottomBy)
+	if !ok {
+		var names []string
+		for _, m := range contributorMeasures {
+			names = append(names, m.name)
+		}
+		fatal("--exclude-bottom-by must be one of: %s", strings.Join(names, ", "))
+	}
+	cut := bottomCut{pct: *excludeBottomPct, measure: bottomBy}
+	if *anonymizeMap != "" && !anonymize.pseudonyms() {
+		fatal("--anonymize-map has no effect without --anonymize=all")
+	}
+	var pseudonyms *pseudonymizer
+	if anonymize.pseudonyms() {
+		var err error
+		if pseudonyms, err = loadPseudonyms(*anonymizeMap); err != nil {
+			fatal("Failed to read --anonymize-map: %v", err)
+		}
+	}
+
+	if *unstableWeeks < 0 || *unstableWeeks >= *weeks {
+		fatal("--unstable-weeks must be between 0 and --weeks - 1")
+	}
+
+	var cache *prCache
+	var cacheMaxAge time.Duration
+	if *cacheDir != "" {
+		cache = &prCache{dir: *cacheDir, redact: *cacheRedact, reviews: *enrichReviewsFlag}
+		restETags.dir = *cacheDir
+		var err error
+		if cacheMaxAge, err = parseAge(*cacheRetention); err != nil {
+			fatal("Invalid --cache-retention: %v", err)
+		}
+	}
+
+	if *minGroupSize > 1 {
+		if *topN > 0 {
+			fatal("--top-contributors shows individual engineers and cannot be combined with --min-group-size")
+		}
+		if *onaHeatList > 0 {
+			fatal("--ona-heat-list shows individual engineers and cannot be combined with --min-group-size")
+		}
+		if *topReviewers > 0 {
+			fatal("--top-reviewers shows individual engineers and cannot be combined with --min-group-size")
+		}
+		if *prOutput != "" {
+			fatal("--pr-output writes per-PR rows and cannot be combined with --min-group-size")
+		}
+		if *notebookBundleDir != "" {
+			fatal("--notebook-bundle writes per-PR rows and cannot be combined with --min-group-size")
+		}
+		if *storageSpec != "" {
+			fatal("--storage writes per-PR rows and cannot be combined with --min-group-size")
+		}
+		if *prDrilldown {
+			fatal("--pr-drilldown lists per-PR authors and cannot be combined with --min-group-size")
+		}
+		if reportAudience.wantsDrilldown() {
+			fatal("--audience %s lists per-PR authors and cannot be combined with --min-group-size", reportAudience)
+		}
+		if *scatter {
+			fatal("--scatter plots individual PRs and cannot be combined with --min-group-size")
+		}
+	}
+
+	contribOpts := contributorOptions{
+		n:         *topN,
+		sortBy:    *contributorsSort,
+		minPRs:    *contributorsMinPRs,
+		anonymize: anonymize.hashesContributors(),
+	}
+
 	// --serve implies --html with a default filename
+	htmlFlagName := "html"
 	if *serve && *htmlOutput == "" {
 		defaultHTML := "chart.html"
 		htmlOutput = &defaultHTML
+		htmlFlagName = "serve"
+	}
+
+	// Fail on unwritable output paths before spending minutes fetching
+	if *htmlOutput != "" {
+		checkWritable(htmlFlagName, *htmlOutput)
+	}
+	if *output != "" {
+		checkWritable("output", *output)
+	}
+	if *schemaVersionFlag < 1 || *schemaVersionFlag > schemaVersion {
+		fatal("--schema-version must be between 1 and %d", schemaVersion)
+	}
+	var csvColumns []string
+	if *columnsFlag != "" {
+		var err error
+		if csvColumns, err = parseColumns(*columnsFlag); err != nil {
+			fatal("Invalid --columns: %v", err)
+		}
+	}
+	if *prOutput != "" {
+		checkWritable("pr-output", *prOutput)
+	}
+	var storage Storage
+	if *storageSpec != "" {
+		var err error
+		if storage, err = openStorage(*storageSpec); err != nil {
+			fatal("Invalid --storage: %v", err)
+		}
+	}
+	if *runnersOutput != "" {
+		checkWritable("runners-output", *runnersOutput)
+	}
+	if *corrMatrixOutput != "" {
+		checkWritable("correlation-matrix-output", *corrMatrixOutput)
+	}
+	if *notebookBundleDir != "" {
+		checkWritable("notebook-bundle", filepath.Clean(*notebookBundleDir))
+	}
+
+	outliers := outlierPolicy{mode: *outlierPolicyFlag}
+	if outliers.mode != "none" && outliers.mode != "winsorize" && outliers.mode != "drop" {
+		fatal("--outlier-policy must be 'none', 'winsorize', or 'drop'")
+	}
+	if lo, hi, err := parseOutlierBounds(*outlierBounds); err != nil {
+		fatal("Invalid --outlier-bounds: %v", err)
+	} else {
+		outliers.lowerPct, outliers.upperPct = lo, hi
+	}
+
+	revertLabels = make(map[string]bool)
+	for _, l := range strings.Split(*revertLabelsFlag, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			revertLabels[strings.ToLower(l)] = true
+		}
+	}
+	if *revertMinLines < 0 {
+		fatal("--revert-min-lines must not be negative")
+	}
+	revertBaseMinLines = *revertMinLines
+	if *revertExcludeTitles != "" {
+		re, err := regexp.Compile(*revertExcludeTitles)
+		if err != nil {
+			fatal("Invalid --revert-exclude-titles: %v", err)
+		}
+		revertBaseExclude = re
+	}
+
+	securityLabels := make(map[string]bool)
+	for _, l := range strings.Split(*securityLabelsFlag, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			securityLabels[strings.ToLower(l)] = true
+		}
+	}
+
+	var postIssueRef *issueRef
+	if *postIssue != "" {
+		ref, err := parseIssueRef(*postIssue)
+		if err != nil {
+			fatal("Invalid --post-issue: %v", err)
+		}
+		postIssueRef = &ref
+	}
+
+	var confluence *confluenceTarget
+	if *confluenceURL != "" {
+		if *confluenceSpace == "" || *confluencePage == "" {
+			fatal("--confluence-url requires --confluence-space and --confluence-page")
+		}
+		if os.Getenv("CONFLUENCE_API_TOKEN") == "" {
+			fatal("--confluence-url requires CONFLUENCE_API_TOKEN (and CONFLUENCE_EMAIL for Atlassian Cloud)")
+		}
+		confluence = &confluenceTarget{baseURL: *confluenceURL, space: *confluenceSpace, pageID: *confluencePage}
+	}
+	if (*notionPage != "" || *notionDatabase != "") && os.Getenv("NOTION_TOKEN") == "" {
+		fatal("--notion-page and --notion-database require NOTION_TOKEN")
+	}
+
+	if err := setLocale(*locale); err != nil {
+		fatal("Invalid --locale: %v", err)
+	}
+
+	if *scatter && *htmlOutput == "" {
+		fatal("--scatter requires --html or --serve")
+	}
+	if *cohortsFlag && *htmlOutput == "" {
+		fatal("--cohorts requires --html or --serve")
+	}
+	if *histograms && *htmlOutput == "" {
+		fatal("--histograms requires --html or --serve")
+	}
+	if *heatmap && *htmlOutput == "" {
+		fatal("--heatmap requires --html or --serve")
+	}
+	var sensitivityOpts sensitivityOptions
+	if *sensitivity {
+		bottomPcts, err := parseIntList(*sensitivityBottomPct)
+		if err != nil || slices.ContainsFunc(bottomPcts, func(n int) bool { return n > 99 }) {
+			fatal("--sensitivity-bottom-pct must be comma-separated percentages from 0 to 99")
+		}
+		minPRValues, err := parseIntList(*sensitivityMinPRs)
+		if err != nil {
+			fatal("Invalid --sensitivity-min-prs: %v", err)
+		}
+		sensitivityOpts = sensitivityOptions{
+			bottomPcts:   bottomPcts,
+			minPRs:       minPRValues,
+			baseline:     sensitivitySetting{bottomPct: *excludeBottomPct, minPRs: *minPRs},
+			windowPct:    *compareWindowPct,
+			onaThreshold: *compareOnaThreshold,
+			minGroupSize: *minGroupSize,
+			bottomBy:     bottomBy,
+			fiscal:       fiscal,
+		}
+	}
+	heatmapZone, err := time.LoadLocation(*timezone)
+	if err != nil {
+		fatal("Invalid --timezone: %v", err)
+	}
+	if *cfd {
+		if *htmlOutput == "" {
+			fatal("--cfd requires --html or --serve")
+		}
+		if *provider != "github" {
+			fatal("--cfd is only supported with --provider github")
+		}
+	}
+
+	if *chartJSPath != "" {
+		if *htmlOutput == "" {
+			fatal("--chart-js requires --html or --serve")
+		}
+		if err := loadChartJS(*chartJSPath); err != nil {
+			fatal("Invalid --chart-js: %v", err)
+		}
+	}
+	var categories []bannerCategory
+	if *categoriesPath != "" {
+		if *htmlOutput == "" {
+			fatal("--categories requires --html or --serve")
+		}
+		if categories, err = loadCategories(*categoriesPath); err != nil {
+			fatal("Invalid --categories: %v", err)
+		}
+	}
+	if *offline {
+		enableOffline(*provider, *gerritURL, externalSeriesDefs, *htmlOutput != "")
+	}
+	if *templatePath != "" {
+		if *htmlOutput == "" {
+			fatal("--template requires --html or --serve")
+		}
+		if err := loadReportTemplate(*templatePath); err != nil {
+			fatal("Invalid --template: %v", err)
+		}
 	}
 
 	cfg := config{
-		branch: *branch,
-		weeks:  *weeks,
-		output: *output,
+		branch:    *branch,
+		weeks:     *weeks,
+		output:    *output,
+		provider:  *provider,
+		gerritURL: *gerritURL,
+		gitDir:    *localGit,
+		mirrorDir: *mirror,
+	}
+	if len(compareWindows) > 0 {
+		ranges := computeWeekRanges(time.Now(), cfg.weeks)
+		for _, w := range compareWindows {
+			if w.start.After(ranges[len(ranges)-1].end) {
+				fatal("--compare-windows range %s starts after the last complete week (%s)", w, ranges[len(ranges)-1].end.Format("2006-01-02"))
+			}
+		}
+		if n := compareWindowsWeeks(time.Now(), cfg.weeks); n > cfg.weeks {
+			fmt.Fprintf(os.Stderr, "Extending --weeks from %d to %d to cover --compare-windows\n", cfg.weeks, n)
+			cfg.weeks = n
+		}
 	}
 
-	// Resolve owner/repo
-	if *repoFlag != "" {
+	// Resolve owner/repo. Gerrit projects may contain slashes, so the whole
+	// --repo value is the project and the Gerrit host stands in for the owner.
+	if cfg.provider == "gerrit" {
+		if *repoFlag == "" {
+			fatal("--provider gerrit requires --repo <project>")
+		}
+		cfg.owner = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(cfg.gerritURL, "/"), "https://"), "http://")
+		cfg.repo = *repoFlag
+	} else if *repoFlag != "" {
 		cfg.owner, cfg.repo = parseRepo(*repoFlag)
 	} else {
-		cfg.owner, cfg.repo = detectRepo()
+		cfg.owner, cfg.repo = detectRepo(cfg.gitDir)
+	}
+	// A local clone without a GitHub remote is named after its directory
+	if cfg.provider == "git" && (cfg.owner == "" || cfg.repo == "") {
+		abs, err := filepath.Abs(cfg.gitDir)
+		if err != nil {
+			fatal("Invalid --local-git: %v", err)
+		}
+		cfg.owner, cfg.repo = "local", filepath.Base(abs)
 	}
 	if cfg.owner == "" || cfg.repo == "" {
 		fatal("Could not determine owner/repo. Use --repo owner/repo.")
@@ -82,10 +720,70 @@ func main() {
 		}
 	}
 
-	// Resolve token
-	cfg.token = resolveToken()
-	if cfg.token == "" {
-		fatal("No GitHub token found. Tried: GH_TOKEN, GITHUB_TOKEN, git credential helper.")
+	// Resolve token (Gerrit uses GERRIT_USERNAME/GERRIT_HTTP_PASSWORD instead)
+	if cfg.provider == "github" {
+		cfg.token = resolveToken()
+		if cfg.token == "" {
+			fatalCode(exitAuth, "No GitHub token found. Tried: %s.", triedTokenSources())
+		}
+	}
+
+	// Default branch when --branch isn't given
+	if cfg.branch == "" {
+		detected, err := detectDefaultBranch(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not detect the default branch (%v), using %s\n", err, fallbackBranch)
+			detected = fallbackBranch
+		} else {
+			fmt.Fprintf(os.Stderr, "Detected default branch: %s\n", detected)
+		}
+		cfg.branch = detected
+	}
+
+	// Sprint boundaries from a GitHub Project's iteration field (optional)
+	var sprints []sprint
+	if *sprintProject != "" {
+		token := cfg.token
+		if token == "" {
+			token = resolveToken()
+		}
+		var err error
+		sprints, err = fetchSprints(token, sprintOwner, sprintNumber, *sprintField)
+		if err != nil {
+			fatal("Failed to read --sprint-project: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Sprints: %d iteration(s) in project %s/%d\n", len(sprints), sprintOwner, sprintNumber)
+	}
+
+	// Required PR description sections, read before anything is fetched so a
+	// missing template fails fast
+	var prTmpl *prTemplate
+	if *templateCompliance {
+		var headings []string
+		for _, h := range strings.Split(*templateSections, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				headings = append(headings, h)
+			}
+		}
+		var err error
+		if len(headings) > 0 {
+			prTmpl, err = newPRTemplate("--template-sections", "", headings)
+		} else {
+			prTmpl, err = loadPRTemplate(cfg)
+		}
+		if err != nil {
+			fatal("--template-compliance: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "PR template sections (%s): %s\n", prTmpl.source, strings.Join(prTmpl.sections, ", "))
+	}
+
+	if *yoyFlag {
+		if *granularity == "sprint" {
+			fatal("--yoy requires --granularity weekly or monthly")
+		}
+		if cfg.weeks > yoyWeeks {
+			fatal("--yoy supports --weeks up to %d (got %d), so the two years don't overlap", yoyWeeks, cfg.weeks)
+		}
 	}
 
 	fmt.Fprintf(os.Stderr, "Repository: %s/%s (branch: %s)\n", cfg.owner, cfg.repo, cfg.branch)
@@ -93,88 +791,242 @@ func main() {
 	// Compute week ranges
 	now := time.Now()
 	weekRanges := computeWeekRanges(now, cfg.weeks)
+	var unstableSince time.Time
+	if *unstableWeeks > 0 {
+		unstableSince = weekRanges[len(weekRanges)-*unstableWeeks].start
+	}
+
+	// Token access, branch, and merged PRs in the window, before fetching
+	if cfg.provider == "github" {
+		if err := checkRepoAccess(cfg, weekRanges[0].start, weekRanges[len(weekRanges)-1].end, *noPreflight); err != nil {
+			fatalCode(exitCodeFor(err, exitError), "Preflight check of %s/%s failed: %v", cfg.owner, cfg.repo, err)
+		}
+	}
 
 	startDate := weekRanges[0].start.Format("2006-01-02")
 	today := now.Format("2006-01-02")
 	fmt.Fprintf(os.Stderr, "Analyzing PRs merged from %s to %s (%d weeks)\n", startDate, today, cfg.weeks)
-	fmt.Fprintf(os.Stderr, "Exclude list: %s\n", excludeList)
+	if anonymize.pseudonyms() {
+		fmt.Fprintf(os.Stderr, "Exclude list: %d user(s)\n", len(cfg.excludeSet))
+	} else {
+		fmt.Fprintf(os.Stderr, "Exclude list: %s\n", excludeList)
+	}
+
+	// --yoy fetches the same weeks a year earlier too; their PRs are split
+	// off after fetching
+	var priorRanges []weekRange
+	allRanges := weekRanges
+	if *yoyFlag {
+		priorRanges = yoyRanges(weekRanges)
+		allRanges = append(slices.Clone(priorRanges), weekRanges...)
+		fmt.Fprintf(os.Stderr, "Year over year: also fetching %s to %s\n",
+			priorRanges[0].start.Format("2006-01-02"), priorRanges[len(priorRanges)-1].end.Format("2006-01-02"))
+	}
+
+	// Raw PR cache (optional): expire old entries, then only fetch the weeks
+	// that aren't cached
+	fetchRanges := allRanges
+	var cachedPRs []PR
+	if cache != nil {
+		if cacheMaxAge > 0 {
+			removed, err := purgeCache(cache.dir, now.Add(-cacheMaxAge))
+			if err != nil {
+				fatal("Failed to expire cache entries: %v", err)
+			}
+			if removed > 0 {
+				fmt.Fprintf(os.Stderr, "Cache: expired %d entr(ies) older than %s\n", removed, *cacheRetention)
+			}
+		}
+		// --unstable-weeks are the newest, at the end of allRanges
+		stable := len(allRanges) - *unstableWeeks
+		cachedPRs, fetchRanges = cache.load(cfg, allRanges[:stable])
+		fetchRanges = append(fetchRanges, allRanges[stable:]...)
+		fmt.Fprintf(os.Stderr, "Cache: %d of %d week(s) cached (%d PRs), fetching %d\n",
+			len(allRanges)-len(fetchRanges), len(allRanges), len(cachedPRs), len(fetchRanges))
+		if *unstableWeeks > 0 {
+			fmt.Fprintf(os.Stderr, "Cache: always refetching the latest %d unstable week(s)\n", *unstableWeeks)
+		}
+		if cache.redact {
+			for _, u := range strings.Split(excludeList, ",") {
+				if u = strings.TrimSpace(u); u != "" {
+					cfg.excludeSet[hashLogin(strings.ToLower(u))] = true
+				}
+			}
+		}
+	}
 
 	// Fetch PRs concurrently
-	fmt.Fprintf(os.Stderr, "Fetching merged PRs via GraphQL...\n")
-	allPRs := fetchAllPRs(cfg, weekRanges)
+	var allPRs []PR
+	var failedWeeks []weekRange
+	var commitNote, truncationNoteText string
+	var truncated []truncatedWeek
+	if cfg.provider == "gerrit" {
+		fmt.Fprintf(os.Stderr, "Fetching merged changes via Gerrit REST API...\n")
+		allPRs, failedWeeks = fetchAllGerritChanges(cfg, fetchRanges)
+	} else if cfg.provider == "git" {
+		fmt.Fprintf(os.Stderr, "Reading commits from local clone %s...\n", cfg.gitDir)
+		allPRs, failedWeeks = fetchLocalGitCommits(cfg, fetchRanges)
+		if len(failedWeeks) > 0 {
+			fatal("Could not read commits from --local-git %s", cfg.gitDir)
+		}
+	} else {
+		if chooseFetchMode(cfg, *fetchMode, fetchRanges) == "pulls" {
+			fmt.Fprintf(os.Stderr, "Fetching merged PRs via repository.pullRequests...\n")
+			allPRs, failedWeeks = fetchPullsByRepository(cfg, fetchRanges)
+		} else {
+			fmt.Fprintf(os.Stderr, "Fetching merged PRs via GraphQL search...\n")
+			allPRs, failedWeeks, truncated = fetchAllPRs(cfg, fetchRanges)
+			if len(truncated) > 0 {
+				truncationNoteText = truncationNote(truncated)
+				warnPartial("%s", truncationNoteText)
+			}
+		}
+
+		if cfg.mirrorDir != "" {
+			logMirror(mirrorCommits(cfg, allPRs))
+		}
 
-	// Backfill first commit for large PRs (needed for cycle time metrics)
-	backfillFirstCommits(cfg, allPRs)
+		// Large PRs (and, with --mirror, PRs missing from the clone): fetch
+		// the full commit list up to --max-commits, or just backfill the
+		// first commit (needed for cycle time metrics)
+		if *maxCommits > 50 || cfg.mirrorDir != "" {
+			paginated, truncated := paginateCommits(cfg, allPRs, max(*maxCommits, 50))
+			if truncated > 0 {
+				commitNote = fmt.Sprintf("%d of %d large PR(s) have more than %d commits; only the first %d were scanned for Ona co-authors", truncated, paginated, *maxCommits, *maxCommits)
+				fmt.Fprintf(os.Stderr, "%s\n", commitNote)
+			} else if paginated > 0 {
+				fmt.Fprintf(os.Stderr, "Fetched full commit history for %d PR(s)\n", paginated)
+			}
+		} else {
+			backfillFirstCommits(cfg, allPRs)
+		}
+
+		if *enrichReviewsFlag {
+			enriched, failed := enrichReviews(cfg, allPRs, cfg.excludeSet)
+			fmt.Fprintf(os.Stderr, "Fetched review history for %d PR(s)\n", enriched)
+			if failed > 0 {
+				warnPartial("review history unavailable for %d PR(s); their review counts are left empty", failed)
+			}
+		}
+	}
+
+	checkFetchFailures(fetchRanges, failedWeeks, truncated)
+	if len(allPRs) == 0 && len(cachedPRs) == 0 && !*noPreflight {
+		fatalCode(exitNoData, "No merged PRs between %s and %s. Use --no-preflight to write the empty report anyway", startDate, today)
+	}
+
+	if cache != nil {
+		if cache.redact {
+			redactPRIdentities(allPRs)
+		}
+		// Weeks with failed queries may be incomplete; don't cache them
+		var complete []weekRange
+		for _, wr := range fetchRanges {
+			if !slices.Contains(failedWeeks, wr) {
+				complete = append(complete, wr)
+			}
+		}
+		if err := cache.save(cfg, complete, allPRs); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to write cache: %v\n", err)
+		}
+		allPRs = append(allPRs, cachedPRs...)
+	}
+
+	var priorPRs []PR
+	if *yoyFlag {
+		allPRs, priorPRs = splitYoYPRs(allPRs, weekRanges[0].start)
+	}
 
 	// Filter and compute metrics
 	fmt.Fprintf(os.Stderr, "Processing PRs...\n")
-	filtered := filterPRs(allPRs, cfg.excludeSet)
+	audit := &filterAudit{redact: anonymize.pseudonyms()}
+	filtered := filterPRs(allPRs, cfg.excludeSet, audit)
 	fmt.Fprintf(os.Stderr, "Processed: %d PRs (%d excluded)\n", len(filtered), len(allPRs)-len(filtered))
+	logRevertSignals(filtered)
+	logRevertBase(filtered)
+	logReopens(filtered)
 
-	// Exclude bottom N% of contributors by total PR count
-	if *excludeBottomPct > 0 && *excludeBottomPct < 100 {
-		// Count PRs per author
-		authorCounts := make(map[string]int)
-		for _, pr := range filtered {
-			authorCounts[pr.authorLogin]++
+	// Replace logins with pseudonyms before anything can log or output them
+	if pseudonyms != nil {
+		pseudonyms.anonymizePRs(filtered)
+		if *anonymizeMap != "" {
+			if err := pseudonyms.save(*anonymizeMap); err != nil {
+				fatalCode(exitWrite, "Failed to write --anonymize-map: %v", err)
+			}
 		}
+		fmt.Fprintf(os.Stderr, "Replaced author logins with pseudonyms\n")
+	}
 
-		// Sort authors by PR count ascending
-		type authorEntry struct {
-			login string
-			count int
+	// --sensitivity reruns the contributor and --min-prs filters itself
+	sensitivityBase, sensitivityWeeks := slices.Clone(filtered), weekRanges
+
+	// Exclude bottom N% of contributors by --exclude-bottom-by. Contributor
+	// stats keep their PRs and mark them as excluded.
+	contributorPRs := filtered
+	if excluded := cut.authors(filtered, weekRanges); len(excluded) > 0 {
+		excludeSet := authorSet(excluded)
+		var names []string
+		for _, a := range excluded {
+			names = append(names, fmt.Sprintf("%s (%d)", a.login, a.count))
 		}
-		authors := make([]authorEntry, 0, len(authorCounts))
-		for login, count := range authorCounts {
-			authors = append(authors, authorEntry{login, count})
+		fmt.Fprintf(os.Stderr, "Excluded %d bottom contributors (<=%d %s): %s\n",
+			len(excluded), excluded[len(excluded)-1].count, bottomBy.unit, strings.Join(names, ", "))
+
+		note := fmt.Sprintf("Excluded bottom %d%% of contributors by %s (%d contributor(s))", cut.pct, bottomBy.desc, len(excluded))
+		kept := audit.dropAuthors(filtered, "Bottom contributors", note, excludeSet)
+		fmt.Fprintf(os.Stderr, "After contributor filter: %d PRs (%d removed)\n", len(kept), len(filtered)-len(kept))
+		filtered = kept
+		contribOpts.excluded = excludeSet
+	}
+
+	// Winsorize or drop extreme cycle-time values (optional)
+	var outlierNotes []string
+	for _, res := range applyOutlierPolicy(filtered, outliers) {
+		note := outlierNote(outliers, res)
+		fmt.Fprintf(os.Stderr, "%s\n", note)
+		if res.affected > 0 {
+			outlierNotes = append(outlierNotes, note)
 		}
-		sort.Slice(authors, func(i, j int) bool {
-			return authors[i].count < authors[j].count
-		})
+	}
 
-		// Compute cutoff: bottom N% of authors by headcount
-		cutoffIdx := len(authors) * *excludeBottomPct / 100
-		if cutoffIdx > 0 {
-			excludeSet := make(map[string]bool)
-			for i := 0; i < cutoffIdx; i++ {
-				excludeSet[authors[i].login] = true
-			}
-			// Also exclude anyone tied with the last excluded author
-			thresholdCount := authors[cutoffIdx-1].count
-			for i := cutoffIdx; i < len(authors); i++ {
-				if authors[i].count <= thresholdCount {
-					excludeSet[authors[i].login] = true
-				} else {
-					break
-				}
-			}
+	// Join PRs to Jira or Linear issues (optional)
+	var issueGroups []issueGroupStat
+	var issueGroupLabel string
+	if jiraCfg != nil {
+		fmt.Fprintf(os.Stderr, "Joining PRs to Jira issues...\n")
+		applyJiraIssues(*jiraCfg, filtered)
+		issueGroupLabel = "Jira Issue Type"
+		issueGroups = computeIssueBreakdown(filtered, func(pr enrichedPR) string { return pr.issueType }, *minGroupSize)
+	} else if linearKeyRe != nil {
+		fmt.Fprintf(os.Stderr, "Joining PRs to Linear issues...\n")
+		applyLinearIssues(linearKeyRe, filtered)
+		issueGroupLabel = "Linear Project"
+		issueGroups = computeIssueBreakdown(filtered, func(pr enrichedPR) string { return pr.issueProject }, *minGroupSize)
+	}
 
-			// Log excluded authors
-			var excluded []string
-			for i := 0; i < len(authors) && excludeSet[authors[i].login]; i++ {
-				excluded = append(excluded, fmt.Sprintf("%s (%d)", authors[i].login, authors[i].count))
-			}
-			fmt.Fprintf(os.Stderr, "Excluded %d bottom contributors (<=%d PRs): %s\n",
-				len(excludeSet), thresholdCount, strings.Join(excluded, ", "))
-
-			// Filter PRs
-			var kept []enrichedPR
-			for _, pr := range filtered {
-				if !excludeSet[pr.authorLogin] {
-					kept = append(kept, pr)
-				}
+	if *prOutput != "" {
+		if err := writePRDetailsCSV(*prOutput, filtered, anonymize.pseudonyms(), *schemaVersionFlag); err != nil {
+			fatalCode(exitWrite, "Failed to write --pr-output: %v", err)
+		}
+		var rewritten int
+		for _, pr := range filtered {
+			if pr.firstCommitMethod == "force_push" {
+				rewritten++
 			}
-			fmt.Fprintf(os.Stderr, "After contributor filter: %d PRs (%d removed)\n", len(kept), len(filtered)-len(kept))
-			filtered = kept
 		}
+		fmt.Fprintf(os.Stderr, "PR details written to %s (%d PR(s) used a pre-force-push first commit)\n", *prOutput, rewritten)
 	}
 
 	// Aggregate and output CSV
 	fmt.Fprintf(os.Stderr, "Aggregating by week...\n")
-	csv, allWeekStats := aggregateCSV(filtered, weekRanges)
+	allWeekStats := aggregateWeeks(filtered, weekRanges)
+	incompleteWeeks := markIncomplete(allWeekStats, weekRanges, failedWeeks)
 
 	// Fetch build volume from GitHub Actions REST API
-	buildStats := fetchBuildRuns(cfg, weekRanges)
+	var buildStats []buildWeekStats
+	if cfg.provider == "github" {
+		buildStats = fetchBuildRuns(cfg, weekRanges)
+	}
 	if buildStats != nil {
 		for i := range allWeekStats {
 			if i < len(buildStats) {
@@ -185,7 +1037,219 @@ func main() {
 			}
 		}
 	}
-	csv = appendBuildColumns(csv, allWeekStats)
+	if *ciQueue {
+		applyCIQueue(buildStats, allWeekStats)
+	}
+
+	// CI cost and self-hosted runner utilization from the sampled runs' jobs
+	var sampledJobs []weekJobs
+	if (*ciCost || *runnersFlag) && buildStats != nil {
+		sampledJobs = fetchWeekJobs(cfg, weekRanges, buildStats)
+	}
+	if *ciCost {
+		applyCICost(sampledJobs, allWeekStats, *ciMinutePrice)
+	}
+	var runnerUsage []runnerLabel
+	if *runnersFlag && buildStats == nil {
+		fmt.Fprintf(os.Stderr, "Skipping runner utilization: no workflow runs\n")
+	} else if *runnersFlag {
+		runnerUsage = aggregateRunnerUsage(weekRanges, sampledJobs)
+		if runnerUsage != nil {
+			logRunnerUsage(runnerUsage)
+		}
+		if *runnersOutput != "" {
+			if err := writeRunnersCSV(*runnersOutput, weekRanges, runnerUsage, *schemaVersionFlag); err != nil {
+				fatalCode(exitWrite, "Failed to write --runners-output: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "Runner utilization written to %s\n", *runnersOutput)
+		}
+	}
+
+	// Reopened PRs (GitHub fetches close/reopen events)
+	if cfg.provider == "github" {
+		for i := range allWeekStats {
+			allWeekStats[i].reopenTracked = true
+		}
+	}
+
+	// Size-weighted throughput (optional)
+	if *sizeWeighted {
+		for i := range allWeekStats {
+			allWeekStats[i].sizeWeighted = true
+		}
+	}
+
+	// Ona uptake by lines changed (optional)
+	if *onaWeighted {
+		for i := range allWeekStats {
+			allWeekStats[i].onaWeighted = true
+		}
+		logOnaWeighted(filtered)
+	}
+
+	// Review time per PR size bucket (optional)
+	if *sizeBucketsFlag {
+		applySizeBuckets(filtered, weekRanges, allWeekStats)
+		logSizeBuckets(filtered)
+	}
+
+	// Active-engineer retention (optional)
+	if *retention {
+		applyRetention(filtered, weekRanges, allWeekStats)
+	}
+
+	// Bot PRs as a separate automation series (optional)
+	if *automation {
+		botPRs := automationPRs(allPRs)
+		logAutomation(botPRs)
+		applyAutomation(botPRs, weekRanges, allWeekStats)
+	}
+
+	// Security fixes as a separate series (optional)
+	if *security {
+		fixes := securityPRs(allPRs, securityLabels)
+		logSecurity(fixes, weekRanges)
+		applySecurity(fixes, weekRanges, allWeekStats)
+	}
+
+	// Release cadence from GitHub releases or tags (optional)
+	if *releasesFlag != "" {
+		fmt.Fprintf(os.Stderr, "Fetching %s...\n", *releasesFlag)
+		releases, err := fetchReleases(cfg, *releasesFlag, releaseRe, weekRanges[0].start)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping release metrics: %v\n", err)
+		} else {
+			logReleases(releases, weekRanges)
+			applyReleases(releases, filtered, weekRanges, allWeekStats)
+			if *releaseChangelog {
+				changelogs := fetchChangelogs(cfg, releases, weekRanges, allPRs)
+				logChangelogs(changelogs)
+				applyChangelogs(changelogs, weekRanges, allWeekStats)
+			}
+		}
+	}
+
+	// Description, issue-link, and test-file shares (optional)
+	if *hygiene {
+		applyHygiene(filtered, weekRanges, allWeekStats, *hygieneMinDescription)
+	}
+
+	// Share of PRs filling the PR template's sections (optional)
+	if prTmpl != nil {
+		applyTemplateCompliance(filtered, weekRanges, allWeekStats, prTmpl)
+	}
+
+	// Approved and unreviewed shares of merged PRs (optional)
+	var reviewCoverageNote string
+	if *reviewCoverage {
+		applyReviewCoverage(filtered, weekRanges, allWeekStats)
+		if n := unknownReviewCoverage(filtered, weekRanges); n > 0 {
+			reviewCoverageNote = fmt.Sprintf("Review coverage leaves out %d merged PR(s) whose reviews weren't fetched (--local-git, or cached before reviews were)", n)
+			fmt.Fprintf(os.Stderr, "%s\n", reviewCoverageNote)
+		}
+	}
+
+	// Review request to first review, from the --enrich-reviews timeline
+	if *enrichReviewsFlag {
+		applyReviewResponsiveness(filtered, weekRanges, allWeekStats)
+	}
+
+	// Incident volume and MTTR from PagerDuty or a CSV export (optional)
+	if *incidentsCSV != "" || *pagerDuty {
+		var incidents []incident
+		var err error
+		if *incidentsCSV != "" {
+			incidents, err = loadIncidentsCSV(*incidentsCSV)
+		} else {
+			fmt.Fprintf(os.Stderr, "Fetching PagerDuty incidents...\n")
+			var serviceIDs []string
+			for _, id := range strings.Split(*pagerDutyServices, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					serviceIDs = append(serviceIDs, id)
+				}
+			}
+			incidents, err = fetchPagerDutyIncidents(serviceIDs, weekRanges[0].start, weekRanges[len(weekRanges)-1].end.AddDate(0, 0, 1))
+		}
+		if err != nil {
+			fatal("Failed to load incidents: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "  %d incidents loaded\n", len(incidents))
+		for i, is := range bucketIncidents(incidents, weekRanges) {
+			allWeekStats[i].incidentsTracked = true
+			allWeekStats[i].incidentCount = is.count
+			allWeekStats[i].medianMTTR = is.mttrHours
+		}
+	}
+
+	// User-defined weekly series (--series)
+	for _, def := range externalSeriesDefs {
+		points, err := def.source.load()
+		if err != nil {
+			fatal("Failed to load series %q: %v", def.name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Series %s: %d points loaded\n", def.name, len(points))
+		for i, v := range bucketSeries(points, def.agg, weekRanges) {
+			if allWeekStats[i].external == nil {
+				allWeekStats[i].external = make(map[string]float64)
+			}
+			allWeekStats[i].external[def.name] = v
+		}
+	}
+	for i := range allWeekStats {
+		computeDerivedMetrics(&allWeekStats[i])
+	}
+
+	// k-anonymity guard: suppress weeks with too few engineers (optional)
+	var suppressedWeeks int
+	if *minGroupSize > 1 {
+		suppressedWeeks = suppressSmallWeeks(allWeekStats, *minGroupSize)
+		if suppressedWeeks > 0 {
+			fmt.Fprintf(os.Stderr, "Suppressed %d week(s) with fewer than %d authors\n", suppressedWeeks, *minGroupSize)
+		}
+	}
+	csv := writeWeeklyCSV(weekRanges, allWeekStats)
+
+	// Join-quarter cohort curves (optional), over all weeks before --min-prs
+	// drops any
+	var cohorts []cohort
+	if *cohortsFlag {
+		cohorts = buildCohorts(filtered, weekRanges, *minGroupSize, fiscal)
+		logCohorts(cohorts)
+	}
+
+	// PR lifecycle states (optional), from the --enrich-reviews history
+	var lifecycle *lifecycleModel
+	if *lifecycleFlag {
+		lifecycle = buildLifecycle(filtered, *minGroupSize)
+		if lifecycle == nil {
+			fmt.Fprintf(os.Stderr, "  Skipping PR lifecycle: no PRs with review history or fewer than %d authors\n", max(*minGroupSize, 1))
+		} else {
+			logLifecycle(lifecycle)
+		}
+	}
+
+	// Week-over-week and 4-week deltas (optional), leaving out weeks the
+	// --min-prs filter below drops
+	if len(deltaMetrics) > 0 {
+		var include func(ws weekStats) bool
+		if *minPRs > 0 && *granularity == "weekly" {
+			include = func(ws weekStats) bool { return ws.prsMerged >= *minPRs }
+		}
+		csv = appendDeltaColumns(csv, allWeekStats, deltaMetrics, include)
+	}
+
+	// Fiscal calendar (optional): tag weeks with their fiscal quarter and add
+	// the fiscal columns
+	if *fiscalYearStart != "" {
+		applyFiscalQuarters(fiscal, weekRanges, allWeekStats)
+		csv = appendFiscalColumns(csv, weekRanges, fiscal)
+	}
+	if len(sprints) > 0 {
+		csv = appendSprintColumn(csv, weekRanges, sprints)
+	}
+	if len(compareWindows) > 0 {
+		applyCompareWindows(weekRanges, allWeekStats)
+	}
 
 	// Filter out low-activity weeks for CSV output and weekly granularity.
 	// For monthly granularity, keep all weeks for aggregation — filter at month level instead.
@@ -198,17 +1262,19 @@ func main() {
 		if len(csvLines) > 0 {
 			filteredCSVLines = append(filteredCSVLines, csvLines[0])
 		}
-		for i, ws := range allWeekStats {
-			if ws.prsMerged >= *minPRs {
-				filteredRanges = append(filteredRanges, weekRanges[i])
-				filteredStats = append(filteredStats, ws)
-				if i+1 < len(csvLines) {
-					filteredCSVLines = append(filteredCSVLines, csvLines[i+1])
-				}
-			} else {
-				droppedWeeks++
+		kept := audit.dropPeriods("Below --min-prs", "week", weekRanges,
+			func(i int) bool { return allWeekStats[i].prsMerged >= *minPRs },
+			func(n int) string {
+				return fmt.Sprintf("Excluded %d week(s) with fewer than %d merged PRs", n, *minPRs)
+			})
+		for _, i := range kept {
+			filteredRanges = append(filteredRanges, weekRanges[i])
+			filteredStats = append(filteredStats, allWeekStats[i])
+			if i+1 < len(csvLines) {
+				filteredCSVLines = append(filteredCSVLines, csvLines[i+1])
 			}
 		}
+		droppedWeeks = len(weekRanges) - len(kept)
 		if droppedWeeks > 0 {
 			fmt.Fprintf(os.Stderr, "Excluded %d week(s) with fewer than %d PRs\n", droppedWeeks, *minPRs)
 		}
@@ -220,84 +1286,413 @@ func main() {
 		}
 	}
 
+	if embedsCompleteness(*schemaVersionFlag) {
+		csv = appendCompleteColumn(csv, allWeekStats)
+	}
+
+	// Pin the CSV layout (optional), or end each row with the schema version
+	if len(csvColumns) > 0 {
+		selected, missing, err := selectColumns(csv, csvColumns, *schemaVersionFlag)
+		if err != nil {
+			fatal("Failed to select --columns: %v", err)
+		}
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "WARNING: --columns not produced by this run, left empty: %s\n", strings.Join(missing, ", "))
+		}
+		csv = selected
+	} else if embedsSchemaVersion(*schemaVersionFlag) {
+		csv = appendSchemaVersionColumn(csv, *schemaVersionFlag)
+	}
+
 	if cfg.output != "" {
 		if err := os.WriteFile(cfg.output, []byte(csv), 0644); err != nil {
-			fatal("Failed to write output: %v", err)
+			fatalCode(exitWrite, "Failed to write output: %v", err)
 		}
 		fmt.Fprintf(os.Stderr, "CSV written to %s\n", cfg.output)
 	} else {
 		fmt.Print(csv)
 	}
+	if *notebookBundleDir != "" {
+		bundle := notebookBundle{
+			repo:        cfg.owner + "/" + cfg.repo,
+			source:      notebookSource(cfg),
+			start:       startDate,
+			end:         weekRanges[len(weekRanges)-1].end.Format("2006-01-02"),
+			weeks:       len(weekRanges),
+			granularity: *granularity,
+			anonymized:  anonymize.pseudonyms(),
+		}
+		if err := writeNotebookBundle(*notebookBundleDir, bundle, csv, filtered, *schemaVersionFlag); err != nil {
+			fatalCode(exitWrite, "Failed to write --notebook-bundle: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Notebook bundle written to %s (%s, %s, %s)\n", *notebookBundleDir, bundleWeeklyCSV, bundlePRsCSV, bundleNotebook)
+	}
 
-	// Monthly aggregation (optional): group weekly data into calendar months
-	// for stats and HTML. CSV output remains weekly.
+	// Monthly or sprint aggregation (optional): group weekly data into
+	// calendar months or --sprint-project sprints for stats and HTML. CSV
+	// output remains weekly.
 	chartRanges := weekRanges
 	chartStats := allWeekStats
-	var droppedMonths int
-	if *granularity == "monthly" {
-		fmt.Fprintf(os.Stderr, "Aggregating into calendar months...\n")
-		chartRanges, chartStats = aggregateMonthly(weekRanges, allWeekStats)
-		fmt.Fprintf(os.Stderr, "  %d months from %d weeks\n", len(chartRanges), len(weekRanges))
+	periodLabel := "week"
+	if *granularity != "weekly" {
+		switch *granularity {
+		case "monthly":
+			periodLabel = "month"
+			fmt.Fprintf(os.Stderr, "Aggregating into calendar months...\n")
+			chartRanges, chartStats = aggregateMonthly(weekRanges, allWeekStats)
+		case "sprint":
+			periodLabel = "sprint"
+			fmt.Fprintf(os.Stderr, "Aggregating into sprints...\n")
+			chartRanges, chartStats = aggregateSprints(weekRanges, allWeekStats, sprints)
+		}
+		fmt.Fprintf(os.Stderr, "  %d %ss from %d weeks\n", len(chartRanges), periodLabel, len(weekRanges))
+		if *fiscalYearStart != "" {
+			applyFiscalQuarters(fiscal, chartRanges, chartStats)
+		}
+		if len(compareWindows) > 0 {
+			applyCompareWindows(chartRanges, chartStats)
+		}
 
-		// Apply min-prs filter at the month level
+		// Apply min-prs filter at the month or sprint level
 		if *minPRs > 0 {
 			var filteredRanges []weekRange
 			var filteredStats []weekStats
-			for i, ms := range chartStats {
-				if ms.prsMerged >= *minPRs {
-					filteredRanges = append(filteredRanges, chartRanges[i])
-					filteredStats = append(filteredStats, ms)
-				} else {
-					droppedMonths++
-				}
+			kept := audit.dropPeriods("Below --min-prs", periodLabel, chartRanges,
+				func(i int) bool { return chartStats[i].prsMerged >= *minPRs },
+				func(n int) string {
+					return fmt.Sprintf("Excluded %d %s(s) with fewer than %d merged PRs", n, periodLabel, *minPRs)
+				})
+			for _, i := range kept {
+				filteredRanges = append(filteredRanges, chartRanges[i])
+				filteredStats = append(filteredStats, chartStats[i])
 			}
-			if droppedMonths > 0 {
-				fmt.Fprintf(os.Stderr, "Excluded %d month(s) with fewer than %d PRs\n", droppedMonths, *minPRs)
+			if dropped := len(chartRanges) - len(kept); dropped > 0 {
+				fmt.Fprintf(os.Stderr, "Excluded %d %s(s) with fewer than %d PRs\n", dropped, periodLabel, *minPRs)
 			}
 			chartRanges = filteredRanges
 			chartStats = filteredStats
 		}
 	}
 
-	// Build filter notes for the HTML notice
-	var filterNotes []string
-	if droppedWeeks > 0 || droppedMonths > 0 {
+	// Year-over-year overlay (optional): the prior year's PRs go through
+	// the same PR filters and granularity, then each chart period is matched
+	// with its period a year earlier
+	var yoy []*weekStats
+	if *yoyFlag {
+		priorStats := aggregateWeeks(filterPRs(priorPRs, cfg.excludeSet, &filterAudit{redact: anonymize.pseudonyms()}), priorRanges)
+		if *minGroupSize > 1 {
+			suppressSmallWeeks(priorStats, *minGroupSize)
+		}
+		priorPeriods := priorRanges
 		if *granularity == "monthly" {
-			filterNotes = append(filterNotes, fmt.Sprintf("Excluded %d month(s) with fewer than %d merged PRs", droppedMonths, *minPRs))
-		} else {
-			filterNotes = append(filterNotes, fmt.Sprintf("Excluded %d week(s) with fewer than %d merged PRs", droppedWeeks, *minPRs))
+			priorPeriods, priorStats = aggregateMonthly(priorRanges, priorStats)
 		}
+		yoy = alignYoY(chartRanges, priorPeriods, priorStats, *granularity == "monthly")
+		logYoY(chartStats, yoy)
 	}
-	if *excludeBottomPct > 0 {
-		filterNotes = append(filterNotes, fmt.Sprintf("Excluded bottom %d%% of contributors by total PR count", *excludeBottomPct))
+
+	// Build filter notes for the HTML notice: the filter pipeline's steps
+	// (including the periods generateStats leaves out), then the rest
+	var holidays [][]string
+	if len(holidayCountries) > 0 {
+		holidays = periodHolidays(chartRanges, holidayCountries)
+		logHolidays(chartRanges, holidays)
 	}
-	{
-		var excluded []string
-		for u := range cfg.excludeSet {
-			excluded = append(excluded, u)
+	// The before/after comparison's periods: the chart's, minus holiday
+	// weeks with --holiday-policy exclude
+	statsRanges, statsInput := chartRanges, chartStats
+	if holidays != nil && *holidayPolicy == "exclude" {
+		kept := audit.skipStatsPeriods("Holiday weeks", periodLabel, chartRanges,
+			func(i int) bool { return len(holidays[i]) == 0 },
+			func(n int) string {
+				return fmt.Sprintf("Left %d week(s) with public holidays (%s) out of the before/after comparison", n, strings.Join(holidayCountries, ", "))
+			})
+		statsRanges, statsInput = nil, nil
+		for _, i := range kept {
+			statsRanges = append(statsRanges, chartRanges[i])
+			statsInput = append(statsInput, chartStats[i])
 		}
-		sort.Strings(excluded)
-		if len(excluded) > 0 {
-			filterNotes = append(filterNotes, fmt.Sprintf("Excluded users: %s", strings.Join(excluded, ", ")))
+	}
+	audit.recordStatsPeriods(statsRanges, statsInput, periodLabel)
+	logFilterAudit(audit)
+	filterNotes := audit.notes()
+	if incompleteWeeks > 0 {
+		filterNotes = append(filterNotes, incompleteNote(incompleteWeeks))
+	}
+	if suppressedWeeks > 0 {
+		filterNotes = append(filterNotes, fmt.Sprintf("Suppressed %d week(s) with fewer than %d authors (--min-group-size)", suppressedWeeks, *minGroupSize))
+	}
+	if cfg.provider == "git" {
+		filterNotes = append(filterNotes, "Local git history (--local-git): each non-merge commit counts as one PR; cycle times and reviews are unavailable")
+	}
+	if note := attributionNote(); note != "" {
+		filterNotes = append(filterNotes, note)
+	}
+	if reviewCoverageNote != "" {
+		filterNotes = append(filterNotes, reviewCoverageNote)
+	}
+	if prTmpl != nil {
+		filterNotes = append(filterNotes, fmt.Sprintf("Template compliance requires the description sections %s (%s)", strings.Join(prTmpl.sections, ", "), prTmpl.source))
+	}
+	filterNotes = append(filterNotes, outlierNotes...)
+	if note := revertBaseNote(); note != "" {
+		filterNotes = append(filterNotes, note)
+	}
+	if truncationNoteText != "" {
+		filterNotes = append(filterNotes, truncationNoteText)
+	}
+	if commitNote != "" {
+		filterNotes = append(filterNotes, commitNote)
+	}
+
+	// Branch protection settings and their recorded changes (optional)
+	var protectionMarks []htmlProtectionChange
+	if *branchProtection {
+		current, err := fetchBranchProtection(cfg.token, cfg.owner, cfg.repo, cfg.branch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to read branch protection: %v\n", err)
+		} else {
+			history := []protectionSnapshot{current}
+			if *storeDir != "" {
+				if history, err = recordProtection(*storeDir, cfg.owner, cfg.repo, current); err != nil {
+					fatalCode(exitWrite, "Failed to write --store protection history: %v", err)
+				}
+			} else {
+				fmt.Fprintf(os.Stderr, "Branch protection changes are only detected across runs with --store; showing the current settings\n")
+			}
+			protectionMarks = protectionMarkers(protectionChanges(history), chartRanges)
+			filterNotes = append(filterNotes, protectionNotes(current, protectionMarks)...)
+			fmt.Fprintf(os.Stderr, "Branch protection on %s: %s; %d change(s) within the chart\n", cfg.branch, current.describe(), len(protectionMarks))
 		}
 	}
-	filterNotes = append(filterNotes, "Excluded bot-authored PRs")
-	filterNotes = append(filterNotes, "Excluded draft PRs")
 
 	// Compute before/after aggregation for HTML summary stat cards
 	fmt.Fprintf(os.Stderr, "Computing aggregation stats...\n")
-	periodLabel := "week"
-	if *granularity == "monthly" {
-		periodLabel = "month"
+	statsRows := generateStats(statsInput, *compareWindowPct, *compareOnaThreshold, periodLabel)
+
+	// Recompute the headline changes across contributor filter settings (optional)
+	var sensitivityRes *sensitivityResult
+	if *sensitivity {
+		sensitivityOpts.outliers = outliers
+		sensitivityOpts.periodLabel = periodLabel
+		switch *granularity {
+		case "monthly":
+			sensitivityOpts.rollup = aggregateMonthly
+		case "sprint":
+			sensitivityOpts.rollup = func(weeks []weekRange, stats []weekStats) ([]weekRange, []weekStats) {
+				return aggregateSprints(weeks, stats, sprints)
+			}
+		}
+		sensitivityRes = runSensitivity(sensitivityBase, sensitivityWeeks, sensitivityOpts)
+		logSensitivity(sensitivityRes)
+	}
+
+	// Place metrics within industry benchmark bands (optional)
+	var benchResults []benchmarkResult
+	if *benchmark != "" {
+		periodDays := 7.0
+		switch *granularity {
+		case "monthly":
+			periodDays = 365.25 / 12
+		case "sprint":
+			periodDays = meanPeriodDays(chartRanges)
+		}
+		benchRows := slices.Concat(statsRows, windowRows(statsInput, benchmarkWindowMetrics, *compareWindowPct, *compareOnaThreshold, periodLabel))
+		benchResults = evaluateBenchmarks(benchSet, benchRows, periodDays)
+		logBenchmarks(benchSet, benchResults)
+	}
+
+	// Check metric goals (optional)
+	var targetResults []targetResult
+	if len(targets) > 0 {
+		fmt.Fprintf(os.Stderr, "Goals:\n")
+		targetResults = evaluateTargets(targets, chartStats, statsRows)
+		logTargets(targetResults)
+	}
+
+	// Correlate incident and user-defined series against Ona uptake and throughput
+	var correlations []correlationRow
+	var corrTargets []string
+	if len(chartStats) > 0 && chartStats[0].incidentsTracked {
+		corrTargets = append(corrTargets, "incident_count", "median_mttr_hours")
+	}
+	corrTargets = append(corrTargets, userMetricNames...)
+	corrDrivers := []string{"pct_ona_involved", "prs_per_engineer", "prs_merged"}
+	if *onaWeighted {
+		// Both as a driver of the other targets and as a target itself, to
+		// show how it tracks PR-count uptake and throughput.
+		corrTargets = append(corrTargets, "pct_ona_lines")
+		corrDrivers = slices.Insert(corrDrivers, 1, "pct_ona_lines")
+	}
+	if len(corrTargets) > 0 {
+		fmt.Fprintf(os.Stderr, "Correlating %s with Ona uptake and throughput...\n", strings.Join(corrTargets, ", "))
+		correlations = computeCorrelations(chartStats,
+			metricsByName(allMetrics, corrTargets...),
+			metricsByName(allMetrics, corrDrivers...))
+		logCorrelations(correlations)
+	}
+
+	// Correlate every pair of metrics (optional)
+	var corrMat *corrMatrix
+	if *corrMatrixFlag {
+		m := computeCorrMatrix(chartStats)
+		corrMat = &m
+		logCorrMatrix(m)
+		if *corrMatrixOutput != "" {
+			if err := writeCorrMatrixCSV(*corrMatrixOutput, m, *schemaVersionFlag); err != nil {
+				fatalCode(exitWrite, "Failed to write --correlation-matrix-output: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "Correlation matrix written to %s\n", *corrMatrixOutput)
+		}
+	}
+
+	// Per-PR outcomes of Ona-involved vs other PRs (optional)
+	var onaComparisons []outcomeComparison
+	if (*onaComparison || *onaMatching) && cfg.provider == "github" {
+		applyPRBuildResults(filtered, fetchPRBuildResults(cfg, weekRanges))
+	}
+	if *onaComparison {
+		fmt.Fprintf(os.Stderr, "Comparing Ona-involved and other PRs...\n")
+		onaComparisons = compareOnaOutcomes(filtered, *minGroupSize)
+		if onaComparisons == nil && *minGroupSize > 1 {
+			fmt.Fprintf(os.Stderr, "  Skipped: a group has fewer than %d authors\n", *minGroupSize)
+		}
+		logOutcomeComparisons(onaComparisons)
+	}
+	var onaMatches *matchingResult
+	if *onaMatching {
+		fmt.Fprintf(os.Stderr, "Matching Ona-involved PRs to similar other PRs...\n")
+		onaMatches = matchOnaPRs(filtered, *minGroupSize)
+		if onaMatches != nil {
+			logMatching(onaMatches)
+		} else {
+			fmt.Fprintf(os.Stderr, "  Skipped: no PRs in one of the groups, or a matched group has fewer than %d authors\n", max(*minGroupSize, 1))
+		}
+	}
+
+	var mixedModels []mixedModelFit
+	if *onaMixedModel {
+		fmt.Fprintf(os.Stderr, "Fitting mixed-effects models of Ona involvement...\n")
+		mixedModels = fitOnaMixedModels(filtered, *minGroupSize)
+		if mixedModels == nil {
+			fmt.Fprintf(os.Stderr, "  Skipped: fewer than %d Ona-involved or other PRs, or a group has fewer than %d authors\n", mixedModelMinPRs, max(*minGroupSize, 1))
+		}
+		logMixedModels(mixedModels)
 	}
-	statsRows := generateStats(chartStats, *compareWindowPct, *compareOnaThreshold, periodLabel)
 
 	// Compute top N contributors before/after Ona (optional)
 	var topContributors []contributorStat
-	if *topN > 0 {
-		topContributors = computeTopContributors(filtered, weekRanges, *topN)
+	if *topN > 0 && !*noContributors {
+		topContributors = computeTopContributors(contributorPRs, weekRanges, contribOpts)
 		if len(topContributors) > 0 {
+			var excluded int
+			for _, c := range topContributors {
+				if c.excluded {
+					excluded++
+				}
+			}
 			fmt.Fprintf(os.Stderr, "Top %d contributors computed.\n", len(topContributors))
+			if excluded > 0 {
+				fmt.Fprintf(os.Stderr, "  %d of them are excluded from the metrics by --exclude-bottom-contributor-pct\n", excluded)
+			}
+		}
+	}
+	var onaPowerUsers, onaUntouched []onaUserStat
+	if *onaHeatList > 0 && !*noContributors {
+		heatOpts := contribOpts
+		heatOpts.n = *onaHeatList
+		onaPowerUsers, onaUntouched = computeOnaHeatList(contributorPRs, heatOpts)
+		logOnaHeatList(onaPowerUsers, onaUntouched)
+	}
+	var topReviewerStats []reviewerStat
+	if *topReviewers > 0 && !*noContributors {
+		topReviewerStats = computeTopReviewers(filtered, *topReviewers, anonymize.hashesContributors())
+	}
+
+	// Persist results for the API server and dashboards (optional)
+	var statsHistory []statsHistoryEntry
+	if *storeDir != "" || storage != nil {
+		var contributors []contributorStat
+		if !*noContributors && *minGroupSize <= 1 {
+			allContribOpts := contribOpts
+			allContribOpts.n = len(contributorPRs)
+			contributors = computeTopContributors(contributorPRs, weekRanges, allContribOpts)
+		}
+		snap := buildSnapshot(cfg, *granularity, weekRanges, allWeekStats, statsRows, contributors)
+		if embedsSchemaVersion(*schemaVersionFlag) {
+			snap.SchemaVersion = *schemaVersionFlag
+		}
+		if *storeDir != "" {
+			if err := saveSnapshot(*storeDir, snap); err != nil {
+				fatalCode(exitWrite, "Failed to write --store snapshot: %v", err)
+			}
+			history, err := appendStatsHistory(*storeDir, snap, len(weekRanges))
+			if err != nil {
+				fatalCode(exitWrite, "Failed to write --store stats history: %v", err)
+			}
+			logHistoryDrift(history)
+			statsHistory = history
+			fmt.Fprintf(os.Stderr, "Results stored in %s\n", *storeDir)
+		}
+		if storage != nil {
+			repo := cfg.owner + "/" + cfg.repo
+			if err := storage.SaveWeeks(repo, snap.Weeks); err != nil {
+				fatalCode(exitWrite, "Failed to save weeks to --storage: %v", err)
+			}
+			if err := storage.SavePRs(repo, storedPRs(filtered, anonymize.pseudonyms())); err != nil {
+				fatalCode(exitWrite, "Failed to save PRs to --storage: %v", err)
+			}
+			if rec, ok := storage.(runRecorder); ok {
+				if err := rec.SaveRun(buildStoredRun(snap, len(filtered)), snap.Contributors); err != nil {
+					fatalCode(exitWrite, "Failed to save the run to --storage: %v", err)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "%d week(s) and %d PR(s) saved to %s\n", len(snap.Weeks), len(filtered), *storageSpec)
+		}
+	}
+
+	// Post the weekly summary to a tracking issue (optional)
+	if postIssueRef != nil && len(weekRanges) > 0 {
+		token := cfg.token
+		if token == "" {
+			token = resolveToken()
+		}
+		marker := summaryMarker(cfg, weekRanges[len(weekRanges)-1])
+		body := formatIssueSummary(cfg, marker, weekRanges, allWeekStats, statsRows, periodLabel, filterNotes)
+		url, err := postIssueSummary(token, *postIssueRef, marker, body)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to post summary to %s: %v\n", *postIssue, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Summary posted to %s\n", url)
+		}
+	}
+
+	// Publish the summary to a Confluence page (optional)
+	if confluence != nil && len(weekRanges) > 0 {
+		body := formatIssueSummary(cfg, "", weekRanges, allWeekStats, statsRows, periodLabel, filterNotes)
+		url, err := publishConfluence(*confluence, body)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to update Confluence page %s: %v\n", confluence.pageID, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Summary published to %s\n", url)
+		}
+	}
+
+	// Publish the summary and weekly table to Notion (optional)
+	if *notionPage != "" && len(weekRanges) > 0 {
+		body := formatIssueSummary(cfg, "", weekRanges, allWeekStats, statsRows, periodLabel, filterNotes)
+		if err := publishNotionPage(os.Getenv("NOTION_TOKEN"), *notionPage, body, csv); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to update Notion page %s: %v\n", *notionPage, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Summary published to Notion page %s\n", *notionPage)
+		}
+	}
+	if *notionDatabase != "" {
+		n, err := publishNotionDatabase(os.Getenv("NOTION_TOKEN"), *notionDatabase, csv)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to update Notion database %s: %v\n", *notionDatabase, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "%d week(s) written to Notion database %s\n", n, *notionDatabase)
 		}
 	}
 
@@ -306,21 +1701,80 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Generating HTML chart...\n")
 		period := *granularity
 		title := fmt.Sprintf("%s/%s — %s to %s (%s)", cfg.owner, cfg.repo, startDate, today, period)
-		htmlContent, err := generateHTML(title, chartRanges, chartStats, statsRows, periodLabel, filterNotes, topContributors)
+		extras := reportExtras{
+			topContributors: topContributors,
+			topReviewers:    topReviewerStats,
+			onaPowerUsers:   onaPowerUsers,
+			onaUntouched:    onaUntouched,
+			issueGroupLabel: issueGroupLabel,
+			issueGroups:     issueGroups,
+			correlations:    correlations,
+			corrMatrix:      corrMat,
+			onaComparisons:  onaComparisons,
+			onaMatches:      onaMatches,
+			mixedModels:     mixedModels,
+			statsHistory:    statsHistory,
+			targets:         targetResults,
+			benchmark:       benchSet,
+			benchmarks:      benchResults,
+			glossary:        glossaryContext{granularity: *granularity, outliers: outliers, maxCommits: *maxCommits, minPRs: *minPRs, provider: cfg.provider},
+		}
+		if *prDrilldown || reportAudience.wantsDrilldown() {
+			extras.prLists = buildDrilldown(cfg, filtered, chartRanges, anonymize.pseudonyms())
+		}
+		extras.sensitivity = sensitivityRes
+		extras.audit = audit
+		extras.holidays = holidays
+		extras.holidayCountries = holidayCountries
+		extras.protection = protectionMarks
+		extras.runnerWeeks, extras.runners = weekRanges, runnerUsage
+		extras.deltaMetrics = deltaMetrics
+		extras.categories = categories
+		extras.cohorts = cohorts
+		extras.lifecycle = lifecycle
+		extras.yoy = yoy
+		if *unstableWeeks > 0 {
+			extras.unstable = unstablePeriods(chartRanges, unstableSince)
+		}
+		extras.schemaVersion = *schemaVersionFlag
+		if *histograms {
+			extras.histograms = buildHistograms(filtered, statsRanges, statsInput, *compareWindowPct, *compareOnaThreshold, *minGroupSize)
+			if extras.histograms == nil {
+				fmt.Fprintf(os.Stderr, "  Skipping histograms: a comparison window has no PRs or fewer than %d authors\n", max(*minGroupSize, 1))
+			}
+		}
+		if *heatmap {
+			if *minGroupSize > 1 && distinctAuthors(filtered) < *minGroupSize {
+				fmt.Fprintf(os.Stderr, "  Skipping heatmap: fewer than %d authors\n", *minGroupSize)
+			} else {
+				extras.heatmaps = buildHeatmaps(filtered, chartRanges, heatmapZone)
+			}
+		}
+		if *scatter {
+			extras.scatter = buildScatter(filtered, chartRanges, anonymize.pseudonyms())
+		}
+		if *cfd {
+			extras.flow = cumulativeFlow(fetchFlowPRs(cfg, weekRanges), chartRanges)
+		}
+		htmlContent, err := generateHTML(title, chartRanges, chartStats, statsRows, periodLabel, filterNotes, reportAudience.apply(extras))
 		if err != nil {
 			fatal("Failed to generate HTML: %v", err)
 		}
+		if err := checkOfflineHTML(htmlContent); err != nil {
+			fatal("%v", err)
+		}
 		if err := os.WriteFile(*htmlOutput, []byte(htmlContent), 0644); err != nil {
-			fatal("Failed to write HTML output: %v", err)
+			fatalCode(exitWrite, "Failed to write HTML output: %v", err)
 		}
 		fmt.Fprintf(os.Stderr, "HTML chart written to %s\n", *htmlOutput)
 	}
 
+	logAPIUsage()
 	fmt.Fprintf(os.Stderr, "Done.\n")
 
-	// Start local server (blocks forever)
+	// Start local server (blocks until interrupted)
 	if *serve {
-		serveHTML(*htmlOutput, *servePort)
+		serveHTML(*htmlOutput, *servePort, *maxClients)
 	}
 }
 
@@ -341,28 +1795,45 @@ func parseRepo(s string) (string, string) {
 	return parts[0], parts[1]
 }
 
-func detectRepo() (string, string) {
-	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+// detectRepo reads owner/repo from the origin remote of the clone in dir
+// (the working directory if dir is empty).
+func detectRepo(dir string) (string, string) {
+	out, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
 	if err != nil {
 		return "", ""
 	}
-	url := strings.TrimSpace(string(out))
-	// Handle SSH URLs: git@github.com:owner/repo.git
-	if strings.HasPrefix(url, "git@") {
-		url = strings.TrimPrefix(url, "git@github.com:")
-		url = strings.TrimSuffix(url, ".git")
-		parts := strings.SplitN(url, "/", 2)
-		if len(parts) == 2 {
-			return parts[0], parts[1]
+	return parseRemoteURL(strings.TrimSpace(string(out)))
+}
+
+// parseRemoteURL reads owner/repo from a github.com remote in any of git's
+// URL forms: https://[user@]github.com/owner/repo, ssh://git@github.com/owner/repo,
+// or scp-like git@github.com:owner/repo, each with or without .git.
+func parseRemoteURL(remote string) (string, string) {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		if u.Hostname() != "github.com" {
+			return "", ""
 		}
+		return parseRepo(strings.TrimPrefix(u.Path, "/"))
+	}
+	// scp-like syntax: [user@]github.com:owner/repo
+	hostPart, path, ok := strings.Cut(remote, ":")
+	if !ok {
+		return "", ""
+	}
+	if _, host, found := strings.Cut(hostPart, "@"); found {
+		hostPart = host
+	}
+	if hostPart != "github.com" {
 		return "", ""
 	}
-	return parseRepo(url)
+	return parseRepo(path)
 }
 
 type weekRange struct {
 	start time.Time
 	end   time.Time
+	name  string // sprint name with --granularity sprint; "" for weeks and months
 }
 
 func computeWeekRanges(now time.Time, weeks int) []weekRange {
@@ -382,6 +1853,5 @@ func computeWeekRanges(now time.Time, weeks int) []weekRange {
 }
 
 func fatal(format string, args ...any) {
-	fmt.Fprintf(os.Stderr, "ERROR: "+format+"\n", args...)
-	os.Exit(1)
+	fatalCode(exitError, format, args...)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mdBlock is one block of the Markdown this tool writes (see
+// formatIssueSummary), parsed for publishers that need another format.
+type mdBlock struct {
+	kind     string     // "heading", "paragraph", "bullets", "table", or "details"
+	level    int        // heading level
+	text     string     // heading or paragraph text, or details summary
+	items    []string   // bullets
+	rows     [][]string // table cells, the first row the header
+	children []mdBlock  // details body
+}
+
+var (
+	mdRuleRe   = regexp.MustCompile(`^\|(\s*:?-+:?\s*\|)+$`)
+	mdDetailRe = regexp.MustCompile(`^<details><summary>(.*)</summary>$`)
+)
+
+// parseMarkdown splits Markdown into headings, paragraphs, bullet lists,
+// pipe tables, and <details> blocks. HTML comments are dropped. It is not a
+// general Markdown parser: it handles what formatIssueSummary writes.
+func parseMarkdown(md string) []mdBlock {
+	var stack [][]mdBlock // enclosing levels of <details>
+	var blocks []mdBlock
+	last := func(kind string) *mdBlock {
+		if n := len(blocks); n > 0 && blocks[n-1].kind == kind {
+			return &blocks[n-1]
+		}
+		return nil
+	}
+
+	for _, line := range strings.Split(md, "\n") {
+		line = strings.TrimRight(line, " ")
+		switch {
+		case line == "" || strings.HasPrefix(line, "<!--") || mdRuleRe.MatchString(line):
+		case mdDetailRe.MatchString(line):
+			blocks = append(blocks, mdBlock{kind: "details", text: mdDetailRe.FindStringSubmatch(line)[1]})
+			stack = append(stack, blocks)
+			blocks = nil
+		case line == "</details>" && len(stack) > 0:
+			body := blocks
+			blocks = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			blocks[len(blocks)-1].children = body
+		case strings.HasPrefix(line, "#"):
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			blocks = append(blocks, mdBlock{kind: "heading", level: level, text: strings.TrimSpace(line[level:])})
+		case strings.HasPrefix(line, "- "):
+			if b := last("bullets"); b != nil {
+				b.items = append(b.items, line[2:])
+			} else {
+				blocks = append(blocks, mdBlock{kind: "bullets", items: []string{line[2:]}})
+			}
+		case strings.HasPrefix(line, "|"):
+			var cells []string
+			for _, c := range strings.Split(strings.Trim(line, "|"), "|") {
+				cells = append(cells, strings.TrimSpace(c))
+			}
+			if b := last("table"); b != nil {
+				b.rows = append(b.rows, cells)
+			} else {
+				blocks = append(blocks, mdBlock{kind: "table", rows: [][]string{cells}})
+			}
+		default:
+			blocks = append(blocks, mdBlock{kind: "paragraph", text: line})
+		}
+	}
+	// An unclosed <details> keeps its body
+	for len(stack) > 0 {
+		body := blocks
+		blocks = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		blocks[len(blocks)-1].children = body
+	}
+	return blocks
+}
+
+// mdSpan is a run of inline text with its formatting.
+type mdSpan struct {
+	text       string
+	bold, code bool
+}
+
+// parseInline splits text into plain, **bold**, and `code` spans. An
+// unclosed marker is kept as text.
+func parseInline(s string) []mdSpan {
+	var spans []mdSpan
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, mdSpan{text: plain.String()})
+			plain.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "**") {
+			if end := strings.Index(s[i+2:], "**"); end > 0 {
+				flush()
+				spans = append(spans, mdSpan{text: s[i+2 : i+2+end], bold: true})
+				i += end + 4
+				continue
+			}
+		}
+		if s[i] == '`' {
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				flush()
+				spans = append(spans, mdSpan{text: s[i+1 : i+1+end], code: true})
+				i += end + 2
+				continue
+			}
+		}
+		plain.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return spans
+}
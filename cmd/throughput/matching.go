@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// matchingResult is the --ona-matching analysis: Ona-involved PRs paired
+// with similar other PRs by propensity score, and the outcomes compared on
+// the matched sample only.
+type matchingResult struct {
+	onaPRs        int     // Ona-involved PRs considered
+	matched       int     // pairs formed within the caliper
+	sameAuthorPct float64 // pairs where both PRs have the same author
+	sameAreaPct   float64 // pairs where both PRs mostly touch the same top-level directory
+	balance       []covariateBalance
+	comparisons   []outcomeComparison
+}
+
+// covariateBalance is a covariate's standardized mean difference between
+// Ona-involved and other PRs before and after matching. |SMD| < 0.1 is the
+// usual threshold for a well-balanced covariate.
+type covariateBalance struct {
+	name   string
+	before float64
+	after  float64
+}
+
+// matchingCaliper is the maximum logit-propensity distance between paired
+// PRs, in standard deviations of the logit (Austin, 2011).
+const matchingCaliper = 0.2
+
+// matchOnaPRs estimates each PR's propensity to be Ona-involved from its size
+// (log lines changed and files changed), merge time, author, and file area,
+// then greedily pairs each Ona-involved PR with the nearest unused other PR
+// within the caliper (1:1, without replacement). Returns nil if there are no
+// PRs in one of the groups or, with minAuthors > 1, if a matched group has
+// fewer distinct authors.
+func matchOnaPRs(prs []enrichedPR, minAuthors int) *matchingResult {
+	var onaIdx, otherIdx []int
+	for i, pr := range prs {
+		if pr.onaInvolved {
+			onaIdx = append(onaIdx, i)
+		} else {
+			otherIdx = append(otherIdx, i)
+		}
+	}
+	if len(onaIdx) == 0 || len(otherIdx) == 0 {
+		return nil
+	}
+
+	x := propensityFeatures(prs)
+	y := make([]float64, len(prs))
+	for _, i := range onaIdx {
+		y[i] = 1
+	}
+	beta := fitLogistic(x, y, 1.0)
+	logit := make([]float64, len(prs))
+	for i, row := range x {
+		logit[i] = dot(row, beta)
+	}
+	caliper := matchingCaliper * math.Sqrt(variance(logit))
+
+	// Match the Ona PRs that are hardest to match (highest propensity) first
+	sort.Slice(onaIdx, func(a, b int) bool { return logit[onaIdx[a]] > logit[onaIdx[b]] })
+	used := make([]bool, len(prs))
+	var onaMatched, otherMatched []enrichedPR
+	var sameAuthor, sameArea int
+	for _, i := range onaIdx {
+		best, bestDist := -1, caliper
+		for _, j := range otherIdx {
+			if d := math.Abs(logit[i] - logit[j]); !used[j] && d <= bestDist {
+				best, bestDist = j, d
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[best] = true
+		onaMatched = append(onaMatched, prs[i])
+		otherMatched = append(otherMatched, prs[best])
+		if prs[i].authorLogin == prs[best].authorLogin {
+			sameAuthor++
+		}
+		if prs[i].fileArea == prs[best].fileArea {
+			sameArea++
+		}
+	}
+
+	res := &matchingResult{onaPRs: len(onaIdx), matched: len(onaMatched)}
+	if res.matched == 0 {
+		return res
+	}
+	if minAuthors > 1 && (distinctAuthors(onaMatched) < minAuthors || distinctAuthors(otherMatched) < minAuthors) {
+		return nil
+	}
+	res.sameAuthorPct = float64(sameAuthor) / float64(res.matched) * 100
+	res.sameAreaPct = float64(sameArea) / float64(res.matched) * 100
+
+	var onaAll, otherAll []enrichedPR
+	for _, i := range onaIdx {
+		onaAll = append(onaAll, prs[i])
+	}
+	for _, j := range otherIdx {
+		otherAll = append(otherAll, prs[j])
+	}
+	for _, c := range balanceCovariates {
+		res.balance = append(res.balance, covariateBalance{
+			name:   c.name,
+			before: standardizedMeanDiff(c.value, onaAll, otherAll),
+			after:  standardizedMeanDiff(c.value, onaMatched, otherMatched),
+		})
+	}
+	res.comparisons = compareOutcomes(onaMatched, otherMatched)
+	return res
+}
+
+// balanceCovariates are the numeric covariates reported in the balance check.
+var balanceCovariates = []struct {
+	name  string
+	value func(pr enrichedPR) float64
+}{
+	{"log2 lines changed", func(pr enrichedPR) float64 { return sizePoints(pr) }},
+	{"log2 files changed", func(pr enrichedPR) float64 { return math.Log2(1 + float64(pr.changedFiles)) }},
+	{"merge week", func(pr enrichedPR) float64 { return float64(pr.mergedEpoch) / (7 * 86400) }},
+}
+
+// standardizedMeanDiff is (mean(a) - mean(b)) / pooled standard deviation.
+func standardizedMeanDiff(value func(enrichedPR) float64, a, b []enrichedPR) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	va, vb := make([]float64, len(a)), make([]float64, len(b))
+	for i, pr := range a {
+		va[i] = value(pr)
+	}
+	for i, pr := range b {
+		vb[i] = value(pr)
+	}
+	sd := math.Sqrt((variance(va) + variance(vb)) / 2)
+	if sd == 0 {
+		return 0
+	}
+	return (mean(va) - mean(vb)) / sd
+}
+
+// propensityFeatures builds the design matrix: an intercept, the
+// standardized balance covariates, and one-hot author and file-area columns.
+func propensityFeatures(prs []enrichedPR) [][]float64 {
+	var cols [][]float64
+	for _, c := range balanceCovariates {
+		col := make([]float64, len(prs))
+		for i, pr := range prs {
+			col[i] = c.value(pr)
+		}
+		m, sd := mean(col), math.Sqrt(variance(col))
+		for i := range col {
+			if sd > 0 {
+				col[i] = (col[i] - m) / sd
+			} else {
+				col[i] = 0
+			}
+		}
+		cols = append(cols, col)
+	}
+	for _, key := range []func(enrichedPR) string{
+		func(pr enrichedPR) string { return pr.authorLogin },
+		func(pr enrichedPR) string { return pr.fileArea },
+	} {
+		levels := make(map[string]int)
+		for _, pr := range prs {
+			if _, ok := levels[key(pr)]; !ok {
+				levels[key(pr)] = len(levels)
+			}
+		}
+		oneHot := make([][]float64, len(levels))
+		for l := range oneHot {
+			oneHot[l] = make([]float64, len(prs))
+		}
+		for i, pr := range prs {
+			oneHot[levels[key(pr)]][i] = 1
+		}
+		cols = append(cols, oneHot...)
+	}
+
+	x := make([][]float64, len(prs))
+	for i := range prs {
+		row := make([]float64, 1+len(cols))
+		row[0] = 1
+		for c, col := range cols {
+			row[1+c] = col[i]
+		}
+		x[i] = row
+	}
+	return x
+}
+
+// fitLogistic fits a ridge-penalized logistic regression by Newton-Raphson.
+// The penalty (not applied to the intercept) keeps the fit finite when an
+// author or area only has Ona-involved or only other PRs.
+func fitLogistic(x [][]float64, y []float64, lambda float64) []float64 {
+	p := len(x[0])
+	beta := make([]float64, p)
+	for iter := 0; iter < 25; iter++ {
+		grad := make([]float64, p)
+		hess := make([][]float64, p)
+		for j := range hess {
+			hess[j] = make([]float64, p)
+		}
+		for i, row := range x {
+			mu := 1 / (1 + math.Exp(-dot(row, beta)))
+			w := mu * (1 - mu)
+			for j, xj := range row {
+				if xj == 0 {
+					continue
+				}
+				grad[j] += (y[i] - mu) * xj
+				for k, xk := range row {
+					hess[j][k] += w * xj * xk
+				}
+			}
+		}
+		for j := 1; j < p; j++ {
+			grad[j] -= lambda * beta[j]
+			hess[j][j] += lambda
+		}
+		step, ok := solveLinear(hess, grad)
+		if !ok {
+			break
+		}
+		var maxStep float64
+		for j := range beta {
+			beta[j] += step[j]
+			maxStep = math.Max(maxStep, math.Abs(step[j]))
+		}
+		if maxStep < 1e-6 {
+			break
+		}
+	}
+	return beta
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// solveLinear solves a·x = b by Gaussian elimination with partial pivoting.
+// a and b are overwritten. ok is false if a is singular.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			if f == 0 {
+				continue
+			}
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for c := r + 1; c < n; c++ {
+			s -= a[r][c] * x[c]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, true
+}
+
+// fileArea returns the top-level directory most of a PR's changed files are
+// in ("/" for files at the repository root), or "" without file data. Ties
+// go to the alphabetically first directory.
+func fileArea(pr PR) string {
+	counts := make(map[string]int)
+	for _, f := range pr.Files.Nodes {
+		dir, _, found := strings.Cut(f.Path, "/")
+		if !found {
+			dir = "/"
+		}
+		counts[dir]++
+	}
+	var area string
+	for dir, n := range counts {
+		if n > counts[area] || (n == counts[area] && dir < area) {
+			area = dir
+		}
+	}
+	return area
+}
+
+// logMatching prints the matched-sample summary and balance to stderr.
+func logMatching(res *matchingResult) {
+	fmt.Fprintf(os.Stderr, "  Matched %d of %d Ona-involved PRs (%.0f%% same author, %.0f%% same area)\n",
+		res.matched, res.onaPRs, res.sameAuthorPct, res.sameAreaPct)
+	for _, b := range res.balance {
+		fmt.Fprintf(os.Stderr, "  Balance %s: SMD %+.2f before, %+.2f after\n", b.name, b.before, b.after)
+	}
+	logOutcomeComparisons(res.comparisons)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"math"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
@@ -11,45 +13,141 @@ import (
 var onaCoauthorRe = regexp.MustCompile(`(?i)Co-authored-by:.*[Oo]na.*@ona\.com`)
 var revertRe = regexp.MustCompile(`(?i)\b(revert|reverting|rollback|roll\s+back|rolled\s+back)\b`)
 
-// enrichedPR holds a PR with computed metrics.
-type enrichedPR struct {
-	mergedEpoch          int64
-	codingTimeHours      float64 // first commit to ready-for-review; -1 means not available
-	reviewTimeHours      float64 // ready-for-review to merged; -1 means not available
-	reviewTurnaround     float64 // PR created to first review submitted; -1 means not available
-	additions            int
-	deletions            int
-	changedFiles         int
-	number               int
-	authorLogin          string
-	onaInvolved          bool
-	isRevert             bool
+// revertBodyRe matches the body GitHub generates for its "Revert" button.
+var revertBodyRe = regexp.MustCompile(`(?m)^Reverts [\w.-]+/[\w.-]+#[0-9]+`)
+
+// revertCommitRe matches the message git writes for `git revert`.
+var revertCommitRe = regexp.MustCompile(`(?m)^This reverts commit [0-9a-f]{7,40}`)
+
+// revertLabels is the lower-cased set of PR labels that mark a revert (--revert-labels).
+var revertLabels = map[string]bool{"revert": true, "rollback": true}
+
+// revertBaseMinLines and revertBaseExclude narrow the pct_reverts denominator
+// to non-trivial PRs (--revert-min-lines, --revert-exclude-titles), so a
+// stream of tiny automated or chore PRs doesn't dilute the revert rate.
+var (
+	revertBaseMinLines int
+	revertBaseExclude  *regexp.Regexp
+)
+
+// inRevertBase reports whether a PR counts toward the pct_reverts
+// denominator. Reverts always count: reverting a small change makes a small
+// PR, and leaving it out would hide the revert.
+func inRevertBase(pr enrichedPR) bool {
+	if pr.isRevert {
+		return true
+	}
+	if pr.additions+pr.deletions < revertBaseMinLines {
+		return false
+	}
+	return revertBaseExclude == nil || !revertBaseExclude.MatchString(pr.title)
 }
 
-// filterPRs filters out bots and excluded users, computes metrics.
-func filterPRs(prs []PR, excludeSet map[string]bool) []enrichedPR {
-	var result []enrichedPR
+// revertBaseNote describes the pct_reverts denominator for the filter notes
+// and the glossary; empty when every PR counts.
+func revertBaseNote() string {
+	var rules []string
+	if revertBaseMinLines > 0 {
+		rules = append(rules, fmt.Sprintf("PRs under %d lines changed (--revert-min-lines)", revertBaseMinLines))
+	}
+	if revertBaseExclude != nil {
+		rules = append(rules, fmt.Sprintf("PRs with titles matching %s (--revert-exclude-titles)", revertBaseExclude))
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "% Reverts is computed against non-trivial PRs only, leaving out " + strings.Join(rules, " and ") + "; reverts themselves always count"
+}
 
-	for _, pr := range prs {
-		// Skip bots
-		if pr.Author.Typename == "Bot" {
-			continue
+// detectRevert reports which signal, if any, marks a PR as a revert. Signals
+// are checked from most to least explicit: "label", "body" (GitHub-generated
+// revert PR), "commit" (git revert message), then "title" (keyword regex).
+func detectRevert(pr PR) string {
+	for _, l := range pr.Labels.Nodes {
+		if revertLabels[strings.ToLower(l.Name)] {
+			return "label"
 		}
-
-		// Skip excluded users (case-insensitive)
-		login := strings.ToLower(pr.Author.Login)
-		if excludeSet[login] {
-			continue
+	}
+	if revertBodyRe.MatchString(pr.Body) {
+		return "body"
+	}
+	for _, cn := range pr.Commits.Nodes {
+		if revertCommitRe.MatchString(cn.Commit.Message) {
+			return "commit"
 		}
+	}
+	if revertRe.MatchString(pr.Title) {
+		return "title"
+	}
+	return ""
+}
+
+// enrichedPR holds a PR with computed metrics.
+type enrichedPR struct {
+	mergedEpoch        int64
+	codingTimeHours    float64 // first commit to ready-for-review; -1 means not available
+	reviewTimeHours    float64 // ready-for-review to merged; -1 means not available
+	reviewTurnaround   float64 // PR created to first review submitted; -1 means not available
+	additions          int
+	deletions          int
+	changedFiles       int
+	number             int
+	title              string
+	branch             string
+	authorLogin        string
+	mergerLogin        string // who merged it; empty if unknown, a bot, or excluded
+	onaInvolved        bool
+	isRevert           bool
+	revertSignal       string             // which detectRevert signal fired; empty if not a revert
+	firstCommitMethod  string             // how the coding-time start was found: "commits", "force_push", or "" if unavailable
+	issueKey           string             // linked Jira/Linear issue key, if any
+	issueType          string             // Jira issue type (e.g. "Story", "Bug"); empty if not linked
+	issueProject       string             // Linear project name; empty if not linked
+	issueLeadTimeHours float64            // issue started (Jira "In Progress" / Linear startedAt) to merged; -1 means not available
+	reviewsEnriched    bool               // review counts below are from --enrich-reviews; all 0 without it
+	reviewCount        int                // submitted reviews
+	changesRequested   int                // reviews requesting changes
+	reviewThreads      int                // review comment threads
+	unresolvedThreads  int                // threads still open at fetch time
+	descriptionLength  int                // description characters, excluding whitespace and template comments
+	closesIssues       bool               // GitHub closing issue reference
+	sections           map[string]string  // description sections by normalized heading, for --template-compliance
+	touchesTests       bool               // at least one changed file matches isTestPath
+	reviewStatesKnown  bool               // PR.ReviewStates was fetched; false with --local-git and old cache entries
+	approvedByOther    bool               // an approving review from someone other than the author
+	reviewedByOther    bool               // any review from someone other than the author
+	fileArea           string             // top-level directory most changed files are in
+	ciRuns             int                // pull_request workflow runs (--ona-comparison); 0 if unknown
+	ciFailures         int                // of those, runs that failed
+	commitEpochs       []int64            // authored times of the fetched commits
+	commitCount        int                // total commits on the PR, including ones not fetched
+	reopenCount        int                // times the PR was closed and reopened before merging
+	closedHours        float64            // total time closed before those reopens, left out of the cycle times
+	reviewResponses    []reviewResponse   // per-reviewer request-to-first-review times from --enrich-reviews
+	lifecycle          []lifecycleStep    // state path from --enrich-reviews (--lifecycle); nil without it
+	custom             map[string]float64 // RegisterMetric values by name; absent if the extractor skipped this PR
+}
 
-		// Skip PRs without mergedAt
-		if pr.MergedAt.IsZero() {
-			continue
+// skipPR reports whether one of basePRFilters drops a PR.
+func skipPR(pr PR, excludeSet map[string]bool) bool {
+	for _, f := range basePRFilters(excludeSet, false) {
+		if f.drop(pr) {
+			return true
 		}
+	}
+	return false
+}
+
+// filterPRs runs the PRs through basePRFilters, recording the removals in
+// audit, and computes metrics for the rest.
+func filterPRs(prs []PR, excludeSet map[string]bool, audit *filterAudit) []enrichedPR {
+	var result []enrichedPR
 
-		// Skip draft PRs (matching GetDX behavior)
-		if pr.IsDraft {
-			continue
+	for _, pr := range audit.applyPRFilters(prs, basePRFilters(excludeSet, audit.redact)) {
+		login := strings.ToLower(pr.Author.Login)
+		merger := strings.ToLower(pr.MergedBy.Login)
+		if pr.MergedBy.Typename == "Bot" || strings.HasSuffix(merger, "[bot]") || excludeSet[merger] {
+			merger = ""
 		}
 
 		mergedEpoch := pr.MergedAt.Unix()
@@ -65,16 +163,20 @@ func filterPRs(prs []PR, excludeSet map[string]bool) []enrichedPR {
 			readyForReviewEpoch = pr.TimelineItems.Nodes[0].CreatedAt.Unix()
 		}
 
+		// Time spent closed before a reopen counts towards neither
+		// coding, review, nor turnaround time
+		closed := closedIntervals(pr)
+
 		// Coding time: earliest commit → ready-for-review.
 		// Review time: ready-for-review → merged.
 		// Both only available for PRs with a ReadyForReviewEvent.
 		codingHours := -1.0
 		reviewTimeHours := -1.0
+		var firstCommitMethod string
 		if hasReadyEvent {
 			// Review time: ready-for-review to merged
 			if mergedEpoch >= readyForReviewEpoch {
-				reviewTimeHours = float64(mergedEpoch-readyForReviewEpoch) / 3600.0
-				reviewTimeHours = math.Round(reviewTimeHours*100) / 100
+				reviewTimeHours = activeHours(readyForReviewEpoch, mergedEpoch, closed)
 			}
 
 			// Coding time: earliest commit to ready-for-review
@@ -86,11 +188,21 @@ func filterPRs(prs []PR, excludeSet map[string]bool) []enrichedPR {
 						earliest = ad
 					}
 				}
+				firstCommitMethod = "commits"
+				// A rebase or reset-author rewrite can move every current
+				// commit's authoredDate forward; the head before the first
+				// force push still carries the original date.
+				if fp := pr.ForcePushes.Nodes; len(fp) > 0 && fp[0].BeforeCommit != nil {
+					ad := fp[0].BeforeCommit.AuthoredDate
+					if !ad.IsZero() && (earliest.IsZero() || ad.Before(earliest)) {
+						earliest = ad
+						firstCommitMethod = "force_push"
+					}
+				}
 				if !earliest.IsZero() {
 					fcEpoch := earliest.Unix()
 					if readyForReviewEpoch >= fcEpoch {
-						codingHours = float64(readyForReviewEpoch-fcEpoch) / 3600.0
-						codingHours = math.Round(codingHours*100) / 100
+						codingHours = activeHours(fcEpoch, readyForReviewEpoch, closed)
 					} else {
 						// Earliest commit postdates ready event (shouldn't happen, but clamp)
 						codingHours = 0
@@ -104,8 +216,7 @@ func filterPRs(prs []PR, excludeSet map[string]bool) []enrichedPR {
 		if len(pr.Reviews.Nodes) > 0 && pr.Reviews.Nodes[0].SubmittedAt != nil {
 			revEpoch := pr.Reviews.Nodes[0].SubmittedAt.Unix()
 			if revEpoch >= createdEpoch {
-				reviewTurnaroundHours = float64(revEpoch-createdEpoch) / 3600.0
-				reviewTurnaroundHours = math.Round(reviewTurnaroundHours*100) / 100
+				reviewTurnaroundHours = activeHours(createdEpoch, revEpoch, closed)
 			}
 		}
 
@@ -120,21 +231,81 @@ func filterPRs(prs []PR, excludeSet map[string]bool) []enrichedPR {
 			}
 		}
 
-		isRevert := revertRe.MatchString(pr.Title)
+		revertSignal := detectRevert(pr)
 
-		result = append(result, enrichedPR{
-			mergedEpoch:      mergedEpoch,
-			codingTimeHours:  codingHours,
-			reviewTimeHours:  reviewTimeHours,
-			reviewTurnaround: reviewTurnaroundHours,
-			additions:        pr.Additions,
-			deletions:        pr.Deletions,
-			changedFiles:     pr.ChangedFiles,
-			number:           pr.Number,
-			authorLogin:      login,
-			onaInvolved:      onaInvolved,
-			isRevert:         isRevert,
-		})
+		epr := enrichedPR{
+			mergedEpoch:        mergedEpoch,
+			codingTimeHours:    codingHours,
+			reviewTimeHours:    reviewTimeHours,
+			reviewTurnaround:   reviewTurnaroundHours,
+			additions:          pr.Additions,
+			deletions:          pr.Deletions,
+			changedFiles:       pr.ChangedFiles,
+			number:             pr.Number,
+			title:              pr.Title,
+			branch:             pr.HeadRefName,
+			authorLogin:        login,
+			mergerLogin:        merger,
+			onaInvolved:        onaInvolved,
+			isRevert:           revertSignal != "",
+			revertSignal:       revertSignal,
+			firstCommitMethod:  firstCommitMethod,
+			issueLeadTimeHours: -1,
+			descriptionLength:  descriptionLength(pr.Body),
+			closesIssues:       pr.ClosingIssuesReferences.TotalCount > 0,
+			sections:           markdownSections(pr.Body),
+			fileArea:           fileArea(pr),
+			custom:             extractCustomMetrics(pr),
+			reopenCount:        len(closed),
+			commitCount:        pr.Commits.TotalCount,
+		}
+		for _, c := range closed {
+			epr.closedHours += float64(c.to-c.from) / 3600.0
+		}
+		for _, cn := range pr.Commits.Nodes {
+			if !cn.Commit.AuthoredDate.IsZero() {
+				epr.commitEpochs = append(epr.commitEpochs, cn.Commit.AuthoredDate.Unix())
+			}
+		}
+		for _, f := range pr.Files.Nodes {
+			if isTestPath(f.Path) {
+				epr.touchesTests = true
+				break
+			}
+		}
+		if rs := pr.ReviewStates; rs != nil {
+			epr.reviewStatesKnown = true
+			for _, r := range rs.Nodes {
+				if reviewer := strings.ToLower(r.Author.Login); reviewer != "" && reviewer == login {
+					continue
+				}
+				epr.reviewedByOther = true
+				if r.State == "APPROVED" {
+					epr.approvedByOther = true
+				}
+			}
+		}
+		if d := pr.ReviewDetails; d != nil {
+			epr.reviewsEnriched = true
+			for _, r := range d.Reviews {
+				if r.SubmittedAt == nil {
+					continue // pending
+				}
+				epr.reviewCount++
+				if r.State == "CHANGES_REQUESTED" {
+					epr.changesRequested++
+				}
+			}
+			epr.reviewThreads = len(d.Threads)
+			for _, t := range d.Threads {
+				if !t.IsResolved {
+					epr.unresolvedThreads++
+				}
+			}
+			epr.reviewResponses = reviewResponses(d, excludeSet)
+			epr.lifecycle = prLifecycle(pr, excludeSet)
+		}
+		result = append(result, epr)
 	}
 
 	return result
@@ -170,6 +341,13 @@ func percentile(values []float64, pct float64) float64 {
 	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
 }
 
+// sizePoints scores a PR by lines changed on a log scale: log2(1 + additions + deletions).
+// A 1-line fix is worth 1 point and a 1,000-line change about 10, so size
+// still counts but a single huge PR can't outweigh dozens of small ones.
+func sizePoints(pr enrichedPR) float64 {
+	return math.Log2(1 + float64(pr.additions+pr.deletions))
+}
+
 func median(values []float64) float64 {
 	return percentile(values, 50)
 }
@@ -177,3 +355,33 @@ func median(values []float64) float64 {
 func p90(values []float64) float64 {
 	return percentile(values, 90)
 }
+
+// logRevertSignals prints how many reverts each detection signal found.
+func logRevertSignals(prs []enrichedPR) {
+	counts := make(map[string]int)
+	for _, pr := range prs {
+		if pr.revertSignal != "" {
+			counts[pr.revertSignal]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Reverts detected: %d by label, %d by GitHub revert body, %d by revert commit, %d by title\n",
+		counts["label"], counts["body"], counts["commit"], counts["title"])
+}
+
+// logRevertBase prints how many PRs the --revert-min-lines and
+// --revert-exclude-titles rules leave in the pct_reverts denominator.
+func logRevertBase(prs []enrichedPR) {
+	if revertBaseNote() == "" {
+		return
+	}
+	var base int
+	for _, pr := range prs {
+		if inRevertBase(pr) {
+			base++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Revert rate base: %d of %d PRs (%d trivial PRs left out)\n", base, len(prs), len(prs)-base)
+}
@@ -0,0 +1,56 @@
+package main
+
+import "slices"
+
+// suppressSmallWeeks enforces --min-group-size on weekly stats: any week whose
+// merged PRs come from fewer than k distinct authors is marked suppressed,
+// which blanks its engineer-derived (metricDef.engineer) CSV cells, and has
+// its stats reset to "no data", so it is skipped by the stats analysis and
+// drawn as empty in the chart. Rolling active-engineer windows with fewer
+// than k engineers are set to -1 the same way. Returns the number of
+// suppressed weeks.
+func suppressSmallWeeks(stats []weekStats, k int) int {
+	if k <= 1 {
+		return 0
+	}
+	var suppressed int
+	for i := range stats {
+		ws := &stats[i]
+		if ws.retentionTracked && ws.activeEngineers4w >= 0 && ws.activeEngineers4w < k {
+			ws.activeEngineers4w = -1
+			ws.churnedEngineers = -1
+		}
+		if ws.uniqueAuthors == 0 || ws.uniqueAuthors >= k {
+			continue
+		}
+		suppressed++
+		ws.suppressed = true
+		ws.prsMerged = 0
+		ws.uniqueAuthors = 0
+		ws.prsPerEngineer = 0
+		ws.sizePointsPerEngineer = 0
+		ws.medianCodingTime = -1
+		ws.medianReviewTime = -1
+		ws.medianReviewResponse = -1
+		ws.reviewRequests = 0
+		ws.unansweredRequests = 0
+		ws.pctOnaInvolved = 0
+		ws.pctOnaLines = -1
+		ws.pctReverts = 0
+		ws.pctDescribed = 0
+		ws.pctLinkedIssue = 0
+		ws.pctWithTests = 0
+		ws.reopenedCount = 0
+		ws.pctApproved, ws.pctUnreviewed = -1, -1
+		ws.pctTemplateCompliant = -1
+		ws.securityPRs, ws.medianSecurityLead = 0, -1
+		if ws.sizeBucketsTracked {
+			ws.prsBySize = make([]int, len(sizeBuckets))
+			ws.reviewTimeBySize = slices.Repeat([]float64{-1}, len(sizeBuckets))
+		}
+		ws.customValues = nil
+		aggregateCustomMetrics(ws)
+		computeDerivedMetrics(ws)
+	}
+	return suppressed
+}
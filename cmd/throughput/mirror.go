@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// mirrorCommitFormat separates commits with RS and fields with US, like
+// gitLogFormat.
+const mirrorCommitFormat = "%x1e%aI%x1f%B"
+
+// checkMirror fails fast when --mirror isn't a git repository.
+func checkMirror(dir string) error {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--git-dir").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s is not a git repository: %s", dir, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// mirrorCommits fills the commit list of each PR from the --mirror clone,
+// replacing the API's capped first page. A PR's commits are those reachable
+// from its head but not from the merge commit's first parent, which holds
+// for merge, squash, and rebase merges alike. That needs the head commit in
+// the clone: `git clone --mirror` fetches GitHub's refs/pull/*/head, a plain
+// clone only has heads of branches that still exist.
+//
+// PRs that can't be resolved (no merge commit, or objects missing from a
+// stale clone) keep an empty list for the API fallback. Returns how many PRs
+// were resolved and how many weren't.
+func mirrorCommits(cfg config, prs []PR) (resolved, missing int) {
+	var (
+		wg            sync.WaitGroup
+		sem           = make(chan struct{}, maxConcurrency)
+		resolvedCount atomic.Int64
+		missingCount  atomic.Int64
+	)
+	for i := range prs {
+		pr := &prs[i]
+		if pr.MergeCommit == nil || pr.HeadRefOid == "" {
+			missingCount.Add(1)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(pr *PR) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			nodes, err := mirrorPRCommits(cfg.mirrorDir, pr.MergeCommit.Oid, pr.HeadRefOid)
+			if err != nil || len(nodes) == 0 {
+				missingCount.Add(1)
+				return
+			}
+			pr.Commits.Nodes = nodes
+			pr.Commits.TotalCount = len(nodes)
+			resolvedCount.Add(1)
+		}(pr)
+	}
+	wg.Wait()
+
+	return int(resolvedCount.Load()), int(missingCount.Load())
+}
+
+// mirrorPRCommits lists the commits in mergeCommit^1..head, oldest first
+// like the API.
+func mirrorPRCommits(dir, mergeCommit, head string) ([]commitNode, error) {
+	out, err := exec.Command("git", "-C", dir, "log", "--reverse", "--format="+mirrorCommitFormat,
+		mergeCommit+"^1.."+head).Output()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []commitNode
+	for _, rec := range strings.Split(string(out), "\x1e") {
+		date, message, ok := strings.Cut(rec, "\x1f")
+		if !ok {
+			continue
+		}
+		authored, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return nil, fmt.Errorf("parse author date %q: %w", date, err)
+		}
+		var node commitNode
+		node.Commit.AuthoredDate = authored
+		node.Commit.Message = strings.TrimSpace(message)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// logMirror reports the local/API split of the commit data.
+func logMirror(resolved, missing int) {
+	fmt.Fprintf(os.Stderr, "Mirror: read commits for %d PR(s) from the local clone\n", resolved)
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "Mirror: %d PR(s) not found in the clone; fetching their commits from the API (run `git fetch` in the mirror to avoid this)\n", missing)
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// mixedOutcome is a per-PR outcome of the --ona-mixed-model regression. It is
+// modeled as log(1 + value), so the Ona coefficient reads as a ratio.
+type mixedOutcome struct {
+	name       string
+	label      string
+	adjustSize bool // add log lines changed as a covariate
+	value      func(pr enrichedPR) (float64, bool)
+}
+
+var mixedOutcomes = []mixedOutcome{
+	{
+		name: "cycle_time_hours", label: "Cycle time", adjustSize: true,
+		value: func(pr enrichedPR) (float64, bool) {
+			return pr.codingTimeHours + pr.reviewTimeHours, pr.codingTimeHours >= 0 && pr.reviewTimeHours >= 0
+		},
+	},
+	{
+		name: "coding_time_hours", label: "Coding time", adjustSize: true,
+		value: func(pr enrichedPR) (float64, bool) { return pr.codingTimeHours, pr.codingTimeHours >= 0 },
+	},
+	{
+		name: "review_time_hours", label: "Review time", adjustSize: true,
+		value: func(pr enrichedPR) (float64, bool) { return pr.reviewTimeHours, pr.reviewTimeHours >= 0 },
+	},
+	{
+		name: "size_lines", label: "Size (lines)",
+		value: func(pr enrichedPR) (float64, bool) { return float64(pr.additions + pr.deletions), true },
+	},
+}
+
+// mixedModelMinPRs is the fewest Ona-involved and other PRs an outcome needs
+// in each group to be fitted.
+const mixedModelMinPRs = 5
+
+// zCrit95 is the two-sided 95% quantile of the standard normal distribution.
+const zCrit95 = 1.959964
+
+// mixedCoef is one fixed effect of a mixed model fit, on the log scale.
+type mixedCoef struct {
+	name   string
+	coef   float64
+	se     float64
+	lo, hi float64 // 95% Wald interval
+	pValue float64
+}
+
+// mixedModelFit is one outcome's --ona-mixed-model fit: log(1 + outcome)
+// regressed on Ona involvement, merge time, and (for cycle times) log size,
+// with a random intercept per contributor, by REML.
+type mixedModelFit struct {
+	outcome      mixedOutcome
+	prs          int
+	onaPRs       int
+	contributors int
+	coefs        []mixedCoef // intercept first, then "ona", "years", and "log_size"
+	residualSD   float64
+	authorSD     float64 // SD of the contributor intercepts
+	icc          float64 // share of unexplained variance between contributors
+}
+
+// ona returns the Ona involvement coefficient.
+func (f mixedModelFit) ona() mixedCoef {
+	return f.coefs[1]
+}
+
+// fitOnaMixedModels fits every outcome with enough PRs. With minAuthors > 1,
+// outcomes whose Ona-involved or other PRs come from fewer distinct authors
+// are skipped (--min-group-size).
+func fitOnaMixedModels(prs []enrichedPR, minAuthors int) []mixedModelFit {
+	var fits []mixedModelFit
+	for _, o := range mixedOutcomes {
+		var ona, other []enrichedPR
+		for _, pr := range prs {
+			if _, ok := o.value(pr); !ok {
+				continue
+			}
+			if pr.onaInvolved {
+				ona = append(ona, pr)
+			} else {
+				other = append(other, pr)
+			}
+		}
+		if len(ona) < mixedModelMinPRs || len(other) < mixedModelMinPRs {
+			continue
+		}
+		if minAuthors > 1 && (distinctAuthors(ona) < minAuthors || distinctAuthors(other) < minAuthors) {
+			continue
+		}
+		if fit, ok := fitMixedOutcome(o, append(ona, other...)); ok {
+			fits = append(fits, fit)
+		}
+	}
+	return fits
+}
+
+// fitMixedOutcome builds the design matrix for one outcome and fits it.
+// Merge time (in years) and log size are centered so the intercept is the
+// typical PR.
+func fitMixedOutcome(o mixedOutcome, prs []enrichedPR) (mixedModelFit, bool) {
+	names := []string{"intercept", "ona", "years"}
+	if o.adjustSize {
+		names = append(names, "log_size")
+	}
+	var meanYears, meanSize float64
+	for _, pr := range prs {
+		meanYears += float64(pr.mergedEpoch) / (365.25 * 86400)
+		meanSize += math.Log1p(float64(pr.additions + pr.deletions))
+	}
+	meanYears /= float64(len(prs))
+	meanSize /= float64(len(prs))
+
+	groupIdx := make(map[string]int)
+	var groups []mixedGroup
+	var onaPRs int
+	for _, pr := range prs {
+		v, _ := o.value(pr)
+		x := []float64{1, boolValue(pr.onaInvolved), float64(pr.mergedEpoch)/(365.25*86400) - meanYears}
+		if o.adjustSize {
+			x = append(x, math.Log1p(float64(pr.additions+pr.deletions))-meanSize)
+		}
+		if pr.onaInvolved {
+			onaPRs++
+		}
+		i, ok := groupIdx[pr.authorLogin]
+		if !ok {
+			i = len(groups)
+			groupIdx[pr.authorLogin] = i
+			groups = append(groups, newMixedGroup(len(x)))
+		}
+		groups[i].add(x, math.Log1p(max(v, 0)))
+	}
+	if len(groups) < 2 {
+		return mixedModelFit{}, false
+	}
+
+	est, ok := fitRandomIntercept(groups, len(prs), len(names))
+	if !ok {
+		return mixedModelFit{}, false
+	}
+	fit := mixedModelFit{
+		outcome:      o,
+		prs:          len(prs),
+		onaPRs:       onaPRs,
+		contributors: len(groups),
+		residualSD:   math.Sqrt(est.sigma2),
+		authorSD:     math.Sqrt(est.lambda * est.sigma2),
+		icc:          est.lambda / (1 + est.lambda),
+	}
+	for i, name := range names {
+		se := math.Sqrt(est.cov[i][i])
+		c := mixedCoef{name: name, coef: est.beta[i], se: se, lo: est.beta[i] - zCrit95*se, hi: est.beta[i] + zCrit95*se, pValue: 1}
+		if se > 0 {
+			c.pValue = math.Erfc(math.Abs(est.beta[i]/se) / math.Sqrt2)
+		}
+		fit.coefs = append(fit.coefs, c)
+	}
+	return fit, true
+}
+
+// mixedGroup holds one contributor's sufficient statistics: X'X, X'y, y'y,
+// the column sums of X, the sum of y, and the PR count.
+type mixedGroup struct {
+	xtx  [][]float64
+	xty  []float64
+	yty  float64
+	xsum []float64
+	ysum float64
+	n    int
+}
+
+func newMixedGroup(p int) mixedGroup {
+	g := mixedGroup{xtx: make([][]float64, p), xty: make([]float64, p), xsum: make([]float64, p)}
+	for i := range g.xtx {
+		g.xtx[i] = make([]float64, p)
+	}
+	return g
+}
+
+func (g *mixedGroup) add(x []float64, y float64) {
+	for i := range x {
+		for j := range x {
+			g.xtx[i][j] += x[i] * x[j]
+		}
+		g.xty[i] += x[i] * y
+		g.xsum[i] += x[i]
+	}
+	g.yty += y * y
+	g.ysum += y
+	g.n++
+}
+
+// mixedEstimate is a random-intercept model fit: the fixed effects, their
+// covariance, the residual variance, and the ratio of the intercept variance
+// to it.
+type mixedEstimate struct {
+	beta   []float64
+	cov    [][]float64
+	sigma2 float64
+	lambda float64
+	reml   float64
+}
+
+// fitRandomIntercept fits y = Xβ + u[group] + ε by REML, profiling out β and
+// the residual variance and searching the variance ratio λ = σ²u/σ² on a log
+// grid refined by golden-section search. Within a group of n PRs, V⁻¹ is
+// (I - λ/(1+nλ)·11ᵀ)/σ², so each step only needs the per-group sums.
+func fitRandomIntercept(groups []mixedGroup, n, p int) (mixedEstimate, bool) {
+	if n <= p {
+		return mixedEstimate{}, false
+	}
+	const lo, hi, steps = -10.0, 5.0, 30
+	best, bestT := mixedEstimate{reml: math.Inf(-1)}, lo
+	for i := 0; i <= steps; i++ {
+		t := lo + (hi-lo)*float64(i)/steps
+		if est, ok := remlAt(groups, n, p, math.Exp(t)); ok && est.reml > best.reml {
+			best, bestT = est, t
+		}
+	}
+	if math.IsInf(best.reml, -1) {
+		return mixedEstimate{}, false
+	}
+
+	// Golden-section search between the best grid point's neighbors.
+	a, b := bestT-(hi-lo)/steps, bestT+(hi-lo)/steps
+	const phi = 0.6180339887498949
+	for range 40 {
+		c, d := b-phi*(b-a), a+phi*(b-a)
+		ec, okc := remlAt(groups, n, p, math.Exp(c))
+		ed, okd := remlAt(groups, n, p, math.Exp(d))
+		if !okc || !okd {
+			break
+		}
+		if ec.reml > ed.reml {
+			b = d
+			if ec.reml > best.reml {
+				best = ec
+			}
+		} else {
+			a = c
+			if ed.reml > best.reml {
+				best = ed
+			}
+		}
+	}
+	return best, true
+}
+
+// remlAt computes the GLS fixed effects and the REML log-likelihood (up to a
+// constant) at variance ratio lambda.
+func remlAt(groups []mixedGroup, n, p int, lambda float64) (mixedEstimate, bool) {
+	xtwx := make([][]float64, p)
+	for i := range xtwx {
+		xtwx[i] = make([]float64, p)
+	}
+	xtwy := make([]float64, p)
+	var ytwy, logDetV float64
+	for _, g := range groups {
+		gamma := lambda / (1 + float64(g.n)*lambda)
+		for i := 0; i < p; i++ {
+			for j := 0; j < p; j++ {
+				xtwx[i][j] += g.xtx[i][j] - gamma*g.xsum[i]*g.xsum[j]
+			}
+			xtwy[i] += g.xty[i] - gamma*g.xsum[i]*g.ysum
+		}
+		ytwy += g.yty - gamma*g.ysum*g.ysum
+		logDetV += math.Log1p(float64(g.n) * lambda)
+	}
+
+	l, ok := cholesky(xtwx)
+	if !ok {
+		return mixedEstimate{}, false
+	}
+	beta := choleskySolve(l, xtwy)
+	rss := ytwy - dot(beta, xtwy)
+	if rss <= 0 {
+		return mixedEstimate{}, false
+	}
+	sigma2 := rss / float64(n-p)
+	var logDetXtWX float64
+	for i := range l {
+		logDetXtWX += 2 * math.Log(l[i][i])
+	}
+
+	cov := make([][]float64, p)
+	for j := range cov {
+		cov[j] = make([]float64, p)
+	}
+	for j := 0; j < p; j++ {
+		e := make([]float64, p)
+		e[j] = 1
+		col := choleskySolve(l, e)
+		for i := range col {
+			cov[i][j] = col[i] * sigma2
+		}
+	}
+	return mixedEstimate{
+		beta:   beta,
+		cov:    cov,
+		sigma2: sigma2,
+		lambda: lambda,
+		reml:   -0.5 * (float64(n-p)*math.Log(sigma2) + logDetV + logDetXtWX),
+	}, true
+}
+
+// cholesky returns the lower-triangular L with L·Lᵀ = a. ok is false if a is
+// not positive definite.
+func cholesky(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 1e-12 {
+					return nil, false
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, true
+}
+
+// choleskySolve solves L·Lᵀ·x = b.
+func choleskySolve(l [][]float64, b []float64) []float64 {
+	n := len(b)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		s := b[i]
+		for k := 0; k < i; k++ {
+			s -= l[i][k] * y[k]
+		}
+		y[i] = s / l[i][i]
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := y[i]
+		for k := i + 1; k < n; k++ {
+			s -= l[k][i] * x[k]
+		}
+		x[i] = s / l[i][i]
+	}
+	return x
+}
+
+// ratioPct converts a log-scale coefficient to a percentage difference.
+func ratioPct(coef float64) float64 {
+	return (math.Exp(coef) - 1) * 100
+}
+
+// logMixedModels prints each fit's coefficients to stderr.
+func logMixedModels(fits []mixedModelFit) {
+	for _, f := range fits {
+		o := f.ona()
+		fmt.Fprintf(os.Stderr, "  %s: Ona %+.1f%% (95%% CI %+.1f%% to %+.1f%%), p=%.3f; %d PRs (%d Ona) by %d contributors\n",
+			f.outcome.name, ratioPct(o.coef), ratioPct(o.lo), ratioPct(o.hi), o.pValue, f.prs, f.onaPRs, f.contributors)
+		fmt.Fprintf(os.Stderr, "    contributor SD %.3f, residual SD %.3f (%.0f%% of variance between contributors)\n",
+			f.authorSD, f.residualSD, f.icc*100)
+		for _, c := range f.coefs {
+			fmt.Fprintf(os.Stderr, "    %-10s %+8.4f  SE %.4f  [%+.4f, %+.4f]  p=%.3f\n", c.name, c.coef, c.se, c.lo, c.hi, c.pValue)
+		}
+	}
+}
+
+// mixedModelRows formats the fits for the HTML report.
+func mixedModelRows(fits []mixedModelFit, loc reportLocale) []htmlMixedModel {
+	pct := func(v float64) string { return loc.localizeNumeric(fmt.Sprintf("%+.1f%%", v)) }
+	var rows []htmlMixedModel
+	for _, f := range fits {
+		o := f.ona()
+		rows = append(rows, htmlMixedModel{
+			Outcome:      loc.T(f.outcome.label),
+			Effect:       pct(ratioPct(o.coef)),
+			CI:           pct(ratioPct(o.lo)) + " … " + pct(ratioPct(o.hi)),
+			Coef:         loc.localizeNumeric(fmt.Sprintf("%+.3f", o.coef)) + " ± " + loc.number(o.se, 3),
+			PValue:       loc.number(o.pValue, 3),
+			Significant:  o.pValue < 0.05,
+			PRs:          f.prs,
+			OnaPRs:       f.onaPRs,
+			Contributors: f.contributors,
+			ICC:          loc.number(f.icc*100, 0) + "%",
+		})
+	}
+	return rows
+}
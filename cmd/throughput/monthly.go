@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"sort"
 	"time"
 )
@@ -11,9 +12,17 @@ type monthRange struct {
 	end   time.Time // last day of month
 }
 
+// periodGroup is a run of weeks rolled up into one chart period.
+type periodGroup struct {
+	start time.Time
+	end   time.Time
+	name  string // label for named periods (sprints); "" otherwise
+	weeks []int  // week indices
+}
+
 // monthlyStats aggregates weekly stats into calendar months.
-// PRs merged, unique authors, and revert counts are summed.
-// PRs/engineer, review speed, Ona involvement, and revert % use the median of weekly values.
+// PRs merged, unique authors, revert counts, reopened PRs, automation PRs, security fixes, releases, and PRs per size bucket are summed.
+// PRs/engineer, size points/engineer, review speed (overall and per size bucket), Ona involvement, and revert % use the median of weekly values.
 // Weeks with 0 PRs are excluded from median calculations.
 func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []weekStats) {
 	if len(weeks) == 0 {
@@ -21,14 +30,7 @@ func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []week
 	}
 
 	// Group week indices by calendar month (YYYY-MM)
-	type monthGroup struct {
-		month string
-		start time.Time
-		end   time.Time
-		weeks []int
-	}
-
-	groups := make(map[string]*monthGroup)
+	groups := make(map[string]*periodGroup)
 	var order []string
 
 	for i, wr := range weeks {
@@ -37,7 +39,7 @@ func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []week
 		if !ok {
 			firstOfMonth := time.Date(wr.start.Year(), wr.start.Month(), 1, 0, 0, 0, 0, time.UTC)
 			lastOfMonth := firstOfMonth.AddDate(0, 1, -1)
-			g = &monthGroup{month: key, start: firstOfMonth, end: lastOfMonth}
+			g = &periodGroup{start: firstOfMonth, end: lastOfMonth}
 			groups[key] = g
 			order = append(order, key)
 		}
@@ -60,25 +62,137 @@ func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []week
 		}
 	}
 
+	var months []periodGroup
+	for _, key := range order {
+		months = append(months, *groups[key])
+	}
+	return rollupWeeks(stats, months)
+}
+
+// rollupWeeks aggregates the weekly stats of each group into one period, as
+// described on aggregateMonthly. Months and sprints share it.
+func rollupWeeks(stats []weekStats, groups []periodGroup) ([]weekRange, []weekStats) {
 	var outRanges []weekRange
 	var outStats []weekStats
 
-	for _, key := range order {
-		g := groups[key]
-
+	for _, g := range groups {
 		var totalPRs int
-		var totalBuildRuns int
-		var prsPerEngVals, codingTimeVals, reviewTimeVals, onaVals, revertPctVals, buildSuccessVals []float64
+		var totalAdditions, totalDeletions, totalFiles, totalReverts, totalOnaLines int
+		var onaWeighted bool
+		var totalBuildRuns, totalIncidents int
+		var totalRequests, totalUnanswered, totalReopened, totalAutomation int
+		var automationMergeVals, ciQueueVals []float64
+		var ciQueueTracked bool
+		var ciCostTracked, ciPriced, templateTracked, securityTracked bool
+		var totalSecurity int
+		var securityLeadVals []float64
+		var releasesTracked bool
+		var totalReleases int
+		var releaseGapVals, prsPerReleaseVals []float64
+		var changelogTracked bool
+		var releaseBatchVals []float64
+		var templateVals []float64
+		var ciMinutes, ciCost float64
+		var ciMinuteWeeks, ciCostWeeks int
+		var automationTracked, reopenTracked, incidentsTracked, sizeWeighted, retentionTracked, responseTracked, hygieneTracked, reviewCoverageTracked bool
+		var sizePerEngVals []float64
+		var prsPerEngVals, commitsPerEngVals, codingTimeVals, reviewTimeVals, responseVals, onaVals, revertPctVals, buildSuccessVals, mttrVals []float64
+		var describedVals, linkedVals, testsVals []float64
+		var approvedVals, unreviewedVals []float64
+		var sizeBucketsTracked, incomplete bool
+		var prsBySize []int
+		var reviewTimeBySizeVals [][]float64
 
 		for _, wi := range g.weeks {
 			ws := stats[wi]
+			incomplete = incomplete || ws.incomplete
 			totalPRs += ws.prsMerged
+			totalAdditions += ws.additions
+			totalDeletions += ws.deletions
+			totalFiles += ws.filesChanged
+			totalReverts += ws.revertCount
+			totalOnaLines += ws.onaLines
+			onaWeighted = onaWeighted || ws.onaWeighted
 			totalBuildRuns += ws.buildRuns
+			totalReopened += ws.reopenedCount
+			reopenTracked = reopenTracked || ws.reopenTracked
+			automationTracked = automationTracked || ws.automationTracked
+			totalAutomation += ws.automationPRs
+			ciQueueTracked = ciQueueTracked || ws.ciQueueTracked
+			ciCostTracked = ciCostTracked || ws.ciCostTracked
+			ciPriced = ciPriced || ws.ciPriced
+			templateTracked = templateTracked || ws.templateTracked
+			securityTracked = securityTracked || ws.securityTracked
+			totalSecurity += ws.securityPRs
+			if ws.securityTracked && ws.medianSecurityLead >= 0 {
+				securityLeadVals = append(securityLeadVals, ws.medianSecurityLead)
+			}
+			releasesTracked = releasesTracked || ws.releasesTracked
+			totalReleases += ws.releases
+			if ws.releasesTracked && ws.medianReleaseGap >= 0 {
+				releaseGapVals = append(releaseGapVals, ws.medianReleaseGap)
+			}
+			if ws.releasesTracked && ws.prsPerRelease >= 0 {
+				prsPerReleaseVals = append(prsPerReleaseVals, ws.prsPerRelease)
+			}
+			changelogTracked = changelogTracked || ws.changelogTracked
+			if ws.changelogTracked && ws.releaseBatch >= 0 {
+				releaseBatchVals = append(releaseBatchVals, ws.releaseBatch)
+			}
+			if ws.templateTracked && ws.pctTemplateCompliant >= 0 {
+				templateVals = append(templateVals, ws.pctTemplateCompliant)
+			}
+			if ws.ciCostTracked && ws.ciMinutes >= 0 {
+				ciMinutes += ws.ciMinutes
+				ciMinuteWeeks++
+			}
+			if ws.ciCostTracked && ws.ciCost >= 0 {
+				ciCost += ws.ciCost
+				ciCostWeeks++
+			}
+			if ws.ciQueueTracked && ws.medianCIQueue >= 0 {
+				ciQueueVals = append(ciQueueVals, ws.medianCIQueue)
+			}
+			if ws.automationPRs > 0 {
+				automationMergeVals = append(automationMergeVals, ws.medianAutomationMerge)
+			}
+			sizeWeighted = sizeWeighted || ws.sizeWeighted
+			retentionTracked = retentionTracked || ws.retentionTracked
+			responseTracked = responseTracked || ws.responseTracked
+			hygieneTracked = hygieneTracked || ws.hygieneTracked
+			reviewCoverageTracked = reviewCoverageTracked || ws.reviewCoverageTracked
+			if ws.pctApproved >= 0 && ws.reviewCoverageTracked {
+				approvedVals = append(approvedVals, ws.pctApproved)
+				unreviewedVals = append(unreviewedVals, ws.pctUnreviewed)
+			}
+			if ws.sizeBucketsTracked {
+				if !sizeBucketsTracked {
+					sizeBucketsTracked = true
+					prsBySize = make([]int, len(sizeBuckets))
+					reviewTimeBySizeVals = make([][]float64, len(sizeBuckets))
+				}
+				for b := range sizeBuckets {
+					prsBySize[b] += ws.prsBySize[b]
+					if ws.reviewTimeBySize[b] >= 0 {
+						reviewTimeBySizeVals[b] = append(reviewTimeBySizeVals[b], ws.reviewTimeBySize[b])
+					}
+				}
+			}
+			totalRequests += ws.reviewRequests
+			totalUnanswered += ws.unansweredRequests
+			if ws.medianReviewResponse >= 0 && ws.responseTracked {
+				responseVals = append(responseVals, ws.medianReviewResponse)
+			}
 
 			if ws.prsMerged > 0 {
 				prsPerEngVals = append(prsPerEngVals, ws.prsPerEngineer)
+				commitsPerEngVals = append(commitsPerEngVals, ws.commitsPerEngineer)
+				sizePerEngVals = append(sizePerEngVals, ws.sizePointsPerEngineer)
 				onaVals = append(onaVals, ws.pctOnaInvolved)
 				revertPctVals = append(revertPctVals, ws.pctReverts)
+				describedVals = append(describedVals, ws.pctDescribed)
+				linkedVals = append(linkedVals, ws.pctLinkedIssue)
+				testsVals = append(testsVals, ws.pctWithTests)
 			}
 			if ws.medianCodingTime >= 0 && ws.prsMerged > 0 {
 				codingTimeVals = append(codingTimeVals, ws.medianCodingTime)
@@ -89,6 +203,13 @@ func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []week
 			if ws.buildRuns > 0 {
 				buildSuccessVals = append(buildSuccessVals, ws.buildSuccessPct)
 			}
+			if ws.incidentsTracked {
+				incidentsTracked = true
+				totalIncidents += ws.incidentCount
+				if ws.medianMTTR >= 0 {
+					mttrVals = append(mttrVals, ws.medianMTTR)
+				}
+			}
 		}
 
 		// For unique authors at the monthly level, we need to re-count from
@@ -121,18 +242,174 @@ func aggregateMonthly(weeks []weekRange, stats []weekStats) ([]weekRange, []week
 			medianReviewTime = -1
 		}
 
-		outRanges = append(outRanges, weekRange{start: g.start, end: g.end})
+		external := make(map[string]float64)
+		for _, def := range externalSeriesDefs {
+			var sum float64
+			var count int
+			for _, wi := range g.weeks {
+				if v, ok := stats[wi].external[def.name]; ok && !math.IsNaN(v) {
+					sum += v
+					count++
+				}
+			}
+			switch {
+			case count == 0:
+				external[def.name] = math.NaN()
+			case def.agg == "mean":
+				external[def.name] = sum / float64(count)
+			default:
+				external[def.name] = sum
+			}
+		}
+
+		customValues := make(map[string][]float64)
+		for _, wi := range g.weeks {
+			for name, vals := range stats[wi].customValues {
+				customValues[name] = append(customValues[name], vals...)
+			}
+		}
+
+		// Rolling active-engineer counts are levels, not flows: a period takes
+		// the value of its last week, whose window covers roughly a month.
+		lastWeek := stats[g.weeks[len(g.weeks)-1]]
+
+		medianResponse := medianFloat(responseVals)
+		if len(responseVals) == 0 {
+			medianResponse = -1
+		}
+		medianApproved, medianUnreviewed := medianFloat(approvedVals), medianFloat(unreviewedVals)
+		if len(approvedVals) == 0 {
+			medianApproved, medianUnreviewed = -1, -1
+		}
+
+		var reviewTimeBySize []float64
+		for _, vals := range reviewTimeBySizeVals {
+			m := medianFloat(vals)
+			if len(vals) == 0 {
+				m = -1
+			}
+			reviewTimeBySize = append(reviewTimeBySize, m)
+		}
+
+		medianMTTR := medianFloat(mttrVals)
+		if len(mttrVals) == 0 {
+			medianMTTR = -1
+		}
+
+		medianCIQueue := medianFloat(ciQueueVals)
+		if len(ciQueueVals) == 0 {
+			medianCIQueue = -1
+		}
+		medianSecurityLead := medianFloat(securityLeadVals)
+		if len(securityLeadVals) == 0 {
+			medianSecurityLead = -1
+		}
+		medianReleaseGap, medianPRsPerRelease := medianFloat(releaseGapVals), medianFloat(prsPerReleaseVals)
+		if len(releaseGapVals) == 0 {
+			medianReleaseGap = -1
+		}
+		if len(prsPerReleaseVals) == 0 {
+			medianPRsPerRelease = -1
+		}
+		medianReleaseBatch := medianFloat(releaseBatchVals)
+		if len(releaseBatchVals) == 0 {
+			medianReleaseBatch = -1
+		}
+		medianTemplate := medianFloat(templateVals)
+		if len(templateVals) == 0 {
+			medianTemplate = -1
+		}
+		// Billable minutes and cost are totals, so they add up
+		if ciMinuteWeeks == 0 {
+			ciMinutes = -1
+		}
+		if ciCostWeeks == 0 {
+			ciCost = -1
+		}
+		medianAutomationMerge := medianFloat(automationMergeVals)
+		if len(automationMergeVals) == 0 {
+			medianAutomationMerge = -1
+		}
+
+		var avgPRSize float64
+		if totalPRs > 0 {
+			avgPRSize = float64(totalAdditions+totalDeletions) / float64(totalPRs)
+		}
+
+		outRanges = append(outRanges, weekRange{start: g.start, end: g.end, name: g.name})
 		outStats = append(outStats, weekStats{
-			prsMerged:        totalPRs,
-			uniqueAuthors:    int(medianAuthors),
-			prsPerEngineer:   medianPrsPerEng,
-			medianCodingTime: medianCodingTime,
-			medianReviewTime: medianReviewTime,
-			pctOnaInvolved:   medianOna,
-			pctReverts:       medianRevertPct,
-			buildRuns:        totalBuildRuns,
-			buildSuccessPct:  medianFloat(buildSuccessVals),
+			incomplete:            incomplete,
+			prsMerged:             totalPRs,
+			additions:             totalAdditions,
+			deletions:             totalDeletions,
+			filesChanged:          totalFiles,
+			avgPRSize:             avgPRSize,
+			revertCount:           totalReverts,
+			p90CodingTime:         -1,
+			p90ReviewTime:         -1,
+			medianTurnaround:      -1,
+			p90Turnaround:         -1,
+			uniqueAuthors:         int(medianAuthors),
+			prsPerEngineer:        medianPrsPerEng,
+			commitsPerEngineer:    medianFloat(commitsPerEngVals),
+			sizeWeighted:          sizeWeighted,
+			sizePointsPerEngineer: medianFloat(sizePerEngVals),
+			retentionTracked:      retentionTracked,
+			activeEngineers4w:     lastWeek.activeEngineers4w,
+			churnedEngineers:      lastWeek.churnedEngineers,
+			medianCodingTime:      medianCodingTime,
+			medianReviewTime:      medianReviewTime,
+			responseTracked:       responseTracked,
+			medianReviewResponse:  medianResponse,
+			reviewRequests:        totalRequests,
+			unansweredRequests:    totalUnanswered,
+			hygieneTracked:        hygieneTracked,
+			pctDescribed:          medianFloat(describedVals),
+			pctLinkedIssue:        medianFloat(linkedVals),
+			pctWithTests:          medianFloat(testsVals),
+			reviewCoverageTracked: reviewCoverageTracked,
+			pctApproved:           medianApproved,
+			pctUnreviewed:         medianUnreviewed,
+			sizeBucketsTracked:    sizeBucketsTracked,
+			prsBySize:             prsBySize,
+			reviewTimeBySize:      reviewTimeBySize,
+			pctOnaInvolved:        medianOna,
+			onaWeighted:           onaWeighted,
+			onaLines:              totalOnaLines,
+			pctOnaLines:           pctOnaLines(totalOnaLines, totalAdditions+totalDeletions),
+			pctReverts:            medianRevertPct,
+			buildRuns:             totalBuildRuns,
+			reopenTracked:         reopenTracked,
+			reopenedCount:         totalReopened,
+			automationTracked:     automationTracked,
+			automationPRs:         totalAutomation,
+			medianAutomationMerge: medianAutomationMerge,
+			ciQueueTracked:        ciQueueTracked,
+			medianCIQueue:         medianCIQueue,
+			securityTracked:       securityTracked,
+			securityPRs:           totalSecurity,
+			medianSecurityLead:    medianSecurityLead,
+			releasesTracked:       releasesTracked,
+			releases:              totalReleases,
+			medianReleaseGap:      medianReleaseGap,
+			prsPerRelease:         medianPRsPerRelease,
+			changelogTracked:      changelogTracked,
+			releaseBatch:          medianReleaseBatch,
+			templateTracked:       templateTracked,
+			pctTemplateCompliant:  medianTemplate,
+			ciCostTracked:         ciCostTracked,
+			ciPriced:              ciPriced,
+			ciMinutes:             ciMinutes,
+			ciCost:                ciCost,
+			buildSuccessPct:       medianFloat(buildSuccessVals),
+			incidentsTracked:      incidentsTracked,
+			incidentCount:         totalIncidents,
+			medianMTTR:            medianMTTR,
+			external:              external,
+			customValues:          customValues,
 		})
+		aggregateCustomMetrics(&outStats[len(outStats)-1])
+		computeDerivedMetrics(&outStats[len(outStats)-1])
 	}
 
 	return outRanges, outStats
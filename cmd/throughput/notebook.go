@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// notebookBundle is what --notebook-bundle writes besides the data files:
+// the run's parameters, filled into the starter notebook.
+type notebookBundle struct {
+	repo        string // owner/repo
+	source      string // the flags naming the data source, for the refresh command (notebookSource)
+	start, end  string // first week's start and last week's end, YYYY-MM-DD
+	weeks       int
+	granularity string
+	anonymized  bool
+	// weeklyColumns is the weekly CSV's header, which --columns may have
+	// narrowed; set by writeNotebookBundle.
+	weeklyColumns []string
+}
+
+// notebookSource returns the flags that point a rerun at cfg's data: the
+// clone's absolute path for --local-git (whose owner/repo may be made up
+// from the directory name), the Gerrit project, or the GitHub repository.
+func notebookSource(cfg config) string {
+	switch cfg.provider {
+	case "git":
+		dir, err := filepath.Abs(cfg.gitDir)
+		if err != nil {
+			dir = cfg.gitDir
+		}
+		return "--local-git " + shellArg(dir)
+	case "gerrit":
+		return "--provider gerrit --gerrit-url " + shellArg(cfg.gerritURL) + " --repo " + shellArg(cfg.repo)
+	}
+	return "--repo " + cfg.owner + "/" + cfg.repo
+}
+
+// shellArg single-quotes s for a shell unless it is made of characters
+// that never need quoting.
+func shellArg(s string) string {
+	safe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("/._-:@+=,", r)
+	}
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !safe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Files of a --notebook-bundle directory.
+const (
+	bundleWeeklyCSV = "weekly.csv"
+	bundlePRsCSV    = "prs.csv"
+	bundleNotebook  = "analysis.ipynb"
+)
+
+// writeNotebookBundle writes the weekly CSV, the per-PR detail CSV, and a
+// starter notebook that loads both into dir, creating it if needed.
+func writeNotebookBundle(dir string, b notebookBundle, weeklyCSV string, prs []enrichedPR, version int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundleWeeklyCSV), []byte(weeklyCSV), 0644); err != nil {
+		return err
+	}
+	header, _, _ := strings.Cut(weeklyCSV, "\n")
+	b.weeklyColumns = strings.Split(header, ",")
+	if err := writePRDetailsCSV(filepath.Join(dir, bundlePRsCSV), prs, b.anonymized, version); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(starterNotebook(b), "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, bundleNotebook), append(data, '\n'), 0644)
+}
+
+// ipynb is the subset of the Jupyter nbformat 4 schema the starter notebook
+// uses.
+type ipynb struct {
+	Cells         []any          `json:"cells"`
+	Metadata      map[string]any `json:"metadata"`
+	NBFormat      int            `json:"nbformat"`
+	NBFormatMinor int            `json:"nbformat_minor"`
+}
+
+type ipynbMarkdown struct {
+	CellType string         `json:"cell_type"`
+	Metadata map[string]any `json:"metadata"`
+	Source   []string       `json:"source"`
+}
+
+// ipynbCode is an unexecuted code cell; nbformat requires execution_count
+// (null) and outputs even so.
+type ipynbCode struct {
+	CellType       string         `json:"cell_type"`
+	ExecutionCount *int           `json:"execution_count"`
+	Metadata       map[string]any `json:"metadata"`
+	Outputs        []any          `json:"outputs"`
+	Source         []string       `json:"source"`
+}
+
+func markdownCell(text string) ipynbMarkdown {
+	return ipynbMarkdown{CellType: "markdown", Metadata: map[string]any{}, Source: sourceLines(text)}
+}
+
+func codeCell(code string, tags ...string) ipynbCode {
+	meta := map[string]any{}
+	if len(tags) > 0 {
+		meta["tags"] = tags
+	}
+	return ipynbCode{CellType: "code", Metadata: meta, Outputs: []any{}, Source: sourceLines(code)}
+}
+
+// sourceLines splits text into nbformat source lines, each but the last
+// keeping its newline.
+func sourceLines(text string) []string {
+	return strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// starterNotebook builds the notebook. The first code cell is tagged
+// "parameters" so papermill can rerun it for another repository or range
+// once the bundle is regenerated.
+func starterNotebook(b notebookBundle) ipynb {
+	authorNote := "`author` is the author's login."
+	if b.anonymized {
+		authorNote = "`author` is a pseudonym (`--anonymize`); PR numbers and titles are empty."
+	}
+	// The week dates are parsed and index the trend plot only if --columns
+	// kept them.
+	var dateCols []string
+	for _, c := range []string{"week_start", "week_end"} {
+		if slices.Contains(b.weeklyColumns, c) {
+			dateCols = append(dateCols, strconv.Quote(c))
+		}
+	}
+	parseDates := ""
+	if len(dateCols) > 0 {
+		parseDates = ", parse_dates=[" + strings.Join(dateCols, ", ") + "]"
+	}
+	trendFrame := "weekly"
+	if slices.Contains(b.weeklyColumns, "week_start") {
+		trendFrame = `weekly.set_index("week_start")`
+	}
+	cells := []any{
+		markdownCell(fmt.Sprintf(`# Engineering throughput: %s
+
+Data for %s to %s (%d weeks), exported by the throughput tool with `+"`--notebook-bundle`"+`. To refresh it, rerun the tool:
+
+`+"```"+`
+go run ./cmd/throughput/ %s --weeks %d --notebook-bundle <this directory>
+`+"```"+`
+
+- `+"`%s`"+`: one row per week, the same columns as the tool's CSV output. Empty cells are weeks without data for that metric.
+- `+"`%s`"+`: one row per merged PR that passed the tool's filters. %s Cycle times are in hours and empty where unknown.`,
+			b.repo, b.start, b.end, b.weeks, b.source, b.weeks, bundleWeeklyCSV, bundlePRsCSV, authorNote)),
+		codeCell(fmt.Sprintf(`REPO = %q
+START = %q
+END = %q
+GRANULARITY = %q  # the tool's --granularity; the CSVs are always weekly and per PR
+DATA_DIR = "."`, b.repo, b.start, b.end, b.granularity), "parameters"),
+		codeCell(`from pathlib import Path
+
+import numpy as np
+import pandas as pd
+
+data = Path(DATA_DIR)
+weekly = pd.read_csv(data / "` + bundleWeeklyCSV + `"` + parseDates + `)
+prs = pd.read_csv(data / "` + bundlePRsCSV + `", parse_dates=["merged_at"])
+prs["lines_changed"] = prs["additions"] + prs["deletions"]
+prs["cycle_time_hours"] = prs["coding_time_hours"] + prs["review_time_hours"]
+print(f"{REPO}: {len(weekly)} weeks, {len(prs)} PRs by {prs['author'].nunique()} authors")
+weekly.tail()`),
+		markdownCell("## Weekly trends\n\nThe tool's headline series. Add any other column of `weekly` to the list."),
+		codeCell(`cols = ["prs_per_engineer", "median_coding_time_hours", "median_review_time_hours", "pct_ona_involved"]
+cols = [c for c in cols if c in weekly]  # --columns may have left some out
+` + trendFrame + `[cols].plot(subplots=True, figsize=(10, 8), marker="o", title=f"{REPO}, {START} to {END}");`),
+		markdownCell("## Ona-involved vs other PRs\n\nPer-PR medians rather than medians of weekly medians."),
+		codeCell(`prs.groupby("ona_involved")[["lines_changed", "coding_time_hours", "review_time_hours", "cycle_time_hours"]].median()`),
+		markdownCell("## Mixed-effects model\n\nA simpler `--ona-mixed-model` (without its merge-time trend) to extend with your own covariates: log(1 + cycle time) on Ona involvement and log size, with a random intercept per author. Needs `statsmodels`."),
+		codeCell(`import statsmodels.formula.api as smf
+
+df = prs.dropna(subset=["cycle_time_hours"]).assign(
+    log_cycle=lambda d: np.log1p(d["cycle_time_hours"]),
+    log_size=lambda d: np.log1p(d["lines_changed"]),
+    ona=lambda d: d["ona_involved"].astype(int),
+)
+fit = smf.mixedlm("log_cycle ~ ona + log_size", df, groups=df["author"]).fit(reml=True)
+print(fit.summary())
+print(f"Ona: {np.expm1(fit.params['ona']):+.1%} cycle time")`),
+	}
+	return ipynb{
+		Cells: cells,
+		Metadata: map[string]any{
+			"kernelspec":    map[string]any{"display_name": "Python 3", "language": "python", "name": "python3"},
+			"language_info": map[string]any{"name": "python"},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 4,
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	notionAPI     = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// notionMaxChildren is the most blocks, or table rows, one Notion request
+	// may carry.
+	notionMaxChildren = 100
+)
+
+// notionTableColumns are the weekly CSV columns of the table --notion-page
+// writes below the summary; columns the run didn't produce are left out.
+var notionTableColumns = []string{
+	"week_start", "prs_merged", "unique_authors", "prs_per_engineer", "pct_ona_involved",
+	"median_coding_time_hours", "median_review_time_hours", "pct_reverts",
+}
+
+// publishNotionPage replaces the content of a Notion page with the run
+// summary and a table of the latest weeks. Child pages and databases on the
+// page are kept.
+func publishNotionPage(token, pageID, markdown, weeklyCSV string) error {
+	blocks := notionBlocks(parseMarkdown(markdown))
+	if table := notionWeeklyTable(weeklyCSV); table != nil {
+		blocks = append(blocks, notionHeading(3, "Weekly metrics"), table)
+	}
+
+	var old []string
+	for cursor := ""; ; {
+		var page struct {
+			Results []struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}
+		url := fmt.Sprintf("%s/blocks/%s/children?page_size=100", notionAPI, pageID)
+		if cursor != "" {
+			url += "&start_cursor=" + cursor
+		}
+		if err := notionREST(token, "GET", url, nil, &page); err != nil {
+			return err
+		}
+		for _, b := range page.Results {
+			if b.Type != "child_page" && b.Type != "child_database" {
+				old = append(old, b.ID)
+			}
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	for _, id := range old {
+		if err := notionREST(token, "DELETE", notionAPI+"/blocks/"+id, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	for len(blocks) > 0 {
+		n := min(notionMaxChildren, len(blocks))
+		if err := notionREST(token, "PATCH", fmt.Sprintf("%s/blocks/%s/children", notionAPI, pageID),
+			map[string]any{"children": blocks[:n]}, nil); err != nil {
+			return err
+		}
+		blocks = blocks[n:]
+	}
+	return nil
+}
+
+// publishNotionDatabase upserts one database row per week, keyed by the
+// title property holding the week start date. Every number property named
+// like a weekly CSV column (e.g. prs_merged) is filled in; other properties
+// are left alone. Returns the number of rows written.
+func publishNotionDatabase(token, databaseID, weeklyCSV string) (int, error) {
+	rows, err := csv.NewReader(strings.NewReader(weeklyCSV)).ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("parse CSV: %w", err)
+	}
+	if len(rows) < 2 {
+		return 0, nil
+	}
+
+	var db struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := notionREST(token, "GET", notionAPI+"/databases/"+databaseID, nil, &db); err != nil {
+		return 0, err
+	}
+	week := -1
+	for i, h := range rows[0] {
+		if h == "week_start" {
+			week = i
+		}
+	}
+	if week < 0 {
+		return 0, fmt.Errorf("CSV has no week_start column")
+	}
+	var title string
+	columns := make(map[int]string) // CSV column index → number property
+	for name, p := range db.Properties {
+		if p.Type == "title" {
+			title = name
+		}
+	}
+	for i, h := range rows[0] {
+		if p, ok := db.Properties[h]; ok && p.Type == "number" {
+			columns[i] = h
+		}
+	}
+	if title == "" {
+		return 0, fmt.Errorf("database %s has no title property", databaseID)
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("database %s has no number properties named like CSV columns (e.g. prs_merged)", databaseID)
+	}
+
+	for _, row := range rows[1:] {
+		start := row[week]
+		props := map[string]any{
+			title: map[string]any{"title": notionText(start)},
+		}
+		for i, name := range columns {
+			var v any // an empty cell clears the property
+			if i < len(row) {
+				if f, err := strconv.ParseFloat(row[i], 64); err == nil {
+					v = f
+				}
+			}
+			props[name] = map[string]any{"number": v}
+		}
+
+		var existing struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+		}
+		query := map[string]any{"filter": map[string]any{"property": title, "title": map[string]string{"equals": start}}}
+		if err := notionREST(token, "POST", notionAPI+"/databases/"+databaseID+"/query", query, &existing); err != nil {
+			return 0, err
+		}
+		if len(existing.Results) > 0 {
+			err = notionREST(token, "PATCH", notionAPI+"/pages/"+existing.Results[0].ID, map[string]any{"properties": props}, nil)
+		} else {
+			err = notionREST(token, "POST", notionAPI+"/pages", map[string]any{
+				"parent":     map[string]string{"database_id": databaseID},
+				"properties": props,
+			}, nil)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("week %s: %w", start, err)
+		}
+	}
+	return len(rows) - 1, nil
+}
+
+// notionBlocks converts parsed summary Markdown to Notion blocks, with
+// <details> blocks as toggles.
+func notionBlocks(blocks []mdBlock) []map[string]any {
+	var out []map[string]any
+	for _, b := range blocks {
+		switch b.kind {
+		case "heading":
+			out = append(out, notionHeading(b.level, b.text))
+		case "paragraph":
+			out = append(out, notionBlock("paragraph", map[string]any{"rich_text": notionText(b.text)}))
+		case "bullets":
+			for _, item := range b.items {
+				out = append(out, notionBlock("bulleted_list_item", map[string]any{"rich_text": notionText(item)}))
+			}
+		case "table":
+			out = append(out, notionTable(b.rows))
+		case "details":
+			out = append(out, notionBlock("toggle", map[string]any{
+				"rich_text": notionText(b.text),
+				"children":  notionBlocks(b.children),
+			}))
+		}
+	}
+	return out
+}
+
+// notionWeeklyTable returns a table of notionTableColumns for the latest
+// weeks of the weekly CSV, or nil if it has none of them.
+func notionWeeklyTable(weeklyCSV string) map[string]any {
+	rows, err := csv.NewReader(strings.NewReader(weeklyCSV)).ReadAll()
+	if err != nil || len(rows) < 2 {
+		return nil
+	}
+	index := make(map[string]int)
+	for i, h := range rows[0] {
+		index[h] = i
+	}
+	var cols []int
+	var header []string
+	for _, c := range notionTableColumns {
+		if i, ok := index[c]; ok {
+			cols = append(cols, i)
+			header = append(header, "`"+c+"`")
+		}
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	table := [][]string{header}
+	for _, row := range rows[max(1, len(rows)-(notionMaxChildren-1)):] {
+		cells := make([]string, len(cols))
+		for j, i := range cols {
+			if i < len(row) {
+				cells[j] = row[i]
+			}
+		}
+		table = append(table, cells)
+	}
+	return notionTable(table)
+}
+
+func notionTable(rows [][]string) map[string]any {
+	var children []map[string]any
+	for _, row := range rows {
+		cells := make([]any, len(row))
+		for i, c := range row {
+			cells[i] = notionText(c)
+		}
+		children = append(children, notionBlock("table_row", map[string]any{"cells": cells}))
+	}
+	return notionBlock("table", map[string]any{
+		"table_width":       len(rows[0]),
+		"has_column_header": true,
+		"has_row_header":    false,
+		"children":          children,
+	})
+}
+
+func notionHeading(level int, text string) map[string]any {
+	level = max(1, min(3, level)) // Notion has three heading levels
+	return notionBlock(fmt.Sprintf("heading_%d", level), map[string]any{"rich_text": notionText(text)})
+}
+
+func notionBlock(kind string, content map[string]any) map[string]any {
+	return map[string]any{"object": "block", "type": kind, kind: content}
+}
+
+// notionText converts bold and code spans to Notion rich text.
+func notionText(s string) []map[string]any {
+	out := []map[string]any{} // an empty cell is [], not null
+	for _, sp := range parseInline(s) {
+		out = append(out, map[string]any{
+			"type":        "text",
+			"text":        map[string]string{"content": sp.text},
+			"annotations": map[string]bool{"bold": sp.bold, "code": sp.code},
+		})
+	}
+	return out
+}
+
+// notionREST sends a JSON request to the Notion API with retry on transport
+// errors, rate limiting, and server errors, decoding the response into out.
+func notionREST(token, method, url string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Notion-Version", notionVersion)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("Notion returned 401 (check NOTION_TOKEN)")
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s not found (is the page or database shared with the integration?)", url)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("Notion returned %d", resp.StatusCode)
+			wait := time.Duration(attempt*5) * time.Second
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			time.Sleep(wait)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(data, out)
+	}
+	return fmt.Errorf("Notion request failed after 3 attempts: %v", lastErr)
+}
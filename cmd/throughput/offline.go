@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// offlineHosts are the only hosts HTTP requests may reach with --offline;
+// nil when the flag isn't set.
+var offlineHosts map[string]bool
+
+// offlineForbidden are the flags that reach services other than the
+// provider. --offline rejects them up front rather than letting the run get
+// minutes in before the transport refuses the first request.
+var offlineForbidden = []struct{ flag, service string }{
+	{"jira-url", "Jira"},
+	{"linear", "Linear"},
+	{"pagerduty", "PagerDuty"},
+	{"confluence-url", "Confluence"},
+	{"notion-page", "Notion"},
+	{"notion-database", "Notion"},
+}
+
+// chartJS is the Chart.js source inlined into the report with --chart-js;
+// empty loads it from the jsDelivr CDN.
+var chartJS template.JS
+
+// offlineTransport refuses requests to hosts outside offlineHosts, so a code
+// path the up-front checks missed fails loudly instead of leaking a request.
+type offlineTransport struct {
+	base http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !offlineHosts[strings.ToLower(req.URL.Hostname())] {
+		return nil, fmt.Errorf("--offline forbids network access to %s (allowed: %s)", req.URL.Host, strings.Join(offlineAllowed(), ", "))
+	}
+	return t.base.RoundTrip(req)
+}
+
+// offlineAllowed returns the allowed hosts, sorted for messages.
+func offlineAllowed() []string {
+	var hosts []string
+	for h := range offlineHosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// enableOffline restricts HTTP to the provider's API: api.github.com, or the
+// --gerrit-url host. It fails if a flag needs another service, an --series
+// is a URL, or the report would load Chart.js from the CDN.
+func enableOffline(provider, gerritURL string, series []externalSeriesDef, html bool) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var violations []string
+	for _, f := range offlineForbidden {
+		if set[f.flag] {
+			violations = append(violations, fmt.Sprintf("--%s contacts %s", f.flag, f.service))
+		}
+	}
+	for _, s := range series {
+		if src, ok := s.source.(jsonURLSource); ok {
+			violations = append(violations, fmt.Sprintf("--series %s fetches %s", s.name, src.url))
+		}
+	}
+	if html && chartJS == "" {
+		violations = append(violations, "the HTML report loads Chart.js from cdn.jsdelivr.net (pass a local copy with --chart-js)")
+	}
+	if len(violations) > 0 {
+		fatal("--offline: %s", strings.Join(violations, "; "))
+	}
+
+	offlineHosts = map[string]bool{}
+	if provider == "gerrit" {
+		u, err := url.Parse(gerritURL)
+		if err != nil || u.Hostname() == "" {
+			fatal("--offline: invalid --gerrit-url %q", gerritURL)
+		}
+		offlineHosts[strings.ToLower(u.Hostname())] = true
+	} else {
+		offlineHosts["api.github.com"] = true
+	}
+	httpClient.Transport = offlineTransport{base: http.DefaultTransport}
+	fmt.Fprintf(os.Stderr, "Offline mode: network access limited to %s\n", strings.Join(offlineAllowed(), ", "))
+}
+
+// remoteAssetRe matches elements that make the browser fetch a remote
+// resource when the report is opened. Plain links are left alone: they are
+// only followed when clicked.
+var remoteAssetRe = regexp.MustCompile(`(?i)<(?:script|link|img|iframe|source)\b[^>]*\b(?:src|href)\s*=\s*["']?(?:https?:)?//([^/"'\s>]+)`)
+
+// checkOfflineHTML fails if a rendered report, typically from a --template,
+// still references remote scripts, stylesheets, or images.
+func checkOfflineHTML(content string) error {
+	if offlineHosts == nil {
+		return nil
+	}
+	if m := remoteAssetRe.FindStringSubmatch(content); m != nil {
+		return fmt.Errorf("--offline: the report loads a resource from %s; inline it in the template", m[1])
+	}
+	return nil
+}
+
+// loadChartJS reads --chart-js, a local copy of Chart.js (chart.umd.js) to
+// inline into the report.
+func loadChartJS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(strings.ToLower(string(data)), "</script") {
+		return fmt.Errorf("%s contains </script and can't be inlined", path)
+	}
+	chartJS = template.JS(data)
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// prOutcome is a per-PR outcome compared between Ona-involved and other PRs.
+// value reports the PR's value and whether it is known; rate outcomes return
+// 1 or 0.
+type prOutcome struct {
+	name  string
+	label string
+	unit  string // "h", "%", or "" for counts
+	kind  string // "median" (Mann-Whitney U), "mean" (Welch's t), or "rate" (two-proportion z)
+	value func(pr enrichedPR) (float64, bool)
+}
+
+// prOutcomes are the rows of the Ona comparison table.
+var prOutcomes = []prOutcome{
+	{
+		name: "size_lines", label: "Median size (lines)", kind: "median",
+		value: func(pr enrichedPR) (float64, bool) { return float64(pr.additions + pr.deletions), true },
+	},
+	{
+		name: "review_time_hours", label: "Median review time", unit: "h", kind: "median",
+		value: func(pr enrichedPR) (float64, bool) { return pr.reviewTimeHours, pr.reviewTimeHours >= 0 },
+	},
+	{
+		name: "review_iterations", label: "Reviews per PR", kind: "mean",
+		value: func(pr enrichedPR) (float64, bool) { return float64(pr.reviewCount), pr.reviewsEnriched },
+	},
+	{
+		name: "revert_rate", label: "Revert rate", unit: "%", kind: "rate",
+		value: func(pr enrichedPR) (float64, bool) { return boolValue(pr.isRevert), true },
+	},
+	{
+		name: "ci_failure_rate", label: "CI failure rate", unit: "%", kind: "rate",
+		value: func(pr enrichedPR) (float64, bool) { return boolValue(pr.ciFailures > 0), pr.ciRuns > 0 },
+	},
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// outcomeComparison is one row of the Ona comparison: the outcome's median,
+// mean, or percentage in each group and the significance of the difference.
+type outcomeComparison struct {
+	outcome prOutcome
+	onaN    int
+	otherN  int
+	ona     float64
+	other   float64
+	pValue  float64 // -1 if the samples are too small or constant
+}
+
+// applyPRBuildResults records each PR's pull_request workflow runs.
+func applyPRBuildResults(prs []enrichedPR, results map[int]prBuildResult) {
+	for i := range prs {
+		if r, ok := results[prs[i].number]; ok {
+			prs[i].ciRuns = r.runs
+			prs[i].ciFailures = r.failures
+		}
+	}
+}
+
+// compareOnaOutcomes compares every outcome between Ona-involved PRs and all
+// other PRs. Outcomes unknown for every PR in a group are skipped. With
+// minAuthors > 1, the comparison is dropped entirely if either group has
+// PRs from fewer distinct authors (--min-group-size).
+func compareOnaOutcomes(prs []enrichedPR, minAuthors int) []outcomeComparison {
+	var ona, other []enrichedPR
+	for _, pr := range prs {
+		if pr.onaInvolved {
+			ona = append(ona, pr)
+		} else {
+			other = append(other, pr)
+		}
+	}
+	if minAuthors > 1 && (distinctAuthors(ona) < minAuthors || distinctAuthors(other) < minAuthors) {
+		return nil
+	}
+	return compareOutcomes(ona, other)
+}
+
+// compareOutcomes compares every outcome between two PR groups.
+func compareOutcomes(ona, other []enrichedPR) []outcomeComparison {
+	var rows []outcomeComparison
+	for _, o := range prOutcomes {
+		a, b := outcomeValues(o, ona), outcomeValues(o, other)
+		if len(a) == 0 || len(b) == 0 {
+			continue
+		}
+		row := outcomeComparison{outcome: o, onaN: len(a), otherN: len(b)}
+		switch o.kind {
+		case "median":
+			row.ona, row.other = median(a), median(b)
+			row.pValue = mannWhitneyPValue(a, b)
+		case "mean":
+			row.ona, row.other = mean(a), mean(b)
+			row.pValue = welchPValue(a, b)
+		case "rate":
+			sa, sb := sum(a), sum(b)
+			row.ona, row.other = sa/float64(len(a))*100, sb/float64(len(b))*100
+			row.pValue = twoProportionPValue(sa, float64(len(a)), sb, float64(len(b)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func outcomeValues(o prOutcome, prs []enrichedPR) []float64 {
+	var vals []float64
+	for _, pr := range prs {
+		if v, ok := o.value(pr); ok {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+func distinctAuthors(prs []enrichedPR) int {
+	set := make(map[string]bool)
+	for _, pr := range prs {
+		set[pr.authorLogin] = true
+	}
+	return len(set)
+}
+
+func sum(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+// mannWhitneyPValue returns the two-tailed p-value of the Mann-Whitney U
+// test for a difference in distribution between a and b, using the normal
+// approximation with tie correction. Returns -1 if either sample has fewer
+// than 2 values or all values are tied.
+func mannWhitneyPValue(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 < 2 || n2 < 2 {
+		return -1
+	}
+	type obs struct {
+		v     float64
+		fromA bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Average ranks over ties, accumulating the tie correction term
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // 1-based average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u := rankSumA - fn1*(fn1+1)/2
+	sigma2 := fn1 * fn2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if sigma2 <= 0 {
+		return -1
+	}
+	z := (u - fn1*fn2/2) / math.Sqrt(sigma2)
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// twoProportionPValue returns the two-tailed p-value of the pooled
+// two-proportion z-test for x1/n1 vs x2/n2, or -1 if the pooled proportion
+// is 0 or 1.
+func twoProportionPValue(x1, n1, x2, n2 float64) float64 {
+	p := (x1 + x2) / (n1 + n2)
+	if p <= 0 || p >= 1 {
+		return -1
+	}
+	se := math.Sqrt(p * (1 - p) * (1/n1 + 1/n2))
+	z := (x1/n1 - x2/n2) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// formatOutcome formats an outcome value with its unit.
+func formatOutcome(o prOutcome, v float64, loc reportLocale) string {
+	switch o.unit {
+	case "h":
+		return loc.number(v, 1) + "h"
+	case "%":
+		return loc.number(v, 1) + "%"
+	}
+	if o.kind == "mean" {
+		return loc.number(v, 2)
+	}
+	return loc.number(v, 0)
+}
+
+// outcomeDifference formats the Ona group's difference from the baseline:
+// percentage points for rates, relative change otherwise.
+func outcomeDifference(c outcomeComparison, loc reportLocale) string {
+	if c.outcome.kind == "rate" {
+		return loc.localizeNumeric(fmt.Sprintf("%+.1f pp", c.ona-c.other))
+	}
+	if c.other == 0 {
+		return "N/A"
+	}
+	return loc.localizeNumeric(fmt.Sprintf("%+.1f%%", (c.ona-c.other)/c.other*100))
+}
+
+// logOutcomeComparisons prints comparison rows to stderr.
+func logOutcomeComparisons(rows []outcomeComparison) {
+	for _, c := range rows {
+		p := "n/a"
+		if c.pValue >= 0 {
+			p = fmt.Sprintf("%.3f", c.pValue)
+		}
+		fmt.Fprintf(os.Stderr, "  %s: Ona %s (n=%d) vs other %s (n=%d), p=%s\n", c.outcome.name,
+			formatOutcome(c.outcome, c.ona, locales["en"]), c.onaN,
+			formatOutcome(c.outcome, c.other, locales["en"]), c.otherN, p)
+	}
+}
+
+// outcomeRows formats comparison rows for the HTML report.
+func outcomeRows(rows []outcomeComparison, loc reportLocale) []htmlOutcomeRow {
+	var out []htmlOutcomeRow
+	for _, c := range rows {
+		row := htmlOutcomeRow{
+			Metric:     loc.T(c.outcome.label),
+			Ona:        formatOutcome(c.outcome, c.ona, loc),
+			OnaN:       c.onaN,
+			Other:      formatOutcome(c.outcome, c.other, loc),
+			OtherN:     c.otherN,
+			Difference: outcomeDifference(c, loc),
+			PValue:     "—",
+		}
+		if c.pValue >= 0 {
+			row.PValue = loc.number(c.pValue, 3)
+			row.Significant = c.pValue < 0.05
+		}
+		out = append(out, row)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// onaUserStat is one contributor's Ona usage for the --ona-heat-list.
+type onaUserStat struct {
+	login    string
+	prs      int
+	onaPRs   int
+	onaPct   float64 // share of the contributor's PRs that were Ona-involved
+	lastOna  int64   // merge epoch of their latest Ona-involved PR; 0 without one
+	lastPR   int64   // merge epoch of their latest PR
+	excluded bool    // cut by --exclude-bottom-contributor-pct: not in the metrics
+}
+
+// computeOnaHeatList splits contributors with at least opts.minPRs PRs into
+// power users, the opts.n with the most Ona-involved PRs (ties by share),
+// and untouched contributors, the opts.n most active ones with no
+// Ona-involved PR at all: the people champions would ask to share their
+// workflow and the ones enablement would reach first.
+func computeOnaHeatList(prs []enrichedPR, opts contributorOptions) (power, untouched []onaUserStat) {
+	if opts.n <= 0 {
+		return nil, nil
+	}
+	for login, authorPRs := range prsByEngineer(prs) {
+		if len(authorPRs) < opts.minPRs {
+			continue
+		}
+		s := onaUserStat{login: login, prs: len(authorPRs), excluded: opts.excluded[login]}
+		for _, pr := range authorPRs {
+			s.lastPR = max(s.lastPR, pr.mergedEpoch)
+			if pr.onaInvolved {
+				s.onaPRs++
+				s.lastOna = max(s.lastOna, pr.mergedEpoch)
+			}
+		}
+		s.onaPct = math.Round(float64(s.onaPRs)/float64(s.prs)*1000) / 10
+		if s.onaPRs > 0 {
+			power = append(power, s)
+		} else {
+			untouched = append(untouched, s)
+		}
+	}
+
+	sort.Slice(power, func(i, j int) bool {
+		if power[i].onaPRs != power[j].onaPRs {
+			return power[i].onaPRs > power[j].onaPRs
+		}
+		if power[i].onaPct != power[j].onaPct {
+			return power[i].onaPct > power[j].onaPct
+		}
+		return power[i].login < power[j].login
+	})
+	sort.Slice(untouched, func(i, j int) bool {
+		if untouched[i].prs != untouched[j].prs {
+			return untouched[i].prs > untouched[j].prs
+		}
+		return untouched[i].login < untouched[j].login
+	})
+	power = power[:min(len(power), opts.n)]
+	untouched = untouched[:min(len(untouched), opts.n)]
+	if opts.anonymize {
+		for i := range power {
+			power[i].login = hashLogin(power[i].login)
+		}
+		for i := range untouched {
+			untouched[i].login = hashLogin(untouched[i].login)
+		}
+	}
+	return power, untouched
+}
+
+// logOnaHeatList prints both lists to stderr.
+func logOnaHeatList(power, untouched []onaUserStat) {
+	fmt.Fprintf(os.Stderr, "Ona power users (%d):\n", len(power))
+	for _, s := range power {
+		fmt.Fprintf(os.Stderr, "  %-24s %3d of %3d PRs (%.1f%%), latest %s\n",
+			s.login, s.onaPRs, s.prs, s.onaPct, time.Unix(s.lastOna, 0).UTC().Format("2006-01-02"))
+	}
+	fmt.Fprintf(os.Stderr, "Contributors without Ona PRs (%d):\n", len(untouched))
+	for _, s := range untouched {
+		fmt.Fprintf(os.Stderr, "  %-24s %3d PRs, latest %s\n",
+			s.login, s.prs, time.Unix(s.lastPR, 0).UTC().Format("2006-01-02"))
+	}
+}
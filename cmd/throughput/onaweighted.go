@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// pctOnaLines returns the share of lines changed that were in Ona-involved
+// PRs, or -1 when no lines changed.
+func pctOnaLines(onaLines, totalLines int) float64 {
+	if totalLines <= 0 {
+		return -1
+	}
+	return float64(onaLines) / float64(totalLines) * 100
+}
+
+// logOnaWeighted prints Ona uptake over the whole range by PR count and by
+// lines changed. A line share well above the PR share means Ona is used on
+// the larger changes.
+func logOnaWeighted(prs []enrichedPR) {
+	var ona, lines, onaLines int
+	for _, pr := range prs {
+		size := pr.additions + pr.deletions
+		lines += size
+		if pr.onaInvolved {
+			ona++
+			onaLines += size
+		}
+	}
+	if len(prs) == 0 || lines == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Ona uptake: %.1f%% of PRs, %.1f%% of lines changed\n",
+		float64(ona)/float64(len(prs))*100, pctOnaLines(onaLines, lines))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// outlierPolicy controls how extreme cycle-time values are handled before
+// weekly aggregation (--outlier-policy, --outlier-bounds).
+type outlierPolicy struct {
+	mode     string  // "none", "winsorize" (clamp to bounds), or "drop" (treat as not available)
+	lowerPct float64 // lower bound percentile, computed over all PRs in the window
+	upperPct float64 // upper bound percentile
+}
+
+// outlierResult reports how one metric was affected by the outlier policy.
+type outlierResult struct {
+	metric   string
+	lower    float64 // bound values in hours
+	upper    float64
+	affected int
+	total    int
+}
+
+// parseOutlierBounds parses "lo,hi" percentiles, e.g. "1,99".
+func parseOutlierBounds(s string) (float64, float64, error) {
+	loStr, hiStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected lo,hi percentiles, got %q", s)
+	}
+	lo, err := strconv.ParseFloat(strings.TrimSpace(loStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("lower bound: %w", err)
+	}
+	hi, err := strconv.ParseFloat(strings.TrimSpace(hiStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("upper bound: %w", err)
+	}
+	if lo < 0 || hi > 100 || lo >= hi {
+		return 0, 0, fmt.Errorf("bounds must satisfy 0 <= lo < hi <= 100, got %q", s)
+	}
+	return lo, hi, nil
+}
+
+// applyOutlierPolicy clamps or drops cycle-time values outside the configured
+// percentile bounds. Bounds are computed per metric across the whole window,
+// so a single long-lived PR can't dominate the p90 of its merge week. PRs are
+// never removed — only the affected metric value changes — so throughput
+// counts are unaffected.
+func applyOutlierPolicy(prs []enrichedPR, policy outlierPolicy) []outlierResult {
+	if policy.mode == "none" {
+		return nil
+	}
+
+	metrics := []struct {
+		name  string
+		value func(pr *enrichedPR) *float64
+	}{
+		{"coding time", func(pr *enrichedPR) *float64 { return &pr.codingTimeHours }},
+		{"review time", func(pr *enrichedPR) *float64 { return &pr.reviewTimeHours }},
+		{"review turnaround", func(pr *enrichedPR) *float64 { return &pr.reviewTurnaround }},
+	}
+
+	var results []outlierResult
+	for _, m := range metrics {
+		var values []float64
+		for i := range prs {
+			if v := *m.value(&prs[i]); v >= 0 {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		res := outlierResult{
+			metric: m.name,
+			lower:  percentile(values, policy.lowerPct),
+			upper:  percentile(values, policy.upperPct),
+			total:  len(values),
+		}
+		for i := range prs {
+			v := m.value(&prs[i])
+			if *v < 0 || (*v >= res.lower && *v <= res.upper) {
+				continue
+			}
+			res.affected++
+			switch {
+			case policy.mode == "drop":
+				*v = -1
+			case *v < res.lower:
+				*v = res.lower
+			default:
+				*v = res.upper
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// outlierNote describes one metric's outlier handling for logs and the HTML filter notice.
+func outlierNote(policy outlierPolicy, r outlierResult) string {
+	verb := "Winsorized"
+	if policy.mode == "drop" {
+		verb = "Dropped"
+	}
+	return fmt.Sprintf("%s %d of %d %s value(s) outside P%g–P%g (%.1fh – %.1fh)",
+		verb, r.affected, r.total, r.metric, policy.lowerPct, policy.upperPct, r.lower, r.upper)
+}
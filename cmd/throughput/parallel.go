@@ -0,0 +1,44 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+// statsWorkers bounds the goroutines used for post-processing: per-week
+// aggregation, per-metric stats rows, and --sensitivity settings
+// (--stats-workers). Fetching has its own limit, maxConcurrency.
+var statsWorkers = runtime.GOMAXPROCS(0)
+
+// parallelFor calls fn for every index in [0, n) on up to workers
+// goroutines and returns when all calls are done. fn must only write to
+// state owned by its index; callers collect results into a slice indexed by
+// i, which keeps output order independent of scheduling.
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// benchPRs returns n synthetic merged PRs spread over weeks, from 40 authors,
+// with the fields the aggregation and stats read.
+func benchPRs(n int, weeks []weekRange) []enrichedPR {
+	rng := rand.New(rand.NewSource(1))
+	start := weeks[0].start.Unix()
+	span := weeks[len(weeks)-1].end.Unix() - start
+	prs := make([]enrichedPR, n)
+	for i := range prs {
+		prs[i] = enrichedPR{
+			mergedEpoch:        start + rng.Int63n(span),
+			codingTimeHours:    rng.ExpFloat64() * 20,
+			reviewTimeHours:    rng.ExpFloat64() * 10,
+			reviewTurnaround:   rng.ExpFloat64() * 5,
+			additions:          rng.Intn(500),
+			deletions:          rng.Intn(200),
+			changedFiles:       1 + rng.Intn(20),
+			number:             i + 1,
+			authorLogin:        fmt.Sprintf("dev%d", rng.Intn(40)),
+			onaInvolved:        rng.Intn(3) == 0,
+			isRevert:           rng.Intn(50) == 0,
+			issueLeadTimeHours: -1,
+			commitCount:        1 + rng.Intn(5),
+		}
+	}
+	return prs
+}
+
+// withWorkers runs fn with statsWorkers set to workers, or to GOMAXPROCS
+// (which go test -cpu sets) for 0.
+func withWorkers(workers int, fn func()) {
+	saved := statsWorkers
+	statsWorkers = workers
+	if workers == 0 {
+		statsWorkers = runtime.GOMAXPROCS(0)
+	}
+	defer func() { statsWorkers = saved }()
+	fn()
+}
+
+// poolSizes are the serial and pooled variants every benchmark runs.
+var poolSizes = []struct {
+	name    string
+	workers int
+}{
+	{"serial", 1},
+	{"pool", 0},
+}
+
+func benchWeeks(n int) []weekRange {
+	return computeWeekRanges(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), n)
+}
+
+func TestAggregateWeeksPoolMatchesSerial(t *testing.T) {
+	weeks := benchWeeks(26)
+	prs := benchPRs(2000, weeks)
+	var serial, pooled []weekStats
+	withWorkers(1, func() { serial = aggregateWeeks(prs, weeks) })
+	withWorkers(8, func() { pooled = aggregateWeeks(prs, weeks) })
+	if !reflect.DeepEqual(serial, pooled) {
+		t.Fatal("pooled aggregation differs from serial")
+	}
+}
+
+func BenchmarkAggregateWeeks(b *testing.B) {
+	weeks := benchWeeks(104)
+	prs := benchPRs(50000, weeks)
+	for _, p := range poolSizes {
+		b.Run(p.name, func(b *testing.B) {
+			withWorkers(p.workers, func() {
+				for i := 0; i < b.N; i++ {
+					aggregateWeeks(prs, weeks)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkStatsRows(b *testing.B) {
+	weeks := benchWeeks(104)
+	stats := aggregateWeeks(benchPRs(50000, weeks), weeks)
+	for _, p := range poolSizes {
+		b.Run(p.name, func(b *testing.B) {
+			withWorkers(p.workers, func() {
+				for i := 0; i < b.N; i++ {
+					generateStatsTo(io.Discard, stats, 10, 0, "week")
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSensitivity(b *testing.B) {
+	weeks := benchWeeks(52)
+	prs := benchPRs(20000, weeks)
+	measure, _ := contributorMeasureByName("prs")
+	opts := sensitivityOptions{
+		bottomPcts:  []int{0, 5, 10, 20, 30},
+		minPRs:      []int{0, 5, 10},
+		periodLabel: "week",
+		windowPct:   10,
+		outliers:    outlierPolicy{mode: "none"},
+		bottomBy:    measure,
+	}
+	for _, p := range poolSizes {
+		b.Run(p.name, func(b *testing.B) {
+			withWorkers(p.workers, func() {
+				for i := 0; i < b.N; i++ {
+					runSensitivity(prs, weeks, opts)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Custom per-PR metrics.
+//
+// Organizations can compile in their own metrics without forking the
+// aggregation code by adding a file to this package that registers them in an
+// init function:
+//
+//	func init() {
+//		RegisterMetric("pct_touches_payments", func(pr PR) (float64, bool) {
+//			for _, f := range pr.Files.Nodes {
+//				if strings.HasPrefix(f.Path, "services/payments/") {
+//					return 1, true
+//				}
+//			}
+//			return 0, true
+//		}, AggregatePercent)
+//	}
+//
+// Registered metrics are computed for every PR that survives filtering,
+// aggregated per week (and re-aggregated from the raw values per month), and
+// appear as CSV columns, stats rows, correlation targets, and chart series.
+
+// MetricExtractor computes a per-PR value. Returning ok=false skips the PR for
+// this metric (e.g. cycle-time-style metrics that aren't always available).
+type MetricExtractor func(pr PR) (value float64, ok bool)
+
+// MetricAggregator combines the per-PR values of one period into a single value.
+type MetricAggregator func(values []float64) float64
+
+// Built-in aggregators for RegisterMetric.
+var (
+	AggregateSum MetricAggregator = func(vs []float64) float64 {
+		var sum float64
+		for _, v := range vs {
+			sum += v
+		}
+		return sum
+	}
+	AggregateMean MetricAggregator = func(vs []float64) float64 {
+		if len(vs) == 0 {
+			return math.NaN()
+		}
+		return AggregateSum(vs) / float64(len(vs))
+	}
+	AggregateMedian MetricAggregator = func(vs []float64) float64 {
+		if len(vs) == 0 {
+			return math.NaN()
+		}
+		return median(vs)
+	}
+	// AggregatePercent reports the percentage of PRs with a non-zero value.
+	AggregatePercent MetricAggregator = func(vs []float64) float64 {
+		if len(vs) == 0 {
+			return math.NaN()
+		}
+		var hits int
+		for _, v := range vs {
+			if v != 0 {
+				hits++
+			}
+		}
+		return float64(hits) / float64(len(vs)) * 100
+	}
+)
+
+type customMetricDef struct {
+	name      string
+	extract   MetricExtractor
+	aggregate MetricAggregator
+}
+
+// customMetricDefs holds metrics registered via RegisterMetric, in registration order.
+var customMetricDefs []customMetricDef
+
+// userMetricNames lists all user-defined metrics (--series and RegisterMetric)
+// in the order their CSV columns and chart series appear.
+var userMetricNames []string
+
+// RegisterMetric adds a custom per-PR metric. It must be called before main
+// runs (i.e. from init). Names must be unique and should be snake_case since
+// they become CSV column headers.
+func RegisterMetric(name string, extract MetricExtractor, aggregate MetricAggregator) {
+	for _, existing := range userMetricNames {
+		if existing == name {
+			panic(fmt.Sprintf("RegisterMetric: duplicate metric name %q", name))
+		}
+	}
+	customMetricDefs = append(customMetricDefs, customMetricDef{name: name, extract: extract, aggregate: aggregate})
+	registerUserMetric(name, true)
+}
+
+// registerUserMetric adds a user-defined metric to the stats metric list so it
+// flows through before/after comparison and correlations like a built-in one.
+// engineer is its metricDef.engineer: true for per-PR (RegisterMetric)
+// metrics.
+func registerUserMetric(name string, engineer bool) {
+	userMetricNames = append(userMetricNames, name)
+	allMetrics = append(allMetrics, metricDef{
+		name: name,
+		extract: func(ws weekStats) float64 {
+			if v, ok := ws.external[name]; ok {
+				return v
+			}
+			return math.NaN()
+		},
+		valid: func(ws weekStats) bool {
+			v, ok := ws.external[name]
+			return ok && !math.IsNaN(v)
+		},
+		doc:      userMetricDoc(name),
+		format:   formatUserMetric,
+		column:   everyRun,
+		engineer: engineer,
+		label:    name,
+		category: "activity",
+	})
+}
+
+// extractCustomMetrics evaluates all registered custom metrics for one PR.
+func extractCustomMetrics(pr PR) map[string]float64 {
+	if len(customMetricDefs) == 0 {
+		return nil
+	}
+	values := make(map[string]float64, len(customMetricDefs))
+	for _, def := range customMetricDefs {
+		if v, ok := def.extract(pr); ok {
+			values[def.name] = v
+		}
+	}
+	return values
+}
+
+// aggregateCustomMetrics sets weekStats.external for each custom metric from
+// the raw per-PR values collected in customValues.
+func aggregateCustomMetrics(ws *weekStats) {
+	for _, def := range customMetricDefs {
+		if ws.external == nil {
+			ws.external = make(map[string]float64)
+		}
+		vals := ws.customValues[def.name]
+		if len(vals) == 0 {
+			ws.external[def.name] = math.NaN()
+			continue
+		}
+		ws.external[def.name] = def.aggregate(vals)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+)
+
+// prDetailsHeader lists the per-PR export columns (--pr-output).
+var prDetailsHeader = []string{
+	"number", "title", "author", "merged_at",
+	"coding_time_hours", "review_time_hours", "review_turnaround_hours",
+	"first_commit_method", "additions", "deletions", "changed_files",
+	"ona_involved", "is_revert", "revert_signal",
+	"reviews", "changes_requested", "review_threads", "unresolved_threads",
+	"reopen_count",
+}
+
+// writePRDetailsCSV writes one row per PR that survived filtering, so
+// individual values behind the weekly medians can be audited. Cycle-time
+// values that are not available are left empty, as are the review counts
+// without --enrich-reviews. With redact (--anonymize), number and title are
+// left empty since either identifies the author. From schema version 2, rows
+// end with the version.
+func writePRDetailsCSV(path string, prs []enrichedPR, redact bool, version int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := prDetailsHeader
+	var trailer []string
+	if embedsSchemaVersion(version) {
+		header = append(append([]string{}, header...), schemaVersionColumn)
+		trailer = []string{strconv.Itoa(version)}
+	}
+	w.Write(header)
+	for _, pr := range prs {
+		number, title := strconv.Itoa(pr.number), pr.title
+		if redact {
+			number, title = "", ""
+		}
+		if pr.number == 0 {
+			number = "" // --local-git commits have no PR number
+		}
+		reviewCols := []string{"", "", "", ""}
+		if pr.reviewsEnriched {
+			reviewCols = []string{
+				strconv.Itoa(pr.reviewCount),
+				strconv.Itoa(pr.changesRequested),
+				strconv.Itoa(pr.reviewThreads),
+				strconv.Itoa(pr.unresolvedThreads),
+			}
+		}
+		w.Write(append([]string{
+			number,
+			title,
+			pr.authorLogin,
+			time.Unix(pr.mergedEpoch, 0).UTC().Format(time.RFC3339),
+			formatPercentile(pr.codingTimeHours),
+			formatPercentile(pr.reviewTimeHours),
+			formatPercentile(pr.reviewTurnaround),
+			pr.firstCommitMethod,
+			strconv.Itoa(pr.additions),
+			strconv.Itoa(pr.deletions),
+			strconv.Itoa(pr.changedFiles),
+			strconv.FormatBool(pr.onaInvolved),
+			strconv.FormatBool(pr.isRevert),
+			pr.revertSignal,
+		}, append(append(reviewCols, strconv.Itoa(pr.reopenCount)), trailer...)...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
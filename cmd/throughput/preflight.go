@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkRepoAccess probes the repository and its pull requests with the
+// token before anything is fetched. GitHub answers queries for a repository
+// the token can't see, because the token isn't authorized for the org's SAML
+// SSO or is a fine-grained PAT not granted the repository, with empty search
+// results rather than an error, which would otherwise turn into a report of
+// empty weeks. The returned error says what to change.
+//
+// Unless skipData (--no-preflight), it then checks the branch exists and at
+// least one PR was merged into it between start and end, so a wrong --branch
+// or window fails with a diagnosis instead of an all-zero CSV.
+func checkRepoAccess(cfg config, start, end time.Time, skipData bool) error {
+	window := fmt.Sprintf("merged:%s..%s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	query := fmt.Sprintf(`{
+  viewer { login }
+  repository(owner: %q, name: %q) {
+    visibility
+    defaultBranchRef { name }
+    ref(qualifiedName: %q) { name }
+    pullRequests(states: MERGED) { totalCount }
+    latest: pullRequests(baseRefName: %q, states: MERGED, first: 1, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      nodes { mergedAt }
+    }
+  }
+  onBranch: search(query: %q, type: ISSUE) { issueCount }
+  anyBranch: search(query: %q, type: ISSUE) { issueCount }
+}`, cfg.owner, cfg.repo, "refs/heads/"+cfg.branch, cfg.branch,
+		fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s %s", cfg.owner, cfg.repo, cfg.branch, window),
+		fmt.Sprintf("repo:%s/%s is:pr is:merged %s", cfg.owner, cfg.repo, window))
+	resp, err := graphqlQuery(cfg.token, query)
+	if err != nil {
+		return err
+	}
+	type count struct {
+		IssueCount int `json:"issueCount"`
+	}
+	var result struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+		Repository *struct {
+			Visibility       string `json:"visibility"`
+			DefaultBranchRef *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+			Ref *struct {
+				Name string `json:"name"`
+			} `json:"ref"`
+			PullRequests *struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"pullRequests"`
+			Latest struct {
+				Nodes []struct {
+					MergedAt time.Time `json:"mergedAt"`
+				} `json:"nodes"`
+			} `json:"latest"`
+		} `json:"repository"`
+		OnBranch  count `json:"onBranch"`
+		AnyBranch count `json:"anyBranch"`
+	}
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	repo := result.Repository
+	if repo == nil || repo.PullRequests == nil {
+		return recordFailure(classified(errAuth, accessError(cfg.token, cfg.owner, cfg.repo, result.Viewer.Login, repo != nil, resp.Errors)))
+	}
+	if skipData {
+		return nil
+	}
+
+	defaultBranch := ""
+	if repo.DefaultBranchRef != nil {
+		defaultBranch = repo.DefaultBranchRef.Name
+	}
+	if repo.Ref == nil {
+		if defaultBranch != "" && defaultBranch != cfg.branch {
+			return classified(errNoData, fmt.Errorf("branch '%s' not found; default branch is '%s' (use --branch %s)", cfg.branch, defaultBranch, defaultBranch))
+		}
+		return classified(errNoData, fmt.Errorf("branch '%s' not found", cfg.branch))
+	}
+	if result.OnBranch.IssueCount > 0 {
+		return nil
+	}
+	var hints []string
+	if other := result.AnyBranch.IssueCount; other > 0 {
+		hint := fmt.Sprintf("%d PR(s) were merged into other branches in that time", other)
+		if defaultBranch != "" && defaultBranch != cfg.branch {
+			hint += fmt.Sprintf("; default branch is '%s'", defaultBranch)
+		}
+		hints = append(hints, hint)
+	}
+	if nodes := repo.Latest.Nodes; len(nodes) > 0 {
+		hints = append(hints, fmt.Sprintf("the latest PR merged into '%s' was merged on %s (try a larger --weeks)", cfg.branch, nodes[0].MergedAt.Format("2006-01-02")))
+	} else {
+		hints = append(hints, fmt.Sprintf("no PR was ever merged into '%s'", cfg.branch))
+	}
+	return classified(errNoData, fmt.Errorf("no PRs merged into '%s' between %s and %s: %s. Use --no-preflight to write the empty report anyway",
+		cfg.branch, start.Format("2006-01-02"), end.Format("2006-01-02"), strings.Join(hints, "; ")))
+}
+
+// accessError explains why the repository or its pull requests couldn't be
+// read: SAML SSO, a fine-grained PAT's grants, or a plain not-found.
+func accessError(token, owner, repo, login string, repoVisible bool, errs []graphqlError) error {
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	detail := strings.Join(messages, "; ")
+	fineGrained := strings.HasPrefix(token, "github_pat_")
+
+	if sso := ssoRequirement(token, owner, repo); sso != "" {
+		return fmt.Errorf("the token isn't authorized for %s's SAML single sign-on, so GitHub hides the repository's data instead of failing. %s", owner, sso)
+	}
+	if strings.Contains(strings.ToLower(detail), "saml") {
+		return fmt.Errorf("the token isn't authorized for %s's SAML single sign-on (%s). Authorize it for the organization: Settings → Developer settings → the token → Configure SSO (classic PATs), or re-authenticate with gh auth refresh", owner, detail)
+	}
+
+	if repoVisible {
+		// The repository resolves but its pull requests don't
+		if fineGrained {
+			return fmt.Errorf("the fine-grained token can read %s/%s but not its pull requests (%s). Grant it read access to \"Pull requests\" (and \"Contents\" for commits) in the token's repository permissions", owner, repo, detail)
+		}
+		return fmt.Errorf("the token can read %s/%s but not its pull requests: %s", owner, repo, detail)
+	}
+	if fineGrained {
+		return fmt.Errorf("repository %s/%s not found with this fine-grained token (%s). Fine-grained tokens only see repositories of their resource owner that they were granted: check the token's resource owner is %s, the repository is selected, and the organization has approved the token if it requires approval", owner, repo, detail, owner)
+	}
+	return fmt.Errorf("repository %s/%s not found or not visible to %s (%s). Check the --repo spelling, and for private repositories that the token has the repo scope", owner, repo, login, detail)
+}
+
+// ssoRequirement asks the REST API for the repository and returns GitHub's
+// SSO hint, "" if it gives none. A token lacking SSO authorization gets an
+// X-GitHub-SSO header naming the URL that authorizes it.
+func ssoRequirement(token, owner, repo string) string {
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo), nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	apiUsage.rest.Add(1)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ""
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// "required; url=https://github.com/orgs/acme/sso?authorization_request=..."
+	header := resp.Header.Get("X-GitHub-SSO")
+	if header == "" {
+		return ""
+	}
+	if _, u, ok := strings.Cut(header, "url="); ok {
+		return "Authorize the token by opening " + strings.TrimSpace(u)
+	}
+	return "Authorize the token for the organization: Settings → Developer settings → the token → Configure SSO, or re-authenticate with gh auth refresh"
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Branch protection can only be read as it is now, so --branch-protection
+// records what it finds next to the --store snapshot, one JSON object per
+// line:
+//
+//	<store>/<owner>/<repo>.protection.jsonl
+//
+// A line is added when the settings differ from the last one recorded for
+// the branch, and a second run on the same date replaces that date's line.
+// Two consecutive lines that differ are a change made somewhere between the
+// two observations, so scheduled runs date changes to within their interval.
+
+// protectionSnapshot is the branch's protection as read on one date.
+type protectionSnapshot struct {
+	Date            string   `json:"date"` // YYYY-MM-DD the settings were read
+	Branch          string   `json:"branch"`
+	Protected       bool     `json:"protected"`
+	RequiredReviews int      `json:"required_reviews"` // approving reviews; -1 if unreadable (classic protection needs admin access)
+	RequiredChecks  []string `json:"required_checks"`  // status check contexts, sorted
+}
+
+func (p protectionSnapshot) sameSettings(q protectionSnapshot) bool {
+	return p.Protected == q.Protected && p.RequiredReviews == q.RequiredReviews && slices.Equal(p.RequiredChecks, q.RequiredChecks)
+}
+
+// describe summarizes the settings, e.g. "2 required reviews, required
+// checks: build, lint".
+func (p protectionSnapshot) describe() string {
+	if !p.Protected {
+		return "unprotected"
+	}
+	var parts []string
+	switch p.RequiredReviews {
+	case -1:
+		parts = append(parts, "required reviews unknown (needs admin access)")
+	case 1:
+		parts = append(parts, "1 required review")
+	default:
+		parts = append(parts, fmt.Sprintf("%d required reviews", p.RequiredReviews))
+	}
+	if len(p.RequiredChecks) == 0 {
+		parts = append(parts, "no required checks")
+	} else {
+		parts = append(parts, "required checks: "+strings.Join(p.RequiredChecks, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// protectionChange is a difference between two consecutive observations.
+type protectionChange struct {
+	from, to protectionSnapshot
+}
+
+// describe lists what changed, e.g. "required reviews 1 → 2; checks added:
+// e2e".
+func (c protectionChange) describe() string {
+	if c.from.Protected != c.to.Protected {
+		if c.to.Protected {
+			return "protection enabled: " + c.to.describe()
+		}
+		return "protection removed"
+	}
+	var parts []string
+	if c.from.RequiredReviews != c.to.RequiredReviews && c.from.RequiredReviews >= 0 && c.to.RequiredReviews >= 0 {
+		parts = append(parts, fmt.Sprintf("required reviews %d → %d", c.from.RequiredReviews, c.to.RequiredReviews))
+	}
+	var added, removed []string
+	for _, check := range c.to.RequiredChecks {
+		if !slices.Contains(c.from.RequiredChecks, check) {
+			added = append(added, check)
+		}
+	}
+	for _, check := range c.from.RequiredChecks {
+		if !slices.Contains(c.to.RequiredChecks, check) {
+			removed = append(removed, check)
+		}
+	}
+	if len(added) > 0 {
+		parts = append(parts, "checks added: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "checks removed: "+strings.Join(removed, ", "))
+	}
+	if len(parts) == 0 {
+		return c.from.describe() + " → " + c.to.describe()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// fetchBranchProtection reads the branch's classic protection and the
+// repository rulesets that apply to it, merged: the highest required review
+// count and the union of required checks. The branch endpoint is readable
+// with read access; the review count of classic protection needs admin
+// access and is -1 without it unless a ruleset sets one.
+func fetchBranchProtection(token, owner, repo, branch string) (protectionSnapshot, error) {
+	base := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
+	snap := protectionSnapshot{Date: time.Now().UTC().Format("2006-01-02"), Branch: branch}
+	checks := make(map[string]bool)
+
+	var br struct {
+		Protected  bool `json:"protected"`
+		Protection struct {
+			RequiredStatusChecks struct {
+				Contexts []string `json:"contexts"`
+			} `json:"required_status_checks"`
+		} `json:"protection"`
+	}
+	if ok, err := protectionGET(token, base+"/branches/"+url.PathEscape(branch), &br); err != nil {
+		return snap, err
+	} else if !ok {
+		return snap, fmt.Errorf("branch %s not found or not readable", branch)
+	}
+	if br.Protected {
+		snap.Protected = true
+		snap.RequiredReviews = -1
+		for _, c := range br.Protection.RequiredStatusChecks.Contexts {
+			checks[c] = true
+		}
+		var prot struct {
+			RequiredPullRequestReviews *struct {
+				RequiredApprovingReviewCount int `json:"required_approving_review_count"`
+			} `json:"required_pull_request_reviews"`
+		}
+		ok, err := protectionGET(token, base+"/branches/"+url.PathEscape(branch)+"/protection", &prot)
+		if err != nil {
+			return snap, err
+		}
+		if ok {
+			snap.RequiredReviews = 0
+			if r := prot.RequiredPullRequestReviews; r != nil {
+				snap.RequiredReviews = r.RequiredApprovingReviewCount
+			}
+		}
+	}
+
+	var rules []struct {
+		Type       string `json:"type"`
+		Parameters struct {
+			RequiredApprovingReviewCount int `json:"required_approving_review_count"`
+			RequiredStatusChecks         []struct {
+				Context string `json:"context"`
+			} `json:"required_status_checks"`
+		} `json:"parameters"`
+	}
+	// Older GitHub Enterprise Server versions have no rulesets
+	if ok, err := protectionGET(token, base+"/rules/branches/"+url.PathEscape(branch), &rules); err != nil {
+		return snap, err
+	} else if ok {
+		for _, r := range rules {
+			switch r.Type {
+			case "pull_request":
+				snap.Protected = true
+				snap.RequiredReviews = max(snap.RequiredReviews, r.Parameters.RequiredApprovingReviewCount)
+			case "required_status_checks":
+				snap.Protected = true
+				for _, c := range r.Parameters.RequiredStatusChecks {
+					checks[c.Context] = true
+				}
+			}
+		}
+	}
+
+	snap.RequiredChecks = []string{}
+	for c := range checks {
+		snap.RequiredChecks = append(snap.RequiredChecks, c)
+	}
+	sort.Strings(snap.RequiredChecks)
+	return snap, nil
+}
+
+// protectionGET fetches a GitHub REST resource with retry on transport and
+// server errors. A 403 or 404 returns false rather than an error: GitHub
+// answers both for resources the token may not read.
+func protectionGET(token, endpoint string, out any) (bool, error) {
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return false, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+		apiUsage.rest.Add(1)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			return false, nil
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("REST API returned %d", resp.StatusCode)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("GET %s returned %d: %s", endpoint, resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		return true, json.Unmarshal(data, out)
+	}
+	return false, fmt.Errorf("REST request failed after 3 attempts: %v", lastErr)
+}
+
+func protectionPath(dir, owner, repo string) (string, error) {
+	path, err := snapshotPath(dir, owner, strings.ReplaceAll(repo, "/", "__"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".json") + ".protection.jsonl", nil
+}
+
+// recordProtection adds snap to the protection history unless the branch's
+// last entry has the same settings, and returns the branch's history, oldest
+// first.
+func recordProtection(dir, owner, repo string, snap protectionSnapshot) ([]protectionSnapshot, error) {
+	path, err := protectionPath(dir, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var entries []protectionSnapshot
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e protectionSnapshot
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	last := -1
+	for i, e := range entries {
+		if e.Branch == snap.Branch {
+			last = i
+		}
+	}
+	switch {
+	case last >= 0 && entries[last].sameSettings(snap):
+	case last >= 0 && entries[last].Date == snap.Date:
+		entries[last] = snap
+	default:
+		entries = append(entries, snap)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date < entries[j].Date })
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	var branch []protectionSnapshot
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+		if e.Branch == snap.Branch {
+			branch = append(branch, e)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return branch, os.Rename(tmp, path)
+}
+
+// protectionChanges returns the changes between consecutive entries of one
+// branch's history.
+func protectionChanges(history []protectionSnapshot) []protectionChange {
+	var changes []protectionChange
+	for i := 1; i < len(history); i++ {
+		if !history[i-1].sameSettings(history[i]) {
+			changes = append(changes, protectionChange{history[i-1], history[i]})
+		}
+	}
+	return changes
+}
+
+// htmlProtectionChange is a --branch-protection change marked on the chart.
+type htmlProtectionChange struct {
+	Period  int    `json:"period"` // chart period index
+	From    string `json:"from"`   // last observation with the old settings
+	To      string `json:"to"`     // first observation with the new ones
+	Summary string `json:"summary"`
+}
+
+// protectionMarkers places each change in the chart period it was observed
+// in. A change observed after the last period, but possibly made during the
+// chart (the previous observation falls before its end), goes on the last
+// period; changes entirely outside the chart are dropped.
+func protectionMarkers(changes []protectionChange, periods []weekRange) []htmlProtectionChange {
+	if len(periods) == 0 {
+		return nil
+	}
+	first, last := periods[0].start.Format("2006-01-02"), periods[len(periods)-1].end.Format("2006-01-02")
+	var markers []htmlProtectionChange
+	for _, c := range changes {
+		if c.to.Date < first || c.from.Date > last {
+			continue
+		}
+		period := len(periods) - 1
+		if t, err := time.Parse("2006-01-02", c.to.Date); err == nil {
+			if i := weekIndex(periods, t.Unix()); i >= 0 {
+				period = i
+			}
+		}
+		markers = append(markers, htmlProtectionChange{
+			Period:  period,
+			From:    c.from.Date,
+			To:      c.to.Date,
+			Summary: c.describe(),
+		})
+	}
+	return markers
+}
+
+// protectionNotes returns the filter notes for --branch-protection: the
+// current settings and each change within the chart.
+func protectionNotes(current protectionSnapshot, markers []htmlProtectionChange) []string {
+	notes := []string{fmt.Sprintf("Branch protection on %s as of %s: %s", current.Branch, current.Date, current.describe())}
+	for _, m := range markers {
+		notes = append(notes, fmt.Sprintf("Branch protection changed between %s and %s (%s); before/after comparisons across it mix two review processes", m.From, m.To, m.Summary))
+	}
+	return notes
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// prTemplatePaths are where GitHub looks for a repository's default PR
+// template, in its order of precedence.
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// markdownHeadingRe matches an ATX heading line: "## Summary", "### Test plan ##".
+var markdownHeadingRe = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+
+// prTemplate is the set of sections --template-compliance requires in PR
+// descriptions.
+type prTemplate struct {
+	source   string            // template path, or "--template-sections"
+	sections []string          // headings, as written
+	defaults map[string]string // normalized heading → the template's own placeholder content
+}
+
+// normalizeHeading makes headings comparable: case, surrounding emphasis,
+// leading emoji or numbering, and a trailing colon don't matter.
+func normalizeHeading(h string) string {
+	h = strings.TrimLeftFunc(h, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	h = strings.TrimRight(strings.TrimSpace(h), ":*_ ")
+	return strings.ToLower(h)
+}
+
+// markdownSections splits a description into its headed sections, keyed by
+// normalized heading. Content has template comments removed and whitespace
+// collapsed; text before the first heading is ignored.
+func markdownSections(body string) map[string]string {
+	sections := make(map[string]string)
+	body = htmlCommentRe.ReplaceAllString(strings.ReplaceAll(body, "\r\n", "\n"), "")
+	var heading string
+	var content []string
+	flush := func() {
+		if heading != "" {
+			sections[heading] = strings.Join(strings.Fields(strings.Join(content, " ")), " ")
+		}
+	}
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if m := markdownHeadingRe.FindStringSubmatch(line); m != nil && !inFence {
+			flush()
+			heading, content = normalizeHeading(m[1]), nil
+			continue
+		}
+		content = append(content, line)
+	}
+	flush()
+	return sections
+}
+
+// templateCompliant reports whether a PR's description has every required
+// section with content of its own: not empty, and not just the template's
+// placeholder text.
+func (t *prTemplate) templateCompliant(sections map[string]string) bool {
+	for _, s := range t.sections {
+		key := normalizeHeading(s)
+		content, ok := sections[key]
+		if !ok || content == "" || content == t.defaults[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// newPRTemplate builds the required sections from a template body, or from
+// the --template-sections list when one is given.
+func newPRTemplate(source, body string, headings []string) (*prTemplate, error) {
+	t := &prTemplate{source: source, defaults: make(map[string]string)}
+	if len(headings) > 0 {
+		t.sections = headings
+		return t, nil
+	}
+	for _, line := range strings.Split(htmlCommentRe.ReplaceAllString(body, ""), "\n") {
+		if m := markdownHeadingRe.FindStringSubmatch(line); m != nil && normalizeHeading(m[1]) != "" {
+			t.sections = append(t.sections, strings.TrimSpace(m[1]))
+		}
+	}
+	if len(t.sections) == 0 {
+		return nil, fmt.Errorf("%s has no markdown headings; pass --template-sections", source)
+	}
+	for key, content := range markdownSections(body) {
+		t.defaults[key] = content
+	}
+	return t, nil
+}
+
+// loadPRTemplate reads the repository's default PR template from the first
+// of prTemplatePaths that exists on the branch: through the contents API on
+// GitHub, or from the clone with --local-git.
+func loadPRTemplate(cfg config) (*prTemplate, error) {
+	for _, p := range prTemplatePaths {
+		var body string
+		var found bool
+		var err error
+		switch cfg.provider {
+		case "github":
+			body, found, err = fetchRepoFile(cfg.token, cfg.owner, cfg.repo, cfg.branch, p)
+		case "git":
+			var out []byte
+			out, err = exec.Command("git", "-C", cfg.gitDir, "show", cfg.branch+":"+p).Output()
+			body, found, err = string(out), err == nil, nil
+		default:
+			return nil, fmt.Errorf("--provider %s can't read the repository's PR template; pass --template-sections", cfg.provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return newPRTemplate(p, body, nil)
+		}
+	}
+	return nil, fmt.Errorf("no PR template found in %s on %s; pass --template-sections", strings.Join(prTemplatePaths, ", "), cfg.branch)
+}
+
+// fetchRepoFile reads a file's raw content on a branch through the contents
+// API. A missing file is not an error.
+func fetchRepoFile(token, owner, repo, branch, path string) (string, bool, error) {
+	endpoint := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s", owner, repo, path, url.QueryEscape(branch))
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return "", false, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github.raw+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+		apiUsage.rest.Add(1)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return "", false, nil
+		}
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("REST API returned %d", resp.StatusCode)
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", false, fmt.Errorf("GET %s returned %d: %s", endpoint, resp.StatusCode, string(data[:min(200, len(data))]))
+		}
+		return string(data), true, nil
+	}
+	return "", false, fmt.Errorf("REST request failed after 3 attempts: %v", lastErr)
+}
+
+// applyTemplateCompliance sets the weekly share of merged PRs whose
+// description fills every required section, and logs how often each section
+// was missing or left as in the template. Weeks without PRs are left at -1.
+func applyTemplateCompliance(prs []enrichedPR, weeks []weekRange, stats []weekStats, t *prTemplate) {
+	type counts struct{ total, compliant int }
+	byWeek := make([]counts, len(weeks))
+	missing := make(map[string]int)
+	var total int
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		total++
+		byWeek[i].total++
+		if t.templateCompliant(pr.sections) {
+			byWeek[i].compliant++
+			continue
+		}
+		for _, s := range t.sections {
+			key := normalizeHeading(s)
+			if c := pr.sections[key]; c == "" || c == t.defaults[key] {
+				missing[s]++
+			}
+		}
+	}
+	for i, c := range byWeek {
+		stats[i].templateTracked = true
+		stats[i].pctTemplateCompliant = -1
+		if c.total > 0 {
+			stats[i].pctTemplateCompliant = float64(c.compliant) / float64(c.total) * 100
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "PR template sections from %s, left unfilled (of %d merged PRs):\n", t.source, total)
+	for _, s := range t.sections {
+		fmt.Fprintf(os.Stderr, "  %-30s %d\n", s, missing[s])
+	}
+}
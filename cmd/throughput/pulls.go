@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// fetchModes are the --fetch values: how merged PRs are listed.
+var fetchModes = []string{"auto", "search", "pulls"}
+
+// fetchPullsByRepository lists the merged PRs into the branch through
+// repository.pullRequests instead of the search API, which has no
+// 1000-result ceiling per query and no index lag. The connection can't be
+// ordered by merge time, so it is read by last update, newest first, until
+// a page is older than the earliest week: a PR is updated when merged, so
+// every PR merged in the weeks comes before that. PRs merged outside the
+// weeks are dropped. Pages are read one after another, so this is slower
+// than the per-week searches for repositories with few PRs. It returns the
+// weeks that may be incomplete: all of them when a page failed.
+func fetchPullsByRepository(cfg config, weeks []weekRange) ([]PR, []weekRange) {
+	if len(weeks) == 0 {
+		return nil, nil
+	}
+	earliest := weeks[0].start
+	for _, wr := range weeks {
+		if wr.start.Before(earliest) {
+			earliest = wr.start
+		}
+	}
+
+	var prs []PR
+	var pages, scanned int
+	after := ""
+	for {
+		query := fmt.Sprintf(`{
+			repository(owner: %q, name: %q) {
+				pullRequests(baseRefName: %q, states: MERGED, first: 100%s, orderBy: {field: UPDATED_AT, direction: DESC}) {
+					pageInfo { hasNextPage endCursor }
+					nodes {
+						updatedAt
+						%s
+					}
+				}
+			}
+		}`, cfg.owner, cfg.repo, cfg.branch, after, prFields(cfg))
+		resp, err := graphqlQuery(cfg.token, query)
+		if err == nil && len(resp.Errors) > 0 {
+			err = fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: pull request query failed after %d page(s): %v\n", pages, err)
+			return prs, weeks
+		}
+		var result struct {
+			Repository struct {
+				PullRequests struct {
+					PageInfo pageInfo          `json:"pageInfo"`
+					Nodes    []json.RawMessage `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Failed to parse pull request response: %v\n", err)
+			return prs, weeks
+		}
+		pages++
+
+		older := false
+		for _, raw := range result.Repository.PullRequests.Nodes {
+			var node struct {
+				UpdatedAt time.Time `json:"updatedAt"`
+			}
+			var pr PR
+			if json.Unmarshal(raw, &node) != nil || json.Unmarshal(raw, &pr) != nil || pr.Number == 0 {
+				continue
+			}
+			scanned++
+			if node.UpdatedAt.Before(earliest) {
+				older = true
+				break
+			}
+			if weekIndex(weeks, pr.MergedAt.Unix()) >= 0 {
+				prs = append(prs, pr)
+			}
+		}
+		if pages%10 == 0 {
+			fmt.Fprintf(os.Stderr, "  %d PRs scanned, %d merged in the window\n", scanned, len(prs))
+		}
+
+		conn := result.Repository.PullRequests
+		if older || !conn.PageInfo.HasNextPage {
+			break
+		}
+		after = fmt.Sprintf(", after: %q", conn.PageInfo.EndCursor)
+	}
+
+	fmt.Fprintf(os.Stderr, "Total PRs fetched: %d (%d scanned in %d page(s))\n", len(prs), scanned, pages)
+	return prs, nil
+}
+
+// weekSearchCounts returns how many merged PRs the search API finds for each
+// week, querying up to 50 weeks per request.
+func weekSearchCounts(cfg config, weeks []weekRange) ([]int, error) {
+	counts := make([]int, len(weeks))
+	for lo := 0; lo < len(weeks); lo += 50 {
+		hi := min(lo+50, len(weeks))
+		var sb strings.Builder
+		sb.WriteString("{\n")
+		for i := lo; i < hi; i++ {
+			q := fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s merged:%s..%s", cfg.owner, cfg.repo, cfg.branch,
+				weeks[i].start.Format("2006-01-02"), weeks[i].end.Format("2006-01-02"))
+			fmt.Fprintf(&sb, "  w%d: search(query: %q, type: ISSUE) { issueCount }\n", i, q)
+		}
+		sb.WriteString("}")
+		resp, err := graphqlQuery(cfg.token, sb.String())
+		if err != nil {
+			return nil, err
+		}
+		var result map[string]struct {
+			IssueCount int `json:"issueCount"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		for i := lo; i < hi; i++ {
+			counts[i] = result[fmt.Sprintf("w%d", i)].IssueCount
+		}
+	}
+	return counts, nil
+}
+
+// chooseFetchMode resolves --fetch auto: the repository listing when a
+// week has more merged PRs than one search returns, else the per-week
+// searches.
+func chooseFetchMode(cfg config, mode string, weeks []weekRange) string {
+	if mode != "auto" {
+		return mode
+	}
+	counts, err := weekSearchCounts(cfg, weeks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  WARNING: could not count PRs per week (%v); using the search API\n", err)
+		return "search"
+	}
+	for i, n := range counts {
+		if n > searchResultLimit {
+			fmt.Fprintf(os.Stderr, "Week %s has %d merged PRs, more than the search API returns (%d); listing the repository's PRs instead\n",
+				weeks[i].start.Format("2006-01-02"), n, searchResultLimit)
+			return "pulls"
+		}
+	}
+	return "search"
+}
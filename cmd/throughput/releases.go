@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReleasePages caps the pages of 100 releases or tags read, newest first,
+// until one older than the window is found.
+const maxReleasePages = 10
+
+// release is a published release or a tag.
+type release struct {
+	name string
+	date time.Time
+}
+
+// fetchReleases returns the repository's releases (source "releases": GitHub
+// releases, drafts and prereleases left out) or tags (source "tags", or any
+// source with --local-git), oldest first. Names not matching pattern are
+// skipped. Reading stops at the first release before since, which is kept
+// so the window's first release has a predecessor.
+func fetchReleases(cfg config, source string, pattern *regexp.Regexp, since time.Time) ([]release, error) {
+	var all []release
+	var err error
+	switch {
+	case cfg.provider == "git":
+		all, err = localGitTags(cfg.gitDir)
+	case source == "tags":
+		all, err = fetchTags(cfg, since)
+	default:
+		all, err = fetchGitHubReleases(cfg, since)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []release
+	for _, r := range all {
+		if pattern == nil || pattern.MatchString(r.name) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].date.Before(out[j].date) })
+	// Keep the last release before the window, drop anything older
+	first := sort.Search(len(out), func(i int) bool { return !out[i].date.Before(since) })
+	if first > 0 {
+		out = out[first-1:]
+	}
+	return out, nil
+}
+
+// fetchGitHubReleases pages the repository's published releases, newest
+// first, until one was published before since.
+func fetchGitHubReleases(cfg config, since time.Time) ([]release, error) {
+	var out []release
+	after := ""
+	for page := 0; page < maxReleasePages; page++ {
+		query := fmt.Sprintf(`{
+  repository(owner: %q, name: %q) {
+    releases(first: 100%s, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { tagName publishedAt isDraft isPrerelease }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`, cfg.owner, cfg.repo, after)
+		resp, err := graphqlQuery(cfg.token, query)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Repository struct {
+				Releases struct {
+					Nodes []struct {
+						TagName      string     `json:"tagName"`
+						PublishedAt  *time.Time `json:"publishedAt"`
+						IsDraft      bool       `json:"isDraft"`
+						IsPrerelease bool       `json:"isPrerelease"`
+					} `json:"nodes"`
+					PageInfo pageInfo `json:"pageInfo"`
+				} `json:"releases"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		rs := result.Repository.Releases
+		done := false
+		for _, n := range rs.Nodes {
+			if n.IsDraft || n.IsPrerelease || n.PublishedAt == nil {
+				continue
+			}
+			out = append(out, release{name: n.TagName, date: *n.PublishedAt})
+			done = done || n.PublishedAt.Before(since)
+		}
+		if done || !rs.PageInfo.HasNextPage {
+			break
+		}
+		after = fmt.Sprintf(", after: %q", rs.PageInfo.EndCursor)
+	}
+	return out, nil
+}
+
+// fetchTags pages the repository's tags, newest commit first, until one
+// points before since. A tag's date is its tagger date for annotated tags
+// and its commit's date otherwise.
+func fetchTags(cfg config, since time.Time) ([]release, error) {
+	var out []release
+	after := ""
+	for page := 0; page < maxReleasePages; page++ {
+		query := fmt.Sprintf(`{
+  repository(owner: %q, name: %q) {
+    refs(refPrefix: "refs/tags/", first: 100%s, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+      nodes {
+        name
+        target {
+          ... on Commit { committedDate }
+          ... on Tag { tagger { date } target { ... on Commit { committedDate } } }
+        }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`, cfg.owner, cfg.repo, after)
+		resp, err := graphqlQuery(cfg.token, query)
+		if err != nil {
+			return nil, err
+		}
+		type commitDate struct {
+			CommittedDate *time.Time `json:"committedDate"`
+		}
+		var result struct {
+			Repository struct {
+				Refs struct {
+					Nodes []struct {
+						Name   string `json:"name"`
+						Target struct {
+							commitDate
+							Tagger *struct {
+								Date *time.Time `json:"date"`
+							} `json:"tagger"`
+							Target *commitDate `json:"target"`
+						} `json:"target"`
+					} `json:"nodes"`
+					PageInfo pageInfo `json:"pageInfo"`
+				} `json:"refs"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		refs := result.Repository.Refs
+		done := false
+		for _, n := range refs.Nodes {
+			var date *time.Time
+			switch t := n.Target; {
+			case t.Tagger != nil && t.Tagger.Date != nil:
+				date = t.Tagger.Date
+			case t.CommittedDate != nil:
+				date = t.CommittedDate
+			case t.Target != nil:
+				date = t.Target.CommittedDate
+			}
+			if date == nil {
+				continue
+			}
+			out = append(out, release{name: n.Name, date: *date})
+			done = done || date.Before(since)
+		}
+		if done || !refs.PageInfo.HasNextPage {
+			break
+		}
+		after = fmt.Sprintf(", after: %q", refs.PageInfo.EndCursor)
+	}
+	return out, nil
+}
+
+// localGitTags lists a clone's tags with their creation date: the tagger
+// date for annotated tags, the commit date otherwise.
+func localGitTags(dir string) ([]release, error) {
+	out, err := exec.Command("git", "-C", dir, "for-each-ref", "refs/tags", "--format=%(creatordate:unix) %(refname:short)").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref: %w", err)
+	}
+	var tags []release
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		epoch, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, release{name: name, date: time.Unix(sec, 0).UTC()})
+	}
+	return tags, nil
+}
+
+// applyReleases sets each week's release count, the median days since the
+// previous release, and the median PRs merged since the previous release,
+// for the releases in the week. PRs per release needs the previous release
+// inside the window, since earlier PRs aren't fetched.
+func applyReleases(releases []release, prs []enrichedPR, weeks []weekRange, stats []weekStats) {
+	merged := make([]int64, 0, len(prs))
+	for _, pr := range prs {
+		merged = append(merged, pr.mergedEpoch)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	countBefore := func(epoch int64) int {
+		return sort.Search(len(merged), func(i int) bool { return merged[i] >= epoch })
+	}
+
+	gaps := make([][]float64, len(weeks))
+	sizes := make([][]float64, len(weeks))
+	counts := make([]int, len(weeks))
+	for i, r := range releases {
+		w := weekIndex(weeks, r.date.Unix())
+		if w < 0 {
+			continue
+		}
+		counts[w]++
+		if i == 0 {
+			continue
+		}
+		prev := releases[i-1].date
+		gaps[w] = append(gaps[w], r.date.Sub(prev).Hours()/24)
+		if !prev.Before(weeks[0].start) {
+			sizes[w] = append(sizes[w], float64(countBefore(r.date.Unix())-countBefore(prev.Unix())))
+		}
+	}
+	for i := range stats {
+		stats[i].releasesTracked = true
+		stats[i].releases = counts[i]
+		stats[i].medianReleaseGap, stats[i].prsPerRelease = -1, -1
+		if len(gaps[i]) > 0 {
+			stats[i].medianReleaseGap = medianFloat(gaps[i])
+		}
+		if len(sizes[i]) > 0 {
+			stats[i].prsPerRelease = medianFloat(sizes[i])
+		}
+	}
+}
+
+// logReleases prints the releases in the window and the latest one.
+func logReleases(releases []release, weeks []weekRange) {
+	var n int
+	for _, r := range releases {
+		if weekIndex(weeks, r.date.Unix()) >= 0 {
+			n++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Releases: %d in the window", n)
+	if len(releases) > 0 {
+		last := releases[len(releases)-1]
+		fmt.Fprintf(os.Stderr, ", latest %s on %s", last.name, last.date.Format("2006-01-02"))
+	}
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// closedInterval is a span during which a PR was closed before being
+// reopened, in Unix seconds.
+type closedInterval struct {
+	from, to int64
+}
+
+// closedIntervals pairs each ClosedEvent with the next ReopenedEvent. The
+// close that comes with the merge (or any close never reopened) has no end
+// and is ignored.
+func closedIntervals(pr PR) []closedInterval {
+	var out []closedInterval
+	var closedAt int64
+	for _, ev := range pr.CloseEvents.Nodes {
+		if ev.CreatedAt == nil {
+			continue
+		}
+		switch ev.Typename {
+		case "ClosedEvent":
+			if closedAt == 0 {
+				closedAt = ev.CreatedAt.Unix()
+			}
+		case "ReopenedEvent":
+			if closedAt != 0 {
+				out = append(out, closedInterval{from: closedAt, to: ev.CreatedAt.Unix()})
+				closedAt = 0
+			}
+		}
+	}
+	return out
+}
+
+// activeHours is the time from from to to in hours, rounded to two
+// decimals, minus the parts of it the PR spent closed.
+func activeHours(from, to int64, closed []closedInterval) float64 {
+	secs := to - from
+	for _, c := range closed {
+		if overlap := min(to, c.to) - max(from, c.from); overlap > 0 {
+			secs -= overlap
+		}
+	}
+	return math.Round(float64(max(secs, 0))/3600.0*100) / 100
+}
+
+// logReopens prints how many PRs were closed and reopened before merging
+// and how much closed time was left out of their cycle times.
+func logReopens(prs []enrichedPR) {
+	var reopened int
+	var closedHours float64
+	for _, pr := range prs {
+		if pr.reopenCount > 0 {
+			reopened++
+			closedHours += pr.closedHours
+		}
+	}
+	if reopened > 0 {
+		fmt.Fprintf(os.Stderr, "Reopened PRs: %d (%.0fh closed time left out of cycle times)\n", reopened, closedHours)
+	}
+}
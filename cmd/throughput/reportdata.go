@@ -0,0 +1,203 @@
+package main
+
+// reportData is the report's machine-readable data, embedded in the HTML as
+// <script type="application/json" id="report-data">. The chart script reads
+// every series it draws from it, so scripts and notebooks parsing the file
+// get exactly what the chart shows.
+type reportData struct {
+	SchemaVersion   int               `json:"schemaVersion,omitempty"`
+	Title           string            `json:"title"`
+	Period          string            `json:"period"` // "week", "month", or "sprint"
+	Periods         []reportPeriod    `json:"periods"`
+	Comparison      []snapshotStat    `json:"comparison"` // before/after rows, as stored by --store
+	FilterNotes     []string          `json:"filterNotes"`
+	Findings        []string          `json:"findings"`
+	HasIncidents    bool              `json:"hasIncidents"`
+	HasSizeWeighted bool              `json:"hasSizeWeighted"`
+	HasOnaWeighted  bool              `json:"hasOnaWeighted"`
+	HasRetention    bool              `json:"hasRetention"`
+	HasResponse     bool              `json:"hasResponse"`
+	HasHygiene      bool              `json:"hasHygiene"`
+	HasReopens      bool              `json:"hasReopens"`
+	HasAutomation   bool              `json:"hasAutomation"`
+	ExternalSeries  []htmlSeries      `json:"externalSeries"`
+	SizeBuckets     []htmlSizeSeries  `json:"sizeBuckets"`
+	TargetLines     []htmlTargetLine  `json:"targetLines"`
+	Deltas          []htmlDelta       `json:"deltas"`
+	PRLists         [][]drilldownPR   `json:"prLists"`
+	Flow            []htmlFlowPoint   `json:"flow"`
+	Cohorts         []htmlCohort      `json:"cohorts"`
+	YoY             []htmlYoYSeries   `json:"yoy"`
+	Lifecycle       htmlLifecycleFlow `json:"lifecycle"`
+	Scatter         []scatterPR       `json:"scatter"`
+	Histograms      []htmlHistogram   `json:"histograms"`
+	Holidays        []string          `json:"holidays"`
+	UnstablePeriods int               `json:"unstablePeriods"`
+
+	Trends map[string]reportTrend `json:"trends"` // by metric name; metrics with too few periods are absent
+
+	HasReviewCoverage bool                   `json:"hasReviewCoverage"`
+	HasCIQueue        bool                   `json:"hasCIQueue"`
+	HasCICost         bool                   `json:"hasCICost"`
+	HasTemplate       bool                   `json:"hasTemplate"`
+	HasSecurity       bool                   `json:"hasSecurity"`
+	HasReleases       bool                   `json:"hasReleases"`
+	HasChangelog      bool                   `json:"hasChangelog"`
+	HasCIPrice        bool                   `json:"hasCIPrice"`        // ciCost is set, in the --ci-minute-price currency
+	ProtectionChanges []htmlProtectionChange `json:"protectionChanges"` // --branch-protection
+
+	Runners reportRunners `json:"runners"` // --runners
+}
+
+// reportRunners is the --runners utilization report. It is always weekly,
+// so it carries its own week labels.
+type reportRunners struct {
+	Weeks  []string           `json:"weeks"`
+	Series []htmlRunnerSeries `json:"series"`
+}
+
+// reportPeriod is one chart period. Coding, review, and MTTR times are 0
+// without data, as drawn; the other metrics without data are null.
+type reportPeriod struct {
+	Week             string   `json:"week"`  // period start, YYYY-MM-DD
+	Label            string   `json:"label"` // week formatted for the report locale, or the sprint name
+	PRsMerged        int      `json:"prsMerged"`
+	PRsPerEngineer   float64  `json:"prsPerEngineer"`
+	SizePoints       float64  `json:"sizePoints"`
+	ActiveEngineers  *int     `json:"activeEngineers"`
+	ChurnedEngineers *int     `json:"churnedEngineers"`
+	CodingTime       float64  `json:"codingTime"`
+	ReviewTime       float64  `json:"reviewTime"`
+	ReviewResponse   *float64 `json:"reviewResponse"`
+	PctOna           float64  `json:"pctOna"`
+	PctOnaLines      *float64 `json:"pctOnaLines"` // --ona-weighted
+	PctReverts       float64  `json:"pctReverts"`
+	PctDescribed     float64  `json:"pctDescribed"`
+	PctLinked        float64  `json:"pctLinked"`
+	PctTests         float64  `json:"pctTests"`
+	PctApproved      *float64 `json:"pctApproved"`
+	PctUnreviewed    *float64 `json:"pctUnreviewed"`
+	PctTemplate      *float64 `json:"pctTemplate"` // --template-compliance
+	SecurityPRs      int      `json:"securityPRs"` // --security
+	SecurityLead     *float64 `json:"securityLead"`
+	Releases         int      `json:"releases"` // --releases
+	ReleaseGap       *float64 `json:"releaseGap"`
+	PRsPerRelease    *float64 `json:"prsPerRelease"`
+	ReleaseBatch     *float64 `json:"releaseBatch"` // --release-changelog
+	BuildRuns        int      `json:"buildRuns"`
+	Incidents        int      `json:"incidents"`
+	MTTR             float64  `json:"mttr"`
+	Reopened         int      `json:"reopened"`
+	AutomationPRs    int      `json:"automationPRs"`
+	AutomationMerge  *float64 `json:"automationMerge"`
+	CIQueue          *float64 `json:"ciQueue"`          // --ci-queue, minutes
+	CIMinutes        *float64 `json:"ciMinutes"`        // --ci-cost billable minutes
+	CICost           *float64 `json:"ciCost"`           // --ci-cost with --ci-minute-price
+	Fiscal           string   `json:"fiscal,omitempty"` // fiscal quarter with --fiscal-year-start, e.g. "FY2026 Q1"
+	Incomplete       bool     `json:"incomplete"`       // a week's fetch failed or was cut off; counts may be low
+}
+
+// buildReportData collects the embedded report data from the rendered
+// htmlData and the comparison rows.
+func buildReportData(d htmlData, periodLabel string, rows []consolidatedRow) reportData {
+	optionalInt := func(v int) *int {
+		if v < 0 {
+			return nil
+		}
+		return &v
+	}
+	optional := func(v float64) *float64 {
+		if v < 0 {
+			return nil
+		}
+		return &v
+	}
+	rd := reportData{
+		Title:           d.Title,
+		Period:          periodLabel,
+		Periods:         []reportPeriod{},
+		Comparison:      snapshotStats(rows),
+		FilterNotes:     d.FilterNotes,
+		Findings:        d.Findings,
+		HasIncidents:    d.HasIncidents,
+		HasSizeWeighted: d.HasSizeWeighted,
+		HasOnaWeighted:  d.HasOnaWeighted,
+		HasRetention:    d.HasRetention,
+		HasResponse:     d.HasResponse,
+		HasHygiene:      d.HasHygiene,
+		HasReopens:      d.HasReopens,
+		HasAutomation:   d.HasAutomation,
+		ExternalSeries:  d.ExternalSeries,
+		SizeBuckets:     d.SizeBuckets,
+		TargetLines:     d.TargetLines,
+		Deltas:          d.Deltas,
+		PRLists:         d.PRLists,
+		Flow:            d.Flow,
+		Cohorts:         d.Cohorts,
+		YoY:             d.YoY,
+		Lifecycle:       d.LifecycleFlow,
+		Scatter:         d.Scatter,
+		Histograms:      d.Histograms,
+		Holidays:        d.Holidays,
+		UnstablePeriods: d.Unstable,
+
+		HasReviewCoverage: d.HasReviewCoverage,
+		HasCIQueue:        d.HasCIQueue,
+		HasCICost:         d.HasCICost,
+		HasTemplate:       d.HasTemplate,
+		HasSecurity:       d.HasSecurity,
+		HasReleases:       d.HasReleases,
+		HasChangelog:      d.HasChangelog,
+		HasCIPrice:        d.HasCIPrice,
+		ProtectionChanges: d.ProtectionChanges,
+
+		Runners: reportRunners{Weeks: d.RunnerWeeks, Series: d.Runners},
+	}
+	if rd.FilterNotes == nil {
+		rd.FilterNotes = []string{}
+	}
+	if rd.Findings == nil {
+		rd.Findings = []string{}
+	}
+	for _, w := range d.Weeks {
+		rd.Periods = append(rd.Periods, reportPeriod{
+			Week:             w.WeekStart,
+			Label:            w.WeekLabel,
+			PRsMerged:        w.PRsMerged,
+			PRsPerEngineer:   w.PRsPerEngineer,
+			SizePoints:       w.SizePointsPerEngineer,
+			ActiveEngineers:  optionalInt(w.ActiveEngineers4w),
+			ChurnedEngineers: optionalInt(w.ChurnedEngineers),
+			CodingTime:       w.MedianCodingTime,
+			ReviewTime:       w.MedianReviewTime,
+			ReviewResponse:   optional(w.MedianReviewResponse),
+			PctOna:           w.PctOnaInvolved,
+			PctOnaLines:      optional(w.PctOnaLines),
+			PctReverts:       w.PctReverts,
+			PctDescribed:     w.PctDescribed,
+			PctLinked:        w.PctLinkedIssue,
+			PctTests:         w.PctWithTests,
+			PctApproved:      optional(w.PctApproved),
+			PctUnreviewed:    optional(w.PctUnreviewed),
+			PctTemplate:      optional(w.PctTemplate),
+			SecurityPRs:      w.SecurityPRs,
+			SecurityLead:     optional(w.SecurityLead),
+			Releases:         w.Releases,
+			ReleaseGap:       optional(w.ReleaseGap),
+			PRsPerRelease:    optional(w.PRsPerRelease),
+			ReleaseBatch:     optional(w.ReleaseBatch),
+			BuildRuns:        w.BuildRuns,
+			Incidents:        w.Incidents,
+			MTTR:             w.MedianMTTR,
+			Reopened:         w.Reopened,
+			AutomationPRs:    w.AutomationPRs,
+			AutomationMerge:  optional(w.AutomationMergeTime),
+			CIQueue:          optional(w.MedianCIQueue),
+			CIMinutes:        optional(w.CIMinutes),
+			CICost:           optional(w.CICost),
+			Fiscal:           w.FiscalQuarter,
+			Incomplete:       w.Incomplete,
+		})
+	}
+	return rd
+}
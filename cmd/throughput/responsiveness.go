@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reviewResponse is one review request to an individual reviewer, paired
+// with that reviewer's first review after it.
+type reviewResponse struct {
+	reviewer string
+	hours    float64 // request to first review; -1 if the request was never answered
+}
+
+// reviewResponses pairs each ReviewRequestedEvent in the --enrich-reviews
+// timeline with the requested reviewer's first submitted review at or after
+// it. A request is dropped when it is withdrawn (ReviewRequestRemovedEvent)
+// or re-sent before the reviewer responds, so only the latest request of a
+// re-request counts. Team requests and excluded reviewers are skipped.
+func reviewResponses(d *prReviewDetails, excludeSet map[string]bool) []reviewResponse {
+	type request struct {
+		at      time.Time
+		removed *time.Time // when the request was withdrawn, if it was
+	}
+	requests := make(map[string][]request)
+	var order []string
+	for _, e := range d.Timeline {
+		login := strings.ToLower(e.RequestedReviewer.Login)
+		if login == "" || e.CreatedAt == nil || excludeSet[login] {
+			continue
+		}
+		switch e.Typename {
+		case "ReviewRequestedEvent":
+			if _, ok := requests[login]; !ok {
+				order = append(order, login)
+			}
+			requests[login] = append(requests[login], request{at: *e.CreatedAt})
+		case "ReviewRequestRemovedEvent":
+			if rs := requests[login]; len(rs) > 0 && rs[len(rs)-1].removed == nil {
+				rs[len(rs)-1].removed = e.CreatedAt
+			}
+		}
+	}
+
+	var responses []reviewResponse
+	for _, login := range order {
+		rs := requests[login]
+		for i, req := range rs {
+			var first *time.Time
+			for _, r := range d.Reviews {
+				if r.SubmittedAt != nil && strings.EqualFold(r.Author.Login, login) && !r.SubmittedAt.Before(req.at) &&
+					(first == nil || r.SubmittedAt.Before(*first)) {
+					first = r.SubmittedAt
+				}
+			}
+			superseded := i+1 < len(rs) && (first == nil || rs[i+1].at.Before(*first))
+			withdrawn := req.removed != nil && (first == nil || req.removed.Before(*first))
+			switch {
+			case superseded || withdrawn:
+				continue
+			case first != nil:
+				hours := math.Round(first.Sub(req.at).Hours()*100) / 100
+				responses = append(responses, reviewResponse{reviewer: login, hours: hours})
+			default:
+				responses = append(responses, reviewResponse{reviewer: login, hours: -1})
+			}
+		}
+	}
+	return responses
+}
+
+// applyReviewResponsiveness sets the weekly median time from review request
+// to first review, bucketing each request by its PR's merge week like the
+// other cycle-time metrics. Only PRs with --enrich-reviews data contribute.
+func applyReviewResponsiveness(prs []enrichedPR, weeks []weekRange, stats []weekStats) {
+	hours := make([][]float64, len(weeks))
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		for _, r := range pr.reviewResponses {
+			stats[i].reviewRequests++
+			if r.hours < 0 {
+				stats[i].unansweredRequests++
+				continue
+			}
+			hours[i] = append(hours[i], r.hours)
+		}
+	}
+	for i := range stats {
+		stats[i].responseTracked = true
+		stats[i].medianReviewResponse = median(hours[i])
+	}
+}
+
+// reviewerStat is one row of the reviewer responsiveness leaderboard.
+type reviewerStat struct {
+	login       string
+	requests    int
+	answered    int
+	medianHours float64 // -1 if no request was answered
+	p90Hours    float64
+}
+
+// computeTopReviewers ranks reviewers by how many review requests they
+// received, then by median response time, and returns the top n.
+func computeTopReviewers(prs []enrichedPR, n int, anonymize bool) []reviewerStat {
+	byReviewer := make(map[string]*reviewerStat)
+	hours := make(map[string][]float64)
+	for _, pr := range prs {
+		for _, r := range pr.reviewResponses {
+			rs := byReviewer[r.reviewer]
+			if rs == nil {
+				rs = &reviewerStat{login: r.reviewer}
+				byReviewer[r.reviewer] = rs
+			}
+			rs.requests++
+			if r.hours >= 0 {
+				rs.answered++
+				hours[r.reviewer] = append(hours[r.reviewer], r.hours)
+			}
+		}
+	}
+
+	results := make([]reviewerStat, 0, len(byReviewer))
+	for login, rs := range byReviewer {
+		rs.medianHours = median(hours[login])
+		rs.p90Hours = p90(hours[login])
+		results = append(results, *rs)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.requests != b.requests {
+			return a.requests > b.requests
+		}
+		if (a.medianHours < 0) != (b.medianHours < 0) {
+			return a.medianHours >= 0
+		}
+		if a.medianHours != b.medianHours {
+			return a.medianHours < b.medianHours
+		}
+		return a.login < b.login // stable tie-break
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+	if anonymize {
+		for i := range results {
+			results[i].login = hashLogin(results[i].login)
+		}
+	}
+	return results
+}
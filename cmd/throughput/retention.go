@@ -0,0 +1,66 @@
+package main
+
+import ()
+
+// retentionWindow is the number of weeks in the rolling active-engineer window.
+// Churn compares one window with the window immediately before it, so it
+// needs 2*retentionWindow weeks of history.
+const retentionWindow = 4
+
+// applyRetention sets the rolling active-engineer count and churn on each
+// week. An engineer is active in a week if they merged at least one PR. For
+// week i, active engineers are the distinct authors of weeks i-3..i, and
+// churned engineers are those active in weeks i-7..i-4 but not in i-3..i.
+// Weeks without enough history get -1.
+func applyRetention(prs []enrichedPR, weeks []weekRange, stats []weekStats) {
+	weekAuthors := make([]map[string]bool, len(weeks))
+	for i := range weekAuthors {
+		weekAuthors[i] = make(map[string]bool)
+	}
+	for _, pr := range prs {
+		if i := weekIndex(weeks, pr.mergedEpoch); i >= 0 {
+			weekAuthors[i][pr.authorLogin] = true
+		}
+	}
+
+	window := func(from, to int) map[string]bool {
+		set := make(map[string]bool)
+		for i := from; i <= to; i++ {
+			for a := range weekAuthors[i] {
+				set[a] = true
+			}
+		}
+		return set
+	}
+
+	for i := range stats {
+		stats[i].retentionTracked = true
+		stats[i].activeEngineers4w = -1
+		stats[i].churnedEngineers = -1
+		if i < retentionWindow-1 {
+			continue
+		}
+		current := window(i-retentionWindow+1, i)
+		stats[i].activeEngineers4w = len(current)
+		if i < 2*retentionWindow-1 {
+			continue
+		}
+		churned := 0
+		for a := range window(i-2*retentionWindow+1, i-retentionWindow) {
+			if !current[a] {
+				churned++
+			}
+		}
+		stats[i].churnedEngineers = churned
+	}
+}
+
+// weekIndex returns the index of the week containing epoch, or -1.
+func weekIndex(weeks []weekRange, epoch int64) int {
+	for i, wr := range weeks {
+		if epoch >= wr.start.Unix() && epoch <= wr.end.Unix()+86399 {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,46 @@
+package main
+
+import ()
+
+// applyReviewCoverage sets the weekly share of merged PRs approved by
+// someone other than their author, and the share merged without any review
+// from someone else. Only PRs whose reviews were fetched count; a week
+// without any is left at -1.
+func applyReviewCoverage(prs []enrichedPR, weeks []weekRange, stats []weekStats) {
+	type counts struct{ known, approved, unreviewed int }
+	byWeek := make([]counts, len(weeks))
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 || !pr.reviewStatesKnown {
+			continue
+		}
+		c := &byWeek[i]
+		c.known++
+		if pr.approvedByOther {
+			c.approved++
+		}
+		if !pr.reviewedByOther {
+			c.unreviewed++
+		}
+	}
+	for i, c := range byWeek {
+		stats[i].reviewCoverageTracked = true
+		stats[i].pctApproved, stats[i].pctUnreviewed = -1, -1
+		if c.known > 0 {
+			stats[i].pctApproved = float64(c.approved) / float64(c.known) * 100
+			stats[i].pctUnreviewed = float64(c.unreviewed) / float64(c.known) * 100
+		}
+	}
+}
+
+// unknownReviewCoverage counts merged PRs in the weeks whose reviews weren't
+// fetched: --local-git, and cache entries written before reviews were.
+func unknownReviewCoverage(prs []enrichedPR, weeks []weekRange) int {
+	var n int
+	for _, pr := range prs {
+		if !pr.reviewStatesKnown && weekIndex(weeks, pr.mergedEpoch) >= 0 {
+			n++
+		}
+	}
+	return n
+}
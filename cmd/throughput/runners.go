@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// runnerWeekHours is one runner's capacity in a week.
+const runnerWeekHours = 7 * 24
+
+// runnersHeader is the --runners-output CSV header.
+var runnersHeader = []string{
+	"week_start", "week_end", "label", "jobs", "runners", "busy_hours",
+	"utilization_pct", "median_queue_minutes", "p90_queue_minutes",
+}
+
+// runnerWeek is one runner label's usage in one week, from the jobs of the
+// sampled runs.
+type runnerWeek struct {
+	jobs        int
+	runners     int       // distinct runner names seen
+	busyHours   float64   // job run time, scaled from the sampled runs to the week's runs
+	utilization float64   // busyHours / (runners × runnerWeekHours) in %, at most 100; -1 without jobs
+	queue       []float64 // job created to started in minutes
+}
+
+// runnerLabel is a self-hosted runner label set: its labels without
+// "self-hosted", sorted and comma-joined.
+type runnerLabel struct {
+	label string
+	weeks []runnerWeek
+}
+
+// aggregateRunnerUsage aggregates the sampled jobs that ran on self-hosted runners
+// per label set and week. Labels are ordered by total busy hours. Returns nil
+// if no self-hosted jobs were found.
+func aggregateRunnerUsage(weeks []weekRange, sampled []weekJobs) []runnerLabel {
+	byLabel := make(map[string]*runnerLabel)
+	runners := make(map[string][]map[string]bool) // label → week → runner names
+	for i, wj := range sampled {
+		for _, j := range wj.jobs {
+			label, ok := selfHostedLabel(j.Labels)
+			if !ok || j.StartedAt.IsZero() || j.CompletedAt.Before(j.StartedAt) {
+				continue
+			}
+			rl := byLabel[label]
+			if rl == nil {
+				rl = &runnerLabel{label: label, weeks: make([]runnerWeek, len(weeks))}
+				byLabel[label] = rl
+				runners[label] = make([]map[string]bool, len(weeks))
+			}
+			w := &rl.weeks[i]
+			w.jobs++
+			w.busyHours += j.CompletedAt.Sub(j.StartedAt).Hours() * wj.scale
+			w.queue = append(w.queue, max(0, j.StartedAt.Sub(j.CreatedAt).Minutes()))
+			if j.RunnerName != "" {
+				if runners[label][i] == nil {
+					runners[label][i] = make(map[string]bool)
+				}
+				runners[label][i][j.RunnerName] = true
+			}
+		}
+	}
+
+	if len(byLabel) == 0 {
+		fmt.Fprintf(os.Stderr, "No jobs on self-hosted runners found\n")
+		return nil
+	}
+
+	var labels []runnerLabel
+	total := make(map[string]float64)
+	for label, rl := range byLabel {
+		for i := range rl.weeks {
+			w := &rl.weeks[i]
+			w.runners = len(runners[label][i])
+			w.utilization = -1
+			if w.runners > 0 {
+				w.utilization = min(100, w.busyHours/float64(w.runners*runnerWeekHours)*100)
+			}
+			total[label] += w.busyHours
+		}
+		labels = append(labels, *rl)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if total[labels[i].label] != total[labels[j].label] {
+			return total[labels[i].label] > total[labels[j].label]
+		}
+		return labels[i].label < labels[j].label
+	})
+	return labels
+}
+
+// selfHostedLabel returns the label key of a job's runner, or false for
+// jobs that didn't request a self-hosted runner.
+func selfHostedLabel(labels []string) (string, bool) {
+	var rest []string
+	selfHosted := false
+	for _, l := range labels {
+		if strings.EqualFold(l, "self-hosted") {
+			selfHosted = true
+		} else {
+			rest = append(rest, l)
+		}
+	}
+	if !selfHosted {
+		return "", false
+	}
+	if len(rest) == 0 {
+		return "self-hosted", true
+	}
+	slices.Sort(rest)
+	return strings.Join(rest, ","), true
+}
+
+// logRunnerUsage prints each label's utilization and median job queue time
+// in the last week.
+func logRunnerUsage(labels []runnerLabel) {
+	fmt.Fprintf(os.Stderr, "Self-hosted runner utilization (last week):\n")
+	for _, rl := range labels {
+		w := rl.weeks[len(rl.weeks)-1]
+		if w.jobs == 0 {
+			fmt.Fprintf(os.Stderr, "  %-30s no jobs\n", rl.label)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %-30s %3d runner(s), %5.1f%% busy, median queue %.1f min, p90 %.1f min\n",
+			rl.label, w.runners, w.utilization, median(w.queue), p90(w.queue))
+	}
+}
+
+// writeRunnersCSV writes one row per runner label and week.
+func writeRunnersCSV(path string, weeks []weekRange, labels []runnerLabel, version int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := runnersHeader
+	var trailer []string
+	if embedsSchemaVersion(version) {
+		header = append(append([]string{}, header...), schemaVersionColumn)
+		trailer = []string{strconv.Itoa(version)}
+	}
+	w.Write(header)
+	for i, wr := range weeks {
+		for _, rl := range labels {
+			rw := rl.weeks[i]
+			row := []string{wr.start.Format("2006-01-02"), wr.end.Format("2006-01-02"), rl.label, strconv.Itoa(rw.jobs), strconv.Itoa(rw.runners), "", "", "", ""}
+			if rw.jobs > 0 {
+				row[5] = strconv.FormatFloat(rw.busyHours, 'f', 1, 64)
+				if rw.utilization >= 0 {
+					row[6] = strconv.FormatFloat(rw.utilization, 'f', 1, 64)
+				}
+				row[7] = strconv.FormatFloat(median(rw.queue), 'f', 1, 64)
+				row[8] = strconv.FormatFloat(p90(rw.queue), 'f', 1, 64)
+			}
+			w.Write(append(row, trailer...))
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
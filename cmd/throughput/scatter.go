@@ -0,0 +1,35 @@
+package main
+
+// scatterPR is one point of the cycle time scatter plot (--scatter). It is
+// marshalled into the report as JSON for the chart script.
+type scatterPR struct {
+	Number     int     `json:"number"`     // 0 with --anonymize
+	MergedAt   int64   `json:"mergedAt"`   // Unix milliseconds, as JavaScript dates expect
+	CycleHours float64 `json:"cycleHours"` // coding + review time
+	Size       int     `json:"size"`       // additions + deletions
+	Ona        bool    `json:"ona"`
+}
+
+// buildScatter returns a point per PR merged in the chart periods whose
+// coding and review times are both known. PRs in periods dropped by
+// --min-prs are left out, like the chart.
+func buildScatter(prs []enrichedPR, periods []weekRange, redact bool) []scatterPR {
+	points := []scatterPR{}
+	for _, pr := range prs {
+		if pr.codingTimeHours < 0 || pr.reviewTimeHours < 0 || weekIndex(periods, pr.mergedEpoch) < 0 {
+			continue
+		}
+		p := scatterPR{
+			Number:     pr.number,
+			MergedAt:   pr.mergedEpoch * 1000,
+			CycleHours: pr.codingTimeHours + pr.reviewTimeHours,
+			Size:       pr.additions + pr.deletions,
+			Ona:        pr.onaInvolved,
+		}
+		if redact {
+			p.Number = 0
+		}
+		points = append(points, p)
+	}
+	return points
+}
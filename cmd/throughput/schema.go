@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// schemaVersion is the version of the machine-readable outputs: the weekly
+// and --pr-output CSVs, --store snapshots (and so /api/v1), and the report's
+// embedded JSON. It is written into each of them. Bump it, and add a
+// schemaChanges entry, when a default layout changes in a way a consumer can
+// trip over: a column or field renamed, removed, or redefined, or a column
+// added to every run. Columns behind a flag don't need a bump.
+const schemaVersion = 3
+
+// schemaChange is one version's migration note. Versions before the current
+// one stay writable with --schema-version, for consumers not yet migrated.
+type schemaChange struct {
+	version int
+	note    string
+}
+
+var schemaChanges = []schemaChange{
+	{1, "Unversioned outputs."},
+	{2, "The weekly and --pr-output CSVs end with a schema_version column; --store snapshots (and /api/v1) carry schema_version, the report JSON schemaVersion. " +
+		"Readers that index CSV columns by name are unaffected; with --schema-version 1 the column and fields are left out."},
+	{3, "The weekly CSV has a complete column (true/false) before schema_version: false marks weeks whose fetch failed or was cut off at the search API limit. " +
+		"Report JSON periods carry incomplete, --store snapshot weeks incomplete when true. With --schema-version 2 the CSV column is left out."},
+}
+
+// outputSchema describes one output for --schema.
+type outputSchema struct {
+	name   string
+	format string
+	fields func() []string
+}
+
+var outputSchemas = []outputSchema{
+	{"weekly CSV (--output)", "csv", func() []string {
+		cols := append(strings.Split(csvHeader, ","), optionalCSVLayout...)
+		return append(cols, "... user-defined metric, delta, fiscal, and sprint columns (see README)", completeColumn, schemaVersionColumn)
+	}},
+	{"per-PR CSV (--pr-output)", "csv", func() []string {
+		return append(append([]string{}, prDetailsHeader...), schemaVersionColumn)
+	}},
+	{"runner utilization CSV (--runners-output)", "csv", func() []string {
+		return append(append([]string{}, runnersHeader...), schemaVersionColumn)
+	}},
+	{"correlation matrix CSV (--correlation-matrix-output)", "csv", func() []string {
+		return append(append([]string{}, corrMatrixHeader...), schemaVersionColumn)
+	}},
+	{"snapshot (--store, /api/v1)", "json", func() []string {
+		return jsonFields(reflect.TypeOf(runSnapshot{}), "")
+	}},
+	{"report data (HTML, #report-data)", "json", func() []string {
+		return jsonFields(reflect.TypeOf(reportData{}), "")
+	}},
+	{"database tables (--storage sqlite:FILE or postgres; Postgres types)", "sql", func() []string {
+		var cols []string
+		for _, t := range storageTables {
+			for _, c := range t.cols {
+				col := t.name + "." + c.name + " " + postgresDialect.types[c.kind]
+				if slices.Contains(t.key, c.name) {
+					col += " (key)"
+				}
+				cols = append(cols, col)
+			}
+		}
+		return cols
+	}},
+}
+
+// printSchemas writes every output's columns or fields and the migration
+// notes (--schema).
+func printSchemas(w io.Writer) {
+	fmt.Fprintf(w, "Schema version %d\n", schemaVersion)
+	for _, s := range outputSchemas {
+		fmt.Fprintf(w, "\n%s [%s]\n", s.name, s.format)
+		for _, f := range s.fields() {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	fmt.Fprintln(w, "\nMigration notes")
+	for _, c := range schemaChanges {
+		fmt.Fprintf(w, "  %d: %s\n", c.version, c.note)
+	}
+}
+
+// jsonFields lists a struct's JSON field paths, nested fields as
+// parent.child and slice elements as parent[].child.
+func jsonFields(t reflect.Type, prefix string) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		path := prefix + name
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct && ft.PkgPath() == "main":
+			fields = append(fields, jsonFields(ft, path+".")...)
+			continue
+		case ft.Kind() == reflect.Slice:
+			elem := ft.Elem()
+			for elem.Kind() == reflect.Slice || elem.Kind() == reflect.Pointer {
+				path += "[]"
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct && elem.PkgPath() == "main" {
+				fields = append(fields, jsonFields(elem, path+"[].")...)
+				continue
+			}
+		}
+		fields = append(fields, path)
+	}
+	return fields
+}
+
+// embedsSchemaVersion reports whether outputs written as the given schema
+// version carry it; version 1 predates the column and fields.
+func embedsSchemaVersion(version int) bool {
+	return version >= 2
+}
+
+// appendSchemaVersionColumn ends every row of a CSV with the schema version.
+func appendSchemaVersionColumn(csv string, version int) string {
+	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return csv
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lines[0])
+	sb.WriteString("," + schemaVersionColumn + "\n")
+	for _, line := range lines[1:] {
+		fmt.Fprintf(&sb, "%s,%d\n", line, version)
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// securityPR is a merged security fix counted by --security: a PR with one
+// of the --security-labels, or a Dependabot security update.
+type securityPR struct {
+	mergedEpoch int64
+	leadHours   float64 // created to merged
+	dependabot  bool
+}
+
+// dependabotSecurityMarkers are in the title or body of the PRs Dependabot
+// opens for a security advisory, but not of its version updates.
+var dependabotSecurityMarkers = []string{"[Security]", "Vulnerabilities fixed", "GitHub Security Advisory Database"}
+
+// isDependabotSecurityUpdate reports whether a PR is a Dependabot security
+// update rather than a routine version bump.
+func isDependabotSecurityUpdate(pr PR) bool {
+	if !strings.HasPrefix(strings.ToLower(pr.Author.Login), "dependabot") {
+		return false
+	}
+	for _, m := range dependabotSecurityMarkers {
+		if strings.Contains(pr.Title, m) || strings.Contains(pr.Body, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// securityPRs returns the merged, non-draft security fixes. Like
+// automationPRs it reads every fetched PR, so Dependabot counts even though
+// the default exclusions leave it out of throughput.
+func securityPRs(prs []PR, labels map[string]bool) []securityPR {
+	var out []securityPR
+	for _, pr := range prs {
+		if pr.MergedAt.IsZero() || pr.IsDraft {
+			continue
+		}
+		dependabot := isDependabotSecurityUpdate(pr)
+		labeled := false
+		for _, l := range pr.Labels.Nodes {
+			if labels[strings.ToLower(l.Name)] {
+				labeled = true
+				break
+			}
+		}
+		if !labeled && !dependabot {
+			continue
+		}
+		out = append(out, securityPR{
+			mergedEpoch: pr.MergedAt.Unix(),
+			leadHours:   math.Round(pr.MergedAt.Sub(pr.CreatedAt).Hours()*100) / 100,
+			dependabot:  dependabot,
+		})
+	}
+	return out
+}
+
+// applySecurity sets each week's security fix count and median lead time.
+// stats must be aligned with weeks.
+func applySecurity(prs []securityPR, weeks []weekRange, stats []weekStats) {
+	hours := make([][]float64, len(weeks))
+	for _, pr := range prs {
+		if i := weekIndex(weeks, pr.mergedEpoch); i >= 0 {
+			hours[i] = append(hours[i], pr.leadHours)
+		}
+	}
+	for i := range stats {
+		stats[i].securityTracked = true
+		stats[i].securityPRs = len(hours[i])
+		stats[i].medianSecurityLead = -1
+		if len(hours[i]) > 0 {
+			stats[i].medianSecurityLead = median(hours[i])
+		}
+	}
+}
+
+// logSecurity prints the security fix count by source, their median and p90
+// lead time, and the slowest fix in the window.
+func logSecurity(prs []securityPR, weeks []weekRange) {
+	var hours []float64
+	var dependabot int
+	for _, pr := range prs {
+		if weekIndex(weeks, pr.mergedEpoch) < 0 {
+			continue
+		}
+		hours = append(hours, pr.leadHours)
+		if pr.dependabot {
+			dependabot++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Security fixes: %d merged (%d by label, %d Dependabot security updates)\n", len(hours), len(hours)-dependabot, dependabot)
+	if len(hours) > 0 {
+		fmt.Fprintf(os.Stderr, "  Lead time (created to merged): median %.1fh, p90 %.1fh, max %.1fh\n", median(hours), p90(hours), slices.Max(hours))
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sensitivitySetting is one point of the --sensitivity grid.
+type sensitivitySetting struct {
+	bottomPct int // --exclude-bottom-contributor-pct
+	minPRs    int // --min-prs
+}
+
+// sensitivityRun is the headline comparison recomputed at one setting.
+type sensitivityRun struct {
+	setting sensitivitySetting
+	prs     int // PRs left after the contributor filter
+	periods int // periods left after --min-prs
+	rows    map[string]consolidatedRow
+}
+
+// sensitivityResult is the --sensitivity sweep. baseline is the run's own
+// setting, which is always part of the grid.
+type sensitivityResult struct {
+	baseline sensitivitySetting
+	runs     []sensitivityRun
+}
+
+// sensitivityOptions are the settings held fixed across the sweep.
+type sensitivityOptions struct {
+	bottomPcts  []int
+	minPRs      []int
+	baseline    sensitivitySetting
+	periodLabel string // "week", "month", or "sprint"
+	// rollup aggregates weeks into months or sprints; nil for weekly
+	rollup       func(weeks []weekRange, stats []weekStats) ([]weekRange, []weekStats)
+	windowPct    int
+	onaThreshold float64
+	outliers     outlierPolicy
+	minGroupSize int
+	bottomBy     contributorMeasure // --exclude-bottom-by
+	fiscal       fiscalCalendar     // for --compare-fiscal-quarters
+}
+
+// parseIntList parses a comma-separated list of non-negative integers.
+func parseIntList(s string) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not a non-negative integer", f)
+		}
+		out = append(out, n)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no values in %q", s)
+	}
+	return out, nil
+}
+
+// runSensitivity recomputes the before/after stats for every combination of
+// bottom-contributor percentage and --min-prs. prs are the PRs before the
+// contributor filter and weeks all analysis weeks; outlier handling,
+// --min-group-size, granularity, and the comparison windows follow the run.
+func runSensitivity(prs []enrichedPR, weeks []weekRange, opts sensitivityOptions) *sensitivityResult {
+	bottomPcts := sortedWith(opts.bottomPcts, opts.baseline.bottomPct)
+	minPRs := sortedWith(opts.minPRs, opts.baseline.minPRs)
+
+	// Each bottom-contributor setting re-aggregates from scratch, which
+	// dominates the sweep's cost, so settings run in parallel.
+	runs := make([][]sensitivityRun, len(bottomPcts))
+	parallelFor(len(bottomPcts), statsWorkers, func(i int) {
+		bp := bottomPcts[i]
+		base := slices.Clone(prs)
+		cut := bottomCut{pct: bp, measure: opts.bottomBy}
+		if cut.enabled() {
+			base = withoutAuthors(base, authorSet(cut.authors(base, weeks)))
+		}
+		applyOutlierPolicy(base, opts.outliers)
+		weekly := aggregateWeeks(base, weeks)
+		if opts.minGroupSize > 1 {
+			suppressSmallWeeks(weekly, opts.minGroupSize)
+		}
+		periods := weeks
+		if opts.rollup != nil {
+			periods, weekly = opts.rollup(weeks, weekly)
+		}
+		if compareFiscalQuarters {
+			applyFiscalQuarters(opts.fiscal, periods, weekly)
+		}
+		if len(compareWindows) > 0 {
+			applyCompareWindows(periods, weekly)
+		}
+
+		for _, mp := range minPRs {
+			var kept []weekStats
+			for _, ws := range weekly {
+				if ws.prsMerged >= mp {
+					kept = append(kept, ws)
+				}
+			}
+			run := sensitivityRun{
+				setting: sensitivitySetting{bottomPct: bp, minPRs: mp},
+				prs:     len(base),
+				periods: len(kept),
+				rows:    make(map[string]consolidatedRow),
+			}
+			for _, r := range generateStatsTo(io.Discard, kept, opts.windowPct, opts.onaThreshold, opts.periodLabel) {
+				run.rows[r.metric] = r
+			}
+			runs[i] = append(runs[i], run)
+		}
+	})
+	return &sensitivityResult{baseline: opts.baseline, runs: slices.Concat(runs...)}
+}
+
+// sortedWith returns values plus extra, deduplicated and ascending.
+func sortedWith(values []int, extra int) []int {
+	out := slices.Clone(values)
+	if !slices.Contains(out, extra) {
+		out = append(out, extra)
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// conclusion classifies a comparison row as a significant rise ("up"), a
+// significant fall ("down"), or no significant change ("flat").
+func conclusion(r consolidatedRow) string {
+	switch {
+	case !r.significant():
+		return "flat"
+	case r.absChange > 0:
+		return "up"
+	case r.absChange < 0:
+		return "down"
+	}
+	return "flat"
+}
+
+// baselineRun returns the run at the baseline setting.
+func (res *sensitivityResult) baselineRun() sensitivityRun {
+	for _, r := range res.runs {
+		if r.setting == res.baseline {
+			return r
+		}
+	}
+	return sensitivityRun{}
+}
+
+// agreement counts the settings where metric has a comparison and the ones
+// among them reaching the baseline's conclusion.
+func (res *sensitivityResult) agreement(metric string) (agree, total int) {
+	b, ok := res.baselineRun().rows[metric]
+	if !ok {
+		return 0, 0
+	}
+	for _, r := range res.runs {
+		row, ok := r.rows[metric]
+		if !ok {
+			continue
+		}
+		total++
+		if conclusion(row) == conclusion(b) {
+			agree++
+		}
+	}
+	return agree, total
+}
+
+// logSensitivity prints each headline metric's baseline conclusion and how
+// many settings agree with it.
+func logSensitivity(res *sensitivityResult) {
+	fmt.Fprintf(os.Stderr, "Filter sensitivity (%d settings):\n", len(res.runs))
+	base := res.baselineRun()
+	for _, m := range headlineMetrics {
+		b, ok := base.rows[m]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "  %-28s no comparison at the run's settings\n", m)
+			continue
+		}
+		agree, total := res.agreement(m)
+		lo, hi := b.absChange, b.absChange
+		loPct, hiPct := b.pctChange, b.pctChange
+		for _, r := range res.runs {
+			if row, ok := r.rows[m]; ok {
+				if row.absChange < lo {
+					lo, loPct = row.absChange, row.pctChange
+				}
+				if row.absChange > hi {
+					hi, hiPct = row.absChange, row.pctChange
+				}
+			}
+		}
+		flag := ""
+		if agree < total {
+			flag = "  <- conclusion flips"
+		}
+		fmt.Fprintf(os.Stderr, "  %-28s %8s (%s); same conclusion in %d/%d settings, range %s to %s%s\n",
+			m, b.pctChange, conclusion(b), agree, total, loPct, hiPct, flag)
+	}
+}
+
+// sensitivityTable formats the sweep for the HTML report. Bold changes are
+// significant; highlighted cells reach a different conclusion than the
+// run's own setting.
+func sensitivityTable(res *sensitivityResult, labelOf func(string) string, loc reportLocale) *htmlSensitivity {
+	base := res.baselineRun()
+	t := &htmlSensitivity{
+		Note: fmt.Sprintf(loc.T("Headline before/after changes recomputed at each filter setting; the run's own setting is shaded. Bold changes are significant at p < %s; highlighted cells reach a different conclusion than the run's setting."),
+			loc.number(significanceLevel, 2)),
+	}
+	for _, m := range headlineMetrics {
+		t.Metrics = append(t.Metrics, labelOf(m))
+		agreement := "—"
+		if agree, total := res.agreement(m); total > 0 {
+			agreement = fmt.Sprintf("%d/%d", agree, total)
+		}
+		t.Agreement = append(t.Agreement, agreement)
+	}
+	for _, r := range res.runs {
+		row := htmlSensitivityRow{
+			BottomPct: r.setting.bottomPct,
+			MinPRs:    r.setting.minPRs,
+			PRs:       r.prs,
+			Periods:   r.periods,
+			Baseline:  r.setting == res.baseline,
+		}
+		for _, m := range headlineMetrics {
+			c, ok := r.rows[m]
+			if !ok {
+				row.Cells = append(row.Cells, htmlSensitivityCell{Change: "—"})
+				continue
+			}
+			b, hasBase := base.rows[m]
+			row.Cells = append(row.Cells, htmlSensitivityCell{
+				Change:      loc.localizeNumeric(c.pctChange),
+				Significant: c.significant(),
+				Flip:        hasBase && conclusion(c) != conclusion(b),
+			})
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
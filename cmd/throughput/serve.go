@@ -1,72 +1,116 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
+const (
+	// serveHeartbeat is how often idle live-reload streams get a comment
+	// line. A write to a client that went away fails, which frees its slot.
+	serveHeartbeat = 30 * time.Second
+	// serveWriteTimeout bounds each write to a live-reload stream, so a
+	// client that stopped reading can't hold a handler forever.
+	serveWriteTimeout = 10 * time.Second
+	// serveDebounce collects the burst of events a single save produces.
+	serveDebounce = 100 * time.Millisecond
+	// serveShutdownTimeout is how long in-flight page loads get to finish
+	// after SIGINT or SIGTERM.
+	serveShutdownTimeout = 5 * time.Second
+)
+
+// reloadScript is injected before </body>. It reloads the page on a reload
+// event, and once more when the stream reconnects after the server was
+// restarted. A refused stream (over --max-clients) closes for good and the
+// page stays as it is.
+const reloadScript = `<script>
+const es = new EventSource("/__reload");
+let dropped = false;
+es.onmessage = () => location.reload();
+es.onerror = () => { if (es.readyState === EventSource.CONNECTING) dropped = true; };
+es.onopen = () => { if (dropped) location.reload(); };
+</script></body>`
+
 // serveHTML starts an HTTP server that serves the HTML file and auto-reloads
-// the browser when the file changes on disk. It blocks forever.
-func serveHTML(htmlFile string, port int) {
+// connected browsers when the file changes on disk. At most maxClients
+// browsers are kept connected for live reload. It blocks until SIGINT or
+// SIGTERM and then shuts down gracefully.
+func serveHTML(htmlFile string, port, maxClients int) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	absPath, err := filepath.Abs(htmlFile)
 	if err != nil {
 		fatal("Failed to resolve path: %v", err)
 	}
-
-	watcher := &fileWatcher{path: absPath}
-	go watcher.watch()
+	watcher := &fileWatcher{path: absPath, clients: make(map[chan struct{}]struct{})}
+	if err := watcher.load(); err != nil {
+		fatal("Failed to read %s: %v", htmlFile, err)
+	}
+	go watcher.run(ctx)
 
 	mux := http.NewServeMux()
 
-	// Serve the HTML file at /
+	// Serve the HTML file at /, from memory; the watcher rereads it on change.
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
 			return
 		}
-		content, err := os.ReadFile(absPath)
-		if err != nil {
-			http.Error(w, "Failed to read file", 500)
-			return
-		}
-		// Inject live-reload script before </body>
-		reload := []byte(`<script>
-const es = new EventSource("/__reload");
-es.onmessage = () => location.reload();
-es.onerror = () => setTimeout(() => location.reload(), 2000);
-</script></body>`)
-		injected := replaceBytes(content, []byte("</body>"), reload)
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.Write(injected)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(watcher.page())
 	})
 
 	// SSE endpoint for live reload
 	mux.HandleFunc("/__reload", func(w http.ResponseWriter, r *http.Request) {
-		flusher, ok := w.(http.Flusher)
+		ch, ok := watcher.subscribe(maxClients)
 		if !ok {
-			http.Error(w, "Streaming not supported", 500)
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "Too many live-reload clients", http.StatusServiceUnavailable)
 			return
 		}
+		defer watcher.unsubscribe(ch)
+
+		rc := http.NewResponseController(w)
+		send := func(msg string) bool {
+			rc.SetWriteDeadline(time.Now().Add(serveWriteTimeout))
+			if _, err := fmt.Fprint(w, msg); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		}
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		flusher.Flush()
-
-		ch := watcher.subscribe()
-		defer watcher.unsubscribe(ch)
+		if !send("retry: 2000\n\n") {
+			return
+		}
 
+		heartbeat := time.NewTicker(serveHeartbeat)
+		defer heartbeat.Stop()
 		for {
 			select {
 			case <-ch:
-				fmt.Fprintf(w, "data: reload\n\n")
-				flusher.Flush()
+				if !send("data: reload\n\n") {
+					return
+				}
+			case <-heartbeat.C:
+				if !send(": ping\n\n") {
+					return
+				}
 			case <-r.Context().Done():
 				return
 			}
@@ -81,13 +125,35 @@ es.onerror = () => setTimeout(() => location.reload(), 2000);
 		fatal("Failed to listen on %s: %v", addr, err)
 	}
 
-	fmt.Fprintf(os.Stderr, "Serving %s at http://localhost%s\n", htmlFile, addr)
+	fmt.Fprintf(os.Stderr, "Serving %s at http://localhost%s (Ctrl-C to stop)\n", htmlFile, addr)
 
 	// Try to open the port in Gitpod and print the public URL
-	openGitpodPort(port)
+	if offlineHosts == nil {
+		openGitpodPort(port)
+	}
 
-	if err := http.Serve(ln, mux); err != nil {
+	// Requests derive from ctx, so a shutdown also ends the live-reload
+	// streams, which would otherwise keep Shutdown waiting.
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
 		fatal("Server error: %v", err)
+	case <-ctx.Done():
+	}
+	stop() // a second Ctrl-C kills the process
+	fmt.Fprintf(os.Stderr, "Shutting down...\n")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		fmt.Fprintf(os.Stderr, "WARNING: Server shutdown: %v\n", err)
 	}
 }
 
@@ -113,71 +179,71 @@ func openGitpodPort(port int) {
 	}
 }
 
-func replaceBytes(s, old, new []byte) []byte {
-	for i := 0; i <= len(s)-len(old); i++ {
-		match := true
-		for j := range old {
-			if s[i+j] != old[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			result := make([]byte, 0, len(s)-len(old)+len(new))
-			result = append(result, s[:i]...)
-			result = append(result, new...)
-			result = append(result, s[i+len(old):]...)
-			return result
-		}
-	}
-	return s
+// fileWatcher keeps the served page in memory and notifies live-reload
+// subscribers when the file's content changes. Change events come from
+// watchFile (inotify on Linux, polling elsewhere); the file is read once per
+// burst of events, and a save that leaves the content unchanged (a touch, or
+// a rerun with the same data) doesn't reload anyone.
+type fileWatcher struct {
+	path    string
+	mu      sync.Mutex
+	content []byte // the file with reloadScript injected
+	hash    uint64 // FNV-1a of the file as read
+	clients map[chan struct{}]struct{}
 }
 
-// hashFile returns a simple FNV-1a hash of the file contents, or 0 on error.
-func hashFile(path string) uint64 {
-	data, err := os.ReadFile(path)
+// load reads the file into memory. It returns errUnchanged when the content
+// is the same as at the last load.
+func (fw *fileWatcher) load() error {
+	data, err := os.ReadFile(fw.path)
 	if err != nil {
-		return 0
+		return err
 	}
-	// FNV-1a
-	var h uint64 = 14695981039346656037
-	for _, b := range data {
-		h ^= uint64(b)
-		h *= 1099511628211
+	h := fnv.New64a()
+	h.Write(data)
+	sum := h.Sum64()
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.content != nil && sum == fw.hash {
+		return errUnchanged
 	}
-	return h
+	fw.hash = sum
+	fw.content = bytes.Replace(data, []byte("</body>"), []byte(reloadScript), 1)
+	return nil
 }
 
-// fileWatcher polls a file for modification time changes and notifies subscribers.
-type fileWatcher struct {
-	path    string
-	mu      sync.Mutex
-	clients []chan struct{}
+var errUnchanged = errors.New("content unchanged")
+
+func (fw *fileWatcher) page() []byte {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.content
 }
 
-func (fw *fileWatcher) subscribe() chan struct{} {
+// subscribe registers a live-reload client, or returns false when max
+// clients are already connected.
+func (fw *fileWatcher) subscribe(max int) (chan struct{}, bool) {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
+	if max > 0 && len(fw.clients) >= max {
+		return nil, false
+	}
 	ch := make(chan struct{}, 1)
-	fw.clients = append(fw.clients, ch)
-	return ch
+	fw.clients[ch] = struct{}{}
+	return ch, true
 }
 
 func (fw *fileWatcher) unsubscribe(ch chan struct{}) {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
-	for i, c := range fw.clients {
-		if c == ch {
-			fw.clients = append(fw.clients[:i], fw.clients[i+1:]...)
-			break
-		}
-	}
+	delete(fw.clients, ch)
 }
 
 func (fw *fileWatcher) notify() {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
-	for _, ch := range fw.clients {
+	for ch := range fw.clients {
 		select {
 		case ch <- struct{}{}:
 		default:
@@ -185,36 +251,29 @@ func (fw *fileWatcher) notify() {
 	}
 }
 
-func (fw *fileWatcher) watch() {
-	var lastMod time.Time
-	var lastSize int64
-	var lastHash uint64
+// run reloads the page after each burst of change events until ctx is done.
+func (fw *fileWatcher) run(ctx context.Context) {
+	events, err := watchFile(ctx, fw.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: Live reload disabled: %v\n", err)
+		return
+	}
+	debounce := time.NewTimer(serveDebounce)
+	debounce.Stop()
 	for {
-		info, err := os.Stat(fw.path)
-		if err == nil {
-			mod := info.ModTime()
-			size := info.Size()
-			changed := false
-			if !lastMod.IsZero() && (mod.After(lastMod) || size != lastSize) {
-				changed = true
-			}
-			// If modtime and size match, check content hash to catch
-			// overwrites within the same filesystem timestamp second.
-			if !changed && !lastMod.IsZero() {
-				if h := hashFile(fw.path); h != 0 && h != lastHash {
-					changed = true
-				}
-			}
-			if changed {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			debounce.Reset(serveDebounce)
+		case <-debounce.C:
+			switch err := fw.load(); {
+			case err == nil:
 				fmt.Fprintf(os.Stderr, "File changed, reloading browsers...\n")
 				fw.notify()
-			}
-			lastMod = mod
-			lastSize = size
-			if h := hashFile(fw.path); h != 0 {
-				lastHash = h
+			case !errors.Is(err, errUnchanged) && !errors.Is(err, os.ErrNotExist):
+				fmt.Fprintf(os.Stderr, "WARNING: Failed to reread %s: %v\n", fw.path, err)
 			}
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sizeBucket is a PR size class by lines changed (additions + deletions), up
+// to but not including maxLines; 0 for the open-ended last bucket.
+type sizeBucket struct {
+	name     string
+	maxLines int
+}
+
+// sizeBuckets are the --size-buckets classes, smallest first.
+var sizeBuckets = []sizeBucket{
+	{"XS", 10},
+	{"S", 50},
+	{"M", 250},
+	{"L", 1000},
+	{"XL", 0},
+}
+
+// sizeBucketIndex returns the index in sizeBuckets of a PR's size class.
+func sizeBucketIndex(pr enrichedPR) int {
+	lines := pr.additions + pr.deletions
+	for i, b := range sizeBuckets {
+		if b.maxLines == 0 || lines < b.maxLines {
+			return i
+		}
+	}
+	return len(sizeBuckets) - 1
+}
+
+// sizeBucketRange describes a bucket's lines-changed range, e.g. "50–249".
+func sizeBucketRange(i int) string {
+	lo := 0
+	if i > 0 {
+		lo = sizeBuckets[i-1].maxLines
+	}
+	if sizeBuckets[i].maxLines == 0 {
+		return fmt.Sprintf("%d+", lo)
+	}
+	return fmt.Sprintf("%d–%d", lo, sizeBuckets[i].maxLines-1)
+}
+
+// applySizeBuckets sets each week's merged PRs and median review time per
+// size bucket, so a shift in review time can be told apart from a shift in
+// the size mix.
+func applySizeBuckets(prs []enrichedPR, weeks []weekRange, stats []weekStats) {
+	reviewTimes := make([][][]float64, len(weeks))
+	for i := range stats {
+		stats[i].sizeBucketsTracked = true
+		stats[i].prsBySize = make([]int, len(sizeBuckets))
+		reviewTimes[i] = make([][]float64, len(sizeBuckets))
+	}
+	for _, pr := range prs {
+		i := weekIndex(weeks, pr.mergedEpoch)
+		if i < 0 {
+			continue
+		}
+		b := sizeBucketIndex(pr)
+		stats[i].prsBySize[b]++
+		if pr.reviewTimeHours >= 0 {
+			reviewTimes[i][b] = append(reviewTimes[i][b], pr.reviewTimeHours)
+		}
+	}
+	for i := range stats {
+		stats[i].reviewTimeBySize = make([]float64, len(sizeBuckets))
+		for b, vals := range reviewTimes[i] {
+			stats[i].reviewTimeBySize[b] = median(vals)
+		}
+	}
+}
+
+// logSizeBuckets prints the PR count and median review time per bucket over
+// the whole range to stderr.
+func logSizeBuckets(prs []enrichedPR) {
+	counts := make([]int, len(sizeBuckets))
+	reviewTimes := make([][]float64, len(sizeBuckets))
+	for _, pr := range prs {
+		b := sizeBucketIndex(pr)
+		counts[b]++
+		if pr.reviewTimeHours >= 0 {
+			reviewTimes[b] = append(reviewTimes[b], pr.reviewTimeHours)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "PR size buckets (lines changed):\n")
+	for i, b := range sizeBuckets {
+		review := "no review data"
+		if m := median(reviewTimes[i]); m >= 0 {
+			review = fmt.Sprintf("median review %.1fh", m)
+		}
+		fmt.Fprintf(os.Stderr, "  %-2s %-9s %5d PR(s), %s\n", b.name, sizeBucketRange(i), counts[i], review)
+	}
+}
+
+// sizeBucketMetrics declares the --size-buckets CSV columns:
+// prs_merged_<bucket> for every bucket, then median_review_time_hours_<bucket>.
+func sizeBucketMetrics() []metricDef {
+	tracked := func(ws weekStats) bool { return ws.sizeBucketsTracked }
+	var counts, reviewTimes []metricDef
+	for b, bucket := range sizeBuckets {
+		suffix := "_" + strings.ToLower(bucket.name)
+		counts = append(counts, metricDef{
+			name: "prs_merged" + suffix,
+			extract: func(ws weekStats) float64 {
+				if ws.prsBySize == nil {
+					return 0
+				}
+				return float64(ws.prsBySize[b])
+			},
+			csv:      "%.0f",
+			column:   tracked,
+			engineer: true,
+		})
+		reviewTimes = append(reviewTimes, metricDef{
+			name: "median_review_time_hours" + suffix,
+			extract: func(ws weekStats) float64 {
+				if ws.reviewTimeBySize == nil {
+					return -1
+				}
+				return ws.reviewTimeBySize[b]
+			},
+			csv:      "%.2f",
+			column:   tracked,
+			engineer: true,
+		})
+	}
+	return append(counts, reviewTimes...)
+}
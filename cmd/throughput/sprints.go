@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sprint is one iteration of a GitHub Project (v2) iteration field
+// (--sprint-project).
+type sprint struct {
+	name  string
+	start time.Time // first day
+	end   time.Time // last day
+}
+
+var projectURLRe = regexp.MustCompile(`^https://github\.com/(?:orgs|users)/([^/]+)/projects/(\d+)`)
+
+// parseSprintProject accepts a project as owner/number or as its URL
+// (https://github.com/orgs/acme/projects/12).
+func parseSprintProject(s string) (owner string, number int, err error) {
+	s = strings.TrimSpace(s)
+	if m := projectURLRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return m[1], n, nil
+	}
+	owner, num, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(num)
+	if !ok || owner == "" || err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("%q is not owner/number or a project URL", s)
+	}
+	return owner, n, nil
+}
+
+// fetchSprints reads the iterations of a project's iteration field, past
+// and planned, sorted by start date. field picks the field by name; "" takes
+// the project's first iteration field. Reading projects needs a token with
+// the read:project scope.
+func fetchSprints(token, owner string, number int, field string) ([]sprint, error) {
+	query := fmt.Sprintf(`{
+  repositoryOwner(login: %q) {
+    ... on ProjectV2Owner {
+      projectV2(number: %d) {
+        title
+        fields(first: 50) {
+          nodes {
+            ... on ProjectV2IterationField {
+              name
+              configuration {
+                iterations { title startDate duration }
+                completedIterations { title startDate duration }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`, owner, number)
+
+	resp, err := graphqlQuery(token, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w (reading projects needs the read:project scope)", err)
+	}
+	type iteration struct {
+		Title     string `json:"title"`
+		StartDate string `json:"startDate"`
+		Duration  int    `json:"duration"` // days
+	}
+	var result struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				Title  string `json:"title"`
+				Fields struct {
+					Nodes []struct {
+						Name          string `json:"name"`
+						Configuration *struct {
+							Iterations          []iteration `json:"iterations"`
+							CompletedIterations []iteration `json:"completedIterations"`
+						} `json:"configuration"`
+					} `json:"nodes"`
+				} `json:"fields"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if result.RepositoryOwner == nil || result.RepositoryOwner.ProjectV2 == nil {
+		return nil, fmt.Errorf("project %s/%d not found or not readable", owner, number)
+	}
+
+	project := result.RepositoryOwner.ProjectV2
+	for _, f := range project.Fields.Nodes {
+		if f.Configuration == nil || (field != "" && f.Name != field) {
+			continue
+		}
+		var sprints []sprint
+		for _, it := range append(f.Configuration.CompletedIterations, f.Configuration.Iterations...) {
+			start, err := time.Parse("2006-01-02", it.StartDate)
+			if err != nil || it.Duration <= 0 {
+				continue
+			}
+			sprints = append(sprints, sprint{name: it.Title, start: start, end: start.AddDate(0, 0, it.Duration-1)})
+		}
+		sort.Slice(sprints, func(i, j int) bool { return sprints[i].start.Before(sprints[j].start) })
+		return sprints, nil
+	}
+	if field != "" {
+		return nil, fmt.Errorf("project %q has no iteration field named %q", project.Title, field)
+	}
+	return nil, fmt.Errorf("project %q has no iteration field", project.Title)
+}
+
+// sprintOf returns the index of the sprint a week belongs to, or -1. Sprints
+// needn't start on Mondays, so a week goes to the sprint holding its
+// Thursday, the one with most of its working days.
+func sprintOf(sprints []sprint, week weekRange) int {
+	mid := week.start.AddDate(0, 0, 3)
+	for i, s := range sprints {
+		if !mid.Before(s.start) && !mid.After(s.end) {
+			return i
+		}
+	}
+	return -1
+}
+
+// aggregateSprints rolls weekly stats up into sprints, like aggregateMonthly
+// does into months. Sprints the week range covers only partly (the one in
+// progress, usually, and the one the range starts in) are dropped, as are
+// weeks between sprints.
+func aggregateSprints(weeks []weekRange, stats []weekStats, sprints []sprint) ([]weekRange, []weekStats) {
+	if len(weeks) == 0 {
+		return nil, nil
+	}
+	// Allow for the days a Monday-aligned week range misses at either end
+	first := weeks[0].start.AddDate(0, 0, -3)
+	last := weeks[len(weeks)-1].end.AddDate(0, 0, 3)
+
+	var groups []periodGroup
+	byIndex := make(map[int]int) // sprint index to groups index
+	for i, wr := range weeks {
+		si := sprintOf(sprints, wr)
+		if si < 0 {
+			continue
+		}
+		s := sprints[si]
+		if s.start.Before(first) || s.end.After(last) {
+			continue
+		}
+		gi, ok := byIndex[si]
+		if !ok {
+			gi = len(groups)
+			byIndex[si] = gi
+			groups = append(groups, periodGroup{start: s.start, end: s.end, name: s.name})
+		}
+		groups[gi].weeks = append(groups[gi].weeks, i)
+	}
+	return rollupWeeks(stats, groups)
+}
+
+// meanPeriodDays is the average length of the periods in days, for per-day
+// rates over sprints of varying length.
+func meanPeriodDays(periods []weekRange) float64 {
+	if len(periods) == 0 {
+		return 7
+	}
+	var days float64
+	for _, p := range periods {
+		days += p.end.Sub(p.start).Hours()/24 + 1
+	}
+	return days / float64(len(periods))
+}
+
+// appendSprintColumn adds the sprint each week belongs to (by sprintOf) to
+// the weekly CSV, empty for weeks between sprints.
+func appendSprintColumn(csv string, weeks []weekRange, sprints []sprint) string {
+	lines := strings.Split(strings.TrimRight(csv, "\n"), "\n")
+	if len(lines) == 0 {
+		return csv
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lines[0])
+	sb.WriteString(",sprint\n")
+	for i, line := range lines[1:] {
+		sb.WriteString(line)
+		sb.WriteByte(',')
+		if i < len(weeks) {
+			if si := sprintOf(sprints, weeks[i]); si >= 0 {
+				sb.WriteString(csvCell(sprints[si].name))
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// csvCell quotes a free-text CSV value if it needs it. Line breaks become
+// spaces: the stats CSV is handled line by line.
+func csvCell(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+	if !strings.ContainsAny(s, ",\"") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
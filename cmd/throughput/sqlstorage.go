@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The SQL backends of --storage talk to the database through its CLI
+// (sqlite3, psql) rather than a driver, keeping the tool free of
+// dependencies beyond the standard library. Statements are built here with
+// inlined, quoted literals and piped to the CLI's stdin; reads have the
+// database render the rows as one JSON array.
+
+// sqlDialect is what differs between the SQL backends.
+type sqlDialect struct {
+	types      map[string]string // column kind (text, date, time, int, float, bool, json) to SQL type
+	trueLit    string
+	falseLit   string
+	jsonObject string                                       // function building a JSON object from key, value pairs
+	selectJSON func(table, obj, where, order string) string // query printing obj of each row as one JSON array
+	jsonBool   func(col string) string                      // col as a JSON boolean inside jsonObject
+	jsonValue  func(col string) string                      // a json column inside jsonObject
+}
+
+// sqlColumn is a column of a --storage table.
+type sqlColumn struct {
+	name string
+	kind string
+}
+
+// sqlTable is a --storage table. Key columns and dates are NOT NULL.
+type sqlTable struct {
+	name    string
+	cols    []sqlColumn
+	key     []string
+	indexes [][]string // secondary indexes, for dashboards filtering on them
+}
+
+var weeksTable = sqlTable{
+	name: "weeks",
+	cols: []sqlColumn{
+		{"repo", "text"}, {"week_start", "date"}, {"week_end", "date"},
+		{"suppressed", "bool"}, {"incomplete", "bool"},
+		{"prs_merged", "int"}, {"unique_authors", "int"}, {"prs_per_engineer", "float"},
+		{"median_coding_time_hours", "float"}, {"median_review_time_hours", "float"},
+		{"pct_ona_involved", "float"}, {"pct_reverts", "float"}, {"build_runs", "int"},
+		{"active_engineers_4w", "int"}, {"churned_engineers", "int"},
+		{"incident_count", "int"}, {"median_mttr_hours", "float"},
+		{"user_metrics", "json"}, {"updated_at", "time"},
+	},
+	key:     []string{"repo", "week_start"},
+	indexes: [][]string{{"week_start"}},
+}
+
+var prsTable = sqlTable{
+	name: "prs",
+	cols: []sqlColumn{
+		{"repo", "text"}, {"number", "int"}, {"author", "text"}, {"merged_at", "time"},
+		{"coding_time_hours", "float"}, {"review_time_hours", "float"},
+		{"additions", "int"}, {"deletions", "int"}, {"changed_files", "int"},
+		{"ona_involved", "bool"}, {"is_revert", "bool"}, {"updated_at", "time"},
+	},
+	key:     []string{"repo", "number", "merged_at", "author"},
+	indexes: [][]string{{"repo", "merged_at"}, {"author", "merged_at"}},
+}
+
+var contributorsTable = sqlTable{
+	name: "contributors",
+	cols: []sqlColumn{
+		{"repo", "text"}, {"login", "text"}, {"total_prs", "int"},
+		{"before_rate", "float"}, {"after_rate", "float"},
+		{"has_ona_prs", "bool"}, {"ona_pct", "float"}, {"excluded", "bool"},
+		{"updated_at", "time"},
+	},
+	key: []string{"repo", "login"},
+}
+
+var runsTable = sqlTable{
+	name: "runs",
+	cols: []sqlColumn{
+		{"repo", "text"}, {"generated_at", "time"}, {"branch", "text"}, {"granularity", "text"},
+		{"weeks", "int"}, {"first_week", "date"}, {"last_week", "date"},
+		{"prs_merged", "int"}, {"schema_version", "int"},
+	},
+	key:     []string{"repo", "generated_at"},
+	indexes: [][]string{{"generated_at"}},
+}
+
+// storageTables are created, if missing, by every SQL storage script.
+var storageTables = []sqlTable{weeksTable, prsTable, contributorsTable, runsTable}
+
+// schemaSQL returns the idempotent CREATE TABLE and CREATE INDEX statements
+// of every table.
+func (d sqlDialect) schemaSQL() string {
+	var b strings.Builder
+	for _, t := range storageTables {
+		var defs []string
+		for _, c := range t.cols {
+			def := c.name + " " + d.types[c.kind]
+			if slices.Contains(t.key, c.name) || c.kind == "date" || c.name == "updated_at" {
+				def += " NOT NULL"
+			}
+			defs = append(defs, def)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(t.key, ", ")+")")
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n  %s\n);\n", t.name, strings.Join(defs, ",\n  "))
+		for _, idx := range t.indexes {
+			fmt.Fprintf(&b, "CREATE INDEX IF NOT EXISTS %s_%s_idx ON %s (%s);\n",
+				t.name, strings.Join(idx, "_"), t.name, strings.Join(idx, ", "))
+		}
+	}
+	return b.String()
+}
+
+// upsertSQL returns INSERT ... ON CONFLICT DO UPDATE statements for rows, in
+// chunks so no statement gets too large.
+func (d sqlDialect) upsertSQL(t sqlTable, rows [][]string) string {
+	var names, updates []string
+	for _, c := range t.cols {
+		names = append(names, c.name)
+		if !slices.Contains(t.key, c.name) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", c.name, c.name))
+		}
+	}
+	var b strings.Builder
+	for start := 0; start < len(rows); start += 500 {
+		chunk := rows[start:min(start+500, len(rows))]
+		fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES\n", t.name, strings.Join(names, ", "))
+		for i, r := range chunk {
+			sep := ",\n"
+			if i == len(chunk)-1 {
+				sep = "\n"
+			}
+			b.WriteString("  (" + strings.Join(r, ", ") + ")" + sep)
+		}
+		fmt.Fprintf(&b, "ON CONFLICT (%s) DO UPDATE SET %s;\n", strings.Join(t.key, ", "), strings.Join(updates, ", "))
+	}
+	return b.String()
+}
+
+// Literals. Strings are quoted by doubling single quotes; the CLIs read
+// standard SQL, so nothing else needs escaping.
+
+func sqlString(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+
+func sqlInt(v int) string { return strconv.Itoa(v) }
+
+func sqlIntPtr(v *int) string {
+	if v == nil {
+		return "NULL"
+	}
+	return sqlInt(*v)
+}
+
+func sqlFloat(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "NULL"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sqlFloatPtr(v *float64) string {
+	if v == nil {
+		return "NULL"
+	}
+	return sqlFloat(*v)
+}
+
+func (d sqlDialect) sqlBool(v bool) string {
+	if v {
+		return d.trueLit
+	}
+	return d.falseLit
+}
+
+func sqlTime(t time.Time) string { return sqlString(t.UTC().Format(time.RFC3339)) }
+
+// weekRows renders weeks as upsertSQL rows of weeksTable.
+func (d sqlDialect) weekRows(repo string, weeks []snapshotWeek, now time.Time) ([][]string, error) {
+	var rows [][]string
+	for _, w := range weeks {
+		metrics := "NULL"
+		if len(w.UserMetrics) > 0 {
+			data, err := json.Marshal(w.UserMetrics)
+			if err != nil {
+				return nil, err
+			}
+			metrics = sqlString(string(data))
+		}
+		rows = append(rows, []string{
+			sqlString(repo), sqlString(w.WeekStart), sqlString(w.WeekEnd),
+			d.sqlBool(w.Suppressed), d.sqlBool(w.Incomplete),
+			sqlInt(w.PRsMerged), sqlInt(w.UniqueAuthors), sqlFloat(w.PRsPerEngineer),
+			sqlFloatPtr(w.MedianCodingTimeHours), sqlFloatPtr(w.MedianReviewTimeHours),
+			sqlFloat(w.PctOnaInvolved), sqlFloat(w.PctReverts), sqlInt(w.BuildRuns),
+			sqlIntPtr(w.ActiveEngineers4w), sqlIntPtr(w.ChurnedEngineers),
+			sqlIntPtr(w.IncidentCount), sqlFloatPtr(w.MedianMTTRHours),
+			metrics, sqlTime(now),
+		})
+	}
+	return rows, nil
+}
+
+// prRows renders PRs as upsertSQL rows of prsTable.
+func (d sqlDialect) prRows(repo string, prs []storedPR, now time.Time) [][]string {
+	var rows [][]string
+	for _, p := range prs {
+		rows = append(rows, []string{
+			sqlString(repo), sqlInt(p.Number), sqlString(p.Author), sqlTime(p.MergedAt),
+			sqlFloatPtr(p.CodingTimeHours), sqlFloatPtr(p.ReviewTimeHours),
+			sqlInt(p.Additions), sqlInt(p.Deletions), sqlInt(p.ChangedFiles),
+			d.sqlBool(p.OnaInvolved), d.sqlBool(p.IsRevert), sqlTime(now),
+		})
+	}
+	return rows
+}
+
+// contributorRows renders contributors as upsertSQL rows of
+// contributorsTable.
+func (d sqlDialect) contributorRows(repo string, contributors []snapshotContributor, now time.Time) [][]string {
+	var rows [][]string
+	for _, c := range contributors {
+		rows = append(rows, []string{
+			sqlString(repo), sqlString(c.Login), sqlInt(c.TotalPRs),
+			sqlFloat(c.BeforeRate), sqlFloat(c.AfterRate),
+			d.sqlBool(c.HasOnaPRs), sqlFloat(c.OnaPct), d.sqlBool(c.Excluded), sqlTime(now),
+		})
+	}
+	return rows
+}
+
+// runRow renders a run as the upsertSQL row of runsTable.
+func (d sqlDialect) runRow(run storedRun) []string {
+	return []string{
+		sqlString(run.Repo), sqlTime(run.GeneratedAt), sqlString(run.Branch), sqlString(run.Granularity),
+		sqlInt(run.Weeks), sqlString(run.FirstWeek), sqlString(run.LastWeek),
+		sqlInt(run.PRsMerged), sqlInt(run.SchemaVersion),
+	}
+}
+
+// saveRunSQL returns the statements recording a run and its contributors.
+func (d sqlDialect) saveRunSQL(run storedRun, contributors []snapshotContributor) string {
+	script := d.upsertSQL(runsTable, [][]string{d.runRow(run)})
+	if rows := d.contributorRows(run.Repo, contributors, run.GeneratedAt); len(rows) > 0 {
+		script += d.upsertSQL(contributorsTable, rows)
+	}
+	return script
+}
+
+// loadRangeSQL returns a query printing the weeks of repo between from and
+// to as a JSON array of snapshotWeek objects.
+func (d sqlDialect) loadRangeSQL(repo string, from, to time.Time) string {
+	var pairs []string
+	for _, c := range weeksTable.cols {
+		col := c.name
+		switch {
+		case c.name == "repo" || c.name == "updated_at":
+			continue
+		case c.kind == "bool":
+			col = d.jsonBool(c.name)
+		case c.kind == "json":
+			col = d.jsonValue(c.name)
+		case c.kind == "date":
+			col = "CAST(" + c.name + " AS TEXT)"
+		}
+		pairs = append(pairs, sqlString(c.name), col)
+	}
+	where := "repo = " + sqlString(repo)
+	if !from.IsZero() {
+		where += " AND week_start >= " + sqlString(from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		where += " AND week_start <= " + sqlString(to.Format("2006-01-02"))
+	}
+	obj := d.jsonObject + "(" + strings.Join(pairs, ", ") + ")"
+	return d.selectJSON("weeks", obj, where, "week_start") + ";\n"
+}
+
+// parseWeeksJSON reads loadRangeSQL's output.
+func parseWeeksJSON(out []byte) ([]snapshotWeek, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return nil, nil
+	}
+	var weeks []snapshotWeek
+	if err := json.Unmarshal(out, &weeks); err != nil {
+		return nil, fmt.Errorf("unexpected query output: %w", err)
+	}
+	return weeks, nil
+}
+
+// runSQLCLI pipes script into cmd and returns its stdout. Errors carry the
+// CLI's stderr.
+func runSQLCLI(cmd *exec.Cmd, script string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", cmd.Args[0], msg)
+		}
+		return nil, fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return out, nil
+}
+
+// sqliteDialect stores dates and times as ISO 8601 text and booleans as
+// 0/1; SQLite 3.24 or later is needed for upserts, 3.38 for the JSON
+// functions without a compile flag.
+var sqliteDialect = sqlDialect{
+	types: map[string]string{
+		"text": "TEXT", "date": "TEXT", "time": "TEXT", "int": "INTEGER",
+		"float": "REAL", "bool": "INTEGER", "json": "TEXT",
+	},
+	trueLit:    "1",
+	falseLit:   "0",
+	jsonObject: "json_object",
+	selectJSON: func(table, obj, where, order string) string {
+		return fmt.Sprintf("SELECT json_group_array(json(%s)) FROM (SELECT * FROM %s WHERE %s ORDER BY %s)", obj, table, where, order)
+	},
+	jsonBool:  func(col string) string { return "json(CASE WHEN " + col + " THEN 'true' ELSE 'false' END)" },
+	jsonValue: func(col string) string { return "json(" + col + ")" },
+}
+
+// sqliteStorage is --storage sqlite:FILE. SQLite serializes writers, so
+// several runs may share the file on one host; it isn't for network
+// filesystems.
+type sqliteStorage struct {
+	path string
+}
+
+func newSQLiteStorage(path string) (Storage, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite: needs a database file")
+	}
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, fmt.Errorf("sqlite storage needs the sqlite3 CLI on PATH")
+	}
+	return sqliteStorage{path: path}, nil
+}
+
+func (s sqliteStorage) exec(script string) ([]byte, error) {
+	return runSQLCLI(exec.Command("sqlite3", "-bail", "-batch", "-noheader", s.path),
+		".timeout 10000\n"+sqliteDialect.schemaSQL()+script)
+}
+
+func (s sqliteStorage) SaveWeeks(repo string, weeks []snapshotWeek) error {
+	if len(weeks) == 0 {
+		return nil
+	}
+	rows, err := sqliteDialect.weekRows(repo, weeks, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = s.exec("BEGIN;\n" + sqliteDialect.upsertSQL(weeksTable, rows) + "COMMIT;\n")
+	return err
+}
+
+func (s sqliteStorage) SavePRs(repo string, prs []storedPR) error {
+	if len(prs) == 0 {
+		return nil
+	}
+	rows := sqliteDialect.prRows(repo, prs, time.Now())
+	_, err := s.exec("BEGIN;\n" + sqliteDialect.upsertSQL(prsTable, rows) + "COMMIT;\n")
+	return err
+}
+
+func (s sqliteStorage) LoadRange(repo string, from, to time.Time) ([]snapshotWeek, error) {
+	out, err := s.exec(sqliteDialect.loadRangeSQL(repo, from, to))
+	if err != nil {
+		return nil, err
+	}
+	return parseWeeksJSON(out)
+}
+
+func (s sqliteStorage) SaveRun(run storedRun, contributors []snapshotContributor) error {
+	_, err := s.exec("BEGIN;\n" + sqliteDialect.saveRunSQL(run, contributors) + "COMMIT;\n")
+	return err
+}
@@ -2,56 +2,471 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"slices"
 )
 
 // --- Metric definitions ---
 
-// metricDef defines how to extract a metric from weekly data.
+// metricDef is the single declaration of a metric: how to read it from a
+// period's stats, how the weekly CSV writes it, and how the HTML report shows
+// it. The stats CSV, stat cards, deltas, targets, correlations, and glossary
+// all work from these lists.
 type metricDef struct {
 	name    string
 	extract func(ws weekStats) float64
 	valid   func(ws weekStats) bool
+	doc     *metricDoc // Metric Definitions card in the HTML report; nil for none
+
+	// csv is the fmt verb for the metric's weekly CSV column; "" for a metric
+	// without one. Negative values (no data) are written empty. format, if
+	// set, replaces it for metrics whose negative values are data.
+	csv    string
+	format func(v float64) string
+	// column reports whether a period's stats carry the metric's optional
+	// CSV column (one in optionalCSVLayout), usually its flag's tracked
+	// field; the column is written when any week does.
+	column func(ws weekStats) bool
+	// engineer marks a metric derived from individual engineers' PRs:
+	// --min-group-size blanks its cells in weeks with too few authors.
+	// Build, incident, and --series metrics come from other sources.
+	engineer bool
+
+	label         string // stat card and chart label, translated by the report locale
+	unit          string // "", "%", "hrs", "min", or "days"
+	category      string // "Speed", "Quality", "Ona Uptake", "Release", "Cycle Time", or "activity"; "" for CSV-only metrics
+	lowerIsBetter bool   // a decrease is colored as an improvement
 }
 
 // allMetrics defines the rows in the consolidated stats CSV.
 var allMetrics = []metricDef{
 	{
-		name:    "prs_merged",
-		extract: func(ws weekStats) float64 { return float64(ws.prsMerged) },
-		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+		name:     "prs_merged",
+		extract:  func(ws weekStats) float64 { return float64(ws.prsMerged) },
+		valid:    func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:      &prsMergedDoc,
+		csv:      "%.0f",
+		engineer: true,
+		label:    "PRs merged",
+		category: "activity",
+	},
+	{
+		name:     "unique_authors",
+		extract:  func(ws weekStats) float64 { return float64(ws.uniqueAuthors) },
+		valid:    func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:      &uniqueAuthorsDoc,
+		csv:      "%.0f",
+		engineer: true,
+		label:    "Unique authors",
+		category: "activity",
+	},
+	{
+		name:     "prs_per_engineer",
+		extract:  func(ws weekStats) float64 { return ws.prsPerEngineer },
+		valid:    func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:      &prsPerEngineerDoc,
+		csv:      "%.2f",
+		engineer: true,
+		label:    "Median PRs / Engineer",
+		category: "Speed",
+	},
+	{
+		name:     "commits_per_engineer",
+		extract:  func(ws weekStats) float64 { return ws.commitsPerEngineer },
+		valid:    func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:      &commitsPerEngineerDoc,
+		label:    "Commits / engineer",
+		category: "activity",
+	},
+	{
+		name:     "size_points_per_engineer",
+		extract:  func(ws weekStats) float64 { return ws.sizePointsPerEngineer },
+		valid:    func(ws weekStats) bool { return ws.sizeWeighted && ws.prsMerged > 0 },
+		csv:      "%.2f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.sizeWeighted },
+		doc:      &sizePointsDoc,
+		label:    "Median Size Points / Engineer",
+		category: "Speed",
+	},
+	{
+		name:     "active_engineers_4w",
+		extract:  func(ws weekStats) float64 { return float64(ws.activeEngineers4w) },
+		valid:    func(ws weekStats) bool { return ws.retentionTracked && ws.activeEngineers4w >= 0 },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.retentionTracked },
+		doc:      &retentionDoc,
+		label:    "Active engineers (4w)",
+		category: "activity",
+	},
+	{
+		name:     "churned_engineers",
+		extract:  func(ws weekStats) float64 { return float64(ws.churnedEngineers) },
+		valid:    func(ws weekStats) bool { return ws.retentionTracked && ws.churnedEngineers >= 0 },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.retentionTracked },
+		label:    "Churned engineers",
+		category: "activity",
+	},
+	{
+		name:          "pct_reverts",
+		extract:       func(ws weekStats) float64 { return ws.pctReverts },
+		valid:         func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:           &revertsDoc,
+		csv:           "%.1f",
+		engineer:      true,
+		label:         "Reverts",
+		unit:          "%",
+		category:      "Quality",
+		lowerIsBetter: true,
+	},
+	{
+		name:     "pct_ona_involved",
+		extract:  func(ws weekStats) float64 { return ws.pctOnaInvolved },
+		valid:    func(ws weekStats) bool { return ws.prsMerged > 0 },
+		doc:      &onaInvolvedDoc,
+		csv:      "%.1f",
+		engineer: true,
+		label:    "Ona Involved",
+		unit:     "%",
+		category: "Ona Uptake",
+	},
+	{
+		name:     "pct_ona_lines",
+		extract:  func(ws weekStats) float64 { return ws.pctOnaLines },
+		valid:    func(ws weekStats) bool { return ws.onaWeighted && ws.pctOnaLines >= 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.onaWeighted },
+		doc:      &onaLinesDoc,
+		label:    "Ona lines",
+		unit:     "%",
+		category: "Ona Uptake",
+	},
+	{
+		name:     "pct_described",
+		extract:  func(ws weekStats) float64 { return hygieneShare(ws, ws.pctDescribed) },
+		valid:    func(ws weekStats) bool { return ws.hygieneTracked && ws.prsMerged > 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.hygieneTracked },
+		doc:      &describedDoc,
+		label:    "Described",
+		unit:     "%",
+		category: "Quality",
+	},
+	{
+		name:     "pct_linked_issue",
+		extract:  func(ws weekStats) float64 { return hygieneShare(ws, ws.pctLinkedIssue) },
+		valid:    func(ws weekStats) bool { return ws.hygieneTracked && ws.prsMerged > 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.hygieneTracked },
+		doc:      &linkedIssueDoc,
+		label:    "Linked to Issue",
+		unit:     "%",
+		category: "Quality",
+	},
+	{
+		name:     "pct_with_tests",
+		extract:  func(ws weekStats) float64 { return hygieneShare(ws, ws.pctWithTests) },
+		valid:    func(ws weekStats) bool { return ws.hygieneTracked && ws.prsMerged > 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.hygieneTracked },
+		doc:      &withTestsDoc,
+		label:    "With Tests",
+		unit:     "%",
+		category: "Quality",
+	},
+	{
+		name:     "pct_approved",
+		extract:  func(ws weekStats) float64 { return ws.pctApproved },
+		valid:    func(ws weekStats) bool { return ws.reviewCoverageTracked && ws.pctApproved >= 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.reviewCoverageTracked },
+		doc:      &approvedDoc,
+		label:    "Approved",
+		unit:     "%",
+		category: "Quality",
+	},
+	{
+		name:          "pct_unreviewed",
+		extract:       func(ws weekStats) float64 { return ws.pctUnreviewed },
+		valid:         func(ws weekStats) bool { return ws.reviewCoverageTracked && ws.pctUnreviewed >= 0 },
+		csv:           "%.1f",
+		engineer:      true,
+		column:        func(ws weekStats) bool { return ws.reviewCoverageTracked },
+		doc:           &unreviewedDoc,
+		label:         "Unreviewed",
+		unit:          "%",
+		category:      "Quality",
+		lowerIsBetter: true,
+	},
+	{
+		name:     "build_runs",
+		extract:  func(ws weekStats) float64 { return float64(ws.buildRuns) },
+		valid:    func(ws weekStats) bool { return ws.buildRuns > 0 },
+		csv:      "%.0f",
+		column:   everyRun,
+		doc:      &buildRunsDoc,
+		label:    "Builds",
+		category: "activity",
+	},
+	{
+		name:     "build_success_pct",
+		extract:  func(ws weekStats) float64 { return ws.buildSuccessPct },
+		valid:    func(ws weekStats) bool { return ws.buildRuns > 0 },
+		csv:      "%.1f",
+		column:   everyRun,
+		doc:      &buildSuccessDoc,
+		label:    "Build success",
+		unit:     "%",
+		category: "activity",
+	},
+	{
+		name:     "pct_template_compliant",
+		extract:  func(ws weekStats) float64 { return ws.pctTemplateCompliant },
+		valid:    func(ws weekStats) bool { return ws.templateTracked && ws.pctTemplateCompliant >= 0 },
+		csv:      "%.1f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.templateTracked },
+		doc:      &templateDoc,
+		label:    "Template compliance",
+		unit:     "%",
+		category: "Quality",
+	},
+	{
+		name:     "security_prs",
+		extract:  func(ws weekStats) float64 { return float64(ws.securityPRs) },
+		valid:    func(ws weekStats) bool { return ws.securityTracked },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.securityTracked },
+		doc:      &securityPRsDoc,
+		label:    "Security Fixes",
+		category: "activity",
+	},
+	{
+		name:          "median_security_lead_hours",
+		extract:       func(ws weekStats) float64 { return ws.medianSecurityLead },
+		valid:         func(ws weekStats) bool { return ws.securityTracked && ws.medianSecurityLead >= 0 },
+		csv:           "%.2f",
+		engineer:      true,
+		column:        func(ws weekStats) bool { return ws.securityTracked },
+		doc:           &securityLeadDoc,
+		label:         "Median Security Fix Lead Time",
+		unit:          "hrs",
+		category:      "Quality",
+		lowerIsBetter: true,
+	},
+	{
+		name:     "releases",
+		extract:  func(ws weekStats) float64 { return float64(ws.releases) },
+		valid:    func(ws weekStats) bool { return ws.releasesTracked },
+		csv:      "%.0f",
+		column:   func(ws weekStats) bool { return ws.releasesTracked },
+		doc:      &releasesDoc,
+		label:    "Releases",
+		category: "Release",
+	},
+	{
+		name:          "median_days_between_releases",
+		extract:       func(ws weekStats) float64 { return ws.medianReleaseGap },
+		valid:         func(ws weekStats) bool { return ws.releasesTracked && ws.medianReleaseGap >= 0 },
+		csv:           "%.2f",
+		column:        func(ws weekStats) bool { return ws.releasesTracked },
+		doc:           &releaseGapDoc,
+		label:         "Median Days Between Releases",
+		unit:          "days",
+		category:      "Release",
+		lowerIsBetter: true,
+	},
+	{
+		name:     "prs_per_release",
+		extract:  func(ws weekStats) float64 { return ws.prsPerRelease },
+		valid:    func(ws weekStats) bool { return ws.releasesTracked && ws.prsPerRelease >= 0 },
+		csv:      "%.2f",
+		column:   func(ws weekStats) bool { return ws.releasesTracked },
+		doc:      &prsPerReleaseDoc,
+		label:    "PRs per Release",
+		category: "Release",
+	},
+	{
+		name:          "median_release_batch_prs",
+		extract:       func(ws weekStats) float64 { return ws.releaseBatch },
+		valid:         func(ws weekStats) bool { return ws.changelogTracked && ws.releaseBatch >= 0 },
+		csv:           "%.2f",
+		column:        func(ws weekStats) bool { return ws.changelogTracked },
+		doc:           &releaseBatchDoc,
+		label:         "Median Release Batch Size",
+		category:      "Release",
+		lowerIsBetter: true,
+	},
+	{
+		name:          "billable_ci_minutes",
+		extract:       func(ws weekStats) float64 { return ws.ciMinutes },
+		valid:         func(ws weekStats) bool { return ws.ciCostTracked && ws.ciMinutes >= 0 },
+		csv:           "%.0f",
+		column:        func(ws weekStats) bool { return ws.ciCostTracked },
+		doc:           &ciMinutesDoc,
+		label:         "Billable CI Minutes",
+		category:      "activity",
+		lowerIsBetter: true,
 	},
 	{
-		name:    "unique_authors",
-		extract: func(ws weekStats) float64 { return float64(ws.uniqueAuthors) },
-		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+		name:          "ci_cost",
+		extract:       func(ws weekStats) float64 { return ws.ciCost },
+		valid:         func(ws weekStats) bool { return ws.ciCostTracked && ws.ciCost >= 0 },
+		csv:           "%.2f",
+		column:        func(ws weekStats) bool { return ws.ciPriced },
+		doc:           &ciCostDoc,
+		label:         "CI Cost",
+		category:      "activity",
+		lowerIsBetter: true,
 	},
 	{
-		name:    "prs_per_engineer",
-		extract: func(ws weekStats) float64 { return ws.prsPerEngineer },
-		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+		name:          "incident_count",
+		extract:       func(ws weekStats) float64 { return float64(ws.incidentCount) },
+		valid:         func(ws weekStats) bool { return ws.incidentsTracked },
+		csv:           "%.0f",
+		column:        func(ws weekStats) bool { return ws.incidentsTracked },
+		doc:           &incidentsDoc,
+		label:         "Incidents",
+		category:      "Quality",
+		lowerIsBetter: true,
+	},
+	{
+		name:          "median_mttr_hours",
+		extract:       func(ws weekStats) float64 { return ws.medianMTTR },
+		valid:         func(ws weekStats) bool { return ws.incidentsTracked && ws.medianMTTR >= 0 },
+		csv:           "%.2f",
+		column:        func(ws weekStats) bool { return ws.incidentsTracked },
+		doc:           &mttrDoc,
+		label:         "Median MTTR",
+		unit:          "hrs",
+		category:      "Quality",
+		lowerIsBetter: true,
+	},
+}
+
+// cycleTimeMetrics are compared like allMetrics but appended after them, so
+// the cycle-time rows come last.
+var cycleTimeMetrics = []metricDef{
+	{
+		name:          "median_coding_time_hours",
+		extract:       func(ws weekStats) float64 { return ws.medianCodingTime },
+		valid:         func(ws weekStats) bool { return ws.prsMerged > 0 && ws.medianCodingTime >= 0 },
+		doc:           &codingTimeDoc,
+		csv:           "%.2f",
+		engineer:      true,
+		label:         "Median Time Spent Coding",
+		unit:          "hrs",
+		category:      "Cycle Time",
+		lowerIsBetter: true,
+	},
+	{
+		name:          "median_review_time_hours",
+		extract:       func(ws weekStats) float64 { return ws.medianReviewTime },
+		valid:         func(ws weekStats) bool { return ws.prsMerged > 0 && ws.medianReviewTime >= 0 },
+		doc:           &reviewTimeDoc,
+		csv:           "%.2f",
+		engineer:      true,
+		label:         "Median Time Spent Reviewing",
+		unit:          "hrs",
+		category:      "Cycle Time",
+		lowerIsBetter: true,
+	},
+	{
+		name:          "median_review_response_hours",
+		extract:       func(ws weekStats) float64 { return ws.medianReviewResponse },
+		valid:         func(ws weekStats) bool { return ws.responseTracked && ws.medianReviewResponse >= 0 },
+		csv:           "%.2f",
+		engineer:      true,
+		column:        func(ws weekStats) bool { return ws.responseTracked },
+		doc:           &reviewResponseDoc,
+		label:         "Median Review Response",
+		unit:          "hrs",
+		category:      "Cycle Time",
+		lowerIsBetter: true,
+	},
+	{
+		name:          "median_ci_queue_minutes",
+		extract:       func(ws weekStats) float64 { return ws.medianCIQueue },
+		valid:         func(ws weekStats) bool { return ws.ciQueueTracked && ws.medianCIQueue >= 0 },
+		csv:           "%.2f",
+		column:        func(ws weekStats) bool { return ws.ciQueueTracked },
+		doc:           &ciQueueDoc,
+		label:         "Median CI Queue Time",
+		unit:          "min",
+		category:      "Cycle Time",
+		lowerIsBetter: true,
+	},
+}
+
+// csvOnlyMetrics are weekly CSV columns without a stats row: totals and
+// spreads that are only meaningful per week, and the optional columns'
+// companion counts. Months and sprints sum the totals, for --derived
+// expressions, and have no data for the spreads.
+var csvOnlyMetrics = slices.Concat([]metricDef{
+	{name: "total_additions", extract: func(ws weekStats) float64 { return float64(ws.additions) }, csv: "%.0f", engineer: true},
+	{name: "total_deletions", extract: func(ws weekStats) float64 { return float64(ws.deletions) }, csv: "%.0f", engineer: true},
+	{name: "total_files_changed", extract: func(ws weekStats) float64 { return float64(ws.filesChanged) }, csv: "%.0f", engineer: true},
+	{name: "p90_coding_time_hours", extract: func(ws weekStats) float64 { return ws.p90CodingTime }, csv: "%.2f", engineer: true},
+	{name: "p90_review_time_hours", extract: func(ws weekStats) float64 { return ws.p90ReviewTime }, csv: "%.2f", engineer: true},
+	{name: "median_review_turnaround_hours", extract: func(ws weekStats) float64 { return ws.medianTurnaround }, csv: "%.2f", engineer: true},
+	{name: "p90_review_turnaround_hours", extract: func(ws weekStats) float64 { return ws.p90Turnaround }, csv: "%.2f", engineer: true},
+	{name: "avg_pr_size_lines", extract: func(ws weekStats) float64 { return ws.avgPRSize }, csv: "%.2f", engineer: true},
+	{name: "revert_count", extract: func(ws weekStats) float64 { return float64(ws.revertCount) }, csv: "%.0f", engineer: true},
+	{
+		name:     "reopened_count",
+		extract:  func(ws weekStats) float64 { return float64(ws.reopenedCount) },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.reopenTracked },
 	},
 	{
-		name:    "pct_reverts",
-		extract: func(ws weekStats) float64 { return ws.pctReverts },
-		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+		name:    "automation_prs",
+		extract: func(ws weekStats) float64 { return float64(ws.automationPRs) },
+		csv:     "%.0f",
+		column:  func(ws weekStats) bool { return ws.automationTracked },
 	},
 	{
-		name:    "pct_ona_involved",
-		extract: func(ws weekStats) float64 { return ws.pctOnaInvolved },
-		valid:   func(ws weekStats) bool { return ws.prsMerged > 0 },
+		name:    "median_automation_merge_hours",
+		extract: func(ws weekStats) float64 { return ws.medianAutomationMerge },
+		csv:     "%.2f",
+		column:  func(ws weekStats) bool { return ws.automationTracked },
 	},
 	{
-		name:    "build_runs",
-		extract: func(ws weekStats) float64 { return float64(ws.buildRuns) },
-		valid:   func(ws weekStats) bool { return ws.buildRuns > 0 },
+		name:     "review_requests",
+		extract:  func(ws weekStats) float64 { return float64(ws.reviewRequests) },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.responseTracked },
 	},
 	{
-		name:    "build_success_pct",
-		extract: func(ws weekStats) float64 { return ws.buildSuccessPct },
-		valid:   func(ws weekStats) bool { return ws.buildRuns > 0 },
+		name:     "unanswered_review_requests",
+		extract:  func(ws weekStats) float64 { return float64(ws.unansweredRequests) },
+		csv:      "%.0f",
+		engineer: true,
+		column:   func(ws weekStats) bool { return ws.responseTracked },
 	},
+}, sizeBucketMetrics())
+
+// metricByName finds a metric in any of the lists above, including
+// registered user metrics.
+func metricByName(name string) (metricDef, bool) {
+	for _, md := range slices.Concat(allMetrics, cycleTimeMetrics, csvOnlyMetrics) {
+		if md.name == name {
+			return md, true
+		}
+	}
+	return metricDef{}, false
 }
 
 // --- Consolidated stats row ---
@@ -67,65 +482,75 @@ type consolidatedRow struct {
 	absChange       float64
 	pctChange       string // formatted, or "N/A"
 	window          string
+	pValue          float64 // Welch's t-test, first vs last window; -1 if untestable
+}
+
+// significanceLevel is the p-value below which a before/after change counts
+// as significant (--significance-level). Zero disables significance gating.
+var significanceLevel = 0.05
+
+// significant reports whether the row's change passes significanceLevel.
+// Rows that couldn't be tested (a window with fewer than 2 periods, or no
+// variance) are not significant.
+func (r consolidatedRow) significant() bool {
+	if significanceLevel <= 0 {
+		return true
+	}
+	return r.pValue >= 0 && r.pValue < significanceLevel
 }
 
 // --- Main entry point ---
 
 // generateStats computes before/after aggregation rows used by the HTML stat cards.
 func generateStats(allStats []weekStats, windowPct int, onaThreshold float64, periodLabel string) []consolidatedRow {
-	// Compute overall average PRs/week (across all non-zero weeks)
-	var totalPRs int
-	var nonZeroCount int
-	for _, ws := range allStats {
-		if ws.prsMerged > 0 {
-			totalPRs += ws.prsMerged
-			nonZeroCount++
-		}
-	}
-	if nonZeroCount == 0 {
-		fmt.Fprintf(os.Stderr, "WARNING: No non-empty weeks. Skipping stats.\n")
+	return generateStatsTo(os.Stderr, allStats, windowPct, onaThreshold, periodLabel)
+}
+
+// generateStatsTo is generateStats logging skipped periods and warnings to
+// w, so repeated runs (--sensitivity) can silence them.
+func generateStatsTo(w io.Writer, allStats []weekStats, windowPct int, onaThreshold float64, periodLabel string) []consolidatedRow {
+	active, threshold, avgPRs := activePeriods(allStats)
+	if avgPRs == 0 {
+		fmt.Fprintf(w, "WARNING: No non-empty weeks. Skipping stats.\n")
 		return nil
 	}
-	avgPRs := float64(totalPRs) / float64(nonZeroCount)
-	threshold := avgPRs * 0.10
 
 	// Filter out weeks below 10% of overall average PRs/week
 	var valid []weekStats
 	var excluded int
+	for _, i := range active {
+		valid = append(valid, allStats[i])
+	}
 	for _, ws := range allStats {
-		if ws.prsMerged > 0 && float64(ws.prsMerged) >= threshold {
-			valid = append(valid, ws)
-		} else if ws.prsMerged > 0 {
+		if ws.prsMerged > 0 && float64(ws.prsMerged) < threshold {
 			excluded++
 		}
 	}
 	if excluded > 0 {
-		fmt.Fprintf(os.Stderr, "Stats: excluded %d week(s) below %.0f PRs (10%% of avg %.1f)\n", excluded, threshold, avgPRs)
+		fmt.Fprintf(w, "Stats: excluded %d week(s) below %.0f PRs (10%% of avg %.1f)\n", excluded, threshold, avgPRs)
 	}
 
 	if len(valid) < 4 {
-		fmt.Fprintf(os.Stderr, "WARNING: Only %d weeks after filtering — need at least 4 for stats. Skipping.\n", len(valid))
+		fmt.Fprintf(w, "WARNING: Only %d weeks after filtering — need at least 4 for stats. Skipping.\n", len(valid))
 		return nil
 	}
 
+	if len(compareWindows) == 0 && !compareFiscalQuarters && onaThreshold <= 0 && len(valid)*windowPct/100 < minCompareWindow {
+		fmt.Fprintf(w, "Stats: comparing the first and last %d %s(s); --compare-window-pct %d covers fewer than %d\n", trendWindowSize(len(valid), windowPct), periodLabel, windowPct, minCompareWindow)
+	}
+
 	// Build metrics list including coding/review time
-	metrics := append(allMetrics,
-		metricDef{
-			name:    "median_coding_time_hours",
-			extract: func(ws weekStats) float64 { return ws.medianCodingTime },
-			valid:   func(ws weekStats) bool { return ws.prsMerged > 0 && ws.medianCodingTime >= 0 },
-		},
-		metricDef{
-			name:    "median_review_time_hours",
-			extract: func(ws weekStats) float64 { return ws.medianReviewTime },
-			valid:   func(ws weekStats) bool { return ws.prsMerged > 0 && ws.medianReviewTime >= 0 },
-		},
-	)
+	metrics := slices.Concat(allMetrics, cycleTimeMetrics)
 
-	var rows []consolidatedRow
+	// Rows are independent; with many --series or registered metrics the
+	// t-tests add up, so build them in parallel and keep the metric order.
+	built := make([]*consolidatedRow, len(metrics))
+	parallelFor(len(metrics), statsWorkers, func(i int) {
+		built[i] = buildRow(metrics[i], valid, windowPct, onaThreshold, periodLabel)
+	})
 
-	for _, md := range metrics {
-		row := buildRow(md, valid, windowPct, onaThreshold, periodLabel)
+	var rows []consolidatedRow
+	for _, row := range built {
 		if row != nil {
 			rows = append(rows, *row)
 		}
@@ -138,38 +563,106 @@ func generateStats(allStats []weekStats, windowPct int, onaThreshold float64, pe
 	return rows
 }
 
+// windowRows builds rows for metrics outside the stats table over the same
+// periods and comparison windows as generateStats, or nil when it has none.
+func windowRows(allStats []weekStats, metrics []metricDef, windowPct int, onaThreshold float64, periodLabel string) []consolidatedRow {
+	active, _, _ := activePeriods(allStats)
+	if len(active) < 4 {
+		return nil
+	}
+	var valid []weekStats
+	for _, i := range active {
+		valid = append(valid, allStats[i])
+	}
+	var rows []consolidatedRow
+	for _, md := range metrics {
+		if row := buildRow(md, valid, windowPct, onaThreshold, periodLabel); row != nil {
+			rows = append(rows, *row)
+		}
+	}
+	return rows
+}
+
+// activePeriods returns the indices of the periods the before/after
+// comparison uses: those with at least 10% of the average PR count of the
+// non-empty periods (threshold). avg is 0 if every period is empty.
+func activePeriods(stats []weekStats) (active []int, threshold, avg float64) {
+	var total, nonZero int
+	for _, ws := range stats {
+		if ws.prsMerged > 0 {
+			total += ws.prsMerged
+			nonZero++
+		}
+	}
+	if nonZero == 0 {
+		return nil, 0, 0
+	}
+	avg = float64(total) / float64(nonZero)
+	threshold = avg * 0.10
+	for i, ws := range stats {
+		if ws.prsMerged > 0 && float64(ws.prsMerged) >= threshold {
+			active = append(active, i)
+		}
+	}
+	return active, threshold, avg
+}
+
+// periodAbbrev shortens a period label for window descriptions ("13w").
+func periodAbbrev(periodLabel string) string {
+	switch periodLabel {
+	case "month":
+		return "mo"
+	case "sprint":
+		return "sp"
+	}
+	return "w"
+}
+
 // buildRow constructs one consolidated row for a metric.
 func buildRow(md metricDef, valid []weekStats, windowPct int, onaThreshold float64, periodLabel string) *consolidatedRow {
-	var firstAvg, lastAvg float64
+	var first, last []float64
 	var n, firstWinSize, lastWinSize int
 	var window string
 	var ok bool
 
-	if onaThreshold > 0 {
-		firstAvg, lastAvg, n, firstWinSize, lastWinSize, ok = thresholdWindow(valid, md, onaThreshold)
+	if len(compareWindows) > 0 {
+		first, last, n, ok = dateWindowValues(valid, md)
+		if !ok {
+			return nil
+		}
+		firstWinSize, lastWinSize = len(first), len(last)
+		abbrev := periodAbbrev(periodLabel)
+		window = fmt.Sprintf("%s (%d%s) vs %s (%d%s)", compareWindows[0], firstWinSize, abbrev, compareWindows[1], lastWinSize, abbrev)
+	} else if compareFiscalQuarters {
+		var firstKey, lastKey int
+		first, last, firstKey, lastKey, n, ok = fiscalWindow(valid, md)
 		if !ok {
 			return nil
 		}
-		abbrev := "w"
-		if periodLabel == "month" {
-			abbrev = "mo"
+		firstWinSize, lastWinSize = len(first), len(last)
+		abbrev := periodAbbrev(periodLabel)
+		window = fmt.Sprintf("%s (%d%s) vs %s (%d%s)", quarterLabel(firstKey), firstWinSize, abbrev, quarterLabel(lastKey), lastWinSize, abbrev)
+	} else if onaThreshold > 0 {
+		first, last, n, ok = thresholdWindow(valid, md, onaThreshold)
+		if !ok {
+			return nil
 		}
+		firstWinSize, lastWinSize = len(first), len(last)
+		abbrev := periodAbbrev(periodLabel)
 		window = fmt.Sprintf("below %.0f%% Ona (%d%s) vs above %.0f%% Ona (%d%s)", onaThreshold, firstWinSize, abbrev, onaThreshold, lastWinSize, abbrev)
 	} else {
-		var winSize int
-		firstAvg, lastAvg, n, winSize, ok = trendWindow(valid, md, windowPct)
+		first, last, n, ok = trendWindow(valid, md, windowPct)
 		if !ok {
 			return nil
 		}
+		winSize := len(first)
 		firstWinSize = winSize
 		lastWinSize = winSize
-		abbrev := "w"
-		if periodLabel == "month" {
-			abbrev = "mo"
-		}
+		abbrev := periodAbbrev(periodLabel)
 		window = fmt.Sprintf("first %d%s vs last %d%s avg", winSize, abbrev, winSize, abbrev)
 	}
 
+	firstAvg, lastAvg := mean(first), mean(last)
 	absChange := lastAvg - firstAvg
 	var pctChange string
 	if firstAvg != 0 {
@@ -201,13 +694,27 @@ func buildRow(md metricDef, valid []weekStats, windowPct int, onaThreshold float
 		absChange:       absChange,
 		pctChange:       pctChange,
 		window:          window,
+		pValue:          welchPValue(first, last),
 	}
 }
 
 // --- Trend windowing ---
 
-// trendWindow computes the first-N%-vs-last-N% averages for a metric.
-func trendWindow(weeks []weekStats, md metricDef, windowPct int) (float64, float64, int, int, bool) {
+// minCompareWindow is the smallest --compare-window-pct window, in periods.
+// Welch's t-test needs 2 values per side, and with the default 5% of 12
+// weeks a window would be a single week that can't be tested.
+const minCompareWindow = 3
+
+// trendWindowSize is the number of periods in each --compare-window-pct
+// window out of n: N% of them, at least minCompareWindow, and at most half
+// so the windows don't overlap.
+func trendWindowSize(n, windowPct int) int {
+	return max(min(max(n*windowPct/100, minCompareWindow), n/2), 1)
+}
+
+// trendWindow returns a metric's values in the first and last N% of valid
+// periods, and the number of valid periods.
+func trendWindow(weeks []weekStats, md metricDef, windowPct int) ([]float64, []float64, int, bool) {
 	var values []float64
 	for _, ws := range weeks {
 		if md.valid(ws) {
@@ -216,31 +723,16 @@ func trendWindow(weeks []weekStats, md metricDef, windowPct int) (float64, float
 	}
 	n := len(values)
 	if n < 2 {
-		return 0, 0, n, 0, false
-	}
-
-	windowSize := int(math.Floor(float64(n) * float64(windowPct) / 100.0))
-	if windowSize < 1 {
-		windowSize = 1
-	}
-
-	var firstSum float64
-	for i := 0; i < windowSize; i++ {
-		firstSum += values[i]
-	}
-	firstAvg := firstSum / float64(windowSize)
-
-	var lastSum float64
-	for i := n - windowSize; i < n; i++ {
-		lastSum += values[i]
+		return nil, nil, n, false
 	}
-	lastAvg := lastSum / float64(windowSize)
 
-	return firstAvg, lastAvg, n, windowSize, true
+	windowSize := trendWindowSize(n, windowPct)
+	return values[:windowSize], values[n-windowSize:], n, true
 }
 
-// thresholdWindow splits weeks by Ona usage threshold and computes averages for each group.
-func thresholdWindow(weeks []weekStats, md metricDef, threshold float64) (float64, float64, int, int, int, bool) {
+// thresholdWindow splits a metric's values by Ona usage threshold into the
+// periods below and above it.
+func thresholdWindow(weeks []weekStats, md metricDef, threshold float64) ([]float64, []float64, int, bool) {
 	var belowVals, aboveVals []float64
 	for _, ws := range weeks {
 		if !md.valid(ws) {
@@ -254,19 +746,45 @@ func thresholdWindow(weeks []weekStats, md metricDef, threshold float64) (float6
 		}
 	}
 	if len(belowVals) == 0 || len(aboveVals) == 0 {
-		return 0, 0, 0, 0, 0, false
+		return nil, nil, 0, false
+	}
+	return belowVals, aboveVals, len(belowVals) + len(aboveVals), true
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
 	}
+	return sum / float64(len(values))
+}
+
+// --- Significance ---
 
-	var belowSum float64
-	for _, v := range belowVals {
-		belowSum += v
+// welchPValue returns the two-tailed p-value of Welch's t-test for a
+// difference in means between a and b, or -1 if either sample has fewer than
+// 2 values or both have zero variance.
+func welchPValue(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return -1
 	}
-	var aboveSum float64
-	for _, v := range aboveVals {
-		aboveSum += v
+	va, vb := variance(a)/float64(len(a)), variance(b)/float64(len(b))
+	se := va + vb
+	if se == 0 {
+		return -1
 	}
+	t := (mean(b) - mean(a)) / math.Sqrt(se)
+	// Welch–Satterthwaite degrees of freedom
+	df := se * se / (va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	return studentTTwoTailed(t, df)
+}
 
-	n := len(belowVals) + len(aboveVals)
-	return belowSum / float64(len(belowVals)), aboveSum / float64(len(aboveVals)),
-		n, len(belowVals), len(aboveVals), true
+// variance returns the sample variance (n-1 denominator).
+func variance(values []float64) float64 {
+	m := mean(values)
+	var ss float64
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	return ss / float64(len(values)-1)
 }
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Storage persists weekly metrics and per-PR rows across runs (--storage),
+// so scheduled runs and several API servers can share one history. Unlike
+// the --store snapshot, which each run overwrites, weeks and PRs accumulate:
+// a run upserts its weeks, keyed by repository and week start, and its PRs,
+// keyed by repository, number, merge time, and author. repo is owner/repo.
+type Storage interface {
+	SaveWeeks(repo string, weeks []snapshotWeek) error
+	SavePRs(repo string, prs []storedPR) error
+	// LoadRange returns the stored weeks starting between from and to
+	// (inclusive; a zero time leaves that end open), oldest first.
+	LoadRange(repo string, from, to time.Time) ([]snapshotWeek, error)
+}
+
+// runRecorder is implemented by the SQL storages, which also keep a row per
+// run and each contributor's latest before/after rates for dashboards.
+type runRecorder interface {
+	SaveRun(run storedRun, contributors []snapshotContributor) error
+}
+
+// storedRun is one run's row in the runs table.
+type storedRun struct {
+	Repo          string
+	GeneratedAt   time.Time
+	Branch        string
+	Granularity   string
+	Weeks         int
+	FirstWeek     string // YYYY-MM-DD
+	LastWeek      string
+	PRsMerged     int
+	SchemaVersion int
+}
+
+// buildStoredRun summarizes a run's snapshot for the runs table.
+func buildStoredRun(snap runSnapshot, prsMerged int) storedRun {
+	run := storedRun{
+		Repo:          snap.Owner + "/" + snap.Repo,
+		GeneratedAt:   snap.GeneratedAt,
+		Branch:        snap.Branch,
+		Granularity:   snap.Granularity,
+		Weeks:         len(snap.Weeks),
+		PRsMerged:     prsMerged,
+		SchemaVersion: max(snap.SchemaVersion, 1),
+	}
+	if len(snap.Weeks) > 0 {
+		run.FirstWeek = snap.Weeks[0].WeekStart
+		run.LastWeek = snap.Weeks[len(snap.Weeks)-1].WeekStart
+	}
+	return run
+}
+
+// storedPR is the persisted form of a merged PR: the --pr-output columns
+// without the title and the review details. Cycle times are null when
+// unknown.
+type storedPR struct {
+	Number          int       `json:"number"` // 0 for --local-git commits and with --anonymize
+	Author          string    `json:"author"`
+	MergedAt        time.Time `json:"merged_at"`
+	CodingTimeHours *float64  `json:"coding_time_hours"`
+	ReviewTimeHours *float64  `json:"review_time_hours"`
+	Additions       int       `json:"additions"`
+	Deletions       int       `json:"deletions"`
+	ChangedFiles    int       `json:"changed_files"`
+	OnaInvolved     bool      `json:"ona_involved"`
+	IsRevert        bool      `json:"is_revert"`
+}
+
+// key identifies the PR within its repository.
+func (p storedPR) key() string {
+	return fmt.Sprintf("%d\x00%s\x00%s", p.Number, p.MergedAt.UTC().Format(time.RFC3339), p.Author)
+}
+
+// storedPRs converts the run's PRs. With redact (--anonymize) numbers are
+// left out, as in --pr-output.
+func storedPRs(prs []enrichedPR, redact bool) []storedPR {
+	hours := func(v float64) *float64 {
+		if v < 0 {
+			return nil
+		}
+		return &v
+	}
+	out := make([]storedPR, 0, len(prs))
+	for _, pr := range prs {
+		p := storedPR{
+			Number:          pr.number,
+			Author:          pr.authorLogin,
+			MergedAt:        time.Unix(pr.mergedEpoch, 0).UTC(),
+			CodingTimeHours: hours(pr.codingTimeHours),
+			ReviewTimeHours: hours(pr.reviewTimeHours),
+			Additions:       pr.additions,
+			Deletions:       pr.deletions,
+			ChangedFiles:    pr.changedFiles,
+			OnaInvolved:     pr.onaInvolved,
+			IsRevert:        pr.isRevert,
+		}
+		if redact {
+			p.Number = 0
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// openStorage parses a --storage value:
+//
+//	fs:DIR         JSON Lines files under DIR (a bare directory works too)
+//	sqlite:FILE    an SQLite database, through the sqlite3 CLI
+//	postgres       a Postgres database named by $THROUGHPUT_DATABASE_URL
+//	               (or $DATABASE_URL), through psql
+func openStorage(spec string) (Storage, error) {
+	switch {
+	case spec == "":
+		return nil, errors.New("empty storage")
+	case spec == "postgres":
+		dsn := os.Getenv("THROUGHPUT_DATABASE_URL")
+		if dsn == "" {
+			dsn = os.Getenv("DATABASE_URL")
+		}
+		return newPostgresStorage(dsn)
+	case strings.HasPrefix(spec, "sqlite:"):
+		return newSQLiteStorage(strings.TrimPrefix(spec, "sqlite:"))
+	default:
+		dir := strings.TrimPrefix(spec, "fs:")
+		if dir == "" {
+			return nil, errors.New("fs: needs a directory")
+		}
+		return fsStorage{dir: dir}, nil
+	}
+}
+
+// fsStorage keeps each repository's weeks and PRs as JSON Lines next to its
+// --store snapshot, if the directories are the same:
+//
+//	<dir>/<owner>/<repo>.weeks.jsonl
+//	<dir>/<owner>/<repo>.prs.jsonl
+//
+// Saves rewrite the file atomically, so readers never see half a file, but
+// two runs saving the same repository at once may lose one's rows.
+type fsStorage struct {
+	dir string
+}
+
+func (s fsStorage) path(repo, suffix string) (string, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	path, err := snapshotPath(s.dir, owner, strings.ReplaceAll(name, "/", "__"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".json") + suffix, nil
+}
+
+func (s fsStorage) SaveWeeks(repo string, weeks []snapshotWeek) error {
+	path, err := s.path(repo, ".weeks.jsonl")
+	if err != nil {
+		return err
+	}
+	return mergeJSONLines(path, weeks, func(w snapshotWeek) string { return w.WeekStart })
+}
+
+func (s fsStorage) SavePRs(repo string, prs []storedPR) error {
+	path, err := s.path(repo, ".prs.jsonl")
+	if err != nil {
+		return err
+	}
+	return mergeJSONLines(path, prs, storedPR.key)
+}
+
+func (s fsStorage) LoadRange(repo string, from, to time.Time) ([]snapshotWeek, error) {
+	path, err := s.path(repo, ".weeks.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	weeks, err := readJSONLines[snapshotWeek](path)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(weeks, func(w snapshotWeek) bool { return !inRange(w.WeekStart, from, to) }), nil
+}
+
+// inRange reports whether the YYYY-MM-DD date lies within from and to.
+func inRange(date string, from, to time.Time) bool {
+	return (from.IsZero() || date >= from.Format("2006-01-02")) &&
+		(to.IsZero() || date <= to.Format("2006-01-02"))
+}
+
+// mergeJSONLines adds rows to the JSON Lines file at path, replacing stored
+// rows with the same key, and rewrites it sorted by key.
+func mergeJSONLines[T any](path string, rows []T, key func(T) string) error {
+	stored, err := readJSONLines[T](path)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]T, len(stored)+len(rows))
+	for _, r := range slices.Concat(stored, rows) {
+		byKey[key(r)] = r
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, k := range keys {
+		if err := enc.Encode(byKey[k]); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readJSONLines reads a JSON Lines file; a missing file has no rows.
+func readJSONLines[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []T
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var r T
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rows = append(rows, r)
+	}
+	return rows, sc.Err()
+}
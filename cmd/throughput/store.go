@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The result store is a directory of JSON snapshots, one per repository:
+//
+//	<store>/<owner>/<repo>.json
+//
+// Each run with --store overwrites its repository's snapshot, so the store
+// always holds the latest results. The API server (throughput server) reads
+// snapshots on every request and needs no other state.
+
+// runSnapshot is the persisted result of one run. Field names are the public
+// API contract for /api/v1; add fields rather than renaming them.
+type runSnapshot struct {
+	SchemaVersion int                   `json:"schema_version,omitempty"` // see schemaVersion; snapshots without one are version 1
+	Owner         string                `json:"owner"`
+	Repo          string                `json:"repo"`
+	Branch        string                `json:"branch"`
+	GeneratedAt   time.Time             `json:"generated_at"`
+	Granularity   string                `json:"granularity"`
+	Weeks         []snapshotWeek        `json:"weeks"`
+	Stats         []snapshotStat        `json:"stats"`
+	Contributors  []snapshotContributor `json:"contributors"`
+}
+
+// snapshotWeek mirrors a CSV row. Metrics without data are null.
+type snapshotWeek struct {
+	WeekStart             string             `json:"week_start"`
+	WeekEnd               string             `json:"week_end"`
+	Suppressed            bool               `json:"suppressed,omitempty"`
+	Incomplete            bool               `json:"incomplete,omitempty"` // the week's fetch failed or was cut off
+	PRsMerged             int                `json:"prs_merged"`
+	UniqueAuthors         int                `json:"unique_authors"`
+	PRsPerEngineer        float64            `json:"prs_per_engineer"`
+	MedianCodingTimeHours *float64           `json:"median_coding_time_hours"`
+	MedianReviewTimeHours *float64           `json:"median_review_time_hours"`
+	PctOnaInvolved        float64            `json:"pct_ona_involved"`
+	PctReverts            float64            `json:"pct_reverts"`
+	BuildRuns             int                `json:"build_runs"`
+	ActiveEngineers4w     *int               `json:"active_engineers_4w,omitempty"`
+	ChurnedEngineers      *int               `json:"churned_engineers,omitempty"`
+	IncidentCount         *int               `json:"incident_count,omitempty"`
+	MedianMTTRHours       *float64           `json:"median_mttr_hours,omitempty"`
+	UserMetrics           map[string]float64 `json:"user_metrics,omitempty"`
+}
+
+// snapshotStat is one before/after comparison row.
+type snapshotStat struct {
+	Metric    string   `json:"metric"`
+	Window    string   `json:"window"`
+	FirstAvg  float64  `json:"first_avg"`
+	LastAvg   float64  `json:"last_avg"`
+	AbsChange float64  `json:"abs_change"`
+	PctChange string   `json:"pct_change"`
+	PValue    *float64 `json:"p_value"` // Welch's t-test; null if untestable
+}
+
+type snapshotContributor struct {
+	Login      string  `json:"login"`
+	TotalPRs   int     `json:"total_prs"`
+	BeforeRate float64 `json:"before_rate"`
+	AfterRate  float64 `json:"after_rate"`
+	HasOnaPRs  bool    `json:"has_ona_prs"`
+	OnaPct     float64 `json:"ona_pct"`
+	Excluded   bool    `json:"excluded,omitempty"` // cut by --exclude-bottom-contributor-pct
+}
+
+// buildSnapshot converts a run's weekly stats, comparison rows, and
+// contributors into the persisted form.
+func buildSnapshot(cfg config, granularity string, weeks []weekRange, stats []weekStats, rows []consolidatedRow, contributors []contributorStat) runSnapshot {
+	snap := runSnapshot{
+		Owner:       cfg.owner,
+		Repo:        cfg.repo,
+		Branch:      cfg.branch,
+		GeneratedAt: time.Now().UTC(),
+		Granularity: granularity,
+	}
+	optional := func(v float64) *float64 {
+		if v < 0 {
+			return nil
+		}
+		return &v
+	}
+	for i, wr := range weeks {
+		s := stats[i]
+		w := snapshotWeek{
+			WeekStart:             wr.start.Format("2006-01-02"),
+			WeekEnd:               wr.end.Format("2006-01-02"),
+			PRsMerged:             s.prsMerged,
+			UniqueAuthors:         s.uniqueAuthors,
+			PRsPerEngineer:        s.prsPerEngineer,
+			MedianCodingTimeHours: optional(s.medianCodingTime),
+			MedianReviewTimeHours: optional(s.medianReviewTime),
+			PctOnaInvolved:        s.pctOnaInvolved,
+			PctReverts:            s.pctReverts,
+			BuildRuns:             s.buildRuns,
+			Suppressed:            s.suppressed,
+			Incomplete:            s.incomplete,
+		}
+		if s.retentionTracked {
+			if s.activeEngineers4w >= 0 {
+				active := s.activeEngineers4w
+				w.ActiveEngineers4w = &active
+			}
+			if s.churnedEngineers >= 0 {
+				churned := s.churnedEngineers
+				w.ChurnedEngineers = &churned
+			}
+		}
+		if s.incidentsTracked {
+			count := s.incidentCount
+			w.IncidentCount = &count
+			w.MedianMTTRHours = optional(s.medianMTTR)
+		}
+		for name, v := range s.external {
+			if math.IsNaN(v) {
+				continue
+			}
+			if w.UserMetrics == nil {
+				w.UserMetrics = make(map[string]float64)
+			}
+			w.UserMetrics[name] = v
+		}
+		snap.Weeks = append(snap.Weeks, w)
+	}
+	snap.Stats = snapshotStats(rows)
+	for _, c := range contributors {
+		snap.Contributors = append(snap.Contributors, snapshotContributor{
+			Login:      c.login,
+			TotalPRs:   c.totalPRs,
+			BeforeRate: c.beforeRate,
+			AfterRate:  c.afterRate,
+			HasOnaPRs:  c.hasOnaPRs,
+			OnaPct:     c.onaPct,
+			Excluded:   c.excluded,
+		})
+	}
+	return snap
+}
+
+// snapshotStats converts comparison rows into their persisted form. The
+// result is never nil.
+func snapshotStats(rows []consolidatedRow) []snapshotStat {
+	stats := []snapshotStat{}
+	for _, r := range rows {
+		st := snapshotStat{
+			Metric:    r.metric,
+			Window:    r.window,
+			FirstAvg:  r.firstAvg,
+			LastAvg:   r.lastAvg,
+			AbsChange: r.absChange,
+			PctChange: r.pctChange,
+		}
+		if r.pValue >= 0 {
+			p := r.pValue
+			st.PValue = &p
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+func snapshotPath(dir, owner, repo string) (string, error) {
+	// Owner and repo come from URLs in server mode; refuse anything that
+	// could escape the store directory.
+	for _, part := range []string{owner, repo} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid repository name %q", owner+"/"+repo)
+		}
+	}
+	return filepath.Join(dir, owner, repo+".json"), nil
+}
+
+// saveSnapshot writes a snapshot atomically (temp file + rename) so the API
+// server never reads a half-written file.
+func saveSnapshot(dir string, snap runSnapshot) error {
+	path, err := snapshotPath(dir, snap.Owner, strings.ReplaceAll(snap.Repo, "/", "__"))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadSnapshot reads the stored snapshot for owner/repo.
+func loadSnapshot(dir, owner, repo string) (runSnapshot, error) {
+	var snap runSnapshot
+	path, err := snapshotPath(dir, owner, repo)
+	if err != nil {
+		return snap, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	err = json.Unmarshal(data, &snap)
+	if snap.SchemaVersion == 0 {
+		snap.SchemaVersion = 1
+	}
+	return snap, err
+}
+
+// listSnapshots returns owner/repo for every snapshot in the store, sorted.
+func listSnapshots(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, m := range matches {
+		owner := filepath.Base(filepath.Dir(m))
+		repos = append(repos, owner+"/"+strings.TrimSuffix(filepath.Base(m), ".json"))
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// targetDef is one --targets goal, e.g. median_review_time_hours<24.
+type targetDef struct {
+	metric string
+	op     string // "<", "<=", ">", ">="
+	value  float64
+}
+
+// targetOps lists comparison operators, two-character ones first so "<="
+// isn't parsed as "<" followed by "=24".
+var targetOps = []string{"<=", ">=", "<", ">"}
+
+// parseTargets parses a comma-separated --targets list. Values may carry an
+// "h" or "%" suffix for readability ("<24h", ">=50%"); it is ignored.
+func parseTargets(s string) ([]targetDef, error) {
+	known := make(map[string]bool)
+	for _, md := range slices.Concat(allMetrics, cycleTimeMetrics) {
+		known[md.name] = true
+	}
+	var targets []targetDef
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		var t targetDef
+		for _, op := range targetOps {
+			if metric, value, ok := strings.Cut(spec, op); ok {
+				t.metric, t.op = strings.TrimSpace(metric), op
+				value = strings.TrimSpace(value)
+				value = strings.TrimSuffix(strings.TrimSuffix(value, "h"), "%")
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%q: invalid value", spec)
+				}
+				t.value = v
+				break
+			}
+		}
+		if t.op == "" {
+			return nil, fmt.Errorf("%q: expected metric<value, metric<=value, metric>value, or metric>=value", spec)
+		}
+		if !known[t.metric] {
+			return nil, fmt.Errorf("%q: unknown metric %q", spec, t.metric)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func (t targetDef) met(v float64) bool {
+	switch t.op {
+	case "<":
+		return v < t.value
+	case "<=":
+		return v <= t.value
+	case ">":
+		return v > t.value
+	default:
+		return v >= t.value
+	}
+}
+
+func (t targetDef) String() string {
+	return fmt.Sprintf("%s %s %g", t.metric, t.op, t.value)
+}
+
+// targetResult is a target evaluated against the run's data. The current
+// value is the metric's last-window average, the same number the summary
+// banner shows; periodsMet counts the individual periods meeting the target.
+type targetResult struct {
+	target     targetDef
+	current    float64
+	hasCurrent bool // false when the metric has no comparison row
+	met        bool
+	periodsMet int
+	periods    int // periods with data for the metric
+}
+
+// evaluateTargets checks each target against the summary rows and the
+// per-period stats.
+func evaluateTargets(targets []targetDef, stats []weekStats, rows []consolidatedRow) []targetResult {
+	defs := slices.Concat(allMetrics, cycleTimeMetrics)
+	var results []targetResult
+	for _, t := range targets {
+		res := targetResult{target: t}
+		for _, r := range rows {
+			if r.metric == t.metric {
+				res.current, res.hasCurrent = r.lastAvg, true
+				res.met = t.met(r.lastAvg)
+				break
+			}
+		}
+		for _, md := range metricsByName(defs, t.metric) {
+			for _, ws := range stats {
+				if !md.valid(ws) {
+					continue
+				}
+				res.periods++
+				if t.met(md.extract(ws)) {
+					res.periodsMet++
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// logTargets prints pass/fail for each target to stderr.
+func logTargets(results []targetResult) {
+	for _, r := range results {
+		status := "no data"
+		if r.hasCurrent {
+			status = fmt.Sprintf("%.2f — FAIL", r.current)
+			if r.met {
+				status = fmt.Sprintf("%.2f — PASS", r.current)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s (met in %d/%d periods)\n", r.target, status, r.periodsMet, r.periods)
+	}
+}
+
+// targetAxes maps metrics drawn on the HTML chart to their y-axis, so goal
+// lines share the metric's scale. Targets for other metrics appear only in
+// the goals table.
+var targetAxes = map[string]string{
+	"prs_per_engineer":             "yPPE",
+	"size_points_per_engineer":     "ySize",
+	"pct_ona_lines":                "yPct",
+	"pct_ona_involved":             "yPct",
+	"pct_reverts":                  "yPct",
+	"pct_described":                "yPct",
+	"pct_linked_issue":             "yPct",
+	"pct_with_tests":               "yPct",
+	"median_coding_time_hours":     "yHrs",
+	"median_review_time_hours":     "yHrs",
+	"median_mttr_hours":            "yHrs",
+	"median_review_response_hours": "yHrs",
+	"prs_merged":                   "yCount",
+	"build_runs":                   "yBuilds",
+	"incident_count":               "yIncidents",
+	"active_engineers_4w":          "yEngineers",
+	"churned_engineers":            "yEngineers",
+}
+
+// targetAxis returns the chart axis for a target's metric, or "" if the
+// metric isn't charted.
+func targetAxis(metric string) string {
+	if axis, ok := targetAxes[metric]; ok {
+		return axis
+	}
+	if i := slices.Index(userMetricNames, metric); i >= 0 {
+		return "yExt" + strconv.Itoa(i)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"reflect"
+)
+
+// reportTemplate is the HTML report template. It defaults to the embedded
+// htmlTemplate and can be replaced with --template.
+var reportTemplate = htmlTemplate
+
+// loadReportTemplate reads a custom HTML template and validates it up front so
+// a typo fails before any API calls are made. The template receives htmlData
+// (see the "Custom templates" section of the README for the field reference).
+func loadReportTemplate(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("chart").Funcs(reportFuncs()).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	// Execute against a fully populated sample so every field reference is
+	// resolved: unknown fields fail here instead of after a multi-minute fetch.
+	if err := tmpl.Execute(io.Discard, sampleHTMLData()); err != nil {
+		return fmt.Errorf("validate %s: %w", path, err)
+	}
+	reportTemplate = string(content)
+	return nil
+}
+
+// sampleHTMLData returns htmlData with every slice holding one element, every
+// bool true, and every string non-empty, so validation walks all branches of
+// {{if}} and {{range}} blocks.
+func sampleHTMLData() htmlData {
+	var d htmlData
+	fillSample(reflect.ValueOf(&d).Elem())
+	return d
+}
+
+func fillSample(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fillSample(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), 1, 1)
+		fillSample(s.Index(0))
+		v.Set(s)
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		fillSample(p.Elem())
+		v.Set(p)
+	case reflect.String:
+		v.SetString("sample")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Float64:
+		v.SetFloat(1)
+	}
+}
+
+// reportFuncs returns the functions available to report templates:
+//
+//	{{t "English text"}}  translates a UI string for the active --locale
+func reportFuncs() template.FuncMap {
+	return template.FuncMap{
+		"t": activeLocale.T,
+	}
+}
+
+// renderReportTemplate executes the active report template.
+func renderReportTemplate(data htmlData) (string, error) {
+	tmpl, err := template.New("chart").Option("missingkey=error").Funcs(reportFuncs()).Parse(reportTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -2,43 +2,97 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"time"
 )
 
-// resolveToken tries GH_TOKEN, GITHUB_TOKEN, then git credential helper.
+// tokenSources are where resolveToken looks for a GitHub token, in order.
+var tokenSources = []struct {
+	name   string
+	desc   string // for the "no token found" message
+	lookup func() string
+}{
+	{"env", "GH_TOKEN, GITHUB_TOKEN", tokenFromEnv},
+	{"credential-helper", "git credential helper", tokenFromCredentialHelper},
+	{"netrc", "~/.netrc", tokenFromNetrc},
+	{"keychain", "OS keychain", tokenFromKeychain},
+}
+
+// tokenSource is --token-source: "auto" for every source in order, or the
+// name of the one source to read.
+var tokenSource = "auto"
+
+// tokenSourceNames lists the --token-source values.
+func tokenSourceNames() []string {
+	names := []string{"auto"}
+	for _, s := range tokenSources {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// triedTokenSources describes the sources resolveToken reads under the
+// current --token-source.
+func triedTokenSources() string {
+	var tried []string
+	for _, s := range tokenSources {
+		if tokenSource == "auto" || tokenSource == s.name {
+			tried = append(tried, s.desc)
+		}
+	}
+	return strings.Join(tried, ", ")
+}
+
+// resolveToken tries GH_TOKEN, GITHUB_TOKEN, the git credential helper,
+// ~/.netrc, then the OS keychain, or only the source --token-source names.
 func resolveToken() string {
-	if t := os.Getenv("GH_TOKEN"); t != "" {
-		return t
+	for _, s := range tokenSources {
+		if tokenSource != "auto" && tokenSource != s.name {
+			continue
+		}
+		if t := s.lookup(); t != "" {
+			return t
+		}
 	}
-	if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+	return ""
+}
+
+func tokenFromEnv() string {
+	if t := os.Getenv("GH_TOKEN"); t != "" {
 		return t
 	}
-	return tokenFromCredentialHelper()
+	return os.Getenv("GITHUB_TOKEN")
 }
 
+// catHelperRe matches the helper Gitpod configures, a shell function that
+// prints a credential file: !f() { cat '/path/to/file'; }; f. The path may
+// be single- or double-quoted and is a Windows path on Windows.
+var catHelperRe = regexp.MustCompile(`^!f\(\)\s*\{\s*cat\s+(?:'([^']+)'|"([^"]+)")\s*;\s*\};\s*f$`)
+
+// tokenFromCredentialHelper reads the github.com password from git's
+// credential helpers. A cat-file helper is read directly, without a shell;
+// anything else (manager and wincred on Windows, osxkeychain, store, cache)
+// is asked through git credential fill.
 func tokenFromCredentialHelper() string {
-	// Get the credential helper config for github.com
-	out, err := exec.Command("git", "config", "--get", "credential.github.com.helper").Output()
+	out, err := exec.Command("git", "config", "--get", "credential.https://github.com.helper").Output()
 	if err != nil {
-		return ""
+		out, _ = exec.Command("git", "config", "--get", "credential.github.com.helper").Output()
 	}
-	helper := strings.TrimSpace(string(out))
-	if helper == "" {
-		return ""
-	}
-
-	// Parse the helper path: strip !f() { cat '...' ; }; f wrapper
-	credFile := helper
-	credFile = strings.TrimPrefix(credFile, "!f() { cat '")
-	credFile = strings.TrimSuffix(credFile, "'; }; f")
-
-	if credFile == "" {
-		return ""
+	if m := catHelperRe.FindStringSubmatch(strings.TrimSpace(string(out))); m != nil {
+		return credentialFilePassword(m[1] + m[2])
 	}
+	return gitCredentialFill("github.com")
+}
 
-	f, err := os.Open(credFile)
+// credentialFilePassword returns the password= line of a credential file.
+func credentialFilePassword(path string) string {
+	f, err := os.Open(filepath.FromSlash(path))
 	if err != nil {
 		return ""
 	}
@@ -46,10 +100,156 @@ func tokenFromCredentialHelper() string {
 
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := strings.TrimRight(scanner.Text(), "\r")
 		if strings.HasPrefix(line, "password=") {
 			return strings.TrimPrefix(line, "password=")
 		}
 	}
 	return ""
 }
+
+// credentialFillTimeout bounds git credential fill, which waits on the
+// helper: a GUI helper that shows a dialog despite the settings below would
+// otherwise hang the run.
+const credentialFillTimeout = 15 * time.Second
+
+// gitCredentialFill asks git's configured helpers for the password of host,
+// "" if none has one. Prompts are disabled, so git fails instead of asking
+// for a username on the terminal, through an askpass program, or in Git
+// Credential Manager's dialog.
+func gitCredentialFill(host string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialFillTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-c", "credential.interactive=false", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=", "GCM_INTERACTIVE=never")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return credentialFillPassword(string(out))
+}
+
+// credentialFillPassword returns the password of git credential fill's
+// key=value output, whose lines end in CRLF on Windows.
+func credentialFillPassword(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(strings.TrimRight(line, "\r"), "password="); ok {
+			return p
+		}
+	}
+	return ""
+}
+
+// tokenFromNetrc reads the password of the github.com (or api.github.com)
+// machine entry from $NETRC, or ~/.netrc (~/_netrc on Windows).
+func tokenFromNetrc() string {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return netrcPassword(string(data), "github.com", "api.github.com")
+}
+
+// netrcPassword returns the password of the first machine entry for one of
+// hosts. Tokens are whitespace-separated; "default" entries and macdef
+// bodies are skipped.
+func netrcPassword(data string, hosts ...string) string {
+	var lines []string
+	inMacro := false
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if f := strings.Fields(line); len(f) > 0 && f[0] == "macdef" {
+			inMacro = true
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	found := make(map[string]string)
+	machine := ""
+	fields := strings.Fields(strings.Join(lines, "\n"))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				machine = fields[i]
+			}
+		case "default":
+			machine = ""
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if _, ok := found[machine]; machine != "" && !ok {
+					found[machine] = fields[i]
+				}
+			}
+		}
+	}
+	for _, h := range hosts {
+		if p := found[h]; p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// tokenFromKeychain reads a github.com token stored by git's or gh's
+// credential store: the macOS Keychain, Windows Credential Manager, or the
+// Secret Service through libsecret's secret-tool.
+func tokenFromKeychain() string {
+	switch runtime.GOOS {
+	case "darwin":
+		// git-credential-osxkeychain stores an internet password, gh a
+		// generic one
+		for _, args := range [][]string{
+			{"find-internet-password", "-s", "github.com", "-w"},
+			{"find-generic-password", "-s", "gh:github.com", "-w"},
+		} {
+			if out, err := exec.Command("security", args...).Output(); err == nil {
+				if t := strings.TrimSpace(string(out)); t != "" {
+					return t
+				}
+			}
+		}
+	case "windows":
+		// The target of Git Credential Manager and git-credential-wincred,
+		// then gh's, which ends in the login
+		for _, target := range []string{"git:https://github.com", "gh:github.com:*"} {
+			if t := windowsCredential(target); t != "" {
+				return t
+			}
+		}
+	default:
+		// git-credential-libsecret's attributes, then gh's
+		for _, attrs := range [][]string{
+			{"protocol", "https", "server", "github.com"},
+			{"service", "gh:github.com"},
+		} {
+			if out, err := exec.Command("secret-tool", append([]string{"lookup"}, attrs...)...).Output(); err == nil {
+				if t := strings.TrimSpace(string(out)); t != "" {
+					return t
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// windowsCredential is only available on Windows.
+func windowsCredential(target string) string { return "" }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNetrcPassword(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"one line", "machine github.com login me password tok1", "tok1"},
+		{"multi-line", "machine github.com\n  login me\n  password tok1\n", "tok1"},
+		{"CRLF", "machine github.com\r\n  login me\r\n  password tok1\r\n", "tok1"},
+		{"api host", "machine api.github.com login me password tok2", "tok2"},
+		{"github.com before api host", "machine api.github.com password tok2\nmachine github.com password tok1", "tok1"},
+		{"first entry wins", "machine github.com password tok1\nmachine github.com password tok3", "tok1"},
+		{"other host", "machine gitlab.com login me password nope", ""},
+		{"default skipped", "default login me password nope", ""},
+		{"default after entry", "machine github.com password tok1\ndefault password nope", "tok1"},
+		{"password of default not github's", "machine github.com login me\ndefault password nope", ""},
+		{"comment", "# machine github.com password nope\nmachine github.com password tok1", "tok1"},
+		{"macdef body", "macdef init\nmachine github.com password nope\n\nmachine github.com password tok1", "tok1"},
+		{"missing value", "machine github.com password", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := netrcPassword(tt.data, "github.com", "api.github.com"); got != tt.want {
+				t.Errorf("netrcPassword(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialFillPassword(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"unix", "protocol=https\nhost=github.com\nusername=me\npassword=tok1\n", "tok1"},
+		{"windows", "protocol=https\r\nhost=github.com\r\nusername=me\r\npassword=tok1\r\n", "tok1"},
+		{"no trailing newline", "host=github.com\npassword=tok1", "tok1"},
+		{"equals in password", "password=a=b\n", "a=b"},
+		{"no password", "protocol=https\nhost=github.com\n", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credentialFillPassword(tt.out); got != tt.want {
+				t.Errorf("credentialFillPassword(%q) = %q, want %q", tt.out, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatHelperRe(t *testing.T) {
+	tests := []struct {
+		helper string
+		want   string // the credential file path; "" for no match
+	}{
+		{`!f() { cat '/home/me/.gitpod/credentials'; }; f`, "/home/me/.gitpod/credentials"},
+		{`!f() { cat "/home/me/creds"; }; f`, "/home/me/creds"},
+		{`!f() { cat 'C:\Users\me\creds'; }; f`, `C:\Users\me\creds`},
+		{`!f() { cat "C:/Users/me/creds"; }; f`, "C:/Users/me/creds"},
+		{`!f(){ cat '/tmp/c'; };f`, "/tmp/c"},
+		{"manager", ""},
+		{"wincred", ""},
+		{"store --file ~/.git-credentials", ""},
+		{`!f() { cat /unquoted; }; f`, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if m := catHelperRe.FindStringSubmatch(tt.helper); m != nil {
+			got = m[1] + m[2]
+		}
+		if got != tt.want {
+			t.Errorf("catHelperRe on %q = %q, want %q", tt.helper, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialFilePassword(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unix", "username=me\npassword=tok1\n", "tok1"},
+		{"windows", "username=me\r\npassword=tok1\r\n", "tok1"},
+		{"no password", "username=me\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := credentialFilePassword(filepath.ToSlash(path)); got != tt.want {
+				t.Errorf("credentialFilePassword = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := credentialFilePassword(filepath.Join(dir, "missing")); got != "" {
+		t.Errorf("credentialFilePassword of a missing file = %q, want empty", got)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package main
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredEnum = advapi32.NewProc("CredEnumerateW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// winCredential mirrors the fields of CREDENTIALW up to the secret.
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+}
+
+// windowsCredential reads the secret of a generic Credential Manager entry,
+// "" if there is none. A target ending in "*" matches by prefix, for stores
+// that append the account name, like gh's "gh:github.com:<login>".
+func windowsCredential(target string) string {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(target, "*") {
+		var count uint32
+		var creds **winCredential
+		if ok, _, _ := procCredEnum.Call(uintptr(unsafe.Pointer(name)), 0, uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&creds))); ok == 0 {
+			return ""
+		}
+		defer procCredFree.Call(uintptr(unsafe.Pointer(creds)))
+		for _, cred := range unsafe.Slice(creds, count) {
+			if secret := credentialSecret(cred); secret != "" {
+				return secret
+			}
+		}
+		return ""
+	}
+	const credTypeGeneric = 1
+	var cred *winCredential
+	if ok, _, _ := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); ok == 0 {
+		return ""
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return credentialSecret(cred)
+}
+
+// credentialSecret decodes a credential's blob.
+func credentialSecret(cred *winCredential) string {
+	if cred.CredentialBlobSize == 0 {
+		return ""
+	}
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	// Git Credential Manager stores UTF-16, gh UTF-8
+	if len(blob)%2 == 0 && blob[1] == 0 {
+		u := make([]uint16, len(blob)/2)
+		for i := range u {
+			u[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+		}
+		return syscall.UTF16ToString(u)
+	}
+	return strings.TrimRight(string(blob), "\x00")
+}
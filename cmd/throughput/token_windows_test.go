@@ -0,0 +1,39 @@
+//go:build windows
+
+package main
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+func TestCredentialSecret(t *testing.T) {
+	utf16LE := func(s string) []byte {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+		return b
+	}
+	tests := []struct {
+		name string
+		blob []byte
+		want string
+	}{
+		{"Git Credential Manager (UTF-16)", utf16LE("ghp_tok1"), "ghp_tok1"},
+		{"gh (UTF-8)", []byte("gho_tok2"), "gho_tok2"},
+		{"UTF-8 with NUL padding", []byte("tok3\x00"), "tok3"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred := &winCredential{CredentialBlobSize: uint32(len(tt.blob))}
+			if len(tt.blob) > 0 {
+				cred.CredentialBlob = &tt.blob[0]
+			}
+			if got := credentialSecret(cred); got != tt.want {
+				t.Errorf("credentialSecret = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// minTrendPeriods is the fewest periods with data a series needs for a trend
+// test. Below it the normal approximation of the Mann-Kendall statistic is
+// too rough for the p-value to mean much.
+const minTrendPeriods = 8
+
+// reportTrend is a Mann-Kendall trend test over one metric's chart periods,
+// shown as a badge next to the series' legend entry.
+type reportTrend struct {
+	Direction string  `json:"direction"` // "up", "down", or "flat" (not significant at --significance-level)
+	Tau       float64 `json:"tau"`       // Kendall's tau, -1 to 1
+	PValue    float64 `json:"pValue"`    // two-sided
+	N         int     `json:"n"`         // periods with data
+}
+
+// computeTrends runs a Mann-Kendall test on every stats metric (including
+// user-defined ones) with at least minTrendPeriods periods of data, keyed by
+// metric name. Periods without data are skipped rather than counted as zero.
+func computeTrends(stats []weekStats) map[string]reportTrend {
+	trends := make(map[string]reportTrend)
+	for _, md := range slices.Concat(allMetrics, cycleTimeMetrics) {
+		var xs []float64
+		for _, ws := range stats {
+			if md.valid(ws) {
+				xs = append(xs, md.extract(ws))
+			}
+		}
+		if len(xs) < minTrendPeriods {
+			continue
+		}
+		tau, p, ok := mannKendall(xs)
+		if !ok {
+			continue
+		}
+		t := reportTrend{Direction: "flat", Tau: tau, PValue: p, N: len(xs)}
+		if tau != 0 && (significanceLevel <= 0 || p < significanceLevel) {
+			t.Direction = "up"
+			if tau < 0 {
+				t.Direction = "down"
+			}
+		}
+		trends[md.name] = t
+	}
+	return trends
+}
+
+// mannKendall returns Kendall's tau and the two-sided p-value of the
+// Mann-Kendall test for a monotonic trend in xs, using the normal
+// approximation with the tie correction and continuity correction. ok is
+// false when every value is the same.
+func mannKendall(xs []float64) (tau, pValue float64, ok bool) {
+	n := len(xs)
+	var s float64
+	for i := 0; i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case xs[j] > xs[i]:
+				s++
+			case xs[j] < xs[i]:
+				s--
+			}
+		}
+	}
+
+	// Variance of S, less the share of tied groups.
+	sorted := slices.Clone(xs)
+	slices.Sort(sorted)
+	fn := float64(n)
+	variance := fn * (fn - 1) * (2*fn + 5)
+	for i := 0; i < n; {
+		j := i
+		for j < n && sorted[j] == sorted[i] {
+			j++
+		}
+		if t := float64(j - i); t > 1 {
+			variance -= t * (t - 1) * (2*t + 5)
+		}
+		i = j
+	}
+	variance /= 18
+	if variance <= 0 {
+		return 0, 0, false
+	}
+
+	var z float64
+	switch {
+	case s > 0:
+		z = (s - 1) / math.Sqrt(variance)
+	case s < 0:
+		z = (s + 1) / math.Sqrt(variance)
+	}
+	return s / (fn * (fn - 1) / 2), math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
@@ -0,0 +1,46 @@
+//go:build !linux
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// watchPollInterval is how often watchFile stats the file where inotify
+// isn't available.
+const watchPollInterval = time.Second
+
+// watchFile sends on the returned channel when path's modification time or
+// size changes, by polling. The channel holds at most one pending event. It
+// stops when ctx is done.
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan struct{}, 1)
+	go func() {
+		lastMod, lastSize := info.ModTime(), info.Size()
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(path)
+			if err != nil || (info.ModTime().Equal(lastMod) && info.Size() == lastSize) {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return events, nil
+}
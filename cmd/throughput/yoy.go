@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// yoyWeeks is how far back --yoy looks: 52 weeks keeps weekdays aligned.
+const yoyWeeks = 52
+
+// yoyMetrics are the metrics the --yoy chart overlays, the first shown by
+// default, labeled as in the main chart.
+var yoyMetrics = []struct{ name, label string }{
+	{"prs_per_engineer", "PRs per Engineer"},
+	{"prs_merged", "PRs Merged"},
+	{"pct_ona_involved", "% Ona Involved"},
+	{"median_coding_time_hours", "Time Spent Coding (hrs)"},
+	{"median_review_time_hours", "Time Spent Reviewing (hrs)"},
+	{"pct_reverts", "% Reverts"},
+}
+
+// yoyRanges returns the weeks 52 weeks before weeks, so each lines up with
+// the same weekday-aligned week a year earlier.
+func yoyRanges(weeks []weekRange) []weekRange {
+	prior := make([]weekRange, len(weeks))
+	for i, wr := range weeks {
+		prior[i] = weekRange{start: wr.start.AddDate(0, 0, -7*yoyWeeks), end: wr.end.AddDate(0, 0, -7*yoyWeeks)}
+	}
+	return prior
+}
+
+// splitYoYPRs separates the PRs merged before start, fetched only for the
+// --yoy overlay, from the analyzed ones.
+func splitYoYPRs(prs []PR, start time.Time) (current, prior []PR) {
+	for _, pr := range prs {
+		if pr.MergedAt.Before(start) {
+			prior = append(prior, pr)
+		} else {
+			current = append(current, pr)
+		}
+	}
+	return current, prior
+}
+
+// alignYoY matches each chart period with the period a year earlier: the
+// week 52 weeks before, or with monthly periods the same calendar month.
+// Periods without one (a prior month dropped as incomplete) are nil.
+func alignYoY(periods []weekRange, priorPeriods []weekRange, priorStats []weekStats, monthly bool) []*weekStats {
+	byStart := make(map[time.Time]int, len(priorPeriods))
+	for i, p := range priorPeriods {
+		byStart[p.start] = i
+	}
+	aligned := make([]*weekStats, len(periods))
+	for i, p := range periods {
+		key := p.start.AddDate(0, 0, -7*yoyWeeks)
+		if monthly {
+			key = p.start.AddDate(-1, 0, 0)
+		}
+		if j, ok := byStart[key]; ok {
+			aligned[i] = &priorStats[j]
+		}
+	}
+	return aligned
+}
+
+// logYoY prints the median PRs per engineer of both years over the periods
+// that have a prior-year match.
+func logYoY(stats []weekStats, prior []*weekStats) {
+	var cur, prev []float64
+	for i, p := range prior {
+		if p == nil || p.prsMerged == 0 || stats[i].prsMerged == 0 {
+			continue
+		}
+		cur = append(cur, stats[i].prsPerEngineer)
+		prev = append(prev, p.prsPerEngineer)
+	}
+	if len(cur) == 0 {
+		fmt.Fprintf(os.Stderr, "Year over year: no periods with PRs in both years\n")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Year over year: median PRs/engineer %.2f this year vs %.2f a year earlier (%d period(s) with PRs in both)\n",
+		median(cur), median(prev), len(cur))
+}